@@ -124,6 +124,100 @@ func TestPairing(t *testing.T) {
 	}
 }
 
+// TestAggregatedPairing checks that challenges over slices of different files,
+// which may share the same slice index, are proved and verified as one aggregate
+func TestAggregatedPairing(t *testing.T) {
+	challengeRound := int64(100)
+	sk, pk, err := GenKeyPair()
+	if err != nil {
+		t.Fatalf("failed to generate random client keypair, err: %v", err)
+	}
+	randomU, err := RandomWithinOrder()
+	if err != nil {
+		t.Fatalf("failed to generate random U, err: %v", err)
+	}
+	randomV, err := RandomWithinOrder()
+	if err != nil {
+		t.Fatalf("failed to generate random V, err: %v", err)
+	}
+
+	// two files with two slices each, slice indices restart from 0 for every file
+	fileIndices := [][]int{{0, 1}, {0, 1}}
+	var contents [][]byte
+	var sigmas []*bls12_381_ecc.G1Affine
+	var indices, vs []*big.Int
+	var randSeed []byte
+	for _, indexList := range fileIndices {
+		for _, index := range indexList {
+			content := make([]byte, 1024)
+			if _, err := io.ReadFull(rand.Reader, content); err != nil {
+				t.Fatalf("failed to read random bytes: %v", err)
+			}
+			sigma, err := CalculateSigmaI(CalculateSigmaIParams{
+				Content: content,
+				Index:   new(big.Int).SetInt64(int64(index)),
+				RandomV: randomV,
+				RandomU: randomU,
+				Privkey: sk,
+				Round:   challengeRound,
+			})
+			if err != nil {
+				t.Fatalf("failed to calculate sigma, err: %v", err)
+			}
+			contents = append(contents, content)
+			sigmas = append(sigmas, sigma)
+		}
+		fileIdx, fileVs, r, err := GenerateChallenge(indexList, challengeRound, sk)
+		if err != nil {
+			t.Fatalf("failed to generate challenge, err: %v", err)
+		}
+		indices = append(indices, fileIdx...)
+		vs = append(vs, fileVs...)
+		randSeed = r
+	}
+
+	sigma, mu, err := Prove(ProofParams{
+		Content:       contents,
+		Indices:       indices,
+		RandomVs:      vs,
+		Sigmas:        sigmas,
+		RandThisRound: randSeed,
+	})
+	if err != nil {
+		t.Fatalf("failed to generate proof, err: %v", err)
+	}
+	verifyParam := VerifyParams{
+		Sigma:    sigma,
+		Mu:       mu,
+		RandomV:  randomV,
+		RandomU:  randomU,
+		Indices:  indices,
+		RandomVs: vs,
+		Pubkey:   pk,
+	}
+	if v, err := Verify(verifyParam); err != nil || !v {
+		t.Errorf("aggregated verification failed, err: %v", err)
+	}
+
+	// a tampered slice breaks the aggregate
+	contents[3][0] ^= 0xff
+	sigma, mu, err = Prove(ProofParams{
+		Content:       contents,
+		Indices:       indices,
+		RandomVs:      vs,
+		Sigmas:        sigmas,
+		RandThisRound: randSeed,
+	})
+	if err != nil {
+		t.Fatalf("failed to generate proof, err: %v", err)
+	}
+	verifyParam.Sigma = sigma
+	verifyParam.Mu = mu
+	if v, _ := Verify(verifyParam); v {
+		t.Errorf("verification of tampered aggregate should fail")
+	}
+}
+
 func createFiles(t *testing.T, fileNames []string) {
 	for _, fileName := range fileNames {
 		data := make([]byte, 102400)
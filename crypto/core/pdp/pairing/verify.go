@@ -22,10 +22,11 @@ import (
 
 // Verify verify the proof
 // e(sigma, g2) = e( (v1*H(v||index_1) + ... + vc*H(v||index_c)) + u*mu, pk)
+// both sides are checked in one multi-pairing as e(sigma, g2) * e(-right, pk) = 1,
+// so an aggregated proof over slices of many files costs a single pairing operation
 func Verify(param VerifyParams) (bool, error) {
-	left, err := bls12_381_ecc.Pair([]bls12_381_ecc.G1Affine{*param.Sigma}, []bls12_381_ecc.G2Affine{g2Gen})
-	if err != nil {
-		return false, err
+	if len(param.Indices) != len(param.RandomVs) || len(param.Indices) == 0 {
+		return false, fmt.Errorf("invalid challenge, %d indices and %d random numbers", len(param.Indices), len(param.RandomVs))
 	}
 
 	vh := new(bls12_381_ecc.G1Affine)
@@ -45,11 +46,8 @@ func Verify(param VerifyParams) (bool, error) {
 	}
 	umu := new(bls12_381_ecc.G1Affine).ScalarMultiplication(param.Mu, param.RandomU)
 	add := new(bls12_381_ecc.G1Affine).Add(vh, umu)
+	negAdd := new(bls12_381_ecc.G1Affine).Neg(add)
 
-	right, err := bls12_381_ecc.Pair([]bls12_381_ecc.G1Affine{*add}, []bls12_381_ecc.G2Affine{*param.Pubkey.P})
-	if err != nil {
-		return false, err
-	}
-
-	return left.Equal(&right), nil
+	return bls12_381_ecc.PairingCheck([]bls12_381_ecc.G1Affine{*param.Sigma, *negAdd},
+		[]bls12_381_ecc.G2Affine{g2Gen, *param.Pubkey.P})
 }
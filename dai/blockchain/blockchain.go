@@ -33,9 +33,12 @@ const (
 	TaskTypePredict = "predict" // prediction task
 
 	/* Define Algorithms stored in Contract */
-	AlgorithmVLine = "linear-vl"       // linear regression with multiple variables in vertical federated learning
-	AlgorithmVLog  = "logistic-vl"     // logistic regression with multiple variables in vertical federated learning
-	AlgorithmVDnn  = "dnn-paddlefl-vl" // dnn implemented using paddlefl
+	AlgorithmVLine        = "linear-vl"       // linear regression with multiple variables in vertical federated learning
+	AlgorithmVLog         = "logistic-vl"     // logistic regression with multiple variables in vertical federated learning
+	AlgorithmVDnn         = "dnn-paddlefl-vl" // dnn implemented using paddlefl
+	AlgorithmVSecureBoost = "secureboost-vl"  // gradient boosting decision trees based on SecureBoost in vertical federated learning
+	AlgorithmHLine        = "linear-hl"       // linear regression with multiple variables in horizontal federated learning
+	AlgorithmHLog         = "logistic-hl"     // logistic regression with multiple variables in horizontal federated learning
 
 	/* Define Regularization stored in Contract */
 	RegModeL1 = "l1" // L1-norm
@@ -47,9 +50,10 @@ const (
 
 // VlAlgorithmListName the mapping of vertical algorithm name and value
 var VlAlgorithmListName = map[string]pbCom.Algorithm{
-	AlgorithmVLine: pbCom.Algorithm_LINEAR_REGRESSION_VL,
-	AlgorithmVLog:  pbCom.Algorithm_LOGIC_REGRESSION_VL,
-	AlgorithmVDnn:  pbCom.Algorithm_DNN_PADDLEFL_VL,
+	AlgorithmVLine:        pbCom.Algorithm_LINEAR_REGRESSION_VL,
+	AlgorithmVLog:         pbCom.Algorithm_LOGIC_REGRESSION_VL,
+	AlgorithmVDnn:         pbCom.Algorithm_DNN_PADDLEFL_VL,
+	AlgorithmVSecureBoost: pbCom.Algorithm_SECUREBOOST_VL,
 }
 
 // VlAlgorithmListValue the mapping of vertical algorithm value and name
@@ -58,6 +62,20 @@ var VlAlgorithmListValue = map[pbCom.Algorithm]string{
 	pbCom.Algorithm_LINEAR_REGRESSION_VL: AlgorithmVLine,
 	pbCom.Algorithm_LOGIC_REGRESSION_VL:  AlgorithmVLog,
 	pbCom.Algorithm_DNN_PADDLEFL_VL:      AlgorithmVDnn,
+	pbCom.Algorithm_SECUREBOOST_VL:       AlgorithmVSecureBoost,
+}
+
+// HlAlgorithmListName the mapping of horizontal algorithm name and value
+var HlAlgorithmListName = map[string]pbCom.Algorithm{
+	AlgorithmHLine: pbCom.Algorithm_LINEAR_REGRESSION_HL,
+	AlgorithmHLog:  pbCom.Algorithm_LOGIC_REGRESSION_HL,
+}
+
+// HlAlgorithmListValue the mapping of horizontal algorithm value and name
+// key is the int value of the algorithm
+var HlAlgorithmListValue = map[pbCom.Algorithm]string{
+	pbCom.Algorithm_LINEAR_REGRESSION_HL: AlgorithmHLine,
+	pbCom.Algorithm_LOGIC_REGRESSION_HL:  AlgorithmHLog,
 }
 
 // TaskTypeListName the mapping of train task type name and value
@@ -89,9 +107,35 @@ var RegModeListValue = map[pbCom.RegMode]string{
 // FLInfo used to parse the content contained in the extra field of the file on the chain,
 // only files that can be parsed can be used for task training or prediction
 type FLInfo struct {
-	FileType  string `json:"fileType"`  // file type, only supports "csv"
-	Features  string `json:"features"`  // feature list
-	TotalRows int64  `json:"totalRows"` // total number of samples
+	FileType      string  `json:"fileType"`                // file type, only supports "csv"
+	Features      string  `json:"features"`                // feature list
+	TotalRows     int64   `json:"totalRows"`               // total number of samples
+	PrivacyBudget float64 `json:"privacyBudget,omitempty"` // total epsilon allowed to spend in training, no limit if not set
+}
+
+// PrivacyBudget records the privacy budget spent by training tasks with differential privacy on a sample file,
+// the limit is configured by the data owner in FLInfo when publishing the file
+type PrivacyBudget struct {
+	DataID       string   `json:"dataID"`
+	Limit        float64  `json:"limit"` // 0 means no limit
+	SpentEpsilon float64  `json:"spentEpsilon"`
+	SpentDelta   float64  `json:"spentDelta"`
+	TaskIDs      []string `json:"taskIDs"` // training tasks that spent the budget
+}
+
+// FileVersion used to parse the version of the sample file on the chain, publishing a file with an existing name
+// creates a new version of it, the file id identifies a version, and is recorded in the task with the version
+type FileVersion struct {
+	ID      string `json:"id"`
+	Version int64  `json:"version"`
+}
+
+// GetVersion returns version of the sample file, files published before versioning are regarded as version 1
+func (f FileVersion) GetVersion() int64 {
+	if f.Version == 0 {
+		return 1
+	}
+	return f.Version
 }
 
 // ExecutorNode has access to samples with which to train models or to predict,
@@ -14,10 +14,15 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"math"
 
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+
 	"github.com/PaddlePaddle/PaddleDTX/dai/blockchain"
+	pbCom "github.com/PaddlePaddle/PaddleDTX/dai/protos/common"
+	pbTask "github.com/PaddlePaddle/PaddleDTX/dai/protos/task"
 )
 
 const (
@@ -30,8 +35,75 @@ const (
 	prefixNodeIndex         = "index_executor_node"
 	prefixNodeNameIndex     = "index_executor_name"
 	prefixNodeListIndex     = "index_executor_node_list"
+	prefixPrivacyBudget     = "index_privacy_budget"
 )
 
+// checkFileVersion checks if the sample file on chain is the version pinned by the task,
+// tasks published before versioning have no version recorded
+func checkFileVersion(file []byte, ds *pbTask.DataForTask) error {
+	if ds.DataVersion == 0 {
+		return nil
+	}
+	var fv blockchain.FileVersion
+	if err := json.Unmarshal(file, &fv); err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to unmarshal file version")
+	}
+	if fv.GetVersion() != ds.DataVersion {
+		return errorx.New(errorx.ErrCodeParam, "bad param:taskId, version of sample file %s is %d, but task requires %d",
+			ds.DataID, fv.GetVersion(), ds.DataVersion)
+	}
+	return nil
+}
+
+// getPrivacyBudgetLimit gets the privacy budget limit the data owner configured in the extra info of the sample file
+func getPrivacyBudgetLimit(file []byte) (float64, error) {
+	var f struct {
+		Ext []byte `json:"ext"`
+	}
+	if err := json.Unmarshal(file, &f); err != nil {
+		return 0, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to unmarshal file")
+	}
+	var info blockchain.FLInfo
+	if err := json.Unmarshal(f.Ext, &info); err != nil {
+		return 0, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to unmarshal file extra info")
+	}
+	return info.PrivacyBudget, nil
+}
+
+// spendPrivacyBudget charges the privacy budget of the sample file for a training task,
+// a file with budget limit can only be trained with differential privacy, and the task is rejected
+// if the spent epsilon would exceed the limit. It returns false if nothing is spent
+func spendPrivacyBudget(budget *blockchain.PrivacyBudget, taskID string, algoParam *pbCom.TaskParams) (bool, error) {
+	dp := algoParam.GetTrainParams().GetDpParams()
+	if !dp.GetEnable() {
+		if budget.Limit > 0 {
+			return false, errorx.New(errorx.ErrCodeParam,
+				"bad param:taskId, sample file %s has a privacy budget, differential privacy is required", budget.DataID)
+		}
+		return false, nil
+	}
+	// only vertical linear and logistic regression add noise, and metrics of evaluation are released without noise
+	if algoParam.Algo != pbCom.Algorithm_LINEAR_REGRESSION_VL && algoParam.Algo != pbCom.Algorithm_LOGIC_REGRESSION_VL {
+		return false, errorx.New(errorx.ErrCodeParam, "bad param:taskId, differential privacy is not supported by algorithm %s", algoParam.Algo)
+	}
+	if algoParam.GetEvalParams().GetEnable() || algoParam.GetLivalParams().GetEnable() {
+		return false, errorx.New(errorx.ErrCodeParam, "bad param:taskId, evaluation is not supported with differential privacy")
+	}
+	if dp.Epsilon <= 0 || dp.Delta <= 0 || dp.Delta >= 1 {
+		return false, errorx.New(errorx.ErrCodeParam, "bad param:taskId, invalid epsilon[%v] or delta[%v]", dp.Epsilon, dp.Delta)
+	}
+	// tolerate rounding errors of float sums
+	if budget.Limit > 0 && budget.SpentEpsilon+dp.Epsilon > budget.Limit+1e-9 {
+		return false, errorx.New(errorx.ErrCodeParam,
+			"bad param:taskId, privacy budget of sample file %s exceeded, limit: %v, spent: %v, required: %v",
+			budget.DataID, budget.Limit, budget.SpentEpsilon, dp.Epsilon)
+	}
+	budget.SpentEpsilon += dp.Epsilon
+	budget.SpentDelta += dp.Delta
+	budget.TaskIDs = append(budget.TaskIDs, taskID)
+	return true, nil
+}
+
 // subByInt64Max return maxInt64 - N
 func subByInt64Max(n int64) int64 {
 	return math.MaxInt64 - n
@@ -98,3 +170,8 @@ func createCompositeKey(objectType string, attributes []string) string {
 	}
 	return ck
 }
+
+// packPrivacyBudgetIndex pack index for saving privacy budget spent on a sample file
+func packPrivacyBudgetIndex(dataID string) string {
+	return fmt.Sprintf("%s/%s", prefixPrivacyBudget, dataID)
+}
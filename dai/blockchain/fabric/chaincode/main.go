@@ -36,74 +36,6 @@ func main() {
 func (x *Xdata) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
 	fn, args := stub.GetFunctionAndParameters()
 	switch fn {
-	// xdb function
-	case "SetValue":
-		return x.SetValue(stub, args)
-	case "GetValue":
-		return x.GetValue(stub, args)
-	case "AddNode":
-		return x.AddNode(stub, args)
-	case "ListNodes":
-		return x.ListNodes(stub, args)
-	case "GetNode":
-		return x.GetNode(stub, args)
-	case "NodeOffline":
-		return x.NodeOffline(stub, args)
-	case "NodeOnline":
-		return x.NodeOnline(stub, args)
-	case "Heartbeat":
-		return x.Heartbeat(stub, args)
-	case "GetHeartbeatNum":
-		return x.GetHeartbeatNum(stub, args)
-	case "ListNodesExpireSlice":
-		return x.ListNodesExpireSlice(stub, args)
-	case "GetSliceMigrateRecords":
-		return x.GetSliceMigrateRecords(stub, args)
-	case "PublishFile":
-		return x.PublishFile(stub, args)
-	case "AddFileNs":
-		return x.AddFileNs(stub, args)
-	case "UpdateNsReplica":
-		return x.UpdateNsReplica(stub, args)
-	case "UpdateFilePublicSliceMeta":
-		return x.UpdateFilePublicSliceMeta(stub, args)
-	case "GetFileByName":
-		return x.GetFileByName(stub, args)
-	case "GetFileByID":
-		return x.GetFileByID(stub, args)
-	case "UpdateFileExpireTime":
-		return x.UpdateFileExpireTime(stub, args)
-	case "SliceMigrateRecord":
-		return x.SliceMigrateRecord(stub, args)
-	case "ListFiles":
-		return x.ListFiles(stub, args)
-	case "ListExpiredFiles":
-		return x.ListExpiredFiles(stub, args)
-	case "ListFileNs":
-		return x.ListFileNs(stub, args)
-	case "GetNsByName":
-		return x.GetNsByName(stub, args)
-	case "PublishFileAuthApplication":
-		return x.PublishFileAuthApplication(stub, args)
-	case "ConfirmFileAuthApplication":
-		return x.ConfirmFileAuthApplication(stub, args)
-	case "RejectFileAuthApplication":
-		return x.RejectFileAuthApplication(stub, args)
-	case "ListFileAuthApplications":
-		return x.ListFileAuthApplications(stub, args)
-	case "GetAuthApplicationByID":
-		return x.GetAuthApplicationByID(stub, args)
-	case "ListChallengeRequests":
-		return x.ListChallengeRequests(stub, args)
-	case "ChallengeRequest":
-		return x.ChallengeRequest(stub, args)
-	case "ChallengeAnswer":
-		return x.ChallengeAnswer(stub, args)
-	case "GetChallengeByID":
-		return x.GetChallengeByID(stub, args)
-	case "GetChallengeNum":
-		return x.GetChallengeNum(stub, args)
-	// dai function
 	case "RegisterExecutorNode":
 		return x.RegisterExecutorNode(stub, args)
 	case "ListExecutorNodes":
@@ -128,7 +60,10 @@ func (x *Xdata) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
 		return x.StartTask(stub, args)
 	case "FinishTask":
 		return x.FinishTask(stub, args)
+	case "GetPrivacyBudget":
+		return x.GetPrivacyBudget(stub, args)
 	default:
-		return shim.Error("Invalid invoke function name.")
+		// xdb function
+		return x.Xdata.Invoke(stub)
 	}
 }
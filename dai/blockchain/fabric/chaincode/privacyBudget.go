@@ -0,0 +1,84 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+
+	"github.com/PaddlePaddle/PaddleDTX/dai/blockchain"
+)
+
+// GetPrivacyBudget gets the privacy budget spent on a sample file
+func (x *Xdata) GetPrivacyBudget(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) < 1 {
+		return shim.Error("invalid arguments. expecting dataID")
+	}
+	budget, err := x.getPrivacyBudget(stub, args[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	s, err := json.Marshal(budget)
+	if err != nil {
+		return shim.Error(errorx.NewCode(err, errorx.ErrCodeInternal, "fail to marshal PrivacyBudget").Error())
+	}
+	return shim.Success(s)
+}
+
+// spendPrivacyBudgets charges privacy budgets of all sample files used by the training task
+func (x *Xdata) spendPrivacyBudgets(stub shim.ChaincodeStubInterface, t blockchain.FLTask) error {
+	for _, ds := range t.DataSets {
+		budget, err := x.getPrivacyBudget(stub, ds.DataID)
+		if err != nil {
+			return err
+		}
+		spent, err := spendPrivacyBudget(&budget, t.TaskID, t.AlgoParam)
+		if err != nil {
+			return err
+		}
+		if !spent {
+			continue
+		}
+		s, err := json.Marshal(budget)
+		if err != nil {
+			return errorx.NewCode(err, errorx.ErrCodeInternal, "fail to marshal PrivacyBudget")
+		}
+		if resp := x.SetValue(stub, []string{packPrivacyBudgetIndex(ds.DataID), string(s)}); resp.Status == shim.ERROR {
+			return errorx.New(errorx.ErrCodeWriteBlockchain, "fail to put index-privacyBudget on fabric: %s", resp.Message)
+		}
+	}
+	return nil
+}
+
+// getPrivacyBudget gets the privacy budget of a sample file, with the limit configured in the file
+func (x *Xdata) getPrivacyBudget(stub shim.ChaincodeStubInterface, dataID string) (budget blockchain.PrivacyBudget, err error) {
+	resp := x.GetValue(stub, []string{dataID})
+	if len(resp.Payload) == 0 {
+		return budget, errorx.New(errorx.ErrCodeNotFound, "sample file[%s] not found: %s", dataID, resp.Message)
+	}
+	// no budget spent if not found
+	if s := x.GetValue(stub, []string{packPrivacyBudgetIndex(dataID)}); len(s.Payload) > 0 {
+		if err := json.Unmarshal(s.Payload, &budget); err != nil {
+			return budget, errorx.NewCode(err, errorx.ErrCodeInternal, "fail to unmarshal PrivacyBudget")
+		}
+	}
+	budget.DataID = dataID
+	if budget.Limit, err = getPrivacyBudgetLimit(resp.Payload); err != nil {
+		return budget, err
+	}
+	return budget, nil
+}
@@ -23,6 +23,7 @@ import (
 	pb "github.com/hyperledger/fabric/protos/peer"
 
 	"github.com/PaddlePaddle/PaddleDTX/dai/blockchain"
+	pbCom "github.com/PaddlePaddle/PaddleDTX/dai/protos/common"
 	pbTask "github.com/PaddlePaddle/PaddleDTX/dai/protos/task"
 	util "github.com/PaddlePaddle/PaddleDTX/xdb/pkgs/strings"
 )
@@ -207,9 +208,14 @@ func (x *Xdata) setTaskConfirmStatus(stub shim.ChaincodeStubInterface, args []st
 	isAllConfirm := true
 	for index, ds := range t.DataSets {
 		if bytes.Equal(ds.Executor, opt.Pubkey) {
-			if resp := x.GetValue(stub, []string{ds.DataID}); len(resp.Payload) == 0 {
+			resp := x.GetValue(stub, []string{ds.DataID})
+			if len(resp.Payload) == 0 {
 				return shim.Error(errorx.New(errorx.ErrCodeParam, "bad param:taskId, dataId not exist").Error())
 			}
+			// judge sample file is the version pinned by the task
+			if err := checkFileVersion(resp.Payload, ds); err != nil {
+				return shim.Error(err.Error())
+			}
 
 			// judge task is confirmed
 			if ds.ConfirmedAt > 0 || ds.RejectedAt > 0 {
@@ -279,6 +285,12 @@ func (x *Xdata) StartTask(stub shim.ChaincodeStubInterface, args []string) pb.Re
 		return shim.Error(errorx.New(errorx.ErrCodeParam,
 			"start task error, task status is not Ready or Failed, taskId: %s, taskStatus: %s", t.TaskID, t.Status).Error())
 	}
+	// training on sample files spends their privacy budget, every start is charged
+	if t.AlgoParam.GetTaskType() == pbCom.TaskType_LEARN {
+		if err := x.spendPrivacyBudgets(stub, t); err != nil {
+			return shim.Error(err.Error())
+		}
+	}
 
 	// update task status
 	t.Status = blockchain.TaskToProcess
@@ -0,0 +1,54 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fabric
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+
+	"github.com/PaddlePaddle/PaddleDTX/dai/blockchain"
+)
+
+// GetFileVersionByID gets the version of the sample file by id from fabric
+func (f *Fabric) GetFileVersionByID(id string) (fv blockchain.FileVersion, err error) {
+	args := [][]byte{[]byte(id), []byte(strconv.FormatInt(time.Now().UnixNano(), 10))}
+	mName := "GetFileByID"
+	s, err := f.QueryContract(args, mName)
+	if err != nil {
+		return fv, err
+	}
+	if err = json.Unmarshal(s, &fv); err != nil {
+		return fv, errorx.NewCode(err, errorx.ErrCodeInternal,
+			"fail to unmarshal file version")
+	}
+	return fv, nil
+}
+
+// GetPrivacyBudget gets the privacy budget spent on the sample file by id from fabric
+func (f *Fabric) GetPrivacyBudget(id string) (budget blockchain.PrivacyBudget, err error) {
+	args := [][]byte{[]byte(id)}
+	mName := "GetPrivacyBudget"
+	s, err := f.QueryContract(args, mName)
+	if err != nil {
+		return budget, err
+	}
+	if err = json.Unmarshal(s, &budget); err != nil {
+		return budget, errorx.NewCode(err, errorx.ErrCodeInternal,
+			"fail to unmarshal privacy budget")
+	}
+	return budget, nil
+}
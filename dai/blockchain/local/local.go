@@ -0,0 +1,46 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	xdblocal "github.com/PaddlePaddle/PaddleDTX/xdb/blockchain/local"
+	xdbxchain "github.com/PaddlePaddle/PaddleDTX/xdb/blockchain/xchain"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+
+	"github.com/PaddlePaddle/PaddleDTX/dai/blockchain/xchain"
+	"github.com/PaddlePaddle/PaddleDTX/dai/blockchain/xchain/contract/core"
+	"github.com/PaddlePaddle/PaddleDTX/dai/config"
+)
+
+// Local is a single-node blockchain which runs the xchain contract in-process by the runtime of xdb,
+// states of the contract are kept in a LevelDB under the configured path.
+//  It's used in the same way as XChain, and is suitable for tests and single-node use.
+//  The LevelDB can not be opened by more than one process at the same time
+type Local struct {
+	*xchain.XChain
+}
+
+// New opens the local blockchain, the contract is initialized when it's opened the first time
+func New(conf *config.LocalChainConf) (*Local, error) {
+	if conf == nil || len(conf.Path) == 0 {
+		return nil, errorx.New(errorx.ErrCodeConfig, "missing path")
+	}
+	r, err := xdblocal.OpenRuntime(conf.Path, new(core.Xdata))
+	if err != nil {
+		return nil, err
+	}
+	return &Local{
+		XChain: &xchain.XChain{XChain: xdbxchain.XChain{Invoker: r}},
+	}, nil
+}
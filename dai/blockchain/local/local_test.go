@@ -0,0 +1,330 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/ecdsa"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/hash"
+	xdbchain "github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
+	xdblocal "github.com/PaddlePaddle/PaddleDTX/xdb/blockchain/local"
+	xdbconfig "github.com/PaddlePaddle/PaddleDTX/xdb/config"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+	util "github.com/PaddlePaddle/PaddleDTX/xdb/pkgs/strings"
+
+	"github.com/PaddlePaddle/PaddleDTX/dai/blockchain"
+	"github.com/PaddlePaddle/PaddleDTX/dai/config"
+	pbCom "github.com/PaddlePaddle/PaddleDTX/dai/protos/common"
+	pbTask "github.com/PaddlePaddle/PaddleDTX/dai/protos/task"
+)
+
+func TestLocal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chain")
+	l, err := New(&config.LocalChainConf{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sk, pk, err := ecdsa.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.GetExecutorNodeByID(pk.String()); !errorx.Is(err, errorx.ErrCodeNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+
+	opt := blockchain.AddNodeOptions{
+		Node: blockchain.ExecutorNode{
+			ID:      pk[:],
+			Name:    "executor1",
+			Address: "127.0.0.1:8184",
+			RegTime: time.Now().UnixNano(),
+		},
+	}
+	msg, err := util.GetSigMessage(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := ecdsa.Sign(sk, hash.HashUsingSha256([]byte(msg)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opt.Signature = sig[:]
+	if err := l.RegisterExecutorNode(&opt); err != nil {
+		t.Fatal(err)
+	}
+
+	// states are kept after reopening
+	if err := l.Invoker.(*xdblocal.Runtime).Close(); err != nil {
+		t.Fatal(err)
+	}
+	l, err = New(&config.LocalChainConf{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	node, err := l.GetExecutorNodeByName("executor1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(node.ID, pk[:]) {
+		t.Fatalf("unexpected node %x", node.ID)
+	}
+	nodes, err := l.ListNodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 0 {
+		t.Fatalf("unexpected storage nodes %v", nodes)
+	}
+}
+
+func TestLocalPrivacyBudget(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chain")
+	l, err := New(&config.LocalChainConf{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the data owner allows to spend epsilon 1.5 in total on the sample file
+	ext, _ := json.Marshal(blockchain.FLInfo{FileType: "csv", Features: "id,x", TotalRows: 10, PrivacyBudget: 1.5})
+	file, _ := json.Marshal(xdbchain.File{ID: "file1", Ext: ext})
+	if err := l.Invoker.(*xdblocal.Runtime).PutState([]byte("file1"), file); err != nil {
+		t.Fatal(err)
+	}
+
+	rsk, rpk, err := ecdsa.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	esk, epk, err := ecdsa.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sign := func(sk ecdsa.PrivateKey, v interface{}) []byte {
+		msg, err := util.GetSigMessage(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig, err := ecdsa.Sign(sk, hash.HashUsingSha256([]byte(msg)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sig[:]
+	}
+	startTask := func(taskID string, algo pbCom.Algorithm, dp *pbCom.DifferentialPrivacyParams) error {
+		task := &pbTask.FLTask{
+			TaskID:    taskID,
+			Requester: rpk[:],
+			AlgoParam: &pbCom.TaskParams{
+				Algo:        algo,
+				TaskType:    pbCom.TaskType_LEARN,
+				TrainParams: &pbCom.TrainParams{DpParams: dp},
+			},
+			DataSets: []*pbTask.DataForTask{{DataID: "file1", Executor: epk[:]}},
+		}
+		if err := l.PublishTask(&blockchain.PublishFLTaskOptions{FLTask: task, Signature: sign(rsk, task)}); err != nil {
+			t.Fatal(err)
+		}
+		confirm := blockchain.FLTaskConfirmOptions{Pubkey: epk[:], TaskID: taskID, CurrentTime: time.Now().UnixNano()}
+		confirm.Signature = sign(esk, confirm)
+		if err := l.ConfirmTask(&confirm); err != nil {
+			t.Fatal(err)
+		}
+		start := blockchain.StartFLTaskOptions{TaskID: taskID}
+		start.Signature = sign(rsk, start)
+		return l.StartTask(&start)
+	}
+	checkSpent := func(epsilon float64, tasks int) {
+		budget, err := l.GetPrivacyBudget("file1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if budget.Limit != 1.5 || budget.SpentEpsilon != epsilon || len(budget.TaskIDs) != tasks {
+			t.Fatalf("unexpected privacy budget %+v", budget)
+		}
+	}
+
+	// differential privacy is required
+	if err := startTask("task1", pbCom.Algorithm_LINEAR_REGRESSION_VL, nil); !errorx.Is(err, errorx.ErrCodeParam) {
+		t.Fatalf("expected param error, got %v", err)
+	}
+	checkSpent(0, 0)
+
+	dp := &pbCom.DifferentialPrivacyParams{Enable: true, Epsilon: 1, Delta: 1e-5, ClipNorm: 1, MaxRounds: 10}
+	// algorithms without noise on the training can not spend the budget
+	if err := startTask("task2", pbCom.Algorithm_SECUREBOOST_VL, dp); !errorx.Is(err, errorx.ErrCodeParam) {
+		t.Fatalf("expected param error, got %v", err)
+	}
+	checkSpent(0, 0)
+
+	if err := startTask("task3", pbCom.Algorithm_LINEAR_REGRESSION_VL, dp); err != nil {
+		t.Fatal(err)
+	}
+	checkSpent(1, 1)
+
+	// exceeding the budget is rejected, and nothing is spent
+	if err := startTask("task4", pbCom.Algorithm_LINEAR_REGRESSION_VL, dp); !errorx.Is(err, errorx.ErrCodeParam) {
+		t.Fatalf("expected param error, got %v", err)
+	}
+	checkSpent(1, 1)
+
+	dp.Epsilon = 0.5
+	if err := startTask("task5", pbCom.Algorithm_LOGIC_REGRESSION_VL, dp); err != nil {
+		t.Fatal(err)
+	}
+	checkSpent(1.5, 2)
+}
+
+func TestLocalSharedWithXdb(t *testing.T) {
+	// the runtime opened by xdb first runs the contract of dai after dai opens the same path
+	path := filepath.Join(t.TempDir(), "chain")
+	x, err := xdblocal.New(&xdbconfig.LocalChainConf{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := New(&config.LocalChainConf{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// dai opened first keeps running its contract after xdb opens the same path
+	path2 := filepath.Join(t.TempDir(), "chain")
+	l2, err := New(&config.LocalChainConf{Path: path2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	x2, err := xdblocal.New(&xdbconfig.LocalChainConf{Path: path2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, c := range []struct {
+		l *Local
+		x *xdblocal.Local
+	}{{l, x}, {l2, x2}} {
+		sk, pk, err := ecdsa.GenerateKeyPair()
+		if err != nil {
+			t.Fatal(err)
+		}
+		opt := blockchain.AddNodeOptions{
+			Node: blockchain.ExecutorNode{
+				ID:      pk[:],
+				Name:    fmt.Sprintf("executor%d", i),
+				Address: "127.0.0.1:8184",
+				RegTime: time.Now().UnixNano(),
+			},
+		}
+		msg, err := util.GetSigMessage(opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig, err := ecdsa.Sign(sk, hash.HashUsingSha256([]byte(msg)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		opt.Signature = sig[:]
+		if err := c.l.RegisterExecutorNode(&opt); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.l.GetExecutorNodeByID(pk.String()); err != nil {
+			t.Fatal(err)
+		}
+		// methods of xdb are run by the contract of dai as well
+		if _, err := c.x.ListNodes(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLocalFileVersionPinning(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chain")
+	l, err := New(&config.LocalChainConf{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// file1 is the version 2 of a sample file, and legacy is published before versioning
+	for id, version := range map[string]int{"file1": 2, "legacy": 0} {
+		file, _ := json.Marshal(xdbchain.File{ID: id, Version: version})
+		if err := l.Invoker.(*xdblocal.Runtime).PutState([]byte(id), file); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rsk, rpk, err := ecdsa.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	esk, epk, err := ecdsa.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sign := func(sk ecdsa.PrivateKey, v interface{}) []byte {
+		msg, err := util.GetSigMessage(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig, err := ecdsa.Sign(sk, hash.HashUsingSha256([]byte(msg)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sig[:]
+	}
+	confirmTask := func(taskID, dataID string, version int64) error {
+		task := &pbTask.FLTask{
+			TaskID:    taskID,
+			Requester: rpk[:],
+			AlgoParam: &pbCom.TaskParams{
+				Algo:     pbCom.Algorithm_LINEAR_REGRESSION_VL,
+				TaskType: pbCom.TaskType_LEARN,
+			},
+			DataSets: []*pbTask.DataForTask{{DataID: dataID, DataVersion: version, Executor: epk[:]}},
+		}
+		if err := l.PublishTask(&blockchain.PublishFLTaskOptions{FLTask: task, Signature: sign(rsk, task)}); err != nil {
+			t.Fatal(err)
+		}
+		confirm := blockchain.FLTaskConfirmOptions{Pubkey: epk[:], TaskID: taskID, CurrentTime: time.Now().UnixNano()}
+		confirm.Signature = sign(esk, confirm)
+		return l.ConfirmTask(&confirm)
+	}
+
+	cases := []struct {
+		dataID  string
+		version int64
+		ok      bool
+	}{
+		{"file1", 2, true},
+		{"file1", 1, false},
+		{"file1", 3, false},
+		// tasks published before versioning pin no version
+		{"file1", 0, true},
+		// the legacy file is regarded as version 1
+		{"legacy", 1, true},
+		{"legacy", 2, false},
+	}
+	for i, c := range cases {
+		err := confirmTask(fmt.Sprintf("task%d", i), c.dataID, c.version)
+		if c.ok && err != nil {
+			t.Fatalf("case %d: unexpected error %v", i, err)
+		}
+		if !c.ok && !errorx.Is(err, errorx.ErrCodeParam) {
+			t.Fatalf("case %d: expected param error, got %v", i, err)
+		}
+	}
+}
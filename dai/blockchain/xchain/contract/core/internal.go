@@ -0,0 +1,166 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+
+	"github.com/PaddlePaddle/PaddleDTX/dai/blockchain"
+	pbCom "github.com/PaddlePaddle/PaddleDTX/dai/protos/common"
+	pbTask "github.com/PaddlePaddle/PaddleDTX/dai/protos/task"
+)
+
+const (
+	prefixFlTaskIndex       = "index_fltask"
+	prefixFlTaskListIndex   = "index_fltask_list"
+	prefixREFlTaskListIndex = "index_re_fltask_list"
+	prefixNodeIndex         = "index_executor_node"
+	prefixNodeNameIndex     = "index_executor_name"
+	prefixNodeListIndex     = "index_executor_node_list"
+	prefixPrivacyBudget     = "index_privacy_budget"
+)
+
+// checkFileVersion checks if the sample file on chain is the version pinned by the task,
+// tasks published before versioning have no version recorded
+func checkFileVersion(file []byte, ds *pbTask.DataForTask) error {
+	if ds.DataVersion == 0 {
+		return nil
+	}
+	var fv blockchain.FileVersion
+	if err := json.Unmarshal(file, &fv); err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to unmarshal file version")
+	}
+	if fv.GetVersion() != ds.DataVersion {
+		return errorx.New(errorx.ErrCodeParam, "bad param:taskId, version of sample file %s is %d, but task requires %d",
+			ds.DataID, fv.GetVersion(), ds.DataVersion)
+	}
+	return nil
+}
+
+// getPrivacyBudgetLimit gets the privacy budget limit the data owner configured in the extra info of the sample file
+func getPrivacyBudgetLimit(file []byte) (float64, error) {
+	var f struct {
+		Ext []byte `json:"ext"`
+	}
+	if err := json.Unmarshal(file, &f); err != nil {
+		return 0, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to unmarshal file")
+	}
+	var info blockchain.FLInfo
+	if err := json.Unmarshal(f.Ext, &info); err != nil {
+		return 0, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to unmarshal file extra info")
+	}
+	return info.PrivacyBudget, nil
+}
+
+// spendPrivacyBudget charges the privacy budget of the sample file for a training task,
+// a file with budget limit can only be trained with differential privacy, and the task is rejected
+// if the spent epsilon would exceed the limit. It returns false if nothing is spent
+func spendPrivacyBudget(budget *blockchain.PrivacyBudget, taskID string, algoParam *pbCom.TaskParams) (bool, error) {
+	dp := algoParam.GetTrainParams().GetDpParams()
+	if !dp.GetEnable() {
+		if budget.Limit > 0 {
+			return false, errorx.New(errorx.ErrCodeParam,
+				"bad param:taskId, sample file %s has a privacy budget, differential privacy is required", budget.DataID)
+		}
+		return false, nil
+	}
+	// only vertical linear and logistic regression add noise, and metrics of evaluation are released without noise
+	if algoParam.Algo != pbCom.Algorithm_LINEAR_REGRESSION_VL && algoParam.Algo != pbCom.Algorithm_LOGIC_REGRESSION_VL {
+		return false, errorx.New(errorx.ErrCodeParam, "bad param:taskId, differential privacy is not supported by algorithm %s", algoParam.Algo)
+	}
+	if algoParam.GetEvalParams().GetEnable() || algoParam.GetLivalParams().GetEnable() {
+		return false, errorx.New(errorx.ErrCodeParam, "bad param:taskId, evaluation is not supported with differential privacy")
+	}
+	if dp.Epsilon <= 0 || dp.Delta <= 0 || dp.Delta >= 1 {
+		return false, errorx.New(errorx.ErrCodeParam, "bad param:taskId, invalid epsilon[%v] or delta[%v]", dp.Epsilon, dp.Delta)
+	}
+	// tolerate rounding errors of float sums
+	if budget.Limit > 0 && budget.SpentEpsilon+dp.Epsilon > budget.Limit+1e-9 {
+		return false, errorx.New(errorx.ErrCodeParam,
+			"bad param:taskId, privacy budget of sample file %s exceeded, limit: %v, spent: %v, required: %v",
+			budget.DataID, budget.Limit, budget.SpentEpsilon, dp.Epsilon)
+	}
+	budget.SpentEpsilon += dp.Epsilon
+	budget.SpentDelta += dp.Delta
+	budget.TaskIDs = append(budget.TaskIDs, taskID)
+	return true, nil
+}
+
+// subByInt64Max return maxInt64 - N
+func subByInt64Max(n int64) int64 {
+	return math.MaxInt64 - n
+}
+
+// packFlTaskIndex pack task index for saving task using taskID
+func packFlTaskIndex(taskID string) string {
+	return fmt.Sprintf("%s/%s", prefixFlTaskIndex, taskID)
+}
+
+// packFlTaskListIndex pack index for saving tasks for requester, in time descending order
+func packFlTaskListIndex(task blockchain.FLTask) string {
+	return fmt.Sprintf("%s/%x/%d/%s", prefixFlTaskListIndex, task.Requester, subByInt64Max(task.PublishTime), task.TaskID)
+}
+
+// packExecutorTaskListIndex pack index for saving tasks that an executor involves, in time descending order
+func packExecutorTaskListIndex(executor []byte, task blockchain.FLTask) string {
+	return fmt.Sprintf("%s/%x/%d/%s", prefixFlTaskListIndex, executor, subByInt64Max(task.PublishTime), task.TaskID)
+}
+
+// packRequesterExecutorTaskIndex pack index for saving tasks for requester and executor, in time descending order
+func packRequesterExecutorTaskIndex(executor []byte, task blockchain.FLTask) string {
+	return fmt.Sprintf("%s/%x/%x/%d/%s", prefixREFlTaskListIndex, task.Requester, executor, subByInt64Max(task.PublishTime), task.TaskID)
+}
+
+// packFlTaskFilter pack filter index with public key for searching tasks for requester or executor
+func packFlTaskFilter(rPubkey, ePubkey []byte) string {
+	// If requester and executor public key not empty
+	filter := prefixFlTaskListIndex + "/"
+	if len(rPubkey) > 0 && len(ePubkey) > 0 {
+		filter = prefixREFlTaskListIndex + "/" + fmt.Sprintf("%x/%x", rPubkey, ePubkey)
+	} else if len(rPubkey) > 0 {
+		filter += fmt.Sprintf("%x/", rPubkey)
+	} else {
+		filter += fmt.Sprintf("%x/", ePubkey)
+	}
+	return filter
+}
+
+// packNodeIndex pack index-id contract key for saving executor node
+func packNodeIndex(nodeID []byte) string {
+	return fmt.Sprintf("%s/%x", prefixNodeIndex, nodeID)
+}
+
+// packNodeStringIndex pack index-id contract key for saving executor node
+func packNodeStringIndex(nodeID string) string {
+	return fmt.Sprintf("%s/%s", prefixNodeIndex, nodeID)
+}
+
+// packNodeNameIndex pack index-name contract key for saving executor node
+func packNodeNameIndex(name string) string {
+	return fmt.Sprintf("%s/%s", prefixNodeNameIndex, name)
+}
+
+// packNodeListIndex pack filter for listing executor nodes
+func packNodeListIndex(node blockchain.ExecutorNode) string {
+	return fmt.Sprintf("%s/%d/%x", prefixNodeListIndex, subByInt64Max(node.RegTime), node.ID)
+}
+
+// packPrivacyBudgetIndex pack index for saving privacy budget spent on a sample file
+func packPrivacyBudgetIndex(dataID string) string {
+	return fmt.Sprintf("%s/%s", prefixPrivacyBudget, dataID)
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"encoding/json"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+	"github.com/xuperchain/xuperchain/core/contractsdk/go/code"
+
+	"github.com/PaddlePaddle/PaddleDTX/dai/blockchain"
+)
+
+// GetPrivacyBudget gets the privacy budget spent on a sample file
+func (x *Xdata) GetPrivacyBudget(ctx code.Context) code.Response {
+	dataID, ok := ctx.Args()["dataID"]
+	if !ok {
+		return code.Error(errorx.New(errorx.ErrCodeParam, "missing param:dataID"))
+	}
+	budget, err := x.getPrivacyBudget(ctx, string(dataID))
+	if err != nil {
+		return code.Error(err)
+	}
+	s, err := json.Marshal(budget)
+	if err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal, "fail to marshal PrivacyBudget"))
+	}
+	return code.OK(s)
+}
+
+// spendPrivacyBudgets charges privacy budgets of all sample files used by the training task
+func (x *Xdata) spendPrivacyBudgets(ctx code.Context, t blockchain.FLTask) error {
+	for _, ds := range t.DataSets {
+		budget, err := x.getPrivacyBudget(ctx, ds.DataID)
+		if err != nil {
+			return err
+		}
+		spent, err := spendPrivacyBudget(&budget, t.TaskID, t.AlgoParam)
+		if err != nil {
+			return err
+		}
+		if !spent {
+			continue
+		}
+		s, err := json.Marshal(budget)
+		if err != nil {
+			return errorx.NewCode(err, errorx.ErrCodeInternal, "fail to marshal PrivacyBudget")
+		}
+		if err := ctx.PutObject([]byte(packPrivacyBudgetIndex(ds.DataID)), s); err != nil {
+			return errorx.NewCode(err, errorx.ErrCodeWriteBlockchain, "fail to put index-privacyBudget on xchain")
+		}
+	}
+	return nil
+}
+
+// getPrivacyBudget gets the privacy budget of a sample file, with the limit configured in the file
+func (x *Xdata) getPrivacyBudget(ctx code.Context, dataID string) (budget blockchain.PrivacyBudget, err error) {
+	f, err := ctx.GetObject([]byte(dataID))
+	if err != nil {
+		return budget, errorx.NewCode(err, errorx.ErrCodeNotFound, "sample file[%s] not found", dataID)
+	}
+	// no budget spent if not found
+	if s, err := ctx.GetObject([]byte(packPrivacyBudgetIndex(dataID))); err == nil {
+		if err := json.Unmarshal(s, &budget); err != nil {
+			return budget, errorx.NewCode(err, errorx.ErrCodeInternal, "fail to unmarshal PrivacyBudget")
+		}
+	}
+	budget.DataID = dataID
+	if budget.Limit, err = getPrivacyBudgetLimit(f); err != nil {
+		return budget, err
+	}
+	return budget, nil
+}
@@ -11,7 +11,7 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-package main
+package core
 
 import (
 	"bytes"
@@ -23,6 +23,7 @@ import (
 	"github.com/xuperchain/xuperchain/core/contractsdk/go/code"
 
 	"github.com/PaddlePaddle/PaddleDTX/dai/blockchain"
+	pbCom "github.com/PaddlePaddle/PaddleDTX/dai/protos/common"
 	pbTask "github.com/PaddlePaddle/PaddleDTX/dai/protos/task"
 	util "github.com/PaddlePaddle/PaddleDTX/xdb/pkgs/strings"
 )
@@ -200,9 +201,14 @@ func (x *Xdata) setTaskConfirmStatus(ctx code.Context, isConfirm bool) code.Resp
 	for index, ds := range t.DataSets {
 		if bytes.Equal(ds.Executor, opt.Pubkey) {
 			// judge sample file exists
-			if _, err := ctx.GetObject([]byte(ds.DataID)); err != nil {
+			f, err := ctx.GetObject([]byte(ds.DataID))
+			if err != nil {
 				return code.Error(errorx.New(errorx.ErrCodeParam, "bad param:taskId, dataId not exist"))
 			}
+			// judge sample file is the version pinned by the task
+			if err := checkFileVersion(f, ds); err != nil {
+				return code.Error(err)
+			}
 			// judge task is confirmed
 			if ds.ConfirmedAt > 0 || ds.RejectedAt > 0 {
 				return code.Error(errorx.New(errorx.ErrCodeAlreadyUpdate, "bad param:taskId, task already confirmed"))
@@ -269,6 +275,12 @@ func (x *Xdata) StartTask(ctx code.Context) code.Response {
 		return code.Error(errorx.New(errorx.ErrCodeParam,
 			"start task error, task status is not Ready or Failed, taskId: %s, taskStatus: %s", t.TaskID, t.Status))
 	}
+	// training on sample files spends their privacy budget, every start is charged
+	if t.AlgoParam.GetTaskType() == pbCom.TaskType_LEARN {
+		if err := x.spendPrivacyBudgets(ctx, t); err != nil {
+			return code.Error(err)
+		}
+	}
 	// update task status
 	t.Status = blockchain.TaskToProcess
 	s, err := json.Marshal(t)
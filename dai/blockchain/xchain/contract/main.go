@@ -16,13 +16,9 @@ package main
 import (
 	"github.com/xuperchain/xuperchain/core/contractsdk/go/driver"
 
-	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain/xchain/contract/core"
+	"github.com/PaddlePaddle/PaddleDTX/dai/blockchain/xchain/contract/core"
 )
 
-type Xdata struct {
-	core.Xdata
-}
-
 func main() {
-	driver.Serve(new(Xdata))
+	driver.Serve(new(core.Xdata))
 }
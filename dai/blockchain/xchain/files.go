@@ -0,0 +1,59 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xchain
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+
+	"github.com/PaddlePaddle/PaddleDTX/dai/blockchain"
+)
+
+// GetFileVersionByID gets the version of the sample file by id from xchain
+func (x *XChain) GetFileVersionByID(id string) (fv blockchain.FileVersion, err error) {
+	args := map[string]string{
+		"id":          id,
+		"currentTime": strconv.FormatInt(time.Now().UnixNano(), 10),
+	}
+	mName := "GetFileByID"
+	s, err := x.QueryContract(args, mName)
+	if err != nil {
+		return fv, err
+	}
+	if err = json.Unmarshal(s, &fv); err != nil {
+		return fv, errorx.NewCode(err, errorx.ErrCodeInternal,
+			"fail to unmarshal file version")
+	}
+	return fv, nil
+}
+
+// GetPrivacyBudget gets the privacy budget spent on the sample file by id from xchain
+func (x *XChain) GetPrivacyBudget(id string) (budget blockchain.PrivacyBudget, err error) {
+	args := map[string]string{
+		"dataID": id,
+	}
+	mName := "GetPrivacyBudget"
+	s, err := x.QueryContract(args, mName)
+	if err != nil {
+		return budget, err
+	}
+	if err = json.Unmarshal(s, &budget); err != nil {
+		return budget, errorx.NewCode(err, errorx.ErrCodeInternal,
+			"fail to unmarshal privacy budget")
+	}
+	return budget, nil
+}
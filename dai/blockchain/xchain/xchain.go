@@ -46,6 +46,9 @@ func New(conf *config.XchainConf) (*XChain, error) {
 
 // Close closes client
 func (x *XChain) Close() {
+	if x.Invoker != nil {
+		return
+	}
 	if err := x.XChain.XchainClient.XchainConn.Close(); err != nil {
 		logger.WithError(err).Error("failed to close xchain client")
 	}
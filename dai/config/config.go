@@ -100,6 +100,7 @@ type ExecutorBlockchainConf struct {
 	Type   string
 	Xchain *XchainConf
 	Fabric *FabricConf
+	Local  *LocalChainConf
 }
 
 type XchainConf struct {
@@ -118,6 +119,11 @@ type FabricConf struct {
 	OrgName    string
 }
 
+// LocalChainConf is the configuration of the in-process blockchain, Path is where states of the contract are kept
+type LocalChainConf struct {
+	Path string
+}
+
 // Log defines the storage path of the logs generated by the executor node at runtime
 type Log struct {
 	Level string
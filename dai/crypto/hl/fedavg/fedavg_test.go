@@ -0,0 +1,250 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fedavg
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"strconv"
+	"testing"
+
+	vl_common "github.com/PaddlePaddle/PaddleDTX/dai/crypto/vl/common"
+	"github.com/PaddlePaddle/PaddleDTX/dai/crypto/vl/common/csv"
+	pb_common "github.com/PaddlePaddle/PaddleDTX/dai/protos/common"
+)
+
+// newPairMasks agrees on pairwise masks among parties, masks[i] are the ones of party i
+func newPairMasks(num int, t *testing.T) [][]*PairMask {
+	var privs []*ecdsa.PrivateKey
+	var pubs [][]byte
+	for i := 0; i < num; i++ {
+		priv, pub, err := GenerateMaskKeyPair()
+		checkErr(err, t)
+		privs = append(privs, priv)
+		pubs = append(pubs, pub)
+	}
+
+	masks := make([][]*PairMask, num)
+	for i := 0; i < num; i++ {
+		for j := 0; j < num; j++ {
+			if i == j {
+				continue
+			}
+			pm, err := NewPairMask(privs[i], pubs[i], pubs[j])
+			checkErr(err, t)
+			masks[i] = append(masks[i], pm)
+		}
+	}
+	return masks
+}
+
+// secureSum sums up vectors of parties by secure aggregation
+func secureSum(vectors [][]float64, domain string, masks [][]*PairMask, t *testing.T) []float64 {
+	var maskedVectors [][]byte
+	for i, v := range vectors {
+		mv, err := MaskVector(v, domain, masks[i], defaultAccuracy)
+		checkErr(err, t)
+		maskedVectors = append(maskedVectors, mv)
+	}
+	sum, err := AggregateVectors(maskedVectors, defaultAccuracy)
+	checkErr(err, t)
+	return sum
+}
+
+func TestSecureAggregation(t *testing.T) {
+	masks := newPairMasks(3, t)
+	vectors := [][]float64{
+		{1.5, -2.25, 1000.125, 0},
+		{-0.5, 3.75, 0.000001, -7},
+		{2, -4, -999.5, 7},
+	}
+
+	sum := secureSum(vectors, "round-0", masks, t)
+	for i := range sum {
+		expected := vectors[0][i] + vectors[1][i] + vectors[2][i]
+		if math.Abs(sum[i]-expected) > 1e-9 {
+			t.Fatalf("index %d: expected sum %v, got %v", i, expected, sum[i])
+		}
+	}
+
+	// a single masked vector reveals nothing about local values
+	mv, err := MaskVector(vectors[0], "round-0", masks[0], defaultAccuracy)
+	checkErr(err, t)
+	single, err := AggregateVectors([][]byte{mv}, defaultAccuracy)
+	checkErr(err, t)
+	for i := range single {
+		if math.Abs(single[i]-vectors[0][i]) < 1 {
+			t.Fatalf("index %d: masked value %v is too close to real value %v", i, single[i], vectors[0][i])
+		}
+	}
+
+	// masks of different domains don't cancel out
+	mv0, err := MaskVector(vectors[0], "round-0", masks[0], defaultAccuracy)
+	checkErr(err, t)
+	mv1, err := MaskVector(vectors[1], "round-1", masks[1], defaultAccuracy)
+	checkErr(err, t)
+	mv2, err := MaskVector(vectors[2], "round-1", masks[2], defaultAccuracy)
+	checkErr(err, t)
+	mixed, err := AggregateVectors([][]byte{mv0, mv1, mv2}, defaultAccuracy)
+	checkErr(err, t)
+	if math.Abs(mixed[0]-sum[0]) < 1 {
+		t.Fatalf("masks of different domains should not cancel out")
+	}
+}
+
+// trainParties trains a model with FedAvg by parties holding different samples, returns the model of first party
+func trainParties(files []string, algo pb_common.Algorithm, params pb_common.TrainParams, t *testing.T) *pb_common.TrainModels {
+	var trainSets []*TrainDataSet
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		checkErr(err, t)
+		rows, err := csv.ReadRowsFromFile(content)
+		checkErr(err, t)
+		trainSet, err := GetTrainDataSetFromFile(rows, algo, params)
+		checkErr(err, t)
+		trainSets = append(trainSets, trainSet)
+	}
+	masks := newPairMasks(len(files), t)
+
+	// global standardization
+	var stats [][]float64
+	for _, trainSet := range trainSets {
+		stats = append(stats, LocalStats(trainSet, algo))
+	}
+	globalStats := secureSum(stats, "stats", masks, t)
+	for _, trainSet := range trainSets {
+		checkErr(Standardize(trainSet, globalStats, algo, params), t)
+	}
+
+	thetas := InitThetas(trainSets[0])
+	var lastCost float64
+	for round := 0; round < 500; round++ {
+		var updates [][]float64
+		for _, trainSet := range trainSets {
+			cost := CalCost(trainSet, thetas, algo, params)
+			newThetas := LocalUpdate(trainSet, thetas, algo, params, round)
+			updates = append(updates, UpdateVector(newThetas, cost, len(trainSet.X)))
+		}
+		aggregated := secureSum(updates, fmt.Sprintf("round-%d", round), masks, t)
+
+		var cost float64
+		var err error
+		thetas, cost, err = AverageUpdate(aggregated, len(thetas))
+		checkErr(err, t)
+		if round > 0 && StopTraining(lastCost, cost, params) {
+			t.Logf("stopped at round %d, cost is %v", round, cost)
+			break
+		}
+		lastCost = cost
+	}
+
+	modelBytes, err := TrainModelsToBytes(thetas, trainSets[0], params)
+	checkErr(err, t)
+	model, err := vl_common.TrainModelsFromBytes(modelBytes)
+	checkErr(err, t)
+	return model
+}
+
+func TestFedAvgLogic(t *testing.T) {
+	params := pb_common.TrainParams{
+		Label:     "Label",
+		LabelName: "Iris-setosa",
+		IdName:    "id",
+		Alpha:     0.1,
+		Amplitude: 0.0001,
+		Accuracy:  10,
+		BatchSize: 16,
+	}
+	files := []string{
+		"../testdata/logic_iris_plants/train_dataA.csv",
+		"../testdata/logic_iris_plants/train_dataB.csv",
+	}
+	algo := pb_common.Algorithm_LOGIC_REGRESSION_HL
+	model := trainParties(files, algo, params, t)
+	if len(model.Thetas) != 5 {
+		t.Fatalf("expected 5 thetas, got %v", model.Thetas)
+	}
+
+	// every party predicts with its own samples
+	var correct, total int
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		checkErr(err, t)
+		rows, err := csv.ReadRowsFromFile(content)
+		checkErr(err, t)
+		predictions, err := Predict(rows, algo, model)
+		checkErr(err, t)
+		for i, p := range predictions {
+			isPositive := rows[i+1][len(rows[i+1])-1] == params.LabelName
+			if (p >= 0.5) == isPositive {
+				correct++
+			}
+			total++
+		}
+	}
+	accuracy := float64(correct) / float64(total)
+	t.Logf("accuracy is %v", accuracy)
+	if accuracy < 0.95 {
+		t.Fatalf("accuracy %v is too low", accuracy)
+	}
+}
+
+func TestFedAvgLinear(t *testing.T) {
+	params := pb_common.TrainParams{
+		Label:     "MEDV",
+		IdName:    "id",
+		RegMode:   pb_common.RegMode_Reg_Ridge,
+		RegParam:  0.1,
+		Alpha:     0.1,
+		Amplitude: 0.00001,
+		Accuracy:  10,
+	}
+	files := []string{
+		"../testdata/linear_boston_housing/train_dataA.csv",
+		"../testdata/linear_boston_housing/train_dataB.csv",
+	}
+	algo := pb_common.Algorithm_LINEAR_REGRESSION_HL
+	model := trainParties(files, algo, params, t)
+
+	var sse float64
+	var total int
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		checkErr(err, t)
+		rows, err := csv.ReadRowsFromFile(content)
+		checkErr(err, t)
+		predictions, err := Predict(rows, algo, model)
+		checkErr(err, t)
+		for i, p := range predictions {
+			y, err := strconv.ParseFloat(rows[i+1][len(rows[i+1])-1], 64)
+			checkErr(err, t)
+			sse += (p - y) * (p - y)
+			total++
+		}
+	}
+	rmse := math.Sqrt(sse / float64(total))
+	t.Logf("RMSE is %v", rmse)
+	if rmse > 6 {
+		t.Fatalf("RMSE %v is too high", rmse)
+	}
+}
+
+func checkErr(err error, t *testing.T) {
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+}
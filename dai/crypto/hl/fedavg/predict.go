@@ -0,0 +1,62 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fedavg
+
+import (
+	"fmt"
+	"strconv"
+
+	pb_common "github.com/PaddlePaddle/PaddleDTX/dai/protos/common"
+)
+
+// Predict calculates predict values with local samples, every party holds the whole model in horizontal learning,
+// fileRows is sample rows, first row is feature list, others are values for each sample,
+// columns not in the model such as ID are ignored
+func Predict(fileRows [][]string, algo pb_common.Algorithm, model *pb_common.TrainModels) ([]float64, error) {
+	if len(fileRows) < 1 {
+		return nil, fmt.Errorf("no feature list found in file")
+	}
+	featureIdx := make(map[string]int)
+	for i, name := range fileRows[0] {
+		featureIdx[name] = i
+	}
+	for name := range model.Thetas {
+		if _, ok := featureIdx[name]; !ok && name != interceptName {
+			return nil, fmt.Errorf("feature[%s] of model not found in file", name)
+		}
+	}
+
+	var predictValues []float64
+	for i := 1; i < len(fileRows); i++ {
+		z := model.Thetas[interceptName]
+		for name, theta := range model.Thetas {
+			if name == interceptName {
+				continue
+			}
+			value, err := strconv.ParseFloat(fileRows[i][featureIdx[name]], 64)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse value, err: %v", err)
+			}
+			z += theta * (value - model.Xbars[name]) / model.Sigmas[name]
+		}
+
+		if IsLogistic(algo) {
+			predictValues = append(predictValues, sigmoid(z))
+		} else {
+			// de-standardize output with average value and standard deviation of target feature
+			predictValues = append(predictValues, z*model.Sigmas[model.Label]+model.Xbars[model.Label])
+		}
+	}
+	return predictValues, nil
+}
@@ -0,0 +1,151 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fedavg
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// Secure aggregation with pairwise masks:
+// each pair of parties agrees on a seed by ECDH, and expands it into masks for each value.
+// A party adds the masks shared with the parties whose public keys are greater than its own,
+// and subtracts the ones shared with the others, so all masks cancel out in the sum of all parties,
+// while a single masked vector reveals nothing about local values.
+// Values are encoded in fixed point and summed up modulo 2^256.
+// Masks only hide values from who sees the masked vectors, every party still gets the sum of all parties,
+// and subtracting its own values from the sum leaves the sum of the others.
+// So at least MinParties parties are required, or else the only other party's values are revealed.
+
+// MinParties is the minimum number of parties to aggregate values securely
+const MinParties = 3
+
+// maskBits is the bit length of the modulus for masked values
+const maskBits = 256
+
+var maskModulus = new(big.Int).Lsh(big.NewInt(1), maskBits)
+
+// PairMask generates masks shared with another party
+type PairMask struct {
+	seed     []byte
+	positive bool // add masks if true, or else subtract them
+}
+
+// GenerateMaskKeyPair generates the key pair used to agree on mask seeds with other parties,
+// returns private key and marshaled public key for transfer
+func GenerateMaskKeyPair() (*ecdsa.PrivateKey, []byte, error) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	return priv, elliptic.Marshal(elliptic.P256(), priv.X, priv.Y), nil
+}
+
+// NewPairMask agrees on mask seed with other party by ECDH,
+// pub is local marshaled public key, and pubOfOther is the one received from other party
+func NewPairMask(priv *ecdsa.PrivateKey, pub, pubOfOther []byte) (*PairMask, error) {
+	curve := elliptic.P256()
+	x, y := elliptic.Unmarshal(curve, pubOfOther)
+	if x == nil {
+		return nil, fmt.Errorf("invalid public key of other party")
+	}
+	cmp := bytes.Compare(pub, pubOfOther)
+	if cmp == 0 {
+		return nil, fmt.Errorf("public key of other party is the same as local one")
+	}
+
+	sx, _ := curve.ScalarMult(x, y, priv.D.Bytes())
+	seed := sha256.Sum256(sx.Bytes())
+	return &PairMask{
+		seed:     seed[:],
+		positive: cmp < 0,
+	}, nil
+}
+
+// mask expands seed into the mask of the value at index in domain
+func (pm *PairMask) mask(domain string, index int) *big.Int {
+	h := sha256.New()
+	h.Write(pm.seed)
+	h.Write([]byte(domain))
+	idx := make([]byte, 8)
+	binary.BigEndian.PutUint64(idx, uint64(index))
+	h.Write(idx)
+	return new(big.Int).SetBytes(h.Sum(nil))
+}
+
+// MaskVector encodes values in fixed point and covers them with masks shared with other parties,
+// domain distinguishes masks used for different vectors, such as statistics or updates of each round
+func MaskVector(values []float64, domain string, masks []*PairMask, accuracy int) ([]byte, error) {
+	scale := new(big.Float).SetFloat64(math.Pow10(accuracy))
+	masked := make([][]byte, len(values))
+	for i, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("invalid value %v to be masked", v)
+		}
+		encoded, _ := new(big.Float).Mul(new(big.Float).SetFloat64(v), scale).Int(nil)
+		for _, pm := range masks {
+			if pm.positive {
+				encoded.Add(encoded, pm.mask(domain, i))
+			} else {
+				encoded.Sub(encoded, pm.mask(domain, i))
+			}
+		}
+		masked[i] = encoded.Mod(encoded, maskModulus).Bytes()
+	}
+	return json.Marshal(masked)
+}
+
+// AggregateVectors sums up masked vectors of all parties, masks cancel out and the sum of values is returned
+func AggregateVectors(maskedVectors [][]byte, accuracy int) ([]float64, error) {
+	var sums []*big.Int
+	for _, mv := range maskedVectors {
+		var masked [][]byte
+		if err := json.Unmarshal(mv, &masked); err != nil {
+			return nil, fmt.Errorf("failed to decode masked vector, err: %v", err)
+		}
+		if sums == nil {
+			sums = make([]*big.Int, len(masked))
+			for i := range sums {
+				sums[i] = new(big.Int)
+			}
+		}
+		if len(masked) != len(sums) {
+			return nil, fmt.Errorf("masked vectors have different lengths, %d and %d", len(masked), len(sums))
+		}
+		for i, m := range masked {
+			sums[i].Add(sums[i], new(big.Int).SetBytes(m))
+		}
+	}
+
+	half := new(big.Int).Rsh(maskModulus, 1)
+	scale := new(big.Float).SetFloat64(math.Pow10(accuracy))
+	values := make([]float64, len(sums))
+	for i, s := range sums {
+		s.Mod(s, maskModulus)
+		// the upper half represents negative values
+		if s.Cmp(half) >= 0 {
+			s.Sub(s, maskModulus)
+		}
+		values[i], _ = new(big.Float).Quo(new(big.Float).SetInt(s), scale).Float64()
+	}
+	return values, nil
+}
@@ -0,0 +1,362 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fedavg
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+
+	pb_common "github.com/PaddlePaddle/PaddleDTX/dai/protos/common"
+)
+
+const (
+	defaultAccuracy = 10
+
+	// interceptName is the key of intercept in model thetas
+	interceptName = "Intercept"
+)
+
+// TrainDataSet is the local train set of a party in horizontal learning,
+// all parties hold the same features and the label for different samples
+type TrainDataSet struct {
+	// FeatureNames are sorted so that every party lays out the model in the same order
+	FeatureNames []string
+	// Features are raw values of each sample, Features[sample][feature]
+	Features [][]float64
+	// Labels are raw values of target feature for linear regression, or 1 and 0 for logistic regression
+	Labels []float64
+
+	// X are standardized features with 1 at the beginning for intercept, set by Standardize
+	X [][]float64
+	// Y are standardized labels for linear regression, or 1 and 0 for logistic regression, set by Standardize
+	Y []float64
+	// Xbars and Sigmas are global averages and standard deviations of features, set by Standardize
+	Xbars, Sigmas map[string]float64
+}
+
+// IsLogistic returns true if the algorithm trains a binary classification model
+func IsLogistic(algo pb_common.Algorithm) bool {
+	return algo == pb_common.Algorithm_LOGIC_REGRESSION_HL
+}
+
+// GetAccuracy returns the number of decimal places kept when values are encoded for secure aggregation
+func GetAccuracy(params pb_common.TrainParams) int {
+	if params.Accuracy <= 0 {
+		return defaultAccuracy
+	}
+	return int(params.Accuracy)
+}
+
+// GetTrainDataSetFromFile retrieve train dataset from file,
+// fileRows is sample rows, first row is feature list, others are values for each sample,
+// the ID column is ignored, and the label is converted to 1 or 0 by LabelName for logistic regression
+func GetTrainDataSetFromFile(fileRows [][]string, algo pb_common.Algorithm, params pb_common.TrainParams) (*TrainDataSet, error) {
+	if len(fileRows) < 2 {
+		return nil, fmt.Errorf("no sample found in file")
+	}
+
+	header := fileRows[0]
+	labelIdx := -1
+	featureIdx := make(map[string]int)
+	for i, name := range header {
+		if name == params.Label {
+			labelIdx = i
+		} else if name != params.IdName {
+			featureIdx[name] = i
+		}
+	}
+	if labelIdx < 0 {
+		return nil, fmt.Errorf("label[%s] not found in file", params.Label)
+	}
+	if len(featureIdx) == 0 {
+		return nil, fmt.Errorf("no feature found in file")
+	}
+
+	var featureNames []string
+	for name := range featureIdx {
+		featureNames = append(featureNames, name)
+	}
+	sort.Strings(featureNames)
+
+	dataSet := &TrainDataSet{FeatureNames: featureNames}
+	for i := 1; i < len(fileRows); i++ {
+		row := fileRows[i]
+		if len(row) != len(header) {
+			return nil, fmt.Errorf("invalid sample in row %d", i)
+		}
+		values := make([]float64, len(featureNames))
+		for j, name := range featureNames {
+			value, err := strconv.ParseFloat(row[featureIdx[name]], 64)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse value, err: %v", err)
+			}
+			values[j] = value
+		}
+		dataSet.Features = append(dataSet.Features, values)
+
+		if IsLogistic(algo) {
+			if row[labelIdx] == params.LabelName {
+				dataSet.Labels = append(dataSet.Labels, 1)
+			} else {
+				dataSet.Labels = append(dataSet.Labels, 0)
+			}
+		} else {
+			label, err := strconv.ParseFloat(row[labelIdx], 64)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse label, err: %v", err)
+			}
+			dataSet.Labels = append(dataSet.Labels, label)
+		}
+	}
+	return dataSet, nil
+}
+
+// LocalStats returns local statistics required by global standardization,
+// [n, sum of each feature, sum of squares of each feature, sum of labels, sum of squares of labels],
+// label parts are only included for linear regression
+func LocalStats(trainSet *TrainDataSet, algo pb_common.Algorithm) []float64 {
+	d := len(trainSet.FeatureNames)
+	stats := make([]float64, 1+2*d)
+	stats[0] = float64(len(trainSet.Features))
+	for _, values := range trainSet.Features {
+		for j, v := range values {
+			stats[1+j] += v
+			stats[1+d+j] += v * v
+		}
+	}
+	if !IsLogistic(algo) {
+		var sum, sumSquare float64
+		for _, y := range trainSet.Labels {
+			sum += y
+			sumSquare += y * y
+		}
+		stats = append(stats, sum, sumSquare)
+	}
+	return stats
+}
+
+// Standardize standardizes local samples with global statistics aggregated from all parties,
+// and keeps global averages and standard deviations for the model
+func Standardize(trainSet *TrainDataSet, globalStats []float64, algo pb_common.Algorithm, params pb_common.TrainParams) error {
+	d := len(trainSet.FeatureNames)
+	expected := 1 + 2*d
+	if !IsLogistic(algo) {
+		expected += 2
+	}
+	if len(globalStats) != expected {
+		return fmt.Errorf("invalid global statistics, expected %d values but got %d, check whether all parties have the same features", expected, len(globalStats))
+	}
+	n := globalStats[0]
+	if n < 1 {
+		return fmt.Errorf("invalid global statistics, no sample found")
+	}
+
+	xbarSigma := func(sum, sumSquare float64) (float64, float64) {
+		xbar := sum / n
+		sigma := math.Sqrt(math.Max(sumSquare/n-xbar*xbar, 0))
+		if sigma == 0 {
+			sigma = 1
+		}
+		return xbar, sigma
+	}
+
+	trainSet.Xbars = make(map[string]float64)
+	trainSet.Sigmas = make(map[string]float64)
+	for j, name := range trainSet.FeatureNames {
+		trainSet.Xbars[name], trainSet.Sigmas[name] = xbarSigma(globalStats[1+j], globalStats[1+d+j])
+	}
+
+	trainSet.X = make([][]float64, len(trainSet.Features))
+	for i, values := range trainSet.Features {
+		x := make([]float64, d+1)
+		x[0] = 1
+		for j, name := range trainSet.FeatureNames {
+			x[j+1] = (values[j] - trainSet.Xbars[name]) / trainSet.Sigmas[name]
+		}
+		trainSet.X[i] = x
+	}
+
+	trainSet.Y = make([]float64, len(trainSet.Labels))
+	if IsLogistic(algo) {
+		copy(trainSet.Y, trainSet.Labels)
+	} else {
+		ybar, sigma := xbarSigma(globalStats[1+2*d], globalStats[2+2*d])
+		trainSet.Xbars[params.Label] = ybar
+		trainSet.Sigmas[params.Label] = sigma
+		for i, y := range trainSet.Labels {
+			trainSet.Y[i] = (y - ybar) / sigma
+		}
+	}
+	return nil
+}
+
+// InitThetas initialize model, the first one is intercept
+func InitThetas(trainSet *TrainDataSet) []float64 {
+	return make([]float64, len(trainSet.FeatureNames)+1)
+}
+
+// hypothesis calculates the output of model for a standardized sample
+func hypothesis(x, thetas []float64, algo pb_common.Algorithm) float64 {
+	var z float64
+	for j, theta := range thetas {
+		z += theta * x[j]
+	}
+	if IsLogistic(algo) {
+		return sigmoid(z)
+	}
+	return z
+}
+
+// CalCost calculates cost of model on local samples, including regularization
+func CalCost(trainSet *TrainDataSet, thetas []float64, algo pb_common.Algorithm, params pb_common.TrainParams) float64 {
+	n := float64(len(trainSet.X))
+	if n == 0 {
+		return 0
+	}
+
+	var cost float64
+	for i, x := range trainSet.X {
+		h := hypothesis(x, thetas, algo)
+		y := trainSet.Y[i]
+		if IsLogistic(algo) {
+			h = math.Min(math.Max(h, 1e-15), 1-1e-15)
+			cost -= y*math.Log(h) + (1-y)*math.Log(1-h)
+		} else {
+			cost += (h - y) * (h - y) / 2
+		}
+	}
+	cost /= n
+
+	// intercept is not regularized
+	switch params.RegMode {
+	case pb_common.RegMode_Reg_Lasso:
+		for _, theta := range thetas[1:] {
+			cost += params.RegParam * math.Abs(theta) / n
+		}
+	case pb_common.RegMode_Reg_Ridge:
+		for _, theta := range thetas[1:] {
+			cost += params.RegParam * theta * theta / (2 * n)
+		}
+	}
+	return cost
+}
+
+// LocalUpdate trains the global model on local samples for one epoch with mini-batch gradient descent,
+// the order of samples is shuffled by round, and all samples make up one batch if BatchSize is not set
+func LocalUpdate(trainSet *TrainDataSet, thetas []float64, algo pb_common.Algorithm, params pb_common.TrainParams, round int) []float64 {
+	newThetas := make([]float64, len(thetas))
+	copy(newThetas, thetas)
+
+	n := len(trainSet.X)
+	batchSize := int(params.BatchSize)
+	if batchSize <= 0 || batchSize > n {
+		batchSize = n
+	}
+	order := rand.New(rand.NewSource(int64(round))).Perm(n)
+
+	for start := 0; start < n; start += batchSize {
+		end := start + batchSize
+		if end > n {
+			end = n
+		}
+		m := float64(end - start)
+
+		grads := make([]float64, len(newThetas))
+		for _, i := range order[start:end] {
+			diff := hypothesis(trainSet.X[i], newThetas, algo) - trainSet.Y[i]
+			for j, x := range trainSet.X[i] {
+				grads[j] += diff * x
+			}
+		}
+		for j := range newThetas {
+			grad := grads[j] / m
+			if j > 0 {
+				switch params.RegMode {
+				case pb_common.RegMode_Reg_Lasso:
+					grad += params.RegParam * sign(newThetas[j]) / m
+				case pb_common.RegMode_Reg_Ridge:
+					grad += params.RegParam * newThetas[j] / m
+				}
+			}
+			newThetas[j] -= params.Alpha * grad
+		}
+	}
+	return newThetas
+}
+
+// UpdateVector packs local model update for secure aggregation, values are weighted by the number of local samples,
+// [n*theta_0, ..., n*theta_d, n*cost, n]
+func UpdateVector(thetas []float64, cost float64, n int) []float64 {
+	vector := make([]float64, 0, len(thetas)+2)
+	for _, theta := range thetas {
+		vector = append(vector, float64(n)*theta)
+	}
+	return append(vector, float64(n)*cost, float64(n))
+}
+
+// AverageUpdate resolves the aggregated update vector of all parties,
+// returns global thetas averaged by the number of samples and global cost
+func AverageUpdate(aggregated []float64, thetasNum int) ([]float64, float64, error) {
+	if len(aggregated) != thetasNum+2 {
+		return nil, 0, fmt.Errorf("invalid aggregated update, expected %d values but got %d", thetasNum+2, len(aggregated))
+	}
+	n := aggregated[thetasNum+1]
+	if n < 1 {
+		return nil, 0, fmt.Errorf("invalid aggregated update, no sample found")
+	}
+	thetas := make([]float64, thetasNum)
+	for j := range thetas {
+		thetas[j] = aggregated[j] / n
+	}
+	return thetas, aggregated[thetasNum] / n, nil
+}
+
+// StopTraining determine if train process should be stopped
+func StopTraining(lastCost, cost float64, params pb_common.TrainParams) bool {
+	return math.Abs(cost-lastCost) < params.Amplitude
+}
+
+// TrainModelsToBytes convert train models to bytes for transfer and save,
+// every party holds the whole model in horizontal learning, so it's always regarded as tag part
+func TrainModelsToBytes(thetas []float64, trainSet *TrainDataSet, params pb_common.TrainParams) ([]byte, error) {
+	thetaMap := map[string]float64{interceptName: thetas[0]}
+	for j, name := range trainSet.FeatureNames {
+		thetaMap[name] = thetas[j+1]
+	}
+	trainModels := pb_common.TrainModels{
+		Thetas:    thetaMap,
+		Xbars:     trainSet.Xbars,
+		Sigmas:    trainSet.Sigmas,
+		Label:     params.Label,
+		IsTagPart: true,
+	}
+	return json.Marshal(trainModels)
+}
+
+func sigmoid(z float64) float64 {
+	return 1 / (1 + math.Exp(-z))
+}
+
+func sign(v float64) float64 {
+	if v > 0 {
+		return 1
+	} else if v < 0 {
+		return -1
+	}
+	return 0
+}
@@ -0,0 +1,209 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math"
+	"math/big"
+
+	pb_common "github.com/PaddlePaddle/PaddleDTX/dai/protos/common"
+)
+
+// DPNoiseMultiplier returns the ratio of gaussian noise's standard deviation to clipping norm,
+// with which `maxRounds` noisy gradient releases satisfy (epsilon, delta)-DP in total.
+// Rounds are composed under zero-concentrated DP, a release with noise multiplier σ is 1/(2σ²)-zCDP,
+// and ρ-zCDP implies (ρ + 2*sqrt(ρ*ln(1/δ)), δ)-DP
+func DPNoiseMultiplier(dp *pb_common.DifferentialPrivacyParams) (float64, error) {
+	if dp.GetEpsilon() <= 0 || dp.GetDelta() <= 0 || dp.GetDelta() >= 1 || dp.GetMaxRounds() <= 0 {
+		return 0, fmt.Errorf("invalid differential privacy params, epsilon[%v] delta[%v] maxRounds[%d]",
+			dp.GetEpsilon(), dp.GetDelta(), dp.GetMaxRounds())
+	}
+
+	logInvDelta := math.Log(1 / dp.Delta)
+	rho := math.Pow(math.Sqrt(dp.Epsilon+logInvDelta)-math.Sqrt(logInvDelta), 2)
+	return math.Sqrt(float64(dp.MaxRounds) / (2 * rho)), nil
+}
+
+// DPAverageGradients clips every sample's gradient on local thetas, sums them up with gaussian noise,
+// and returns the noisy average gradient for each theta.
+// realGradients[i] is the gradient of theta i for each sample in this round, parties is the number of
+// parties in training. Local gradients are clipped to clipNorm/sqrt(parties), so that a sample's gradient
+// on the whole model is bounded by clipNorm. The noise is slightly larger than DPNoiseMultiplier*clipNorm,
+// to cover rounding noisy sums to the grid as well
+func DPAverageGradients(realGradients []map[int]float64, dp *pb_common.DifferentialPrivacyParams, parties int) ([]float64, error) {
+	if dp.GetClipNorm() <= 0 {
+		return nil, fmt.Errorf("invalid differential privacy params, clipNorm[%v]", dp.GetClipNorm())
+	}
+	if len(realGradients) == 0 || len(realGradients[0]) == 0 {
+		return nil, fmt.Errorf("no gradients to average")
+	}
+	multiplier, err := DPNoiseMultiplier(dp)
+	if err != nil {
+		return nil, err
+	}
+
+	// clip each sample's local gradient in L2 norm
+	localClip := dp.ClipNorm / math.Sqrt(float64(parties))
+	sums := make([]float64, len(realGradients))
+	for id := range realGradients[0] {
+		var norm float64
+		for _, grads := range realGradients {
+			norm += grads[id] * grads[id]
+		}
+		norm = math.Sqrt(norm)
+		scale := 1.0
+		if norm > localClip {
+			scale = localClip / norm
+		}
+		for i, grads := range realGradients {
+			sums[i] += grads[id] * scale
+		}
+	}
+
+	// sums are rounded to the grid of noise, so released values are exact multiples of the grid.
+	// Rounding moves the sums of neighbouring datasets apart by up to one grid on each theta, so the local
+	// sensitivity is localClip + sqrt(thetas)*grid, and the noise is scaled up to keep the same ratio to it
+	rounding := multiplier * math.Sqrt(float64(parties*len(sums))) / dgaussSigma
+	if rounding >= 1 {
+		return nil, fmt.Errorf("too many thetas[%d] for noise multiplier[%v]", len(sums), multiplier)
+	}
+	grid := multiplier * dp.ClipNorm / (1 - rounding) / dgaussSigma
+	sampleNum := float64(len(realGradients[0]))
+	avgGrads := make([]float64, len(sums))
+	for i, sum := range sums {
+		noise, err := sampleDiscreteGaussian()
+		if err != nil {
+			return nil, fmt.Errorf("failed to sample noise: %v", err)
+		}
+		avgGrads[i] = (math.Round(sum/grid) + float64(noise)) * grid / sampleNum
+	}
+	return avgGrads, nil
+}
+
+// Gaussian noise is sampled on integers by the exact sampler of Canonne, Kamath and Steinke,
+// "The Discrete Gaussian for Differential Privacy" (2020), using only crypto/rand and rational arithmetic,
+// so neither the noise can be predicted nor floating-point artifacts leak the real value.
+// The sampled integer is scaled to the noise's standard deviation, the resolution is 1/dgaussSigma of it.
+
+// dgaussSigma is the standard deviation of the discrete gaussian sampled on integers,
+// a fine resolution keeps the sensitivity added by rounding to the grid small
+const dgaussSigma = 1 << 20
+
+// sampleDiscreteGaussian samples from the discrete gaussian on integers with standard deviation dgaussSigma
+func sampleDiscreteGaussian() (int64, error) {
+	sigma2 := new(big.Rat).SetInt64(dgaussSigma * dgaussSigma)
+	t := int64(dgaussSigma + 1)
+	for {
+		y, err := sampleDiscreteLaplace(t)
+		if err != nil {
+			return 0, err
+		}
+		// accept y with probability exp(-(|y| - σ²/t)² / 2σ²)
+		gamma := new(big.Rat).SetInt64(abs(y))
+		gamma.Sub(gamma, new(big.Rat).Quo(sigma2, new(big.Rat).SetInt64(t)))
+		gamma.Mul(gamma, gamma)
+		gamma.Quo(gamma, new(big.Rat).Mul(big.NewRat(2, 1), sigma2))
+		c, err := bernoulliExp(gamma)
+		if err != nil {
+			return 0, err
+		}
+		if c {
+			return y, nil
+		}
+	}
+}
+
+// sampleDiscreteLaplace samples y on integers with probability proportional to exp(-|y|/t)
+func sampleDiscreteLaplace(t int64) (int64, error) {
+	for {
+		u, err := rand.Int(rand.Reader, big.NewInt(t))
+		if err != nil {
+			return 0, err
+		}
+		d, err := bernoulliExp(new(big.Rat).SetFrac(u, big.NewInt(t)))
+		if err != nil {
+			return 0, err
+		}
+		if !d {
+			continue
+		}
+		// v follows geometric distribution with p = 1 - exp(-1)
+		var v int64
+		for {
+			a, err := bernoulliExp(big.NewRat(1, 1))
+			if err != nil {
+				return 0, err
+			}
+			if !a {
+				break
+			}
+			v++
+		}
+		y := u.Int64() + t*v
+		negative, err := bernoulli(big.NewRat(1, 2))
+		if err != nil {
+			return 0, err
+		}
+		if negative && y == 0 {
+			continue
+		}
+		if negative {
+			return -y, nil
+		}
+		return y, nil
+	}
+}
+
+// bernoulliExp returns true with probability exp(-gamma), gamma must not be negative
+func bernoulliExp(gamma *big.Rat) (bool, error) {
+	one := big.NewRat(1, 1)
+	// exp(-gamma) = exp(-1)^floor(gamma) * exp(-(gamma - floor(gamma)))
+	for gamma.Cmp(one) > 0 {
+		b, err := bernoulliExp(big.NewRat(1, 1))
+		if err != nil || !b {
+			return false, err
+		}
+		gamma = new(big.Rat).Sub(gamma, one)
+	}
+	// for gamma in [0, 1], the first k failing bernoulli(gamma/k) is odd with probability exp(-gamma)
+	k := int64(1)
+	for {
+		a, err := bernoulli(new(big.Rat).Quo(gamma, new(big.Rat).SetInt64(k)))
+		if err != nil {
+			return false, err
+		}
+		if !a {
+			return k%2 == 1, nil
+		}
+		k++
+	}
+}
+
+// bernoulli returns true with probability p in [0, 1]
+func bernoulli(p *big.Rat) (bool, error) {
+	r, err := rand.Int(rand.Reader, p.Denom())
+	if err != nil {
+		return false, err
+	}
+	return r.Cmp(p.Num()) < 0, nil
+}
+
+func abs(x int64) int64 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"math"
+	"testing"
+
+	pb_common "github.com/PaddlePaddle/PaddleDTX/dai/protos/common"
+)
+
+func TestDPNoiseMultiplier(t *testing.T) {
+	dp := &pb_common.DifferentialPrivacyParams{Enable: true, Epsilon: 1, Delta: 1e-5, MaxRounds: 100}
+	sigma, err := DPNoiseMultiplier(dp)
+	checkErr(err, t)
+
+	// composed privacy loss of all rounds should equal epsilon
+	rho := float64(dp.MaxRounds) / (2 * sigma * sigma)
+	eps := rho + 2*math.Sqrt(rho*math.Log(1/dp.Delta))
+	if math.Abs(eps-dp.Epsilon) > 1e-9 {
+		t.Fatalf("expected epsilon %v, got %v", dp.Epsilon, eps)
+	}
+
+	// smaller budget or more rounds need more noise
+	sigmaMoreRounds, err := DPNoiseMultiplier(&pb_common.DifferentialPrivacyParams{Epsilon: 1, Delta: 1e-5, MaxRounds: 400})
+	checkErr(err, t)
+	if math.Abs(sigmaMoreRounds-2*sigma) > 1e-9 {
+		t.Fatalf("expected noise multiplier %v, got %v", 2*sigma, sigmaMoreRounds)
+	}
+	sigmaLessEps, err := DPNoiseMultiplier(&pb_common.DifferentialPrivacyParams{Epsilon: 0.5, Delta: 1e-5, MaxRounds: 100})
+	checkErr(err, t)
+	if sigmaLessEps <= sigma {
+		t.Fatalf("expected noise multiplier larger than %v, got %v", sigma, sigmaLessEps)
+	}
+
+	for _, invalid := range []*pb_common.DifferentialPrivacyParams{
+		{Epsilon: 0, Delta: 1e-5, MaxRounds: 100},
+		{Epsilon: 1, Delta: 0, MaxRounds: 100},
+		{Epsilon: 1, Delta: 1, MaxRounds: 100},
+		{Epsilon: 1, Delta: 1e-5, MaxRounds: 0},
+	} {
+		if _, err := DPNoiseMultiplier(invalid); err == nil {
+			t.Fatalf("expected error with params %v", invalid)
+		}
+	}
+}
+
+func TestDPAverageGradients(t *testing.T) {
+	// sample 0 is within norm bound, sample 1 is clipped from norm 5 to 1
+	realGradients := []map[int]float64{
+		{0: 0.3, 1: 3},
+		{0: 0.4, 1: 4},
+	}
+	// noise is negligible with a huge budget
+	dp := &pb_common.DifferentialPrivacyParams{Enable: true, Epsilon: 1e12, Delta: 1e-5, ClipNorm: 1, MaxRounds: 1}
+	grads, err := DPAverageGradients(realGradients, dp, 1)
+	checkErr(err, t)
+	expected := []float64{(0.3 + 0.6) / 2, (0.4 + 0.8) / 2}
+	for i := range expected {
+		if math.Abs(grads[i]-expected[i]) > 1e-4 {
+			t.Fatalf("theta %d, expected gradient %v, got %v", i, expected[i], grads[i])
+		}
+	}
+
+	// with 4 parties, local gradients are bounded by 1/2
+	grads, err = DPAverageGradients(realGradients, dp, 4)
+	checkErr(err, t)
+	expected = []float64{(0.3 + 0.3) / 2, (0.4 + 0.4) / 2}
+	for i := range expected {
+		if math.Abs(grads[i]-expected[i]) > 1e-4 {
+			t.Fatalf("theta %d, expected gradient %v, got %v", i, expected[i], grads[i])
+		}
+	}
+
+	// noise is added with a normal budget
+	dp.Epsilon = 1
+	grads, err = DPAverageGradients(realGradients, dp, 1)
+	checkErr(err, t)
+	if math.Abs(grads[0]-0.45) < 1e-4 && math.Abs(grads[1]-0.6) < 1e-4 {
+		t.Fatalf("expected noisy gradients, got %v", grads)
+	}
+
+	// rounding to the grid can't be covered if the noise is too large for its resolution
+	dp.Epsilon = 1e-6
+	if _, err := DPAverageGradients(realGradients, dp, 1); err == nil {
+		t.Fatal("expected error with noise multiplier too large")
+	}
+
+	dp.ClipNorm = 0
+	if _, err := DPAverageGradients(realGradients, dp, 1); err == nil {
+		t.Fatal("expected error with zero clipNorm")
+	}
+}
+
+func TestSampleDiscreteGaussian(t *testing.T) {
+	const n = 5000
+	var sum, sumSq float64
+	for i := 0; i < n; i++ {
+		y, err := sampleDiscreteGaussian()
+		checkErr(err, t)
+		sum += float64(y)
+		sumSq += float64(y) * float64(y)
+	}
+	// the standard error of the mean is dgaussSigma/sqrt(n)
+	mean := sum / n
+	if math.Abs(mean) > 5*dgaussSigma/math.Sqrt(n) {
+		t.Fatalf("expected mean around 0, got %v", mean)
+	}
+	std := math.Sqrt(sumSq/n - mean*mean)
+	if math.Abs(std-dgaussSigma)/dgaussSigma > 0.05 {
+		t.Fatalf("expected standard deviation around %d, got %v", dgaussSigma, std)
+	}
+}
@@ -71,6 +71,26 @@ func IntersectTwoParts(sampleID []string, reEncSetLocal []byte, reEncSetOthers [
 	return xchainCryptoClient.PSIntersect(sampleID, localSet, otherSetList), nil
 }
 
+// IntersectParts get intersection of local ID set and several other parts' ID sets
+// sampleID is ID list retrieved from local sample file
+// reEncSetLocal is local ID list that was already encrypted by all parts
+// reEncSetsOthers are other parties' ID lists that were already encrypted by all parts
+func IntersectParts(sampleID []string, reEncSetLocal []byte, reEncSetsOthers [][]byte) ([]string, error) {
+	localSet, err := PSIEncSetFromBytes(reEncSetLocal)
+	if err != nil {
+		return nil, err
+	}
+	var otherSetList []*linear_vertical.EncSet
+	for _, reEncSetOther := range reEncSetsOthers {
+		otherSet, err := PSIEncSetFromBytes(reEncSetOther)
+		if err != nil {
+			return nil, err
+		}
+		otherSetList = append(otherSetList, otherSet)
+	}
+	return xchainCryptoClient.PSIntersect(sampleID, localSet, otherSetList), nil
+}
+
 // RetrieveIDsFromFile retrieve ID set from file rows by id name
 // fileRows is original sample rows, including feature list and sample values
 // idName is the name of ID feature, like "id", "card_number"...
@@ -15,8 +15,14 @@ package common
 
 import (
 	"fmt"
+	"math"
+	"math/big"
 	"sort"
 
+	"github.com/PaddlePaddle/PaddleDTX/crypto/common/math/homomorphism/paillier"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/common/math/rand"
+	ml_common "github.com/PaddlePaddle/PaddleDTX/crypto/core/machine_learning/common"
+
 	pb_common "github.com/PaddlePaddle/PaddleDTX/dai/protos/common"
 )
 
@@ -78,3 +84,59 @@ func randTrainSet(trainSet [][]float64, round int) [][]float64 {
 
 	return reOrderedSet
 }
+
+// CalEncGradientWithOtherPart calculate encrypted gradient part of local feature contributed by other no-tag part,
+// used when more than two parties train a model, no-tag parties have to exchange their local predictions.
+// For sample j and feature i, encGrad(j) = encByC(preValC(j)) * xj(i) * scale + encByC(RanNum)
+// - encPredicts is local predictions of other no-tag part encrypted by its public key, mapped by sample ID
+// - trainSet is train set for this round, first column is sample ID
+// - scale is the coefficient of other part's prediction in gradient formula
+// - publicKey is homomorphic public key of other no-tag part
+func CalEncGradientWithOtherPart(encPredicts map[int]*big.Int, trainSet [][]float64, featureIndex, accuracy int,
+	scale float64, publicKey *paillier.PublicKey) (*ml_common.EncLocalGradient, error) {
+	randomBytes, err := rand.GenerateSeedWithStrengthAndKeyLen(rand.KeyStrengthHard, rand.KeyLengthInt64)
+	if err != nil {
+		return nil, err
+	}
+	ranNum := big.NewInt(0).SetBytes(randomBytes)
+
+	encGradMap := make(map[int]*big.Int)
+	for i := 0; i < len(trainSet); i++ {
+		id := int(math.Floor(trainSet[i][0] + 0.5))
+		encPredict, ok := encPredicts[id]
+		if !ok {
+			return nil, fmt.Errorf("failed to get encrypted prediction of other part for id: %d", id)
+		}
+
+		// both prediction and feature value have one accuracy, so result has double accuracy
+		scaleFactor := big.NewInt(int64(math.Round(trainSet[i][featureIndex+1] * scale * math.Pow(10, float64(accuracy)))))
+		encValue := publicKey.CypherPlainMultiply(encPredict, scaleFactor)
+		encGradMap[id] = publicKey.CypherPlainsAdd(encValue, ranNum)
+	}
+
+	return &ml_common.EncLocalGradient{
+		EncGrad:     encGradMap,
+		RandomNoise: ranNum,
+	}, nil
+}
+
+// RemoveRedundantGradient remove redundant gradient parts contributed by tag part itself.
+// When more than two parties train a model, tag part gets gradient from each other party,
+// and every one contains the same local part, times is the count of redundant ones.
+// - realGrad is real gradient of the feature summed up over all parties, mapped by sample ID
+// - rawParts is local part with one accuracy, mapped by sample ID
+// - trainSet is train set for this round, first column is sample ID
+func RemoveRedundantGradient(realGrad map[int]float64, rawParts map[int]*big.Int, trainSet [][]float64, featureIndex, accuracy, times int) error {
+	for i := 0; i < len(trainSet); i++ {
+		id := int(math.Floor(trainSet[i][0] + 0.5))
+		rawPart, ok := rawParts[id]
+		if !ok {
+			return fmt.Errorf("failed to get raw part for id: %d", id)
+		}
+
+		scaleFactor := math.Round(trainSet[i][featureIndex+1] * math.Pow(10, float64(accuracy)))
+		localGrad := float64(rawPart.Int64()) * scaleFactor / math.Pow(10, float64(accuracy)*2)
+		realGrad[id] -= float64(times) * localGrad
+	}
+	return nil
+}
@@ -54,13 +54,17 @@ func PredictLocalPart(fileRows [][]string, params *pb_common.TrainModels) ([]flo
 // DeStandardizeOutput de-standardize predict sum to get real result
 // de-standardizing requires average value and standard deviation of target feature
 // only party with label can de-standardize predict sum
-func DeStandardizeOutput(params *pb_common.TrainModels, localPredict, otherPredict []float64) []float64 {
+// otherPredicts are predict values of other parties, one slice for each party
+func DeStandardizeOutput(params *pb_common.TrainModels, localPredict []float64, otherPredicts [][]float64) []float64 {
 	var realPredictValue []float64
 	ybar := params.Xbars[params.Label]
 	sigma := params.Sigmas[params.Label]
 
 	for i := 0; i < len(localPredict); i++ {
-		predictSum := localPredict[i] + otherPredict[i]
+		predictSum := localPredict[i]
+		for _, otherPredict := range otherPredicts {
+			predictSum += otherPredict[i]
+		}
 		realValue := xchainCryptoClient.LinRegVLDeStandardizeOutput(ybar, sigma, predictSum)
 		realPredictValue = append(realPredictValue, realValue)
 	}
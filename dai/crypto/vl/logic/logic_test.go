@@ -144,16 +144,16 @@ func TestLogicReg(t *testing.T) {
 			checkErr(err, t)
 			costB, err = UpdateCost(costBytesB, costNoiseB, paramsB)
 			checkErr(err, t)
-			if StopTraining(lastCostA, costA, paramsA) && StopTraining(lastCostB, costB, paramsB) {
+			if StopTraining(lastCostA, costA, paramsA, round) && StopTraining(lastCostB, costB, paramsB, round) {
 				break
 			}
 
 			log.Printf("round[%d], deltaA: %v, deltaB: %v", round, math.Abs(costA-lastCostA), math.Abs(costB-lastCostB))
 		}
 
-		thetasA, err = UpdateGradient(gradBytesA, gradientNoiseA, thetasA, paramsA)
+		thetasA, err = UpdateGradient([][]byte{gradBytesA}, [][]*big.Int{gradientNoiseA}, rawPartA, trainDataSetA, thetasA, paramsA, round)
 		checkErr(err, t)
-		thetasB, err = UpdateGradient(gradBytesB, gradientNoiseB, thetasB, paramsB)
+		thetasB, err = UpdateGradient([][]byte{gradBytesB}, [][]*big.Int{gradientNoiseB}, rawPartB, trainDataSetB, thetasB, paramsB, round)
 		checkErr(err, t)
 
 		lastCostA = costA
@@ -193,10 +193,190 @@ func TestLogicReg(t *testing.T) {
 	predictB, err := PredictLocalPart(fileRowsB, modelB)
 	checkErr(err, t)
 
-	output := CalRealPredictValue(predictA, predictB)
+	output := CalRealPredictValue(predictA, [][]float64{predictB})
 	t.Logf("predict value: %v\n", output)
 }
 
+func TestLogicRegThreeParts(t *testing.T) {
+	fileContentA, err := ioutil.ReadFile("../testdata/logic_iris_plants/train_dataA.csv")
+	checkErr(err, t)
+	fileContentB, err := ioutil.ReadFile("../testdata/logic_iris_plants/train_dataB.csv")
+	checkErr(err, t)
+	rowsA, err := csv.ReadRowsFromFile(fileContentA)
+	checkErr(err, t)
+	rowsB, err := csv.ReadRowsFromFile(fileContentB)
+	checkErr(err, t)
+
+	// split features of A into two no-tag parts, B is the tag part
+	var rowsA1, rowsA2 [][]string
+	for _, row := range rowsA {
+		rowsA1 = append(rowsA1, row[:1])
+		rowsA2 = append(rowsA2, row[1:])
+	}
+	fileRows := [][][]string{rowsA1, rowsA2, rowsB}
+	tag := 2
+
+	var params []pb_common.TrainParams
+	var trainDataSets []*ml_common.TrainDataSet
+	var thetas [][]float64
+	var homoPrivs []*paillier.PrivateKey
+	var homoPubs [][]byte
+	for k := range fileRows {
+		param := pb_common.TrainParams{
+			Label:     "Label",
+			LabelName: "Iris-setosa",
+			Alpha:     0.1,
+			Accuracy:  10,
+			IsTagPart: k == tag,
+			BatchSize: 16,
+		}
+		trainDataSet, err := GetTrainDataSetFromFile(fileRows[k], param)
+		checkErr(err, t)
+		theta := InitThetas(trainDataSet, param)
+		for i := range theta {
+			theta[i] = 0.1 * float64(i+k+1)
+		}
+		homoPriv, homoPub, err := vl_common.GenerateHomoKeyPair()
+		checkErr(err, t)
+
+		params = append(params, param)
+		trainDataSets = append(trainDataSets, trainDataSet)
+		thetas = append(thetas, theta)
+		homoPrivs = append(homoPrivs, homoPriv)
+		homoPubs = append(homoPubs, homoPub)
+	}
+
+	var rawParts []*logic_vertical.RawLocalGradAndCostPart
+	var partBytes [][]byte
+	for k := range fileRows {
+		rawPart, partByte, newSet, err := CalLocalGradientAndCost(trainDataSets[k], thetas[k], params[k], &homoPrivs[k].PublicKey, 0)
+		checkErr(err, t)
+		trainDataSets[k].TrainSet = newSet
+		rawParts = append(rawParts, rawPart)
+		partBytes = append(partBytes, partByte)
+	}
+
+	// each part gets its gradient from all other parts
+	var newThetas [][]float64
+	for k := range fileRows {
+		var decGrads [][]byte
+		var noises [][]*big.Int
+		for l := range fileRows {
+			if l == k {
+				continue
+			}
+			var encGrad, encCost []byte
+			var noise []*big.Int
+			if k == tag || l == tag {
+				encGrad, encCost, noise, _, err = CalEncGradientAndCost(rawParts[k], partBytes[l], trainDataSets[k], params[k], homoPubs[l], thetas[k], 0)
+			} else {
+				encGrad, noise, err = CalEncGradientWithOtherPart(partBytes[l], trainDataSets[k], params[k], homoPubs[l], thetas[k], 0)
+			}
+			checkErr(err, t)
+			decGrad, _, err := DecGradientAndCost(encGrad, encCost, homoPrivs[l])
+			checkErr(err, t)
+			decGrads = append(decGrads, decGrad)
+			noises = append(noises, noise)
+		}
+		newTheta, err := UpdateGradient(decGrads, noises, rawParts[k], trainDataSets[k], thetas[k], params[k], 0)
+		checkErr(err, t)
+		newThetas = append(newThetas, newTheta)
+	}
+
+	// compare with gradient descent on plaintext
+	var batches [][][]float64
+	for k := range fileRows {
+		batch, _ := vl_common.GetBatchSetBySize(trainDataSets[k].TrainSet, params[k], 0, false)
+		batches = append(batches, batch)
+	}
+	residuals := make(map[int]float64)
+	for k := range fileRows {
+		for _, row := range batches[k] {
+			id := int(math.Floor(row[0] + 0.5))
+			predict := 0.0
+			if k == tag {
+				predict = thetas[k][0]
+				for i := 1; i < len(thetas[k]); i++ {
+					predict += thetas[k][i] * row[i+1]
+				}
+				residuals[id] += 0.5 + predict/4 - row[len(row)-1]
+			} else {
+				for i := 0; i < len(thetas[k]); i++ {
+					predict += thetas[k][i] * row[i+1]
+				}
+				residuals[id] += predict / 4
+			}
+		}
+	}
+	for k := range fileRows {
+		for i := range thetas[k] {
+			grad := 0.0
+			for _, row := range batches[k] {
+				grad += residuals[int(math.Floor(row[0]+0.5))] * row[i+1]
+			}
+			expected := thetas[k][i] - params[k].Alpha*grad/float64(len(batches[k]))
+			if math.Abs(expected-newThetas[k][i]) > 1e-6 {
+				t.Fatalf("part %d theta %d, expected %v, got %v", k, i, expected, newThetas[k][i])
+			}
+		}
+	}
+}
+
+func TestLogicRegDP(t *testing.T) {
+	fileContentA, err := ioutil.ReadFile("../testdata/logic_iris_plants/train_dataA.csv")
+	checkErr(err, t)
+	fileContentB, err := ioutil.ReadFile("../testdata/logic_iris_plants/train_dataB.csv")
+	checkErr(err, t)
+	rowsA, err := csv.ReadRowsFromFile(fileContentA)
+	checkErr(err, t)
+	rowsB, err := csv.ReadRowsFromFile(fileContentB)
+	checkErr(err, t)
+
+	// noise is negligible with a huge budget, and gradients are never clipped
+	dp := &pb_common.DifferentialPrivacyParams{Enable: true, Epsilon: 1e16, Delta: 1e-5, ClipNorm: 1e3, MaxRounds: 3}
+	pA := pb_common.TrainParams{Label: "Label", LabelName: "Iris-setosa", Alpha: 0.1, Accuracy: 10, BatchSize: 4}
+	pB := pb_common.TrainParams{Label: "Label", LabelName: "Iris-setosa", Alpha: 0.1, Accuracy: 10, BatchSize: 4, IsTagPart: true}
+	setA, err := GetTrainDataSetFromFile(rowsA, pA)
+	checkErr(err, t)
+	setB, err := GetTrainDataSetFromFile(rowsB, pB)
+	checkErr(err, t)
+	thA := InitThetas(setA, pA)
+	thB := InitThetas(setB, pB)
+	privA, pubA, err := vl_common.GenerateHomoKeyPair()
+	checkErr(err, t)
+	privB, pubB, err := vl_common.GenerateHomoKeyPair()
+	checkErr(err, t)
+
+	rawA, partA, newA, err := CalLocalGradientAndCost(setA, thA, pA, &privA.PublicKey, 0)
+	checkErr(err, t)
+	rawB, partB, newB, err := CalLocalGradientAndCost(setB, thB, pB, &privB.PublicKey, 0)
+	checkErr(err, t)
+	setA.TrainSet = newA
+	setB.TrainSet = newB
+	encGradA, encCostA, noiseA, _, err := CalEncGradientAndCost(rawA, partB, setA, pA, pubB, thA, 0)
+	checkErr(err, t)
+	gradA, _, err := DecGradientAndCost(encGradA, encCostA, privB)
+	checkErr(err, t)
+	_, _, _, _, err = CalEncGradientAndCost(rawB, partA, setB, pB, pubA, thB, 0)
+	checkErr(err, t)
+
+	expected, err := UpdateGradient([][]byte{gradA}, [][]*big.Int{noiseA}, rawA, setA, thA, pA, 0)
+	checkErr(err, t)
+	pA.DpParams = dp
+	got, err := UpdateGradient([][]byte{gradA}, [][]*big.Int{noiseA}, rawA, setA, thA, pA, 0)
+	checkErr(err, t)
+	for i := range expected {
+		if math.Abs(expected[i]-got[i]) > 1e-4 {
+			t.Fatalf("theta %d, expected %v, got %v", i, expected[i], got[i])
+		}
+	}
+
+	// training stops after thetas are updated in all rounds, no matter how cost changes
+	if StopTraining(1, 1, pA, 2) || !StopTraining(1, 100, pA, 3) {
+		t.Fatal("expected training to stop at round 3")
+	}
+}
+
 func checkErr(err error, t *testing.T) {
 	if err != nil {
 		t.Error(err)
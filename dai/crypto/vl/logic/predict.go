@@ -53,11 +53,15 @@ func PredictLocalPart(fileRows [][]string, params *pb_common.TrainModels) ([]flo
 }
 
 // CalRealPredictValue calculate final predict value by sum of predict parts
-func CalRealPredictValue(localPredict, otherPredict []float64) []float64 {
+// otherPredicts are predict values of other parties, one slice for each party
+func CalRealPredictValue(localPredict []float64, otherPredicts [][]float64) []float64 {
 	var realPredictValue []float64
 
 	for i := 0; i < len(localPredict); i++ {
-		predictSum := localPredict[i] + otherPredict[i]
+		predictSum := localPredict[i]
+		for _, otherPredict := range otherPredicts {
+			predictSum += otherPredict[i]
+		}
 		realValue := 1 / (1 + math.Exp(-1*predictSum))
 		realPredictValue = append(realPredictValue, realValue)
 	}
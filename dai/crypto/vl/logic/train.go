@@ -154,6 +154,44 @@ func CalEncGradientAndCost(rawPart *logic_vertical.RawLocalGradAndCostPart, othe
 	return encGradListBytes, encCostBytes, gradientNoise, costNoise, nil
 }
 
+// CalEncGradientWithOtherPart calculate own encrypted gradient contributed by other no-tag part, encrypted by its public key
+// only used by no-tag part when more than two parties train a model, gradient of feature i is extended by xA(i)*preValC/4
+// otherPartBytes is received from other no-tag party, publicKeyBytes is its homomorphic public key
+// return encGradient and gradient noise, cost is not evaluated between no-tag parts
+func CalEncGradientWithOtherPart(otherPartBytes []byte, trainSet *ml_common.TrainDataSet, params pb_common.TrainParams,
+	publicKeyBytes []byte, thetas []float64, round int) ([]byte, []*big.Int, error) {
+
+	otherEncPart, err := vl_common.LogicEncGradAndCostPartFromBytes(otherPartBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	publicKey, err := vl_common.HomoPubkeyFromBytes(publicKeyBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// BGD, SGD or MBGD
+	trainSetThisRound, _ := vl_common.GetBatchSetBySize(trainSet.TrainSet, params, round, false)
+
+	var encGradList []map[int]*big.Int
+	var gradientNoise []*big.Int
+	for i := 0; i < len(thetas); i++ {
+		encGrad, err := vl_common.CalEncGradientWithOtherPart(otherEncPart.EncPart1, trainSetThisRound, i, int(params.Accuracy), 0.25, publicKey)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		encGradList = append(encGradList, encGrad.EncGrad)
+		gradientNoise = append(gradientNoise, encGrad.RandomNoise)
+	}
+
+	encGradListBytes, err := vl_common.GradListToBytes(encGradList)
+	if err != nil {
+		return nil, nil, err
+	}
+	return encGradListBytes, gradientNoise, nil
+}
+
 // DecGradientAndCost decrypt gradient list and cost for other part
 // encGradsBytes and encCostBytes are ciphertext received from other party, encrypted by local homomorphic public key
 // privateKey is local homomorphic private key, used to decrypt encGradsBytes and encCostBytes
@@ -169,16 +207,20 @@ func DecGradientAndCost(encGradsBytes []byte, encCostBytes []byte, privateKey *p
 		decGradList = append(decGradList, grad)
 	}
 
-	encCost, err := vl_common.CostFromBytes(encCostBytes)
+	decGradBytes, err := vl_common.GradListToBytes(decGradList)
 	if err != nil {
 		return nil, nil, err
 	}
-	cost := xchainCryptoClient.LogRegVLDecryptCost(encCost, privateKey)
 
-	decGradBytes, err := vl_common.GradListToBytes(decGradList)
+	// no cost is evaluated between no-tag parts
+	if len(encCostBytes) == 0 {
+		return decGradBytes, []byte{}, nil
+	}
+	encCost, err := vl_common.CostFromBytes(encCostBytes)
 	if err != nil {
 		return nil, nil, err
 	}
+	cost := xchainCryptoClient.LogRegVLDecryptCost(encCost, privateKey)
 	decCostBytes, err := vl_common.CostToBytes(cost)
 	if err != nil {
 		return nil, nil, err
@@ -202,18 +244,58 @@ func UpdateCost(decCostBytes []byte, costNoise *big.Int, params pb_common.TrainP
 }
 
 // UpdateGradient retrieve and update thetas
-// decGradBytes is decrypted gradient received from other party, with gradientNoise
-func UpdateGradient(decGradBytes []byte, gradientNoise []*big.Int, thetas []float64, params pb_common.TrainParams) ([]float64, error) {
-	grads, err := vl_common.GradListFromBytes(decGradBytes)
-	if err != nil {
-		return nil, err
+// decGradBytes is decrypted gradients received from other parties, with gradientNoise one by one
+// when more than two parties train a model, every gradient for tag part contains its local part,
+// and redundant ones are removed with rawPart
+func UpdateGradient(decGradBytes [][]byte, gradientNoise [][]*big.Int, rawPart *logic_vertical.RawLocalGradAndCostPart, trainSet *ml_common.TrainDataSet,
+	thetas []float64, params pb_common.TrainParams, round int) ([]float64, error) {
+	var gradsList [][]map[int]*big.Int
+	for _, gradBytes := range decGradBytes {
+		grads, err := vl_common.GradListFromBytes(gradBytes)
+		if err != nil {
+			return nil, err
+		}
+		gradsList = append(gradsList, grads)
 	}
 
+	// BGD, SGD or MBGD
+	trainSetThisRound, _ := vl_common.GetBatchSetBySize(trainSet.TrainSet, params, round, false)
+
 	newThetas := make([]float64, len(thetas))
 	copy(newThetas[0:], thetas)
 
+	realGradients := make([]map[int]float64, len(newThetas))
 	for i := 0; i < len(newThetas); i++ {
-		realGradient := xchainCryptoClient.LogRegVLRetrieveRealGradient(grads[i], int(params.Accuracy), gradientNoise[i])
+		realGradient := make(map[int]float64)
+		for k, grads := range gradsList {
+			partGradient := xchainCryptoClient.LogRegVLRetrieveRealGradient(grads[i], int(params.Accuracy), gradientNoise[k][i])
+			for id, g := range partGradient {
+				realGradient[id] += g
+			}
+		}
+		if params.IsTagPart && len(gradsList) > 1 {
+			err := vl_common.RemoveRedundantGradient(realGradient, rawPart.RawPart5, trainSetThisRound, i, int(params.Accuracy), len(gradsList)-1)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		realGradients[i] = realGradient
+	}
+
+	// with differential privacy, per-sample gradients are clipped and perturbed before updating thetas
+	if dp := params.GetDpParams(); dp.GetEnable() {
+		grads, err := vl_common.DPAverageGradients(realGradients, dp, len(decGradBytes)+1)
+		if err != nil {
+			return nil, err
+		}
+		for i, grad := range grads {
+			newThetas[i] = newThetas[i] - params.Alpha*grad
+		}
+		return newThetas, nil
+	}
+
+	for i, realGradient := range realGradients {
 		grad := xchainCryptoClient.LogRegVLCalGradient(realGradient)
 		newThetas[i] = newThetas[i] - params.Alpha*grad
 	}
@@ -222,6 +304,11 @@ func UpdateGradient(decGradBytes []byte, gradientNoise []*big.Int, thetas []floa
 }
 
 // StopTraining determine if train process should be stopped
-func StopTraining(lastCost, cost float64, params pb_common.TrainParams) bool {
+// with differential privacy, training stops once thetas have been updated in all the rounds the privacy budget is spread over,
+// and cost is not used to avoid an extra data-dependent decision
+func StopTraining(lastCost, cost float64, params pb_common.TrainParams, round int) bool {
+	if dp := params.GetDpParams(); dp.GetEnable() {
+		return int64(round) >= dp.MaxRounds
+	}
 	return math.Abs(cost-lastCost) < params.Amplitude
 }
@@ -0,0 +1,215 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secureboost
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	pb_common "github.com/PaddlePaddle/PaddleDTX/dai/protos/common"
+)
+
+// Tree is a regression tree of gradient boosting, kept by tag part
+// the root is Nodes[0]
+type Tree struct {
+	Nodes []*TreeNode
+}
+
+// TreeNode is a node of Tree, it splits on a local feature of tag part, or on a split record kept by a no-tag part,
+// or it is a leaf with weight
+type TreeNode struct {
+	IsLeaf bool    `json:",omitempty"`
+	Weight float64 `json:",omitempty"`
+
+	// Left and Right are indexes of children in Tree.Nodes
+	Left  int `json:",omitempty"`
+	Right int `json:",omitempty"`
+
+	// split on local feature, a sample goes left if its value is no greater than Threshold
+	Feature   string  `json:",omitempty"`
+	Threshold float64 `json:",omitempty"`
+
+	// split on record kept by no-tag part
+	Owner    string `json:",omitempty"`
+	RecordID string `json:",omitempty"`
+}
+
+// SplitRecord is a split of tree node on local feature of no-tag part,
+// a sample goes left if its value is no greater than Threshold
+type SplitRecord struct {
+	Feature   string
+	Threshold float64
+}
+
+// BoostModel is the model trained by SecureBoost, saved as TrainModels.BoostTrees
+// tag part keeps the initial score and all trees, no-tag part keeps its split records
+type BoostModel struct {
+	InitScore float64                 `json:",omitempty"`
+	Trees     []*Tree                 `json:",omitempty"`
+	Records   map[string]*SplitRecord `json:",omitempty"`
+}
+
+// TrainModelsToBytes convert boost model to bytes for transfer and save
+func TrainModelsToBytes(model *BoostModel, params pb_common.TrainParams) ([]byte, error) {
+	boostTrees, err := json.Marshal(model)
+	if err != nil {
+		return nil, err
+	}
+	trainModels := pb_common.TrainModels{
+		BoostTrees: boostTrees,
+		Label:      params.Label,
+		IsTagPart:  params.IsTagPart,
+	}
+	return json.Marshal(trainModels)
+}
+
+// BoostModelFromTrainModels retrieve boost model from train models
+func BoostModelFromTrainModels(params *pb_common.TrainModels) (*BoostModel, error) {
+	var model BoostModel
+	if err := json.Unmarshal(params.BoostTrees, &model); err != nil {
+		return nil, fmt.Errorf("failed to parse boost trees, err: %v", err)
+	}
+	return &model, nil
+}
+
+// PredictLocalPart calculates split directions of each sample on local split records, used by no-tag part
+// fileRows is sample rows, first row is feature list, others are values for each sample
+// returns bytes of map from record ID to directions of samples, true means going to left child
+func PredictLocalPart(fileRows [][]string, params *pb_common.TrainModels) ([]byte, error) {
+	model, err := BoostModelFromTrainModels(params)
+	if err != nil {
+		return nil, err
+	}
+	inputs, err := getInputs(fileRows, params.Label)
+	if err != nil {
+		return nil, err
+	}
+
+	directions := make(map[string][]bool)
+	for id, record := range model.Records {
+		dirs := make([]bool, len(inputs))
+		for i, input := range inputs {
+			value, ok := input[record.Feature]
+			if !ok {
+				return nil, fmt.Errorf("file does not contain feature: %s", record.Feature)
+			}
+			dirs[i] = value <= record.Threshold
+		}
+		directions[id] = dirs
+	}
+	return json.Marshal(directions)
+}
+
+// PredictTagPart walks through all trees to calculate predict values, used by tag part
+// otherParts are split directions received from no-tag parties, each returned by PredictLocalPart
+// returns the probability of each sample being positive
+func PredictTagPart(fileRows [][]string, params *pb_common.TrainModels, otherParts [][]byte) ([]float64, error) {
+	model, err := BoostModelFromTrainModels(params)
+	if err != nil {
+		return nil, err
+	}
+	inputs, err := getInputs(fileRows, params.Label)
+	if err != nil {
+		return nil, err
+	}
+
+	directions := make(map[string][]bool)
+	for _, part := range otherParts {
+		var dirs map[string][]bool
+		if err := json.Unmarshal(part, &dirs); err != nil {
+			return nil, fmt.Errorf("failed to parse predict part, err: %v", err)
+		}
+		for id, d := range dirs {
+			if len(d) != len(inputs) {
+				return nil, fmt.Errorf("predict part of record %s has %d samples, expected %d", id, len(d), len(inputs))
+			}
+			directions[id] = d
+		}
+	}
+
+	var values []float64
+	for i, input := range inputs {
+		score := model.InitScore
+		for _, tree := range model.Trees {
+			weight, err := tree.predict(i, input, directions)
+			if err != nil {
+				return nil, err
+			}
+			score += weight
+		}
+		values = append(values, sigmoid(score))
+	}
+	return values, nil
+}
+
+// predict walks from root to leaf for the i-th sample and returns leaf weight
+func (t *Tree) predict(i int, input map[string]float64, directions map[string][]bool) (float64, error) {
+	idx := 0
+	for {
+		if idx < 0 || idx >= len(t.Nodes) {
+			return 0, fmt.Errorf("invalid tree node index: %d", idx)
+		}
+		node := t.Nodes[idx]
+		if node.IsLeaf {
+			return node.Weight, nil
+		}
+
+		var goLeft bool
+		if node.RecordID != "" {
+			dirs, ok := directions[node.RecordID]
+			if !ok {
+				return 0, fmt.Errorf("failed to get split directions of record %s from %s", node.RecordID, node.Owner)
+			}
+			goLeft = dirs[i]
+		} else {
+			value, ok := input[node.Feature]
+			if !ok {
+				return 0, fmt.Errorf("file does not contain feature: %s", node.Feature)
+			}
+			goLeft = value <= node.Threshold
+		}
+
+		if goLeft {
+			idx = node.Left
+		} else {
+			idx = node.Right
+		}
+	}
+}
+
+// getInputs parses feature values of each sample, label column is skipped
+func getInputs(fileRows [][]string, label string) ([]map[string]float64, error) {
+	if len(fileRows) == 0 {
+		return nil, fmt.Errorf("empty file content")
+	}
+	featureList := fileRows[0]
+
+	var inputs []map[string]float64
+	for i := 1; i < len(fileRows); i++ {
+		input := make(map[string]float64)
+		for j := 0; j < len(featureList); j++ {
+			if featureList[j] == label {
+				continue
+			}
+			value, err := strconv.ParseFloat(fileRows[i][j], 64)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse value, err: %v", err)
+			}
+			input[featureList[j]] = value
+		}
+		inputs = append(inputs, input)
+	}
+	return inputs, nil
+}
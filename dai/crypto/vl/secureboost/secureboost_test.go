@@ -0,0 +1,175 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secureboost
+
+import (
+	"io/ioutil"
+	"math"
+	"testing"
+
+	vl_common "github.com/PaddlePaddle/PaddleDTX/dai/crypto/vl/common"
+	"github.com/PaddlePaddle/PaddleDTX/dai/crypto/vl/common/csv"
+	pb_common "github.com/PaddlePaddle/PaddleDTX/dai/protos/common"
+)
+
+type treeNodeTask struct {
+	idx     int
+	depth   int
+	samples []int
+}
+
+func TestSecureBoost(t *testing.T) {
+	fileContentA, err := ioutil.ReadFile("../testdata/logic_iris_plants/train_dataA.csv")
+	checkErr(err, t)
+	fileContentB, err := ioutil.ReadFile("../testdata/logic_iris_plants/train_dataB.csv")
+	checkErr(err, t)
+	fileRowsA, err := csv.ReadRowsFromFile(fileContentA)
+	checkErr(err, t)
+	fileRowsB, err := csv.ReadRowsFromFile(fileContentB)
+	checkErr(err, t)
+
+	// A is no-tag part, B is tag part
+	paramsA := pb_common.TrainParams{
+		Label:     "Label",
+		LabelName: "Iris-versicolor",
+		Alpha:     0.5,
+		Accuracy:  10,
+		TreeNum:   3,
+		MaxDepth:  3,
+	}
+	paramsB := paramsA
+	paramsB.IsTagPart = true
+
+	trainSetA, err := GetTrainDataSetFromFile(fileRowsA, paramsA)
+	checkErr(err, t)
+	trainSetB, err := GetTrainDataSetFromFile(fileRowsB, paramsB)
+	checkErr(err, t)
+	homoPrivB, homoPubB, err := vl_common.GenerateHomoKeyPair()
+	checkErr(err, t)
+
+	modelA := &BoostModel{Records: make(map[string]*SplitRecord)}
+	modelB := &BoostModel{InitScore: InitScore(trainSetB.Labels)}
+	scores := make([]float64, len(trainSetB.Labels))
+	for i := range scores {
+		scores[i] = modelB.InitScore
+	}
+
+	allSamples := make([]int, len(trainSetB.Labels))
+	for i := range allSamples {
+		allSamples[i] = i
+	}
+	for n := 0; n < GetTreeNum(paramsB); n++ {
+		grads, hess := CalGradAndHess(trainSetB.Labels, scores)
+		encGradHessBytes, err := EncGradAndHess(grads, hess, paramsB, &homoPrivB.PublicKey)
+		checkErr(err, t)
+		encGradHess, err := EncGradHessFromBytes(encGradHessBytes)
+		checkErr(err, t)
+
+		tree := &Tree{Nodes: []*TreeNode{{}}}
+		leaves := make(map[int][]int)
+		tasks := []treeNodeTask{{samples: allSamples}}
+		for len(tasks) > 0 {
+			task := tasks[0]
+			tasks = tasks[1:]
+			node := tree.Nodes[task.idx]
+
+			var left, right []int
+			if task.depth < GetMaxDepth(paramsB) {
+				histsB := CalHistograms(grads, hess, trainSetB, task.samples)
+				splitB := FindBestSplit(histsB, paramsB)
+
+				encHistsA, err := CalEncHistograms(encGradHess, trainSetA, task.samples, homoPubB)
+				checkErr(err, t)
+				histsA, err := DecHistograms(encHistsA, paramsB, homoPrivB)
+				checkErr(err, t)
+				expected := CalHistograms(grads, hess, trainSetA, task.samples)
+				for f := range expected {
+					for b := range expected[f].Grads {
+						if math.Abs(expected[f].Grads[b]-histsA[f].Grads[b]) > 1e-6 || math.Abs(expected[f].Hess[b]-histsA[f].Hess[b]) > 1e-6 {
+							t.Fatalf("histogram of feature %d bin %d mismatched", f, b)
+						}
+					}
+				}
+				splitA := FindBestSplit(histsA, paramsB)
+
+				if splitA != nil && (splitB == nil || splitA.Gain > splitB.Gain) {
+					id, record, err := NewSplitRecord(trainSetA, splitA.FeatureIdx, splitA.BinIdx)
+					checkErr(err, t)
+					modelA.Records[id] = record
+					node.Owner, node.RecordID = "A", id
+					left, right, err = SplitSamples(trainSetA, task.samples, splitA.FeatureIdx, splitA.BinIdx)
+					checkErr(err, t)
+				} else if splitB != nil {
+					node.Feature = trainSetB.FeatureNames[splitB.FeatureIdx]
+					node.Threshold = trainSetB.Thresholds[splitB.FeatureIdx][splitB.BinIdx]
+					left, right, err = SplitSamples(trainSetB, task.samples, splitB.FeatureIdx, splitB.BinIdx)
+					checkErr(err, t)
+				}
+			}
+
+			if len(left) == 0 || len(right) == 0 {
+				node.IsLeaf = true
+				node.Weight = LeafWeight(grads, hess, task.samples, paramsB)
+				leaves[task.idx] = task.samples
+				continue
+			}
+			node.Left, node.Right = len(tree.Nodes), len(tree.Nodes)+1
+			tree.Nodes = append(tree.Nodes, &TreeNode{}, &TreeNode{})
+			tasks = append(tasks, treeNodeTask{idx: node.Left, depth: task.depth + 1, samples: left},
+				treeNodeTask{idx: node.Right, depth: task.depth + 1, samples: right})
+		}
+
+		UpdateScores(scores, tree, leaves)
+		modelB.Trees = append(modelB.Trees, tree)
+	}
+
+	modelBytesA, err := TrainModelsToBytes(modelA, paramsA)
+	checkErr(err, t)
+	modelBytesB, err := TrainModelsToBytes(modelB, paramsB)
+	checkErr(err, t)
+	t.Logf("model A: %s\n", modelBytesA)
+	t.Logf("model B: %s\n", modelBytesB)
+
+	// predict on train set, the outcome should be consistent with training scores
+	trainModelsA, err := vl_common.TrainModelsFromBytes(modelBytesA)
+	checkErr(err, t)
+	trainModelsB, err := vl_common.TrainModelsFromBytes(modelBytesB)
+	checkErr(err, t)
+	predictA, err := PredictLocalPart(fileRowsA, trainModelsA)
+	checkErr(err, t)
+	outcomes, err := PredictTagPart(fileRowsB, trainModelsB, [][]byte{predictA})
+	checkErr(err, t)
+
+	correct := 0
+	for i, outcome := range outcomes {
+		if math.Abs(outcome-sigmoid(scores[i])) > 1e-9 {
+			t.Fatalf("sample %d, expected %v, got %v", i, sigmoid(scores[i]), outcome)
+		}
+		if (outcome > 0.5) == (trainSetB.Labels[i] == 1) {
+			correct++
+		}
+	}
+	accuracy := float64(correct) / float64(len(outcomes))
+	t.Logf("accuracy on train set: %v", accuracy)
+	if accuracy < 0.9 {
+		t.Fatalf("accuracy %v is too low", accuracy)
+	}
+}
+
+func checkErr(err error, t *testing.T) {
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+}
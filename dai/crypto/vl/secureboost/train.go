@@ -0,0 +1,476 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secureboost
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/common/math/homomorphism/paillier"
+
+	vl_common "github.com/PaddlePaddle/PaddleDTX/dai/crypto/vl/common"
+	pb_common "github.com/PaddlePaddle/PaddleDTX/dai/protos/common"
+)
+
+const (
+	defaultTreeNum      = 5
+	defaultMaxDepth     = 3
+	defaultLearningRate = 0.3
+	defaultLambda       = 1.0
+	defaultAccuracy     = 10
+
+	// maxBins is the max number of bins each feature is divided into
+	maxBins = 16
+	// minChildHess is the min sum of hessians of a child node after split
+	minChildHess = 1e-3
+)
+
+// TrainDataSet is the local train set of a party, features are divided into bins
+type TrainDataSet struct {
+	FeatureNames []string
+	// Thresholds are split thresholds of each feature, a sample whose value is no greater than Thresholds[f][b] falls into bin b or lower
+	Thresholds [][]float64
+	// Bins are bin indexes of each sample on each feature, Bins[sample][feature]
+	Bins [][]int
+	// Labels are 1 or 0 for each sample, only kept by tag part
+	Labels []float64
+}
+
+// SplitInfo is the best split found in histograms of a tree node
+type SplitInfo struct {
+	FeatureIdx int
+	BinIdx     int
+	Gain       float64
+}
+
+// Histogram is the sums of gradients and hessians of samples in each bin of a feature
+type Histogram struct {
+	Grads []float64
+	Hess  []float64
+}
+
+// EncHistogram is the encrypted sums of gradients and hessians of samples in each bin of a feature
+type EncHistogram struct {
+	Grads []*big.Int
+	Hess  []*big.Int
+}
+
+// EncGradHess is the encrypted gradients and hessians of all samples, sent by tag part
+type EncGradHess struct {
+	Grads []*big.Int
+	Hess  []*big.Int
+}
+
+// GetTreeNum returns the number of trees to train
+func GetTreeNum(params pb_common.TrainParams) int {
+	if params.TreeNum <= 0 {
+		return defaultTreeNum
+	}
+	return int(params.TreeNum)
+}
+
+// GetMaxDepth returns the max depth of each tree
+func GetMaxDepth(params pb_common.TrainParams) int {
+	if params.MaxDepth <= 0 {
+		return defaultMaxDepth
+	}
+	return int(params.MaxDepth)
+}
+
+func getLearningRate(params pb_common.TrainParams) float64 {
+	if params.Alpha <= 0 {
+		return defaultLearningRate
+	}
+	return params.Alpha
+}
+
+func getLambda(params pb_common.TrainParams) float64 {
+	if params.RegParam <= 0 {
+		return defaultLambda
+	}
+	return params.RegParam
+}
+
+func getAccuracy(params pb_common.TrainParams) int {
+	if params.Accuracy <= 0 {
+		return defaultAccuracy
+	}
+	return int(params.Accuracy)
+}
+
+// GetTrainDataSetFromFile retrieve train dataset from file for tag/no-tag part
+// fileRows is sample rows, first row is feature list, others are values for each sample
+// label column is converted to 1 if its value equals to params.LabelName, otherwise 0
+func GetTrainDataSetFromFile(fileRows [][]string, params pb_common.TrainParams) (*TrainDataSet, error) {
+	if len(fileRows) < 2 {
+		return nil, fmt.Errorf("empty train set")
+	}
+	if params.IsTagPart && params.LabelName == "" {
+		return nil, fmt.Errorf("labelName is required for tag part")
+	}
+
+	labelIdx := -1
+	var featureIdxs []int
+	var featureNames []string
+	for i, name := range fileRows[0] {
+		if name == params.Label {
+			labelIdx = i
+			continue
+		}
+		featureIdxs = append(featureIdxs, i)
+		featureNames = append(featureNames, name)
+	}
+	if params.IsTagPart && labelIdx < 0 {
+		return nil, fmt.Errorf("file does not contain label: %s", params.Label)
+	}
+
+	sampleNum := len(fileRows) - 1
+	values := make([][]float64, len(featureIdxs))
+	for f := range values {
+		values[f] = make([]float64, sampleNum)
+	}
+	var labels []float64
+	for i := 0; i < sampleNum; i++ {
+		row := fileRows[i+1]
+		for f, idx := range featureIdxs {
+			value, err := strconv.ParseFloat(row[idx], 64)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse value, err: %v", err)
+			}
+			values[f][i] = value
+		}
+		if params.IsTagPart {
+			label := 0.0
+			if row[labelIdx] == params.LabelName {
+				label = 1
+			}
+			labels = append(labels, label)
+		}
+	}
+
+	dataSet := &TrainDataSet{
+		FeatureNames: featureNames,
+		Thresholds:   make([][]float64, len(featureIdxs)),
+		Bins:         make([][]int, sampleNum),
+		Labels:       labels,
+	}
+	for i := range dataSet.Bins {
+		dataSet.Bins[i] = make([]int, len(featureIdxs))
+	}
+	for f := range values {
+		dataSet.Thresholds[f] = getThresholds(values[f])
+		for i, value := range values[f] {
+			dataSet.Bins[i][f] = sort.SearchFloat64s(dataSet.Thresholds[f], value)
+		}
+	}
+	return dataSet, nil
+}
+
+// getThresholds divides values into at most maxBins bins by quantiles,
+// the max value is never used as a threshold so that no bin is empty on the right side
+func getThresholds(values []float64) []float64 {
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	var distinct []float64
+	for i, v := range sorted {
+		if i == 0 || v != sorted[i-1] {
+			distinct = append(distinct, v)
+		}
+	}
+	if len(distinct) <= maxBins {
+		return distinct[:len(distinct)-1]
+	}
+
+	var thresholds []float64
+	for b := 1; b < maxBins; b++ {
+		t := sorted[b*len(sorted)/maxBins]
+		if t == sorted[len(sorted)-1] {
+			break
+		}
+		if len(thresholds) == 0 || t > thresholds[len(thresholds)-1] {
+			thresholds = append(thresholds, t)
+		}
+	}
+	return thresholds
+}
+
+// InitScore calculates the initial raw score of all samples, that is the log-odds of positive labels
+func InitScore(labels []float64) float64 {
+	sum := 0.0
+	for _, label := range labels {
+		sum += label
+	}
+	mean := sum / float64(len(labels))
+	mean = math.Min(math.Max(mean, 1e-6), 1-1e-6)
+	return math.Log(mean / (1 - mean))
+}
+
+// CalGradAndHess calculates first and second order gradients of logistic loss on raw scores
+func CalGradAndHess(labels, scores []float64) ([]float64, []float64) {
+	grads := make([]float64, len(labels))
+	hess := make([]float64, len(labels))
+	for i := range labels {
+		p := sigmoid(scores[i])
+		grads[i] = p - labels[i]
+		hess[i] = p * (1 - p)
+	}
+	return grads, hess
+}
+
+// EncGradAndHess encrypts gradients and hessians by tag part's homomorphic public key, then converts them to bytes for transfer
+func EncGradAndHess(grads, hess []float64, params pb_common.TrainParams, publicKey *paillier.PublicKey) ([]byte, error) {
+	accuracy := getAccuracy(params)
+	encGradHess := &EncGradHess{
+		Grads: make([]*big.Int, len(grads)),
+		Hess:  make([]*big.Int, len(hess)),
+	}
+	for i := range grads {
+		encGrad, err := publicKey.EncryptSupNegNum(encode(grads[i], accuracy))
+		if err != nil {
+			return nil, err
+		}
+		encHess, err := publicKey.EncryptSupNegNum(encode(hess[i], accuracy))
+		if err != nil {
+			return nil, err
+		}
+		encGradHess.Grads[i] = encGrad
+		encGradHess.Hess[i] = encHess
+	}
+	return json.Marshal(encGradHess)
+}
+
+// EncGradHessFromBytes retrieve encrypted gradients and hessians from bytes
+func EncGradHessFromBytes(encGradHessBytes []byte) (*EncGradHess, error) {
+	var encGradHess EncGradHess
+	if err := json.Unmarshal(encGradHessBytes, &encGradHess); err != nil {
+		return nil, err
+	}
+	return &encGradHess, nil
+}
+
+// CalEncHistograms calculates encrypted histograms of local features for samples of a tree node, used by no-tag part
+// encGradHess is received from tag part, and publicKeyBytes is tag part's homomorphic public key
+func CalEncHistograms(encGradHess *EncGradHess, trainSet *TrainDataSet, samples []int, publicKeyBytes []byte) ([]byte, error) {
+	publicKey, err := vl_common.HomoPubkeyFromBytes(publicKeyBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	var hists []*EncHistogram
+	for f := range trainSet.FeatureNames {
+		binNum := len(trainSet.Thresholds[f]) + 1
+		gradBins := make([][]*big.Int, binNum)
+		hessBins := make([][]*big.Int, binNum)
+		for _, s := range samples {
+			if s < 0 || s >= len(trainSet.Bins) || s >= len(encGradHess.Grads) {
+				return nil, fmt.Errorf("invalid sample index: %d", s)
+			}
+			b := trainSet.Bins[s][f]
+			gradBins[b] = append(gradBins[b], encGradHess.Grads[s])
+			hessBins[b] = append(hessBins[b], encGradHess.Hess[s])
+		}
+
+		hist := &EncHistogram{
+			Grads: make([]*big.Int, binNum),
+			Hess:  make([]*big.Int, binNum),
+		}
+		for b := 0; b < binNum; b++ {
+			hist.Grads[b] = publicKey.CyphersAdd(gradBins[b]...)
+			hist.Hess[b] = publicKey.CyphersAdd(hessBins[b]...)
+		}
+		hists = append(hists, hist)
+	}
+	return json.Marshal(hists)
+}
+
+// DecHistograms decrypts histograms received from no-tag part by tag part's homomorphic private key
+func DecHistograms(encHistsBytes []byte, params pb_common.TrainParams, privateKey *paillier.PrivateKey) ([]*Histogram, error) {
+	var encHists []*EncHistogram
+	if err := json.Unmarshal(encHistsBytes, &encHists); err != nil {
+		return nil, err
+	}
+
+	accuracy := getAccuracy(params)
+	var hists []*Histogram
+	for _, encHist := range encHists {
+		if len(encHist.Grads) != len(encHist.Hess) {
+			return nil, fmt.Errorf("invalid histogram with %d gradients and %d hessians", len(encHist.Grads), len(encHist.Hess))
+		}
+		hist := &Histogram{
+			Grads: make([]float64, len(encHist.Grads)),
+			Hess:  make([]float64, len(encHist.Hess)),
+		}
+		for b := range encHist.Grads {
+			hist.Grads[b] = decode(privateKey.DecryptSupNegNum(encHist.Grads[b]), accuracy)
+			hist.Hess[b] = decode(privateKey.DecryptSupNegNum(encHist.Hess[b]), accuracy)
+		}
+		hists = append(hists, hist)
+	}
+	return hists, nil
+}
+
+// CalHistograms calculates plaintext histograms of local features for samples of a tree node, used by tag part
+func CalHistograms(grads, hess []float64, trainSet *TrainDataSet, samples []int) []*Histogram {
+	var hists []*Histogram
+	for f := range trainSet.FeatureNames {
+		binNum := len(trainSet.Thresholds[f]) + 1
+		hist := &Histogram{
+			Grads: make([]float64, binNum),
+			Hess:  make([]float64, binNum),
+		}
+		for _, s := range samples {
+			b := trainSet.Bins[s][f]
+			hist.Grads[b] += grads[s]
+			hist.Hess[b] += hess[s]
+		}
+		hists = append(hists, hist)
+	}
+	return hists
+}
+
+// FindBestSplit finds the split with max gain in histograms, returns nil if no split reduces loss
+// gain = (GL^2/(HL+λ) + GR^2/(HR+λ) - G^2/(H+λ)) / 2
+func FindBestSplit(hists []*Histogram, params pb_common.TrainParams) *SplitInfo {
+	lambda := getLambda(params)
+
+	var best *SplitInfo
+	for f, hist := range hists {
+		var g, h float64
+		for b := range hist.Grads {
+			g += hist.Grads[b]
+			h += hist.Hess[b]
+		}
+
+		var gl, hl float64
+		for b := 0; b < len(hist.Grads)-1; b++ {
+			gl += hist.Grads[b]
+			hl += hist.Hess[b]
+			gr, hr := g-gl, h-hl
+			if hl < minChildHess || hr < minChildHess {
+				continue
+			}
+			gain := (gl*gl/(hl+lambda) + gr*gr/(hr+lambda) - g*g/(h+lambda)) / 2
+			if gain > 0 && (best == nil || gain > best.Gain) {
+				best = &SplitInfo{
+					FeatureIdx: f,
+					BinIdx:     b,
+					Gain:       gain,
+				}
+			}
+		}
+	}
+	return best
+}
+
+// SplitSamples divides samples of a tree node into left and right child by local feature and bin
+func SplitSamples(trainSet *TrainDataSet, samples []int, featureIdx, binIdx int) ([]int, []int, error) {
+	if featureIdx < 0 || featureIdx >= len(trainSet.FeatureNames) {
+		return nil, nil, fmt.Errorf("invalid feature index: %d", featureIdx)
+	}
+	if binIdx < 0 || binIdx >= len(trainSet.Thresholds[featureIdx]) {
+		return nil, nil, fmt.Errorf("invalid bin index: %d", binIdx)
+	}
+
+	var left, right []int
+	for _, s := range samples {
+		if s < 0 || s >= len(trainSet.Bins) {
+			return nil, nil, fmt.Errorf("invalid sample index: %d", s)
+		}
+		if trainSet.Bins[s][featureIdx] <= binIdx {
+			left = append(left, s)
+		} else {
+			right = append(right, s)
+		}
+	}
+	return left, right, nil
+}
+
+// NewSplitRecord creates a split record on local feature and bin with a random ID, used by no-tag part
+// the record is kept locally, only its ID is sent to tag part
+func NewSplitRecord(trainSet *TrainDataSet, featureIdx, binIdx int) (string, *SplitRecord, error) {
+	if featureIdx < 0 || featureIdx >= len(trainSet.FeatureNames) {
+		return "", nil, fmt.Errorf("invalid feature index: %d", featureIdx)
+	}
+	if binIdx < 0 || binIdx >= len(trainSet.Thresholds[featureIdx]) {
+		return "", nil, fmt.Errorf("invalid bin index: %d", binIdx)
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", nil, err
+	}
+	record := &SplitRecord{
+		Feature:   trainSet.FeatureNames[featureIdx],
+		Threshold: trainSet.Thresholds[featureIdx][binIdx],
+	}
+	return hex.EncodeToString(id), record, nil
+}
+
+// LeafWeight calculates the weight of a leaf node, shrunk by learning rate
+// weight = -G/(H+λ) * α
+func LeafWeight(grads, hess []float64, samples []int, params pb_common.TrainParams) float64 {
+	var g, h float64
+	for _, s := range samples {
+		g += grads[s]
+		h += hess[s]
+	}
+	return -g / (h + getLambda(params)) * getLearningRate(params)
+}
+
+// UpdateScores adds leaf weights of a new tree to raw scores of samples
+// leaves maps leaf node index to samples falling into it
+func UpdateScores(scores []float64, tree *Tree, leaves map[int][]int) {
+	for idx, samples := range leaves {
+		for _, s := range samples {
+			scores[s] += tree.Nodes[idx].Weight
+		}
+	}
+}
+
+// SamplesToBytes convert sample indexes of a tree node to bytes for transfer
+func SamplesToBytes(samples []int) ([]byte, error) {
+	return json.Marshal(samples)
+}
+
+// SamplesFromBytes retrieve sample indexes of a tree node from bytes
+func SamplesFromBytes(samplesBytes []byte) ([]int, error) {
+	var samples []int
+	if err := json.Unmarshal(samplesBytes, &samples); err != nil {
+		return nil, err
+	}
+	return samples, nil
+}
+
+// encode scales a float number to big integer by accuracy for homomorphic encryption
+func encode(value float64, accuracy int) *big.Int {
+	return big.NewInt(int64(math.Round(value * math.Pow10(accuracy))))
+}
+
+// decode recovers a float number from big integer scaled by accuracy
+func decode(value *big.Int, accuracy int) float64 {
+	f, _ := new(big.Float).SetInt(value).Float64()
+	return f / math.Pow10(accuracy)
+}
+
+func sigmoid(x float64) float64 {
+	return 1 / (1 + math.Exp(-x))
+}
@@ -46,8 +46,12 @@ var getByIDCmd = &cobra.Command{
 			t.TaskID, t.Requester, blockchain.TaskTypeListValue[t.AlgoParam.TaskType], t.Name, t.Description, t.AlgoParam.TrainParams.Label,
 			t.AlgoParam.TrainParams.LabelName, blockchain.RegModeListValue[t.AlgoParam.TrainParams.RegMode], t.AlgoParam.TrainParams.RegParam)
 
+		algoName := blockchain.VlAlgorithmListValue[t.AlgoParam.Algo]
+		if name, ok := blockchain.HlAlgorithmListValue[t.AlgoParam.Algo]; ok {
+			algoName = name
+		}
 		fmt.Printf("Algorithm: %v\nAlpha: %f\nAmplitude: %f\nAccuracy: %v\nModelTaskID: %s\nStatus: %s\nPublishTime: %s\n\n",
-			blockchain.VlAlgorithmListValue[t.AlgoParam.Algo], t.AlgoParam.TrainParams.Alpha, t.AlgoParam.TrainParams.Amplitude,
+			algoName, t.AlgoParam.TrainParams.Alpha, t.AlgoParam.TrainParams.Amplitude,
 			t.AlgoParam.TrainParams.Accuracy, t.AlgoParam.ModelTaskID, t.Status, ptime)
 
 		if t.AlgoParam.EvalParams != nil && t.AlgoParam.EvalParams.Enable {
@@ -64,6 +68,11 @@ var getByIDCmd = &cobra.Command{
 			}
 		}
 
+		if dp := t.AlgoParam.TrainParams.GetDpParams(); dp.GetEnable() {
+			fmt.Printf("DifferentialPrivacy: true\nEpsilon: %v\nDelta: %v\nClipNorm: %v\nMaxRounds: %d\n\n",
+				dp.Epsilon, dp.Delta, dp.ClipNorm, dp.MaxRounds)
+		}
+
 		fmt.Println("Task data sets: ")
 
 		for _, d := range t.DataSets {
@@ -74,8 +83,8 @@ var getByIDCmd = &cobra.Command{
 			if d.RejectedAt > 0 {
 				rt = time.Unix(0, d.RejectedAt).Format(timeTemplate)
 			}
-			fmt.Printf("DataID: %s\nOwner: %x\nExecutor: %x\nAddress: %s\nPSILabel: %s\nConfirmedAt: %s\nRejectedAt: %s\n\n",
-				d.DataID, d.Owner, d.Executor, d.Address, d.PsiLabel, ct, rt)
+			fmt.Printf("DataID: %s\nDataVersion: %d\nOwner: %x\nExecutor: %x\nAddress: %s\nPSILabel: %s\nConfirmedAt: %s\nRejectedAt: %s\n\n",
+				d.DataID, d.DataVersion, d.Owner, d.Executor, d.Address, d.PsiLabel, ct, rt)
 		}
 
 		startTime := time.Unix(0, t.StartTime).Format(timeTemplate)
@@ -22,6 +22,7 @@ import (
 	"github.com/PaddlePaddle/PaddleDTX/xdb/peer"
 
 	"github.com/PaddlePaddle/PaddleDTX/dai/blockchain/fabric"
+	localchain "github.com/PaddlePaddle/PaddleDTX/dai/blockchain/local"
 	"github.com/PaddlePaddle/PaddleDTX/dai/blockchain/xchain"
 	"github.com/PaddlePaddle/PaddleDTX/dai/config"
 	"github.com/PaddlePaddle/PaddleDTX/dai/executor/handler"
@@ -95,6 +96,8 @@ func newBlockchain(conf *config.ExecutorBlockchainConf) (b handler.Blockchain, e
 		b, err = xchain.New(conf.Xchain)
 	case "fabric":
 		b, err = fabric.New(conf.Fabric)
+	case "local":
+		b, err = localchain.New(conf.Local)
 	default:
 		return b, errorx.New(errorx.ErrCodeConfig, "invalid blockchain type: %s", conf.Type)
 	}
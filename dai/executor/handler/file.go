@@ -3,13 +3,13 @@ package handler
 import (
 	"bytes"
 	"context"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"time"
 
-	"github.com/cjqpker/slidewindow"
 	"github.com/sirupsen/logrus"
 
 	"github.com/PaddlePaddle/PaddleDTX/crypto/core/aes"
@@ -18,15 +18,16 @@ import (
 	"github.com/PaddlePaddle/PaddleDTX/crypto/core/hash"
 	"github.com/PaddlePaddle/PaddleDTX/dai/executor/storage/xuperdb"
 	xdbchain "github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/client/e2e"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/common"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/encryptor/chunk"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/encryptor/seal"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/types"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/pkgs/http"
 	util "github.com/PaddlePaddle/PaddleDTX/xdb/pkgs/strings"
 )
 
-var defaultConcurrency uint64 = 10
-
 // Define the ExecutionType of the executor, used to download sample files during task training
 //  ProxyExecutionMode indicates to execute tasks using others' data
 //  SelfExecutionMode indicates to execute tasks using own data
@@ -35,6 +36,12 @@ const (
 	SelfExecutionMode  = "Self"
 )
 
+// sampleHeaderRangeSize is the length of each range downloaded when reading the header of a sample file
+const sampleHeaderRangeSize = 4096
+
+// downloads records downloads of sample files read through by the executor, see common.DownloadRecorder
+var downloads = common.NewDownloadRecorder()
+
 // Storage files operations, read and write
 //  supports local storage and xuperdb storage
 type Storage interface {
@@ -71,239 +78,467 @@ func (f *FileDownload) GetSampleFile(fileID string, chain Blockchain) (io.ReadCl
 		}
 		return plainText, nil
 	} else {
-		// 1. get the sample file info from chain
-		file, err := chain.GetFileByID(fileID)
+		file, authID, firstKey, secKey, chunkKey, e2eKey, err := f.getAuthorizedFile(fileID, chain)
 		if err != nil {
-			return nil, errorx.New(errorx.ErrCodeInternal, "failed to get the sample file from contract, fileID: %s", fileID)
+			return nil, err
 		}
-		// 2. get the authorization ID, use the authKey to decrypt the sample file
-		pubkey := ecdsa.PublicKeyFromPrivateKey(f.NodePrivateKey)
-		fileAuths, err := chain.ListFileAuthApplications(&xdbchain.ListFileAuthOptions{
-			Applier:    pubkey[:],
-			Authorizer: file.Owner,
-			Status:     xdbchain.FileAuthApproved,
-			FileID:     fileID,
-			TimeStart:  0,
-			TimeEnd:    time.Now().UnixNano(),
-			Limit:      1,
-		})
+		// download slices and decrypt
+		plainText, err := f.recoverFile(context.Background(), chain, file, firstKey, secKey, chunkKey)
 		if err != nil {
-			return nil, errorx.Wrap(err,
-				"get the file authorization application failed, fileID: %s, Applier: %x, Authorizer: %x", fileID, pubkey[:], file.Owner)
+			return nil, err
+		}
+		if file.ClientEncrypted {
+			plainText, err = decryptClientEncrypted(plainText, e2eKey)
+			if err != nil {
+				return nil, err
+			}
 		}
-		if len(fileAuths) == 0 {
-			return nil, errorx.New(errorx.ErrCodeInternal,
-				"the file authorization application is empty, fileID: %s, Applier: %x, Authorizer: %x", fileID, pubkey[:], file.Owner)
+		return f.trackDownload(chain, fileID, authID, plainText), nil
+	}
+}
+
+// GetSampleFileRange downloads a range of the sample file, length 0 means reading to the end of the file.
+// If f.Type is 'Proxy', only slices covering the range are downloaded from storage nodes,
+// except that client encrypted file is downloaded and decrypted as a whole
+func (f *FileDownload) GetSampleFileRange(fileID string, offset, length uint64, chain Blockchain) (io.ReadCloser, error) {
+	if f.Type == SelfExecutionMode {
+		xuperdbClient := xuperdb.New(0, "", f.Host, f.PrivateKey)
+		plainText, err := xuperdbClient.ReadRange(fileID, offset, length)
+		if err != nil {
+			return nil, errorx.Wrap(err, "failed to download the sample file from the dataOwner node, fileID: %s", fileID)
+		}
+		return plainText, nil
+	}
+	file, authID, firstKey, secKey, chunkKey, e2eKey, err := f.getAuthorizedFile(fileID, chain)
+	if err != nil {
+		return nil, err
+	}
+	if !file.ClientEncrypted {
+		r, err := f.recoverFileRange(context.Background(), chain, file, firstKey, secKey, chunkKey, offset, length)
+		if err != nil {
+			return nil, err
 		}
-		// 3. obtain the derived key needed to decrypt the file through the AuthKey
-		firstKey, secKey, err := f.getDecryptAuthKey(fileAuths[0].AuthKey)
+		return f.trackDownload(chain, fileID, authID, r), nil
+	}
+
+	// the range is located in plaintext, which is known only after decryption
+	cipherText, err := f.recoverFile(context.Background(), chain, file, firstKey, secKey, chunkKey)
+	if err != nil {
+		return nil, err
+	}
+	plainText, err := decryptClientEncrypted(cipherText, e2eKey)
+	if err != nil {
+		return nil, err
+	}
+	defer plainText.Close()
+	content, err := ioutil.ReadAll(plainText)
+	if err != nil {
+		return nil, errorx.NewCode(err, errorx.ErrCodeCrypto, "failed to decrypt the client encrypted file")
+	}
+	if offset >= uint64(len(content)) {
+		return nil, errorx.New(errorx.ErrCodeParam, "offset %d out of range, file length %d", offset, len(content))
+	}
+	total := uint64(len(content))
+	content = content[offset:]
+	if length > 0 && length < uint64(len(content)) {
+		content = content[:length]
+	}
+	r := &types.RangeReader{
+		ReadCloser: ioutil.NopCloser(bytes.NewReader(content)),
+		Offset:     offset,
+		Length:     uint64(len(content)),
+		Total:      total,
+	}
+	return f.trackDownload(chain, fileID, authID, r), nil
+}
+
+// GetSampleFileHeader reads column names of the CSV sample file, only the beginning of the file
+// is downloaded range by range, until the first line is complete.
+// Client encrypted file is downloaded as a whole, as its ranges can't be decrypted alone
+func (f *FileDownload) GetSampleFileHeader(fileID string, chain Blockchain) ([]string, error) {
+	file, err := chain.GetFileByID(fileID)
+	if err != nil {
+		return nil, errorx.New(errorx.ErrCodeInternal, "failed to get the sample file from contract, fileID: %s", fileID)
+	}
+	if file.ClientEncrypted && f.Type != SelfExecutionMode {
+		r, err := f.GetSampleFile(fileID, chain)
 		if err != nil {
 			return nil, err
 		}
-		// 4. download slices and decrypt
-		plainText, err := f.recoverFile(context.Background(), chain, file, firstKey, secKey)
+		defer r.Close()
+		header, err := csv.NewReader(r).Read()
+		if err != nil {
+			return nil, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to parse the header of the sample file, fileID: %s", fileID)
+		}
+		return header, nil
+	}
+	var content []byte
+	for uint64(len(content)) < file.Length {
+		r, err := f.GetSampleFileRange(fileID, uint64(len(content)), sampleHeaderRangeSize, chain)
 		if err != nil {
 			return nil, err
 		}
-		return plainText, nil
+		data, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			return nil, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to read the sample file")
+		}
+		if len(data) == 0 {
+			break
+		}
+		content = append(content, data...)
+		if i := bytes.IndexByte(content, '\n'); i >= 0 {
+			content = content[:i]
+			break
+		}
+	}
+	header, err := csv.NewReader(bytes.NewReader(content)).Read()
+	if err != nil {
+		return nil, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to parse the header of the sample file, fileID: %s", fileID)
+	}
+	return header, nil
+}
+
+// getAuthorizedFile gets the sample file info and keys to decrypt it, only after the file owner
+// has confirmed the executor's file authorization application, the keys can be obtained.
+// authID is the ID of the approved application, e2eKey is the file key of client encrypted file, nil for other files
+func (f *FileDownload) getAuthorizedFile(fileID string, chain Blockchain) (file xdbchain.File, authID string,
+	firstKey aes.AESKey, secKey map[string]map[string]aes.AESKey, chunkKey map[string]aes.AESKey, e2eKey []byte, err error) {
+	// 1. get the sample file info from chain
+	file, err = chain.GetFileByID(fileID)
+	if err != nil {
+		return file, "", firstKey, nil, nil, nil, errorx.New(errorx.ErrCodeInternal, "failed to get the sample file from contract, fileID: %s", fileID)
+	}
+	// 2. refuse to download the sample file if the authorization has been revoked
+	revoked, err := f.IsFileAuthRevoked(fileID, file.Owner, chain)
+	if err != nil {
+		return file, "", firstKey, nil, nil, nil, err
+	}
+	if revoked {
+		return file, "", firstKey, nil, nil, nil, errorx.New(errorx.ErrCodeNotAuthorized,
+			"the file authorization application has been revoked by the file owner, fileID: %s", fileID)
+	}
+	// 3. get the authorization ID, use the authKey to decrypt the sample file
+	pubkey := ecdsa.PublicKeyFromPrivateKey(f.NodePrivateKey)
+	fileAuths, err := chain.ListFileAuthApplications(&xdbchain.ListFileAuthOptions{
+		Applier:    pubkey[:],
+		Authorizer: file.Owner,
+		Status:     xdbchain.FileAuthApproved,
+		FileID:     fileID,
+		TimeStart:  0,
+		TimeEnd:    time.Now().UnixNano(),
+		Limit:      1,
+	})
+	if err != nil {
+		return file, "", firstKey, nil, nil, nil, errorx.Wrap(err,
+			"get the file authorization application failed, fileID: %s, Applier: %x, Authorizer: %x", fileID, pubkey[:], file.Owner)
+	}
+	if len(fileAuths) == 0 {
+		return file, "", firstKey, nil, nil, nil, errorx.New(errorx.ErrCodeInternal,
+			"the file authorization application is empty, fileID: %s, Applier: %x, Authorizer: %x", fileID, pubkey[:], file.Owner)
+	}
+	// 4. obtain the derived key needed to decrypt the file through the AuthKey
+	firstKey, secKey, chunkKey, e2eKey, err = f.getDecryptAuthKey(fileAuths[0].AuthKey)
+	if err == nil && file.ClientEncrypted && e2eKey == nil {
+		err = errorx.New(errorx.ErrCodeNotAuthorized, "no file key is authorized for the client encrypted file, fileID: %s", fileID)
+	}
+	return file, fileAuths[0].ID, firstKey, secKey, chunkKey, e2eKey, err
+}
+
+// trackDownload records the download of the sample file once r is read through,
+// at most once in common.DownloadRecordWindow for the file and the executor
+func (f *FileDownload) trackDownload(chain Blockchain, fileID, authID string, r io.ReadCloser) io.ReadCloser {
+	pubkey := ecdsa.PublicKeyFromPrivateKey(f.NodePrivateKey)
+	return downloads.Track(fileID+"/"+pubkey.String(), r, func() {
+		f.recordFileDownload(chain, fileID, authID)
+	})
+}
+
+// recordFileDownload records a download of the sample file by the executor in its audit trail,
+// the download is authorized by the application authID. Failures are only logged
+func (f *FileDownload) recordFileDownload(chain Blockchain, fileID, authID string) {
+	pubkey := ecdsa.PublicKeyFromPrivateKey(f.NodePrivateKey)
+	opt := &xdbchain.RecordFileDownloadOptions{
+		FileID:      fileID,
+		User:        pubkey[:],
+		AuthID:      authID,
+		CurrentTime: time.Now().UnixNano(),
+	}
+	l := logger.WithField("file_id", fileID)
+	msg, err := util.GetSigMessage(opt)
+	if err != nil {
+		l.WithError(err).Warn("failed to get the message to sign for download record")
+		return
+	}
+	sig, err := ecdsa.Sign(f.NodePrivateKey, hash.HashUsingSha256([]byte(msg)))
+	if err != nil {
+		l.WithError(err).Warn("failed to sign download record")
+		return
+	}
+	opt.Signature = sig[:]
+	if err := chain.RecordFileDownload(opt); err != nil {
+		l.WithError(err).Warn("failed to record file download on blockchain")
+	}
+}
+
+// IsFileAuthRevoked checks whether the file owner has revoked the executor node's authorization for the sample file,
+// all applications of the executor for the file are checked, as any approved one may be revoked
+func (f *FileDownload) IsFileAuthRevoked(fileID string, owner []byte, chain Blockchain) (bool, error) {
+	pubkey := ecdsa.PublicKeyFromPrivateKey(f.NodePrivateKey)
+	now := time.Now().UnixNano()
+	fileAuths, err := chain.ListFileAuthApplications(&xdbchain.ListFileAuthOptions{
+		Applier:    pubkey[:],
+		Authorizer: owner,
+		FileID:     fileID,
+		TimeStart:  0,
+		TimeEnd:    now,
+	})
+	if err != nil {
+		return false, errorx.Wrap(err,
+			"get the file authorization application failed, fileID: %s, Applier: %x, Authorizer: %x", fileID, pubkey[:], owner)
+	}
+	return FileAuthsRevoked(fileAuths, now), nil
+}
+
+// FileAuthsRevoked checks whether the authorization is revoked by the applications of an applier for a file,
+// it's revoked if any application was revoked, and no application has been approved again after the latest revocation
+func FileAuthsRevoked(fileAuths xdbchain.FileAuthApplications, now int64) bool {
+	var revokeTime int64
+	for _, fa := range fileAuths {
+		if fa.Status == xdbchain.FileAuthRevoked && fa.RevokeTime > revokeTime {
+			revokeTime = fa.RevokeTime
+		}
+	}
+	if revokeTime == 0 {
+		return false
+	}
+	for _, fa := range fileAuths {
+		if fa.Status == xdbchain.FileAuthApproved && fa.ApprovalTime > revokeTime && fa.ExpireTime > now {
+			return false
+		}
 	}
+	return true
 }
 
 // getDecryptAuthKey get the authorization key for file decryption, return firKey and secKey.
 // firKey used to decrypt the file and file's Structure
 // secKey used to decrypt slices, different slices of different stroage nodes use different AES Keys
-func (f *FileDownload) getDecryptAuthKey(authKey []byte) (firKey aes.AESKey, secKey map[string]map[string]aes.AESKey, err error) {
+// chunkKey used to decrypt contents of slices for deduplicated file, which is encrypted chunk by chunk, nil for other files
+// e2eKey is the file key of client encrypted file, which is wrapped by the authorizer for the executor, nil for other files
+func (f *FileDownload) getDecryptAuthKey(authKey []byte) (firKey aes.AESKey, secKey map[string]map[string]aes.AESKey,
+	chunkKey map[string]aes.AESKey, e2eKey []byte, err error) {
 	// 1 parse ecdsa.PrivateKey to EC PrivateKey key
 	applierPrivateKey := ecdsa.ParsePrivateKey(f.NodePrivateKey)
 	// applier's EC private key decrypt the authKey
 	decryptAuthKey, err := ecies.Decrypt(&applierPrivateKey, authKey)
 	if err != nil {
-		return firKey, secKey, errorx.NewCode(err, errorx.ErrCodeInternal, "fail to decrypt the authKey")
+		return firKey, secKey, nil, nil, errorx.NewCode(err, errorx.ErrCodeInternal, "fail to decrypt the authKey")
 	}
 
 	// 2 unmarshal decrypt authKey
 	decryptKey := make(map[string]interface{})
 	if err = json.Unmarshal(decryptAuthKey, &decryptKey); err != nil {
-		return firKey, secKey, errorx.NewCode(err, errorx.ErrCodeInternal, "fail to unmarshal decrypt authKey")
+		return firKey, secKey, nil, nil, errorx.NewCode(err, errorx.ErrCodeInternal, "fail to unmarshal decrypt authKey")
 	}
 
 	// 3 get the first-level derived key
 	firstEncSecret, err := json.Marshal(decryptKey["firstEncSecret"])
 	if err != nil {
-		return firKey, secKey, errorx.Wrap(err, "failed to marshal firstEncSecret")
+		return firKey, secKey, nil, nil, errorx.Wrap(err, "failed to marshal firstEncSecret")
 	}
 	if err = json.Unmarshal(firstEncSecret, &firKey); err != nil {
-		return firKey, secKey, errorx.NewCode(err, errorx.ErrCodeInternal, "fail to get file first encrypt key")
+		return firKey, secKey, nil, nil, errorx.NewCode(err, errorx.ErrCodeInternal, "fail to get file first encrypt key")
 	}
 
 	// 4 get the second-level derived key
 	secondEncSecret, err := json.Marshal(decryptKey["secondEncSecret"])
 	if err != nil {
-		return firKey, secKey, errorx.Wrap(err, "failed to marshal secondEncSecret")
+		return firKey, secKey, nil, nil, errorx.Wrap(err, "failed to marshal secondEncSecret")
 	}
 	if err = json.Unmarshal(secondEncSecret, &secKey); err != nil {
-		return firKey, secKey, errorx.NewCode(err, errorx.ErrCodeInternal, "fail to get slice second encrypt key")
+		return firKey, secKey, nil, nil, errorx.NewCode(err, errorx.ErrCodeInternal, "fail to get slice second encrypt key")
 	}
-	return firKey, secKey, nil
+
+	// 5 get keys of chunks for deduplicated file
+	if _, ok := decryptKey["chunkEncSecret"]; ok {
+		chunkEncSecret, err := json.Marshal(decryptKey["chunkEncSecret"])
+		if err != nil {
+			return firKey, secKey, nil, nil, errorx.Wrap(err, "failed to marshal chunkEncSecret")
+		}
+		if err = json.Unmarshal(chunkEncSecret, &chunkKey); err != nil {
+			return firKey, secKey, nil, nil, errorx.NewCode(err, errorx.ErrCodeInternal, "fail to get chunk encrypt key")
+		}
+	}
+
+	// 6 unwrap the file key of client encrypted file
+	if _, ok := decryptKey["e2eEncSecret"]; ok {
+		e2eEncSecret, err := json.Marshal(decryptKey["e2eEncSecret"])
+		if err != nil {
+			return firKey, secKey, nil, nil, errorx.Wrap(err, "failed to marshal e2eEncSecret")
+		}
+		var wrappedKey []byte
+		if err = json.Unmarshal(e2eEncSecret, &wrappedKey); err != nil {
+			return firKey, secKey, nil, nil, errorx.NewCode(err, errorx.ErrCodeInternal, "fail to get wrapped file key")
+		}
+		if e2eKey, err = e2e.UnwrapFileKey(wrappedKey, f.NodePrivateKey); err != nil {
+			return firKey, secKey, nil, nil, err
+		}
+	}
+	return firKey, secKey, chunkKey, e2eKey, nil
 }
 
 // recoverFile recover file by pulling slices from storage nodes
 // The detailed steps are as follows:
-// 1. parameters check
-// 2. read file info from the blockchain
-// 3. decrypt the file's struct to get slice's order
-// 4. download slices from the storage node, if request fails, pull slices from other storage nodes
-// 5. slices decryption and combination
-// 6. decrypt the combined slices to get the original file
+// 1. get online storage nodes from the blockchain
+// 2. decrypt the file's struct to get slice's order
+// 3. download slices from the storage node, if request fails, pull slices from other storage nodes
+// 4. slices decryption and combination
+// 5. decrypt the combined slices to get the original file
+// Slices are recovered the same way as XuperDB does, see common.ReadFile of xdb
 func (f *FileDownload) recoverFile(ctx context.Context, chain Blockchain, file xdbchain.File,
-	firstKey aes.AESKey, secKey map[string]map[string]aes.AESKey) (io.ReadCloser, error) {
-	ctx, cancel := context.WithCancel(ctx)
-
-	// get storage nodes
-	allNodes, err := chain.ListNodes()
+	firstKey aes.AESKey, secKey map[string]map[string]aes.AESKey, chunkKey map[string]aes.AESKey) (io.ReadCloser, error) {
+	nodesMap, fs, err := f.prepareRecovery(chain, file, firstKey)
 	if err != nil {
-		cancel()
-		return nil, errorx.Wrap(err, "failed to get nodes from blockchain")
-	}
-	// filter offline status storage nodes
-	var nodes xdbchain.Nodes
-	for _, n := range allNodes {
-		if n.Online {
-			nodes = append(nodes, n)
-		}
-	}
-	if len(nodes) == 0 {
-		cancel()
-		return nil, errorx.New(errorx.ErrCodeInternal, "empty online nodes")
+		return nil, err
 	}
-	nodesMap := common.ToNodesMap(nodes)
+	dec := &authorizedDecrypter{f: f, fileID: file.ID, firstKey: firstKey, secKey: secKey, chunkKey: chunkKey}
+	return common.ReadFile(ctx, file, fs, dec, nodesMap, logger)
+}
 
-	// recover file structure to get slice's order
-	fs, err := f.recoverChainFileStructure(firstKey, file.Structure)
+// recoverFileRange downloads and decrypts a range of the file, only slices covering the range are pulled,
+// see common.ReadRange of xdb
+func (f *FileDownload) recoverFileRange(ctx context.Context, chain Blockchain, file xdbchain.File,
+	firstKey aes.AESKey, secKey map[string]map[string]aes.AESKey, chunkKey map[string]aes.AESKey,
+	offset, length uint64) (io.ReadCloser, error) {
+	nodesMap, fs, err := f.prepareRecovery(chain, file, firstKey)
 	if err != nil {
-		cancel()
 		return nil, err
 	}
+	dec := &authorizedDecrypter{f: f, fileID: file.ID, firstKey: firstKey, secKey: secKey, chunkKey: chunkKey}
+	return common.ReadRange(ctx, file, fs, dec, nodesMap, offset, length, logger)
+}
 
-	// use sliding window
-	sw := slidewindow.SlideWindow{
-		Total:       uint64(len(fs)),
-		Concurrency: defaultConcurrency,
+// prepareRecovery gets online storage nodes and the file structure needed to recover the file
+func (f *FileDownload) prepareRecovery(chain Blockchain, file xdbchain.File, firstKey aes.AESKey) (
+	map[string]xdbchain.Node, xdbchain.FileStructure, error) {
+	nodesMap, err := getOnlineNodesMap(chain)
+	if err != nil {
+		return nil, nil, err
 	}
-
-	sw.Init = func(ctx context.Context, s *slidewindow.Session) error {
-		return nil
+	// recover file structure to get slice's order
+	fs, err := f.recoverChainFileStructure(firstKey, file.Structure)
+	if err != nil {
+		return nil, nil, err
 	}
-	// get the mapping of slice-storageNodes
-	slicesPool := makeSlicesPool4Read(file.Slices)
-	sw.Task = func(ctx context.Context, s *slidewindow.Session) error {
-		slice := fs[int(s.Index())]
-		// get the list of storage nodes where the slice stored
-		targetPool, ok := slicesPool[slice.SliceID]
-		if !ok {
-			return errorx.Internal(nil, "bad file structure")
-		}
-		for _, target := range targetPool {
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			default:
-			}
-
-			node, exist := nodesMap[string(target.NodeID)]
-			if !exist || !node.Online {
-				logger.WithField("node_id", string(target.NodeID)).Warn("abnormal node")
-				continue
-			}
-			// pull slice
-			r, err := f.pull(ctx, target.ID, target.StorIndex, file.ID, node.Address)
-			if err != nil {
-				logger.WithFields(logrus.Fields{
-					"slice_id":    target.ID,
-					"file_id":     file.ID,
-					"target_node": string(node.ID),
-				}).WithError(err).Warn("failed to pull slice")
-				continue
-			}
-			defer r.Close()
-
-			// read slice and check slice hash
-			cipherText, err := ioutil.ReadAll(r)
-			if err != nil {
-				logger.WithError(err).Warn("failed to read slice from target node")
-				continue
-			}
-			if len(cipherText) != int(target.Length) {
-				logger.WithFields(logrus.Fields{"expected": target.Length, "got": len(cipherText)}).
-					Warn("invalid slice length.")
-				continue
-			}
-			hGot := hash.HashUsingSha256(cipherText)
-			if !bytes.Equal(hGot, target.CipherHash) {
-				logger.WithFields(logrus.Fields{"expected": target.CipherHash, "got": hGot}).
-					Warn("invalid slice hash.")
-				continue
-			}
-
-			// decrypt the encrypted slice
-			plainText, err := f.recover(secKey[target.ID][string(node.ID)], cipherText)
-			if err != nil {
-				logger.WithError(err).Error("failed to decrypt slice")
-				continue
-			}
+	return nodesMap, fs, nil
+}
 
-			// trim 0 at the end of file
-			if s.Index() != sw.Total-1 {
-				s.Set("data", plainText)
-			} else {
-				s.Set("data", bytes.TrimRight(plainText, string([]byte{0})))
-			}
+// authorizedDecrypter decrypts the sample file with keys authorized by the file owner, see common.FileDecrypter of xdb.
+// firstKey decrypts the file, secKey decrypts slices, and chunkKey decrypts chunks of deduplicated file
+type authorizedDecrypter struct {
+	f        *FileDownload
+	fileID   string
+	firstKey aes.AESKey
+	secKey   map[string]map[string]aes.AESKey
+	chunkKey map[string]aes.AESKey
+}
 
-			break
+// PullSlice pulls a slice from any of its storage nodes, and returns the decrypted content
+func (d *authorizedDecrypter) PullSlice(ctx context.Context, targetPool []xdbchain.PublicSliceMeta,
+	nodesMap map[string]xdbchain.Node) ([]byte, error) {
+	for _, target := range targetPool {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
 		}
 
-		if _, exist := s.Get("data"); !exist {
-			return errorx.New(errorx.ErrCodeNotFound, "failed to pull slice %s", slice.SliceID)
+		node, exist := nodesMap[string(target.NodeID)]
+		if !exist || !node.Online {
+			logger.WithField("node_id", string(target.NodeID)).Warn("abnormal node")
+			continue
 		}
-
-		return nil
-	}
-
-	reader, writer := io.Pipe()
-	sw.Done = func(ctx context.Context, s *slidewindow.Session) error {
-		data, exist := s.Get("data")
-		if !exist {
-			return errorx.New(errorx.ErrCodeNotFound, "failed to find data")
+		// pull slice
+		r, err := d.f.pull(ctx, target.ID, target.StorIndex, d.fileID, node.Address)
+		if err != nil {
+			logger.WithFields(logrus.Fields{
+				"slice_id":    target.ID,
+				"file_id":     d.fileID,
+				"target_node": string(node.ID),
+			}).WithError(err).Warn("failed to pull slice")
+			continue
 		}
 
-		if _, err := writer.Write(data.([]byte)); err != nil {
-			return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to write")
+		// read slice and check slice hash
+		cipherText, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			logger.WithError(err).Warn("failed to read slice from target node")
+			continue
+		}
+		if len(cipherText) != int(target.Length) {
+			logger.WithFields(logrus.Fields{"expected": target.Length, "got": len(cipherText)}).
+				Warn("invalid slice length.")
+			continue
+		}
+		hGot := hash.HashUsingSha256(cipherText)
+		if !bytes.Equal(hGot, target.CipherHash) {
+			logger.WithFields(logrus.Fields{"expected": target.CipherHash, "got": hGot}).
+				Warn("invalid slice hash.")
+			continue
 		}
 
-		// exit on success
-		if s.Index() == uint64(len(fs)-1) {
-			writer.Close()
+		// unseal the replica and decrypt the encrypted slice
+		if cipherText, err = seal.Unseal(cipherText, node.ID); err != nil {
+			logger.WithError(err).Error("failed to unseal slice")
+			continue
+		}
+		plainText, err := d.f.recover(d.secKey[target.ID][string(node.ID)], cipherText)
+		if err != nil {
+			logger.WithError(err).Error("failed to decrypt slice")
+			continue
 		}
-		return nil
+		return plainText, nil
 	}
 
-	go func() {
-		defer cancel()
-		if err := sw.Start(ctx); err != nil {
-			writer.CloseWithError(err)
-		}
-	}()
+	if len(targetPool) == 0 {
+		return nil, errorx.Internal(nil, "bad file structure")
+	}
+	return nil, errorx.New(errorx.ErrCodeNotFound, "failed to pull slice %s", targetPool[0].ID)
+}
+
+// DecryptChunk decrypts a chunk of deduplicated file by its authorized key
+func (d *authorizedDecrypter) DecryptChunk(slice xdbchain.PrivateSliceMeta, ciphertext []byte) ([]byte, error) {
+	return d.f.recover(d.chunkKey[slice.SliceID], ciphertext)
+}
+
+// DecryptFile decrypts the file encrypted as a whole
+func (d *authorizedDecrypter) DecryptFile(ciphertext []byte) ([]byte, error) {
+	return d.f.recover(d.firstKey, ciphertext)
+}
 
-	// decrypt recovered file
-	fileCipherText, err := ioutil.ReadAll(reader)
+// FileCipher returns the cipher of chunked file, whose key is the first-level derived key
+func (d *authorizedDecrypter) FileCipher() (*chunk.Cipher, error) {
+	return chunk.New(d.firstKey)
+}
+
+// getOnlineNodesMap gets online storage nodes from blockchain, key is the node's ID
+func getOnlineNodesMap(chain Blockchain) (map[string]xdbchain.Node, error) {
+	allNodes, err := chain.ListNodes()
 	if err != nil {
-		return nil, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to read")
+		return nil, errorx.Wrap(err, "failed to get nodes from blockchain")
 	}
-	plainText, err := f.recover(firstKey, fileCipherText)
-	if err != nil {
-		return nil, errorx.NewCode(err, errorx.ErrCodeCrypto, "file decryption failed")
+	// filter offline status storage nodes
+	var nodes xdbchain.Nodes
+	for _, n := range allNodes {
+		if n.Online {
+			nodes = append(nodes, n)
+		}
 	}
-	return ioutil.NopCloser(bytes.NewReader(plainText)), nil
+	if len(nodes) == 0 {
+		return nil, errorx.New(errorx.ErrCodeInternal, "empty online nodes")
+	}
+	return common.ToNodesMap(nodes), nil
 }
 
 // pull used pull slices from storage nodes
@@ -352,6 +587,19 @@ func (f *FileDownload) recoverChainFileStructure(aesKey aes.AESKey, structure []
 	return fStructures, nil
 }
 
+// decryptClientEncrypted decrypts the content of client encrypted file by the file key
+func decryptClientEncrypted(rc io.ReadCloser, fileKey []byte) (io.ReadCloser, error) {
+	r, err := e2e.NewDecryptReaderWithFileKey(rc, fileKey)
+	if err != nil {
+		rc.Close()
+		return nil, errorx.Wrap(err, "failed to decrypt the client encrypted file")
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{r, rc}, nil
+}
+
 // recover decrypt the ciphertext using AES-GCM
 func (f *FileDownload) recover(aesKey aes.AESKey, ciphertext []byte) ([]byte, error) {
 	plaintext, err := aes.DecryptUsingAESGCM(aesKey, ciphertext, nil)
@@ -361,19 +609,3 @@ func (f *FileDownload) recover(aesKey aes.AESKey, ciphertext []byte) ([]byte, er
 
 	return plaintext, nil
 }
-
-// makeSlicesPool4Read return the mapping of storage nodes where the slice is located
-// key is sliceID, value is the list of slices meta
-func makeSlicesPool4Read(srs []xdbchain.PublicSliceMeta) map[string][]xdbchain.PublicSliceMeta {
-	slicesPool := make(map[string][]xdbchain.PublicSliceMeta)
-	for _, s := range srs {
-		var ss []xdbchain.PublicSliceMeta
-		if v, exist := slicesPool[s.ID]; exist {
-			ss = v
-		}
-		ss = append(ss, s)
-		slicesPool[s.ID] = ss
-	}
-
-	return slicesPool
-}
@@ -0,0 +1,385 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/aes"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/ecdsa"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/ecies"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/hash"
+	xdbchain "github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/client/e2e"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/encryptor/seal"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/peer"
+	util "github.com/PaddlePaddle/PaddleDTX/xdb/pkgs/strings"
+
+	pbTask "github.com/PaddlePaddle/PaddleDTX/dai/protos/task"
+)
+
+// authChain returns file authorization applications of the executor, other methods are not implemented
+type authChain struct {
+	Blockchain
+	fileAuths map[string]xdbchain.FileAuthApplications // by file ID
+	queries   int
+}
+
+func (c *authChain) ListFileAuthApplications(opt *xdbchain.ListFileAuthOptions) (xdbchain.FileAuthApplications, error) {
+	c.queries++
+	return c.fileAuths[opt.FileID], nil
+}
+
+func TestFileAuthsRevoked(t *testing.T) {
+	now := time.Now().UnixNano()
+	approved := func(approvalTime int64) *xdbchain.FileAuthApplication {
+		return &xdbchain.FileAuthApplication{Status: xdbchain.FileAuthApproved, ApprovalTime: approvalTime, ExpireTime: now + 100}
+	}
+	revoked := func(revokeTime int64) *xdbchain.FileAuthApplication {
+		return &xdbchain.FileAuthApplication{Status: xdbchain.FileAuthRevoked, ApprovalTime: revokeTime - 10, RevokeTime: revokeTime}
+	}
+	expired := approved(now - 10)
+	expired.ExpireTime = now
+
+	cases := []struct {
+		name      string
+		fileAuths xdbchain.FileAuthApplications
+		revoked   bool
+	}{
+		{"no application", nil, false},
+		{"approved", xdbchain.FileAuthApplications{approved(now - 10)}, false},
+		{"latest revoked", xdbchain.FileAuthApplications{revoked(now - 10), approved(now - 50)}, true},
+		// the latest application was approved before the older one is revoked
+		{"older revoked", xdbchain.FileAuthApplications{approved(now - 50), revoked(now - 10)}, true},
+		{"approved again", xdbchain.FileAuthApplications{approved(now - 10), revoked(now - 50)}, false},
+		{"approved again but expired", xdbchain.FileAuthApplications{expired, revoked(now - 50)}, true},
+	}
+	for _, c := range cases {
+		if revoked := FileAuthsRevoked(c.fileAuths, now); revoked != c.revoked {
+			t.Errorf("%s: expected revoked %v, got %v", c.name, c.revoked, revoked)
+		}
+	}
+}
+
+func TestCheckRevokedTasks(t *testing.T) {
+	privkey, pubkey, err := ecdsa.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UnixNano()
+	chain := &authChain{
+		fileAuths: map[string]xdbchain.FileAuthApplications{
+			"file1": {{Status: xdbchain.FileAuthApproved, ApprovalTime: now, ExpireTime: now + time.Hour.Nanoseconds()}},
+		},
+	}
+	m := &MpcModelHandler{
+		Node:     Node{Local: peer.Local{PrivateKey: privkey}},
+		Download: FileDownload{Type: ProxyExecutionMode, NodePrivateKey: privkey},
+		Chain:    chain,
+		MpcTasks: make(map[string]*FlTask),
+	}
+	for _, taskID := range []string{"task1", "task2"} {
+		m.MpcTasks[taskID] = &FlTask{FLTask: pbTask.FLTask{
+			TaskID:   taskID,
+			DataSets: []*pbTask.DataForTask{{DataID: "file1", Executor: pubkey[:]}},
+		}}
+	}
+
+	// the sample file shared by tasks is checked once
+	m.CheckRevokedTasks()
+	if chain.queries != 1 {
+		t.Fatalf("expected 1 query, got %d", chain.queries)
+	}
+	// and not checked again within revokeCheckInterval
+	m.CheckRevokedTasks()
+	if chain.queries != 1 {
+		t.Fatalf("expected no more query, got %d", chain.queries)
+	}
+	m.authCheckTimes["file1"] = time.Now().Add(-revokeCheckInterval)
+	m.CheckRevokedTasks()
+	if chain.queries != 2 {
+		t.Fatalf("expected the file checked again, got %d queries", chain.queries)
+	}
+	if len(m.MpcTasks) != 2 {
+		t.Fatalf("expected tasks kept running, got %d tasks", len(m.MpcTasks))
+	}
+}
+
+// sampleChain serves a sample file stored on one storage node and its approved authorization application,
+// downloads recorded are sent to the channel
+type sampleChain struct {
+	Blockchain
+	file      xdbchain.File
+	node      xdbchain.Node
+	fileAuth  *xdbchain.FileAuthApplication
+	downloads chan *xdbchain.RecordFileDownloadOptions
+}
+
+func (c *sampleChain) GetFileByID(id string) (xdbchain.File, error) {
+	return c.file, nil
+}
+
+func (c *sampleChain) ListFileAuthApplications(opt *xdbchain.ListFileAuthOptions) (xdbchain.FileAuthApplications, error) {
+	return xdbchain.FileAuthApplications{c.fileAuth}, nil
+}
+
+func (c *sampleChain) ListNodes() (xdbchain.Nodes, error) {
+	return xdbchain.Nodes{c.node}, nil
+}
+
+func (c *sampleChain) RecordFileDownload(opt *xdbchain.RecordFileDownloadOptions) error {
+	c.downloads <- opt
+	return nil
+}
+
+func newTestAESKey(t *testing.T) aes.AESKey {
+	key := aes.AESKey{Key: make([]byte, 32), Nonce: make([]byte, 12)}
+	if _, err := rand.Read(key.Key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rand.Read(key.Nonce); err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// newClientEncryptedSample publishes content encrypted in the client by userKey as a sample file of one slice,
+// which is encrypted the way the dataOwner node does, and authorizes it to the applier.
+// wrap returns the file key wrapped for the applier by the authorizer, nil means no file key is authorized.
+// The slice is sealed for the storage node if sealRounds is positive
+func newClientEncryptedSample(t *testing.T, content, userKey []byte, applier ecdsa.PublicKey,
+	wrap func(fileKey []byte) []byte, sealRounds int) *sampleChain {
+	e2eCipherText, err := e2e.Encrypt(content, userKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the padding of the last slice is trimmed when the file is recovered, so the ciphertext shouldn't end with 0
+	var firstKey aes.AESKey
+	var fileCipherText []byte
+	for len(fileCipherText) == 0 || fileCipherText[len(fileCipherText)-1] == 0 {
+		firstKey = newTestAESKey(t)
+		if fileCipherText, err = aes.EncryptUsingAESGCM(firstKey, e2eCipherText, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	secKey := newTestAESKey(t)
+	sliceCipherText, err := aes.EncryptUsingAESGCM(secKey, fileCipherText, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sealRounds > 0 {
+		if sliceCipherText, err = seal.Seal(sliceCipherText, []byte("node1"), sealRounds); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fs := xdbchain.FileStructure{{SliceID: "slice1", PlainHash: hash.HashUsingSha256(fileCipherText)}}
+	structure, err := fs.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	encStructure, err := aes.EncryptUsingAESGCM(firstKey, structure, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(sliceCipherText)
+	}))
+	t.Cleanup(server.Close)
+
+	// the authorization key is made the same way as the dataOwner node does
+	authKey := map[string]interface{}{
+		"firstEncSecret":  firstKey,
+		"secondEncSecret": map[string]map[string]aes.AESKey{"slice1": {"node1": secKey}},
+	}
+	if wrap != nil {
+		fileKey, err := e2e.FileKey(e2eCipherText[:e2e.HeaderSize], userKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		authKey["e2eEncSecret"] = wrap(fileKey)
+	}
+	authKeyBytes, err := json.Marshal(authKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubkey, err := ecdsa.ParsePublicKey(applier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encAuthKey, err := ecies.Encrypt(&pubkey, authKeyBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UnixNano()
+	return &sampleChain{
+		file: xdbchain.File{
+			ID:        "file1",
+			Structure: encStructure,
+			Slices: []xdbchain.PublicSliceMeta{{
+				ID:         "slice1",
+				CipherHash: hash.HashUsingSha256(sliceCipherText),
+				Length:     uint64(len(sliceCipherText)),
+				NodeID:     []byte("node1"),
+				Sealed:     sealRounds > 0,
+			}},
+			Length:          uint64(len(e2eCipherText)),
+			ClientEncrypted: true,
+		},
+		node: xdbchain.Node{ID: []byte("node1"), Address: strings.TrimPrefix(server.URL, "http://"), Online: true},
+		fileAuth: &xdbchain.FileAuthApplication{
+			ID:           "auth1",
+			Status:       xdbchain.FileAuthApproved,
+			AuthKey:      encAuthKey,
+			ApprovalTime: now,
+			ExpireTime:   now + time.Hour.Nanoseconds(),
+		},
+		downloads: make(chan *xdbchain.RecordFileDownloadOptions, 10),
+	}
+}
+
+func TestGetClientEncryptedSampleFile(t *testing.T) {
+	privkey, pubkey, err := ecdsa.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	userKey, err := e2e.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := []byte("id,age,label\n1,20,0\n2,30,1\n")
+	wrap := func(fileKey []byte) []byte {
+		wrapped, err := e2e.WrapFileKey(fileKey, pubkey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return wrapped
+	}
+	f := FileDownload{Type: ProxyExecutionMode, NodePrivateKey: privkey}
+
+	// the executor unwraps the file key from the authorization, and decrypts the sample file with it
+	chain := newClientEncryptedSample(t, content, userKey, pubkey, wrap, 0)
+	r, err := f.GetSampleFile("file1", chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(r)
+	r.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(content, data) {
+		t.Fatalf("expected %q, got %q", content, data)
+	}
+	// the download is recorded by the executor with its authorization
+	select {
+	case opt := <-chain.downloads:
+		if opt.FileID != "file1" || opt.AuthID != "auth1" || !bytes.Equal(opt.User, pubkey[:]) {
+			t.Fatalf("unexpected download record %+v", opt)
+		}
+		sig := opt.Signature
+		opt.Signature = nil
+		msg, err := util.GetSigMessage(opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var signature ecdsa.Signature
+		copy(signature[:], sig)
+		if err := ecdsa.Verify(pubkey, hash.HashUsingSha256([]byte(msg)), signature); err != nil {
+			t.Fatalf("bad signature of download record: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("download is not recorded")
+	}
+
+	// ranges are located in plaintext
+	r, err = f.GetSampleFileRange("file1", 13, 7, chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err = ioutil.ReadAll(r)
+	r.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "1,20,0\n" {
+		t.Fatalf("expected the second line, got %q", data)
+	}
+	header, err := f.GetSampleFileHeader("file1", chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(header, ",") != "id,age,label" {
+		t.Fatalf("unexpected header %v", header)
+	}
+	// neither ranges nor reading the file again in the window are recorded as another download
+	r, err = f.GetSampleFile("file1", chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ioutil.ReadAll(r); err != nil {
+		t.Fatal(err)
+	}
+	r.Close()
+	select {
+	case opt := <-chain.downloads:
+		t.Fatalf("unexpected download record %+v", opt)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// replicas sealed for the storage node are unsealed before decryption
+	chain = newClientEncryptedSample(t, content, userKey, pubkey, wrap, 4)
+	r, err = f.GetSampleFileRange("file1", 13, 7, chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err = ioutil.ReadAll(r)
+	r.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "1,20,0\n" {
+		t.Fatalf("expected the second line of sealed sample, got %q", data)
+	}
+
+	// the file can't be read without the file key, or with the key of another file
+	chain = newClientEncryptedSample(t, content, userKey, pubkey, nil, 0)
+	if _, err := f.GetSampleFile("file1", chain); err == nil {
+		t.Fatal("expected error without the file key")
+	}
+	if len(chain.downloads) != 0 {
+		t.Fatal("failed download is recorded")
+	}
+	another, err := e2e.Encrypt(content, userKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	anotherKey, err := e2e.FileKey(another[:e2e.HeaderSize], userKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain = newClientEncryptedSample(t, content, userKey, pubkey, func([]byte) []byte { return wrap(anotherKey) }, 0)
+	if _, err := f.GetSampleFile("file1", chain); err == nil {
+		t.Fatal("expected error with the key of another file")
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"strings"
@@ -39,6 +40,10 @@ import (
 	util "github.com/PaddlePaddle/PaddleDTX/xdb/pkgs/strings"
 )
 
+// revokeCheckInterval is how long the authorization of a sample file checked by CheckRevokedTasks is trusted,
+// before it's queried from blockchain again
+const revokeCheckInterval = time.Minute
+
 var (
 	logger = logrus.WithField("module", "handler.mpc")
 )
@@ -71,6 +76,10 @@ type MpcHandler interface {
 	// and stops expired tasks
 	CheckMpcTimeOutTasks()
 
+	// CheckRevokedTasks checks tasks in execution pool if the authorizations of their
+	// sample files are revoked, and stops these tasks
+	CheckRevokedTasks()
+
 	//Close closes all inner services
 	Close()
 }
@@ -96,6 +105,10 @@ type MpcModelHandler struct {
 	// store execution mpc tasks
 	MpcTasks map[string]*FlTask
 	sync.RWMutex
+
+	// times when the authorizations of sample files were checked not revoked, by file ID,
+	// only accessed by CheckRevokedTasks
+	authCheckTimes map[string]time.Time
 }
 
 // ParticipantParams local parameters required for task execution
@@ -218,6 +231,64 @@ func (m *MpcModelHandler) CheckMpcTimeOutTasks() {
 	}
 }
 
+// CheckRevokedTasks checks tasks in execution pool if the authorizations of their
+// sample files are revoked, and stops these tasks, only works when the executor uses others' data.
+//  Each sample file is checked once for all tasks using it, and not checked again within revokeCheckInterval
+func (m *MpcModelHandler) CheckRevokedTasks() {
+	if m.Download.Type != ProxyExecutionMode {
+		return
+	}
+	pubkey := ecdsa.PublicKeyFromPrivateKey(m.Node.PrivateKey)
+	owners := make(map[string][]byte)      // owners of sample files to check, by file ID
+	fileTasks := make(map[string][]string) // tasks using sample files, by file ID
+	m.RLock()
+	for _, task := range m.MpcTasks {
+		for _, dataset := range task.DataSets {
+			if !bytes.Equal(dataset.Executor, pubkey[:]) {
+				continue
+			}
+			owners[dataset.DataID] = dataset.Owner
+			fileTasks[dataset.DataID] = append(fileTasks[dataset.DataID], task.TaskID)
+		}
+	}
+	m.RUnlock()
+
+	if m.authCheckTimes == nil {
+		m.authCheckTimes = make(map[string]time.Time)
+	}
+	now := time.Now()
+	for fileID, t := range m.authCheckTimes {
+		if _, ok := owners[fileID]; !ok || now.Sub(t) >= revokeCheckInterval {
+			delete(m.authCheckTimes, fileID)
+		}
+	}
+
+	revokedTasks := make(map[string]string)
+	for fileID, owner := range owners {
+		if _, ok := m.authCheckTimes[fileID]; ok {
+			continue
+		}
+		revoked, err := m.Download.IsFileAuthRevoked(fileID, owner, m.Chain)
+		if err != nil {
+			logger.WithError(err).Warnf("failed to check file authorization, fileID: %s", fileID)
+			continue
+		}
+		if !revoked {
+			m.authCheckTimes[fileID] = now
+			continue
+		}
+		for _, taskID := range fileTasks[fileID] {
+			revokedTasks[taskID] = fileID
+		}
+	}
+
+	// stop revoked tasks and update status in blockchain
+	for taskID, fileID := range revokedTasks {
+		m.updateTaskStatusAndStopLocalMpc(taskID,
+			fmt.Sprintf("the authorization of sample file is revoked, fileID: %s", fileID), "")
+	}
+}
+
 // updateTaskStatusAndStopLocalMpc used update task status and execute result into chain and stop local mpc task
 // executeErr indicates whether task is successfully executed or failed
 // executeResult is task result, only for prediction task
@@ -506,7 +577,12 @@ func (m *MpcModelHandler) getTaskParticipantParam(task blockchain.FLTask) (partP
 			logger.Infof("got one other party: %s", dataset.Address)
 		}
 	}
-	partParam.otherParts = otherParts
+	// label owner is placed first, feature-only parties exchange training and prediction parts with it
+	tagExecutor, err := m.getTagExecutor(task)
+	if err != nil {
+		return partParam, err
+	}
+	partParam.otherParts = sortOtherParts(task, otherParts, tagExecutor)
 
 	// if task's execution use paddlefl
 	paddleFLNodes := [3]string{}
@@ -527,7 +603,47 @@ func (m *MpcModelHandler) getTaskParticipantParam(task blockchain.FLTask) (partP
 	return partParam, nil
 }
 
-// getTargetPart determine whether local sample has label by parsing file extra information
+// getTagExecutor returns the executor of the dataset with label,
+// for prediction task, it's found in the training task of the model
+func (m *MpcModelHandler) getTagExecutor(task blockchain.FLTask) ([]byte, error) {
+	dataSets := task.DataSets
+	if task.AlgoParam.TaskType == pbCom.TaskType_PREDICT {
+		modelTask, err := m.Chain.GetTaskById(task.AlgoParam.ModelTaskID)
+		if err != nil {
+			return nil, err
+		}
+		dataSets = modelTask.DataSets
+	}
+	for _, dataset := range dataSets {
+		if dataset.IsTagPart {
+			return dataset.Executor, nil
+		}
+	}
+	return nil, nil
+}
+
+// sortOtherParts moves the address of label owner to the front of other parties
+func sortOtherParts(task blockchain.FLTask, otherParts []string, tagExecutor []byte) []string {
+	var tagAddress string
+	for _, dataset := range task.DataSets {
+		if bytes.Equal(dataset.Executor, tagExecutor) {
+			tagAddress = dataset.Address
+			break
+		}
+	}
+	sorted := make([]string, 0, len(otherParts))
+	for _, part := range otherParts {
+		if part == tagAddress {
+			sorted = append([]string{part}, sorted...)
+		} else {
+			sorted = append(sorted, part)
+		}
+	}
+	return sorted
+}
+
+// getTargetPart determine whether local sample has label by parsing file extra information,
+// if features are not declared in file extra information, only the header of the sample file is downloaded to get them
 func (m *MpcModelHandler) getTargetPart(fileID, labelName string) (bool, error) {
 	sampleFile, err := m.Chain.GetFileByID(fileID)
 	if err != nil {
@@ -535,8 +651,17 @@ func (m *MpcModelHandler) getTargetPart(fileID, labelName string) (bool, error)
 	}
 	// parse file struct
 	fileExtra := blockchain.FLInfo{}
-	if err := json.Unmarshal(sampleFile.Ext, &fileExtra); err != nil {
-		return false, errorx.New(errorx.ErrCodeInternal, "failed to get file extra info")
+	if len(sampleFile.Ext) > 0 {
+		if err := json.Unmarshal(sampleFile.Ext, &fileExtra); err != nil {
+			return false, errorx.New(errorx.ErrCodeInternal, "failed to get file extra info")
+		}
+	}
+	if fileExtra.Features == "" {
+		header, err := m.Download.GetSampleFileHeader(fileID, m.Chain)
+		if err != nil {
+			return false, err
+		}
+		return util.IsContain(header, labelName), nil
 	}
 	fileFeatures := strings.Split(fileExtra.Features, ",")
 	isTagPart := util.IsContain(fileFeatures, labelName)
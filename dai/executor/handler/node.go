@@ -49,6 +49,8 @@ type Blockchain interface {
 	ListFileAuthApplications(opt *xdbchain.ListFileAuthOptions) (xdbchain.FileAuthApplications, error)
 	// publish sample file's authorization application
 	PublishFileAuthApplication(opt *xdbchain.PublishFileAuthOptions) error
+	// record a download of the sample file in its audit trail
+	RecordFileDownload(opt *xdbchain.RecordFileDownloadOptions) error
 	// query the list of storage nodes
 	ListNodes() (xdbchain.Nodes, error)
 
@@ -53,6 +53,10 @@ type MpcHandler interface {
 	// CheckMpcTimeOutTasks checks tasks in execution pool if they're expired,
 	// and stops expired tasks
 	CheckMpcTimeOutTasks()
+
+	// CheckRevokedTasks checks tasks in execution pool if the authorizations of their
+	// sample files are revoked, and stops these tasks
+	CheckRevokedTasks()
 }
 
 // TaskMonitor
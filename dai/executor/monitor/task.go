@@ -70,6 +70,10 @@ func (t *TaskMonitor) loopRequest(ctx context.Context) {
 		//checks tasks in execution pool if they're expired,
 		// then stops expired tasks
 		t.MpcHandler.CheckMpcTimeOutTasks()
+
+		// checks tasks in execution pool if the authorizations of sample files are revoked,
+		// then stops revoked tasks
+		t.MpcHandler.CheckRevokedTasks()
 	}
 }
 
@@ -125,14 +129,29 @@ func (t *TaskMonitor) confirmTaskByExecutionType(taskID string, ds *pbTask.DataF
 			FileID:     ds.DataID,
 			TimeStart:  0,
 			TimeEnd:    currentTime,
-			Limit:      1,
 		})
 		if err != nil {
 			return errorx.Wrap(err, "failed to find the file authorization application, fileID: %s, Applier: %x, Authorizer: %x",
 				ds.DataID, t.PublicKey[:], ds.Owner)
 		}
-		// 2. if the authorization application has not been published or the authorization application has expired,
+		// 2. if the authorization has been revoked by the file owner, reject the task,
+		// if the authorization application has not been published or the authorization application has expired,
 		// then publish the file authorization application
+		if handler.FileAuthsRevoked(fileAuths, currentTime) {
+			rejectReason := fmt.Sprintf("File authorization application is revoked, fileID: %s", ds.DataID)
+			if err := t.confirmTaskOnChain(taskID, rejectReason, false); err != nil {
+				return errorx.Wrap(err, "reject task failed, taskID: %s, Executor: %x", taskID, t.PublicKey[:])
+			}
+			return nil
+		}
+		// revoked applications are ignored once the authorization is approved again
+		var activeAuths xdbchain.FileAuthApplications
+		for _, fa := range fileAuths {
+			if fa.Status != xdbchain.FileAuthRevoked {
+				activeAuths = append(activeAuths, fa)
+			}
+		}
+		fileAuths = activeAuths
 		if len(fileAuths) == 0 || (fileAuths[0].Status == xdbchain.FileAuthApproved &&
 			fileAuths[0].ExpireTime <= currentTime) {
 			if err := t.publishFileAuthApplication(ds.DataID, taskID, ds.Owner); err != nil {
@@ -92,3 +92,19 @@ func (x *XuperDB) Read(fileID string) (io.ReadCloser, error) {
 	}
 	return reader, nil
 }
+
+// ReadRange requests the dataOwner node to download a range of the file, length 0 means reading to the end of the file
+func (x *XuperDB) ReadRange(fileID string, offset, length uint64) (io.ReadCloser, error) {
+	client, err := httpclient.New(x.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	opt := httpclient.ReadOptions{
+		PrivateKey: x.PrivateKey.String(),
+		FileID:     fileID,
+		Offset:     offset,
+		Length:     length,
+	}
+	return client.Read(context.Background(), opt)
+}
@@ -815,9 +815,9 @@ func fundIDIndex(fileRows [][]string, idName string) int {
 func NewEvaluator(req *pbCom.StartTaskRequest, mpc Mpc, trainer Trainer) (Evaluator, error) {
 	getCaseType := func(algo pbCom.Algorithm) (pbCom.CaseType, error) {
 		switch algo {
-		case pbCom.Algorithm_LINEAR_REGRESSION_VL:
+		case pbCom.Algorithm_LINEAR_REGRESSION_VL, pbCom.Algorithm_LINEAR_REGRESSION_HL:
 			return pbCom.CaseType_Regression, nil
-		case pbCom.Algorithm_LOGIC_REGRESSION_VL:
+		case pbCom.Algorithm_LOGIC_REGRESSION_VL, pbCom.Algorithm_SECUREBOOST_VL, pbCom.Algorithm_LOGIC_REGRESSION_HL:
 			return pbCom.CaseType_BinaryClass, nil
 		default:
 			return 0, errorx.New(errcodes.ErrCodeParam, "unknown algorithm: %s", algo.String())
@@ -844,6 +844,10 @@ func NewEvaluator(req *pbCom.StartTaskRequest, mpc Mpc, trainer Trainer) (Evalua
 			return nil, errorx.New(errcodes.ErrCodeParam, "invalid evaluation rule: %s", req.Params.EvalParams.EvalRule)
 		}
 	case pbCom.EvaluationRule_ErLOO:
+		// parties hold different samples in horizontal learning, so the number of folds can't be agreed
+		if req.Params.Algo == pbCom.Algorithm_LINEAR_REGRESSION_HL || req.Params.Algo == pbCom.Algorithm_LOGIC_REGRESSION_HL {
+			return nil, errorx.New(errcodes.ErrCodeParam, "evaluation rule %s is not supported by %s", req.Params.EvalParams.EvalRule, req.Params.Algo)
+		}
 	default:
 		return nil, errorx.New(errcodes.ErrCodeParam, "unknown evaluation rule: %s", req.Params.EvalParams.EvalRule)
 	}
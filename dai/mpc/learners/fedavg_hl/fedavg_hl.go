@@ -0,0 +1,658 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fedavg_hl
+
+import (
+	"sync"
+	"time"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+	"github.com/golang/protobuf/proto"
+	"github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleDTX/dai/crypto/hl/fedavg"
+	"github.com/PaddlePaddle/PaddleDTX/dai/crypto/vl/common/csv"
+	"github.com/PaddlePaddle/PaddleDTX/dai/errcodes"
+	pbCom "github.com/PaddlePaddle/PaddleDTX/dai/protos/common"
+	pb "github.com/PaddlePaddle/PaddleDTX/dai/protos/mpc"
+	pbFedAvgHl "github.com/PaddlePaddle/PaddleDTX/dai/protos/mpc/learners/fedavg_hl"
+)
+
+var (
+	logger = logrus.WithField("module", "mpc.learners.fedavg_hl")
+)
+
+// RpcHandler used to request remote mpc-node
+type RpcHandler interface {
+	StepTrain(req *pb.TrainRequest, peerName string) (*pb.TrainResponse, error)
+
+	// StepTrainWithRetry sends training message to remote mpc-node
+	// retries 2 times at most
+	// inteSec indicates the interval between retry requests, in seconds
+	StepTrainWithRetry(req *pb.TrainRequest, peerName string, times int, inteSec int64) (*pb.TrainResponse, error)
+}
+
+// ResultHandler handles final result which is successful or failed
+// Should be called when learning finished
+type ResultHandler interface {
+	SaveResult(*pbCom.TrainTaskResult)
+}
+
+// LiveEvaluator performs staged evaluation during training.
+// Trigger triggers model evaluation.
+// The parameter contains two types of messages.
+// One is to set the learner for evaluation with training set and start it.
+// The other is to drive the learner to continue training. When the conditions are met(reaching pause round),
+// stop training and instantiate the model for validation.
+type LiveEvaluator interface {
+	Trigger(*pb.LiveEvaluationTriggerMsg) error
+}
+
+type learnerStatusType uint8
+
+const (
+	learnerStatusWaitSamples learnerStatusType = iota
+	learnerStatusGotSamples
+	learnerStatusStartTrain
+	learnerStatusEndTrain
+)
+
+// Learner trains linear or logistic regression model in horizontal federated learning based on FedAvg.
+// Every party trains the global model with local samples in each round,
+// and model updates are averaged by secure aggregation with pairwise masks,
+// so that no one could learn the update of any single party.
+type Learner struct {
+	id           string
+	algo         pbCom.Algorithm
+	address      string   // address indicates local mpc-node
+	parties      []string // parties are other learners who participates in MPC, assigned with mpc-node address usually
+	maskPub      []byte   // public key for mask seeds agreement
+	trainParams  *pbCom.TrainParams
+	procMutex    sync.Mutex
+	process      *process // process of training model
+	loopRound    uint64
+	loopStarted  bool          // loopStarted means whether Round-0 has started, repeated messages to start it are neglected
+	rpc          RpcHandler    // rpc is used to request remote mpc-node
+	rh           ResultHandler // rh handles final result which is successful or failed
+	lEvaluated   bool          // lEvaluated means whether perform LiveEvaluation
+	lEvaluator   LiveEvaluator
+	triggerInter uint64     // triggerInter is the number of interval rounds of triggering `LiveEvaluation`
+	triggerRound uint64     // if in `triggerRound`, `LiveEvaluation` will be triggered
+	fileRows     [][]string // local samples for training
+
+	status learnerStatusType
+	// stopMsgNeglected means whether to ignore the Stop signal,
+	// that's to say, the loop will continue as long as receive specific messages
+	stopSigNeglected bool
+	pauseRound       uint64 // if in `pauseRound`, the process will pause
+}
+
+func (l *Learner) Advance(payload []byte) (*pb.TrainResponse, error) {
+	m := &pbFedAvgHl.Message{}
+	err := proto.Unmarshal(payload, m)
+	if err != nil {
+		return nil, errorx.New(errcodes.ErrCodeParam, "failed to Unmarshal payload: %s", err.Error())
+	}
+
+	return l.advance(m)
+}
+
+// getTrainSet returns local training set without ID,
+// which keeps the same form as that of vertical learning after Sample Alignment
+func (l *Learner) getTrainSet() []*pbCom.TrainTaskResult_FileRow {
+	idIdx := -1
+	if len(l.fileRows) > 0 {
+		for i, name := range l.fileRows[0] {
+			if name == l.trainParams.IdName {
+				idIdx = i
+				break
+			}
+		}
+	}
+
+	var frs []*pbCom.TrainTaskResult_FileRow
+	for _, fr := range l.fileRows {
+		row := fr
+		if idIdx >= 0 {
+			row = make([]string, 0, len(fr)-1)
+			row = append(row, fr[:idIdx]...)
+			row = append(row, fr[idIdx+1:]...)
+		}
+		frs = append(frs, &pbCom.TrainTaskResult_FileRow{Row: row})
+	}
+	return frs
+}
+
+// setTrainSet set training set
+func (l *Learner) setTrainSet(file []*pbCom.TrainTaskResult_FileRow) {
+	l.fileRows = make([][]string, 0, len(file))
+	for _, r := range file {
+		l.fileRows = append(l.fileRows, r.Row)
+	}
+}
+
+// advance handles all kinds of message
+func (l *Learner) advance(message *pbFedAvgHl.Message) (*pb.TrainResponse, error) {
+	mType := message.Type
+
+	handleError := func(err error) {
+		logger.WithField("error", err.Error()).Warning("failed to train out a model")
+		res := &pbCom.TrainTaskResult{TaskID: l.id, ErrMsg: err.Error()}
+		l.rh.SaveResult(res)
+	}
+
+	// startStats starts aggregating statistics once mask seeds are agreed with all parties
+	startStats := func() error {
+		ready, err := l.process.readyToStart()
+		if err != nil {
+			return err
+		}
+		if ready {
+			go func() {
+				m := &pbFedAvgHl.Message{
+					Type: pbFedAvgHl.MessageType_MsgTrainStats,
+				}
+				l.advance(m)
+			}()
+		}
+		return nil
+	}
+
+	var ret *pb.TrainResponse
+	switch mType {
+	case pbFedAvgHl.MessageType_MsgTrainHup: // local message
+		l.procMutex.Lock()
+		defer l.procMutex.Unlock()
+		if learnerStatusGotSamples == l.status {
+			l.status = learnerStatusStartTrain
+			err := l.process.init(l.fileRows)
+			if err != nil {
+				go handleError(err)
+				return nil, err
+			}
+
+			for _, party := range l.parties {
+				m := &pbFedAvgHl.Message{
+					Type:       pbFedAvgHl.MessageType_MsgKeyExchange,
+					MaskPubkey: l.maskPub,
+				}
+				_, err = l.sendMessageWithRetry(m, party)
+				if err != nil {
+					go handleError(err)
+					return nil, err
+				}
+			}
+
+			if err := startStats(); err != nil {
+				go handleError(err)
+				return nil, err
+			}
+		}
+
+	case pbFedAvgHl.MessageType_MsgKeyExchange:
+		l.process.setMaskPubOfOther(message.From, message.MaskPubkey)
+		if err := startStats(); err != nil {
+			go handleError(err)
+			return nil, err
+		}
+		ret = &pb.TrainResponse{
+			TaskID: l.id,
+		}
+
+	case pbFedAvgHl.MessageType_MsgTrainStats: // local message
+		maskedStats, err := l.process.maskedStats()
+		if err != nil {
+			go handleError(err)
+			return nil, err
+		}
+
+		for _, party := range l.parties {
+			m := &pbFedAvgHl.Message{
+				Type:       pbFedAvgHl.MessageType_MsgMaskedStats,
+				MaskedPart: maskedStats,
+			}
+			_, err = l.sendMessageWithRetry(m, party)
+			if err != nil {
+				go handleError(err)
+				return nil, err
+			}
+		}
+
+		if l.process.setMaskedPart(statsDomain, l.address, maskedStats) {
+			go func() {
+				m := &pbFedAvgHl.Message{
+					Type: pbFedAvgHl.MessageType_MsgTrainStandardize,
+				}
+				l.advance(m)
+			}()
+		}
+
+	case pbFedAvgHl.MessageType_MsgMaskedStats:
+		if l.process.setMaskedPart(statsDomain, message.From, message.MaskedPart) {
+			go func() {
+				m := &pbFedAvgHl.Message{
+					Type: pbFedAvgHl.MessageType_MsgTrainStandardize,
+				}
+				l.advance(m)
+			}()
+		}
+		ret = &pb.TrainResponse{
+			TaskID: l.id,
+		}
+
+	case pbFedAvgHl.MessageType_MsgTrainStandardize: // local message
+		err := l.process.standardize()
+		if err != nil {
+			go handleError(err)
+			return nil, err
+		}
+
+		// if perform LiveEvaluation(Learner.lEvaluated is `true`), pack message and trigger `LiveEvaluation`,
+		// if not, enter `TrainLoop`
+		go func() {
+			if !l.lEvaluated {
+				m := &pbFedAvgHl.Message{
+					Type:      pbFedAvgHl.MessageType_MsgTrainLoop,
+					LoopRound: 0, // start Round-0
+				}
+				l.advance(m)
+			} else {
+				cbm := &pbFedAvgHl.Message{
+					Type:         pbFedAvgHl.MessageType_MsgContinueLoop,
+					LoopRound:    0, // to start Round-0
+					TriggerRound: l.triggerRound + l.triggerInter,
+				}
+				fwm := &pbFedAvgHl.Message{
+					Type:       pbFedAvgHl.MessageType_MsgTrainSet,
+					PauseRound: cbm.TriggerRound,
+				}
+				l.triggerLiveEvaluation(pb.TriggerMsgType_MsgSetAndRun, cbm, fwm)
+			}
+		}()
+
+	case pbFedAvgHl.MessageType_MsgTrainSet: // from live evaluator
+		// only learner evaluating other would receive the message
+		// set training samples, ignore `StopSignal`, set pause round, and enter `TrainHup`
+		l.procMutex.Lock()
+		defer l.procMutex.Unlock()
+		if learnerStatusWaitSamples == l.status {
+			l.pauseRound = message.PauseRound
+			l.setTrainSet(message.TrainSet)
+			l.status = learnerStatusGotSamples
+			l.stopSigNeglected = true
+			go func() {
+				m := &pbFedAvgHl.Message{
+					Type: pbFedAvgHl.MessageType_MsgTrainHup,
+				}
+				l.advance(m)
+			}()
+		}
+
+	case pbFedAvgHl.MessageType_MsgContinueLoop: // from live evaluator
+		// for learner be evaluating, set pause round, and enter `TrainLoop`
+		// for learner be evaluated, set trigger round, and enter `TrainLoop`
+		loopRound := message.LoopRound
+		if loopRound == l.loopRound {
+			if l.lEvaluated {
+				l.triggerRound = message.TriggerRound
+			} else if l.stopSigNeglected {
+				l.pauseRound = message.PauseRound
+			}
+			go func() {
+				m := &pbFedAvgHl.Message{
+					Type: pbFedAvgHl.MessageType_MsgTrainLoop,
+				}
+				if loopRound == 0 {
+					m.LoopRound = 0 // start Round-0
+				} else {
+					m.LoopRound = loopRound + 1 //for starting new round
+				}
+				l.advance(m)
+			}()
+		}
+
+	case pbFedAvgHl.MessageType_MsgTrainLoop: // local message
+		newRound := message.LoopRound
+		l.procMutex.Lock()
+		defer l.procMutex.Unlock()
+		if (newRound == 0 && !l.loopStarted) || (l.loopStarted && newRound == l.loopRound+1) {
+			l.loopStarted = true
+			l.loopRound = newRound
+			err := l.process.upRound(l.loopRound)
+			if err != nil {
+				go handleError(err)
+				return nil, err
+			}
+
+			maskedUpdate, err := l.process.maskedUpdate()
+			if err != nil {
+				go handleError(err)
+				return nil, err
+			}
+			for _, party := range l.parties {
+				m := &pbFedAvgHl.Message{
+					Type:       pbFedAvgHl.MessageType_MsgMaskedUpdate,
+					MaskedPart: maskedUpdate,
+					LoopRound:  newRound,
+				}
+				_, err = l.sendMessageWithRetry(m, party)
+				if err != nil {
+					go handleError(err)
+					return nil, err
+				}
+			}
+
+			if l.process.setMaskedPart(roundDomain(newRound), l.address, maskedUpdate) {
+				go func() {
+					m := &pbFedAvgHl.Message{
+						Type:      pbFedAvgHl.MessageType_MsgTrainAggregate,
+						LoopRound: newRound,
+					}
+					l.advance(m)
+				}()
+			}
+		}
+
+	case pbFedAvgHl.MessageType_MsgMaskedUpdate:
+		// other parties may enter next round earlier, so updates are kept by round
+		loopRound := message.LoopRound
+		if l.process.setMaskedPart(roundDomain(loopRound), message.From, message.MaskedPart) {
+			go func() {
+				m := &pbFedAvgHl.Message{
+					Type:      pbFedAvgHl.MessageType_MsgTrainAggregate,
+					LoopRound: loopRound,
+				}
+				l.advance(m)
+			}()
+		}
+		ret = &pb.TrainResponse{
+			TaskID: l.id,
+		}
+
+	case pbFedAvgHl.MessageType_MsgTrainAggregate: // local message
+		loopRound := message.LoopRound
+		if loopRound != l.loopRound {
+			break
+		}
+		stopped, err := l.process.updateModel()
+		if err != nil {
+			go handleError(err)
+			return nil, err
+		}
+
+		if l.stopSigNeglected {
+			// if all don't care about Stop signal, not need to check whether process is `Stopped` or not
+			go func() {
+				m := &pbFedAvgHl.Message{
+					Type:      pbFedAvgHl.MessageType_MsgCheckPauseRound,
+					LoopRound: loopRound,
+				}
+				l.advance(m)
+			}()
+		} else if stopped {
+			logger.WithField("loopRound", l.loopRound).Infof("learner[%s] trained out a model this round[%d], got ready to stop.", l.id, loopRound)
+			go func() {
+				m := &pbFedAvgHl.Message{
+					Type:      pbFedAvgHl.MessageType_MsgTrainModels,
+					LoopRound: loopRound,
+				}
+				l.advance(m)
+			}()
+		} else {
+			go func() {
+				// if perform `LiveEvaluation`, check if in trigger round,
+				//  if so, trigger `LiveEvaluation`,
+				//  or else, start new round
+				if l.lEvaluated && loopRound == l.triggerRound {
+					logger.WithField("loopRound", l.loopRound).Infof("learner[%s] did not train out model this round[%d], but reached trigger round[%d].", l.id, loopRound, l.triggerRound)
+					cbm := &pbFedAvgHl.Message{
+						Type:         pbFedAvgHl.MessageType_MsgContinueLoop,
+						LoopRound:    loopRound,
+						TriggerRound: l.triggerRound + l.triggerInter,
+					}
+					fwm := &pbFedAvgHl.Message{
+						Type:       pbFedAvgHl.MessageType_MsgContinueLoop,
+						LoopRound:  loopRound,
+						PauseRound: cbm.TriggerRound,
+					}
+					l.triggerLiveEvaluation(pb.TriggerMsgType_MsgGoOn, cbm, fwm)
+				} else {
+					logger.WithField("loopRound", l.loopRound).Infof("learner[%s] did not train out model this round[%d], got ready to start new round[%d].", l.id, loopRound, loopRound+1)
+					m := &pbFedAvgHl.Message{
+						Type:      pbFedAvgHl.MessageType_MsgTrainLoop,
+						LoopRound: loopRound + 1, //for starting new round
+					}
+					l.advance(m)
+				}
+			}()
+		}
+
+	case pbFedAvgHl.MessageType_MsgTrainModels: // local message
+		l.procMutex.Lock()
+		defer l.procMutex.Unlock()
+		if learnerStatusStartTrain == l.status {
+			l.status = learnerStatusEndTrain
+			model, err := l.process.getTrainModels()
+			if err != nil {
+				go handleError(err)
+				return nil, err
+			}
+			logger.WithField("loopRound", l.loopRound).Infof("learner[%s] trained out model[%v] successfully.", l.id, model)
+			res := &pbCom.TrainTaskResult{
+				TaskID:   l.id,
+				Success:  true,
+				Model:    model,
+				TrainSet: l.getTrainSet(),
+			}
+			l.rh.SaveResult(res)
+		}
+
+	case pbFedAvgHl.MessageType_MsgCheckPauseRound: // local message
+		loopRound := message.LoopRound
+
+		// determine whether it is in the pause round,
+		// if so, get the staged model and give it to `LiveEvaluator`(through calling back `Trainer`) for further processing,
+		// if it is not in the pause round, continue to the next round.
+		if l.loopRound == l.pauseRound {
+			model, err := l.process.getTrainModels()
+			if err != nil {
+				go handleError(err)
+				return nil, err
+			}
+			logger.WithField("loopRound", l.loopRound).Infof("learner[%s] trained out a staged model[%v] at the pause round[%d].", l.id, model, l.pauseRound)
+			res := &pbCom.TrainTaskResult{
+				TaskID:   l.id,
+				Success:  true,
+				Model:    model,
+				TrainSet: l.getTrainSet(),
+			}
+			l.rh.SaveResult(res)
+		} else {
+			logger.WithField("loopRound", l.loopRound).Infof("learner[%s] checked pause round and got ready to start new round[%d].", l.id, loopRound+1)
+			go func() {
+				m := &pbFedAvgHl.Message{
+					Type:      pbFedAvgHl.MessageType_MsgTrainLoop,
+					LoopRound: loopRound + 1, //for starting new round
+				}
+				l.advance(m)
+			}()
+		}
+	}
+
+	logger.WithFields(logrus.Fields{
+		"address":      l.address,
+		"loopRound":    l.loopRound,
+		"messageRound": message.LoopRound,
+	}).Infof("learner[%s] finished advance . message %s", l.id, message.Type.String())
+	return ret, nil
+}
+
+// triggerLiveEvaluation packs message and trigger `LiveEvaluation`
+func (l *Learner) triggerLiveEvaluation(msgType pb.TriggerMsgType, callbackMsg *pbFedAvgHl.Message, forward *pbFedAvgHl.Message) error {
+	callbackPayload, err := proto.Marshal(callbackMsg)
+	if err != nil {
+		logger.WithField("loopRound", l.loopRound).Warnf("failed to Marshal message: %s", err.Error())
+		return err
+	}
+	payload, err := proto.Marshal(forward)
+	if err != nil {
+		logger.WithField("loopRound", l.loopRound).Warnf("failed to Marshal message: %s", err.Error())
+		return err
+	}
+
+	m := &pb.LiveEvaluationTriggerMsg{
+		Type:            msgType,
+		PauseRound:      forward.PauseRound,
+		CallbackPayload: callbackPayload,
+		Payload:         payload,
+	}
+	if msgType == pb.TriggerMsgType_MsgSetAndRun {
+		m.TrainSet = l.getTrainSet()
+	}
+
+	err = l.lEvaluator.Trigger(m)
+	if err != nil {
+		logger.WithField("loopRound", l.loopRound).Warnf("failed to trigger evaluation: %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// sendMessageWithRetry sends message to remote mpc-node
+// retries 2 times at most
+func (l *Learner) sendMessageWithRetry(message *pbFedAvgHl.Message, address string) (*pbFedAvgHl.Message, error) {
+	times := 3
+
+	var m *pbFedAvgHl.Message
+	var err error
+	for i := 0; i < times; i++ {
+		if i > 0 {
+			time.Sleep(3 * time.Second)
+		}
+		m, err = l.sendMessage(message, address)
+		if err == nil {
+			break
+		}
+	}
+
+	return m, err
+}
+
+// sendMessage sends message to remote mpc-node
+func (l *Learner) sendMessage(message *pbFedAvgHl.Message, address string) (*pbFedAvgHl.Message, error) {
+	message.From = l.address
+	message.To = address
+
+	payload, err := proto.Marshal(message)
+	if err != nil {
+		return nil, errorx.New(errcodes.ErrCodeInternal, "failed to Marshal payload: %s", err.Error())
+	}
+
+	trainReq := &pb.TrainRequest{
+		TaskID:  l.id,
+		Algo:    l.algo,
+		Payload: payload,
+	}
+	resp, err := l.rpc.StepTrain(trainReq, address)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &pbFedAvgHl.Message{}
+	if len(resp.Payload) != 0 {
+		err := proto.Unmarshal(resp.Payload, m)
+		if err != nil {
+			return nil, errorx.New(errcodes.ErrCodeInternal, "failed to Unmarshal payload[%s] from[%s] and err is[%s] ", string(resp.Payload), address, err.Error())
+		}
+	}
+	return m, nil
+}
+
+// NewLearner returns a horizontal Learner based on FedAvg
+// id is the assigned id for Learner
+// address indicates local mpc-node
+// algo is Algorithm_LINEAR_REGRESSION_HL or Algorithm_LOGIC_REGRESSION_HL
+// parties are other learners who participates in MPC, assigned with mpc-node address usually
+// rpc is used to request remote mpc-node
+// rh handles final result which is successful or failed
+// params are parameters for training model
+// samplesFile contains samples for training model
+// le is an LiveEvaluator, and LiveEvaluation will be performed by learner if it is assigned without nil
+func NewLearner(id string, address string, algo pbCom.Algorithm, params *pbCom.TrainParams, samplesFile []byte,
+	parties []string, rpc RpcHandler, rh ResultHandler, le LiveEvaluator) (*Learner, error) {
+
+	fileRows, err := csv.ReadRowsFromFile(samplesFile)
+	if err != nil {
+		return nil, errorx.New(errcodes.ErrCodeParam, "failed to read samples: %s", err.Error())
+	}
+
+	l, err := NewLearnerWithoutSamples(id, address, algo, params, parties, rpc, rh)
+	if err != nil {
+		return nil, err
+	}
+	l.fileRows = fileRows
+	l.status = learnerStatusGotSamples
+	if le != nil {
+		l.lEvaluated = true
+		l.lEvaluator = le
+		l.triggerInter = 5 // it shoule be configured later
+	}
+
+	// start training
+	go func() {
+		m := &pbFedAvgHl.Message{
+			Type: pbFedAvgHl.MessageType_MsgTrainHup,
+		}
+		l.advance(m)
+	}()
+	return l, nil
+}
+
+// NewLearnerWithoutSamples returns a horizontal Learner based on FedAvg but doesn't run it
+// id is the assigned id for Learner
+// address indicates local mpc-node
+// algo is Algorithm_LINEAR_REGRESSION_HL or Algorithm_LOGIC_REGRESSION_HL
+// parties are other learners who participates in MPC, assigned with mpc-node address usually
+// rpc is used to request remote mpc-node
+// rh handles final result which is successful or failed
+// params are parameters for training model
+func NewLearnerWithoutSamples(id string, address string, algo pbCom.Algorithm, params *pbCom.TrainParams,
+	parties []string, rpc RpcHandler, rh ResultHandler) (*Learner, error) {
+
+	// with only one other party, its local statistics and updates could be derived from the aggregated ones
+	if len(parties)+1 < fedavg.MinParties {
+		return nil, errorx.New(errcodes.ErrCodeParam, "at least %d parties are required in horizontal learning, got %d", fedavg.MinParties, len(parties)+1)
+	}
+
+	maskPriv, maskPub, err := fedavg.GenerateMaskKeyPair()
+	if err != nil {
+		return nil, errorx.New(errcodes.ErrCodeInternal, "failed to generate mask key pair: %s", err.Error())
+	}
+
+	l := &Learner{
+		id:          id,
+		algo:        algo,
+		address:     address,
+		parties:     parties,
+		maskPub:     maskPub,
+		trainParams: params,
+		process:     newProcess(algo, maskPriv, maskPub, params, parties),
+		rpc:         rpc,
+		rh:          rh,
+		status:      learnerStatusWaitSamples,
+	}
+
+	return l, nil
+}
@@ -0,0 +1,263 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fedavg_hl
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"sync"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+
+	"github.com/PaddlePaddle/PaddleDTX/dai/crypto/hl/fedavg"
+	"github.com/PaddlePaddle/PaddleDTX/dai/errcodes"
+	pbCom "github.com/PaddlePaddle/PaddleDTX/dai/protos/common"
+)
+
+// statsDomain is the domain of masks for statistics used by global standardization
+const statsDomain = "stats"
+
+// roundDomain returns the domain of masks for model updates of a round
+func roundDomain(round uint64) string {
+	return fmt.Sprintf("round-%d", round)
+}
+
+type process struct {
+	round    uint64
+	algo     pbCom.Algorithm
+	maskPriv *ecdsa.PrivateKey // private key to agree on mask seeds with other parties
+	maskPub  []byte            // public key for transfer
+	params   *pbCom.TrainParams
+	fileRows [][]string // file rows obtained from sample file
+	parties  []string   // other parties, every one holds different samples with the same features
+
+	trainDataSet    *fedavg.TrainDataSet // own data set for training, formatted from filesRow
+	maskPubOfOthers map[string][]byte    // public keys of other parties
+	pairMasks       []*fedavg.PairMask   // masks shared with other parties
+	started         bool                 // whether secure aggregation has started
+
+	mutex sync.Mutex
+
+	// masked vectors of all parties including local one, mapped by mask domain and then by party
+	maskedParts map[string]map[string][]byte
+	aggregated  map[string]bool // mask domains which have been aggregated
+
+	cost, lastCost float64
+	thetas         []float64 // global model of current round
+}
+
+// init initialize Process, before training
+func (p *process) init(fileRows [][]string) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.fileRows = fileRows
+
+	trainDataSet, err := fedavg.GetTrainDataSetFromFile(p.fileRows, p.algo, *p.params)
+	if err != nil {
+		return errorx.New(errcodes.ErrCodeInternal, "mistake[%s] happened when fedavg_hl GetTrainDataSetFromFile", err.Error())
+	}
+	p.trainDataSet = trainDataSet
+
+	return nil
+}
+
+// setMaskPubOfOther save public key from other party, used for mask seeds agreement
+func (p *process) setMaskPubOfOther(party string, maskPubOfOther []byte) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.maskPubOfOthers[party] = maskPubOfOther
+}
+
+// readyToStart returns true only once when local process is initialized and public keys of all parties are received,
+// and mask seeds are agreed with other parties at the time
+func (p *process) readyToStart() (bool, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.started || p.trainDataSet == nil || len(p.maskPubOfOthers) < len(p.parties) {
+		return false, nil
+	}
+	for _, party := range p.parties {
+		pm, err := fedavg.NewPairMask(p.maskPriv, p.maskPub, p.maskPubOfOthers[party])
+		if err != nil {
+			return false, errorx.New(errcodes.ErrCodeInternal, "mistake[%s] happened when fedavg_hl NewPairMask with party[%s]", err.Error(), party)
+		}
+		p.pairMasks = append(p.pairMasks, pm)
+	}
+	p.started = true
+	return true, nil
+}
+
+// maskVector covers local vector with pairwise masks shared with other parties
+func (p *process) maskVector(values []float64, domain string) ([]byte, error) {
+	masked, err := fedavg.MaskVector(values, domain, p.pairMasks, fedavg.GetAccuracy(*p.params))
+	if err != nil {
+		return nil, errorx.New(errcodes.ErrCodeInternal, "mistake[%s] happened when fedavg_hl MaskVector", err.Error())
+	}
+	return masked, nil
+}
+
+// maskedStats returns local statistics covered by masks
+func (p *process) maskedStats() ([]byte, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.maskVector(fedavg.LocalStats(p.trainDataSet, p.algo), statsDomain)
+}
+
+// setMaskedPart keeps masked vector of the party for the domain,
+// returns true if vectors of all parties are received just now
+func (p *process) setMaskedPart(domain, party string, part []byte) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.aggregated[domain] {
+		return false
+	}
+	parts, ok := p.maskedParts[domain]
+	if !ok {
+		parts = make(map[string][]byte)
+		p.maskedParts[domain] = parts
+	}
+	if _, ok := parts[party]; ok {
+		return false
+	}
+	parts[party] = part
+
+	if len(parts) == len(p.parties)+1 {
+		p.aggregated[domain] = true
+		return true
+	}
+	return false
+}
+
+// aggregate sums up masked vectors of all parties for the domain
+func (p *process) aggregate(domain string) ([]float64, error) {
+	var parts [][]byte
+	for _, part := range p.maskedParts[domain] {
+		parts = append(parts, part)
+	}
+	delete(p.maskedParts, domain)
+
+	sum, err := fedavg.AggregateVectors(parts, fedavg.GetAccuracy(*p.params))
+	if err != nil {
+		return nil, errorx.New(errcodes.ErrCodeInternal, "mistake[%s] happened when fedavg_hl AggregateVectors", err.Error())
+	}
+	return sum, nil
+}
+
+// standardize standardizes local samples with global statistics, and initializes model
+func (p *process) standardize() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	globalStats, err := p.aggregate(statsDomain)
+	if err != nil {
+		return err
+	}
+	err = fedavg.Standardize(p.trainDataSet, globalStats, p.algo, *p.params)
+	if err != nil {
+		return errorx.New(errcodes.ErrCodeInternal, "mistake[%s] happened when fedavg_hl Standardize", err.Error())
+	}
+	p.thetas = fedavg.InitThetas(p.trainDataSet)
+	return nil
+}
+
+// upRound enter next round
+func (p *process) upRound(newRound uint64) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if newRound == p.round {
+		return nil
+	}
+
+	if newRound != p.round+1 {
+		return errorx.New(errcodes.ErrCodeParam, "target round %d dismatch process.round %d", newRound, p.round)
+	}
+
+	p.round++
+	return nil
+}
+
+// maskedUpdate trains global model with local samples,
+// returns local model update and cost weighted by the number of samples and covered by masks
+func (p *process) maskedUpdate() ([]byte, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	cost := fedavg.CalCost(p.trainDataSet, p.thetas, p.algo, *p.params)
+	newThetas := fedavg.LocalUpdate(p.trainDataSet, p.thetas, p.algo, *p.params, int(p.round))
+	vector := fedavg.UpdateVector(newThetas, cost, len(p.trainDataSet.X))
+
+	return p.maskVector(vector, roundDomain(p.round))
+}
+
+// updateModel aggregates model updates of all parties as global model of next round,
+// every party gets the same global model and cost, so whether to stop is decided locally
+func (p *process) updateModel() (bool, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	aggregated, err := p.aggregate(roundDomain(p.round))
+	if err != nil {
+		return false, err
+	}
+	thetas, cost, err := fedavg.AverageUpdate(aggregated, len(p.thetas))
+	if err != nil {
+		return false, errorx.New(errcodes.ErrCodeInternal, "mistake[%s] happened when fedavg_hl AverageUpdate", err.Error())
+	}
+
+	p.lastCost = p.cost
+	p.cost = cost
+	p.thetas = thetas
+
+	var stopped bool
+	if p.round > 0 {
+		stopped = fedavg.StopTraining(p.lastCost, p.cost, *p.params)
+	}
+	logger.Infof("updateModel lastCost %v, cost %v, round %d", p.lastCost, p.cost, p.round)
+
+	return stopped, nil
+}
+
+// getTrainModels retrieve own model
+func (p *process) getTrainModels() ([]byte, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	modelBytes, err := fedavg.TrainModelsToBytes(p.thetas, p.trainDataSet, *p.params)
+	if err != nil {
+		return []byte{}, errorx.New(errcodes.ErrCodeInternal, "mistake[%s] happened when fedavg_hl trainModelsToBytes", err.Error())
+	}
+
+	return modelBytes, nil
+}
+
+// newProcess init process by mask key, training task params and other parties
+func newProcess(algo pbCom.Algorithm, maskPriv *ecdsa.PrivateKey, maskPub []byte,
+	params *pbCom.TrainParams, parties []string) *process {
+	return &process{
+		algo:            algo,
+		maskPriv:        maskPriv,
+		maskPub:         maskPub,
+		params:          params,
+		parties:         parties,
+		maskPubOfOthers: make(map[string][]byte),
+		maskedParts:     make(map[string]map[string][]byte),
+		aggregated:      make(map[string]bool),
+	}
+}
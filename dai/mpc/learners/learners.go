@@ -14,9 +14,14 @@
 package learners
 
 import (
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+
+	"github.com/PaddlePaddle/PaddleDTX/dai/errcodes"
 	"github.com/PaddlePaddle/PaddleDTX/dai/mpc/learners/dnn_paddlefl_vl"
+	"github.com/PaddlePaddle/PaddleDTX/dai/mpc/learners/fedavg_hl"
 	"github.com/PaddlePaddle/PaddleDTX/dai/mpc/learners/linear_reg_vl"
 	"github.com/PaddlePaddle/PaddleDTX/dai/mpc/learners/logic_reg_vl"
+	"github.com/PaddlePaddle/PaddleDTX/dai/mpc/learners/secureboost_vl"
 	pbCom "github.com/PaddlePaddle/PaddleDTX/dai/protos/common"
 	pb "github.com/PaddlePaddle/PaddleDTX/dai/protos/mpc"
 )
@@ -80,6 +85,11 @@ func NewLearner(id string, address string, algo pbCom.Algorithm,
 			parties, rpc, rh, le)
 	} else if pbCom.Algorithm_DNN_PADDLEFL_VL == algo {
 		return dnn_paddlefl_vl.NewLearner(id, address, params, samplesFile, parties, paddleFLParams, rpc, rh)
+	} else if pbCom.Algorithm_SECUREBOOST_VL == algo {
+		return secureboost_vl.NewLearner(id, address, params, samplesFile, parties, rpc, rh)
+	} else if pbCom.Algorithm_LINEAR_REGRESSION_HL == algo || pbCom.Algorithm_LOGIC_REGRESSION_HL == algo {
+		return fedavg_hl.NewLearner(id, address, algo, params, samplesFile,
+			parties, rpc, rh, le)
 	} else { // pbCom.Algorithm_LOGIC_REGRESSION_VL
 		return logic_reg_vl.NewLearner(id, address, params, samplesFile,
 			parties, rpc, rh, le)
@@ -102,6 +112,11 @@ func NewLearnerWithoutSamples(id string, address string, algo pbCom.Algorithm,
 			parties, rpc, rh)
 	} else if pbCom.Algorithm_DNN_PADDLEFL_VL == algo {
 		panic("Algorithm_DNN_PADDLEFL_VL NewLearnerWithoutSamples")
+	} else if pbCom.Algorithm_SECUREBOOST_VL == algo {
+		return nil, errorx.New(errcodes.ErrCodeParam, "live evaluation is not supported by Algorithm_SECUREBOOST_VL")
+	} else if pbCom.Algorithm_LINEAR_REGRESSION_HL == algo || pbCom.Algorithm_LOGIC_REGRESSION_HL == algo {
+		return fedavg_hl.NewLearnerWithoutSamples(id, address, algo, params,
+			parties, rpc, rh)
 	} else { // pbCom.Algorithm_LOGIC_REGRESSION_VL
 		return logic_reg_vl.NewLearnerWithoutSamples(id, address, params,
 			parties, rpc, rh)
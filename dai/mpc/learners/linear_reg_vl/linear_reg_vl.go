@@ -189,21 +189,40 @@ func (l *Learner) advance(message *pbLinearRegVl.Message) (*pb.TrainResponse, er
 		}()
 
 	case pbLinearRegVl.MessageType_MsgPsiAskReEnc: // local message
-		newMess := &pbLinearRegVl.Message{
-			Type:              pbLinearRegVl.MessageType_MsgPsiReEnc,
-			VlLPsiReEncIDsReq: message.VlLPsiReEncIDsReq,
-			LoopRound:         l.loopRound,
-		}
-		reM, err := l.sendMessageWithRetry(newMess, l.parties[0])
-		if err != nil {
-			go handleError(err)
-			return nil, err
+		// local ID-Set is re-encrypted by other parties one by one,
+		// then the final one is sent to the parties who haven't seen it, the last one in chain already has it
+		newMess := func(encIDs []byte) *pbLinearRegVl.Message {
+			return &pbLinearRegVl.Message{
+				Type: pbLinearRegVl.MessageType_MsgPsiReEnc,
+				VlLPsiReEncIDsReq: &pb.VLPsiReEncIDsRequest{
+					TaskID: l.id,
+					EncIDs: encIDs,
+				},
+				LoopRound: l.loopRound,
+			}
 		}
 
-		done, err := l.psi.SetReEncryptIDSet(l.parties[0], reM.VlLPsiReEncIDsResp.ReEncIDs)
-		if err != nil {
-			go handleError(err)
-			return nil, err
+		var done bool
+		encIDs := message.VlLPsiReEncIDsReq.EncIDs
+		for _, party := range l.parties {
+			reM, err := l.sendMessageWithRetry(newMess(encIDs), party)
+			if err != nil {
+				go handleError(err)
+				return nil, err
+			}
+
+			done, err = l.psi.SetReEncryptIDSet(party, reM.VlLPsiReEncIDsResp.ReEncIDs)
+			if err != nil {
+				go handleError(err)
+				return nil, err
+			}
+			encIDs = reM.VlLPsiReEncIDsResp.ReEncIDs
+		}
+		for _, party := range l.parties[:len(l.parties)-1] {
+			if _, err := l.sendMessageWithRetry(newMess(encIDs), party); err != nil {
+				go handleError(err)
+				return nil, err
+			}
 		}
 
 		if done {
@@ -283,15 +302,17 @@ func (l *Learner) advance(message *pbLinearRegVl.Message) (*pb.TrainResponse, er
 				return nil, err
 			}
 
-			m := &pbLinearRegVl.Message{
-				Type:       pbLinearRegVl.MessageType_MsgHomoPubkey,
-				HomoPubkey: l.homoPub,
-				LoopRound:  l.loopRound,
-			}
-			_, err = l.sendMessageWithRetry(m, l.parties[0])
-			if err != nil {
-				go handleError(err)
-				return nil, err
+			for _, party := range l.parties {
+				m := &pbLinearRegVl.Message{
+					Type:       pbLinearRegVl.MessageType_MsgHomoPubkey,
+					HomoPubkey: l.homoPub,
+					LoopRound:  l.loopRound,
+				}
+				_, err = l.sendMessageWithRetry(m, party)
+				if err != nil {
+					go handleError(err)
+					return nil, err
+				}
 			}
 
 			go func() {
@@ -360,7 +381,7 @@ func (l *Learner) advance(message *pbLinearRegVl.Message) (*pb.TrainResponse, er
 
 	case pbLinearRegVl.MessageType_MsgHomoPubkey:
 		homoPubkeyOfOther := message.HomoPubkey
-		l.process.setHomoPubOfOther(homoPubkeyOfOther)
+		l.process.setHomoPubOfOther(message.From, homoPubkeyOfOther)
 		ret = &pb.TrainResponse{
 			TaskID: l.id,
 		}
@@ -395,15 +416,17 @@ func (l *Learner) advance(message *pbLinearRegVl.Message) (*pb.TrainResponse, er
 			}
 
 			if t == 1 {
-				m := &pbLinearRegVl.Message{
-					Type:      pbLinearRegVl.MessageType_MsgTrainPartBytes,
-					PartBytes: partBytesForOther,
-					LoopRound: loopRound,
-				}
-				_, err = l.sendMessageWithRetry(m, l.parties[0])
-				if err != nil {
-					go handleError(err)
-					return nil, err
+				for _, party := range l.parties {
+					m := &pbLinearRegVl.Message{
+						Type:      pbLinearRegVl.MessageType_MsgTrainPartBytes,
+						PartBytes: partBytesForOther,
+						LoopRound: loopRound,
+					}
+					_, err = l.sendMessageWithRetry(m, party)
+					if err != nil {
+						go handleError(err)
+						return nil, err
+					}
 				}
 
 				go func() {
@@ -420,7 +443,7 @@ func (l *Learner) advance(message *pbLinearRegVl.Message) (*pb.TrainResponse, er
 		loopRound := message.LoopRound
 		partBytesFromOther := message.PartBytes
 		if loopRound == l.loopRound || loopRound == l.loopRound+1 {
-			err := l.process.setPartBytesFromOther(partBytesFromOther, loopRound)
+			err := l.process.setPartBytesFromOther(message.From, partBytesFromOther, loopRound)
 			if err != nil {
 				go handleError(err)
 				return nil, err
@@ -443,25 +466,30 @@ func (l *Learner) advance(message *pbLinearRegVl.Message) (*pb.TrainResponse, er
 	case pbLinearRegVl.MessageType_MsgTrainCalEncGradCost: // local message
 		loopRound := message.LoopRound
 		if loopRound == l.loopRound {
-			encGradForOther, encCostForOther, t, err := l.process.calEncGradientAndCost()
+			encGradForOthers, encCostForOthers, err := l.process.calEncGradientAndCost()
 			if err != nil {
 				go handleError(err)
 				return nil, err
 			}
 
-			if t == 1 {
+			// only send to the parties calculated just now, wait for parts of others
+			for _, party := range l.parties {
+				encGradForOther, ok := encGradForOthers[party]
+				if !ok {
+					continue
+				}
 				m := &pbLinearRegVl.Message{
 					Type:             pbLinearRegVl.MessageType_MsgTrainEncGradCost,
 					EncGradFromOther: encGradForOther,
-					EncCostFromOther: encCostForOther,
+					EncCostFromOther: encCostForOthers[party],
 					LoopRound:        loopRound,
 				}
-				_, err = l.sendMessageWithRetry(m, l.parties[0])
+				_, err = l.sendMessageWithRetry(m, party)
 				if err != nil {
 					go handleError(err)
 					return nil, err
 				}
-			} // else wait for message
+			}
 		}
 
 	case pbLinearRegVl.MessageType_MsgTrainEncGradCost:
@@ -469,8 +497,8 @@ func (l *Learner) advance(message *pbLinearRegVl.Message) (*pb.TrainResponse, er
 		encGradFromOther := message.EncGradFromOther
 		encCostFromOther := message.EncCostFromOther
 		if loopRound == l.loopRound {
-			t := l.process.setEncGradientAndCostFromOther(encGradFromOther, encCostFromOther)
-			if t == 1 {
+			first := l.process.setEncGradientAndCostFromOther(message.From, encGradFromOther, encCostFromOther)
+			if first {
 				go func() {
 					m := &pbLinearRegVl.Message{
 						Type:      pbLinearRegVl.MessageType_MsgTrainDecLocalGradCost,
@@ -487,20 +515,24 @@ func (l *Learner) advance(message *pbLinearRegVl.Message) (*pb.TrainResponse, er
 	case pbLinearRegVl.MessageType_MsgTrainDecLocalGradCost: // local message
 		loopRound := message.LoopRound
 		if loopRound == l.loopRound {
-			gradBytesForOther, costBytesForOther, t, err := l.process.decGradientAndCost()
+			gradBytesForOthers, costBytesForOthers, err := l.process.decGradientAndCost()
 			if err != nil {
 				go handleError(err)
 				return nil, err
 			}
 
-			if t == 1 {
+			for _, party := range l.parties {
+				gradBytesForOther, ok := gradBytesForOthers[party]
+				if !ok {
+					continue
+				}
 				m := &pbLinearRegVl.Message{
 					Type:      pbLinearRegVl.MessageType_MsgTrainGradAndCost,
 					GradBytes: gradBytesForOther,
-					CostBytes: costBytesForOther,
+					CostBytes: costBytesForOthers[party],
 					LoopRound: loopRound,
 				}
-				_, err = l.sendMessageWithRetry(m, l.parties[0])
+				_, err = l.sendMessageWithRetry(m, party)
 				if err != nil {
 					go handleError(err)
 					return nil, err
@@ -513,8 +545,8 @@ func (l *Learner) advance(message *pbLinearRegVl.Message) (*pb.TrainResponse, er
 		gradBytesFromOther := message.GradBytes
 		costBytesFromOther := message.CostBytes
 		if loopRound == l.loopRound {
-			t := l.process.SetGradientAndCostFromOther(gradBytesFromOther, costBytesFromOther)
-			if t == 1 {
+			done := l.process.SetGradientAndCostFromOther(message.From, gradBytesFromOther, costBytesFromOther)
+			if done {
 				go func() {
 					m := &pbLinearRegVl.Message{
 						Type:      pbLinearRegVl.MessageType_MsgTrainUpdCostGrad,
@@ -537,16 +569,18 @@ func (l *Learner) advance(message *pbLinearRegVl.Message) (*pb.TrainResponse, er
 				return nil, err
 			}
 
-			m := &pbLinearRegVl.Message{
-				Type:      pbLinearRegVl.MessageType_MsgTrainStatus,
-				Stopped:   stopped,
-				LoopRound: loopRound,
-			}
-			logger.Infof("learner[%s] send to remote learner[%s]'s status[%t], loopRound[%d].", l.id, l.parties[0], stopped, l.loopRound)
-			_, err = l.sendMessageWithRetry(m, l.parties[0])
-			if err != nil {
-				go handleError(err)
-				return nil, err
+			for _, party := range l.parties {
+				m := &pbLinearRegVl.Message{
+					Type:      pbLinearRegVl.MessageType_MsgTrainStatus,
+					Stopped:   stopped,
+					LoopRound: loopRound,
+				}
+				logger.Infof("learner[%s] send to remote learner[%s]'s status[%t], loopRound[%d].", l.id, party, stopped, l.loopRound)
+				_, err = l.sendMessageWithRetry(m, party)
+				if err != nil {
+					go handleError(err)
+					return nil, err
+				}
 			}
 
 			go func() {
@@ -564,7 +598,7 @@ func (l *Learner) advance(message *pbLinearRegVl.Message) (*pb.TrainResponse, er
 		if loopRound == l.loopRound {
 			otherStopped := message.Stopped
 			logger.Infof("learner[%s] got remote learner[%s]'s status[%t], loopRound[%d].", l.id, message.From, otherStopped, l.loopRound)
-			l.process.setOtherStatus(otherStopped)
+			l.process.setOtherStatus(message.From, otherStopped)
 
 			go func() {
 				m := &pbLinearRegVl.Message{
@@ -811,7 +845,7 @@ func (l *Learner) sendMessage(message *pbLinearRegVl.Message, address string) (*
 func NewLearner(id string, address string, params *pbCom.TrainParams, samplesFile []byte,
 	parties []string, rpc RpcHandler, rh ResultHandler, le LiveEvaluator) (*Learner, error) {
 
-	p, err := psi.NewVLTwoPartsPSI(address, samplesFile, params.GetIdName(), parties)
+	p, err := psi.NewVLPSI(address, samplesFile, params.GetIdName(), parties)
 	if err != nil {
 		return nil, err
 	}
@@ -830,7 +864,7 @@ func NewLearner(id string, address string, params *pbCom.TrainParams, samplesFil
 		homoPub:     homoPub,
 		psi:         p,
 		trainParams: params,
-		process:     newProcess(homoPriv, params),
+		process:     newProcess(homoPriv, params, parties),
 		samplesFile: samplesFile,
 		rpc:         rpc,
 		rh:          rh,
@@ -875,7 +909,7 @@ func NewLearnerWithoutSamples(id string, address string, params *pbCom.TrainPara
 		homoPriv:    homoPriv,
 		homoPub:     homoPub,
 		trainParams: params,
-		process:     newProcess(homoPriv, params),
+		process:     newProcess(homoPriv, params, parties),
 		rpc:         rpc,
 		rh:          rh,
 		status:      learnerStatusStartPSI,
@@ -187,21 +187,40 @@ func (l *Learner) advance(message *pbLogicRegVl.Message) (*pb.TrainResponse, err
 		}()
 
 	case pbLogicRegVl.MessageType_MsgPsiAskReEnc: // local message
-		newMess := &pbLogicRegVl.Message{
-			Type:              pbLogicRegVl.MessageType_MsgPsiReEnc,
-			VlLPsiReEncIDsReq: message.VlLPsiReEncIDsReq,
-			LoopRound:         l.loopRound,
-		}
-		reM, err := l.sendMessageWithRetry(newMess, l.parties[0])
-		if err != nil {
-			go handleError(err)
-			return nil, err
+		// local ID-Set is re-encrypted by other parties one by one,
+		// then the final one is sent to the parties who haven't seen it, the last one in chain already has it
+		newMess := func(encIDs []byte) *pbLogicRegVl.Message {
+			return &pbLogicRegVl.Message{
+				Type: pbLogicRegVl.MessageType_MsgPsiReEnc,
+				VlLPsiReEncIDsReq: &pb.VLPsiReEncIDsRequest{
+					TaskID: l.id,
+					EncIDs: encIDs,
+				},
+				LoopRound: l.loopRound,
+			}
 		}
 
-		done, err := l.psi.SetReEncryptIDSet(l.parties[0], reM.VlLPsiReEncIDsResp.ReEncIDs)
-		if err != nil {
-			go handleError(err)
-			return nil, err
+		var done bool
+		encIDs := message.VlLPsiReEncIDsReq.EncIDs
+		for _, party := range l.parties {
+			reM, err := l.sendMessageWithRetry(newMess(encIDs), party)
+			if err != nil {
+				go handleError(err)
+				return nil, err
+			}
+
+			done, err = l.psi.SetReEncryptIDSet(party, reM.VlLPsiReEncIDsResp.ReEncIDs)
+			if err != nil {
+				go handleError(err)
+				return nil, err
+			}
+			encIDs = reM.VlLPsiReEncIDsResp.ReEncIDs
+		}
+		for _, party := range l.parties[:len(l.parties)-1] {
+			if _, err := l.sendMessageWithRetry(newMess(encIDs), party); err != nil {
+				go handleError(err)
+				return nil, err
+			}
 		}
 
 		if done {
@@ -282,15 +301,17 @@ func (l *Learner) advance(message *pbLogicRegVl.Message) (*pb.TrainResponse, err
 				return nil, err
 			}
 
-			m := &pbLogicRegVl.Message{
-				Type:       pbLogicRegVl.MessageType_MsgHomoPubkey,
-				HomoPubkey: l.homoPub,
-				LoopRound:  l.loopRound,
-			}
-			_, err = l.sendMessageWithRetry(m, l.parties[0])
-			if err != nil {
-				go handleError(err)
-				return nil, err
+			for _, party := range l.parties {
+				m := &pbLogicRegVl.Message{
+					Type:       pbLogicRegVl.MessageType_MsgHomoPubkey,
+					HomoPubkey: l.homoPub,
+					LoopRound:  l.loopRound,
+				}
+				_, err = l.sendMessageWithRetry(m, party)
+				if err != nil {
+					go handleError(err)
+					return nil, err
+				}
 			}
 
 			// if perform LiveEvaluation(Learner.lEvaluated is `true`), pack message and trigger `LiveEvaluation`,
@@ -361,7 +382,7 @@ func (l *Learner) advance(message *pbLogicRegVl.Message) (*pb.TrainResponse, err
 
 	case pbLogicRegVl.MessageType_MsgHomoPubkey:
 		homoPubkeyOfOther := message.HomoPubkey
-		l.process.setHomoPubOfOther(homoPubkeyOfOther)
+		l.process.setHomoPubOfOther(message.From, homoPubkeyOfOther)
 		ret = &pb.TrainResponse{
 			TaskID: l.id,
 		}
@@ -396,15 +417,17 @@ func (l *Learner) advance(message *pbLogicRegVl.Message) (*pb.TrainResponse, err
 			}
 
 			if t == 1 {
-				m := &pbLogicRegVl.Message{
-					Type:      pbLogicRegVl.MessageType_MsgTrainPartBytes,
-					PartBytes: partBytesForOther,
-					LoopRound: loopRound,
-				}
-				_, err = l.sendMessageWithRetry(m, l.parties[0])
-				if err != nil {
-					go handleError(err)
-					return nil, err
+				for _, party := range l.parties {
+					m := &pbLogicRegVl.Message{
+						Type:      pbLogicRegVl.MessageType_MsgTrainPartBytes,
+						PartBytes: partBytesForOther,
+						LoopRound: loopRound,
+					}
+					_, err = l.sendMessageWithRetry(m, party)
+					if err != nil {
+						go handleError(err)
+						return nil, err
+					}
 				}
 
 				go func() {
@@ -421,7 +444,7 @@ func (l *Learner) advance(message *pbLogicRegVl.Message) (*pb.TrainResponse, err
 		loopRound := message.LoopRound
 		partBytesFromOther := message.PartBytes
 		if loopRound == l.loopRound || loopRound == l.loopRound+1 {
-			err := l.process.setPartBytesFromOther(partBytesFromOther, loopRound)
+			err := l.process.setPartBytesFromOther(message.From, partBytesFromOther, loopRound)
 			if err != nil {
 				go handleError(err)
 				return nil, err
@@ -444,25 +467,30 @@ func (l *Learner) advance(message *pbLogicRegVl.Message) (*pb.TrainResponse, err
 	case pbLogicRegVl.MessageType_MsgTrainCalEncGradCost: // local message
 		loopRound := message.LoopRound
 		if loopRound == l.loopRound {
-			encGradForOther, encCostForOther, t, err := l.process.calEncGradientAndCost()
+			encGradForOthers, encCostForOthers, err := l.process.calEncGradientAndCost()
 			if err != nil {
 				go handleError(err)
 				return nil, err
 			}
 
-			if t == 1 {
+			// only send to the parties calculated just now, wait for parts of others
+			for _, party := range l.parties {
+				encGradForOther, ok := encGradForOthers[party]
+				if !ok {
+					continue
+				}
 				m := &pbLogicRegVl.Message{
 					Type:             pbLogicRegVl.MessageType_MsgTrainEncGradCost,
 					EncGradFromOther: encGradForOther,
-					EncCostFromOther: encCostForOther,
+					EncCostFromOther: encCostForOthers[party],
 					LoopRound:        loopRound,
 				}
-				_, err = l.sendMessageWithRetry(m, l.parties[0])
+				_, err = l.sendMessageWithRetry(m, party)
 				if err != nil {
 					go handleError(err)
 					return nil, err
 				}
-			} // else wait for message
+			}
 		}
 
 	case pbLogicRegVl.MessageType_MsgTrainEncGradCost:
@@ -470,8 +498,8 @@ func (l *Learner) advance(message *pbLogicRegVl.Message) (*pb.TrainResponse, err
 		encGradFromOther := message.EncGradFromOther
 		encCostFromOther := message.EncCostFromOther
 		if loopRound == l.loopRound {
-			t := l.process.setEncGradientAndCostFromOther(encGradFromOther, encCostFromOther)
-			if t == 1 {
+			first := l.process.setEncGradientAndCostFromOther(message.From, encGradFromOther, encCostFromOther)
+			if first {
 				go func() {
 					m := &pbLogicRegVl.Message{
 						Type:      pbLogicRegVl.MessageType_MsgTrainDecLocalGradCost,
@@ -488,20 +516,24 @@ func (l *Learner) advance(message *pbLogicRegVl.Message) (*pb.TrainResponse, err
 	case pbLogicRegVl.MessageType_MsgTrainDecLocalGradCost: // local message
 		loopRound := message.LoopRound
 		if loopRound == l.loopRound {
-			gradBytesForOther, costBytesForOther, t, err := l.process.decGradientAndCost()
+			gradBytesForOthers, costBytesForOthers, err := l.process.decGradientAndCost()
 			if err != nil {
 				go handleError(err)
 				return nil, err
 			}
 
-			if t == 1 {
+			for _, party := range l.parties {
+				gradBytesForOther, ok := gradBytesForOthers[party]
+				if !ok {
+					continue
+				}
 				m := &pbLogicRegVl.Message{
 					Type:      pbLogicRegVl.MessageType_MsgTrainGradAndCost,
 					GradBytes: gradBytesForOther,
-					CostBytes: costBytesForOther,
+					CostBytes: costBytesForOthers[party],
 					LoopRound: loopRound,
 				}
-				_, err = l.sendMessageWithRetry(m, l.parties[0])
+				_, err = l.sendMessageWithRetry(m, party)
 				if err != nil {
 					go handleError(err)
 					return nil, err
@@ -514,8 +546,8 @@ func (l *Learner) advance(message *pbLogicRegVl.Message) (*pb.TrainResponse, err
 		gradBytesFromOther := message.GradBytes
 		costBytesFromOther := message.CostBytes
 		if loopRound == l.loopRound {
-			t := l.process.SetGradientAndCostFromOther(gradBytesFromOther, costBytesFromOther)
-			if t == 1 {
+			done := l.process.SetGradientAndCostFromOther(message.From, gradBytesFromOther, costBytesFromOther)
+			if done {
 				go func() {
 					m := &pbLogicRegVl.Message{
 						Type:      pbLogicRegVl.MessageType_MsgTrainUpdCostGrad,
@@ -538,16 +570,18 @@ func (l *Learner) advance(message *pbLogicRegVl.Message) (*pb.TrainResponse, err
 				return nil, err
 			}
 
-			m := &pbLogicRegVl.Message{
-				Type:      pbLogicRegVl.MessageType_MsgTrainStatus,
-				Stopped:   stopped,
-				LoopRound: loopRound,
-			}
-			logger.Infof("learner[%s] send to remote learner[%s]'s status[%t], loopRound[%d].", l.id, l.parties[0], stopped, l.loopRound)
-			_, err = l.sendMessageWithRetry(m, l.parties[0])
-			if err != nil {
-				go handleError(err)
-				return nil, err
+			for _, party := range l.parties {
+				m := &pbLogicRegVl.Message{
+					Type:      pbLogicRegVl.MessageType_MsgTrainStatus,
+					Stopped:   stopped,
+					LoopRound: loopRound,
+				}
+				logger.Infof("learner[%s] send to remote learner[%s]'s status[%t], loopRound[%d].", l.id, party, stopped, l.loopRound)
+				_, err = l.sendMessageWithRetry(m, party)
+				if err != nil {
+					go handleError(err)
+					return nil, err
+				}
 			}
 
 			go func() {
@@ -565,7 +599,7 @@ func (l *Learner) advance(message *pbLogicRegVl.Message) (*pb.TrainResponse, err
 		if loopRound == l.loopRound {
 			otherStopped := message.Stopped
 			logger.Infof("learner[%s] got remote learner[%s]'s status[%t], loopRound[%d].", l.id, message.From, otherStopped, l.loopRound)
-			l.process.setOtherStatus(otherStopped)
+			l.process.setOtherStatus(message.From, otherStopped)
 
 			go func() {
 				m := &pbLogicRegVl.Message{
@@ -796,7 +830,7 @@ func (l *Learner) sendMessage(message *pbLogicRegVl.Message, address string) (*p
 func NewLearner(id string, address string, params *pbCom.TrainParams, samplesFile []byte,
 	parties []string, rpc RpcHandler, rh ResultHandler, le LiveEvaluator) (*Learner, error) {
 
-	p, err := psi.NewVLTwoPartsPSI(address, samplesFile, params.GetIdName(), parties)
+	p, err := psi.NewVLPSI(address, samplesFile, params.GetIdName(), parties)
 	if err != nil {
 		return nil, err
 	}
@@ -815,7 +849,7 @@ func NewLearner(id string, address string, params *pbCom.TrainParams, samplesFil
 		homoPub:     homoPub,
 		psi:         p,
 		trainParams: params,
-		process:     newProcess(homoPriv, params),
+		process:     newProcess(homoPriv, params, parties),
 		samplesFile: samplesFile,
 		rpc:         rpc,
 		rh:          rh,
@@ -860,7 +894,7 @@ func NewLearnerWithoutSamples(id string, address string, params *pbCom.TrainPara
 		homoPriv:    homoPriv,
 		homoPub:     homoPub,
 		trainParams: params,
-		process:     newProcess(homoPriv, params),
+		process:     newProcess(homoPriv, params, parties),
 		rpc:         rpc,
 		rh:          rh,
 		status:      learnerStatusStartPSI,
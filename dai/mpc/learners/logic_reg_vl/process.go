@@ -31,38 +31,36 @@ import (
 type process struct {
 	round    uint64
 	homoPriv *paillier.PrivateKey // homomorphic private key for parameter encryption/decryption
-	params   *pbCom.TrainParams // params for the training task
-	fileRows [][]string // file rows obtained from sample file
+	params   *pbCom.TrainParams   // params for the training task
+	fileRows [][]string           // file rows obtained from sample file
+	parties  []string             // other parties, for no-tag part, the first one is the tag part
 
-	trainDataSet   *mlCom.TrainDataSet // own data set for training, formatted from filesRow
-	homoPubOfOther []byte // public key of other part
+	trainDataSet    *mlCom.TrainDataSet // own data set for training, formatted from filesRow
+	homoPubOfOthers map[string][]byte   // public keys of other parties
 
 	mutex sync.Mutex
 
 	// intermediate results
-	// logic.train for more
-	cost, lastCost                        float64
-	thetas, nextThetas                    []float64
-	rawPart                               *logicVert.RawLocalGradAndCostPart
-	partBytesForOther, partBytesFromOther []byte
-	encGradForOther, encGradFromOther     []byte
-	encCostForOther, encCostFromOther     []byte
-	gradientNoise                         []*big.Int
-	costNoise                             *big.Int
-	gradBytesForOther, gradBytesFromOther []byte
-	costBytesForOther, costBytesFromOther []byte
-
-	stopped      int8 // 0 means not decided, 1 means received `Stopped`, 2 means received `NotStopped`
-	otherStopped int8 // 0 means not received decision, 1 means received `Stopped`, 2 means received `NotStopped`
-
-	calLocalGradientAndCostTimes        int
-	calEncGradientAndCostTimes          int
-	setEncGradientAndCostFromOtherTimes int
-	decGradientAndCostTimes             int
-	setGradientAndCostFromOtherTimes    int
+	// logic.train for more, results related to other parties are mapped by their addresses
+	cost, lastCost                          float64
+	thetas, nextThetas                      []float64
+	rawPart                                 *logicVert.RawLocalGradAndCostPart
+	partBytesForOthers                      []byte
+	partBytesFromOthers                     map[string][]byte
+	encGradForOthers, encGradFromOthers     map[string][]byte
+	encCostForOthers, encCostFromOthers     map[string][]byte
+	gradientNoises                          map[string][]*big.Int
+	costNoises                              map[string]*big.Int
+	gradBytesForOthers, gradBytesFromOthers map[string][]byte
+	costBytesForOthers, costBytesFromOthers map[string][]byte
+
+	stopped       int8            // 0 means not decided, 1 means received `Stopped`, 2 means received `NotStopped`
+	othersStopped map[string]bool // decisions received from other parties
+
+	calLocalGradientAndCostTimes int
 
 	// cache intermediate result for next round
-	partBytesFromOtherNextRound []byte
+	partBytesFromOthersNextRound map[string][]byte
 }
 
 // init initialize Process, after PSI, before training
@@ -108,46 +106,44 @@ func (p *process) upRound(newRound uint64) error {
 
 	// clean intermediate results
 	p.rawPart = nil
-	p.partBytesForOther = []byte{}
+	p.partBytesForOthers = []byte{}
 
-	if len(p.partBytesFromOtherNextRound) == 0 {
-		p.partBytesFromOther = []byte{}
-	} else {
-		p.partBytesFromOther = p.partBytesFromOtherNextRound
-		p.partBytesFromOtherNextRound = []byte{}
-	}
+	p.partBytesFromOthers = p.partBytesFromOthersNextRound
+	p.partBytesFromOthersNextRound = make(map[string][]byte)
 
-	p.encGradForOther = []byte{}
-	p.encGradFromOther = []byte{}
-	p.encCostForOther = []byte{}
-	p.encCostFromOther = []byte{}
+	p.encGradForOthers = make(map[string][]byte)
+	p.encGradFromOthers = make(map[string][]byte)
+	p.encCostForOthers = make(map[string][]byte)
+	p.encCostFromOthers = make(map[string][]byte)
 
-	p.gradientNoise = []*big.Int{}
-	p.costNoise = nil
+	p.gradientNoises = make(map[string][]*big.Int)
+	p.costNoises = make(map[string]*big.Int)
 
-	p.gradBytesForOther = []byte{}
-	p.gradBytesFromOther = []byte{}
-	p.costBytesForOther = []byte{}
-	p.costBytesFromOther = []byte{}
+	p.gradBytesForOthers = make(map[string][]byte)
+	p.gradBytesFromOthers = make(map[string][]byte)
+	p.costBytesForOthers = make(map[string][]byte)
+	p.costBytesFromOthers = make(map[string][]byte)
 
 	p.stopped = 0
-	p.otherStopped = 0
+	p.othersStopped = make(map[string]bool)
 
 	p.calLocalGradientAndCostTimes = 0
-	p.calEncGradientAndCostTimes = 0
-	p.setEncGradientAndCostFromOtherTimes = 0
-	p.decGradientAndCostTimes = 0
-	p.setGradientAndCostFromOtherTimes = 0
 
 	return nil
 }
 
+// isTagPair checks whether tag part is one of local part and other party,
+// gradient and cost are evaluated with tag part, while only gradient is evaluated between no-tag parts
+func (p *process) isTagPair(party string) bool {
+	return p.params.IsTagPart || party == p.parties[0]
+}
+
 func (p *process) calLocalGradientAndCost() ([]byte, int, error) {
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
 	if p.calLocalGradientAndCostTimes != 0 {
 		p.calLocalGradientAndCostTimes++
-		return p.partBytesForOther, p.calLocalGradientAndCostTimes, nil
+		return p.partBytesForOthers, p.calLocalGradientAndCostTimes, nil
 	}
 
 	rawPart, otherPartBytes, newSet, err := logic.CalLocalGradientAndCost(p.trainDataSet, p.thetas, *p.params, &p.homoPriv.PublicKey, int(p.round))
@@ -158,14 +154,14 @@ func (p *process) calLocalGradientAndCost() ([]byte, int, error) {
 	p.trainDataSet.TrainSet = newSet
 
 	p.rawPart = rawPart
-	p.partBytesForOther = otherPartBytes
+	p.partBytesForOthers = otherPartBytes
 
 	p.calLocalGradientAndCostTimes++
 
-	return p.partBytesForOther, p.calLocalGradientAndCostTimes, nil
+	return p.partBytesForOthers, p.calLocalGradientAndCostTimes, nil
 }
 
-func (p *process) setPartBytesFromOther(partBytesFromOther []byte, round uint64) error {
+func (p *process) setPartBytesFromOther(party string, partBytesFromOther []byte, round uint64) error {
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
 
@@ -174,9 +170,9 @@ func (p *process) setPartBytesFromOther(partBytesFromOther []byte, round uint64)
 	}
 
 	if round == p.round {
-		p.partBytesFromOther = partBytesFromOther
+		p.partBytesFromOthers[party] = partBytesFromOther
 	} else if round == p.round+1 {
-		p.partBytesFromOtherNextRound = partBytesFromOther
+		p.partBytesFromOthersNextRound[party] = partBytesFromOther
 	} else {
 		return errorx.New(errcodes.ErrCodeParam, "target round [%d] should 1 greater or equal than process.round %d", round, p.round)
 	}
@@ -184,87 +180,105 @@ func (p *process) setPartBytesFromOther(partBytesFromOther []byte, round uint64)
 	return nil
 }
 
-func (p *process) calEncGradientAndCost() ([]byte, []byte, int, error) {
+// calEncGradientAndCost calculates encrypted gradient and cost for the parties whose parts are received,
+// returns the newly calculated ones mapped by party
+func (p *process) calEncGradientAndCost() (map[string][]byte, map[string][]byte, error) {
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
 
-	if p.calEncGradientAndCostTimes != 0 {
-		p.calEncGradientAndCostTimes++
-		return p.encGradForOther, p.encCostForOther, p.calEncGradientAndCostTimes, nil
+	encGrads := make(map[string][]byte)
+	encCosts := make(map[string][]byte)
+	if p.rawPart == nil {
+		return encGrads, encCosts, nil
 	}
 
-	if len(p.partBytesFromOther) == 0 || p.rawPart == nil {
-		return []byte{}, []byte{}, p.calEncGradientAndCostTimes, nil
-	}
+	for _, party := range p.parties {
+		if _, ok := p.encGradForOthers[party]; ok || len(p.partBytesFromOthers[party]) == 0 {
+			continue
+		}
 
-	encGradForOther, encCostForOther, gradientNoise, costNoise, err := logic.CalEncGradientAndCost(p.rawPart, p.partBytesFromOther, p.trainDataSet, *p.params, p.homoPubOfOther, p.thetas, int(p.round))
-	if err != nil {
-		return []byte{}, []byte{}, p.calEncGradientAndCostTimes, errorx.New(errcodes.ErrCodeInternal, "mistake[%s] happened when logic_reg_vl calEncGradientAndCost", err.Error())
-	}
+		var encGradForOther []byte
+		var gradientNoise []*big.Int
+		var err error
+		if p.isTagPair(party) {
+			var encCostForOther []byte
+			var costNoise *big.Int
+			encGradForOther, encCostForOther, gradientNoise, costNoise, err = logic.CalEncGradientAndCost(p.rawPart, p.partBytesFromOthers[party], p.trainDataSet, *p.params, p.homoPubOfOthers[party], p.thetas, int(p.round))
+			p.encCostForOthers[party] = encCostForOther
+			p.costNoises[party] = costNoise
+		} else {
+			encGradForOther, gradientNoise, err = logic.CalEncGradientWithOtherPart(p.partBytesFromOthers[party], p.trainDataSet, *p.params, p.homoPubOfOthers[party], p.thetas, int(p.round))
+		}
+		if err != nil {
+			return encGrads, encCosts, errorx.New(errcodes.ErrCodeInternal, "mistake[%s] happened when logic_reg_vl calEncGradientAndCost", err.Error())
+		}
 
-	p.encGradForOther = encGradForOther
-	p.encCostForOther = encCostForOther
-	p.gradientNoise = gradientNoise
-	p.costNoise = costNoise
+		p.encGradForOthers[party] = encGradForOther
+		p.gradientNoises[party] = gradientNoise
 
-	p.calEncGradientAndCostTimes++
+		encGrads[party] = encGradForOther
+		encCosts[party] = p.encCostForOthers[party]
+	}
 
-	return p.encGradForOther, p.encCostForOther, p.calEncGradientAndCostTimes, nil
+	return encGrads, encCosts, nil
 }
 
-func (p *process) setEncGradientAndCostFromOther(encGradFromOther, encCostFromOther []byte) int {
+// setEncGradientAndCostFromOther returns true if it's the first time to receive them from the party
+func (p *process) setEncGradientAndCostFromOther(party string, encGradFromOther, encCostFromOther []byte) bool {
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
 
-	if p.setEncGradientAndCostFromOtherTimes != 0 {
-		p.setEncGradientAndCostFromOtherTimes++
-		return p.setEncGradientAndCostFromOtherTimes
+	if _, ok := p.encGradFromOthers[party]; ok {
+		return false
 	}
 
-	p.encGradFromOther = encGradFromOther
-	p.encCostFromOther = encCostFromOther
+	p.encGradFromOthers[party] = encGradFromOther
+	p.encCostFromOthers[party] = encCostFromOther
 
-	p.setEncGradientAndCostFromOtherTimes++
-	return p.setEncGradientAndCostFromOtherTimes
+	return true
 }
 
-func (p *process) decGradientAndCost() ([]byte, []byte, int, error) {
+// decGradientAndCost decrypts gradient and cost received from other parties,
+// returns the newly decrypted ones mapped by party
+func (p *process) decGradientAndCost() (map[string][]byte, map[string][]byte, error) {
 
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
 
-	if p.decGradientAndCostTimes != 0 {
-		p.decGradientAndCostTimes++
-		return p.gradBytesForOther, p.costBytesForOther, p.decGradientAndCostTimes, nil
-	}
+	gradBytes := make(map[string][]byte)
+	costBytes := make(map[string][]byte)
+	for party, encGradFromOther := range p.encGradFromOthers {
+		if _, ok := p.gradBytesForOthers[party]; ok {
+			continue
+		}
 
-	gradBytesForOther, costBytesForOther, err := logic.DecGradientAndCost(p.encGradFromOther, p.encCostFromOther, p.homoPriv)
-	if err != nil {
-		return []byte{}, []byte{}, p.decGradientAndCostTimes, errorx.New(errcodes.ErrCodeInternal, "mistake[%s] happened when logic_reg_vl decGradientAndCost", err.Error())
-	}
+		gradBytesForOther, costBytesForOther, err := logic.DecGradientAndCost(encGradFromOther, p.encCostFromOthers[party], p.homoPriv)
+		if err != nil {
+			return gradBytes, costBytes, errorx.New(errcodes.ErrCodeInternal, "mistake[%s] happened when logic_reg_vl decGradientAndCost", err.Error())
+		}
 
-	p.gradBytesForOther = gradBytesForOther
-	p.costBytesForOther = costBytesForOther
+		p.gradBytesForOthers[party] = gradBytesForOther
+		p.costBytesForOthers[party] = costBytesForOther
 
-	p.decGradientAndCostTimes++
+		gradBytes[party] = gradBytesForOther
+		costBytes[party] = costBytesForOther
+	}
 
-	return p.gradBytesForOther, p.costBytesForOther, p.decGradientAndCostTimes, nil
+	return gradBytes, costBytes, nil
 }
 
-func (p *process) SetGradientAndCostFromOther(gradBytesFromOther, costBytesFromOther []byte) int {
+// SetGradientAndCostFromOther returns true if gradients from all parties are received just now
+func (p *process) SetGradientAndCostFromOther(party string, gradBytesFromOther, costBytesFromOther []byte) bool {
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
 
-	if p.setGradientAndCostFromOtherTimes != 0 {
-		p.setGradientAndCostFromOtherTimes++
-		return p.setGradientAndCostFromOtherTimes
+	if _, ok := p.gradBytesFromOthers[party]; ok {
+		return false
 	}
-	p.gradBytesFromOther = gradBytesFromOther
-	p.costBytesFromOther = costBytesFromOther
-
-	p.setGradientAndCostFromOtherTimes++
+	p.gradBytesFromOthers[party] = gradBytesFromOther
+	p.costBytesFromOthers[party] = costBytesFromOther
 
-	return p.setGradientAndCostFromOtherTimes
+	return len(p.gradBytesFromOthers) == len(p.parties)
 }
 
 func (p *process) updateCostAndGradient() (bool, error) {
@@ -281,24 +295,38 @@ func (p *process) updateCostAndGradient() (bool, error) {
 		return stopped, nil
 	}
 
-	if len(p.gradBytesFromOther) == 0 || len(p.gradientNoise) == 0 {
-		logger.Panicf("gradBytesFromOther is [%v], gradientNoise is [%v], thetas is [%v], round [%v]", p.gradBytesFromOther, p.gradientNoise, p.thetas, p.round)
+	var gradBytesFromOthers [][]byte
+	var gradientNoises [][]*big.Int
+	for _, party := range p.parties {
+		if len(p.gradBytesFromOthers[party]) == 0 || len(p.gradientNoises[party]) == 0 {
+			logger.Panicf("gradBytesFromOther is [%v], gradientNoise is [%v], party [%s], thetas is [%v], round [%v]", p.gradBytesFromOthers[party], p.gradientNoises[party], party, p.thetas, p.round)
+		}
+		gradBytesFromOthers = append(gradBytesFromOthers, p.gradBytesFromOthers[party])
+		gradientNoises = append(gradientNoises, p.gradientNoises[party])
 	}
 
-	nextThetas, err := logic.UpdateGradient(p.gradBytesFromOther, p.gradientNoise, p.thetas, *p.params)
+	nextThetas, err := logic.UpdateGradient(gradBytesFromOthers, gradientNoises, p.rawPart, p.trainDataSet, p.thetas, *p.params, int(p.round))
 	if err != nil {
 		return stopped, errorx.New(errcodes.ErrCodeInternal, "mistake[%s] happened when logic_reg_vl updateGradient", err.Error())
 	}
 	p.nextThetas = nextThetas
 
 	if p.round > 0 {
-		cost, err := logic.UpdateCost(p.costBytesFromOther, p.costNoise, *p.params)
-		if err != nil {
-			return stopped, errorx.New(errcodes.ErrCodeInternal, "mistake[%s] happened when logic_reg_vl updateCost", err.Error())
+		// cost is only evaluated with tag part, and tag part sums up costs with all no-tag parts
+		var cost float64
+		for _, party := range p.parties {
+			if !p.isTagPair(party) {
+				continue
+			}
+			c, err := logic.UpdateCost(p.costBytesFromOthers[party], p.costNoises[party], *p.params)
+			if err != nil {
+				return stopped, errorx.New(errcodes.ErrCodeInternal, "mistake[%s] happened when logic_reg_vl updateCost", err.Error())
+			}
+			cost += c
 		}
 
 		p.cost = cost
-		stopped = logic.StopTraining(p.lastCost, p.cost, *p.params)
+		stopped = logic.StopTraining(p.lastCost, p.cost, *p.params, int(p.round))
 	}
 	if stopped {
 		p.stopped = 1
@@ -312,31 +340,32 @@ func (p *process) updateCostAndGradient() (bool, error) {
 }
 
 // setOtherStatus set other's stop status
-func (p *process) setOtherStatus(otherStopped bool) {
+func (p *process) setOtherStatus(party string, otherStopped bool) {
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
 
-	if p.otherStopped != 0 {
+	if _, ok := p.othersStopped[party]; ok {
 		return
 	}
 
-	if otherStopped {
-		p.otherStopped = 1
-	} else {
-		p.otherStopped = 2
-	}
+	p.othersStopped[party] = otherStopped
 }
 
-// stop check if training should be stopped
+// stop check if training should be stopped,
+// it's decided after all parties' status are received, and stopped only if all parties stopped
 func (p *process) stop() (decided bool, stopped bool) {
-	if p.otherStopped != 0 && p.stopped != 0 {
-		logger.Infof("stop or not, otherStopped %d, stopped %d, round %d", p.otherStopped, p.stopped, p.round)
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if len(p.othersStopped) == len(p.parties) && p.stopped != 0 {
+		logger.Infof("stop or not, othersStopped %v, stopped %d, round %d", p.othersStopped, p.stopped, p.round)
 		decided = true
 	}
 
 	if decided {
-		if p.otherStopped == 1 && p.stopped == 1 {
-			stopped = true
+		stopped = p.stopped == 1
+		for _, otherStopped := range p.othersStopped {
+			stopped = stopped && otherStopped
 		}
 	}
 
@@ -354,15 +383,33 @@ func (p *process) getTrainModels() ([]byte, error) {
 }
 
 // setHomoPubOfOther save homomorphic public key from other party, used for secret transmission
-func (p *process) setHomoPubOfOther(homoPubOfOther []byte) {
-	p.homoPubOfOther = homoPubOfOther
+func (p *process) setHomoPubOfOther(party string, homoPubOfOther []byte) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.homoPubOfOthers[party] = homoPubOfOther
 }
 
-// newProcess init process by homomorphic key and training task params
-func newProcess(homoPriv *paillier.PrivateKey, params *pbCom.TrainParams) *process {
+// newProcess init process by homomorphic key, training task params and other parties
+func newProcess(homoPriv *paillier.PrivateKey, params *pbCom.TrainParams, parties []string) *process {
 	return &process{
-		round:    0,
-		params:   params,
-		homoPriv: homoPriv,
+		round:                        0,
+		params:                       params,
+		parties:                      parties,
+		homoPriv:                     homoPriv,
+		homoPubOfOthers:              make(map[string][]byte),
+		partBytesFromOthers:          make(map[string][]byte),
+		encGradForOthers:             make(map[string][]byte),
+		encGradFromOthers:            make(map[string][]byte),
+		encCostForOthers:             make(map[string][]byte),
+		encCostFromOthers:            make(map[string][]byte),
+		gradientNoises:               make(map[string][]*big.Int),
+		costNoises:                   make(map[string]*big.Int),
+		gradBytesForOthers:           make(map[string][]byte),
+		gradBytesFromOthers:          make(map[string][]byte),
+		costBytesForOthers:           make(map[string][]byte),
+		costBytesFromOthers:          make(map[string][]byte),
+		othersStopped:                make(map[string]bool),
+		partBytesFromOthersNextRound: make(map[string][]byte),
 	}
 }
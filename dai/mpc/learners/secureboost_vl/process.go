@@ -0,0 +1,248 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secureboost_vl
+
+import (
+	"sync"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/common/math/homomorphism/paillier"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+
+	"github.com/PaddlePaddle/PaddleDTX/dai/crypto/vl/secureboost"
+	"github.com/PaddlePaddle/PaddleDTX/dai/errcodes"
+	pbCom "github.com/PaddlePaddle/PaddleDTX/dai/protos/common"
+)
+
+type process struct {
+	homoPriv *paillier.PrivateKey // homomorphic private key for gradients encryption/decryption, only used by tag part
+	params   *pbCom.TrainParams   // params for the training task
+	fileRows [][]string           // file rows obtained from sample file
+	parties  []string             // other parties, for no-tag part, the first one is the tag part
+
+	trainDataSet *secureboost.TrainDataSet // own data set for training, formatted from filesRow
+	homoPubOfTag []byte                    // public key of tag part, only used by no-tag part
+	model        *secureboost.BoostModel   // trees for tag part, or split records for no-tag part
+
+	mutex sync.Mutex
+
+	// intermediate results of tag part
+	readyParties map[string]bool // no-tag parties who are ready for training
+	started      bool
+	scores       []float64 // raw scores of samples predicted by trained trees
+	grads, hess  []float64 // gradients and hessians of samples for the tree under training
+
+	// intermediate results of no-tag part
+	encGradHess *secureboost.EncGradHess // encrypted gradients and hessians for the tree under training
+}
+
+// init initialize Process, after PSI, before training
+func (p *process) init(fileRows [][]string) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.fileRows = fileRows
+
+	trainDataSet, err := secureboost.GetTrainDataSetFromFile(p.fileRows, *p.params)
+	if err != nil {
+		return errorx.New(errcodes.ErrCodeInternal, "mistake[%s] happened when secureboost_vl GetTrainDataSetFromFile", err.Error())
+	}
+	p.trainDataSet = trainDataSet
+
+	if p.params.IsTagPart {
+		p.model = &secureboost.BoostModel{
+			InitScore: secureboost.InitScore(trainDataSet.Labels),
+		}
+		p.scores = make([]float64, len(trainDataSet.Labels))
+		for i := range p.scores {
+			p.scores[i] = p.model.InitScore
+		}
+	} else {
+		p.model = &secureboost.BoostModel{
+			Records: make(map[string]*secureboost.SplitRecord),
+		}
+	}
+
+	return nil
+}
+
+// setReady records no-tag party who is ready for training
+func (p *process) setReady(party string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.readyParties[party] = true
+}
+
+// readyToStart returns true only once when local process is initialized and all no-tag parties are ready
+func (p *process) readyToStart() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.started || p.trainDataSet == nil || len(p.readyParties) < len(p.parties) {
+		return false
+	}
+	p.started = true
+	return true
+}
+
+// allSamples returns indexes of all samples, which are on the root of a tree
+func (p *process) allSamples() []int {
+	samples := make([]int, len(p.trainDataSet.Bins))
+	for i := range samples {
+		samples[i] = i
+	}
+	return samples
+}
+
+// startTree calculates gradients and hessians with current scores, and encrypts them for no-tag parties
+func (p *process) startTree() ([]byte, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.grads, p.hess = secureboost.CalGradAndHess(p.trainDataSet.Labels, p.scores)
+	encGradHess, err := secureboost.EncGradAndHess(p.grads, p.hess, *p.params, &p.homoPriv.PublicKey)
+	if err != nil {
+		return nil, errorx.New(errcodes.ErrCodeInternal, "mistake[%s] happened when secureboost_vl EncGradAndHess", err.Error())
+	}
+	return encGradHess, nil
+}
+
+// findLocalSplit finds the best split on local features for samples of a tree node
+func (p *process) findLocalSplit(samples []int) *secureboost.SplitInfo {
+	hists := secureboost.CalHistograms(p.grads, p.hess, p.trainDataSet, samples)
+	return secureboost.FindBestSplit(hists, *p.params)
+}
+
+// findSplitOfOther decrypts histograms received from no-tag party and finds the best split on its features
+func (p *process) findSplitOfOther(encHists []byte) (*secureboost.SplitInfo, error) {
+	hists, err := secureboost.DecHistograms(encHists, *p.params, p.homoPriv)
+	if err != nil {
+		return nil, errorx.New(errcodes.ErrCodeInternal, "mistake[%s] happened when secureboost_vl DecHistograms", err.Error())
+	}
+	return secureboost.FindBestSplit(hists, *p.params), nil
+}
+
+// splitLocal splits samples of a tree node on local feature, and fills the node with the feature and threshold
+func (p *process) splitLocal(node *secureboost.TreeNode, split *secureboost.SplitInfo, samples []int) ([]int, []int, error) {
+	left, right, err := secureboost.SplitSamples(p.trainDataSet, samples, split.FeatureIdx, split.BinIdx)
+	if err != nil {
+		return nil, nil, errorx.New(errcodes.ErrCodeInternal, "mistake[%s] happened when secureboost_vl SplitSamples", err.Error())
+	}
+	node.Feature = p.trainDataSet.FeatureNames[split.FeatureIdx]
+	node.Threshold = p.trainDataSet.Thresholds[split.FeatureIdx][split.BinIdx]
+	return left, right, nil
+}
+
+// leafWeight calculates weight of a leaf node with gradients and hessians of its samples
+func (p *process) leafWeight(samples []int) float64 {
+	return secureboost.LeafWeight(p.grads, p.hess, samples, *p.params)
+}
+
+// finishTree saves the trained tree and updates scores of samples
+func (p *process) finishTree(tree *secureboost.Tree, leaves map[int][]int) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	secureboost.UpdateScores(p.scores, tree, leaves)
+	p.model.Trees = append(p.model.Trees, tree)
+}
+
+// setHomoPubOfTag save homomorphic public key from tag part, used for histograms calculation
+func (p *process) setHomoPubOfTag(homoPubOfTag []byte) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.homoPubOfTag = homoPubOfTag
+}
+
+// setEncGradHess save encrypted gradients and hessians received from tag part for the tree under training
+func (p *process) setEncGradHess(encGradHessBytes []byte) error {
+	encGradHess, err := secureboost.EncGradHessFromBytes(encGradHessBytes)
+	if err != nil {
+		return errorx.New(errcodes.ErrCodeParam, "mistake[%s] happened when secureboost_vl EncGradHessFromBytes", err.Error())
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.encGradHess = encGradHess
+	return nil
+}
+
+// calEncHistograms calculates encrypted histograms on local features for samples of a tree node
+func (p *process) calEncHistograms(samplesBytes []byte) ([]byte, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.trainDataSet == nil || p.encGradHess == nil || len(p.homoPubOfTag) == 0 {
+		return nil, errorx.New(errcodes.ErrCodeInternal, "secureboost_vl is not ready to calculate histograms")
+	}
+	samples, err := secureboost.SamplesFromBytes(samplesBytes)
+	if err != nil {
+		return nil, errorx.New(errcodes.ErrCodeParam, "mistake[%s] happened when secureboost_vl SamplesFromBytes", err.Error())
+	}
+	encHists, err := secureboost.CalEncHistograms(p.encGradHess, p.trainDataSet, samples, p.homoPubOfTag)
+	if err != nil {
+		return nil, errorx.New(errcodes.ErrCodeInternal, "mistake[%s] happened when secureboost_vl CalEncHistograms", err.Error())
+	}
+	return encHists, nil
+}
+
+// addSplitRecord splits samples of a tree node on local feature and bin chosen by tag part,
+// keeps the split as a record, returns record ID and samples on left child
+func (p *process) addSplitRecord(featureIdx, binIdx int, samplesBytes []byte) (string, []byte, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	samples, err := secureboost.SamplesFromBytes(samplesBytes)
+	if err != nil {
+		return "", nil, errorx.New(errcodes.ErrCodeParam, "mistake[%s] happened when secureboost_vl SamplesFromBytes", err.Error())
+	}
+	left, _, err := secureboost.SplitSamples(p.trainDataSet, samples, featureIdx, binIdx)
+	if err != nil {
+		return "", nil, errorx.New(errcodes.ErrCodeParam, "mistake[%s] happened when secureboost_vl SplitSamples", err.Error())
+	}
+	id, record, err := secureboost.NewSplitRecord(p.trainDataSet, featureIdx, binIdx)
+	if err != nil {
+		return "", nil, errorx.New(errcodes.ErrCodeInternal, "mistake[%s] happened when secureboost_vl NewSplitRecord", err.Error())
+	}
+	leftBytes, err := secureboost.SamplesToBytes(left)
+	if err != nil {
+		return "", nil, errorx.New(errcodes.ErrCodeInternal, "mistake[%s] happened when secureboost_vl SamplesToBytes", err.Error())
+	}
+
+	p.model.Records[id] = record
+	return id, leftBytes, nil
+}
+
+// getTrainModels retrieve own model
+func (p *process) getTrainModels() ([]byte, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	model, err := secureboost.TrainModelsToBytes(p.model, *p.params)
+	if err != nil {
+		return nil, errorx.New(errcodes.ErrCodeInternal, "mistake[%s] happened when secureboost_vl TrainModelsToBytes", err.Error())
+	}
+	return model, nil
+}
+
+// newProcess init process by homomorphic key, training task params and other parties
+func newProcess(homoPriv *paillier.PrivateKey, params *pbCom.TrainParams, parties []string) *process {
+	return &process{
+		homoPriv:     homoPriv,
+		params:       params,
+		parties:      parties,
+		readyParties: make(map[string]bool),
+	}
+}
@@ -0,0 +1,706 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secureboost_vl
+
+import (
+	"sync"
+	"time"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/common/math/homomorphism/paillier"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+	"github.com/golang/protobuf/proto"
+	"github.com/sirupsen/logrus"
+
+	crypCom "github.com/PaddlePaddle/PaddleDTX/dai/crypto/vl/common"
+	"github.com/PaddlePaddle/PaddleDTX/dai/crypto/vl/secureboost"
+	"github.com/PaddlePaddle/PaddleDTX/dai/errcodes"
+	"github.com/PaddlePaddle/PaddleDTX/dai/mpc/psi"
+	pbCom "github.com/PaddlePaddle/PaddleDTX/dai/protos/common"
+	pb "github.com/PaddlePaddle/PaddleDTX/dai/protos/mpc"
+	pbSecureBoostVl "github.com/PaddlePaddle/PaddleDTX/dai/protos/mpc/learners/secureboost_vl"
+)
+
+var (
+	logger = logrus.WithField("module", "mpc.learners.secureboost_vl")
+)
+
+// PSI is for vertical learning,
+// initialized at the beginning of training by Learner
+type PSI interface {
+	// EncryptSampleIDSet to encrypt local IDs
+	EncryptSampleIDSet() ([]byte, error)
+
+	// SetReEncryptIDSet sets re-encrypted IDs from other party,
+	// and tries to calculate final re-encrypted IDs
+	// returns True if calculation is Done, otherwise False if still waiting for others' parts
+	// returns Error if any mistake happens
+	SetReEncryptIDSet(party string, reEncIDs []byte) (bool, error)
+
+	// ReEncryptIDSet to encrypt encrypted IDs for other party
+	ReEncryptIDSet(party string, encIDs []byte) ([]byte, error)
+
+	// SetOtherFinalReEncryptIDSet sets final re-encrypted IDs of other party
+	SetOtherFinalReEncryptIDSet(party string, reEncIDs []byte) error
+
+	// IntersectParts tries to calculate intersection with all parties' samples
+	// returns True with final result if calculation is Done, otherwise False if still waiting for others' samples
+	// returns Error if any mistake happens
+	// You'd better call it when SetReEncryptIDSet returns Done or SetOtherFinalReEncryptIDSet finishes
+	IntersectParts() (bool, [][]string, []string, error)
+}
+
+// RpcHandler used to request remote mpc-node
+type RpcHandler interface {
+	StepTrain(req *pb.TrainRequest, peerName string) (*pb.TrainResponse, error)
+
+	// StepTrainWithRetry sends training message to remote mpc-node
+	// retries 2 times at most
+	// inteSec indicates the interval between retry requests, in seconds
+	StepTrainWithRetry(req *pb.TrainRequest, peerName string, times int, inteSec int64) (*pb.TrainResponse, error)
+}
+
+// ResultHandler handles final result which is successful or failed
+// Should be called when learning finished
+type ResultHandler interface {
+	SaveResult(*pbCom.TrainTaskResult)
+}
+
+type learnerStatusType uint8
+
+const (
+	learnerStatusStartPSI learnerStatusType = iota
+	learnerStatusEndPSI
+	learnerStatusStartTrain
+	learnerStatusEndTrain
+)
+
+// treeNodeTask is a tree node waiting to be split or to be a leaf
+type treeNodeTask struct {
+	idx     int   // index of node in tree
+	depth   int   // depth of node, 0 for root
+	samples []int // samples on node
+}
+
+// Learner trains gradient boosting trees based on SecureBoost,
+// tag part holds labels and all trees, encrypts gradients and hessians for no-tag parties,
+// no-tag parties only calculate encrypted histograms and keep split records on their own features
+type Learner struct {
+	id          string
+	algo        pbCom.Algorithm
+	address     string               // address indicates local mpc-node
+	parties     []string             // parties are other learners who participates in MPC, assigned with mpc-node address usually
+	homoPriv    *paillier.PrivateKey // homomorphic private key
+	homoPub     []byte               // homomorphic public key for transfer
+	trainParams *pbCom.TrainParams
+	samplesFile []byte // sample file content for training model
+	psi         PSI
+	procMutex   sync.Mutex
+	process     *process      // process of training model
+	loopRound   uint64        // loopRound is the index of tree under training
+	rpc         RpcHandler    // rpc is used to request remote mpc-node
+	rh          ResultHandler // rh handles final result which is successful or failed
+	fileRows    [][]string    // fileRows returned by psi.IntersectParts
+
+	status learnerStatusType
+}
+
+func (l *Learner) Advance(payload []byte) (*pb.TrainResponse, error) {
+	m := &pbSecureBoostVl.Message{}
+	err := proto.Unmarshal(payload, m)
+	if err != nil {
+		return nil, errorx.New(errcodes.ErrCodeParam, "failed to Unmarshal payload: %s", err.Error())
+	}
+
+	return l.advance(m)
+}
+
+// getTrainSet returns training set after Sample Alignment
+func (l *Learner) getTrainSet() []*pbCom.TrainTaskResult_FileRow {
+	var frs []*pbCom.TrainTaskResult_FileRow
+	for _, fr := range l.fileRows {
+		frs = append(frs, &pbCom.TrainTaskResult_FileRow{Row: fr})
+	}
+	return frs
+}
+
+// advance handles all kinds of message
+func (l *Learner) advance(message *pbSecureBoostVl.Message) (*pb.TrainResponse, error) {
+	mType := message.Type
+
+	handleError := func(err error) {
+		logger.WithField("error", err.Error()).Warning("failed to train out a model")
+		res := &pbCom.TrainTaskResult{TaskID: l.id, ErrMsg: err.Error()}
+		l.rh.SaveResult(res)
+	}
+
+	var ret *pb.TrainResponse
+	switch mType {
+	case pbSecureBoostVl.MessageType_MsgPsiEnc: // local message
+		encIDs, err := l.psi.EncryptSampleIDSet()
+		if err != nil {
+			go handleError(err)
+			return nil, err
+		}
+
+		go func() {
+			m := &pbSecureBoostVl.Message{
+				Type: pbSecureBoostVl.MessageType_MsgPsiAskReEnc,
+				VlLPsiReEncIDsReq: &pb.VLPsiReEncIDsRequest{
+					TaskID: l.id,
+					EncIDs: encIDs,
+				},
+			}
+			l.advance(m)
+		}()
+
+	case pbSecureBoostVl.MessageType_MsgPsiAskReEnc: // local message
+		// local ID-Set is re-encrypted by other parties one by one,
+		// then the final one is sent to the parties who haven't seen it, the last one in chain already has it
+		newMess := func(encIDs []byte) *pbSecureBoostVl.Message {
+			return &pbSecureBoostVl.Message{
+				Type: pbSecureBoostVl.MessageType_MsgPsiReEnc,
+				VlLPsiReEncIDsReq: &pb.VLPsiReEncIDsRequest{
+					TaskID: l.id,
+					EncIDs: encIDs,
+				},
+				LoopRound: l.loopRound,
+			}
+		}
+
+		var done bool
+		encIDs := message.VlLPsiReEncIDsReq.EncIDs
+		for _, party := range l.parties {
+			reM, err := l.sendMessageWithRetry(newMess(encIDs), party)
+			if err != nil {
+				go handleError(err)
+				return nil, err
+			}
+
+			done, err = l.psi.SetReEncryptIDSet(party, reM.VlLPsiReEncIDsResp.ReEncIDs)
+			if err != nil {
+				go handleError(err)
+				return nil, err
+			}
+			encIDs = reM.VlLPsiReEncIDsResp.ReEncIDs
+		}
+		for _, party := range l.parties[:len(l.parties)-1] {
+			if _, err := l.sendMessageWithRetry(newMess(encIDs), party); err != nil {
+				go handleError(err)
+				return nil, err
+			}
+		}
+
+		if done {
+			go func() {
+				m := &pbSecureBoostVl.Message{
+					Type: pbSecureBoostVl.MessageType_MsgPsiIntersect,
+				}
+				l.advance(m)
+			}()
+		}
+
+	case pbSecureBoostVl.MessageType_MsgPsiReEnc:
+		reEncIDs, err := l.psi.ReEncryptIDSet(message.From, message.VlLPsiReEncIDsReq.EncIDs)
+		if err != nil {
+			go handleError(err)
+			return nil, err
+		}
+
+		retM := &pbSecureBoostVl.Message{
+			Type: pbSecureBoostVl.MessageType_MsgPsiReEnc,
+			To:   message.From,
+			From: l.address,
+			VlLPsiReEncIDsResp: &pb.VLPsiReEncIDsResponse{
+				TaskID:   l.id,
+				ReEncIDs: reEncIDs,
+			},
+		}
+		ret, err = l.newTrainResponse(retM)
+		if err != nil {
+			go handleError(err)
+			return nil, err
+		}
+
+		err = l.psi.SetOtherFinalReEncryptIDSet(message.From, reEncIDs)
+		if err != nil {
+			go handleError(err)
+		} else {
+			go func() {
+				m := &pbSecureBoostVl.Message{
+					Type: pbSecureBoostVl.MessageType_MsgPsiIntersect,
+				}
+				l.advance(m)
+			}()
+		}
+
+	case pbSecureBoostVl.MessageType_MsgPsiIntersect: // local message
+		done, newRows, _, err := l.psi.IntersectParts()
+		if err != nil {
+			go handleError(err)
+			return nil, err
+		}
+
+		if done {
+			l.fileRows = newRows
+			l.status = learnerStatusEndPSI
+			go func() {
+				m := &pbSecureBoostVl.Message{
+					Type: pbSecureBoostVl.MessageType_MsgTrainHup,
+				}
+				l.advance(m)
+			}()
+		}
+
+	case pbSecureBoostVl.MessageType_MsgTrainHup: // local message
+		// tag part sends homomorphic public key to no-tag parties, and waits for them to be ready,
+		// no-tag part tells tag part it's ready after initialization
+		l.procMutex.Lock()
+		defer l.procMutex.Unlock()
+		if learnerStatusEndPSI == l.status {
+			l.status = learnerStatusStartTrain
+			err := l.process.init(l.fileRows)
+			if err != nil {
+				go handleError(err)
+				return nil, err
+			}
+
+			if l.trainParams.IsTagPart {
+				for _, party := range l.parties {
+					m := &pbSecureBoostVl.Message{
+						Type:       pbSecureBoostVl.MessageType_MsgHomoPubkey,
+						HomoPubkey: l.homoPub,
+					}
+					_, err = l.sendMessageWithRetry(m, party)
+					if err != nil {
+						go handleError(err)
+						return nil, err
+					}
+				}
+				l.startTrainLoop()
+			} else {
+				m := &pbSecureBoostVl.Message{
+					Type: pbSecureBoostVl.MessageType_MsgTrainReady,
+				}
+				_, err = l.sendMessageWithRetry(m, l.parties[0])
+				if err != nil {
+					go handleError(err)
+					return nil, err
+				}
+			}
+		}
+
+	case pbSecureBoostVl.MessageType_MsgHomoPubkey:
+		l.process.setHomoPubOfTag(message.HomoPubkey)
+		ret = &pb.TrainResponse{
+			TaskID: l.id,
+		}
+
+	case pbSecureBoostVl.MessageType_MsgTrainReady:
+		l.process.setReady(message.From)
+		l.startTrainLoop()
+		ret = &pb.TrainResponse{
+			TaskID: l.id,
+		}
+
+	case pbSecureBoostVl.MessageType_MsgTrainLoop: // local message
+		// only tag part enters the loop, one tree is trained each round
+		loopRound := message.LoopRound
+		l.procMutex.Lock()
+		if loopRound != 0 && loopRound != l.loopRound+1 {
+			l.procMutex.Unlock()
+			break
+		}
+		l.loopRound = loopRound
+		l.procMutex.Unlock()
+
+		err := l.trainTree(loopRound)
+		if err != nil {
+			go handleError(err)
+			return nil, err
+		}
+		logger.WithField("loopRound", loopRound).Infof("learner[%s] trained out tree[%d].", l.id, loopRound)
+
+		if int(loopRound)+1 < secureboost.GetTreeNum(*l.trainParams) {
+			go func() {
+				m := &pbSecureBoostVl.Message{
+					Type:      pbSecureBoostVl.MessageType_MsgTrainLoop,
+					LoopRound: loopRound + 1, //for starting new round
+				}
+				l.advance(m)
+			}()
+			break
+		}
+
+		for _, party := range l.parties {
+			m := &pbSecureBoostVl.Message{
+				Type:      pbSecureBoostVl.MessageType_MsgTrainStatus,
+				Stopped:   true,
+				LoopRound: loopRound,
+			}
+			_, err = l.sendMessageWithRetry(m, party)
+			if err != nil {
+				go handleError(err)
+				return nil, err
+			}
+		}
+		go func() {
+			m := &pbSecureBoostVl.Message{
+				Type:      pbSecureBoostVl.MessageType_MsgTrainModels,
+				LoopRound: loopRound,
+			}
+			l.advance(m)
+		}()
+
+	case pbSecureBoostVl.MessageType_MsgTrainGradHess:
+		l.loopRound = message.LoopRound
+		err := l.process.setEncGradHess(message.EncGradHess)
+		if err != nil {
+			go handleError(err)
+			return nil, err
+		}
+		ret = &pb.TrainResponse{
+			TaskID: l.id,
+		}
+
+	case pbSecureBoostVl.MessageType_MsgTrainHistograms:
+		encHists, err := l.process.calEncHistograms(message.NodeSamples)
+		if err != nil {
+			go handleError(err)
+			return nil, err
+		}
+
+		retM := &pbSecureBoostVl.Message{
+			Type:       pbSecureBoostVl.MessageType_MsgTrainHistograms,
+			To:         message.From,
+			From:       l.address,
+			LoopRound:  message.LoopRound,
+			Histograms: encHists,
+		}
+		ret, err = l.newTrainResponse(retM)
+		if err != nil {
+			go handleError(err)
+			return nil, err
+		}
+
+	case pbSecureBoostVl.MessageType_MsgTrainSplit:
+		recordID, left, err := l.process.addSplitRecord(int(message.FeatureIdx), int(message.BinIdx), message.NodeSamples)
+		if err != nil {
+			go handleError(err)
+			return nil, err
+		}
+
+		retM := &pbSecureBoostVl.Message{
+			Type:        pbSecureBoostVl.MessageType_MsgTrainSplit,
+			To:          message.From,
+			From:        l.address,
+			LoopRound:   message.LoopRound,
+			RecordID:    recordID,
+			NodeSamples: left,
+		}
+		ret, err = l.newTrainResponse(retM)
+		if err != nil {
+			go handleError(err)
+			return nil, err
+		}
+
+	case pbSecureBoostVl.MessageType_MsgTrainStatus:
+		logger.Infof("learner[%s] got remote learner[%s]'s status[%t], loopRound[%d].", l.id, message.From, message.Stopped, message.LoopRound)
+		if message.Stopped {
+			go func() {
+				m := &pbSecureBoostVl.Message{
+					Type:      pbSecureBoostVl.MessageType_MsgTrainModels,
+					LoopRound: message.LoopRound,
+				}
+				l.advance(m)
+			}()
+		}
+		ret = &pb.TrainResponse{
+			TaskID: l.id,
+		}
+
+	case pbSecureBoostVl.MessageType_MsgTrainModels: // local message
+		l.procMutex.Lock()
+		defer l.procMutex.Unlock()
+		if learnerStatusStartTrain == l.status {
+			l.status = learnerStatusEndTrain
+			model, err := l.process.getTrainModels()
+			if err != nil {
+				go handleError(err)
+				return nil, err
+			}
+			logger.WithField("loopRound", l.loopRound).Infof("learner[%s] trained out model[%v] successfully.", l.id, model)
+			res := &pbCom.TrainTaskResult{
+				TaskID:   l.id,
+				Success:  true,
+				Model:    model,
+				TrainSet: l.getTrainSet(),
+			}
+			l.rh.SaveResult(res)
+		}
+	}
+
+	logger.WithFields(logrus.Fields{
+		"address":      l.address,
+		"loopRound":    l.loopRound,
+		"messageRound": message.LoopRound,
+	}).Infof("learner[%s] finished advance . message %s", l.id, message.Type.String())
+	return ret, nil
+}
+
+// startTrainLoop starts training the first tree if tag part is initialized and all no-tag parties are ready
+func (l *Learner) startTrainLoop() {
+	if l.process.readyToStart() {
+		go func() {
+			m := &pbSecureBoostVl.Message{
+				Type:      pbSecureBoostVl.MessageType_MsgTrainLoop,
+				LoopRound: 0, // start Round-0
+			}
+			l.advance(m)
+		}()
+	}
+}
+
+// trainTree grows a tree level by level, used by tag part
+// encrypted gradients and hessians are sent to no-tag parties first,
+// then each node is split on the feature with max gain among all parties,
+// until max depth is reached or no split reduces loss
+func (l *Learner) trainTree(loopRound uint64) error {
+	encGradHess, err := l.process.startTree()
+	if err != nil {
+		return err
+	}
+	for _, party := range l.parties {
+		m := &pbSecureBoostVl.Message{
+			Type:        pbSecureBoostVl.MessageType_MsgTrainGradHess,
+			EncGradHess: encGradHess,
+			LoopRound:   loopRound,
+		}
+		if _, err := l.sendMessageWithRetry(m, party); err != nil {
+			return err
+		}
+	}
+
+	tree := &secureboost.Tree{Nodes: []*secureboost.TreeNode{{}}}
+	leaves := make(map[int][]int)
+	tasks := []treeNodeTask{{samples: l.process.allSamples()}}
+	for len(tasks) > 0 {
+		task := tasks[0]
+		tasks = tasks[1:]
+		node := tree.Nodes[task.idx]
+
+		var left, right []int
+		if task.depth < secureboost.GetMaxDepth(*l.trainParams) {
+			left, right, err = l.splitNode(node, task.samples, loopRound)
+			if err != nil {
+				return err
+			}
+		}
+		if len(left) == 0 || len(right) == 0 {
+			node.IsLeaf = true
+			node.Weight = l.process.leafWeight(task.samples)
+			leaves[task.idx] = task.samples
+			continue
+		}
+
+		node.Left, node.Right = len(tree.Nodes), len(tree.Nodes)+1
+		tree.Nodes = append(tree.Nodes, &secureboost.TreeNode{}, &secureboost.TreeNode{})
+		tasks = append(tasks, treeNodeTask{idx: node.Left, depth: task.depth + 1, samples: left},
+			treeNodeTask{idx: node.Right, depth: task.depth + 1, samples: right})
+	}
+
+	l.process.finishTree(tree, leaves)
+	return nil
+}
+
+// splitNode finds the best split of a tree node among all parties and splits its samples,
+// returns empty children if no split reduces loss
+func (l *Learner) splitNode(node *secureboost.TreeNode, samples []int, loopRound uint64) ([]int, []int, error) {
+	samplesBytes, err := secureboost.SamplesToBytes(samples)
+	if err != nil {
+		return nil, nil, errorx.New(errcodes.ErrCodeInternal, "failed to convert samples: %s", err.Error())
+	}
+
+	best := l.process.findLocalSplit(samples)
+	bestParty := ""
+	for _, party := range l.parties {
+		m := &pbSecureBoostVl.Message{
+			Type:        pbSecureBoostVl.MessageType_MsgTrainHistograms,
+			NodeSamples: samplesBytes,
+			LoopRound:   loopRound,
+		}
+		reM, err := l.sendMessageWithRetry(m, party)
+		if err != nil {
+			return nil, nil, err
+		}
+		split, err := l.process.findSplitOfOther(reM.Histograms)
+		if err != nil {
+			return nil, nil, err
+		}
+		if split != nil && (best == nil || split.Gain > best.Gain) {
+			best, bestParty = split, party
+		}
+	}
+
+	if best == nil {
+		return nil, nil, nil
+	}
+	if bestParty == "" {
+		return l.process.splitLocal(node, best, samples)
+	}
+
+	// split on other party's feature, only record ID and samples on left child are returned
+	m := &pbSecureBoostVl.Message{
+		Type:        pbSecureBoostVl.MessageType_MsgTrainSplit,
+		NodeSamples: samplesBytes,
+		FeatureIdx:  int64(best.FeatureIdx),
+		BinIdx:      int64(best.BinIdx),
+		LoopRound:   loopRound,
+	}
+	reM, err := l.sendMessageWithRetry(m, bestParty)
+	if err != nil {
+		return nil, nil, err
+	}
+	left, err := secureboost.SamplesFromBytes(reM.NodeSamples)
+	if err != nil {
+		return nil, nil, errorx.New(errcodes.ErrCodeParam, "failed to get samples on left child from[%s]: %s", bestParty, err.Error())
+	}
+
+	inLeft := make(map[int]bool)
+	for _, s := range left {
+		inLeft[s] = true
+	}
+	var right []int
+	for _, s := range samples {
+		if !inLeft[s] {
+			right = append(right, s)
+		}
+	}
+	node.Owner, node.RecordID = bestParty, reM.RecordID
+	return left, right, nil
+}
+
+// newTrainResponse packs message as TrainResponse
+func (l *Learner) newTrainResponse(message *pbSecureBoostVl.Message) (*pb.TrainResponse, error) {
+	payload, err := proto.Marshal(message)
+	if err != nil {
+		return nil, errorx.New(errcodes.ErrCodeInternal, "failed to Marshal payload: %s", err.Error())
+	}
+
+	return &pb.TrainResponse{
+		TaskID:  l.id,
+		Payload: payload,
+	}, nil
+}
+
+// sendMessageWithRetry sends message to remote mpc-node
+// retries 2 times at most
+func (l *Learner) sendMessageWithRetry(message *pbSecureBoostVl.Message, address string) (*pbSecureBoostVl.Message, error) {
+	times := 3
+
+	var m *pbSecureBoostVl.Message
+	var err error
+	for i := 0; i < times; i++ {
+		if i > 0 {
+			time.Sleep(3 * time.Second)
+		}
+		m, err = l.sendMessage(message, address)
+		if err == nil {
+			break
+		}
+	}
+
+	return m, err
+}
+
+// sendMessage sends message to remote mpc-node
+func (l *Learner) sendMessage(message *pbSecureBoostVl.Message, address string) (*pbSecureBoostVl.Message, error) {
+	message.From = l.address
+	message.To = address
+
+	payload, err := proto.Marshal(message)
+	if err != nil {
+		return nil, errorx.New(errcodes.ErrCodeInternal, "failed to Marshal payload: %s", err.Error())
+	}
+
+	trainReq := &pb.TrainRequest{
+		TaskID:  l.id,
+		Algo:    l.algo,
+		Payload: payload,
+	}
+	resp, err := l.rpc.StepTrain(trainReq, address)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &pbSecureBoostVl.Message{}
+	if len(resp.Payload) != 0 {
+		err := proto.Unmarshal(resp.Payload, m)
+		if err != nil {
+			return nil, errorx.New(errcodes.ErrCodeInternal, "failed to Unmarshal payload[%s] from[%s] and err is[%s] ", string(resp.Payload), address, err.Error())
+		}
+	}
+	return m, nil
+}
+
+// NewLearner returns a VerticalSecureBoost Learner
+// id is the assigned id for Learner
+// address indicates local mpc-node
+// parties are other learners who participates in MPC, assigned with mpc-node address usually
+// rpc is used to request remote mpc-node
+// rh handles final result which is successful or failed
+// params are parameters for training model
+// samplesFile contains samples for training model
+func NewLearner(id string, address string, params *pbCom.TrainParams, samplesFile []byte,
+	parties []string, rpc RpcHandler, rh ResultHandler) (*Learner, error) {
+
+	p, err := psi.NewVLPSI(address, samplesFile, params.GetIdName(), parties)
+	if err != nil {
+		return nil, err
+	}
+
+	// only tag part's key pair is used to encrypt gradients and hessians
+	var homoPriv *paillier.PrivateKey
+	var homoPub []byte
+	if params.IsTagPart {
+		homoPriv, homoPub, err = crypCom.GenerateHomoKeyPair()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	l := &Learner{
+		id:          id,
+		algo:        pbCom.Algorithm_SECUREBOOST_VL,
+		address:     address,
+		parties:     parties,
+		homoPriv:    homoPriv,
+		homoPub:     homoPub,
+		psi:         p,
+		trainParams: params,
+		process:     newProcess(homoPriv, params, parties),
+		samplesFile: samplesFile,
+		rpc:         rpc,
+		rh:          rh,
+		status:      learnerStatusStartPSI,
+	}
+
+	// start training
+	go func() {
+		m := &pbSecureBoostVl.Message{
+			Type: pbSecureBoostVl.MessageType_MsgPsiEnc,
+		}
+		l.advance(m)
+	}()
+	return l, nil
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/PaddlePaddle/PaddleDTX/dai/errcodes"
 	pbCom "github.com/PaddlePaddle/PaddleDTX/dai/protos/common"
 	pb "github.com/PaddlePaddle/PaddleDTX/dai/protos/mpc"
+	pbFedAvgHl "github.com/PaddlePaddle/PaddleDTX/dai/protos/mpc/learners/fedavg_hl"
 	pbLinearRegVl "github.com/PaddlePaddle/PaddleDTX/dai/protos/mpc/learners/linear_reg_vl"
 	pbLogicRegVl "github.com/PaddlePaddle/PaddleDTX/dai/protos/mpc/learners/logic_reg_vl"
 	"github.com/golang/protobuf/proto"
@@ -372,6 +373,50 @@ func (le *liveEvaluator) runLogicRegVL(msg *pb.LiveEvaluationTriggerMsg) error {
 	return nil
 }
 
+func (le *liveEvaluator) runFedAvgHL(msg *pb.LiveEvaluationTriggerMsg) error {
+	// get local training set from message sent by learner
+	m := &pbFedAvgHl.Message{}
+	err := proto.Unmarshal(msg.Payload, m)
+	if err != nil {
+		return errorx.New(errcodes.ErrCodeParam, "live evaluator[%s] failed to Unmarshal payload: %s", le.id, err.Error())
+	}
+
+	ts, err := le.splitAndGetTrainSetVL(msg)
+	if err != nil {
+		return errorx.New(errcodes.ErrCodeParam, "live evaluator[%s] failed to split LiveEvaluationTriggerMsg.TrainSet to get training set for evaluation: %s", le.id, err.Error())
+	}
+
+	// remove IDs
+	ts = le.removeIDInFile(ts)
+
+	// reset `TrainSet` in message
+	var mts []*pbCom.TrainTaskResult_FileRow
+	for _, r := range ts {
+		mts = append(mts, &pbCom.TrainTaskResult_FileRow{Row: r})
+	}
+	m.TrainSet = mts
+
+	pl, err := proto.Marshal(m)
+	if err != nil {
+		return errorx.New(errcodes.ErrCodeParam, "live evaluator[%s] failed to Marshal message: %s", le.id, err.Error())
+	}
+
+	resp, err := le.mpc.Train(&pb.TrainRequest{
+		TaskID:  le.evalLearnerID,
+		Algo:    le.algo,
+		Payload: pl,
+	})
+	if err != nil {
+		logger.Warnf("live evaluator[%s] failed to run learner[%s], and error is[%s].",
+			le.id, le.evalLearnerID, err.Error())
+		return err
+	}
+	logger.Infof("live evaluator[%s] run learner[%s] successfully and response is[%v].",
+		le.id, le.evalLearnerID, resp)
+
+	return nil
+}
+
 // rebuildFileForEvaluation adds ID back to file in order to keep the same order when shuffle samples for parties,
 // because it had been removed after Sample Alignment
 func (le *liveEvaluator) rebuildFileForShuffle(f [][]string) [][]string {
@@ -755,6 +800,14 @@ func NewLiveEvaluator(req *pbCom.StartTaskRequest, mpc Mpc) (LiveEvaluator, erro
 		le.caseType = pbCom.CaseType_BinaryClass
 		le.calMetricScoresAndCallback = le.calMetricScoresAndCallbackCaseBinClass
 		le.runLearner = le.runLogicRegVL
+	case pbCom.Algorithm_LINEAR_REGRESSION_HL:
+		le.caseType = pbCom.CaseType_Regression
+		le.calMetricScoresAndCallback = le.calMetricScoresAndCallbackCaseRegression
+		le.runLearner = le.runFedAvgHL
+	case pbCom.Algorithm_LOGIC_REGRESSION_HL:
+		le.caseType = pbCom.CaseType_BinaryClass
+		le.calMetricScoresAndCallback = le.calMetricScoresAndCallbackCaseBinClass
+		le.runLearner = le.runFedAvgHL
 	default:
 		return nil, errorx.New(errcodes.ErrCodeParam, "unknown algorithm: %s", algo.String())
 	}
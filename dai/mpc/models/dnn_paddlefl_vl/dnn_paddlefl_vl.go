@@ -653,7 +653,7 @@ func (model *Model) deStandardizeOutput() (done bool, outcomes []float64) {
 	if len(model.predictPart) == 0 || len(model.predictPartFromOther) == 0 {
 		return
 	}
-	outcomes = linear.DeStandardizeOutput(model.params, model.predictPart, model.predictPartFromOther)
+	outcomes = linear.DeStandardizeOutput(model.params, model.predictPart, [][]float64{model.predictPartFromOther})
 	model.outcomes = outcomes
 	done = true
 
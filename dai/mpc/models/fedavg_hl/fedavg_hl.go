@@ -0,0 +1,200 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fedavg_hl
+
+import (
+	"sync"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+	"github.com/golang/protobuf/proto"
+	"github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleDTX/dai/crypto/hl/fedavg"
+	vl_common "github.com/PaddlePaddle/PaddleDTX/dai/crypto/vl/common"
+	"github.com/PaddlePaddle/PaddleDTX/dai/crypto/vl/common/csv"
+	"github.com/PaddlePaddle/PaddleDTX/dai/errcodes"
+	pbCom "github.com/PaddlePaddle/PaddleDTX/dai/protos/common"
+	pb "github.com/PaddlePaddle/PaddleDTX/dai/protos/mpc"
+	pbFedAvgHl "github.com/PaddlePaddle/PaddleDTX/dai/protos/mpc/learners/fedavg_hl"
+)
+
+var (
+	logger = logrus.WithField("module", "mpc.models.fedavg_hl")
+)
+
+// RpcHandler used to request remote mpc-node
+type RpcHandler interface {
+	StepPredict(req *pb.PredictRequest, peerName string) (*pb.PredictResponse, error)
+
+	// StepPredictWithRetry sends prediction message to remote mpc-node
+	// retries 2 times at most
+	// inteSec indicates the interval between retry requests, in seconds
+	StepPredictWithRetry(req *pb.PredictRequest, peerName string, times int, inteSec int64) (*pb.PredictResponse, error)
+}
+
+// ResultHandler handles final result which is successful or failed
+// Should be called when prediction finished
+type ResultHandler interface {
+	SaveResult(*pbCom.PredictTaskResult)
+}
+
+type modelStatusType uint8
+
+const (
+	modelStatusStartPredict modelStatusType = iota
+	modelStatusEndPredict
+)
+
+// Model was trained out by a horizontal Learner based on FedAvg.
+// Every party holds the complete global model and whole samples with all features,
+// so prediction is done locally without communicating with others
+type Model struct {
+	id          string
+	algo        pbCom.Algorithm
+	address     string   // address indicates local mpc-node
+	parties     []string // parties are other models who participates in MPC, assigned with mpc-node address usually
+	params      *pbCom.TrainModels
+	samplesFile []byte        // sample file content for prediction
+	rpc         RpcHandler    // rpc is used to request remote mpc-node
+	rh          ResultHandler // rh handles final result which is successful or failed
+
+	procMutex sync.Mutex
+	status    modelStatusType
+}
+
+// Advance does calculation with local samples to predict outcomes
+// payload could be resolved by Model trained out by specific algorithm and samples
+// We'd better call the method asynchronously avoid blocking the main go-routine
+func (model *Model) Advance(payload []byte) (*pb.PredictResponse, error) {
+	m := &pbFedAvgHl.PredictMessage{}
+	err := proto.Unmarshal(payload, m)
+	if err != nil {
+		return nil, errorx.New(errcodes.ErrCodeParam, "failed to Unmarshal payload: %s", err.Error())
+	}
+
+	return model.advance(m)
+}
+
+// advance handles all kinds of message
+func (model *Model) advance(message *pbFedAvgHl.PredictMessage) (*pb.PredictResponse, error) {
+	mType := message.Type
+
+	handleError := func(err error) {
+		logger.WithField("error", err.Error()).Warning("failed to predict")
+		res := &pbCom.PredictTaskResult{TaskID: model.id, ErrMsg: err.Error()}
+		model.rh.SaveResult(res)
+	}
+
+	switch mType {
+	case pbFedAvgHl.MessageType_MsgPredictHup: // local message
+		model.procMutex.Lock()
+		defer model.procMutex.Unlock()
+		if modelStatusStartPredict != model.status {
+			break
+		}
+		model.status = modelStatusEndPredict
+
+		ids, outcomes, err := model.predict()
+		if err != nil {
+			go handleError(err)
+			return nil, err
+		}
+		outs, err := vl_common.PredictResultToBytes(model.params.IdName, ids, outcomes)
+		if err != nil {
+			go handleError(err)
+			return nil, err
+		}
+		go func() {
+			logger.Infof("model[%s] finish prediction and outcomes are[%v].", model.id, outcomes)
+			model.rh.SaveResult(&pbCom.PredictTaskResult{
+				TaskID:   model.id,
+				Success:  true,
+				Outcomes: outs,
+			})
+		}()
+	}
+
+	logger.WithFields(logrus.Fields{
+		"address": model.address,
+	}).Infof("model[%s] finished advance. message %s", model.id, message.Type.String())
+	return nil, nil
+}
+
+// predict predicts outcomes of local samples, and returns IDs of samples along with outcomes
+func (model *Model) predict() ([]string, []float64, error) {
+	fileRows, err := csv.ReadRowsFromFile(model.samplesFile)
+	if err != nil {
+		return nil, nil, errorx.New(errcodes.ErrCodeParam, "failed to read samples: %s", err.Error())
+	}
+	if len(fileRows) == 0 {
+		return nil, nil, errorx.New(errcodes.ErrCodeParam, "empty samples for prediction")
+	}
+
+	idIdx := -1
+	for i, name := range fileRows[0] {
+		if name == model.params.IdName {
+			idIdx = i
+			break
+		}
+	}
+	if idIdx < 0 {
+		return nil, nil, errorx.New(errcodes.ErrCodeParam, "id column[%s] not found in samples", model.params.IdName)
+	}
+
+	var ids []string
+	for _, r := range fileRows[1:] {
+		ids = append(ids, r[idIdx])
+	}
+
+	outcomes, err := fedavg.Predict(fileRows, model.algo, model.params)
+	if err != nil {
+		return nil, nil, errorx.New(errcodes.ErrCodeInternal, "failed to predict: %s", err.Error())
+	}
+	return ids, outcomes, nil
+}
+
+// NewModel returns a horizontal Model based on FedAvg
+// id is the assigned id for Model
+// samplesFile is sample file content for prediction
+// address indicates local mpc-node
+// algo is Algorithm_LINEAR_REGRESSION_HL or Algorithm_LOGIC_REGRESSION_HL
+// parties are other models who participates in MPC, assigned with mpc-node address usually
+// rpc is used to request remote mpc-node
+// rh handles final result which is successful or failed
+// params are parameters for training model
+func NewModel(id string, address string, algo pbCom.Algorithm,
+	params *pbCom.TrainModels, samplesFile []byte,
+	parties []string, rpc RpcHandler, rh ResultHandler) (*Model, error) {
+
+	model := &Model{
+		id:          id,
+		algo:        algo,
+		samplesFile: samplesFile,
+		address:     address,
+		parties:     parties,
+		params:      params,
+		rpc:         rpc,
+		rh:          rh,
+		status:      modelStatusStartPredict,
+	}
+
+	go func() {
+		m := &pbFedAvgHl.PredictMessage{
+			Type: pbFedAvgHl.MessageType_MsgPredictHup,
+		}
+		model.advance(m)
+	}()
+
+	return model, nil
+}
@@ -100,8 +100,9 @@ type Model struct {
 	fileRows    [][]string    // fileRows returned by psi.IntersectParts
 	intersect   []string      // intersect returned by psi.IntersectParts
 
-	predictPart          []float64 // local prediction part
-	predictPartFromOther []float64 // prediction part from other party
+	predictPart           []float64            // local prediction part
+	predictPartFromOthers map[string][]float64 // prediction parts from other parties
+	partMutex             sync.Mutex
 
 	outcomes []float64 // final result
 
@@ -152,20 +153,39 @@ func (model *Model) advance(message *pbLogicRegVl.PredictMessage) (*pb.PredictRe
 		}()
 
 	case pbLogicRegVl.MessageType_MsgPsiAskReEnc: // local message
-		newMess := &pbLogicRegVl.PredictMessage{
-			Type:              pbLogicRegVl.MessageType_MsgPsiReEnc,
-			VlLPsiReEncIDsReq: message.VlLPsiReEncIDsReq,
-		}
-		reM, err := model.sendMessageWithRetry(newMess, model.parties[0])
-		if err != nil {
-			go handleError(err)
-			return nil, err
+		// local ID-Set is re-encrypted by other parties one by one,
+		// then the final one is sent to the parties who haven't seen it, the last one in chain already has it
+		newMess := func(encIDs []byte) *pbLogicRegVl.PredictMessage {
+			return &pbLogicRegVl.PredictMessage{
+				Type: pbLogicRegVl.MessageType_MsgPsiReEnc,
+				VlLPsiReEncIDsReq: &pb.VLPsiReEncIDsRequest{
+					TaskID: model.id,
+					EncIDs: encIDs,
+				},
+			}
 		}
 
-		done, err := model.psi.SetReEncryptIDSet(model.parties[0], reM.VlLPsiReEncIDsResp.ReEncIDs)
-		if err != nil {
-			go handleError(err)
-			return nil, err
+		var done bool
+		encIDs := message.VlLPsiReEncIDsReq.EncIDs
+		for _, party := range model.parties {
+			reM, err := model.sendMessageWithRetry(newMess(encIDs), party)
+			if err != nil {
+				go handleError(err)
+				return nil, err
+			}
+
+			done, err = model.psi.SetReEncryptIDSet(party, reM.VlLPsiReEncIDsResp.ReEncIDs)
+			if err != nil {
+				go handleError(err)
+				return nil, err
+			}
+			encIDs = reM.VlLPsiReEncIDsResp.ReEncIDs
+		}
+		for _, party := range model.parties[:len(model.parties)-1] {
+			if _, err := model.sendMessageWithRetry(newMess(encIDs), party); err != nil {
+				go handleError(err)
+				return nil, err
+			}
 		}
 
 		if done {
@@ -246,8 +266,9 @@ func (model *Model) advance(message *pbLogicRegVl.PredictMessage) (*pb.PredictRe
 				return nil, err
 			}
 
-			// The party who has target tag needs the PredictPart from the party who hasn't target tag
-			// So the party who hasn't target sends message , and the party who has target tag waits
+			// The party who has target tag needs the PredictPart from the parties who haven't target tag
+			// So the parties who haven't target send message , and the party who has target tag waits
+			// the party who has target tag is the first one in parties
 			if !model.params.IsTagPart {
 				newMess := &pbLogicRegVl.PredictMessage{
 					Type:        pbLogicRegVl.MessageType_MsgPredictPart,
@@ -270,7 +291,7 @@ func (model *Model) advance(message *pbLogicRegVl.PredictMessage) (*pb.PredictRe
 
 	case pbLogicRegVl.MessageType_MsgPredictPart:
 		partFromOther := message.PredictPart
-		model.setPredictPartFromOther(partFromOther)
+		model.setPredictPartFromOther(message.From, partFromOther)
 		ret = &pb.PredictResponse{
 			TaskID: model.id,
 		}
@@ -340,15 +361,25 @@ func (model *Model) predictLocalPart() (predictPart []float64, err error) {
 	return
 }
 
-func (model *Model) setPredictPartFromOther(predictPart []float64) {
-	model.predictPartFromOther = predictPart
+func (model *Model) setPredictPartFromOther(party string, predictPart []float64) {
+	model.partMutex.Lock()
+	defer model.partMutex.Unlock()
+
+	model.predictPartFromOthers[party] = predictPart
 }
 
 func (model *Model) calRealPredictValue() (done bool, outcomes []float64) {
-	if len(model.predictPart) == 0 || len(model.predictPartFromOther) == 0 {
+	model.partMutex.Lock()
+	defer model.partMutex.Unlock()
+
+	if len(model.predictPart) == 0 || len(model.predictPartFromOthers) != len(model.parties) {
 		return
 	}
-	outcomes = logic.CalRealPredictValue(model.predictPart, model.predictPartFromOther)
+	var predictPartFromOthers [][]float64
+	for _, party := range model.parties {
+		predictPartFromOthers = append(predictPartFromOthers, model.predictPartFromOthers[party])
+	}
+	outcomes = logic.CalRealPredictValue(model.predictPart, predictPartFromOthers)
 	model.outcomes = outcomes
 	done = true
 
@@ -417,7 +448,7 @@ func NewModel(id string, address string,
 	params *pbCom.TrainModels, samplesFile []byte,
 	parties []string, rpc RpcHandler, rh ResultHandler) (*Model, error) {
 
-	p, err := psi.NewVLTwoPartsPSI(address, samplesFile, params.GetIdName(), parties)
+	p, err := psi.NewVLPSI(address, samplesFile, params.GetIdName(), parties)
 	if err != nil {
 		return nil, err
 	}
@@ -433,6 +464,8 @@ func NewModel(id string, address string,
 		rpc:         rpc,
 		rh:          rh,
 		status:      modelStatusStartPSI,
+
+		predictPartFromOthers: make(map[string][]float64),
 	}
 
 	go func() {
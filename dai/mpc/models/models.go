@@ -15,8 +15,10 @@ package models
 
 import (
 	"github.com/PaddlePaddle/PaddleDTX/dai/mpc/models/dnn_paddlefl_vl"
+	"github.com/PaddlePaddle/PaddleDTX/dai/mpc/models/fedavg_hl"
 	"github.com/PaddlePaddle/PaddleDTX/dai/mpc/models/linear_reg_vl"
 	"github.com/PaddlePaddle/PaddleDTX/dai/mpc/models/logic_reg_vl"
+	"github.com/PaddlePaddle/PaddleDTX/dai/mpc/models/secureboost_vl"
 	pbCom "github.com/PaddlePaddle/PaddleDTX/dai/protos/common"
 	pb "github.com/PaddlePaddle/PaddleDTX/dai/protos/mpc"
 )
@@ -65,6 +67,12 @@ func NewModel(id string, address string, algo pbCom.Algorithm,
 			parties, rpc, rh)
 	} else if pbCom.Algorithm_DNN_PADDLEFL_VL == algo {
 		return dnn_paddlefl_vl.NewModel(id, address, params, samplesFile, parties, paddleFLParams, rpc, rh)
+	} else if pbCom.Algorithm_SECUREBOOST_VL == algo {
+		return secureboost_vl.NewModel(id, address, params, samplesFile,
+			parties, rpc, rh)
+	} else if pbCom.Algorithm_LINEAR_REGRESSION_HL == algo || pbCom.Algorithm_LOGIC_REGRESSION_HL == algo {
+		return fedavg_hl.NewModel(id, address, algo, params, samplesFile,
+			parties, rpc, rh)
 	} else { // pbCom.Algorithm_LOGIC_REGRESSION_VL
 		return logic_reg_vl.NewModel(id, address, params, samplesFile,
 			parties, rpc, rh)
@@ -21,6 +21,7 @@ import (
 	"time"
 
 	vl_common "github.com/PaddlePaddle/PaddleDTX/dai/crypto/vl/common"
+	"github.com/PaddlePaddle/PaddleDTX/dai/mpc/learners/fedavg_hl"
 	"github.com/PaddlePaddle/PaddleDTX/dai/mpc/predictor"
 	"github.com/PaddlePaddle/PaddleDTX/dai/mpc/trainer"
 	"github.com/PaddlePaddle/PaddleDTX/dai/p2p"
@@ -1851,6 +1852,425 @@ func TestEvaluBinClassLoo(t *testing.T) {
 	}
 }
 
+// routeRpc dispatches messages to mpc instances by peer name, used by tests with more than two parties
+type routeRpc struct {
+	mpcs map[string]*mpc
+}
+
+func (r *routeRpc) StepTrainWithRetry(req *pb.TrainRequest, peerName string, times int, inteSec int64) (*pb.TrainResponse, error) {
+	return r.StepTrain(req, peerName)
+}
+
+func (r *routeRpc) StepTrain(req *pb.TrainRequest, peerName string) (*pb.TrainResponse, error) {
+	return r.mpcs[peerName].Train(req)
+}
+
+func (r *routeRpc) StepPredictWithRetry(req *pb.PredictRequest, peerName string, times int, inteSec int64) (*pb.PredictResponse, error) {
+	return r.StepPredict(req, peerName)
+}
+
+func (r *routeRpc) StepPredict(req *pb.PredictRequest, peerName string) (*pb.PredictResponse, error) {
+	return r.mpcs[peerName].Predict(req)
+}
+
+// runMultiParts trains a model with vertical learning by several parties and predicts with it,
+// the last one of trainFiles and predictFiles belongs to the tag part,
+// returns prediction outcomes of the tag part
+func runMultiParts(t *testing.T, algo pbCom.Algorithm, taskID string, trainParams *pbCom.TrainParams,
+	trainFiles, predictFiles []string) [][]string {
+
+	var addresses []string
+	for i := range trainFiles {
+		addresses = append(addresses, ":"+strconv.Itoa(9080+i))
+	}
+	tag := len(addresses) - 1
+
+	// initiate mpc instances, and the tag part is the first one in hosts of no-tag parts
+	rpc := &routeRpc{mpcs: make(map[string]*mpc)}
+	var mhs []*modelHolder
+	var hosts [][]string
+	for i, address := range addresses {
+		mh := &modelHolder{
+			trainResultC:   make(chan *pbCom.TrainTaskResult, 1),
+			predictResultC: make(chan *pbCom.PredictTaskResult, 1),
+			t:              t,
+		}
+		m := &mpc{
+			stopC:    make(chan struct{}),
+			doneC:    make(chan struct{}),
+			trainC:   make(chan trainRequest),
+			predictC: make(chan predictRequest),
+		}
+		m.trainer = trainer.NewTrainer(address, rpc, &TrainCallBack{ModelHolder: mh, Mpc: m}, 1000)
+		m.predictor = predictor.NewPredictor(address, rpc, &PredictCallBack{ModelHolder: mh, Mpc: m}, 2000)
+		go m.run()
+		defer m.Stop()
+
+		rpc.mpcs[address] = m
+		mhs = append(mhs, mh)
+
+		var host []string
+		if i != tag {
+			host = append(host, addresses[tag])
+		}
+		for j, other := range addresses {
+			if j != i && j != tag {
+				host = append(host, other)
+			}
+		}
+		hosts = append(hosts, host)
+	}
+
+	// train
+	for i, address := range addresses {
+		file, err := ioutil.ReadFile(trainFiles[i])
+		checkErr(err, t)
+		params := *trainParams
+		params.IsTagPart = i == tag
+		req := &pbCom.StartTaskRequest{
+			TaskID: taskID,
+			File:   file,
+			Hosts:  hosts[i],
+			Params: &pbCom.TaskParams{
+				Algo:        algo,
+				TaskType:    pbCom.TaskType_LEARN,
+				TrainParams: &params,
+			},
+		}
+		err = rpc.mpcs[address].StartTask(req)
+		checkErr(err, t)
+	}
+
+	var models []*pbCom.TrainModels
+	for i := range addresses {
+		result := <-mhs[i].trainResultC
+		if result == nil {
+			t.Fatalf("party[%s] failed to train model", addresses[i])
+		}
+		model, err := vl_common.TrainModelsFromBytes(result.Model)
+		checkErr(err, t)
+		model.IdName = trainParams.IdName
+		t.Logf("party[%s] trained out model[%v]", addresses[i], model)
+		models = append(models, model)
+	}
+
+	// predict
+	for i, address := range addresses {
+		file, err := ioutil.ReadFile(predictFiles[i])
+		checkErr(err, t)
+		req := &pbCom.StartTaskRequest{
+			TaskID: taskID + "-predict",
+			File:   file,
+			Hosts:  hosts[i],
+			Params: &pbCom.TaskParams{
+				Algo:        algo,
+				TaskType:    pbCom.TaskType_PREDICT,
+				ModelParams: models[i],
+			},
+		}
+		err = rpc.mpcs[address].StartTask(req)
+		checkErr(err, t)
+	}
+
+	var outcomes [][]string
+	for i := range addresses {
+		result := <-mhs[i].predictResultC
+		if result == nil {
+			t.Fatalf("party[%s] failed to predict", addresses[i])
+		}
+		if i == tag {
+			var err error
+			outcomes, err = vl_common.PredictResultFromBytes(result.Outcomes)
+			checkErr(err, t)
+		}
+	}
+	return outcomes
+}
+
+func TestLogicRegressionThreeParts(t *testing.T) {
+	trainParams := &pbCom.TrainParams{
+		Label:     "Label",
+		LabelName: "Iris-setosa",
+		RegMode:   0,
+		RegParam:  0.1,
+		Alpha:     0.5,
+		Amplitude: 0.01,
+		Accuracy:  10,
+		IdName:    "id",
+	}
+	trainFiles := []string{
+		"./testdata/vl/logic_iris_plants/train_dataA1.csv",
+		"./testdata/vl/logic_iris_plants/train_dataA2.csv",
+		"./testdata/vl/logic_iris_plants/train_dataB.csv",
+	}
+	predictFiles := []string{
+		"./testdata/vl/logic_iris_plants/predict_dataA1.csv",
+		"./testdata/vl/logic_iris_plants/predict_dataA2.csv",
+		"./testdata/vl/logic_iris_plants/predict_dataB.csv",
+	}
+
+	outcomes := runMultiParts(t, pbCom.Algorithm_LOGIC_REGRESSION_VL, "TestLogicThreeParts", trainParams, trainFiles, predictFiles)
+	// the first row is header
+	if len(outcomes) != 16 {
+		t.Fatalf("expected 15 predictions, got %d", len(outcomes)-1)
+	}
+	t.Logf("prediction outcomes are[%v]", outcomes)
+}
+
+func TestLogicRegressionThreePartsDP(t *testing.T) {
+	trainParams := &pbCom.TrainParams{
+		Label:     "Label",
+		LabelName: "Iris-setosa",
+		Alpha:     0.5,
+		Amplitude: 0.01,
+		Accuracy:  10,
+		IdName:    "id",
+		BatchSize: 16,
+		DpParams: &pbCom.DifferentialPrivacyParams{
+			Enable:    true,
+			Epsilon:   5,
+			Delta:     1e-5,
+			ClipNorm:  1,
+			MaxRounds: 10,
+		},
+	}
+	trainFiles := []string{
+		"./testdata/vl/logic_iris_plants/train_dataA1.csv",
+		"./testdata/vl/logic_iris_plants/train_dataA2.csv",
+		"./testdata/vl/logic_iris_plants/train_dataB.csv",
+	}
+	predictFiles := []string{
+		"./testdata/vl/logic_iris_plants/predict_dataA1.csv",
+		"./testdata/vl/logic_iris_plants/predict_dataA2.csv",
+		"./testdata/vl/logic_iris_plants/predict_dataB.csv",
+	}
+
+	outcomes := runMultiParts(t, pbCom.Algorithm_LOGIC_REGRESSION_VL, "TestLogicThreePartsDP", trainParams, trainFiles, predictFiles)
+	// the first row is header
+	if len(outcomes) != 16 {
+		t.Fatalf("expected 15 predictions, got %d", len(outcomes)-1)
+	}
+	t.Logf("prediction outcomes are[%v]", outcomes)
+}
+
+func TestSecureBoostThreeParts(t *testing.T) {
+	trainParams := &pbCom.TrainParams{
+		Label:     "Label",
+		LabelName: "Iris-versicolor",
+		RegParam:  1,
+		Alpha:     0.5,
+		Accuracy:  10,
+		TreeNum:   2,
+		MaxDepth:  2,
+		IdName:    "id",
+	}
+	trainFiles := []string{
+		"./testdata/vl/logic_iris_plants/train_dataA1.csv",
+		"./testdata/vl/logic_iris_plants/train_dataA2.csv",
+		"./testdata/vl/logic_iris_plants/train_dataB.csv",
+	}
+	predictFiles := []string{
+		"./testdata/vl/logic_iris_plants/predict_dataA1.csv",
+		"./testdata/vl/logic_iris_plants/predict_dataA2.csv",
+		"./testdata/vl/logic_iris_plants/predict_dataB.csv",
+	}
+
+	outcomes := runMultiParts(t, pbCom.Algorithm_SECUREBOOST_VL, "TestSecureBoostThreeParts", trainParams, trainFiles, predictFiles)
+	// the first row is header
+	if len(outcomes) != 16 {
+		t.Fatalf("expected 15 predictions, got %d", len(outcomes)-1)
+	}
+	t.Logf("prediction outcomes are[%v]", outcomes)
+}
+
+// runHorizontalParts trains a model with horizontal learning by several parties and predicts with it,
+// every party holds samples with label and predicts its own samples,
+// returns prediction outcomes of all parties with only one header
+func runHorizontalParts(t *testing.T, algo pbCom.Algorithm, taskID string, trainParams *pbCom.TrainParams,
+	trainFiles, predictFiles []string) [][]string {
+
+	var addresses []string
+	for i := range trainFiles {
+		addresses = append(addresses, ":"+strconv.Itoa(9180+i))
+	}
+
+	rpc := &routeRpc{mpcs: make(map[string]*mpc)}
+	var mhs []*modelHolder
+	var hosts [][]string
+	for i, address := range addresses {
+		mh := &modelHolder{
+			trainResultC:   make(chan *pbCom.TrainTaskResult, 1),
+			predictResultC: make(chan *pbCom.PredictTaskResult, 1),
+			t:              t,
+		}
+		m := &mpc{
+			stopC:    make(chan struct{}),
+			doneC:    make(chan struct{}),
+			trainC:   make(chan trainRequest),
+			predictC: make(chan predictRequest),
+		}
+		m.trainer = trainer.NewTrainer(address, rpc, &TrainCallBack{ModelHolder: mh, Mpc: m}, 1000)
+		m.predictor = predictor.NewPredictor(address, rpc, &PredictCallBack{ModelHolder: mh, Mpc: m}, 2000)
+		go m.run()
+		defer m.Stop()
+
+		rpc.mpcs[address] = m
+		mhs = append(mhs, mh)
+
+		var host []string
+		for j, other := range addresses {
+			if j != i {
+				host = append(host, other)
+			}
+		}
+		hosts = append(hosts, host)
+	}
+
+	// train
+	for i, address := range addresses {
+		file, err := ioutil.ReadFile(trainFiles[i])
+		checkErr(err, t)
+		params := *trainParams
+		params.IsTagPart = true
+		req := &pbCom.StartTaskRequest{
+			TaskID: taskID,
+			File:   file,
+			Hosts:  hosts[i],
+			Params: &pbCom.TaskParams{
+				Algo:        algo,
+				TaskType:    pbCom.TaskType_LEARN,
+				TrainParams: &params,
+			},
+		}
+		err = rpc.mpcs[address].StartTask(req)
+		checkErr(err, t)
+	}
+
+	var models []*pbCom.TrainModels
+	for i := range addresses {
+		result := <-mhs[i].trainResultC
+		if result == nil {
+			t.Fatalf("party[%s] failed to train model", addresses[i])
+		}
+		model, err := vl_common.TrainModelsFromBytes(result.Model)
+		checkErr(err, t)
+		model.IdName = trainParams.IdName
+		t.Logf("party[%s] trained out model[%v]", addresses[i], model)
+		models = append(models, model)
+	}
+	// all parties share the same global model
+	for i := 1; i < len(models); i++ {
+		for k, v := range models[0].Thetas {
+			if models[i].Thetas[k] != v {
+				t.Fatalf("party[%s] got different model with party[%s]", addresses[i], addresses[0])
+			}
+		}
+	}
+
+	// predict
+	for i, address := range addresses {
+		file, err := ioutil.ReadFile(predictFiles[i])
+		checkErr(err, t)
+		req := &pbCom.StartTaskRequest{
+			TaskID: taskID + "-predict",
+			File:   file,
+			Hosts:  hosts[i],
+			Params: &pbCom.TaskParams{
+				Algo:        algo,
+				TaskType:    pbCom.TaskType_PREDICT,
+				ModelParams: models[i],
+			},
+		}
+		err = rpc.mpcs[address].StartTask(req)
+		checkErr(err, t)
+	}
+
+	var outcomes [][]string
+	for i := range addresses {
+		result := <-mhs[i].predictResultC
+		if result == nil {
+			t.Fatalf("party[%s] failed to predict", addresses[i])
+		}
+		rows, err := vl_common.PredictResultFromBytes(result.Outcomes)
+		checkErr(err, t)
+		if len(outcomes) > 0 && len(rows) > 0 {
+			rows = rows[1:]
+		}
+		outcomes = append(outcomes, rows...)
+	}
+	return outcomes
+}
+
+func TestLogicRegressionHorizontal(t *testing.T) {
+	trainParams := &pbCom.TrainParams{
+		Label:     "Label",
+		LabelName: "Iris-setosa",
+		RegMode:   0,
+		RegParam:  0.1,
+		Alpha:     0.1,
+		Amplitude: 0.0001,
+		Accuracy:  10,
+		BatchSize: 16,
+		IdName:    "id",
+	}
+	trainFiles := []string{
+		"./testdata/hl/logic_iris_plants/train_dataA.csv",
+		"./testdata/hl/logic_iris_plants/train_dataB.csv",
+		"./testdata/hl/logic_iris_plants/train_dataC.csv",
+	}
+	predictFiles := []string{
+		"./testdata/hl/logic_iris_plants/predict_dataA.csv",
+		"./testdata/hl/logic_iris_plants/predict_dataB.csv",
+		"./testdata/hl/logic_iris_plants/predict_dataC.csv",
+	}
+
+	outcomes := runHorizontalParts(t, pbCom.Algorithm_LOGIC_REGRESSION_HL, "TestLogicHorizontal", trainParams, trainFiles, predictFiles)
+	// the first row is header
+	if len(outcomes) != 16 {
+		t.Fatalf("expected 15 predictions, got %d", len(outcomes)-1)
+	}
+	t.Logf("prediction outcomes are[%v]", outcomes)
+}
+
+func TestLinearRegressionHorizontal(t *testing.T) {
+	trainParams := &pbCom.TrainParams{
+		Label:     "MEDV",
+		RegMode:   pbCom.RegMode_Reg_Ridge,
+		RegParam:  0.1,
+		Alpha:     0.1,
+		Amplitude: 0.0001,
+		Accuracy:  10,
+		BatchSize: 16,
+		IdName:    "id",
+	}
+	trainFiles := []string{
+		"./testdata/hl/linear_boston_housing/train_dataA.csv",
+		"./testdata/hl/linear_boston_housing/train_dataB.csv",
+		"./testdata/hl/linear_boston_housing/train_dataC.csv",
+	}
+	predictFiles := []string{
+		"./testdata/hl/linear_boston_housing/predict_dataA.csv",
+		"./testdata/hl/linear_boston_housing/predict_dataB.csv",
+		"./testdata/hl/linear_boston_housing/predict_dataC.csv",
+	}
+
+	outcomes := runHorizontalParts(t, pbCom.Algorithm_LINEAR_REGRESSION_HL, "TestLinearHorizontal", trainParams, trainFiles, predictFiles)
+	// the first row is header
+	if len(outcomes) != 51 {
+		t.Fatalf("expected 50 predictions, got %d", len(outcomes)-1)
+	}
+	t.Logf("prediction outcomes are[%v]", outcomes)
+}
+
+func TestHorizontalTwoParts(t *testing.T) {
+	// the aggregated sum would reveal local values of the other party
+	_, err := fedavg_hl.NewLearnerWithoutSamples("TestHorizontalTwoParts", ":9180", pbCom.Algorithm_LINEAR_REGRESSION_HL,
+		&pbCom.TrainParams{Label: "MEDV"}, []string{":9181"}, nil, nil)
+	if err == nil {
+		t.Fatal("horizontal learning with two parties should be rejected")
+	}
+}
+
 func checkErr(err error, t *testing.T) {
 	if err != nil {
 		t.Error(err)
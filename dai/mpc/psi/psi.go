@@ -215,3 +215,13 @@ func NewVLTwoPartsPSI(name string, samplesFile []byte, samplesIdName string, par
 
 	return p, nil
 }
+
+// NewVLPSI create a VLPSI instance for vertical Learners and Models according to the number of parties,
+// two parties use VLTwoPartsPSI, and more parties use VLMultiPartsPSI,
+// which reveals sizes of intersections among subsets of parties to every party, see vlMultiPartsPsi
+func NewVLPSI(name string, samplesFile []byte, samplesIdName string, parties []string) (VLPSI, error) {
+	if len(parties) > 1 {
+		return NewVLMultiPartsPSI(name, samplesFile, samplesIdName, parties)
+	}
+	return NewVLTwoPartsPSI(name, samplesFile, samplesIdName, parties)
+}
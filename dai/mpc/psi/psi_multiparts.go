@@ -0,0 +1,288 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package psi
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"sync"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+
+	vl_common "github.com/PaddlePaddle/PaddleDTX/dai/crypto/vl/common"
+	csv "github.com/PaddlePaddle/PaddleDTX/dai/crypto/vl/common/csv"
+	"github.com/PaddlePaddle/PaddleDTX/dai/errcodes"
+)
+
+// chainedIDSet is the ID-Set passed along the re-encryption chain,
+// it's encrypted by its owner at first and then re-encrypted by every other party one by one
+type chainedIDSet struct {
+	Owner string          // party whose sample IDs are in the set
+	Chain []string        // parties who have re-encrypted the set, in order
+	IDs   json.RawMessage // encrypted IDs, see vl_common.PSIEncSetToBytes
+}
+
+// vlMultiPartsPsi implements VLPSI, intersecting sample IDs of two or more parties.
+// Each party's ID-Set is encrypted by itself and then re-encrypted by all other parties in chain,
+// because ECDH encryption is commutative, IDs shared by all parties finally become the same ciphertexts.
+// The party who drives the chain of its own ID-Set is expected to:
+//  1. send the result of EncryptSampleIDSet to one of other parties to ReEncryptIDSet,
+//  2. pass the re-encrypted ID-Set to SetReEncryptIDSet and send it to next party to ReEncryptIDSet,
+//     repeat until SetReEncryptIDSet returns Done,
+//  3. send the final ID-Set to the parties who haven't seen it, except for the last one in chain,
+//     ReEncryptIDSet leaves a final ID-Set unchanged, and SetOtherFinalReEncryptIDSet only keeps final ones.
+//
+// Every party intersects the final ID-Sets of all parties by itself, so besides the intersection of all parties,
+// it learns the sizes of other parties' ID-Sets and of the intersections among any of them,
+// e.g. with three parties A, B and C, A knows how many IDs B and C share even if A doesn't have them.
+// The IDs themselves are not revealed, use two parties when those sizes are also sensitive.
+type vlMultiPartsPsi struct {
+	name          string
+	privkey       *ecdsa.PrivateKey // local ecc private key for ID encryption
+	samplesFile   []byte            // csv file content subjected to specified form
+	samplesIdName string            // feature name for samples ID, used to extract IDs
+	parties       map[string]bool   // names of other parties who participate MPC
+
+	// intermediate results
+	// see vl_common.psi for more
+	ids                          []string
+	rows                         [][]string
+	encIDs                       []byte
+	finalReEncIDs                []byte
+	reEncryptIDSetsFromOthers    sync.Map // stores re-encrypted ID-Sets returned by other parties
+	finalReEncryptIDSetsOfOthers sync.Map // stores final re-encrypted ID-Sets of other parties
+
+	// final results
+	lock      sync.Mutex
+	done      bool
+	newRows   [][]string
+	intersect []string
+}
+
+// EncryptSampleIDSet encrypt sample ID list using own public key
+func (vp *vlMultiPartsPsi) EncryptSampleIDSet() ([]byte, error) {
+	err := vp.readSamples()
+	if err != nil {
+		return []byte{}, errorx.New(errcodes.ErrCodePSISamplesFile, "mistake[%s] happened when PSI read IDs from file", err.Error())
+	}
+
+	encIDs, err := vl_common.EncryptSampleIDSet(vp.ids, &vp.privkey.PublicKey)
+	if err != nil {
+		return []byte{}, errorx.New(errcodes.ErrCodePSIEncryptSampleIDSet, "mistake[%s] happened when PSI encrypt SampleIDSet", err.Error())
+	}
+
+	set, err := json.Marshal(chainedIDSet{Owner: vp.name, IDs: encIDs})
+	if err != nil {
+		return []byte{}, errorx.New(errcodes.ErrCodePSIEncryptSampleIDSet, "mistake[%s] happened when PSI marshal SampleIDSet", err.Error())
+	}
+	vp.encIDs = set
+
+	return vp.encIDs, nil
+}
+
+// SetReEncryptIDSet sets own ID-Set re-encrypted by other party,
+// returns True if it has been re-encrypted by all parties
+func (vp *vlMultiPartsPsi) SetReEncryptIDSet(party string, reEncIDs []byte) (bool, error) {
+	if _, ok := vp.parties[party]; !ok {
+		// if from unknown party, ignore
+		return false, nil
+	}
+
+	set, err := vp.unmarshalIDSet(vp.name, reEncIDs)
+	if err != nil {
+		return false, errorx.New(errcodes.ErrCodePSIReEncryptIDSet, "mistake[%s] happened when PSI set ReEncryptIDSet from party[%s]", err.Error(), party)
+	}
+	vp.reEncryptIDSetsFromOthers.LoadOrStore(party, reEncIDs)
+
+	if !vp.isFinal(set) {
+		return false, nil
+	}
+	vp.lock.Lock()
+	defer vp.lock.Unlock()
+	vp.finalReEncIDs = set.IDs
+
+	return true, nil
+}
+
+// ReEncryptIDSet re-encrypt ID list for other party using own private key,
+// the ID-Set already re-encrypted by all parties is returned as it is
+func (vp *vlMultiPartsPsi) ReEncryptIDSet(party string, encIDs []byte) ([]byte, error) {
+	// if from unknown party, don't care about any Error
+	if _, ok := vp.parties[party]; !ok {
+		return []byte{}, nil
+	}
+
+	set, err := vp.unmarshalIDSet(party, encIDs)
+	if err != nil {
+		return []byte{}, errorx.New(errcodes.ErrCodePSIReEncryptIDSet, "mistake[%s] happened when PSI encrypt EncryptedSampleIDSet for other party[%s]", err.Error(), party)
+	}
+	if vp.isFinal(set) {
+		return encIDs, nil
+	}
+	for _, p := range set.Chain {
+		if p == vp.name {
+			return []byte{}, errorx.New(errcodes.ErrCodePSIReEncryptIDSet, "EncryptedSampleIDSet of party[%s] was already re-encrypted by local party", party)
+		}
+	}
+
+	reEncIDs, err := vl_common.ReEncryptIDSet(set.IDs, vp.privkey)
+	if err != nil {
+		return []byte{}, errorx.New(errcodes.ErrCodePSIReEncryptIDSet, "mistake[%s] happened when PSI encrypt EncryptedSampleIDSet for other party[%s]", err.Error(), party)
+	}
+	set.IDs = reEncIDs
+	set.Chain = append(set.Chain, vp.name)
+
+	reEncSet, err := json.Marshal(set)
+	if err != nil {
+		return []byte{}, errorx.New(errcodes.ErrCodePSIReEncryptIDSet, "mistake[%s] happened when PSI marshal EncryptedSampleIDSet for other party[%s]", err.Error(), party)
+	}
+	return reEncSet, nil
+}
+
+// SetOtherFinalReEncryptIDSet sets final re-encrypted IDs of other party,
+// ID-Sets still in the middle of chain are ignored
+func (vp *vlMultiPartsPsi) SetOtherFinalReEncryptIDSet(party string, reEncIDs []byte) error {
+	// if from unknown party, don't store result
+	if _, ok := vp.parties[party]; !ok {
+		return nil
+	}
+
+	set, err := vp.unmarshalIDSet(party, reEncIDs)
+	if err != nil {
+		return errorx.New(errcodes.ErrCodePSIReEncryptIDSet, "mistake[%s] happened when PSI set final ReEncryptIDSet of party[%s]", err.Error(), party)
+	}
+	if vp.isFinal(set) {
+		vp.finalReEncryptIDSetsOfOthers.LoadOrStore(party, []byte(set.IDs))
+	}
+	return nil
+}
+
+// IntersectParts calculate intersections of all parties samples, and re-arrange sample files
+func (vp *vlMultiPartsPsi) IntersectParts() (bool, [][]string, []string, error) {
+	vp.lock.Lock()
+	defer vp.lock.Unlock()
+
+	if vp.done {
+		return vp.done, vp.newRows, vp.intersect, nil
+	}
+
+	var newRows [][]string
+	if vp.finalReEncIDs == nil {
+		return false, newRows, nil, nil
+	}
+
+	var finalReEncIDsOfOthers [][]byte
+	for party := range vp.parties {
+		v, ok := vp.finalReEncryptIDSetsOfOthers.Load(party)
+		if !ok {
+			return false, newRows, nil, nil
+		}
+		finalReEncIDsOfOthers = append(finalReEncIDsOfOthers, v.([]byte))
+	}
+
+	intersect, err := vl_common.IntersectParts(vp.ids, vp.finalReEncIDs, finalReEncIDsOfOthers)
+	if err != nil {
+		return false, newRows, intersect, errorx.New(errcodes.ErrCodePSIIntersectParts, "mistake[%s] happened when PSI intersect all parts", err.Error())
+	}
+
+	newRows, err = vl_common.RearrangeFileWithIntersectIDs(vp.rows, vp.samplesIdName, intersect)
+	if err != nil {
+		return false, newRows, intersect, errorx.New(errcodes.ErrCodePSIRearrangeFile, "mistake[%s] happened when PSI rearrange file with intersected IDs", err.Error())
+	}
+
+	vp.newRows = newRows
+	vp.intersect = intersect
+	vp.done = true
+
+	return vp.done, vp.newRows, vp.intersect, nil
+}
+
+// unmarshalIDSet retrieve chained ID-Set from bytes and check its owner
+func (vp *vlMultiPartsPsi) unmarshalIDSet(owner string, setBytes []byte) (chainedIDSet, error) {
+	var set chainedIDSet
+	if err := json.Unmarshal(setBytes, &set); err != nil {
+		return set, err
+	}
+	if set.Owner != owner {
+		return set, errorx.New(errcodes.ErrCodeParam, "ID-Set owned by[%s] is not expected, expected owner is[%s]", set.Owner, owner)
+	}
+	return set, nil
+}
+
+// isFinal checks whether the ID-Set was re-encrypted by all parties except for its owner
+func (vp *vlMultiPartsPsi) isFinal(set chainedIDSet) bool {
+	chained := make(map[string]bool)
+	for _, p := range set.Chain {
+		chained[p] = true
+	}
+	if len(chained) != len(vp.parties) {
+		return false
+	}
+	for p := range chained {
+		if p == set.Owner || (p != vp.name && !vp.parties[p]) {
+			return false
+		}
+	}
+	return true
+}
+
+// readSamples retrieve ID list from sample file rows
+func (vp *vlMultiPartsPsi) readSamples() error {
+	rows, IDs, err := csv.ReadIDsFromFileRows(vp.samplesFile, vp.samplesIdName)
+
+	if err != nil {
+		return err
+	}
+
+	vp.ids = IDs
+	vp.rows = rows
+
+	return nil
+}
+
+// NewVLMultiPartsPSI create a VLPSI instance and initiate it, intersecting samples of two or more parties
+// by chaining ECDH re-encryption across all parties.
+// name is to name the PSI instance, and also identifies local party in the chain
+// parties are names of other parties who participate MPC
+// sampleFile is csv file content subjected to specified form
+// sampleIdName is used to extract IDs
+func NewVLMultiPartsPSI(name string, samplesFile []byte, samplesIdName string, parties []string) (VLPSI, error) {
+	if len(parties) <= 0 {
+		return nil, errorx.New(errcodes.ErrCodeParam, "no parties in PSI")
+	}
+
+	p := &vlMultiPartsPsi{
+		name:          name,
+		samplesFile:   samplesFile,
+		samplesIdName: samplesIdName,
+	}
+
+	p.parties = make(map[string]bool)
+	for _, party := range parties {
+		if party == name {
+			return nil, errorx.New(errcodes.ErrCodeParam, "local party[%s] should not be in parties of PSI", name)
+		}
+		p.parties[party] = true
+	}
+
+	// create local ecc private key and public key pair for ID encryption
+	privkey, err := vl_common.GeneratePSIKeyPair()
+	if err != nil {
+		return nil, errorx.New(errcodes.ErrCodeInternal, "mistake[%s] happened when PSI GeneratePSIKeyPair", err.Error())
+	}
+
+	p.privkey = privkey
+
+	return p, nil
+}
@@ -4,7 +4,12 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"reflect"
+	"sort"
+	"strconv"
 	"testing"
+
+	csv "github.com/PaddlePaddle/PaddleDTX/dai/crypto/vl/common/csv"
 )
 
 func TestVlThreePartsPsi(t *testing.T) {
@@ -105,6 +110,126 @@ func TestVLTwoPartsPsi(t *testing.T) {
 
 }
 
+func TestVLMultiPartsPsiThreeParts(t *testing.T) {
+	path, _ := os.Getwd()
+
+	files := map[string][]byte{
+		"address1": readTestData(path + "/testdata/dataA.csv"),
+		"address2": readTestData(path + "/testdata/dataB.csv"),
+		"address3": readTestData(path + "/testdata/dataC.csv"),
+	}
+	intersect := runMultiPartsPsi(t, files)
+
+	_, ids, err := csv.ReadIDsFromFileRows(files["address1"], "id")
+	checkErr(err)
+	if len(intersect) != len(ids) {
+		t.Fatalf("intersection should contain all %d samples, got %d", len(ids), len(intersect))
+	}
+}
+
+func TestVLMultiPartsPsiFourParts(t *testing.T) {
+	path, _ := os.Getwd()
+
+	files := map[string][]byte{
+		"address1": readTestData(path + "/testdata/dataA.csv"),
+		"address2": readTestData(path + "/testdata/dataB.csv"),
+		"address3": readTestData(path + "/testdata/dataC.csv"),
+		"address4": readTestData(path + "/testdata/dataD.csv"),
+	}
+	intersect := runMultiPartsPsi(t, files)
+
+	// dataD only contains samples with IDs from 101 to 400
+	if len(intersect) != 300 {
+		t.Fatalf("intersection should contain 300 samples, got %d", len(intersect))
+	}
+	for _, id := range intersect {
+		n, err := strconv.Atoi(id)
+		checkErr(err)
+		if n < 101 || n > 400 {
+			t.Fatalf("unexpected ID[%s] in intersection", id)
+		}
+	}
+}
+
+// runMultiPartsPsi runs PSI among in-process parties the same way as Learners do,
+// each party drives the re-encryption chain of its own ID-Set and then sends the final one to others,
+// returns the intersection after checking all parties get the same result
+func runMultiPartsPsi(t *testing.T, files map[string][]byte) []string {
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	vps := make(map[string]VLPSI)
+	for _, name := range names {
+		var parties []string
+		for _, p := range names {
+			if p != name {
+				parties = append(parties, p)
+			}
+		}
+		vp, err := NewVLMultiPartsPSI(name, files[name], "id", parties)
+		checkErr(err)
+		vps[name] = vp
+	}
+
+	for i, owner := range names {
+		encIDs, err := vps[owner].EncryptSampleIDSet()
+		checkErr(err)
+
+		// every party starts its chain from a different peer
+		var chain []string
+		for j := 1; j < len(names); j++ {
+			chain = append(chain, names[(i+j)%len(names)])
+		}
+
+		done := false
+		for _, p := range chain {
+			if done {
+				t.Fatalf("chain of party[%s] is done before party[%s] re-encrypts", owner, p)
+			}
+			reEncIDs, err := vps[p].ReEncryptIDSet(owner, encIDs)
+			checkErr(err)
+			checkErr(vps[p].SetOtherFinalReEncryptIDSet(owner, reEncIDs))
+
+			done, err = vps[owner].SetReEncryptIDSet(p, reEncIDs)
+			checkErr(err)
+			encIDs = reEncIDs
+		}
+		if !done {
+			t.Fatalf("chain of party[%s] is not done", owner)
+		}
+
+		for _, p := range chain[:len(chain)-1] {
+			finalIDs, err := vps[p].ReEncryptIDSet(owner, encIDs)
+			checkErr(err)
+			checkErr(vps[p].SetOtherFinalReEncryptIDSet(owner, finalIDs))
+		}
+	}
+
+	var intersect []string
+	for i, name := range names {
+		done, rows, ids, err := vps[name].IntersectParts()
+		checkErr(err)
+		if !done {
+			t.Fatalf("party[%s] failed to intersect all parts", name)
+		}
+		if len(rows) != len(ids)+1 {
+			t.Fatalf("party[%s] got %d rows for %d intersected IDs", name, len(rows), len(ids))
+		}
+		sort.Strings(ids)
+		if i == 0 {
+			intersect = ids
+			continue
+		}
+		if !reflect.DeepEqual(intersect, ids) {
+			t.Fatalf("party[%s] got a different intersection", name)
+		}
+	}
+	return intersect
+}
+
 func readTestData(filename string) []byte {
 	file, err := os.Open(filename)
 	checkErr(err)
@@ -27,18 +27,27 @@ const (
 	Algorithm_LINEAR_REGRESSION_VL Algorithm = 0
 	Algorithm_LOGIC_REGRESSION_VL  Algorithm = 1
 	Algorithm_DNN_PADDLEFL_VL      Algorithm = 2
+	Algorithm_SECUREBOOST_VL       Algorithm = 3
+	Algorithm_LINEAR_REGRESSION_HL Algorithm = 4
+	Algorithm_LOGIC_REGRESSION_HL  Algorithm = 5
 )
 
 var Algorithm_name = map[int32]string{
 	0: "LINEAR_REGRESSION_VL",
 	1: "LOGIC_REGRESSION_VL",
 	2: "DNN_PADDLEFL_VL",
+	3: "SECUREBOOST_VL",
+	4: "LINEAR_REGRESSION_HL",
+	5: "LOGIC_REGRESSION_HL",
 }
 
 var Algorithm_value = map[string]int32{
 	"LINEAR_REGRESSION_VL": 0,
 	"LOGIC_REGRESSION_VL":  1,
 	"DNN_PADDLEFL_VL":      2,
+	"SECUREBOOST_VL":       3,
+	"LINEAR_REGRESSION_HL": 4,
+	"LOGIC_REGRESSION_HL":  5,
 }
 
 func (x Algorithm) String() string {
@@ -165,19 +174,22 @@ func (CaseType) EnumDescriptor() ([]byte, []int) {
 
 // TrainParams lists all the parameters for training
 type TrainParams struct {
-	Label                string   `protobuf:"bytes,1,opt,name=label,proto3" json:"label,omitempty"`
-	LabelName            string   `protobuf:"bytes,2,opt,name=labelName,proto3" json:"labelName,omitempty"`
-	RegMode              RegMode  `protobuf:"varint,3,opt,name=regMode,proto3,enum=common.RegMode" json:"regMode,omitempty"`
-	RegParam             float64  `protobuf:"fixed64,4,opt,name=regParam,proto3" json:"regParam,omitempty"`
-	Alpha                float64  `protobuf:"fixed64,5,opt,name=alpha,proto3" json:"alpha,omitempty"`
-	Amplitude            float64  `protobuf:"fixed64,6,opt,name=amplitude,proto3" json:"amplitude,omitempty"`
-	Accuracy             int64    `protobuf:"varint,7,opt,name=accuracy,proto3" json:"accuracy,omitempty"`
-	IsTagPart            bool     `protobuf:"varint,8,opt,name=isTagPart,proto3" json:"isTagPart,omitempty"`
-	IdName               string   `protobuf:"bytes,9,opt,name=idName,proto3" json:"idName,omitempty"`
-	BatchSize            int64    `protobuf:"varint,10,opt,name=batchSize,proto3" json:"batchSize,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	Label                string                     `protobuf:"bytes,1,opt,name=label,proto3" json:"label,omitempty"`
+	LabelName            string                     `protobuf:"bytes,2,opt,name=labelName,proto3" json:"labelName,omitempty"`
+	RegMode              RegMode                    `protobuf:"varint,3,opt,name=regMode,proto3,enum=common.RegMode" json:"regMode,omitempty"`
+	RegParam             float64                    `protobuf:"fixed64,4,opt,name=regParam,proto3" json:"regParam,omitempty"`
+	Alpha                float64                    `protobuf:"fixed64,5,opt,name=alpha,proto3" json:"alpha,omitempty"`
+	Amplitude            float64                    `protobuf:"fixed64,6,opt,name=amplitude,proto3" json:"amplitude,omitempty"`
+	Accuracy             int64                      `protobuf:"varint,7,opt,name=accuracy,proto3" json:"accuracy,omitempty"`
+	IsTagPart            bool                       `protobuf:"varint,8,opt,name=isTagPart,proto3" json:"isTagPart,omitempty"`
+	IdName               string                     `protobuf:"bytes,9,opt,name=idName,proto3" json:"idName,omitempty"`
+	BatchSize            int64                      `protobuf:"varint,10,opt,name=batchSize,proto3" json:"batchSize,omitempty"`
+	TreeNum              int64                      `protobuf:"varint,11,opt,name=treeNum,proto3" json:"treeNum,omitempty"`
+	MaxDepth             int64                      `protobuf:"varint,12,opt,name=maxDepth,proto3" json:"maxDepth,omitempty"`
+	DpParams             *DifferentialPrivacyParams `protobuf:"bytes,13,opt,name=dpParams,proto3" json:"dpParams,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                   `json:"-"`
+	XXX_unrecognized     []byte                     `json:"-"`
+	XXX_sizecache        int32                      `json:"-"`
 }
 
 func (m *TrainParams) Reset()         { *m = TrainParams{} }
@@ -275,6 +287,27 @@ func (m *TrainParams) GetBatchSize() int64 {
 	return 0
 }
 
+func (m *TrainParams) GetTreeNum() int64 {
+	if m != nil {
+		return m.TreeNum
+	}
+	return 0
+}
+
+func (m *TrainParams) GetMaxDepth() int64 {
+	if m != nil {
+		return m.MaxDepth
+	}
+	return 0
+}
+
+func (m *TrainParams) GetDpParams() *DifferentialPrivacyParams {
+	if m != nil {
+		return m.DpParams
+	}
+	return nil
+}
+
 // TrainModels is final result of distributed training
 type TrainModels struct {
 	Thetas               map[string]float64 `protobuf:"bytes,1,rep,name=thetas,proto3" json:"thetas,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"fixed64,2,opt,name=value,proto3"`
@@ -284,6 +317,7 @@ type TrainModels struct {
 	IsTagPart            bool               `protobuf:"varint,5,opt,name=isTagPart,proto3" json:"isTagPart,omitempty"`
 	IdName               string             `protobuf:"bytes,6,opt,name=idName,proto3" json:"idName,omitempty"`
 	Path                 string             `protobuf:"bytes,7,opt,name=path,proto3" json:"path,omitempty"`
+	BoostTrees           []byte             `protobuf:"bytes,8,opt,name=boostTrees,proto3" json:"boostTrees,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
 	XXX_unrecognized     []byte             `json:"-"`
 	XXX_sizecache        int32              `json:"-"`
@@ -363,6 +397,13 @@ func (m *TrainModels) GetPath() string {
 	return ""
 }
 
+func (m *TrainModels) GetBoostTrees() []byte {
+	if m != nil {
+		return m.BoostTrees
+	}
+	return nil
+}
+
 // TaskParams lists all the parameters in a task
 type TaskParams struct {
 	Algo                 Algorithm             `protobuf:"varint,1,opt,name=algo,proto3,enum=common.Algorithm" json:"algo,omitempty"`
@@ -1376,6 +1417,79 @@ func (m *StopTaskRequest) GetParams() *TaskParams {
 	return nil
 }
 
+// DifferentialPrivacyParams defines the privacy budget of a training task,
+// gaussian noise calibrated by (epsilon, delta) is added to clipped gradients in every round.
+type DifferentialPrivacyParams struct {
+	Enable               bool     `protobuf:"varint,1,opt,name=enable,proto3" json:"enable,omitempty"`
+	Epsilon              float64  `protobuf:"fixed64,2,opt,name=epsilon,proto3" json:"epsilon,omitempty"`
+	Delta                float64  `protobuf:"fixed64,3,opt,name=delta,proto3" json:"delta,omitempty"`
+	ClipNorm             float64  `protobuf:"fixed64,4,opt,name=clipNorm,proto3" json:"clipNorm,omitempty"`
+	MaxRounds            int64    `protobuf:"varint,5,opt,name=maxRounds,proto3" json:"maxRounds,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DifferentialPrivacyParams) Reset()         { *m = DifferentialPrivacyParams{} }
+func (m *DifferentialPrivacyParams) String() string { return proto.CompactTextString(m) }
+func (*DifferentialPrivacyParams) ProtoMessage()    {}
+func (*DifferentialPrivacyParams) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8f954d82c0b891f6, []int{15}
+}
+
+func (m *DifferentialPrivacyParams) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DifferentialPrivacyParams.Unmarshal(m, b)
+}
+func (m *DifferentialPrivacyParams) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DifferentialPrivacyParams.Marshal(b, m, deterministic)
+}
+func (m *DifferentialPrivacyParams) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DifferentialPrivacyParams.Merge(m, src)
+}
+func (m *DifferentialPrivacyParams) XXX_Size() int {
+	return xxx_messageInfo_DifferentialPrivacyParams.Size(m)
+}
+func (m *DifferentialPrivacyParams) XXX_DiscardUnknown() {
+	xxx_messageInfo_DifferentialPrivacyParams.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DifferentialPrivacyParams proto.InternalMessageInfo
+
+func (m *DifferentialPrivacyParams) GetEnable() bool {
+	if m != nil {
+		return m.Enable
+	}
+	return false
+}
+
+func (m *DifferentialPrivacyParams) GetEpsilon() float64 {
+	if m != nil {
+		return m.Epsilon
+	}
+	return 0
+}
+
+func (m *DifferentialPrivacyParams) GetDelta() float64 {
+	if m != nil {
+		return m.Delta
+	}
+	return 0
+}
+
+func (m *DifferentialPrivacyParams) GetClipNorm() float64 {
+	if m != nil {
+		return m.ClipNorm
+	}
+	return 0
+}
+
+func (m *DifferentialPrivacyParams) GetMaxRounds() int64 {
+	if m != nil {
+		return m.MaxRounds
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterEnum("common.Algorithm", Algorithm_name, Algorithm_value)
 	proto.RegisterEnum("common.TaskType", TaskType_name, TaskType_value)
@@ -1405,104 +1519,115 @@ func init() {
 	proto.RegisterType((*StartTaskRequest)(nil), "common.StartTaskRequest")
 	proto.RegisterType((*PaddleFLParams)(nil), "common.PaddleFLParams")
 	proto.RegisterType((*StopTaskRequest)(nil), "common.StopTaskRequest")
+	proto.RegisterType((*DifferentialPrivacyParams)(nil), "common.DifferentialPrivacyParams")
 }
 
 //func init() { proto.RegisterFile("common/common.proto", fileDescriptor_8f954d82c0b891f6) }
 
 var fileDescriptor_8f954d82c0b891f6 = []byte{
-	// 1498 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x57, 0xcd, 0x6e, 0x1b, 0x47,
-	0x12, 0xd6, 0x50, 0xe2, 0x5f, 0x51, 0xa6, 0xc6, 0x2d, 0xaf, 0x77, 0x40, 0x1b, 0x5e, 0x62, 0x16,
-	0x0b, 0xc8, 0xf2, 0x46, 0x42, 0xe8, 0x18, 0xfe, 0x03, 0x0c, 0xc8, 0x14, 0x65, 0x2b, 0xa0, 0x24,
-	0xa2, 0x49, 0x1b, 0x46, 0x2e, 0x42, 0x6b, 0xa6, 0x35, 0x1c, 0x78, 0xc8, 0x61, 0xa6, 0x87, 0xb4,
-	0x95, 0x7b, 0x9e, 0x21, 0x2f, 0x90, 0x63, 0xee, 0xb9, 0xe6, 0x92, 0x53, 0x1e, 0x25, 0xb7, 0x3c,
-	0x41, 0x50, 0xdd, 0x3d, 0x3f, 0x94, 0x44, 0x59, 0x42, 0x2e, 0x52, 0x7f, 0xd5, 0x55, 0x5d, 0x55,
-	0x5f, 0xf5, 0x74, 0x15, 0x61, 0xdd, 0x09, 0x47, 0xa3, 0x70, 0xbc, 0xad, 0xfe, 0x6d, 0x4d, 0xa2,
-	0x30, 0x0e, 0x49, 0x49, 0x21, 0xfb, 0x97, 0x02, 0xd4, 0x06, 0x11, 0xf3, 0xc7, 0x3d, 0x16, 0xb1,
-	0x91, 0x20, 0x77, 0xa0, 0x18, 0xb0, 0x13, 0x1e, 0x58, 0x46, 0xd3, 0xd8, 0xa8, 0x52, 0x05, 0xc8,
-	0x7d, 0xa8, 0xca, 0xc5, 0x21, 0x1b, 0x71, 0xab, 0x20, 0x77, 0x32, 0x01, 0x79, 0x08, 0xe5, 0x88,
-	0x7b, 0x07, 0xa1, 0xcb, 0xad, 0xe5, 0xa6, 0xb1, 0x51, 0x6f, 0xad, 0x6d, 0x69, 0x5f, 0x54, 0x89,
-	0x69, 0xb2, 0x4f, 0x1a, 0x50, 0x89, 0xb8, 0x27, 0x7d, 0x59, 0x2b, 0x4d, 0x63, 0xc3, 0xa0, 0x29,
-	0x46, 0xd7, 0x2c, 0x98, 0x0c, 0x99, 0x55, 0x94, 0x1b, 0x0a, 0xa0, 0x6b, 0x36, 0x9a, 0x04, 0x7e,
-	0x3c, 0x75, 0xb9, 0x55, 0x92, 0x3b, 0x99, 0x00, 0xcf, 0x63, 0x8e, 0x33, 0x8d, 0x98, 0x73, 0x66,
-	0x95, 0x9b, 0xc6, 0xc6, 0x32, 0x4d, 0x31, 0x5a, 0xfa, 0x62, 0xc0, 0xf0, 0xf4, 0xd8, 0xaa, 0x34,
-	0x8d, 0x8d, 0x0a, 0xcd, 0x04, 0xe4, 0x2e, 0x94, 0x7c, 0x57, 0xe6, 0x53, 0x95, 0xf9, 0x68, 0x84,
-	0x56, 0x27, 0x2c, 0x76, 0x86, 0x7d, 0xff, 0x07, 0x6e, 0x81, 0x3c, 0x32, 0x13, 0xd8, 0xbf, 0x2f,
-	0x6b, 0xba, 0x30, 0x9b, 0x40, 0x90, 0xa7, 0x50, 0x8a, 0x87, 0x3c, 0x66, 0xc2, 0x32, 0x9a, 0xcb,
-	0x1b, 0xb5, 0xd6, 0x7f, 0x92, 0xcc, 0x73, 0x4a, 0x5b, 0x03, 0xa9, 0xd1, 0x19, 0xc7, 0xd1, 0x19,
-	0xd5, 0xea, 0xe4, 0x1b, 0x28, 0x7e, 0x3e, 0x61, 0x91, 0xb0, 0x0a, 0xd2, 0xee, 0xc1, 0x65, 0x76,
-	0x1f, 0x50, 0x41, 0x99, 0x29, 0x65, 0x74, 0x27, 0x7c, 0x6f, 0xc4, 0x84, 0xb5, 0xbc, 0xd8, 0x5d,
-	0x5f, 0x6a, 0x68, 0x77, 0x4a, 0x3d, 0x2b, 0xeb, 0xca, 0xb9, 0xb2, 0x66, 0x0c, 0x15, 0x17, 0x33,
-	0x54, 0x9a, 0x63, 0x88, 0xc0, 0xca, 0x84, 0xc5, 0x43, 0xc9, 0x77, 0x95, 0xca, 0x75, 0xe3, 0x39,
-	0xd4, 0x72, 0x59, 0x12, 0x13, 0x96, 0x3f, 0xf2, 0x33, 0x7d, 0x87, 0x70, 0x89, 0x01, 0xcc, 0x58,
-	0x30, 0x55, 0xb7, 0xc7, 0xa0, 0x0a, 0xbc, 0x28, 0x3c, 0x33, 0x1a, 0xcf, 0x00, 0xb2, 0x44, 0x6f,
-	0x64, 0xf9, 0x1c, 0x6a, 0xb9, 0x5c, 0x6f, 0x62, 0x6a, 0xff, 0x59, 0x00, 0x18, 0x30, 0xf1, 0x51,
-	0xdf, 0xfa, 0xff, 0xc1, 0x0a, 0x0b, 0xbc, 0x50, 0xda, 0xd6, 0x5b, 0xb7, 0x13, 0x56, 0x77, 0x02,
-	0x2f, 0x8c, 0xfc, 0x78, 0x38, 0xa2, 0x72, 0x9b, 0xfc, 0x1f, 0x2a, 0x31, 0x13, 0x1f, 0x07, 0x67,
-	0x13, 0x75, 0x64, 0xbd, 0x65, 0xa6, 0x05, 0xd0, 0x72, 0x9a, 0x6a, 0x90, 0x27, 0x50, 0x8b, 0xb3,
-	0x2f, 0x4b, 0x7e, 0x1a, 0xb5, 0xd6, 0xfa, 0x5c, 0xc5, 0xd4, 0x16, 0xcd, 0xeb, 0x91, 0x26, 0xd4,
-	0x46, 0x58, 0x48, 0x3c, 0x71, 0x7f, 0x57, 0x17, 0x2c, 0x2f, 0xc2, 0x83, 0x25, 0xd4, 0x07, 0x17,
-	0x2f, 0x39, 0x58, 0x5d, 0x05, 0x9a, 0xd7, 0x23, 0xcf, 0x00, 0xf8, 0x8c, 0x25, 0x56, 0x25, 0x69,
-	0x65, 0x25, 0x56, 0x1d, 0xe4, 0x86, 0xc5, 0x7e, 0x98, 0xc4, 0x94, 0xd3, 0x25, 0xaf, 0xa0, 0x16,
-	0xf8, 0x99, 0x69, 0x59, 0x9a, 0xde, 0x4f, 0x4c, 0xbb, 0xfe, 0x8c, 0x5f, 0x30, 0xcf, 0x1b, 0xd8,
-	0xbf, 0x1a, 0x60, 0x9e, 0xd7, 0xc0, 0xeb, 0xc5, 0xc7, 0xec, 0x24, 0xe0, 0x92, 0xf5, 0x0a, 0xd5,
-	0x88, 0xb4, 0xa0, 0x82, 0xae, 0xe9, 0x34, 0x48, 0x48, 0xbe, 0x7b, 0x31, 0x48, 0xdc, 0xa5, 0xa9,
-	0x1e, 0x32, 0x12, 0xb1, 0xb1, 0x1b, 0x8e, 0xfa, 0xf8, 0x30, 0x9c, 0xa7, 0x9a, 0x66, 0x5b, 0x34,
-	0xaf, 0x47, 0x9a, 0x50, 0x70, 0x66, 0x92, 0xe1, 0x5a, 0x56, 0xc9, 0x76, 0x14, 0x0a, 0xf1, 0x9e,
-	0x05, 0xb4, 0xe0, 0xcc, 0x6c, 0x0e, 0x77, 0x2e, 0x4b, 0x6f, 0x61, 0xf0, 0xe7, 0x02, 0x29, 0x5c,
-	0x2f, 0x10, 0xfb, 0x11, 0xd4, 0x72, 0x7b, 0xf8, 0x5d, 0x4e, 0x78, 0xe4, 0xf0, 0x71, 0xdc, 0x3d,
-	0x92, 0x0e, 0x8a, 0x34, 0x13, 0xd8, 0x9f, 0xa1, 0x92, 0xc4, 0x88, 0x37, 0xfc, 0x34, 0x0c, 0x5c,
-	0xa1, 0xb5, 0x14, 0x20, 0x16, 0x94, 0xc5, 0x70, 0x7a, 0x7a, 0xaa, 0x19, 0xac, 0xd0, 0x04, 0xaa,
-	0xf7, 0x77, 0xc2, 0x59, 0xcc, 0x5d, 0xc9, 0x52, 0x85, 0xa6, 0x18, 0x2f, 0x9e, 0x5a, 0x0f, 0xfc,
-	0x11, 0x17, 0x92, 0x96, 0x22, 0xcd, 0x8b, 0xec, 0xbf, 0x0c, 0xb8, 0x9b, 0x51, 0x71, 0xc0, 0xe3,
-	0xc8, 0x77, 0xfa, 0x4e, 0x18, 0x71, 0x41, 0x3c, 0xb8, 0x77, 0xe2, 0x8f, 0x59, 0x74, 0xd6, 0x0e,
-	0x98, 0x10, 0x6d, 0x26, 0x78, 0x7e, 0x5b, 0x86, 0x57, 0x6b, 0xfd, 0x37, 0x21, 0xe2, 0xf5, 0x62,
-	0xd5, 0xb7, 0x4b, 0xf4, 0xaa, 0x93, 0x88, 0x0b, 0x0d, 0xca, 0xbd, 0x88, 0x0b, 0xe1, 0x87, 0xe3,
-	0x0b, 0x7e, 0x14, 0xe1, 0x76, 0xae, 0xff, 0x2c, 0xd0, 0x7c, 0xbb, 0x44, 0xaf, 0x38, 0xe7, 0x75,
-	0x15, 0xca, 0x13, 0x76, 0x16, 0x84, 0xcc, 0xb5, 0x7f, 0x2e, 0xc2, 0xbd, 0x2b, 0xe2, 0xc5, 0x47,
-	0xc1, 0x61, 0x82, 0xcb, 0x47, 0xc1, 0x98, 0x7f, 0x14, 0xda, 0x5a, 0x4e, 0x53, 0x0d, 0x24, 0x99,
-	0xcd, 0xbc, 0x9d, 0xa4, 0x67, 0xa9, 0x87, 0x29, 0x2f, 0x22, 0x36, 0xac, 0xb2, 0x99, 0xd7, 0x8b,
-	0xb8, 0xe3, 0x63, 0x68, 0xb2, 0x4c, 0x06, 0x9d, 0x93, 0xc9, 0xa6, 0x38, 0xf3, 0x28, 0x77, 0x58,
-	0x10, 0xe8, 0x3e, 0x9a, 0x09, 0xc8, 0x03, 0x00, 0x36, 0xf3, 0xf6, 0xbe, 0x96, 0x01, 0xea, 0x6e,
-	0x9a, 0x93, 0xe0, 0xe5, 0x45, 0x87, 0xef, 0xda, 0xba, 0x9f, 0x6a, 0x44, 0x8e, 0xa1, 0x3e, 0x92,
-	0x99, 0x89, 0x1e, 0x8f, 0xf6, 0xc2, 0xc0, 0xb5, 0xca, 0xb2, 0xcb, 0x3c, 0xbd, 0x46, 0xd9, 0xb6,
-	0x0e, 0xe6, 0x2c, 0x55, 0xf7, 0x39, 0x77, 0x5c, 0xe3, 0x5f, 0x50, 0xec, 0x85, 0xfe, 0x38, 0x26,
-	0xab, 0x60, 0x4c, 0x64, 0xc7, 0x34, 0xa8, 0x31, 0x69, 0xfc, 0x61, 0x40, 0x7d, 0xde, 0x7c, 0xae,
-	0xaf, 0x1b, 0x6a, 0x4e, 0xc8, 0xf7, 0xf5, 0x49, 0xca, 0x8e, 0x22, 0x30, 0x13, 0x60, 0x72, 0x91,
-	0xe2, 0x45, 0x11, 0xa7, 0x11, 0x7e, 0x13, 0x09, 0x23, 0x8a, 0xb0, 0x04, 0x62, 0xdf, 0x40, 0x2e,
-	0x14, 0x4f, 0xb8, 0x24, 0x2f, 0x61, 0x99, 0x1e, 0x21, 0x3b, 0x98, 0xfd, 0xc3, 0xeb, 0x64, 0x2f,
-	0xd3, 0xa2, 0x68, 0xd5, 0x98, 0xc2, 0xfa, 0x25, 0x5c, 0xe4, 0xbb, 0x53, 0x51, 0x75, 0xa7, 0xb7,
-	0xf9, 0xee, 0x54, 0x6b, 0xb5, 0x6e, 0xce, 0x72, 0xbe, 0xa3, 0xfd, 0x58, 0xb8, 0xea, 0xc3, 0xb8,
-	0xe1, 0x2d, 0x6d, 0x43, 0x91, 0x1e, 0xf4, 0x3b, 0xc9, 0x74, 0xf2, 0xd5, 0x97, 0xbf, 0xa7, 0x2d,
-	0xa9, 0xaf, 0x87, 0x15, 0xb9, 0xc6, 0x1a, 0x8e, 0x38, 0x1b, 0x23, 0xd0, 0xb5, 0x48, 0x31, 0x5e,
-	0x51, 0x11, 0xbb, 0xbb, 0x7c, 0x26, 0x77, 0x55, 0x41, 0x72, 0x12, 0x1c, 0x0a, 0xb2, 0x03, 0x2f,
-	0xe1, 0x6e, 0x71, 0x67, 0xff, 0xa9, 0x00, 0x6b, 0xb2, 0x05, 0x62, 0xb3, 0xa4, 0x5c, 0x4c, 0x03,
-	0x39, 0xc9, 0xc4, 0xaa, 0x9b, 0xaa, 0xe1, 0x40, 0x23, 0xf9, 0x4e, 0x4e, 0x1d, 0x87, 0x0b, 0x91,
-	0xbe, 0x93, 0x0a, 0xe2, 0xf9, 0xb2, 0x75, 0xca, 0xc0, 0x57, 0xa9, 0x02, 0xf2, 0xd5, 0x8f, 0xa2,
-	0x03, 0xe1, 0xe9, 0xae, 0xac, 0x11, 0xf9, 0x16, 0x4c, 0x6c, 0x45, 0x73, 0x2f, 0x91, 0xea, 0xaf,
-	0x0f, 0x2e, 0xb6, 0xae, 0xbc, 0x16, 0xbd, 0x60, 0x47, 0x5e, 0x42, 0x45, 0x4e, 0x03, 0x7d, 0x8e,
-	0x23, 0xd9, 0xc5, 0x21, 0x2f, 0x4b, 0x6b, 0x6b, 0xcf, 0x0f, 0x38, 0x0d, 0x3f, 0xd1, 0xd4, 0xa0,
-	0x71, 0x0f, 0xca, 0x5a, 0x88, 0x9c, 0x45, 0xe1, 0x27, 0xf9, 0x91, 0x55, 0x29, 0x2e, 0xed, 0x33,
-	0xb8, 0xdd, 0x8b, 0xb8, 0xeb, 0x3b, 0xf1, 0x3f, 0xa2, 0xa6, 0x01, 0x95, 0x70, 0x1a, 0x3b, 0x21,
-	0xf6, 0x08, 0xc5, 0x4e, 0x8a, 0x17, 0x11, 0x64, 0xff, 0x66, 0x80, 0xd9, 0x8f, 0x59, 0xa4, 0x3d,
-	0x7f, 0x3f, 0xe5, 0x22, 0xef, 0xba, 0x30, 0xe7, 0x9a, 0xc0, 0xca, 0xa9, 0x1f, 0x70, 0x7d, 0xb8,
-	0x5c, 0x63, 0x3d, 0x86, 0xa1, 0x88, 0xb1, 0x2b, 0x61, 0x3e, 0x0a, 0x90, 0x4d, 0x28, 0x4d, 0xf2,
-	0x33, 0x10, 0xc9, 0x4f, 0x63, 0x7a, 0x10, 0xd1, 0x1a, 0xe4, 0x15, 0xd4, 0x27, 0xcc, 0x75, 0x03,
-	0xbe, 0xd7, 0x9d, 0x9b, 0x80, 0xd2, 0xe1, 0xa2, 0x37, 0xb7, 0x4b, 0xcf, 0x69, 0xdb, 0x2f, 0xa0,
-	0x3e, 0xaf, 0x81, 0x71, 0x46, 0xa1, 0x9e, 0x00, 0x8a, 0x54, 0xae, 0x31, 0xce, 0x71, 0xe8, 0x72,
-	0xf5, 0xe1, 0x54, 0xa9, 0x02, 0xf6, 0x3b, 0x58, 0xeb, 0xc7, 0xe1, 0xe4, 0x3a, 0xc9, 0x67, 0x29,
-	0xad, 0x7c, 0x29, 0xa5, 0xcd, 0x3e, 0x54, 0xd3, 0x09, 0x95, 0x58, 0x70, 0xa7, 0xbb, 0x7f, 0xd8,
-	0xd9, 0xa1, 0xc7, 0xb4, 0xf3, 0x86, 0x76, 0xfa, 0xfd, 0xfd, 0xa3, 0xc3, 0xe3, 0xf7, 0x5d, 0x73,
-	0x89, 0xfc, 0x1b, 0xd6, 0xbb, 0x47, 0x6f, 0xf6, 0xdb, 0xe7, 0x36, 0x0c, 0xb2, 0x0e, 0x6b, 0xbb,
-	0x87, 0x87, 0xc7, 0xbd, 0x9d, 0xdd, 0xdd, 0x6e, 0x67, 0xaf, 0x8b, 0xc2, 0xc2, 0xa6, 0x0d, 0x95,
-	0x64, 0x96, 0x25, 0x55, 0x28, 0x76, 0x3b, 0x3b, 0xf4, 0xd0, 0x5c, 0x22, 0x35, 0x28, 0xf7, 0x68,
-	0x67, 0x77, 0xbf, 0x3d, 0x30, 0x8d, 0xcd, 0x27, 0x50, 0xd6, 0xbf, 0xec, 0xc8, 0x2a, 0x54, 0x28,
-	0xf7, 0x8e, 0x0f, 0xc3, 0x31, 0x37, 0x97, 0xc8, 0x2d, 0xa8, 0x22, 0xea, 0x32, 0x21, 0x42, 0xd3,
-	0x48, 0x20, 0xf5, 0x5d, 0x8f, 0x9b, 0x85, 0xcd, 0x57, 0x50, 0x9f, 0x9f, 0xe0, 0xc8, 0x6d, 0xb8,
-	0xd5, 0x89, 0x72, 0x93, 0x8f, 0xb9, 0x44, 0xea, 0x00, 0x9d, 0x28, 0x99, 0x6f, 0x4c, 0x03, 0x63,
-	0xe8, 0x44, 0xdd, 0xa3, 0x23, 0xb3, 0xb0, 0xf9, 0x08, 0x2a, 0xc9, 0x5b, 0x85, 0x6a, 0xd9, 0x63,
-	0x64, 0x2e, 0x91, 0x35, 0xa8, 0xe5, 0xde, 0x4d, 0xd3, 0x78, 0xfd, 0xe4, 0xbb, 0xc7, 0x9e, 0x1f,
-	0x0f, 0xa7, 0x27, 0x48, 0xe0, 0xb6, 0x2a, 0x9d, 0xfa, 0xab, 0xc1, 0xee, 0xe0, 0xc3, 0xb6, 0xcb,
-	0xfc, 0x6d, 0xf9, 0x7b, 0x58, 0xe8, 0x5f, 0xc7, 0x27, 0x25, 0x09, 0x1f, 0xff, 0x1d, 0x00, 0x00,
-	0xff, 0xff, 0x70, 0x53, 0x63, 0x30, 0x35, 0x0f, 0x00, 0x00,
+	// 1660 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x58, 0x5f, 0x6f, 0x1b, 0xb9,
+	0x11, 0xf7, 0x4a, 0x96, 0x25, 0x8d, 0x1c, 0x79, 0x43, 0xa7, 0xe9, 0x56, 0x39, 0xa4, 0xea, 0x16,
+	0x05, 0x7c, 0xbe, 0xd6, 0x41, 0x75, 0x0d, 0x2e, 0x77, 0x87, 0x06, 0x70, 0x24, 0xf9, 0xec, 0x42,
+	0x96, 0x05, 0x4a, 0x39, 0x1c, 0xfa, 0x62, 0xd0, 0xbb, 0xb4, 0xb4, 0xc8, 0x4a, 0xbb, 0x25, 0x57,
+	0xba, 0xb8, 0xef, 0xfd, 0x0c, 0x05, 0x0a, 0xf4, 0xa1, 0x40, 0xbf, 0x43, 0x5f, 0xfb, 0xde, 0xcf,
+	0xd1, 0xa7, 0xbe, 0xf5, 0x13, 0x14, 0x43, 0x72, 0xff, 0xc8, 0xb6, 0x72, 0x31, 0xfa, 0x62, 0xf3,
+	0x37, 0x9c, 0x19, 0x0e, 0x7f, 0x43, 0x72, 0x66, 0x05, 0xfb, 0x5e, 0x34, 0x9f, 0x47, 0x8b, 0x17,
+	0xfa, 0xdf, 0x51, 0x2c, 0xa2, 0x24, 0x22, 0x3b, 0x1a, 0xb9, 0x7f, 0x2b, 0x43, 0x63, 0x22, 0x58,
+	0xb0, 0x18, 0x31, 0xc1, 0xe6, 0x92, 0x3c, 0x81, 0x4a, 0xc8, 0xae, 0x78, 0xe8, 0x58, 0x6d, 0xeb,
+	0xa0, 0x4e, 0x35, 0x20, 0x9f, 0x40, 0x5d, 0x0d, 0x86, 0x6c, 0xce, 0x9d, 0x92, 0x9a, 0xc9, 0x05,
+	0xe4, 0x53, 0xa8, 0x0a, 0x3e, 0x3d, 0x8f, 0x7c, 0xee, 0x94, 0xdb, 0xd6, 0x41, 0xb3, 0xb3, 0x77,
+	0x64, 0xd6, 0xa2, 0x5a, 0x4c, 0xd3, 0x79, 0xd2, 0x82, 0x9a, 0xe0, 0x53, 0xb5, 0x96, 0xb3, 0xdd,
+	0xb6, 0x0e, 0x2c, 0x9a, 0x61, 0x5c, 0x9a, 0x85, 0xf1, 0x8c, 0x39, 0x15, 0x35, 0xa1, 0x01, 0x2e,
+	0xcd, 0xe6, 0x71, 0x18, 0x24, 0x4b, 0x9f, 0x3b, 0x3b, 0x6a, 0x26, 0x17, 0xa0, 0x3f, 0xe6, 0x79,
+	0x4b, 0xc1, 0xbc, 0x1b, 0xa7, 0xda, 0xb6, 0x0e, 0xca, 0x34, 0xc3, 0x68, 0x19, 0xc8, 0x09, 0x43,
+	0xef, 0x89, 0x53, 0x6b, 0x5b, 0x07, 0x35, 0x9a, 0x0b, 0xc8, 0x53, 0xd8, 0x09, 0x7c, 0xb5, 0x9f,
+	0xba, 0xda, 0x8f, 0x41, 0x68, 0x75, 0xc5, 0x12, 0x6f, 0x36, 0x0e, 0xfe, 0xc8, 0x1d, 0x50, 0x2e,
+	0x73, 0x01, 0x71, 0xa0, 0x9a, 0x08, 0xce, 0x87, 0xcb, 0xb9, 0xd3, 0x50, 0x73, 0x29, 0xc4, 0x48,
+	0xe6, 0xec, 0x7d, 0x8f, 0xc7, 0xc9, 0xcc, 0xd9, 0xd5, 0x91, 0xa4, 0x98, 0xfc, 0x16, 0x6a, 0x7e,
+	0xac, 0x09, 0x76, 0x1e, 0xb5, 0xad, 0x83, 0x46, 0xe7, 0x67, 0x29, 0x43, 0xbd, 0xe0, 0xfa, 0x9a,
+	0x0b, 0xbe, 0x48, 0x02, 0x16, 0x8e, 0x44, 0xb0, 0x62, 0xde, 0x8d, 0x56, 0xa4, 0x99, 0x89, 0xfb,
+	0xef, 0x34, 0x47, 0x48, 0x61, 0x28, 0xc9, 0x17, 0xb0, 0x93, 0xcc, 0x78, 0xc2, 0xa4, 0x63, 0xb5,
+	0xcb, 0x07, 0x8d, 0xce, 0x4f, 0x53, 0x67, 0x05, 0xa5, 0xa3, 0x89, 0xd2, 0xe8, 0x2f, 0x12, 0x71,
+	0x43, 0x8d, 0x3a, 0xf9, 0x0d, 0x54, 0xde, 0x5f, 0x31, 0x21, 0x9d, 0x92, 0xb2, 0x7b, 0x7e, 0x9f,
+	0xdd, 0x77, 0xa8, 0xa0, 0xcd, 0xb4, 0x32, 0x2e, 0x27, 0x83, 0xe9, 0x9c, 0x49, 0xa7, 0xbc, 0x79,
+	0xb9, 0xb1, 0xd2, 0x30, 0xcb, 0x69, 0xf5, 0xfc, 0x2c, 0x6d, 0xdf, 0x3a, 0x4b, 0x79, 0x5a, 0x2a,
+	0x9b, 0xd3, 0xb2, 0xb3, 0x96, 0x16, 0x02, 0xdb, 0x31, 0x4b, 0x66, 0x2a, 0xc9, 0x75, 0xaa, 0xc6,
+	0xe4, 0x39, 0xc0, 0x55, 0x14, 0xc9, 0x64, 0x22, 0x38, 0x97, 0x2a, 0xc3, 0xbb, 0xb4, 0x20, 0x69,
+	0x7d, 0x09, 0x8d, 0x02, 0x0b, 0xc4, 0x86, 0xf2, 0x3b, 0x7e, 0x63, 0x0e, 0x36, 0x0e, 0x31, 0xc0,
+	0x15, 0x0b, 0x97, 0xfa, 0x48, 0x5b, 0x54, 0x83, 0xaf, 0x4a, 0xaf, 0xac, 0xd6, 0x2b, 0x80, 0x9c,
+	0x88, 0x07, 0x59, 0x7e, 0x09, 0x8d, 0x02, 0x17, 0x0f, 0x31, 0x75, 0xff, 0x53, 0x02, 0x98, 0x30,
+	0xf9, 0xce, 0x5c, 0xc5, 0x5f, 0xc0, 0x36, 0x0b, 0xa7, 0x91, 0xb2, 0x6d, 0x76, 0x1e, 0xa7, 0xac,
+	0x1f, 0x87, 0xd3, 0x48, 0x04, 0xc9, 0x6c, 0x4e, 0xd5, 0x34, 0xf9, 0x25, 0xd4, 0x12, 0x26, 0xdf,
+	0x4d, 0x6e, 0x62, 0xed, 0xb2, 0xd9, 0xb1, 0xb3, 0x04, 0x19, 0x39, 0xcd, 0x34, 0xc8, 0x4b, 0x68,
+	0x24, 0xf9, 0x75, 0x57, 0xf7, 0xb5, 0xd1, 0xd9, 0x5f, 0xcb, 0xa8, 0x9e, 0xa2, 0x45, 0x3d, 0xd2,
+	0x86, 0xc6, 0x1c, 0x13, 0x8d, 0x1e, 0xcf, 0x7a, 0x26, 0xa1, 0x45, 0x11, 0x3a, 0x56, 0xd0, 0x38,
+	0xae, 0xdc, 0xe3, 0x58, 0x1f, 0x15, 0x5a, 0xd4, 0x23, 0xaf, 0x00, 0xf8, 0x8a, 0xa5, 0x56, 0x3b,
+	0xca, 0xca, 0x49, 0xad, 0xfa, 0xc8, 0x0d, 0x4b, 0x82, 0x28, 0x8d, 0xa9, 0xa0, 0x4b, 0x5e, 0x43,
+	0x23, 0x0c, 0x72, 0xd3, 0xaa, 0x32, 0xfd, 0x24, 0x35, 0x1d, 0x04, 0x2b, 0x7e, 0xc7, 0xbc, 0x68,
+	0xe0, 0xfe, 0xc3, 0x02, 0xfb, 0xb6, 0x06, 0x1e, 0x3f, 0xbe, 0x60, 0x57, 0x21, 0x57, 0xac, 0xd7,
+	0xa8, 0x41, 0xa4, 0x03, 0x35, 0x5c, 0x9a, 0x2e, 0xc3, 0x94, 0xe4, 0xa7, 0x77, 0x83, 0xc4, 0x59,
+	0x9a, 0xe9, 0x21, 0x23, 0x82, 0x2d, 0xfc, 0x68, 0x3e, 0xc6, 0xd7, 0xea, 0x36, 0xd5, 0x34, 0x9f,
+	0xa2, 0x45, 0x3d, 0xd2, 0x86, 0x92, 0xb7, 0x52, 0x0c, 0x37, 0xf2, 0x4c, 0x76, 0x45, 0x24, 0xe5,
+	0xb7, 0x2c, 0xa4, 0x25, 0x6f, 0xe5, 0x72, 0x78, 0x72, 0xdf, 0xf6, 0x36, 0x06, 0x7f, 0x2b, 0x90,
+	0xd2, 0xc7, 0x05, 0xe2, 0x7e, 0x06, 0x8d, 0xc2, 0x1c, 0xde, 0xdb, 0x98, 0x0b, 0x8f, 0x2f, 0x92,
+	0xc1, 0x85, 0x5a, 0xa0, 0x42, 0x73, 0x81, 0xfb, 0x1e, 0x6a, 0x69, 0x8c, 0x78, 0xc2, 0xaf, 0xa3,
+	0xd0, 0x97, 0x46, 0x4b, 0x03, 0x7c, 0x3a, 0xe5, 0x6c, 0x79, 0x7d, 0x6d, 0x18, 0xac, 0xd1, 0x14,
+	0xea, 0xa2, 0x10, 0x73, 0x96, 0x70, 0x5f, 0xb1, 0x54, 0xa3, 0x19, 0xc6, 0x83, 0xa7, 0xc7, 0x93,
+	0x60, 0xce, 0xa5, 0xa2, 0xa5, 0x42, 0x8b, 0x22, 0xf7, 0xbf, 0x16, 0x3c, 0xcd, 0xa9, 0x38, 0xe7,
+	0x89, 0x08, 0xbc, 0xb1, 0x17, 0x09, 0x2e, 0xc9, 0x14, 0x9e, 0x5d, 0x05, 0x0b, 0x26, 0x6e, 0xba,
+	0x21, 0x93, 0xb2, 0xcb, 0x24, 0x2f, 0x4e, 0xab, 0xf0, 0x1a, 0x9d, 0x9f, 0xa7, 0x44, 0xbc, 0xd9,
+	0xac, 0x7a, 0xba, 0x45, 0x3f, 0xe4, 0x89, 0xf8, 0xd0, 0xa2, 0x7c, 0x2a, 0xb8, 0x94, 0x41, 0xb4,
+	0xb8, 0xb3, 0x8e, 0x26, 0xdc, 0x2d, 0x14, 0xc5, 0x0d, 0x9a, 0xa7, 0x5b, 0xf4, 0x03, 0x7e, 0xde,
+	0xd4, 0xa1, 0x1a, 0xb3, 0x9b, 0x30, 0x62, 0xbe, 0xfb, 0xf7, 0x0a, 0x3c, 0xfb, 0x40, 0xbc, 0xf8,
+	0x28, 0x78, 0x4c, 0x72, 0xf5, 0x28, 0x58, 0xeb, 0x8f, 0x42, 0xd7, 0xc8, 0x69, 0xa6, 0x81, 0x24,
+	0xb3, 0xd5, 0xf4, 0x38, 0x2d, 0xa4, 0xfa, 0x61, 0x2a, 0x8a, 0x88, 0x0b, 0xbb, 0x6c, 0x35, 0x1d,
+	0x09, 0xee, 0x05, 0x18, 0x9a, 0x4a, 0x93, 0x45, 0xd7, 0x64, 0xaa, 0x52, 0xaf, 0xa6, 0x94, 0x7b,
+	0x2c, 0x0c, 0x4d, 0x71, 0xcf, 0x05, 0xf8, 0x58, 0xb3, 0xd5, 0xf4, 0xe4, 0xd7, 0x2a, 0x40, 0x53,
+	0xe2, 0x0b, 0x12, 0x3c, 0xbc, 0xb8, 0xe0, 0xdb, 0xae, 0x29, 0xf2, 0x06, 0x91, 0x4b, 0x68, 0xce,
+	0xd5, 0xce, 0xe4, 0x88, 0x8b, 0x93, 0x28, 0xf4, 0x9d, 0xaa, 0xaa, 0x42, 0x5f, 0x7c, 0x44, 0xda,
+	0x8e, 0xce, 0xd7, 0x2c, 0x75, 0x75, 0xba, 0xe5, 0xae, 0xf5, 0x23, 0xa8, 0x8c, 0xa2, 0x60, 0x91,
+	0x90, 0x5d, 0xb0, 0x62, 0x55, 0x51, 0x2d, 0x6a, 0xc5, 0xad, 0x7f, 0x59, 0xd0, 0x5c, 0x37, 0x5f,
+	0x6b, 0x36, 0x2c, 0xdd, 0xbc, 0x14, 0x9b, 0x8d, 0x38, 0x63, 0x47, 0x13, 0x98, 0x0b, 0x70, 0x73,
+	0x42, 0xf3, 0xa2, 0x89, 0x33, 0x08, 0xef, 0x44, 0xca, 0x88, 0x26, 0x2c, 0x85, 0x58, 0x37, 0x90,
+	0x0b, 0xcd, 0x13, 0x0e, 0xc9, 0xd7, 0x50, 0xa6, 0x17, 0xc8, 0x0e, 0xee, 0xfe, 0xd3, 0x8f, 0xd9,
+	0xbd, 0xda, 0x16, 0x45, 0xab, 0xd6, 0x12, 0xf6, 0xef, 0xe1, 0xa2, 0x58, 0x9d, 0x2a, 0xba, 0x3a,
+	0x9d, 0x16, 0xab, 0x53, 0xa3, 0xd3, 0x79, 0x38, 0xcb, 0xc5, 0x8a, 0xf6, 0xa7, 0xd2, 0x87, 0x2e,
+	0xc6, 0x03, 0x4f, 0x69, 0x17, 0x2a, 0xf4, 0x7c, 0xdc, 0x4f, 0xbb, 0x97, 0x5f, 0xfd, 0xf0, 0x7d,
+	0x3a, 0x52, 0xfa, 0xa6, 0x99, 0x51, 0x63, 0xd5, 0xa6, 0x71, 0xb6, 0x40, 0x60, 0x72, 0x91, 0x61,
+	0x3c, 0xa2, 0x32, 0xf1, 0x7b, 0x7c, 0xa5, 0x66, 0x75, 0x42, 0x0a, 0x12, 0x6c, 0x0a, 0x72, 0x87,
+	0xf7, 0x70, 0xb7, 0xb9, 0xb2, 0xff, 0xb9, 0x04, 0x7b, 0xaa, 0x04, 0x62, 0xb1, 0xa4, 0x5c, 0x2e,
+	0x43, 0xd5, 0xe9, 0x24, 0xba, 0x9a, 0xea, 0xe6, 0xc0, 0x20, 0xf5, 0x4e, 0x2e, 0x3d, 0x8f, 0x4b,
+	0x99, 0xbd, 0x93, 0x1a, 0xa2, 0x7f, 0x55, 0x3a, 0x55, 0xe0, 0xbb, 0x54, 0x03, 0xf4, 0xc3, 0x85,
+	0x38, 0x97, 0x53, 0x53, 0x95, 0x0d, 0x22, 0xbf, 0x03, 0x1b, 0x4b, 0xd1, 0xda, 0x4b, 0xa4, 0xeb,
+	0xeb, 0xf3, 0xbb, 0xa5, 0xab, 0xa8, 0x45, 0xef, 0xd8, 0x91, 0xaf, 0xa1, 0xa6, 0xba, 0x81, 0x31,
+	0xc7, 0x96, 0xed, 0x6e, 0x13, 0x98, 0x6f, 0xeb, 0xe8, 0x24, 0x08, 0x39, 0x8d, 0xbe, 0xa7, 0x99,
+	0x41, 0xeb, 0x19, 0x54, 0x8d, 0x10, 0x39, 0x13, 0xd1, 0xf7, 0xea, 0x92, 0xd5, 0x29, 0x0e, 0xdd,
+	0x1b, 0x78, 0x3c, 0x12, 0xdc, 0x0f, 0xbc, 0xe4, 0xff, 0xa2, 0xa6, 0x05, 0xb5, 0x68, 0x99, 0x78,
+	0x11, 0xd6, 0x08, 0xcd, 0x4e, 0x86, 0x37, 0x11, 0xe4, 0xfe, 0xd3, 0x02, 0x7b, 0x9c, 0x30, 0x61,
+	0x56, 0xfe, 0xc3, 0x92, 0xcb, 0xe2, 0xd2, 0xa5, 0xb5, 0xa5, 0x09, 0x6c, 0x5f, 0x07, 0x21, 0x37,
+	0xce, 0xd5, 0x18, 0xf3, 0x31, 0x8b, 0x64, 0x82, 0x55, 0x09, 0xf7, 0xa3, 0x01, 0x39, 0x84, 0x9d,
+	0xb8, 0xd8, 0x03, 0x91, 0x62, 0x37, 0x66, 0x1a, 0x11, 0xa3, 0x41, 0x5e, 0x43, 0x33, 0x66, 0xbe,
+	0x1f, 0xf2, 0x93, 0xc1, 0x5a, 0x07, 0x94, 0x35, 0x17, 0xa3, 0xb5, 0x59, 0x7a, 0x4b, 0xdb, 0xfd,
+	0x0a, 0x9a, 0xeb, 0x1a, 0x18, 0xa7, 0x88, 0x4c, 0x07, 0x50, 0xa1, 0x6a, 0x8c, 0x71, 0x2e, 0x22,
+	0x9f, 0xeb, 0x8b, 0x53, 0xa7, 0x1a, 0xb8, 0x6f, 0x61, 0x6f, 0x9c, 0x44, 0xf1, 0xc7, 0x6c, 0x3e,
+	0xdf, 0xd2, 0xf6, 0x0f, 0x6d, 0xc9, 0xfd, 0xab, 0x05, 0x3f, 0xd9, 0xf8, 0x51, 0xb3, 0xb1, 0x45,
+	0x71, 0xa0, 0xca, 0x63, 0x19, 0x84, 0xd9, 0xe3, 0x99, 0x42, 0x0c, 0xde, 0xe7, 0x61, 0xc2, 0xcc,
+	0x6d, 0xd5, 0x00, 0xf3, 0xed, 0x85, 0x41, 0x3c, 0x8c, 0x44, 0xf6, 0x1d, 0x99, 0x62, 0x7c, 0x8a,
+	0xe7, 0xec, 0x3d, 0x8d, 0x96, 0x0b, 0x5f, 0xe7, 0xa0, 0x4c, 0x73, 0xc1, 0xe1, 0x5f, 0x2c, 0xa8,
+	0x67, 0x2d, 0x34, 0x71, 0xe0, 0xc9, 0xe0, 0x6c, 0xd8, 0x3f, 0xa6, 0x97, 0xb4, 0xff, 0x0d, 0xed,
+	0x8f, 0xc7, 0x67, 0x17, 0xc3, 0xcb, 0x6f, 0x07, 0xf6, 0x16, 0xf9, 0x31, 0xec, 0x0f, 0x2e, 0xbe,
+	0x39, 0xeb, 0xde, 0x9a, 0xb0, 0xc8, 0x3e, 0xec, 0xf5, 0x86, 0xc3, 0xcb, 0xd1, 0x71, 0xaf, 0x37,
+	0xe8, 0x9f, 0x0c, 0x50, 0x58, 0x22, 0x04, 0x9a, 0xe3, 0x7e, 0xf7, 0x2d, 0xed, 0xbf, 0xb9, 0xb8,
+	0x18, 0x4f, 0x50, 0x56, 0xbe, 0xdf, 0xf7, 0xe9, 0xc0, 0xde, 0xbe, 0xd7, 0xf7, 0xe9, 0xc0, 0xae,
+	0x1c, 0xba, 0x50, 0x4b, 0x7b, 0x76, 0x52, 0x87, 0xca, 0xa0, 0x7f, 0x4c, 0x87, 0xf6, 0x16, 0x69,
+	0x40, 0x75, 0x44, 0xfb, 0xbd, 0xb3, 0xee, 0xc4, 0xb6, 0x0e, 0x5f, 0x42, 0xd5, 0x7c, 0x56, 0x93,
+	0x5d, 0xa8, 0x51, 0x3e, 0xbd, 0x1c, 0x46, 0x0b, 0x6e, 0x6f, 0x91, 0x47, 0x50, 0x47, 0x34, 0x60,
+	0x52, 0x46, 0xb6, 0x95, 0x42, 0x1a, 0xf8, 0x53, 0x6e, 0x97, 0x0e, 0x5f, 0x43, 0x73, 0xbd, 0x53,
+	0x25, 0x8f, 0xe1, 0x51, 0x5f, 0x14, 0x3a, 0x3c, 0x7b, 0x8b, 0x34, 0x01, 0xfa, 0x22, 0xed, 0xe3,
+	0x6c, 0x0b, 0x63, 0xe8, 0x8b, 0xc1, 0xc5, 0x85, 0x5d, 0x3a, 0xfc, 0x0c, 0x6a, 0xe9, 0x9b, 0x8c,
+	0x6a, 0xf9, 0xa3, 0x6b, 0x6f, 0x91, 0x3d, 0x68, 0x14, 0xea, 0x83, 0x6d, 0xbd, 0x79, 0xf9, 0xfb,
+	0xcf, 0xa7, 0x41, 0x32, 0x5b, 0x5e, 0xe1, 0x41, 0x79, 0xa1, 0x8f, 0xa8, 0xfe, 0x6b, 0x40, 0x6f,
+	0xf2, 0xdd, 0x0b, 0x9f, 0x05, 0x2f, 0xd4, 0x8f, 0x11, 0xd2, 0xfc, 0x34, 0x71, 0xb5, 0xa3, 0xe0,
+	0xe7, 0xff, 0x1b, 0x00, 0x94, 0xe5, 0xcb, 0xe9, 0xb2, 0x10, 0x00, 0x00,
 }
@@ -0,0 +1,285 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: mpc/learners/fedavg_hl/fedavg_hl.proto
+
+package fedavg_hl
+
+import (
+	fmt "fmt"
+	common "github.com/PaddlePaddle/PaddleDTX/dai/protos/common"
+	proto "github.com/golang/protobuf/proto"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+//MessageType defines the type of message with which communicate with nodes in cluster,
+// and in some way it indicates the phase of learning
+//Some types are for local message which is not passed between nodes
+type MessageType int32
+
+const (
+	MessageType_MsgTrainHup         MessageType = 0
+	MessageType_MsgKeyExchange      MessageType = 1
+	MessageType_MsgTrainStats       MessageType = 2
+	MessageType_MsgMaskedStats      MessageType = 3
+	MessageType_MsgTrainStandardize MessageType = 4
+	MessageType_MsgTrainLoop        MessageType = 5
+	MessageType_MsgMaskedUpdate     MessageType = 6
+	MessageType_MsgTrainAggregate   MessageType = 7
+	MessageType_MsgTrainModels      MessageType = 8
+	MessageType_MsgCheckPauseRound  MessageType = 9
+	MessageType_MsgTrainSet         MessageType = 10
+	MessageType_MsgContinueLoop     MessageType = 11
+	MessageType_MsgPredictHup       MessageType = 51
+)
+
+var MessageType_name = map[int32]string{
+	0:  "MsgTrainHup",
+	1:  "MsgKeyExchange",
+	2:  "MsgTrainStats",
+	3:  "MsgMaskedStats",
+	4:  "MsgTrainStandardize",
+	5:  "MsgTrainLoop",
+	6:  "MsgMaskedUpdate",
+	7:  "MsgTrainAggregate",
+	8:  "MsgTrainModels",
+	9:  "MsgCheckPauseRound",
+	10: "MsgTrainSet",
+	11: "MsgContinueLoop",
+	51: "MsgPredictHup",
+}
+
+var MessageType_value = map[string]int32{
+	"MsgTrainHup":         0,
+	"MsgKeyExchange":      1,
+	"MsgTrainStats":       2,
+	"MsgMaskedStats":      3,
+	"MsgTrainStandardize": 4,
+	"MsgTrainLoop":        5,
+	"MsgMaskedUpdate":     6,
+	"MsgTrainAggregate":   7,
+	"MsgTrainModels":      8,
+	"MsgCheckPauseRound":  9,
+	"MsgTrainSet":         10,
+	"MsgContinueLoop":     11,
+	"MsgPredictHup":       51,
+}
+
+func (x MessageType) String() string {
+	return proto.EnumName(MessageType_name, int32(x))
+}
+
+func (MessageType) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_aa05b4b4f3307463, []int{0}
+}
+
+type Message struct {
+	Type                 MessageType                       `protobuf:"varint,1,opt,name=type,proto3,enum=fedavg_hl.MessageType" json:"type,omitempty"`
+	To                   string                            `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	From                 string                            `protobuf:"bytes,3,opt,name=from,proto3" json:"from,omitempty"`
+	LoopRound            uint64                            `protobuf:"varint,4,opt,name=loopRound,proto3" json:"loopRound,omitempty"`
+	MaskPubkey           []byte                            `protobuf:"bytes,5,opt,name=maskPubkey,proto3" json:"maskPubkey,omitempty"`
+	MaskedPart           []byte                            `protobuf:"bytes,6,opt,name=maskedPart,proto3" json:"maskedPart,omitempty"`
+	TrainSet             []*common.TrainTaskResult_FileRow `protobuf:"bytes,7,rep,name=trainSet,proto3" json:"trainSet,omitempty"`
+	PauseRound           uint64                            `protobuf:"varint,8,opt,name=pauseRound,proto3" json:"pauseRound,omitempty"`
+	TriggerRound         uint64                            `protobuf:"varint,9,opt,name=triggerRound,proto3" json:"triggerRound,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                          `json:"-"`
+	XXX_unrecognized     []byte                            `json:"-"`
+	XXX_sizecache        int32                             `json:"-"`
+}
+
+func (m *Message) Reset()         { *m = Message{} }
+func (m *Message) String() string { return proto.CompactTextString(m) }
+func (*Message) ProtoMessage()    {}
+func (*Message) Descriptor() ([]byte, []int) {
+	return fileDescriptor_aa05b4b4f3307463, []int{0}
+}
+
+func (m *Message) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Message.Unmarshal(m, b)
+}
+func (m *Message) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Message.Marshal(b, m, deterministic)
+}
+func (m *Message) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Message.Merge(m, src)
+}
+func (m *Message) XXX_Size() int {
+	return xxx_messageInfo_Message.Size(m)
+}
+func (m *Message) XXX_DiscardUnknown() {
+	xxx_messageInfo_Message.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Message proto.InternalMessageInfo
+
+func (m *Message) GetType() MessageType {
+	if m != nil {
+		return m.Type
+	}
+	return MessageType_MsgTrainHup
+}
+
+func (m *Message) GetTo() string {
+	if m != nil {
+		return m.To
+	}
+	return ""
+}
+
+func (m *Message) GetFrom() string {
+	if m != nil {
+		return m.From
+	}
+	return ""
+}
+
+func (m *Message) GetLoopRound() uint64 {
+	if m != nil {
+		return m.LoopRound
+	}
+	return 0
+}
+
+func (m *Message) GetMaskPubkey() []byte {
+	if m != nil {
+		return m.MaskPubkey
+	}
+	return nil
+}
+
+func (m *Message) GetMaskedPart() []byte {
+	if m != nil {
+		return m.MaskedPart
+	}
+	return nil
+}
+
+func (m *Message) GetTrainSet() []*common.TrainTaskResult_FileRow {
+	if m != nil {
+		return m.TrainSet
+	}
+	return nil
+}
+
+func (m *Message) GetPauseRound() uint64 {
+	if m != nil {
+		return m.PauseRound
+	}
+	return 0
+}
+
+func (m *Message) GetTriggerRound() uint64 {
+	if m != nil {
+		return m.TriggerRound
+	}
+	return 0
+}
+
+type PredictMessage struct {
+	Type                 MessageType `protobuf:"varint,1,opt,name=type,proto3,enum=fedavg_hl.MessageType" json:"type,omitempty"`
+	To                   string      `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	From                 string      `protobuf:"bytes,3,opt,name=from,proto3" json:"from,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *PredictMessage) Reset()         { *m = PredictMessage{} }
+func (m *PredictMessage) String() string { return proto.CompactTextString(m) }
+func (*PredictMessage) ProtoMessage()    {}
+func (*PredictMessage) Descriptor() ([]byte, []int) {
+	return fileDescriptor_aa05b4b4f3307463, []int{1}
+}
+
+func (m *PredictMessage) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PredictMessage.Unmarshal(m, b)
+}
+func (m *PredictMessage) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PredictMessage.Marshal(b, m, deterministic)
+}
+func (m *PredictMessage) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PredictMessage.Merge(m, src)
+}
+func (m *PredictMessage) XXX_Size() int {
+	return xxx_messageInfo_PredictMessage.Size(m)
+}
+func (m *PredictMessage) XXX_DiscardUnknown() {
+	xxx_messageInfo_PredictMessage.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PredictMessage proto.InternalMessageInfo
+
+func (m *PredictMessage) GetType() MessageType {
+	if m != nil {
+		return m.Type
+	}
+	return MessageType_MsgTrainHup
+}
+
+func (m *PredictMessage) GetTo() string {
+	if m != nil {
+		return m.To
+	}
+	return ""
+}
+
+func (m *PredictMessage) GetFrom() string {
+	if m != nil {
+		return m.From
+	}
+	return ""
+}
+
+func init() {
+	proto.RegisterEnum("fedavg_hl.MessageType", MessageType_name, MessageType_value)
+	proto.RegisterType((*Message)(nil), "fedavg_hl.Message")
+	proto.RegisterType((*PredictMessage)(nil), "fedavg_hl.PredictMessage")
+}
+
+func init() {
+	proto.RegisterFile("mpc/learners/fedavg_hl/fedavg_hl.proto", fileDescriptor_aa05b4b4f3307463)
+}
+
+var fileDescriptor_aa05b4b4f3307463 = []byte{
+	// 468 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x93, 0xcf, 0x8e, 0xd3, 0x30,
+	0x10, 0xc6, 0x49, 0xb6, 0xdb, 0x3f, 0xd3, 0xd2, 0xf5, 0xba, 0x62, 0x89, 0x10, 0x82, 0xaa, 0x07,
+	0x54, 0xed, 0xa1, 0x91, 0x76, 0x8f, 0x9c, 0xa0, 0x2c, 0x42, 0x82, 0x48, 0x51, 0x5a, 0x24, 0xc4,
+	0x05, 0xdc, 0x78, 0xd6, 0x8d, 0x9a, 0xc4, 0x91, 0xed, 0x00, 0xe5, 0x3d, 0x78, 0x17, 0x1e, 0x0f,
+	0xc5, 0x49, 0xdb, 0x20, 0x71, 0xe5, 0x92, 0x38, 0xbf, 0xef, 0x1b, 0x8f, 0xbf, 0x49, 0x02, 0x2f,
+	0xb2, 0x22, 0xf6, 0x53, 0x64, 0x2a, 0x47, 0xa5, 0xfd, 0x7b, 0xe4, 0xec, 0x9b, 0xf8, 0xb2, 0x4d,
+	0x4f, 0xab, 0x45, 0xa1, 0xa4, 0x91, 0x74, 0x70, 0x04, 0x4f, 0x26, 0xb1, 0xcc, 0x32, 0x99, 0xfb,
+	0xf5, 0xad, 0xd6, 0x67, 0xbf, 0x5d, 0xe8, 0x05, 0xa8, 0x35, 0x13, 0x48, 0xaf, 0xa1, 0x63, 0xf6,
+	0x05, 0x7a, 0xce, 0xd4, 0x99, 0x8f, 0x6f, 0xae, 0x16, 0xa7, 0xbd, 0x1a, 0xc7, 0x7a, 0x5f, 0x60,
+	0x64, 0x3d, 0x74, 0x0c, 0xae, 0x91, 0x9e, 0x3b, 0x75, 0xe6, 0x83, 0xc8, 0x35, 0x92, 0x52, 0xe8,
+	0xdc, 0x2b, 0x99, 0x79, 0x67, 0x96, 0xd8, 0x35, 0x7d, 0x0a, 0x83, 0x54, 0xca, 0x22, 0x92, 0x65,
+	0xce, 0xbd, 0xce, 0xd4, 0x99, 0x77, 0xa2, 0x13, 0xa0, 0xcf, 0x00, 0x32, 0xa6, 0x77, 0x61, 0xb9,
+	0xd9, 0xe1, 0xde, 0x3b, 0x9f, 0x3a, 0xf3, 0x51, 0xd4, 0x22, 0x07, 0x1d, 0x79, 0xc8, 0x94, 0xf1,
+	0xba, 0x27, 0xbd, 0x26, 0xf4, 0x25, 0xf4, 0x8d, 0x62, 0x49, 0xbe, 0x42, 0xe3, 0xf5, 0xa6, 0x67,
+	0xf3, 0xe1, 0xcd, 0xf3, 0x45, 0x13, 0x6d, 0x5d, 0xf1, 0x35, 0xd3, 0xbb, 0x08, 0x75, 0x99, 0x9a,
+	0xc5, 0xdb, 0x24, 0xc5, 0x48, 0x7e, 0x8f, 0x8e, 0x05, 0xd5, 0xe6, 0x05, 0x2b, 0x35, 0xd6, 0x67,
+	0xeb, 0xdb, 0xb3, 0xb5, 0x08, 0x9d, 0xc1, 0xc8, 0xa8, 0x44, 0x08, 0x54, 0xb5, 0x63, 0x60, 0x1d,
+	0x7f, 0xb1, 0xd9, 0x57, 0x18, 0x87, 0x0a, 0x79, 0x12, 0x9b, 0xff, 0x34, 0xc0, 0xeb, 0x5f, 0x2e,
+	0x0c, 0x5b, 0x95, 0xf4, 0x02, 0x86, 0x81, 0x16, 0x36, 0xdd, 0xbb, 0xb2, 0x20, 0x0f, 0x28, 0x85,
+	0x71, 0xa0, 0xc5, 0x7b, 0xdc, 0xdf, 0xfd, 0x88, 0xb7, 0x2c, 0x17, 0x48, 0x1c, 0x7a, 0x09, 0x0f,
+	0x0f, 0xa6, 0x95, 0x61, 0x46, 0x13, 0xb7, 0xb1, 0x05, 0x76, 0x76, 0x35, 0x3b, 0xa3, 0x8f, 0x61,
+	0xd2, 0xb2, 0xe5, 0x9c, 0x29, 0x9e, 0xfc, 0x44, 0xd2, 0xa1, 0x04, 0x46, 0x07, 0xe1, 0x83, 0x94,
+	0x05, 0x39, 0xa7, 0x13, 0xb8, 0x38, 0x96, 0x7f, 0x2c, 0x38, 0x33, 0x48, 0xba, 0xf4, 0x11, 0x5c,
+	0x1e, 0x6c, 0xaf, 0x84, 0x50, 0x28, 0x2a, 0xdc, 0x6b, 0x5a, 0x59, 0x1c, 0x48, 0x8e, 0xa9, 0x26,
+	0x7d, 0x7a, 0x05, 0x34, 0xd0, 0x62, 0xb9, 0xc5, 0x78, 0x17, 0x1e, 0x47, 0x4c, 0x06, 0xed, 0x38,
+	0x2b, 0x34, 0x04, 0x9a, 0x46, 0x4b, 0x99, 0x9b, 0x24, 0x2f, 0xd1, 0x76, 0x1f, 0x36, 0x79, 0x9a,
+	0x49, 0x57, 0xb1, 0x6f, 0x5f, 0xdf, 0x7d, 0x5e, 0x8a, 0xc4, 0x6c, 0xcb, 0x4d, 0xf5, 0xc2, 0xfd,
+	0x90, 0x71, 0x9e, 0x62, 0x7d, 0x6d, 0x1e, 0xde, 0xac, 0x3f, 0xf9, 0x9c, 0x25, 0xbe, 0xfd, 0xc6,
+	0xb5, 0xff, 0xef, 0x7f, 0x65, 0xd3, 0xb5, 0xf2, 0xed, 0x9f, 0x01, 0x00, 0xef, 0xaf, 0xcf, 0x90,
+	0x4c, 0x03, 0x00, 0x00,
+}
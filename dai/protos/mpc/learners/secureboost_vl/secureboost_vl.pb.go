@@ -0,0 +1,365 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: mpc/learners/secureboost_vl/secureboost_vl.proto
+
+package secureboost_vl
+
+import (
+	fmt "fmt"
+	mpc "github.com/PaddlePaddle/PaddleDTX/dai/protos/mpc"
+	proto "github.com/golang/protobuf/proto"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+// MessageType defines the type of message with which communicate with nodes in cluster,
+// and in some way it indicates the phase of learning
+// Some types are for local message which is not passed between nodes
+type MessageType int32
+
+const (
+	MessageType_MsgPsiEnc          MessageType = 0
+	MessageType_MsgPsiAskReEnc     MessageType = 1
+	MessageType_MsgPsiReEnc        MessageType = 2
+	MessageType_MsgPsiIntersect    MessageType = 3
+	MessageType_MsgTrainHup        MessageType = 4
+	MessageType_MsgHomoPubkey      MessageType = 5
+	MessageType_MsgTrainReady      MessageType = 6
+	MessageType_MsgTrainLoop       MessageType = 7
+	MessageType_MsgTrainGradHess   MessageType = 8
+	MessageType_MsgTrainHistograms MessageType = 9
+	MessageType_MsgTrainSplit      MessageType = 10
+	MessageType_MsgTrainStatus     MessageType = 11
+	MessageType_MsgTrainModels     MessageType = 12
+	MessageType_MsgPredictHup      MessageType = 51
+	MessageType_MsgPredictPart     MessageType = 52
+	MessageType_MsgPredictFinal    MessageType = 53
+)
+
+var MessageType_name = map[int32]string{
+	0:  "MsgPsiEnc",
+	1:  "MsgPsiAskReEnc",
+	2:  "MsgPsiReEnc",
+	3:  "MsgPsiIntersect",
+	4:  "MsgTrainHup",
+	5:  "MsgHomoPubkey",
+	6:  "MsgTrainReady",
+	7:  "MsgTrainLoop",
+	8:  "MsgTrainGradHess",
+	9:  "MsgTrainHistograms",
+	10: "MsgTrainSplit",
+	11: "MsgTrainStatus",
+	12: "MsgTrainModels",
+	51: "MsgPredictHup",
+	52: "MsgPredictPart",
+	53: "MsgPredictFinal",
+}
+
+var MessageType_value = map[string]int32{
+	"MsgPsiEnc":          0,
+	"MsgPsiAskReEnc":     1,
+	"MsgPsiReEnc":        2,
+	"MsgPsiIntersect":    3,
+	"MsgTrainHup":        4,
+	"MsgHomoPubkey":      5,
+	"MsgTrainReady":      6,
+	"MsgTrainLoop":       7,
+	"MsgTrainGradHess":   8,
+	"MsgTrainHistograms": 9,
+	"MsgTrainSplit":      10,
+	"MsgTrainStatus":     11,
+	"MsgTrainModels":     12,
+	"MsgPredictHup":      51,
+	"MsgPredictPart":     52,
+	"MsgPredictFinal":    53,
+}
+
+func (x MessageType) String() string {
+	return proto.EnumName(MessageType_name, int32(x))
+}
+
+func (MessageType) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_fdda82fb52cf88d6, []int{0}
+}
+
+type Message struct {
+	Type                 MessageType                `protobuf:"varint,1,opt,name=type,proto3,enum=secureboost_vl.MessageType" json:"type,omitempty"`
+	To                   string                     `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	From                 string                     `protobuf:"bytes,3,opt,name=from,proto3" json:"from,omitempty"`
+	LoopRound            uint64                     `protobuf:"varint,4,opt,name=loopRound,proto3" json:"loopRound,omitempty"`
+	VlLPsiReEncIDsReq    *mpc.VLPsiReEncIDsRequest  `protobuf:"bytes,5,opt,name=vlLPsiReEncIDsReq,proto3" json:"vlLPsiReEncIDsReq,omitempty"`
+	VlLPsiReEncIDsResp   *mpc.VLPsiReEncIDsResponse `protobuf:"bytes,6,opt,name=vlLPsiReEncIDsResp,proto3" json:"vlLPsiReEncIDsResp,omitempty"`
+	HomoPubkey           []byte                     `protobuf:"bytes,7,opt,name=homoPubkey,proto3" json:"homoPubkey,omitempty"`
+	EncGradHess          []byte                     `protobuf:"bytes,8,opt,name=encGradHess,proto3" json:"encGradHess,omitempty"`
+	NodeSamples          []byte                     `protobuf:"bytes,9,opt,name=nodeSamples,proto3" json:"nodeSamples,omitempty"`
+	Histograms           []byte                     `protobuf:"bytes,10,opt,name=histograms,proto3" json:"histograms,omitempty"`
+	FeatureIdx           int64                      `protobuf:"varint,11,opt,name=featureIdx,proto3" json:"featureIdx,omitempty"`
+	BinIdx               int64                      `protobuf:"varint,12,opt,name=binIdx,proto3" json:"binIdx,omitempty"`
+	RecordID             string                     `protobuf:"bytes,13,opt,name=recordID,proto3" json:"recordID,omitempty"`
+	Stopped              bool                       `protobuf:"varint,14,opt,name=stopped,proto3" json:"stopped,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                   `json:"-"`
+	XXX_unrecognized     []byte                     `json:"-"`
+	XXX_sizecache        int32                      `json:"-"`
+}
+
+func (m *Message) Reset()         { *m = Message{} }
+func (m *Message) String() string { return proto.CompactTextString(m) }
+func (*Message) ProtoMessage()    {}
+func (*Message) Descriptor() ([]byte, []int) {
+	return fileDescriptor_fdda82fb52cf88d6, []int{0}
+}
+
+func (m *Message) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Message.Unmarshal(m, b)
+}
+func (m *Message) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Message.Marshal(b, m, deterministic)
+}
+func (m *Message) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Message.Merge(m, src)
+}
+func (m *Message) XXX_Size() int {
+	return xxx_messageInfo_Message.Size(m)
+}
+func (m *Message) XXX_DiscardUnknown() {
+	xxx_messageInfo_Message.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Message proto.InternalMessageInfo
+
+func (m *Message) GetType() MessageType {
+	if m != nil {
+		return m.Type
+	}
+	return MessageType_MsgPsiEnc
+}
+
+func (m *Message) GetTo() string {
+	if m != nil {
+		return m.To
+	}
+	return ""
+}
+
+func (m *Message) GetFrom() string {
+	if m != nil {
+		return m.From
+	}
+	return ""
+}
+
+func (m *Message) GetLoopRound() uint64 {
+	if m != nil {
+		return m.LoopRound
+	}
+	return 0
+}
+
+func (m *Message) GetVlLPsiReEncIDsReq() *mpc.VLPsiReEncIDsRequest {
+	if m != nil {
+		return m.VlLPsiReEncIDsReq
+	}
+	return nil
+}
+
+func (m *Message) GetVlLPsiReEncIDsResp() *mpc.VLPsiReEncIDsResponse {
+	if m != nil {
+		return m.VlLPsiReEncIDsResp
+	}
+	return nil
+}
+
+func (m *Message) GetHomoPubkey() []byte {
+	if m != nil {
+		return m.HomoPubkey
+	}
+	return nil
+}
+
+func (m *Message) GetEncGradHess() []byte {
+	if m != nil {
+		return m.EncGradHess
+	}
+	return nil
+}
+
+func (m *Message) GetNodeSamples() []byte {
+	if m != nil {
+		return m.NodeSamples
+	}
+	return nil
+}
+
+func (m *Message) GetHistograms() []byte {
+	if m != nil {
+		return m.Histograms
+	}
+	return nil
+}
+
+func (m *Message) GetFeatureIdx() int64 {
+	if m != nil {
+		return m.FeatureIdx
+	}
+	return 0
+}
+
+func (m *Message) GetBinIdx() int64 {
+	if m != nil {
+		return m.BinIdx
+	}
+	return 0
+}
+
+func (m *Message) GetRecordID() string {
+	if m != nil {
+		return m.RecordID
+	}
+	return ""
+}
+
+func (m *Message) GetStopped() bool {
+	if m != nil {
+		return m.Stopped
+	}
+	return false
+}
+
+type PredictMessage struct {
+	Type                 MessageType                `protobuf:"varint,1,opt,name=type,proto3,enum=secureboost_vl.MessageType" json:"type,omitempty"`
+	To                   string                     `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	From                 string                     `protobuf:"bytes,3,opt,name=from,proto3" json:"from,omitempty"`
+	VlLPsiReEncIDsReq    *mpc.VLPsiReEncIDsRequest  `protobuf:"bytes,4,opt,name=vlLPsiReEncIDsReq,proto3" json:"vlLPsiReEncIDsReq,omitempty"`
+	VlLPsiReEncIDsResp   *mpc.VLPsiReEncIDsResponse `protobuf:"bytes,5,opt,name=vlLPsiReEncIDsResp,proto3" json:"vlLPsiReEncIDsResp,omitempty"`
+	PredictPart          []byte                     `protobuf:"bytes,6,opt,name=predictPart,proto3" json:"predictPart,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                   `json:"-"`
+	XXX_unrecognized     []byte                     `json:"-"`
+	XXX_sizecache        int32                      `json:"-"`
+}
+
+func (m *PredictMessage) Reset()         { *m = PredictMessage{} }
+func (m *PredictMessage) String() string { return proto.CompactTextString(m) }
+func (*PredictMessage) ProtoMessage()    {}
+func (*PredictMessage) Descriptor() ([]byte, []int) {
+	return fileDescriptor_fdda82fb52cf88d6, []int{1}
+}
+
+func (m *PredictMessage) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PredictMessage.Unmarshal(m, b)
+}
+func (m *PredictMessage) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PredictMessage.Marshal(b, m, deterministic)
+}
+func (m *PredictMessage) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PredictMessage.Merge(m, src)
+}
+func (m *PredictMessage) XXX_Size() int {
+	return xxx_messageInfo_PredictMessage.Size(m)
+}
+func (m *PredictMessage) XXX_DiscardUnknown() {
+	xxx_messageInfo_PredictMessage.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PredictMessage proto.InternalMessageInfo
+
+func (m *PredictMessage) GetType() MessageType {
+	if m != nil {
+		return m.Type
+	}
+	return MessageType_MsgPsiEnc
+}
+
+func (m *PredictMessage) GetTo() string {
+	if m != nil {
+		return m.To
+	}
+	return ""
+}
+
+func (m *PredictMessage) GetFrom() string {
+	if m != nil {
+		return m.From
+	}
+	return ""
+}
+
+func (m *PredictMessage) GetVlLPsiReEncIDsReq() *mpc.VLPsiReEncIDsRequest {
+	if m != nil {
+		return m.VlLPsiReEncIDsReq
+	}
+	return nil
+}
+
+func (m *PredictMessage) GetVlLPsiReEncIDsResp() *mpc.VLPsiReEncIDsResponse {
+	if m != nil {
+		return m.VlLPsiReEncIDsResp
+	}
+	return nil
+}
+
+func (m *PredictMessage) GetPredictPart() []byte {
+	if m != nil {
+		return m.PredictPart
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterEnum("secureboost_vl.MessageType", MessageType_name, MessageType_value)
+	proto.RegisterType((*Message)(nil), "secureboost_vl.Message")
+	proto.RegisterType((*PredictMessage)(nil), "secureboost_vl.PredictMessage")
+}
+
+func init() {
+	proto.RegisterFile("mpc/learners/secureboost_vl/secureboost_vl.proto", fileDescriptor_fdda82fb52cf88d6)
+}
+
+var fileDescriptor_fdda82fb52cf88d6 = []byte{
+	// 585 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x94, 0xdf, 0x6e, 0xd3, 0x4a,
+	0x10, 0xc6, 0x8f, 0x53, 0x37, 0x7f, 0xc6, 0x49, 0xba, 0xdd, 0x73, 0x54, 0xed, 0x29, 0x08, 0x59,
+	0xbd, 0xb2, 0xb8, 0x88, 0x51, 0x0b, 0x0f, 0x00, 0x6a, 0x69, 0x52, 0x35, 0x52, 0xe4, 0x56, 0x08,
+	0x71, 0x83, 0x1c, 0x7b, 0x9a, 0x5a, 0xb5, 0xbd, 0xcb, 0xce, 0xba, 0x22, 0xd7, 0xbc, 0x06, 0x8f,
+	0xc3, 0x83, 0xa1, 0xd8, 0x4e, 0x6a, 0x42, 0xc5, 0x05, 0x82, 0x1b, 0xcb, 0xf3, 0x9b, 0x6f, 0xbe,
+	0xf5, 0x78, 0x46, 0x0b, 0x2f, 0x32, 0x15, 0xf9, 0x29, 0x86, 0x3a, 0x47, 0x4d, 0x3e, 0x61, 0x54,
+	0x68, 0x9c, 0x4b, 0x49, 0xe6, 0xe3, 0x7d, 0xba, 0x15, 0x8e, 0x94, 0x96, 0x46, 0xf2, 0xe1, 0x8f,
+	0xf4, 0x70, 0xb0, 0x72, 0x50, 0x94, 0x54, 0xe9, 0xa3, 0x2f, 0x36, 0x74, 0xa6, 0x48, 0x14, 0x2e,
+	0x90, 0xfb, 0x60, 0x9b, 0xa5, 0x42, 0x61, 0xb9, 0x96, 0x37, 0x3c, 0x7e, 0x32, 0xda, 0xf2, 0xab,
+	0x65, 0xd7, 0x4b, 0x85, 0x41, 0x29, 0xe4, 0x43, 0x68, 0x19, 0x29, 0x5a, 0xae, 0xe5, 0xf5, 0x82,
+	0x96, 0x91, 0x9c, 0x83, 0x7d, 0xa3, 0x65, 0x26, 0x76, 0x4a, 0x52, 0xbe, 0xf3, 0xa7, 0xd0, 0x4b,
+	0xa5, 0x54, 0x81, 0x2c, 0xf2, 0x58, 0xd8, 0xae, 0xe5, 0xd9, 0xc1, 0x03, 0xe0, 0xe7, 0xb0, 0x7f,
+	0x9f, 0x5e, 0xce, 0x28, 0x09, 0xf0, 0x2c, 0x8f, 0x26, 0xa7, 0x14, 0xe0, 0x27, 0xb1, 0xeb, 0x5a,
+	0x9e, 0x73, 0xfc, 0xff, 0x28, 0x53, 0xd1, 0xe8, 0xdd, 0x56, 0xb2, 0x40, 0x32, 0xc1, 0xcf, 0x35,
+	0xfc, 0x02, 0xf8, 0x36, 0x24, 0x25, 0xda, 0xa5, 0xd3, 0xe1, 0x63, 0x4e, 0xa4, 0x64, 0x4e, 0x18,
+	0x3c, 0x52, 0xc5, 0x9f, 0x01, 0xdc, 0xca, 0x4c, 0xce, 0x8a, 0xf9, 0x1d, 0x2e, 0x45, 0xc7, 0xb5,
+	0xbc, 0x7e, 0xd0, 0x20, 0xdc, 0x05, 0x07, 0xf3, 0xe8, 0x5c, 0x87, 0xf1, 0x18, 0x89, 0x44, 0xb7,
+	0x14, 0x34, 0xd1, 0x4a, 0x91, 0xcb, 0x18, 0xaf, 0xc2, 0x4c, 0xa5, 0x48, 0xa2, 0x57, 0x29, 0x1a,
+	0xa8, 0x3c, 0x23, 0x21, 0x23, 0x17, 0x3a, 0xcc, 0x48, 0x40, 0x7d, 0xc6, 0x86, 0xac, 0xf2, 0x37,
+	0x18, 0x9a, 0x42, 0xe3, 0x24, 0xfe, 0x2c, 0x1c, 0xd7, 0xf2, 0x76, 0x82, 0x06, 0xe1, 0x07, 0xd0,
+	0x9e, 0x27, 0xf9, 0x2a, 0xd7, 0x2f, 0x73, 0x75, 0xc4, 0x0f, 0xa1, 0xab, 0x31, 0x92, 0x3a, 0x9e,
+	0x9c, 0x8a, 0x41, 0x39, 0x86, 0x4d, 0xcc, 0x05, 0x74, 0xc8, 0x48, 0xa5, 0x30, 0x16, 0x43, 0xd7,
+	0xf2, 0xba, 0xc1, 0x3a, 0x3c, 0xfa, 0xda, 0x82, 0xe1, 0x4c, 0x63, 0x9c, 0x44, 0xe6, 0xaf, 0x2e,
+	0xc3, 0xa3, 0xe3, 0xb6, 0xff, 0xd8, 0xb8, 0x77, 0x7f, 0x6b, 0xdc, 0x2e, 0x38, 0xaa, 0xea, 0x7d,
+	0x16, 0x6a, 0x53, 0xee, 0x4c, 0x3f, 0x68, 0xa2, 0xe7, 0xdf, 0x5a, 0xe0, 0x34, 0x1a, 0xe6, 0x03,
+	0xe8, 0x4d, 0x69, 0x31, 0xa3, 0xe4, 0x2c, 0x8f, 0xd8, 0x3f, 0x9c, 0xc3, 0xb0, 0x0a, 0x5f, 0xd3,
+	0x5d, 0xe9, 0xcc, 0x2c, 0xbe, 0x07, 0x4e, 0xc5, 0x2a, 0xd0, 0xe2, 0xff, 0xc2, 0x5e, 0x05, 0x26,
+	0xb9, 0x41, 0x4d, 0x18, 0x19, 0xb6, 0x53, 0xab, 0xae, 0x75, 0x98, 0xe4, 0xe3, 0x42, 0x31, 0x9b,
+	0xef, 0xc3, 0x60, 0x4a, 0x8b, 0xf1, 0x66, 0xd7, 0xd8, 0x6e, 0x8d, 0x4a, 0x4d, 0x80, 0x61, 0xbc,
+	0x64, 0x6d, 0xce, 0xa0, 0xbf, 0x46, 0x97, 0x52, 0x2a, 0xd6, 0xe1, 0xff, 0x01, 0x5b, 0x93, 0xf5,
+	0x12, 0xb2, 0x2e, 0x3f, 0x00, 0xbe, 0xb1, 0xdf, 0xac, 0x16, 0xeb, 0x35, 0x2d, 0xaf, 0x54, 0x9a,
+	0x18, 0x06, 0x75, 0x0f, 0x15, 0x32, 0xa1, 0x29, 0x88, 0x39, 0x4d, 0x36, 0x95, 0x31, 0xa6, 0xc4,
+	0xfa, 0x75, 0x69, 0xbd, 0x2b, 0xab, 0x6f, 0x3e, 0x59, 0xb7, 0xff, 0xf0, 0xbf, 0xd8, 0xcb, 0x75,
+	0xb7, 0x15, 0x7b, 0x9b, 0xe4, 0x61, 0xca, 0x5e, 0xbd, 0xb9, 0xf8, 0x30, 0x5e, 0x24, 0xe6, 0xb6,
+	0x98, 0x8f, 0x22, 0x99, 0xf9, 0xb3, 0x30, 0x8e, 0x53, 0xac, 0x9e, 0x75, 0x70, 0x7a, 0xfd, 0xde,
+	0x8f, 0xc3, 0xc4, 0x2f, 0xaf, 0x26, 0xf2, 0x7f, 0x71, 0xd7, 0xcd, 0xdb, 0xa5, 0xe6, 0xe4, 0xfb,
+	0x00, 0x21, 0xb5, 0x0a, 0x90, 0x11, 0x05, 0x00, 0x00,
+}
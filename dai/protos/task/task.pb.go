@@ -212,6 +212,7 @@ type DataForTask struct {
 	RejectedAt           int64    `protobuf:"varint,6,opt,name=rejectedAt,proto3" json:"rejectedAt,omitempty"`
 	Address              string   `protobuf:"bytes,7,opt,name=address,proto3" json:"address,omitempty"`
 	IsTagPart            bool     `protobuf:"varint,8,opt,name=isTagPart,proto3" json:"isTagPart,omitempty"`
+	DataVersion          int64    `protobuf:"varint,9,opt,name=dataVersion,proto3" json:"dataVersion,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -298,6 +299,13 @@ func (m *DataForTask) GetIsTagPart() bool {
 	return false
 }
 
+func (m *DataForTask) GetDataVersion() int64 {
+	if m != nil {
+		return m.DataVersion
+	}
+	return 0
+}
+
 // FLTask is a message received from Executor and defines Federated Learning Task based on MPC
 type FLTask struct {
 	TaskID               string             `protobuf:"bytes,1,opt,name=taskID,proto3" json:"taskID,omitempty"`
@@ -568,54 +576,55 @@ func init() {
 func init() { proto.RegisterFile("task/task.proto", fileDescriptor_8e8f2b86464a95fe) }
 
 var fileDescriptor_8e8f2b86464a95fe = []byte{
-	// 742 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x54, 0xdf, 0x6e, 0xd3, 0x3e,
-	0x14, 0x56, 0xba, 0xae, 0x7f, 0x4e, 0xbb, 0xdf, 0xf6, 0x33, 0x1b, 0x44, 0x65, 0x9a, 0xaa, 0x5c,
-	0x4c, 0x15, 0x12, 0x0b, 0xeb, 0xee, 0xb8, 0x63, 0x94, 0x4d, 0x83, 0x81, 0xaa, 0xac, 0x17, 0x08,
-	0x6e, 0x70, 0x9b, 0xb3, 0xcc, 0x2c, 0x89, 0x83, 0xed, 0x00, 0xbd, 0xe5, 0x15, 0x78, 0x0a, 0xde,
-	0x80, 0xf7, 0xe0, 0x15, 0xb8, 0xe5, 0x19, 0x40, 0xb6, 0x93, 0x36, 0x9d, 0x86, 0xc4, 0x4d, 0x9b,
-	0xef, 0x1c, 0xfb, 0xf3, 0x77, 0xce, 0xf9, 0x6c, 0xd8, 0x54, 0x54, 0x5e, 0xfb, 0xfa, 0xe7, 0x20,
-	0x13, 0x5c, 0x71, 0x52, 0xd7, 0xdf, 0xbd, 0x3b, 0x33, 0x9e, 0x24, 0x3c, 0xf5, 0xed, 0x9f, 0x4d,
-	0xf5, 0x76, 0x23, 0xce, 0xa3, 0x18, 0x7d, 0x9a, 0x31, 0x9f, 0xa6, 0x29, 0x57, 0x54, 0x31, 0x9e,
-	0x4a, 0x9b, 0xf5, 0xde, 0x42, 0x67, 0x42, 0xe5, 0x75, 0x80, 0x1f, 0x72, 0x94, 0x8a, 0xdc, 0x85,
-	0x46, 0x96, 0x4f, 0x5f, 0xe0, 0xdc, 0x75, 0xfa, 0xce, 0xa0, 0x1b, 0x14, 0x48, 0xc7, 0xf5, 0x09,
-	0x67, 0x23, 0xb7, 0xd6, 0x77, 0x06, 0xed, 0xa0, 0x40, 0x64, 0x17, 0xda, 0x92, 0x45, 0x29, 0x55,
-	0xb9, 0x40, 0xb7, 0x6e, 0xb6, 0x2c, 0x03, 0xde, 0x3e, 0x74, 0x2d, 0xb9, 0xcc, 0x78, 0x2a, 0xf1,
-	0x6f, 0x2c, 0xde, 0x37, 0x07, 0x36, 0xcf, 0x99, 0x54, 0xff, 0xa2, 0xc4, 0x85, 0x26, 0x8e, 0x6d,
-	0xa2, 0x66, 0x12, 0x25, 0xd4, 0x3b, 0xa4, 0xa2, 0x2a, 0x97, 0xee, 0x9a, 0x65, 0xb7, 0x48, 0x6b,
-	0x54, 0x2c, 0xc1, 0x0b, 0x45, 0x85, 0x32, 0x1a, 0xd7, 0x82, 0x65, 0x40, 0xf3, 0x69, 0xf0, 0x2c,
-	0x0d, 0xdd, 0x75, 0x93, 0x2b, 0x21, 0xd9, 0x86, 0xf5, 0x98, 0x25, 0x4c, 0xb9, 0x0d, 0x13, 0xb7,
-	0xc0, 0xfb, 0xe5, 0x40, 0x67, 0x44, 0x15, 0x3d, 0xe1, 0x42, 0xcb, 0xd5, 0xab, 0xf8, 0xa7, 0x14,
-	0x45, 0x21, 0xd3, 0x02, 0xd2, 0x83, 0x16, 0x7e, 0xc6, 0x59, 0xae, 0xb8, 0x28, 0x64, 0x2e, 0xb0,
-	0xd6, 0x19, 0x52, 0x45, 0xcf, 0x46, 0xa5, 0x4e, 0x8b, 0xf4, 0x9e, 0x4c, 0xb2, 0x73, 0x3a, 0xc5,
-	0xd8, 0xc8, 0x6c, 0x07, 0x0b, 0x4c, 0xfa, 0xd0, 0x99, 0xf1, 0xf4, 0x92, 0x89, 0x04, 0xc3, 0x27,
-	0xaa, 0x50, 0x5a, 0x0d, 0x91, 0x3d, 0x00, 0x81, 0xef, 0x71, 0xa6, 0xcc, 0x02, 0x2b, 0xb9, 0x12,
-	0xd1, 0x75, 0xd2, 0x30, 0x14, 0x28, 0xa5, 0xdb, 0x34, 0xe4, 0x25, 0xd4, 0xfd, 0x61, 0x72, 0x42,
-	0xa3, 0xb1, 0xee, 0x4f, 0xab, 0xef, 0x0c, 0x5a, 0xc1, 0x32, 0xe0, 0xfd, 0xae, 0x41, 0xe3, 0xe4,
-	0xdc, 0x94, 0xba, 0x1c, 0x9f, 0xb3, 0x62, 0x02, 0x02, 0xf5, 0x94, 0x26, 0x58, 0x0c, 0xd5, 0x7c,
-	0x6b, 0xc1, 0x21, 0xca, 0x99, 0x60, 0x99, 0x76, 0x5b, 0x51, 0x69, 0x35, 0xa4, 0x8f, 0x15, 0x76,
-	0xd6, 0x28, 0x4a, 0xeb, 0x2c, 0x02, 0xe4, 0x21, 0xb4, 0x74, 0x5b, 0x2e, 0x50, 0x49, 0x77, 0xbd,
-	0xbf, 0x36, 0xe8, 0x0c, 0xff, 0x3f, 0x30, 0x7e, 0xaf, 0xf4, 0x3e, 0x58, 0x2c, 0x21, 0x8f, 0xa0,
-	0x4d, 0xe3, 0x88, 0x8f, 0xa9, 0xa0, 0x89, 0x29, 0xbe, 0x33, 0x24, 0x07, 0xc5, 0x35, 0xd0, 0x4b,
-	0x4d, 0x42, 0x06, 0xcb, 0x45, 0x15, 0xb7, 0x34, 0x57, 0xdc, 0xb2, 0x07, 0x80, 0x42, 0xbc, 0x44,
-	0x29, 0x69, 0x84, 0xa6, 0x1d, 0xed, 0xa0, 0x12, 0xd1, 0xfb, 0x04, 0xca, 0x3c, 0x56, 0x6e, 0xdb,
-	0xee, 0xb3, 0x48, 0x17, 0x9c, 0xe5, 0xd3, 0x98, 0xc9, 0xab, 0x09, 0x4b, 0xd0, 0x05, 0x3b, 0xa1,
-	0x4a, 0xc8, 0xdc, 0x15, 0x6d, 0x39, 0x93, 0xef, 0x58, 0x1f, 0x2e, 0x02, 0xc6, 0xd7, 0x69, 0x68,
-	0x72, 0x5d, 0xeb, 0xc3, 0x02, 0x7a, 0x87, 0xd0, 0xb4, 0x03, 0x90, 0x64, 0x1f, 0x9a, 0x97, 0xf6,
-	0xd3, 0x75, 0x4c, 0x53, 0xba, 0xb6, 0x29, 0x36, 0x1f, 0x94, 0x49, 0x6f, 0x00, 0xff, 0x9d, 0xe2,
-	0xcd, 0xeb, 0x74, 0xdb, 0xec, 0xbc, 0xa7, 0xb0, 0x39, 0x16, 0x18, 0xb2, 0x99, 0xba, 0xe5, 0x96,
-	0xae, 0x8e, 0xd9, 0x85, 0x66, 0x46, 0xe7, 0x31, 0xa7, 0x61, 0x79, 0xf3, 0x0a, 0x38, 0xfc, 0x5e,
-	0x83, 0xba, 0x71, 0xc8, 0x73, 0x68, 0x95, 0xf7, 0x98, 0xec, 0x58, 0x69, 0x37, 0xee, 0x75, 0x6f,
-	0xa3, 0xaa, 0x58, 0x7a, 0xee, 0x97, 0x1f, 0x3f, 0xbf, 0xd6, 0x88, 0xb7, 0xe1, 0x7f, 0x3c, 0x34,
-	0x0f, 0x9a, 0x1f, 0x33, 0xa9, 0x1e, 0x3b, 0x0f, 0xc8, 0x2b, 0xe8, 0x14, 0x35, 0x1c, 0xcf, 0xcf,
-	0x42, 0xb2, 0x6d, 0xf7, 0xad, 0x96, 0xd5, 0x5b, 0xa9, 0xdf, 0xbb, 0x6f, 0xc8, 0x76, 0xbc, 0xad,
-	0x05, 0x59, 0x84, 0x6a, 0x3a, 0x67, 0xa1, 0xe6, 0x7b, 0x07, 0x5b, 0xa7, 0xa8, 0x96, 0xc5, 0xea,
-	0xa1, 0x15, 0x9e, 0xaa, 0x32, 0x16, 0xb2, 0x6f, 0x34, 0xc5, 0xf3, 0x0c, 0xf5, 0xae, 0x77, 0x6f,
-	0x41, 0x9d, 0xd9, 0x15, 0x02, 0xa5, 0x3e, 0x45, 0x9f, 0x30, 0x84, 0xb6, 0x79, 0x53, 0x4c, 0xf9,
-	0xb7, 0x50, 0x93, 0x6a, 0xc8, 0xf2, 0x1e, 0x1f, 0xbd, 0x39, 0x8c, 0x98, 0xba, 0xca, 0xa7, 0xda,
-	0xad, 0xfe, 0x98, 0x86, 0x61, 0x8c, 0xf6, 0xb7, 0x00, 0xa3, 0xc9, 0x6b, 0x3f, 0xa4, 0xcc, 0x37,
-	0xcf, 0xb5, 0x34, 0x47, 0x4f, 0x1b, 0x06, 0x1c, 0xfd, 0x09, 0x00, 0x00, 0xff, 0xff, 0xb8, 0xf7,
-	0x49, 0x52, 0x07, 0x06, 0x00, 0x00,
+	// 755 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x54, 0x4d, 0x6e, 0xdb, 0x38,
+	0x14, 0x86, 0x1c, 0xc7, 0xb6, 0x68, 0x67, 0x92, 0xe1, 0x24, 0x33, 0x82, 0x27, 0x08, 0x0c, 0x2d,
+	0x02, 0x63, 0x80, 0x89, 0x26, 0xce, 0x6e, 0x76, 0x93, 0x71, 0x13, 0xa4, 0x4d, 0x0b, 0x43, 0x31,
+	0x8a, 0xa2, 0xdd, 0x94, 0xb6, 0x5e, 0x14, 0x36, 0x92, 0xa8, 0x92, 0x54, 0x5b, 0x6f, 0x7b, 0x81,
+	0x2e, 0x7a, 0x8a, 0xde, 0xa0, 0xf7, 0xe8, 0x15, 0x7a, 0x8f, 0x16, 0x7c, 0x94, 0x6c, 0x39, 0x48,
+	0x81, 0x6e, 0x6c, 0x7d, 0xdf, 0x23, 0x3f, 0x7e, 0x7c, 0x3f, 0x24, 0xdb, 0x9a, 0xa9, 0xdb, 0xc0,
+	0xfc, 0x1c, 0xe5, 0x52, 0x68, 0x41, 0x9b, 0xe6, 0xbb, 0xff, 0xdb, 0x5c, 0xa4, 0xa9, 0xc8, 0x02,
+	0xfb, 0x67, 0x43, 0xfd, 0xfd, 0x58, 0x88, 0x38, 0x81, 0x80, 0xe5, 0x3c, 0x60, 0x59, 0x26, 0x34,
+	0xd3, 0x5c, 0x64, 0xca, 0x46, 0xfd, 0x17, 0xa4, 0x3b, 0x65, 0xea, 0x36, 0x84, 0xd7, 0x05, 0x28,
+	0x4d, 0x7f, 0x27, 0xad, 0xbc, 0x98, 0x3d, 0x82, 0x85, 0xe7, 0x0c, 0x9c, 0x61, 0x2f, 0x2c, 0x91,
+	0xe1, 0xcd, 0x09, 0x17, 0x63, 0xaf, 0x31, 0x70, 0x86, 0x6e, 0x58, 0x22, 0xba, 0x4f, 0x5c, 0xc5,
+	0xe3, 0x8c, 0xe9, 0x42, 0x82, 0xd7, 0xc4, 0x2d, 0x2b, 0xc2, 0x3f, 0x24, 0x3d, 0x2b, 0xae, 0x72,
+	0x91, 0x29, 0xf8, 0x91, 0x8a, 0xff, 0xc9, 0x21, 0xdb, 0x97, 0x5c, 0xe9, 0x9f, 0x71, 0xe2, 0x91,
+	0x36, 0x4c, 0x6c, 0xa0, 0x81, 0x81, 0x0a, 0x9a, 0x1d, 0x4a, 0x33, 0x5d, 0x28, 0x6f, 0xc3, 0xaa,
+	0x5b, 0x64, 0x3c, 0x6a, 0x9e, 0xc2, 0x95, 0x66, 0x52, 0xa3, 0xc7, 0x8d, 0x70, 0x45, 0x18, 0x3d,
+	0x03, 0x1e, 0x64, 0x91, 0xb7, 0x89, 0xb1, 0x0a, 0xd2, 0x5d, 0xb2, 0x99, 0xf0, 0x94, 0x6b, 0xaf,
+	0x85, 0xbc, 0x05, 0xfe, 0x87, 0x06, 0xe9, 0x8e, 0x99, 0x66, 0x67, 0x42, 0x1a, 0xbb, 0x66, 0x95,
+	0x78, 0x9b, 0x81, 0x2c, 0x6d, 0x5a, 0x40, 0xfb, 0xa4, 0x03, 0xef, 0x60, 0x5e, 0x68, 0x21, 0x4b,
+	0x9b, 0x4b, 0x6c, 0x7c, 0x46, 0x4c, 0xb3, 0x8b, 0x71, 0xe5, 0xd3, 0x22, 0xb3, 0x27, 0x57, 0xfc,
+	0x92, 0xcd, 0x20, 0x41, 0x9b, 0x6e, 0xb8, 0xc4, 0x74, 0x40, 0xba, 0x73, 0x91, 0x5d, 0x73, 0x99,
+	0x42, 0xf4, 0x9f, 0x2e, 0x9d, 0xd6, 0x29, 0x7a, 0x40, 0x88, 0x84, 0x57, 0x30, 0xd7, 0xb8, 0xc0,
+	0x5a, 0xae, 0x31, 0xe6, 0x9e, 0x2c, 0x8a, 0x24, 0x28, 0xe5, 0xb5, 0x51, 0xbc, 0x82, 0x26, 0x3f,
+	0x5c, 0x4d, 0x59, 0x3c, 0x31, 0xf9, 0xe9, 0x0c, 0x9c, 0x61, 0x27, 0x5c, 0x11, 0xe6, 0x64, 0xe3,
+	0xef, 0x29, 0x48, 0xc5, 0x45, 0xe6, 0xb9, 0xf6, 0xe4, 0x1a, 0xe5, 0x7f, 0x6b, 0x90, 0xd6, 0xd9,
+	0x25, 0x26, 0x63, 0x55, 0x60, 0x67, 0xad, 0x4d, 0x28, 0x69, 0x66, 0x2c, 0x85, 0xb2, 0xec, 0xf8,
+	0x8d, 0xc2, 0xa0, 0xe6, 0x92, 0xe7, 0xa6, 0x1f, 0xcb, 0x5c, 0xd4, 0x29, 0x63, 0x4c, 0xda, 0x6e,
+	0x00, 0x59, 0x35, 0xd7, 0x92, 0xa0, 0x7f, 0x93, 0x8e, 0x71, 0x71, 0x05, 0x5a, 0x79, 0x9b, 0x83,
+	0x8d, 0x61, 0x77, 0xf4, 0xeb, 0x11, 0x4e, 0x44, 0xad, 0x3a, 0xe1, 0x72, 0x09, 0xfd, 0x87, 0xb8,
+	0x2c, 0x89, 0xc5, 0x84, 0x49, 0x96, 0x62, 0x7a, 0xba, 0x23, 0x7a, 0x54, 0x0e, 0x8a, 0x59, 0x8a,
+	0x01, 0x15, 0xae, 0x16, 0xd5, 0xfa, 0xa9, 0xbd, 0xd6, 0x4f, 0x07, 0x84, 0x80, 0x94, 0x8f, 0x41,
+	0x29, 0x16, 0x03, 0x26, 0xcc, 0x0d, 0x6b, 0x8c, 0xd9, 0x27, 0x41, 0x15, 0x89, 0xc6, 0x64, 0xb9,
+	0x61, 0x89, 0xcc, 0x85, 0xf3, 0x62, 0x96, 0x70, 0x75, 0x33, 0xe5, 0x29, 0x78, 0xc4, 0x66, 0xb2,
+	0x46, 0xe1, 0x34, 0x99, 0xa6, 0xc4, 0x78, 0xd7, 0x76, 0xea, 0x92, 0xc0, 0xce, 0xcf, 0x22, 0x8c,
+	0xf5, 0x6c, 0xa7, 0x96, 0xd0, 0x3f, 0x26, 0x6d, 0x5b, 0x00, 0x45, 0x0f, 0x49, 0xfb, 0xda, 0x7e,
+	0x7a, 0x0e, 0x26, 0xa5, 0x67, 0x93, 0x62, 0xe3, 0x61, 0x15, 0xf4, 0x87, 0xe4, 0x97, 0x73, 0xb8,
+	0x3b, 0x70, 0xf7, 0xd5, 0xce, 0xff, 0x9f, 0x6c, 0x4f, 0x24, 0x44, 0x7c, 0xae, 0xef, 0x99, 0xe3,
+	0xf5, 0x32, 0x7b, 0xa4, 0x9d, 0xb3, 0x45, 0x22, 0x58, 0x54, 0xcd, 0x66, 0x09, 0x47, 0x9f, 0x1b,
+	0xa4, 0x89, 0x1d, 0xf2, 0x90, 0x74, 0xaa, 0x49, 0xa7, 0x7b, 0xd6, 0xda, 0x9d, 0xc9, 0xef, 0x6f,
+	0xd5, 0x1d, 0x2b, 0xdf, 0x7b, 0xff, 0xe5, 0xeb, 0xc7, 0x06, 0xf5, 0xb7, 0x82, 0x37, 0xc7, 0xf8,
+	0xe4, 0x05, 0x09, 0x57, 0xfa, 0x5f, 0xe7, 0x2f, 0xfa, 0x84, 0x74, 0xcb, 0x3b, 0x9c, 0x2e, 0x2e,
+	0x22, 0xba, 0x6b, 0xf7, 0xad, 0x5f, 0xab, 0xbf, 0x76, 0x7f, 0xff, 0x4f, 0x14, 0xdb, 0xf3, 0x77,
+	0x96, 0x62, 0x31, 0xe8, 0xd9, 0x82, 0x47, 0x46, 0xef, 0x25, 0xd9, 0x39, 0x07, 0xbd, 0xba, 0xac,
+	0x29, 0x5a, 0xd9, 0x53, 0x75, 0xc5, 0xd2, 0xf6, 0x9d, 0xa4, 0xf8, 0x3e, 0x4a, 0xef, 0xfb, 0x7f,
+	0x2c, 0xa5, 0x73, 0xbb, 0x42, 0x82, 0x32, 0xa7, 0x98, 0x13, 0x46, 0xc4, 0xc5, 0x57, 0x07, 0xaf,
+	0x7f, 0x8f, 0x34, 0xad, 0x53, 0x56, 0xf7, 0xf4, 0xe4, 0xf9, 0x71, 0xcc, 0xf5, 0x4d, 0x31, 0x33,
+	0xdd, 0x1a, 0x4c, 0x58, 0x14, 0x25, 0x60, 0x7f, 0x4b, 0x30, 0x9e, 0x3e, 0x0b, 0x22, 0xc6, 0x03,
+	0x7c, 0xd0, 0x15, 0x1e, 0x3d, 0x6b, 0x21, 0x38, 0xf9, 0x3e, 0x00, 0x96, 0x22, 0xc2, 0xa1, 0x29,
+	0x06, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -30,8 +30,10 @@ import (
 
 	"github.com/PaddlePaddle/PaddleDTX/dai/blockchain"
 	fabricblockchain "github.com/PaddlePaddle/PaddleDTX/dai/blockchain/fabric"
+	localblockchain "github.com/PaddlePaddle/PaddleDTX/dai/blockchain/local"
 	xchainblockchain "github.com/PaddlePaddle/PaddleDTX/dai/blockchain/xchain"
 	"github.com/PaddlePaddle/PaddleDTX/dai/config"
+	"github.com/PaddlePaddle/PaddleDTX/dai/crypto/hl/fedavg"
 	"github.com/PaddlePaddle/PaddleDTX/dai/crypto/vl/common/csv"
 	pbCom "github.com/PaddlePaddle/PaddleDTX/dai/protos/common"
 	pbTask "github.com/PaddlePaddle/PaddleDTX/dai/protos/task"
@@ -54,6 +56,8 @@ type Blockchain interface {
 
 	// file operation
 	GetFileByID(id string) (xdbchain.File, error)
+	GetFileVersionByID(id string) (blockchain.FileVersion, error)
+	GetPrivacyBudget(id string) (blockchain.PrivacyBudget, error)
 	ListFileAuthApplications(opt *xdbchain.ListFileAuthOptions) (xdbchain.FileAuthApplications, error)
 	GetAuthApplicationByID(authID string) (xdbchain.FileAuthApplication, error)
 }
@@ -69,6 +73,8 @@ func newChainClient(conf *config.ExecutorBlockchainConf) (b Blockchain, err erro
 		b, err = xchainblockchain.New(conf.Xchain)
 	case "fabric":
 		b, err = fabricblockchain.New(conf.Fabric)
+	case "local":
+		b, err = localblockchain.New(conf.Local)
 	default:
 		return b, errorx.New(errorx.ErrCodeConfig, "invalid blockchain type: %s", conf.Type)
 	}
@@ -123,6 +129,15 @@ func (c *Client) checkPublishTaskOptions(opt PublishOptions) ([]*pbTask.DataForT
 		if opt.AlgoParam.Algo == pbCom.Algorithm_LOGIC_REGRESSION_VL && opt.AlgoParam.TrainParams.LabelName == "" {
 			return nil, errorx.New(errorx.ErrCodeParam, "labelName can not be empty for logistic-vl")
 		}
+		if opt.AlgoParam.Algo == pbCom.Algorithm_SECUREBOOST_VL && opt.AlgoParam.TrainParams.LabelName == "" {
+			return nil, errorx.New(errorx.ErrCodeParam, "labelName can not be empty for secureboost-vl")
+		}
+		if opt.AlgoParam.Algo == pbCom.Algorithm_LOGIC_REGRESSION_HL && opt.AlgoParam.TrainParams.LabelName == "" {
+			return nil, errorx.New(errorx.ErrCodeParam, "labelName can not be empty for logistic-hl")
+		}
+		if err := checkDPParams(opt.AlgoParam); err != nil {
+			return nil, err
+		}
 	}
 
 	// 2. check data sets number and executor nodes number, at least two parties
@@ -153,21 +168,45 @@ func (c *Client) checkPublishTaskOptions(opt PublishOptions) ([]*pbTask.DataForT
 			return nil, errorx.New(errorx.ErrCodeParam, "sample file num not match psi label num")
 		}
 	}
+	// horizontal task doesn't need PSI, but the ID feature is used to mark samples in prediction result
+	_, isHorizontal := blockchain.HlAlgorithmListValue[opt.AlgoParam.Algo]
+	if isHorizontal {
+		if opt.PSILabels == "" {
+			return nil, errorx.New(errorx.ErrCodeParam, "PSILabel cannot be empty for horizontal task")
+		}
+		if len(fileIDs) != len(psiLabels) {
+			return nil, errorx.New(errorx.ErrCodeParam, "sample file num not match psi label num")
+		}
+		// the sum aggregated by two parties reveals the other's local values
+		if opt.AlgoParam.TaskType == pbCom.TaskType_LEARN && len(fileIDs) < fedavg.MinParties {
+			return nil, errorx.New(errorx.ErrCodeParam, "at least %d sample files are required in horizontal train task, got: %d", fedavg.MinParties, len(fileIDs))
+		}
+	}
 
 	// 4. check if dataset and specified label exist
 	var dataSets []*pbTask.DataForTask
-	var isTagPart bool
 	isLabelExist := 0
 	for index, fileID := range fileIDs {
 		file, err := c.chainClient.GetFileByID(fileID)
 		if err != nil {
 			return nil, err
 		}
+		// samples are pinned to the version of the file, so the task records exactly which version it uses
+		fileVersion, err := c.chainClient.GetFileVersionByID(fileID)
+		if err != nil {
+			return nil, err
+		}
 
 		fileExtra := blockchain.FLInfo{}
 		if err := json.Unmarshal(file.Ext, &fileExtra); err != nil {
 			return nil, errorx.New(errorx.ErrCodeInternal, "failed to get file extra info: %v", err)
 		}
+		// the contract checks privacy budget again when the task starts
+		if opt.AlgoParam.TaskType == pbCom.TaskType_LEARN && fileExtra.PrivacyBudget > 0 {
+			if err := c.checkPrivacyBudget(fileID, opt.AlgoParam.TrainParams.GetDpParams()); err != nil {
+				return nil, err
+			}
+		}
 		fileFeatures := strings.Split(fileExtra.Features, ",")
 		// check if psiLabel exists in feature list
 		if !util.IsContain(fileFeatures, psiLabels[index]) {
@@ -175,12 +214,16 @@ func (c *Client) checkPublishTaskOptions(opt PublishOptions) ([]*pbTask.DataForT
 		}
 
 		// check if label exists in one of the datasets
-		if util.IsContain(fileFeatures, opt.AlgoParam.TrainParams.Label) {
+		isTagPart := util.IsContain(fileFeatures, opt.AlgoParam.TrainParams.Label)
+		if isTagPart {
 			isLabelExist += 1
-			isTagPart = true
 		}
-		// only one party is allowed to have label
-		if isLabelExist > 1 {
+		// every party holds samples with label in horizontal train task
+		if isHorizontal && opt.AlgoParam.TaskType == pbCom.TaskType_LEARN && !isTagPart {
+			return nil, errorx.New(errorx.ErrCodeParam, "invalid fileIDs, every sample file should have label in horizontal train task")
+		}
+		// only one party is allowed to have label in vertical task
+		if !isHorizontal && isLabelExist > 1 {
 			return nil, errorx.New(errorx.ErrCodeParam, "invalid fileIDs, only one sample file is allowed to have label")
 		}
 
@@ -190,12 +233,13 @@ func (c *Client) checkPublishTaskOptions(opt PublishOptions) ([]*pbTask.DataForT
 			return nil, errorx.Wrap(err, "failed to get executor node by node name")
 		}
 		dataSets = append(dataSets, &pbTask.DataForTask{
-			Owner:     file.Owner,
-			Executor:  executorNode.ID,
-			PsiLabel:  psiLabels[index],
-			DataID:    fileID,
-			Address:   executorNode.Address,
-			IsTagPart: isTagPart,
+			Owner:       file.Owner,
+			Executor:    executorNode.ID,
+			PsiLabel:    psiLabels[index],
+			DataID:      fileID,
+			DataVersion: fileVersion.GetVersion(),
+			Address:     executorNode.Address,
+			IsTagPart:   isTagPart,
 		})
 	}
 	if opt.AlgoParam.TaskType == pbCom.TaskType_LEARN && isLabelExist < 1 {
@@ -204,6 +248,45 @@ func (c *Client) checkPublishTaskOptions(opt PublishOptions) ([]*pbTask.DataForT
 	return dataSets, nil
 }
 
+// checkDPParams checks params of differential privacy for train task,
+// only vertical linear and logistic regression support it, and evaluation is not allowed
+// since metrics on the training samples are released without noise
+func checkDPParams(algoParam pbCom.TaskParams) error {
+	dp := algoParam.TrainParams.GetDpParams()
+	if !dp.GetEnable() {
+		return nil
+	}
+	if algoParam.Algo != pbCom.Algorithm_LINEAR_REGRESSION_VL && algoParam.Algo != pbCom.Algorithm_LOGIC_REGRESSION_VL {
+		return errorx.New(errorx.ErrCodeParam, "differential privacy only supports linear-vl and logistic-vl")
+	}
+	if algoParam.GetEvalParams().GetEnable() || algoParam.GetLivalParams().GetEnable() {
+		return errorx.New(errorx.ErrCodeParam, "evaluation is not supported with differential privacy")
+	}
+	if dp.Epsilon <= 0 || dp.Delta <= 0 || dp.Delta >= 1 {
+		return errorx.New(errorx.ErrCodeParam, "invalid differential privacy params, epsilon should be positive and delta in the range of (0,1)")
+	}
+	if dp.ClipNorm <= 0 || dp.MaxRounds <= 0 {
+		return errorx.New(errorx.ErrCodeParam, "invalid differential privacy params, clipNorm and maxRounds should be positive")
+	}
+	return nil
+}
+
+// checkPrivacyBudget checks if the sample file has enough privacy budget for the train task
+func (c *Client) checkPrivacyBudget(fileID string, dp *pbCom.DifferentialPrivacyParams) error {
+	if !dp.GetEnable() {
+		return errorx.New(errorx.ErrCodeParam, "sample file %s has a privacy budget, differential privacy is required", fileID)
+	}
+	budget, err := c.chainClient.GetPrivacyBudget(fileID)
+	if err != nil {
+		return err
+	}
+	if budget.Limit > 0 && budget.SpentEpsilon+dp.Epsilon > budget.Limit+1e-9 {
+		return errorx.New(errorx.ErrCodeParam, "privacy budget of sample file %s exceeded, limit: %v, spent: %v, required: %v",
+			fileID, budget.Limit, budget.SpentEpsilon, dp.Epsilon)
+	}
+	return nil
+}
+
 // Publish publishes a task, returns taskID
 func (c *Client) Publish(opt PublishOptions) (taskId string, err error) {
 	pubkey, privkey, err := checkUserPrivateKey(opt.PrivateKey)
@@ -327,18 +410,47 @@ func (c *Client) GetPredictResult(privateKey, taskID, output string) (err error)
 	if err != nil {
 		return err
 	}
-	var executorHost string
-	for _, dataset := range modelTask.DataSets {
-		if dataset.IsTagPart {
-			executorHost = dataset.Address
-			break
+	var executorHosts []string
+	if _, ok := blockchain.HlAlgorithmListValue[modelTask.AlgoParam.Algo]; ok {
+		// every party predicts its own samples in horizontal task
+		for _, dataset := range task.DataSets {
+			executorHosts = append(executorHosts, dataset.Address)
+		}
+	} else {
+		for _, dataset := range modelTask.DataSets {
+			if dataset.IsTagPart {
+				executorHosts = append(executorHosts, dataset.Address)
+				break
+			}
 		}
 	}
 
+	var rows [][]string
+	for _, executorHost := range executorHosts {
+		hostRows, err := c.downloadPredictResult(executorHost, taskID, pubkey, privkey)
+		if err != nil {
+			return err
+		}
+		// keep only one header when merging results of several parties
+		if len(rows) > 0 && len(hostRows) > 0 {
+			hostRows = hostRows[1:]
+		}
+		rows = append(rows, hostRows...)
+	}
+	// save result to csv file
+	if err := csv.WriteRowsToFile(rows, output); err != nil {
+		return errorx.Wrap(err, "failed to unmarshal result to rows")
+	}
+
+	return nil
+}
+
+// downloadPredictResult downloads prediction result of the task from executor
+func (c *Client) downloadPredictResult(executorHost, taskID string, pubkey ecdsa.PublicKey, privkey ecdsa.PrivateKey) ([][]string, error) {
 	// connect to result owner
 	conn, err := grpc.Dial(executorHost, grpc.WithInsecure())
 	if err != nil {
-		return errorx.New(errorx.ErrCodeInternal, "CAN_NOT_CONNECT_EXECUTOR_SERVER: %v", err)
+		return nil, errorx.New(errorx.ErrCodeInternal, "CAN_NOT_CONNECT_EXECUTOR_SERVER: %v", err)
 	}
 	defer conn.Close()
 	taskClient := pbTask.NewTaskClient(conn)
@@ -351,29 +463,24 @@ func (c *Client) GetPredictResult(privateKey, taskID, output string) (err error)
 	// verify signature
 	msg, err := util.GetSigMessage(in)
 	if err != nil {
-		return errorx.Internal(err, "failed to get the message to sign for download prediction result")
+		return nil, errorx.Internal(err, "failed to get the message to sign for download prediction result")
 	}
 	sig, err := ecdsa.Sign(privkey, hash.HashUsingSha256([]byte(msg)))
 	if err != nil {
-		return errorx.Wrap(err, "failed to sign predict task")
+		return nil, errorx.Wrap(err, "failed to sign predict task")
 	}
 	in.Signature = sig[:]
 
 	// request data node to download predict file
 	out, err := taskClient.GetPredictResult(context.Background(), in)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	var rows [][]string
 	if err := json.Unmarshal(out.Payload, &rows); err != nil {
-		return errorx.Wrap(err, "failed to unmarshal result to rows")
-	}
-	// save result to csv file
-	if err := csv.WriteRowsToFile(rows, output); err != nil {
-		return errorx.Wrap(err, "failed to unmarshal result to rows")
+		return nil, errorx.Wrap(err, "failed to unmarshal result to rows")
 	}
-
-	return nil
+	return rows, nil
 }
 
 // ListExecutorNodes list all executor nodes
@@ -400,6 +507,11 @@ func (c *Client) GetFileAuthByID(id string) (fileAuth xdbchain.FileAuthApplicati
 	return c.chainClient.GetAuthApplicationByID(id)
 }
 
+// GetPrivacyBudget get the privacy budget spent on the sample file by training tasks with differential privacy
+func (c *Client) GetPrivacyBudget(fileID string) (budget blockchain.PrivacyBudget, err error) {
+	return c.chainClient.GetPrivacyBudget(fileID)
+}
+
 // ListFileAuthApplications query the list of authorization applications
 func (c *Client) ListFileAuthApplications(opt *xdbchain.ListFileAuthOptions) (fileAuths xdbchain.FileAuthApplications, err error) {
 	return c.chainClient.ListFileAuthApplications(opt)
@@ -0,0 +1,57 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package file
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	requestClient "github.com/PaddlePaddle/PaddleDTX/dai/requester/client"
+)
+
+var fileID string
+
+// getBudgetCmd represents the command to get the privacy budget spent on a sample file
+var getBudgetCmd = &cobra.Command{
+	Use:   "getbudget",
+	Short: "get the privacy budget spent on the sample file by training tasks with differential privacy",
+	Run: func(cmd *cobra.Command, args []string) {
+		client, err := requestClient.GetRequestClient(configPath)
+		if err != nil {
+			fmt.Printf("GetRequestClient failed: %v\n", err)
+			return
+		}
+
+		budget, err := client.GetPrivacyBudget(fileID)
+		if err != nil {
+			fmt.Printf("err：%v\n", err)
+			return
+		}
+		limit := "unlimited"
+		if budget.Limit > 0 {
+			limit = fmt.Sprintf("%v", budget.Limit)
+		}
+		fmt.Printf("FileID: %s\nLimit: %s\nSpentEpsilon: %v\nSpentDelta: %v\nTasks: %v\n\n",
+			budget.DataID, limit, budget.SpentEpsilon, budget.SpentDelta, budget.TaskIDs)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(getBudgetCmd)
+
+	getBudgetCmd.Flags().StringVarP(&fileID, "fileID", "f", "", "id of sample file")
+
+	getBudgetCmd.MarkFlagRequired("fileID")
+}
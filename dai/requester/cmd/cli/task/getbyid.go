@@ -46,8 +46,12 @@ var getByIDCmd = &cobra.Command{
 			task.TaskID, task.Requester, blockchain.TaskTypeListValue[task.AlgoParam.TaskType], task.Name, task.Description, task.AlgoParam.TrainParams.Label,
 			task.AlgoParam.TrainParams.LabelName, blockchain.RegModeListValue[task.AlgoParam.TrainParams.RegMode], task.AlgoParam.TrainParams.RegParam)
 
+		algoName := blockchain.VlAlgorithmListValue[task.AlgoParam.Algo]
+		if name, ok := blockchain.HlAlgorithmListValue[task.AlgoParam.Algo]; ok {
+			algoName = name
+		}
 		fmt.Printf("Algorithm: %v\nAlpha: %f\nAmplitude: %f\nAccuracy: %v\nModelTaskID: %s\nStatus: %s\nPublishTime: %s\n\n",
-			blockchain.VlAlgorithmListValue[task.AlgoParam.Algo], task.AlgoParam.TrainParams.Alpha, task.AlgoParam.TrainParams.Amplitude,
+			algoName, task.AlgoParam.TrainParams.Alpha, task.AlgoParam.TrainParams.Amplitude,
 			task.AlgoParam.TrainParams.Accuracy, task.AlgoParam.ModelTaskID, task.Status, publishTime)
 
 		if task.AlgoParam.EvalParams != nil && task.AlgoParam.EvalParams.Enable {
@@ -64,6 +68,11 @@ var getByIDCmd = &cobra.Command{
 			}
 		}
 
+		if dp := task.AlgoParam.TrainParams.GetDpParams(); dp.GetEnable() {
+			fmt.Printf("DifferentialPrivacy: true\nEpsilon: %v\nDelta: %v\nClipNorm: %v\nMaxRounds: %d\n\n",
+				dp.Epsilon, dp.Delta, dp.ClipNorm, dp.MaxRounds)
+		}
+
 		fmt.Println("Task data sets: ")
 		for _, data := range task.DataSets {
 			var ct, rt string
@@ -73,8 +82,8 @@ var getByIDCmd = &cobra.Command{
 			if data.RejectedAt > 0 {
 				rt = time.Unix(0, data.RejectedAt).Format(timeTemplate)
 			}
-			fmt.Printf("DataID: %s\nOwner: %x\nExecutor: %x\nAddress: %s\nPSILabel: %s\nConfirmedAt: %s\nRejectedAt: %s\n\n",
-				data.DataID, data.Owner, data.Executor, data.Address, data.PsiLabel, ct, rt)
+			fmt.Printf("DataID: %s\nDataVersion: %d\nOwner: %x\nExecutor: %x\nAddress: %s\nPSILabel: %s\nConfirmedAt: %s\nRejectedAt: %s\n\n",
+				data.DataID, data.DataVersion, data.Owner, data.Executor, data.Address, data.PsiLabel, ct, rt)
 		}
 
 		var startTime, endTime string
@@ -43,6 +43,8 @@ var (
 	description string // task description
 	psiLabel    string // id features list
 	batchSize   uint64 // batch size for each round
+	treeNum     uint64 // number of trees for secureboost-vl
+	maxDepth    uint64 // max depth of each tree for secureboost-vl
 	ev          bool   // whether perform model evaluation
 	evRule      int32  // evRule is the way to evaluate model, 0 means `Random Split`, 1 means `Cross Validation`, 2 means `Leave One Out`
 	percentLO   int32  // percentage to leave out as validation set when perform model evaluation in the way of `Random Split`
@@ -51,6 +53,12 @@ var (
 
 	le         bool  // whether perform live model evaluation
 	lPercentLO int32 // percentage to leave out as validation set when perform live model evaluation
+
+	dp       bool    // whether train with differential privacy
+	epsilon  float64 // privacy budget the task spends on each sample file
+	delta    float64 // probability that the privacy guarantee of epsilon fails
+	clipNorm float64 // L2 bound of each sample's gradient
+	dpRounds uint64  // number of rounds the privacy budget is spent over
 )
 
 // checkTaskPublishParams check mpc task parameters
@@ -62,8 +70,10 @@ func checkTaskPublishParams() (pbCom.Algorithm, pbCom.TaskType, pbCom.RegMode, e
 	// task algorithm name check
 	if algo, ok := blockchain.VlAlgorithmListName[algorithm]; ok {
 		pAlgo = algo
+	} else if algo, ok := blockchain.HlAlgorithmListName[algorithm]; ok {
+		pAlgo = algo
 	} else {
-		return pAlgo, pType, pRegMode, errorx.New(errorx.ErrCodeParam, "algorithm only support linear-vl or logistic-vl")
+		return pAlgo, pType, pRegMode, errorx.New(errorx.ErrCodeParam, "algorithm only support linear-vl, logistic-vl, secureboost-vl, linear-hl or logistic-hl")
 	}
 	// task type check
 	if taskType, ok := blockchain.TaskTypeListName[taskType]; ok {
@@ -128,6 +138,8 @@ var publishCmd = &cobra.Command{
 				Amplitude: amplitude,
 				Accuracy:  int64(accuracy),
 				BatchSize: int64(batchSize),
+				TreeNum:   int64(treeNum),
+				MaxDepth:  int64(maxDepth),
 			},
 		}
 		// set `Evaluation` part
@@ -145,6 +157,16 @@ var publishCmd = &cobra.Command{
 				}
 			}
 		}
+		// set `DifferentialPrivacy` part
+		if dp {
+			algorithmParams.TrainParams.DpParams = &pbCom.DifferentialPrivacyParams{
+				Enable:    true,
+				Epsilon:   epsilon,
+				Delta:     delta,
+				ClipNorm:  clipNorm,
+				MaxRounds: int64(dpRounds),
+			}
+		}
 		// set `LiveEvaluation` part
 		if le {
 			algorithmParams.LivalParams = &pbCom.LiveEvaluationParams{
@@ -188,14 +210,14 @@ func init() {
 	publishCmd.Flags().StringVarP(&privateKey, "privkey", "k", "", "requester's private key hex string")
 	publishCmd.Flags().StringVarP(&keyPath, "keyPath", "", "./reqkeys", "requester's key path")
 	publishCmd.Flags().StringVarP(&taskType, "type", "t", "", "task type, 'train' or 'predict'")
-	publishCmd.Flags().StringVarP(&algorithm, "algorithm", "a", "", "algorithm assigned to task, 'linear-vl' and 'logistic-vl' are supported")
+	publishCmd.Flags().StringVarP(&algorithm, "algorithm", "a", "", "algorithm assigned to task, 'linear-vl', 'logistic-vl', 'secureboost-vl', 'linear-hl' and 'logistic-hl' are supported")
 	publishCmd.Flags().StringVarP(&files, "files", "f", "", "sample files IDs with ',' as delimiter, like '123,456'")
 	publishCmd.Flags().StringVarP(&executors, "executors", "e", "", "executor node names with ',' as delimiter, like 'executor1,executor2'")
 
 	// optional params
 	publishCmd.Flags().StringVarP(&label, "label", "l", "", "target feature for training task")
-	publishCmd.Flags().StringVar(&labelName, "labelName", "", "target variable required in logistic-vl training")
-	publishCmd.Flags().StringVarP(&psiLabel, "psiLabel", "p", "", "ID feature name list with ',' as delimiter, like 'id,id', required in vertical task")
+	publishCmd.Flags().StringVar(&labelName, "labelName", "", "target variable required in logistic-vl, secureboost-vl and logistic-hl training")
+	publishCmd.Flags().StringVarP(&psiLabel, "psiLabel", "p", "", "ID feature name list with ',' as delimiter, like 'id,id', required in vertical and horizontal task")
 	publishCmd.Flags().StringVarP(&taskId, "taskId", "i", "", "finished train task ID from which obtain the model, required for predict task")
 	publishCmd.Flags().StringVar(&regMode, "regMode", "", "regularization mode required in train task, no regularization if not set, options are l1(L1-norm) and l2(L2-norm)")
 	publishCmd.Flags().Float64Var(&regParam, "regParam", 0.1, "regularization parameter required in train task if set regMode")
@@ -205,6 +227,8 @@ func init() {
 	publishCmd.Flags().StringVarP(&description, "description", "d", "", "task description")
 	publishCmd.Flags().Uint64VarP(&batchSize, "batchSize", "b", 4,
 		"size of samples for one round of training loop, 0 for BGD(Batch Gradient Descent), non-zero for SGD(Stochastic Gradient Descent) or MBGD(Mini-Batch Gradient Descent)")
+	publishCmd.Flags().Uint64Var(&treeNum, "treeNum", 5, "number of trees in secureboost-vl training")
+	publishCmd.Flags().Uint64Var(&maxDepth, "maxDepth", 3, "max depth of each tree in secureboost-vl training")
 	// optional params about evaluation
 	publishCmd.Flags().BoolVar(&ev, "ev", false, "perform model evaluation")
 	publishCmd.Flags().Int32Var(&evRule, "evRule", 0, "the way to evaluate model, 0 means 'Random Split', 1 means 'Cross Validation', 2 means 'Leave One Out'")
@@ -216,6 +240,13 @@ func init() {
 	publishCmd.Flags().BoolVar(&le, "le", false, "perform live model evaluation")
 	publishCmd.Flags().Int32Var(&lPercentLO, "lplo", 30, "percentage to leave out as validation set when perform live model evaluation")
 
+	// optional params about differential privacy
+	publishCmd.Flags().BoolVar(&dp, "dp", false, "train with differential privacy, supported in linear-vl and logistic-vl")
+	publishCmd.Flags().Float64Var(&epsilon, "epsilon", 1, "privacy budget the task spends on each sample file when training with differential privacy")
+	publishCmd.Flags().Float64Var(&delta, "delta", 0.00001, "probability that the privacy guarantee fails when training with differential privacy")
+	publishCmd.Flags().Float64Var(&clipNorm, "clipNorm", 1, "L2 bound of each sample's gradient when training with differential privacy")
+	publishCmd.Flags().Uint64Var(&dpRounds, "dpRounds", 100, "number of rounds the privacy budget is spent over, training stops when reached")
+
 	publishCmd.MarkFlagRequired("name")
 	publishCmd.MarkFlagRequired("type")
 	publishCmd.MarkFlagRequired("algorithm")
@@ -29,6 +29,7 @@ const (
 	FileAuthUnapproved = "Unapproved" // the applier published file's authorization application and the authorizer has not yet approved
 	FileAuthApproved   = "Approved"   // the authorizer approved applier's authorization application
 	FileAuthRejected   = "Rejected"   // the authorizer rejected applier's authorization application
+	FileAuthRevoked    = "Revoked"    // the authorizer revoked the approved authorization application
 )
 
 // define variables about node health
@@ -54,9 +55,58 @@ const (
 	NodeHealthBoundMedium = 0.6  // health ratio between 0.6 and 0.85 means node's status is Yellow
 )
 
+// define variables about storage node reputation
+const (
+	// Define the event types changing the reputation score of storage node
+	ReputationChallengeFailed = "ChallengeFailed"
+	ReputationChallengeProved = "ChallengeProved"
+	ReputationHeartbeatMissed = "HeartbeatMissed"
+	ReputationSliceLost       = "SliceLost"
+
+	// Heartbeat timeouts are timed by data owners, so the time may run ahead of the latest heartbeat of
+	// the node by HeartbeatTimeoutWindow at most, and at most MaxMissedHeartbeats are penalized at a time
+	HeartbeatTimeoutWindow = 10 * HeartBeatFreq
+	MaxMissedHeartbeats    = 3
+	// Deadlines pass at the first heartbeat of the node after them, so time-bounded challenges
+	// must leave the node MinAnswerWindow at least to answer
+	MinAnswerWindow = 5 * HeartBeatFreq
+)
+
+// DefaultReputationPolicy used by contract if no policy is given when the contract is initialized
+var DefaultReputationPolicy = ReputationPolicy{
+	InitialScore:           1000,
+	MaxScore:               1000,
+	ExcludeThreshold:       600,
+	ChallengeFailedPenalty: 50,
+	HeartbeatMissedPenalty: 1,
+	SliceLostPenalty:       20,
+	ChallengeProvedReward:  5,
+}
+
+// define variables about file audit trail
+const (
+	// Define the types of events recorded in the audit trail of a file
+	FileAuditPublished         = "Published"
+	FileAuditSlicesUpdated     = "SlicesUpdated" // slices changed by replica expansion, migration or re-encryption
+	FileAuditSliceMigrated     = "SliceMigrated"
+	FileAuditExpireTimeUpdated = "ExpireTimeUpdated"
+	FileAuditDeleted           = "Deleted"
+	FileAuditChallenged        = "Challenged"
+	FileAuditChallengeProved   = "ChallengeProved"
+	FileAuditChallengeFailed   = "ChallengeFailed"
+	FileAuditAuthApplied       = "AuthApplied"
+	FileAuditAuthApproved      = "AuthApproved"
+	FileAuditAuthRejected      = "AuthRejected"
+	FileAuditAuthRevoked       = "AuthRevoked"
+	FileAuditDownloaded        = "Downloaded"
+)
+
 // define variables about monitor module
 const (
 	FileRetainPeriod = 7 * 24 * time.Hour
+	// DeleteTimeSkew is how far scanners of deleted slices overlap their last scan, deletions are indexed
+	// by the time of delete requests, which is earlier than the time they are committed
+	DeleteTimeSkew = 5 * time.Second
 )
 
 // define variables about contract request
@@ -67,11 +117,12 @@ const (
 
 // PublicSliceMeta public, description of a slice stored on a specific node
 type PublicSliceMeta struct {
-	ID         string `json:"id"`         // slice ID
-	CipherHash []byte `json:"cipherHash"` // hash of cipher text
-	Length     uint64 `json:"length"`     // length of cipher text
-	NodeID     []byte `json:"nodeID"`     // where slice is stored
-	StorIndex  string `json:"storIndex"`  // storage index of slice, is used to query a slice from Storage, created by StorageNode
+	ID         string `json:"id"`               // slice ID
+	CipherHash []byte `json:"cipherHash"`       // hash of cipher text
+	Length     uint64 `json:"length"`           // length of cipher text
+	NodeID     []byte `json:"nodeID"`           // where slice is stored
+	StorIndex  string `json:"storIndex"`        // storage index of slice, is used to query a slice from Storage, created by StorageNode
+	Sealed     bool   `json:"sealed,omitempty"` // whether the cipher text is sealed for the node, see seal.Seal
 
 	// for pairing based challenge
 	SliceIdx int `json:"sliceIdx"` // slice index stored on this node, like 1,2,3...
@@ -81,6 +132,13 @@ type PublicSliceMeta struct {
 type PrivateSliceMeta struct {
 	SliceID   string `json:"sliceID"`   // slice ID
 	PlainHash []byte `json:"plainHash"` // hash of plain text
+
+	// for erasure coded files
+	Stripe int  `json:"stripe,omitempty"` // index of the stripe which the slice belongs to
+	Parity bool `json:"parity,omitempty"` // parity slice generated by Reed-Solomon code or not
+
+	// for deduplicated files, hash of the plaintext chunk which derives the key of the slice
+	ChunkHash []byte `json:"chunkHash,omitempty"`
 }
 
 type FileStructure []PrivateSliceMeta
@@ -107,6 +165,33 @@ type File struct {
 	PublishTime int64             `json:"publishTime"` // publish time on blockchain
 	ExpireTime  int64             `json:"expireTime"`  // file expire time
 
+	// for erasure coded files, the number of data slices and parity slices of each stripe
+	DataShards   int `json:"dataShards,omitempty"`
+	ParityShards int `json:"parityShards,omitempty"`
+
+	// time when the file was deleted by its owner, 0 means not deleted
+	DeleteTime int64 `json:"deleteTime,omitempty"`
+
+	// slices are cut by content and encrypted chunk by chunk, and may be shared with other files of the owner
+	Dedup bool `json:"dedup,omitempty"`
+
+	// content is encrypted chunk by chunk using AES-GCM before slicing, see engine/encryptor/chunk,
+	// otherwise the whole content is encrypted at once
+	Chunked bool `json:"chunked,omitempty"`
+
+	// version of the file among files with the same name in the namespace, starts from 1,
+	// 0 means the file was published before versioning and is regarded as version 1
+	Version int `json:"version,omitempty"`
+
+	// versions of the password which keys of the file are derived from, KeyVersion is for the structure,
+	// SliceKeyVersion is for slices and changes when slices are re-encrypted under a new password,
+	// 0 means the file was published before key versioning and is regarded as version 1
+	KeyVersion      int `json:"keyVersion,omitempty"`
+	SliceKeyVersion int `json:"sliceKeyVersion,omitempty"`
+
+	// content was encrypted in the client by a user-held key before uploading, and is opaque to the dataOwner node
+	ClientEncrypted bool `json:"clientEncrypted,omitempty"`
+
 	// for pairing based challenge
 	PdpPubkey []byte `json:"pdpPubkey"`
 	RandU     []byte `json:"randU"`
@@ -116,14 +201,57 @@ type File struct {
 	Ext []byte `json:"ext"`
 }
 
+// GetVersion returns version of the file, files published before versioning are regarded as version 1
+func (f File) GetVersion() int {
+	if f.Version == 0 {
+		return 1
+	}
+	return f.Version
+}
+
+// GetKeyVersion returns version of the password which the file structure is encrypted under
+func (f File) GetKeyVersion() int {
+	if f.KeyVersion == 0 {
+		return 1
+	}
+	return f.KeyVersion
+}
+
+// GetSliceKeyVersion returns version of the password which slices of the file are encrypted under
+func (f File) GetSliceKeyVersion() int {
+	if f.SliceKeyVersion == 0 {
+		return 1
+	}
+	return f.SliceKeyVersion
+}
+
 type FileH struct {
 	File   File   `json:"file"`
 	Health string `json:"health"`
 }
 
 type PublishFileOptions struct {
-	File      File   `json:"file"`
-	Signature []byte `json:"signature"`
+	File      File       `json:"file"`
+	SliceRefs []SliceRef `json:"sliceRefs,omitempty"` // deduplicated slices referenced by the file
+	Signature []byte     `json:"signature"`
+}
+
+// SliceRef records files referencing a deduplicated slice, a slice is stored once for
+// identical content of an owner, and is kept by storage nodes until no file references it
+type SliceRef struct {
+	SliceID    string   `json:"sliceID"`
+	Owner      []byte   `json:"owner,omitempty"`
+	SealedHash []byte   `json:"sealedHash"`      // hash of the chunk sealed by the key derived from its content
+	Files      []string `json:"files,omitempty"` // IDs of files referencing the slice
+	RefCount   int      `json:"refCount"`        // number of referencing files neither deleted nor cleared, counted on query
+}
+
+// GetSliceRefOptions used to query a deduplicated slice by SliceID, or by Owner and SealedHash
+type GetSliceRefOptions struct {
+	SliceID     string `json:"sliceID"`
+	Owner       []byte `json:"owner"`
+	SealedHash  []byte `json:"sealedHash"`
+	CurrentTime int64  `json:"currentTime"`
 }
 
 // Challenge public information stored on chain
@@ -141,6 +269,10 @@ type Challenge struct {
 	Round              int64    `json:"round"`              // challenge found
 	RandThisRound      []byte   `json:"randThisRound"`      // random number for the challenge
 
+	// Items is set for aggregated pairing based challenges covering many files on TargetNode,
+	//  then FileID, SliceIDs, SliceStorIndexes, Indices and Vs above are empty
+	Items []ChallengeItem `json:"items,omitempty"`
+
 	SliceID        string  `json:"sliceID"`
 	SliceStorIndex string  `json:"sliceStorIndex"` // storage index of slice, is used to query a slice from Storage
 	Ranges         []Range `json:"ranges"`
@@ -149,6 +281,41 @@ type Challenge struct {
 	Status        string `json:"status"`        // challenge status
 	ChallengeTime int64  `json:"challengeTime"` // challenge publish time
 	AnswerTime    int64  `json:"answerTime"`    // challenge answer time
+
+	// Deadline is set for time-bounded challenges, answers later than it fail,
+	//  so that a node can not fetch the replica from another node before answering
+	Deadline int64 `json:"deadline,omitempty"`
+}
+
+// ChallengeItem is the part of an aggregated pairing based challenge that covers one file
+type ChallengeItem struct {
+	FileID           string   `json:"fileID"`
+	SliceIDs         []string `json:"sliceIDs"`
+	SliceStorIndexes []string `json:"sliceStorIndexes"`
+	Indices          [][]byte `json:"indices"`
+	Vs               [][]byte `json:"vs"`
+}
+
+// FileIDs returns IDs of all files covered by the challenge
+func (c Challenge) FileIDs() []string {
+	if len(c.Items) == 0 {
+		return []string{c.FileID}
+	}
+	var ids []string
+	for _, item := range c.Items {
+		ids = append(ids, item.FileID)
+	}
+	return ids
+}
+
+// CoversFile checks whether the challenge covers the file
+func (c Challenge) CoversFile(fileID string) bool {
+	for _, id := range c.FileIDs() {
+		if id == fileID {
+			return true
+		}
+	}
+	return false
 }
 
 type ListFileOptions struct {
@@ -161,6 +328,14 @@ type ListFileOptions struct {
 	Limit       int64 `json:"limit"` // file number limit
 }
 
+// ListFileVersionsOptions lists all versions of a file in descending order, including deleted and expired ones
+type ListFileVersionsOptions struct {
+	Owner     []byte `json:"owner"`     // file owner
+	Namespace string `json:"namespace"` // file namespace
+	Name      string `json:"name"`      // file name
+	Limit     int64  `json:"limit"`     // version number limit
+}
+
 type ListChallengeOptions struct {
 	FileOwner  []byte `json:"fileOwner"`  // file owner
 	TargetNode []byte `json:"targetNode"` // storage node
@@ -189,6 +364,10 @@ type ChallengeRequestOptions struct {
 	Round         int64    `json:"round"`
 	RandThisRound []byte   `json:"randThisRound"`
 
+	Items []ChallengeItem `json:"items,omitempty"` // per-file parts of an aggregated pairing based challenge
+
+	Deadline int64 `json:"deadline,omitempty"` // answer deadline of time-bounded challenge, 0 means no deadline
+
 	SliceID        string  `json:"sliceID"`
 	SliceStorIndex string  `json:"sliceStorIndex"` // storage index of slice, is used to query a slice from Storage
 	Ranges         []Range `json:"ranges"`
@@ -197,6 +376,14 @@ type ChallengeRequestOptions struct {
 	Signature []byte `json:"signature"`
 }
 
+// ChallengeTimeoutOptions used for dataOwner nodes to mark time-bounded challenges not answered before deadline as failed
+type ChallengeTimeoutOptions struct {
+	ChallengeID string `json:"challengeID"`
+	CurrentTime int64  `json:"currentTime"`
+
+	Signature []byte `json:"signature"`
+}
+
 type Range struct {
 	Start uint64 `json:"start"`
 	End   uint64 `json:"end"`
@@ -223,6 +410,17 @@ type Node struct {
 	Online   bool   `json:"online"`   // whether node is online or offline
 	RegTime  int64  `json:"regTime"`  // node register time
 	UpdateAt int64  `json:"updateAt"` // node recent update time
+
+	Capacity uint64 `json:"capacity,omitempty"` // bytes offered by node for storing slices, 0 means unknown capacity or usage
+	Used     uint64 `json:"used,omitempty"`     // bytes occupied by slices stored on node
+	Zone     string `json:"zone,omitempty"`     // failure domain of node, such as a zone or rack label
+
+	// Draining node is being decommissioned, data owners move its slices to other nodes,
+	// and it is allowed to go offline only after no slices are left on it
+	Draining bool `json:"draining,omitempty"`
+
+	// HeartbeatCheckedAt is the time before which missed heartbeats of node have been penalized
+	HeartbeatCheckedAt int64 `json:"heartbeatCheckedAt,omitempty"`
 }
 
 type NodeH struct {
@@ -240,9 +438,10 @@ type AddNodeOptions struct {
 
 // NodeOperateOptions used to online or offline storage node
 type NodeOperateOptions struct {
-	NodeID    []byte `json:"nodeID"`
-	Nonce     int64  `json:"nonce"`
-	Signature []byte `json:"signature"`
+	NodeID      []byte `json:"nodeID"`
+	Nonce       int64  `json:"nonce"`
+	CurrentTime int64  `json:"currentTime,omitempty"` // used to count unexpired slices left on draining node
+	Signature   []byte `json:"signature"`
 }
 
 // NodeHeartBeatOptions define parameters for heartbeat detection of storage nodes
@@ -250,9 +449,20 @@ type NodeHeartBeatOptions struct {
 	NodeID        []byte `json:"nodeID"`
 	CurrentTime   int64  `json:"currentTime"`
 	BeginningTime int64  `json:"beginningTime"`
+	Capacity      uint64 `json:"capacity,omitempty"`
+	Used          uint64 `json:"used,omitempty"`
+	Zone          string `json:"zone,omitempty"`
 	Signature     []byte `json:"signature"`
 }
 
+// HeartbeatTimeoutOptions used for dataOwner nodes to penalize the heartbeats missed by storage nodes storing their files
+type HeartbeatTimeoutOptions struct {
+	NodeID      []byte `json:"nodeID"`
+	FileOwner   []byte `json:"fileOwner"`
+	CurrentTime int64  `json:"currentTime"`
+	Signature   []byte `json:"signature"`
+}
+
 // UpdateExptimeOptions used to update file's expireTime
 type UpdateExptimeOptions struct {
 	FileID        string `json:"fileID"`
@@ -261,13 +471,23 @@ type UpdateExptimeOptions struct {
 	Signature     []byte `json:"signature"`
 }
 
+// DeleteFileOptions used by dataOwner to delete a file before it expires
+type DeleteFileOptions struct {
+	FileID      string `json:"fileID"`
+	CurrentTime int64  `json:"currentTime"`
+	Signature   []byte `json:"signature"`
+}
+
 // UpdateFilePSMOptions used to update the slice public info on chain
 // when the dataOwner migrates slice from bad storage node to good storage node
 type UpdateFilePSMOptions struct {
-	FileID    string            `json:"fileID"`
-	Owner     []byte            `json:"owner"`
-	Slices    []PublicSliceMeta `json:"slices"`
-	Signature []byte            `json:"signature"`
+	FileID string            `json:"fileID"`
+	Owner  []byte            `json:"owner"`
+	Slices []PublicSliceMeta `json:"slices"`
+	// version of the password slices are encrypted under, 0 means unchanged
+	SliceKeyVersion int    `json:"sliceKeyVersion,omitempty"`
+	CurrentTime     int64  `json:"currentTime,omitempty"` // time of the update recorded in the audit trail of the file
+	Signature       []byte `json:"signature"`
 }
 
 // UpdateNsReplicaOptions used to update the replica on the blockchain
@@ -298,6 +518,89 @@ type ListNodeSliceOptions struct {
 
 type NodeSliceMigrateOptions ListNodeSliceOptions
 
+// Status of a slice found unhealthy by scrubbing on storage node
+const (
+	SliceScrubCorrupted = "Corrupted" // ciphertext of the slice mismatches the cipherHash on chain
+	SliceScrubMissing   = "Missing"   // slice is not found in storage
+)
+
+// SliceScrubReport is reported by storage node when a slice stored on it is found unhealthy by scrubbing,
+// the file owner migrates the slice to another node before the next challenge round.
+// The report is resolved once the slice is no longer stored on the node, e.g. migrated or deleted
+type SliceScrubReport struct {
+	NodeID      []byte `json:"nodeID"`
+	Owner       []byte `json:"owner"`
+	FileID      string `json:"fileID"`
+	SliceID     string `json:"sliceID"`
+	Status      string `json:"status"`
+	ScrubTime   int64  `json:"scrubTime"`
+	ResolveTime int64  `json:"resolveTime,omitempty"` // 0 means the slice is still to be migrated
+}
+
+// ReportSliceScrubOptions used by storage node to report a slice found unhealthy by scrubbing
+type ReportSliceScrubOptions struct {
+	NodeID      []byte `json:"nodeID"`
+	FileID      string `json:"fileID"`
+	SliceID     string `json:"sliceID"`
+	Status      string `json:"status"`
+	CurrentTime int64  `json:"currentTime"`
+	Signature   []byte `json:"signature"`
+}
+
+// ListSliceScrubReportsOptions used to list scrub reports of a storage node, or of the files of an owner
+type ListSliceScrubReportsOptions struct {
+	Target []byte `json:"target"` // storage node
+	Owner  []byte `json:"owner"`  // file owner
+
+	// list unresolved reports of the owner only, Owner is required
+	Unresolved bool `json:"unresolved,omitempty"`
+
+	StartTime int64 `json:"startTime"`
+	EndTime   int64 `json:"endTime"`
+	Limit     int64 `json:"limit"`
+}
+
+// ReputationPolicy defines how the reputation score of storage node changes, it is set when the contract is initialized
+type ReputationPolicy struct {
+	InitialScore     int64 `json:"initialScore"`     // score of a newly registered node
+	MaxScore         int64 `json:"maxScore"`         // upper bound of score, rewards beyond it are dropped
+	ExcludeThreshold int64 `json:"excludeThreshold"` // nodes with score below the threshold are excluded from storing new slices
+
+	ChallengeFailedPenalty int64 `json:"challengeFailedPenalty"`
+	HeartbeatMissedPenalty int64 `json:"heartbeatMissedPenalty"` // penalty for each missed heartbeat of an online node
+	SliceLostPenalty       int64 `json:"sliceLostPenalty"`       // penalty for each slice reported corrupted or missing
+	ChallengeProvedReward  int64 `json:"challengeProvedReward"`
+}
+
+// NodeReputation is the reputation of a storage node kept on chain
+type NodeReputation struct {
+	NodeID     []byte `json:"nodeID"`
+	Score      int64  `json:"score"`
+	Excluded   bool   `json:"excluded"` // whether score is below the ExcludeThreshold
+	UpdateTime int64  `json:"updateTime"`
+
+	History []ReputationEvent `json:"history,omitempty"` // filled by GetNodeReputation, the latest event comes first
+}
+
+// ReputationEvent is a change of node reputation score
+type ReputationEvent struct {
+	NodeID []byte `json:"nodeID"`
+	Type   string `json:"type"`
+	Delta  int64  `json:"delta"` // score changed by the event, negative for penalties
+	Score  int64  `json:"score"` // score after the event
+	Ref    string `json:"ref"`   // ID of the challenge or slice causing the event
+	Time   int64  `json:"time"`
+}
+
+// GetNodeReputationOptions used to query the reputation of a storage node with its history
+type GetNodeReputationOptions struct {
+	NodeID []byte `json:"nodeID"`
+
+	StartTime int64 `json:"startTime"`
+	EndTime   int64 `json:"endTime"`
+	Limit     int64 `json:"limit"` // max number of history events, no history is returned if it is 0
+}
+
 type AddNsOptions struct {
 	Namespace Namespace `json:"namespace"`
 	Signature []byte    `json:"signature"`
@@ -313,6 +616,17 @@ type Namespace struct {
 	FileTotalNum int64  `json:"fileTotalNum"`
 	CreateTime   int64  `json:"createTime"`
 	UpdateTime   int64  `json:"updateTime"`
+
+	// If DataShards is greater than 0, files under the namespace are erasure coded instead of replicated,
+	// every DataShards slices are grouped into a stripe with ParityShards Reed-Solomon parity slices,
+	// and each slice of a stripe is stored on a different node
+	DataShards   int `json:"dataShards,omitempty"`
+	ParityShards int `json:"parityShards,omitempty"`
+
+	// If Dedup is true, identical slices of files under the namespace are stored once, see SliceRef.
+	// Deduplicated slices are encrypted by keys derived from their content instead of the file key,
+	// so files under the namespace can not be shredded on deletion
+	Dedup bool `json:"dedup,omitempty"`
 }
 
 // NamespaceH used to list file's information under namespace
@@ -368,6 +682,10 @@ type FileAuthApplication struct {
 	ApprovalTime int64  `json:"approvalTime"` // time when authorizer confirmed or rejected the authorization
 	ExpireTime   int64  `json:"expireTime"`   // expiration time for file use
 
+	// reason and time of the revocation, only for revoked authorization
+	RevokeReason string `json:"revokeReason,omitempty"`
+	RevokeTime   int64  `json:"revokeTime,omitempty"`
+
 	// extension
 	Ext []byte `json:"ext"`
 }
@@ -391,6 +709,15 @@ type ConfirmFileAuthOptions struct {
 	Signature []byte `json:"signature"` // authorizer's signature
 }
 
+// RevokeFileAuthOptions parameters for authorizers to revoke the approved file authorization application
+type RevokeFileAuthOptions struct {
+	ID           string `json:"id"`
+	RevokeReason string `json:"revokeReason"`
+	CurrentTime  int64  `json:"currentTime"`
+
+	Signature []byte `json:"signature"` // authorizer's signature
+}
+
 // ListFileAuthOptions parameters for authorizers or appliers to query the list of file authorization application
 type ListFileAuthOptions struct {
 	Applier    []byte `json:"applier"`    // applier's public key
@@ -401,3 +728,45 @@ type ListFileAuthOptions struct {
 	TimeEnd    int64  `json:"timeEnd"`
 	Limit      int64  `json:"limit"` // limit number of applications in list request
 }
+
+// FileAuditEvent is an operation on a file recorded on chain, events of a file make up its audit trail
+type FileAuditEvent struct {
+	ID       string `json:"id"` // unique among events of the file, events are sorted by ID in time order
+	FileID   string `json:"fileID"`
+	Type     string `json:"type"`
+	Operator string `json:"operator"` // hex encoded public key of who signed the operation
+	Ref      string `json:"ref"`      // ID of the challenge, authorization application or slice the event refers to
+	Detail   string `json:"detail"`
+	Time     int64  `json:"time"`
+
+	// Digest is the hex encoded sha256 hash of the operation's signature, which is found in the transaction,
+	// TxID is the transaction the operation was submitted in, they are used to verify the event on chain
+	Digest string `json:"digest"`
+	TxID   string `json:"txID"`
+}
+
+// ListFileAuditEventsOptions used to list events in the audit trail of a file in time order
+type ListFileAuditEventsOptions struct {
+	FileID string `json:"fileID"`
+
+	StartTime int64 `json:"startTime"`
+	EndTime   int64 `json:"endTime"`
+	Limit     int64 `json:"limit"`
+}
+
+// SetFileAuditTxOptions used to fill in the transaction ID of audit events on chains where
+// contracts can not get the ID of the transaction they are running in
+type SetFileAuditTxOptions struct {
+	Digest string `json:"digest"` // see FileAuditEvent
+	TxID   string `json:"txID"`
+}
+
+// RecordFileDownloadOptions used for dataOwner nodes and authorized appliers to record a download of the file
+// in its audit trail, signed by who downloads the file
+type RecordFileDownloadOptions struct {
+	FileID      string `json:"fileID"`
+	User        []byte `json:"user"`             // public key of who downloads the file
+	AuthID      string `json:"authID,omitempty"` // approved authorization application of the applier, empty for the owner
+	CurrentTime int64  `json:"currentTime"`
+	Signature   []byte `json:"signature"`
+}
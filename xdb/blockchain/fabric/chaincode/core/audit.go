@@ -0,0 +1,143 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/hash"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+	util "github.com/PaddlePaddle/PaddleDTX/xdb/pkgs/strings"
+)
+
+// RecordFileDownload records a download of the file in its audit trail
+func (x *Xdata) RecordFileDownload(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) < 1 {
+		return shim.Error("invalid arguments. expecting RecordFileDownloadOptions")
+	}
+	var opt blockchain.RecordFileDownloadOptions
+	if err := json.Unmarshal([]byte(args[0]), &opt); err != nil {
+		return shim.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to unmarshal RecordFileDownloadOptions").Error())
+	}
+	f, err := x.getFileByID(stub, opt.FileID)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	// verify sig
+	msg, err := util.GetSigMessage(opt)
+	if err != nil {
+		return shim.Error(errorx.Internal(err, "failed to get the message to sign").Error())
+	}
+	if err := x.checkSign(opt.Signature, opt.User, []byte(msg)); err != nil {
+		return shim.Error(err.Error())
+	}
+	// appliers download the file by an approved authorization which is not expired
+	if !bytes.Equal(opt.User, f.Owner) {
+		fa, err := x.getFileAuthByID(stub, opt.AuthID)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		if fa.FileID != f.ID || !bytes.Equal(fa.Applier, opt.User) || fa.Status != blockchain.FileAuthApproved ||
+			fa.ExpireTime <= opt.CurrentTime {
+			return shim.Error(errorx.New(errorx.ErrCodeNotAuthorized, "download of the file is not authorized").Error())
+		}
+	}
+
+	e := blockchain.FileAuditEvent{
+		FileID:   f.ID,
+		Type:     blockchain.FileAuditDownloaded,
+		Operator: hex.EncodeToString(opt.User),
+		Ref:      opt.AuthID,
+		Time:     opt.CurrentTime,
+	}
+	if err := x.recordFileAudit(stub, e, opt.Signature); err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success([]byte("OK"))
+}
+
+// ListFileAuditEvents lists events in the audit trail of a file in time order
+func (x *Xdata) ListFileAuditEvents(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) < 1 {
+		return shim.Error("invalid arguments. expecting ListFileAuditEventsOptions")
+	}
+	var opt blockchain.ListFileAuditEventsOptions
+	if err := json.Unmarshal([]byte(args[0]), &opt); err != nil {
+		return shim.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to unmarshal ListFileAuditEventsOptions").Error())
+	}
+	if opt.StartTime < 0 || (opt.EndTime > 0 && opt.EndTime < opt.StartTime) {
+		return shim.Error(errorx.New(errorx.ErrCodeParam, "bad param").Error())
+	}
+	if _, err := x.getFileByID(stub, opt.FileID); err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// iterate events, the earliest event comes first
+	prefix, attr := packFileAuditFilter(opt.FileID)
+	iterator, err := stub.GetStateByPartialCompositeKey(prefix, attr)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	defer iterator.Close()
+
+	var events []blockchain.FileAuditEvent
+	for iterator.HasNext() {
+		if opt.Limit > 0 && int64(len(events)) >= opt.Limit {
+			break
+		}
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		var e blockchain.FileAuditEvent
+		if err := json.Unmarshal(queryResponse.Value, &e); err != nil {
+			return shim.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+				"failed to unmarshal FileAuditEvent").Error())
+		}
+		if e.Time < opt.StartTime || (opt.EndTime > 0 && e.Time > opt.EndTime) {
+			continue
+		}
+		events = append(events, e)
+	}
+	b, err := json.Marshal(events)
+	if err != nil {
+		return shim.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to marshal FileAuditEvents").Error())
+	}
+	return shim.Success(b)
+}
+
+// recordFileAudit records an event in the audit trail of the file with the current transaction ID,
+// sig is the signature of the operation causing the event
+func (x *Xdata) recordFileAudit(stub shim.ChaincodeStubInterface, e blockchain.FileAuditEvent, sig []byte) error {
+	e.Digest = hex.EncodeToString(hash.HashUsingSha256(sig))
+	e.ID = packFileAuditEventID(e)
+	e.TxID = stub.GetTxID()
+	s, err := json.Marshal(e)
+	if err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal FileAuditEvent")
+	}
+	if resp := x.SetValue(stub, []string{packFileAuditIndex(e.FileID, e.ID), string(s)}); resp.Status == shim.ERROR {
+		return errorx.New(errorx.ErrCodeWriteBlockchain, "failed to put index-auditEvent on chain: %s", resp.Message)
+	}
+	return nil
+}
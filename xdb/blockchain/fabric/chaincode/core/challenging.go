@@ -82,7 +82,7 @@ func (x *Xdata) ListChallengeRequests(stub shim.ChaincodeStubInterface, args []s
 		if c.Status != opt.Status || c.ChallengeTime < opt.TimeStart || c.ChallengeTime > opt.TimeEnd {
 			continue
 		}
-		if len(opt.FileID) != 0 && c.FileID != opt.FileID {
+		if len(opt.FileID) != 0 && !c.CoversFile(opt.FileID) {
 			continue
 		}
 		cs = append(cs, c)
@@ -124,6 +124,26 @@ func (x *Xdata) ChallengeRequest(stub shim.ChaincodeStubInterface, args []string
 	if err != nil {
 		return shim.Error(err.Error())
 	}
+	// deleted files are no longer challenged
+	if f, err := x.getFileByID(stub, opt.FileID); err == nil && f.DeleteTime > 0 {
+		return shim.Error(errorx.New(errorx.ErrCodeParam, "bad param, file already deleted").Error())
+	}
+	if opt.Deadline != 0 && opt.Deadline-opt.ChallengeTime < int64(blockchain.MinAnswerWindow) {
+		return shim.Error(errorx.New(errorx.ErrCodeParam,
+			"bad param, deadline should be %s later than challenge time at least", blockchain.MinAnswerWindow).Error())
+	}
+	// time-bounded challenges can't start before the latest heartbeat of the target node,
+	// so that the file owner can't shorten the time to answer by a challenge time in the past
+	if opt.Deadline != 0 {
+		node, err := x.getNode(stub, opt.TargetNode)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		if opt.ChallengeTime < node.UpdateAt {
+			return shim.Error(errorx.New(errorx.ErrCodeParam,
+				"bad param, challenge time earlier than the latest heartbeat of target node").Error())
+		}
+	}
 
 	// make challenge
 	c := blockchain.Challenge{
@@ -134,6 +154,7 @@ func (x *Xdata) ChallengeRequest(stub shim.ChaincodeStubInterface, args []string
 		Status:             blockchain.ChallengeToProve,
 		ChallengeTime:      opt.ChallengeTime,
 		ChallengeAlgorithm: opt.ChallengeAlgorithm,
+		Deadline:           opt.Deadline,
 	}
 
 	if opt.ChallengeAlgorithm == types.PairingChallengeAlgorithm {
@@ -143,6 +164,12 @@ func (x *Xdata) ChallengeRequest(stub shim.ChaincodeStubInterface, args []string
 		c.Round = opt.Round
 		c.RandThisRound = opt.RandThisRound
 		c.Vs = opt.Vs
+		if len(opt.Items) > 0 {
+			if err := x.checkChallengeItems(stub, &opt); err != nil {
+				return shim.Error(err.Error())
+			}
+			c.Items = opt.Items
+		}
 
 	} else if opt.ChallengeAlgorithm == types.MerkleChallengeAlgorithm {
 		c.SliceID = opt.SliceID
@@ -176,6 +203,19 @@ func (x *Xdata) ChallengeRequest(stub shim.ChaincodeStubInterface, args []string
 		return shim.Error(errorx.New(errorx.ErrCodeWriteBlockchain,
 			"failed to set index4Target-ChallengeID on chain: %s", resp.Message).Error())
 	}
+	for _, fileID := range c.FileIDs() {
+		e := blockchain.FileAuditEvent{
+			FileID:   fileID,
+			Type:     blockchain.FileAuditChallenged,
+			Operator: hex.EncodeToString(c.FileOwner),
+			Ref:      c.ID,
+			Detail:   fmt.Sprintf("%s challenge to node %s", c.ChallengeAlgorithm, c.TargetNode),
+			Time:     c.ChallengeTime,
+		}
+		if err := x.recordFileAudit(stub, e, opt.Signature); err != nil {
+			return shim.Error(err.Error())
+		}
+	}
 
 	return shim.Success([]byte("requested"))
 }
@@ -205,10 +245,15 @@ func (x *Xdata) ChallengeAnswer(stub shim.ChaincodeStubInterface, args []string)
 		return shim.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
 			"failed to unmarshal Challenge").Error())
 	}
-	if c.Status == blockchain.ChallengeProved || c.Status == blockchain.ChallengeFailed {
+	if c.Status == blockchain.ChallengeProved {
 		return shim.Error(errorx.New(errorx.ErrCodeAlreadyExists,
 			"challenge already answered").Error())
 	}
+	// answers are rejected once the challenge failed, including the timeout recorded by the file owner
+	if c.Status == blockchain.ChallengeFailed {
+		return shim.Error(errorx.New(errorx.ErrCodeAlreadyExists,
+			"challenge already failed or timed out").Error())
+	}
 
 	// verify signature
 	msg, err := util.GetSigMessage(opt)
@@ -224,8 +269,8 @@ func (x *Xdata) ChallengeAnswer(stub shim.ChaincodeStubInterface, args []string)
 		return shim.Error(err.Error())
 	}
 
-	// judge if file exists
-	resp = x.GetValue(stub, []string{c.FileID})
+	// judge if file exists, files covered by an aggregated challenge share pairing params with the first one
+	resp = x.GetValue(stub, []string{c.FileIDs()[0]})
 	if len(resp.Payload) == 0 {
 		return shim.Error(errorx.New(errorx.ErrCodeNotFound, "File not found: %s", resp.Message).Error())
 	}
@@ -238,11 +283,28 @@ func (x *Xdata) ChallengeAnswer(stub shim.ChaincodeStubInterface, args []string)
 	c.AnswerTime = opt.AnswerTime
 	c.Status = blockchain.ChallengeProved
 
-	// sig verification
+	// sig verification, answers of time-bounded challenges fail without verification once the node
+	// sent a heartbeat after the deadline, the answer time signed by the node is not trusted for lateness
 	var verifyErr error
-	if c.ChallengeAlgorithm == types.PairingChallengeAlgorithm {
-		// verify pairing based challenge
-		v, err := xchainClient.VerifyPairingProof(opt.Sigma, opt.Mu, file.RandV, file.RandU, file.PdpPubkey, c.Indices, c.Vs)
+	node, err := x.getNode(stub, c.TargetNode)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	late := c.Deadline > 0 && node.UpdateAt > c.Deadline
+	if late {
+		verifyErr = errorx.New(errorx.ErrCodeExpired, "answer deadline missed")
+		c.Status = blockchain.ChallengeFailed
+	} else if c.ChallengeAlgorithm == types.PairingChallengeAlgorithm {
+		// verify pairing based challenge, an aggregated challenge is verified with one proof over all its files
+		indices, vs := c.Indices, c.Vs
+		if len(c.Items) > 0 {
+			indices, vs = nil, nil
+			for _, item := range c.Items {
+				indices = append(indices, item.Indices...)
+				vs = append(vs, item.Vs...)
+			}
+		}
+		v, err := xchainClient.VerifyPairingProof(opt.Sigma, opt.Mu, file.RandV, file.RandU, file.PdpPubkey, indices, vs)
 		if err != nil || !v {
 			e := fmt.Errorf("verify pairing based challenge proof failed: %v", err)
 			verifyErr = errorx.NewCode(e, errorx.ErrCodeCrypto, "verification failed")
@@ -281,12 +343,155 @@ func (x *Xdata) ChallengeAnswer(stub shim.ChaincodeStubInterface, args []string)
 			"failed to update ChallengeID-Challenge on chain: %s", resp.Message).Error())
 	}
 
+	// reward or penalize the target node, late answers are penalized as well
+	eventType := blockchain.ReputationChallengeProved
+	if c.Status == blockchain.ChallengeFailed {
+		eventType = blockchain.ReputationChallengeFailed
+	}
+	if err := x.updateNodeReputation(stub, c.TargetNode, eventType, c.ID, opt.AnswerTime, 1); err != nil {
+		return shim.Error(err.Error())
+	}
+	if err := x.recordChallengeResult(stub, c, opt.AnswerTime, opt.Signature, verifyErr); err != nil {
+		return shim.Error(err.Error())
+	}
+
 	if c.Status == blockchain.ChallengeProved {
 		return shim.Success([]byte("answered"))
 	}
 	return shim.Success([]byte(verifyErr.Error()))
 }
 
+// ChallengeTimeout marks a time-bounded challenge not answered before its deadline as failed and penalizes
+// the target node, the deadline must have passed by the latest heartbeat of the node as well
+// args = {ChallengeTimeoutOptions}
+func (x *Xdata) ChallengeTimeout(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) < 1 {
+		return shim.Error("incorrect arguments. expecting ChallengeTimeoutOptions")
+	}
+
+	var opt blockchain.ChallengeTimeoutOptions
+	// unmarshal opt
+	if err := json.Unmarshal([]byte(args[0]), &opt); err != nil {
+		return shim.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to unmarshal ChallengeTimeoutOptions").Error())
+	}
+	// judge if challenge exists
+	index := packChallengeIndex(opt.ChallengeID)
+	resp := x.GetValue(stub, []string{index})
+	if len(resp.Payload) == 0 {
+		return shim.Error(errorx.New(errorx.ErrCodeNotFound, "Challenge not found: %s", resp.Message).Error())
+	}
+	var c blockchain.Challenge
+	if err := json.Unmarshal(resp.Payload, &c); err != nil {
+		return shim.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to unmarshal Challenge").Error())
+	}
+	if c.Status != blockchain.ChallengeToProve {
+		return shim.Error(errorx.New(errorx.ErrCodeAlreadyExists, "challenge already answered").Error())
+	}
+	if c.Deadline == 0 || opt.CurrentTime <= c.Deadline {
+		return shim.Error(errorx.New(errorx.ErrCodeParam, "bad param, challenge deadline not reached").Error())
+	}
+
+	// verify signature
+	msg, err := util.GetSigMessage(opt)
+	if err != nil {
+		return shim.Error(errorx.Internal(err, "failed to get the message to sign").Error())
+	}
+	if err = x.checkSign(opt.Signature, c.FileOwner, []byte(msg)); err != nil {
+		return shim.Error(err.Error())
+	}
+	// the time signed by the file owner is not trusted alone, or it could fail the challenge early
+	node, err := x.getNode(stub, c.TargetNode)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if node.UpdateAt <= c.Deadline {
+		return shim.Error(errorx.New(errorx.ErrCodeParam, "bad param, no heartbeat of target node after deadline").Error())
+	}
+
+	c.Status = blockchain.ChallengeFailed
+	s, err := json.Marshal(c)
+	if err != nil {
+		return shim.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to marshal Challenge").Error())
+	}
+	if resp := x.SetValue(stub, []string{index, string(s)}); resp.Status == shim.ERROR {
+		return shim.Error(errorx.New(errorx.ErrCodeWriteBlockchain,
+			"failed to update ChallengeID-Challenge on chain: %s", resp.Message).Error())
+	}
+	if err := x.updateNodeReputation(stub, c.TargetNode, blockchain.ReputationChallengeFailed,
+		c.ID, opt.CurrentTime, 1); err != nil {
+		return shim.Error(err.Error())
+	}
+	if err := x.recordChallengeResult(stub, c, opt.CurrentTime, opt.Signature,
+		errorx.New(errorx.ErrCodeExpired, "answer deadline missed")); err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success([]byte("OK"))
+}
+
+// recordChallengeResult records the result of a challenge in the audit trail of files it covers
+func (x *Xdata) recordChallengeResult(stub shim.ChaincodeStubInterface, c blockchain.Challenge, ctime int64,
+	sig []byte, verifyErr error) error {
+	e := blockchain.FileAuditEvent{
+		Type:     blockchain.FileAuditChallengeProved,
+		Operator: string(c.TargetNode),
+		Ref:      c.ID,
+		Time:     ctime,
+	}
+	if c.Status == blockchain.ChallengeFailed {
+		e.Type = blockchain.FileAuditChallengeFailed
+		e.Detail = verifyErr.Error()
+	}
+	for _, fileID := range c.FileIDs() {
+		e.FileID = fileID
+		if err := x.recordFileAudit(stub, e, sig); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkChallengeItems checks files covered by an aggregated challenge, they must be alive,
+//  belong to the challenge owner and share pairing params so that one proof answers all of them
+func (x *Xdata) checkChallengeItems(stub shim.ChaincodeStubInterface, opt *blockchain.ChallengeRequestOptions) error {
+	if len(opt.FileID) != 0 || len(opt.SliceIDs) != 0 {
+		return errorx.New(errorx.ErrCodeParam, "bad param, aggregated challenge with fileID or sliceIDs")
+	}
+	var first blockchain.File
+	fileIDs := make(map[string]struct{})
+	for i, item := range opt.Items {
+		if len(item.Indices) == 0 || len(item.Indices) != len(item.Vs) || len(item.Indices) != len(item.SliceIDs) ||
+			len(item.SliceIDs) != len(item.SliceStorIndexes) {
+			return errorx.New(errorx.ErrCodeParam, "bad param, invalid challenge for file %s", item.FileID)
+		}
+		if _, exist := fileIDs[item.FileID]; exist {
+			return errorx.New(errorx.ErrCodeParam, "bad param, duplicated file %s", item.FileID)
+		}
+		fileIDs[item.FileID] = struct{}{}
+
+		f, err := x.getFileByID(stub, item.FileID)
+		if err != nil {
+			return err
+		}
+		if f.DeleteTime > 0 {
+			return errorx.New(errorx.ErrCodeParam, "bad param, file %s already deleted", item.FileID)
+		}
+		if !bytes.Equal(f.Owner, opt.FileOwner) {
+			return errorx.New(errorx.ErrCodeParam, "bad param, file %s not owned by challenger", item.FileID)
+		}
+		if i == 0 {
+			first = f
+			continue
+		}
+		if !bytes.Equal(f.RandU, first.RandU) || !bytes.Equal(f.RandV, first.RandV) || !bytes.Equal(f.PdpPubkey, first.PdpPubkey) {
+			return errorx.New(errorx.ErrCodeParam, "bad param, file %s has different pairing params", item.FileID)
+		}
+	}
+	return nil
+}
+
 // GetChallengeByID query challenge result
 // args = {id}
 func (x *Xdata) GetChallengeByID(stub shim.ChaincodeStubInterface, args []string) pb.Response {
@@ -14,7 +14,9 @@
 package core
 
 import (
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 	pb "github.com/hyperledger/fabric/protos/peer"
@@ -95,6 +97,16 @@ func (x *Xdata) PublishFileAuthApplication(stub shim.ChaincodeStubInterface, arg
 		return shim.Error(errorx.New(errorx.ErrCodeWriteBlockchain,
 			"failed to set index_fileauth_list_applier_authorizer on chain: %s", resp.Message).Error())
 	}
+	e := blockchain.FileAuditEvent{
+		FileID:   fa.FileID,
+		Type:     blockchain.FileAuditAuthApplied,
+		Operator: hex.EncodeToString(fa.Applier),
+		Ref:      fa.ID,
+		Time:     fa.CreateTime,
+	}
+	if err := x.recordFileAudit(stub, e, opt.Signature); err != nil {
+		return shim.Error(err.Error())
+	}
 	return shim.Success([]byte("OK"))
 }
 
@@ -161,6 +173,89 @@ func (x *Xdata) setFileAuthConfirmStatus(stub shim.ChaincodeStubInterface, args
 		return shim.Error(errorx.New(errorx.ErrCodeWriteBlockchain,
 			"failed to confirm index_fileauth on chain: %s", resp.Message).Error())
 	}
+	e := blockchain.FileAuditEvent{
+		FileID:   fa.FileID,
+		Type:     blockchain.FileAuditAuthApproved,
+		Operator: hex.EncodeToString(fa.Authorizer),
+		Ref:      fa.ID,
+		Detail:   fmt.Sprintf("applier %x, expire time %d", fa.Applier, fa.ExpireTime),
+		Time:     opt.CurrentTime,
+	}
+	if !isConfirm {
+		e.Type = blockchain.FileAuditAuthRejected
+		e.Detail = fmt.Sprintf("applier %x, reason: %s", fa.Applier, fa.RejectReason)
+	}
+	if err := x.recordFileAudit(stub, e, opt.Signature); err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte("OK"))
+}
+
+// RevokeFileAuth is called when the dataOwner node revokes the approved file's authorization,
+// the authorization key is removed and appliers can no longer use the file
+func (x *Xdata) RevokeFileAuth(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	// get opt
+	if len(args) < 1 {
+		return shim.Error("invalid arguments. expecting RevokeFileAuthOptions")
+	}
+
+	// unmarshal opt
+	var opt blockchain.RevokeFileAuthOptions
+	if err := json.Unmarshal([]byte(args[0]), &opt); err != nil {
+		return shim.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to unmarshal RevokeFileAuthOptions").Error())
+	}
+
+	// query authorization application detail by authID
+	fa, err := x.getFileAuthByID(stub, opt.ID)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	// verify signature by authorizer's public key
+	msg, err := util.GetSigMessage(opt)
+	if err != nil {
+		return shim.Error(errorx.Internal(err, "failed to get the message to sign").Error())
+	}
+	if err := x.checkSign(opt.Signature, fa.Authorizer, []byte(msg)); err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// check status
+	if fa.Status != blockchain.FileAuthApproved {
+		return shim.Error(errorx.New(errorx.ErrCodeParam,
+			"revoke file auth error, fileAuthStatus is not Approved, authID: %s, fileAuthStatus: %s", fa.ID, fa.Status).Error())
+	}
+	if fa.ExpireTime <= opt.CurrentTime {
+		return shim.Error(errorx.New(errorx.ErrCodeParam,
+			"revoke file auth error, authorization already expired, authID: %s", fa.ID).Error())
+	}
+	// update authorization status
+	fa.Status = blockchain.FileAuthRevoked
+	fa.RevokeReason = opt.RevokeReason
+	fa.RevokeTime = opt.CurrentTime
+	fa.AuthKey = nil
+	s, err := json.Marshal(fa)
+	if err != nil {
+		return shim.Error(errorx.NewCode(err, errorx.ErrCodeInternal, "fail to marshal FileAuthApplication").Error())
+	}
+	// update index_fileauth on chain
+	index := packFileAuthIndex(fa.ID)
+	if resp := x.SetValue(stub, []string{index, string(s)}); resp.Status == shim.ERROR {
+		return shim.Error(errorx.New(errorx.ErrCodeWriteBlockchain,
+			"failed to revoke index_fileauth on chain: %s", resp.Message).Error())
+	}
+	e := blockchain.FileAuditEvent{
+		FileID:   fa.FileID,
+		Type:     blockchain.FileAuditAuthRevoked,
+		Operator: hex.EncodeToString(fa.Authorizer),
+		Ref:      fa.ID,
+		Detail:   fmt.Sprintf("applier %x, reason: %s", fa.Applier, fa.RevokeReason),
+		Time:     opt.CurrentTime,
+	}
+	if err := x.recordFileAudit(stub, e, opt.Signature); err != nil {
+		return shim.Error(err.Error())
+	}
 
 	return shim.Success([]byte("OK"))
 }
@@ -14,7 +14,9 @@
 package core
 
 import (
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"strconv"
 	"strings"
 
@@ -76,17 +78,26 @@ func (x *Xdata) PublishFile(stub shim.ChaincodeStubInterface, args []string) pb.
 			"failed to unmarshal namespace").Error())
 	}
 
-	// if there's a expired file with the same name in user's storage, overwrite it with the new one
+	// publishing a file with an existing name creates a new version of it
 	filenameIndex := packFileNameIndex(f.Owner, f.Namespace, f.Name)
-
+	version := 1
 	if resp := x.GetValue(stub, []string{filenameIndex}); len(resp.Payload) != 0 {
 		fc, err := x.getFileByID(stub, string(resp.Payload))
 		if err != nil {
 			return shim.Error(err.Error())
 		}
-		if fc.ExpireTime+blockchain.FileRetainPeriod.Nanoseconds() > opt.File.PublishTime {
-			return shim.Error(errorx.New(errorx.ErrCodeAlreadyExists, "duplicated file name").Error())
+		// files published before versioning have no version index
+		if fc.Version == 0 {
+			versionIndex := packFileVersionIndex(fc.Owner, fc.Namespace, fc.Name, fc.GetVersion())
+			if resp := x.SetValue(stub, []string{versionIndex, fc.ID}); resp.Status == shim.ERROR {
+				return shim.Error(errorx.New(errorx.ErrCodeWriteBlockchain,
+					"failed to set versionIndex-id on chain: %s", resp.Message).Error())
+			}
 		}
+		version = fc.GetVersion() + 1
+	}
+	if f.Version != version {
+		return shim.Error(errorx.New(errorx.ErrCodeAlreadyExists, "file version conflicts, expected version: %d", version).Error())
 	}
 
 	// marshal file
@@ -105,6 +116,12 @@ func (x *Xdata) PublishFile(stub shim.ChaincodeStubInterface, args []string) pb.
 		return shim.Error(errorx.New(errorx.ErrCodeWriteBlockchain,
 			"failed to set index-id on chain: %s", resp.Message).Error())
 	}
+	// set versionIndex-id on chain
+	versionIndex := packFileVersionIndex(f.Owner, f.Namespace, f.Name, f.Version)
+	if resp := x.SetValue(stub, []string{versionIndex, f.ID}); resp.Status == shim.ERROR {
+		return shim.Error(errorx.New(errorx.ErrCodeWriteBlockchain,
+			"failed to set versionIndex-id on chain: %s", resp.Message).Error())
+	}
 	// set filenameListIndex-id on chain
 	filenameListIndex := packFileListByOwnerIndex(f.Owner, f.Namespace, f.Name, f.PublishTime)
 	if resp := x.SetValue(stub, []string{filenameListIndex, f.ID}); resp.Status == shim.ERROR {
@@ -137,16 +154,22 @@ func (x *Xdata) PublishFile(stub shim.ChaincodeStubInterface, args []string) pb.
 	}
 
 	// set node-sliceID-expireTime on chain
-	nodeSlice := make(map[string][]string)
-	for _, slice := range f.Slices {
-		nodeSlice[string(slice.NodeID)] = append(nodeSlice[string(slice.NodeID)], slice.ID+":"+slice.StorIndex)
+	if err := x.putNodeSliceIndexes(stub, f); err != nil {
+		return shim.Error(err.Error())
 	}
-	for nodeID, sliceL := range nodeSlice {
-		prefixNodeFileSlice := packNodeSliceIndex(nodeID, f)
-		if resp := x.SetValue(stub, []string{prefixNodeFileSlice, strings.Join(sliceL, ",")}); resp.Status == shim.ERROR {
-			return shim.Error(errorx.New(errorx.ErrCodeWriteBlockchain,
-				"failed to set index-id on chain: %s", resp.Message).Error())
-		}
+	// set sliceref of deduplicated slices on chain
+	if err := x.addSliceRefs(stub, f, opt.SliceRefs); err != nil {
+		return shim.Error(err.Error())
+	}
+	e := blockchain.FileAuditEvent{
+		FileID:   f.ID,
+		Type:     blockchain.FileAuditPublished,
+		Operator: hex.EncodeToString(f.Owner),
+		Detail:   fmt.Sprintf("version %d, %d slices", f.Version, len(f.Slices)),
+		Time:     f.PublishTime,
+	}
+	if err := x.recordFileAudit(stub, e, opt.Signature); err != nil {
+		return shim.Error(err.Error())
 	}
 
 	return shim.Success([]byte("Published"))
@@ -295,8 +318,23 @@ func (x *Xdata) UpdateFilePublicSliceMeta(stub shim.ChaincodeStubInterface, args
 		return shim.Error(errorx.New(errorx.ErrCodeNotAuthorized, "bad param, file owner is wrong").Error())
 	}
 
+	// slices encrypted under an older key version are not allowed to overwrite the re-encrypted ones
+	if opt.SliceKeyVersion > 0 && opt.SliceKeyVersion < f.GetSliceKeyVersion() {
+		return shim.Error(errorx.New(errorx.ErrCodeParam,
+			"slices of the file are already encrypted under a newer key version").Error())
+	}
+
 	// update slices
+	removed := removedSlices(f.Slices, opt.Slices)
+	if f.DeleteTime == 0 {
+		if err := x.deleteNodeSliceIndexes(stub, f); err != nil {
+			return shim.Error(err.Error())
+		}
+	}
 	f.Slices = opt.Slices
+	if opt.SliceKeyVersion > f.SliceKeyVersion {
+		f.SliceKeyVersion = opt.SliceKeyVersion
+	}
 	nfs, err := json.Marshal(f)
 	if err != nil {
 		return shim.Error(errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal Namespaces").Error())
@@ -306,13 +344,33 @@ func (x *Xdata) UpdateFilePublicSliceMeta(stub shim.ChaincodeStubInterface, args
 		return shim.Error(errorx.New(errorx.ErrCodeWriteBlockchain,
 			"failed to set id-file on chain: %s", resp.Message).Error())
 	}
+	// storage nodes find slices to scrub and to clear by node-slice indexes, so they follow migrated slices
+	if f.DeleteTime == 0 {
+		if err := x.putNodeSliceIndexes(stub, f); err != nil {
+			return shim.Error(err.Error())
+		}
+	}
+	// slices moved away from nodes are no longer to be migrated for their scrub reports
+	if err := x.resolveSliceScrubs(stub, removed, opt.CurrentTime); err != nil {
+		return shim.Error(err.Error())
+	}
+	e := blockchain.FileAuditEvent{
+		FileID:   f.ID,
+		Type:     blockchain.FileAuditSlicesUpdated,
+		Operator: hex.EncodeToString(f.Owner),
+		Detail:   fmt.Sprintf("%d slices, slice key version %d", len(f.Slices), f.GetSliceKeyVersion()),
+		Time:     opt.CurrentTime,
+	}
+	if err := x.recordFileAudit(stub, e, opt.Signature); err != nil {
+		return shim.Error(err.Error())
+	}
 	return shim.Success([]byte("OK"))
 }
 
 // GetFileByName gets file by name from fabric
 func (x *Xdata) GetFileByName(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 	if len(args) < 4 {
-		return shim.Error("invalid arguments. expecting owner, ns, name and currentTime")
+		return shim.Error("invalid arguments. expecting owner, ns, name, currentTime and optional version")
 	}
 
 	// get owner
@@ -333,8 +391,16 @@ func (x *Xdata) GetFileByName(stub shim.ChaincodeStubInterface, args []string) p
 		return shim.Error(errorx.New(errorx.ErrCodeParam, "bad param, ns not found: %s", resp.Message).Error())
 	}
 
-	// pack fileNameIndex
+	// pack fileNameIndex, the latest version is returned if version is not specified
 	index := packFileNameIndex(owner, ns, name)
+	versioned := len(args) > 4
+	if versioned {
+		version, err := strconv.Atoi(args[4])
+		if err != nil || version <= 0 {
+			return shim.Error(errorx.New(errorx.ErrCodeParam, "bad param:version").Error())
+		}
+		index = x.getFileVersionIndex(stub, owner, ns, name, version)
+	}
 	//get id from index
 	resp := x.GetValue(stub, []string{index})
 	if len(resp.Payload) == 0 {
@@ -351,10 +417,23 @@ func (x *Xdata) GetFileByName(stub shim.ChaincodeStubInterface, args []string) p
 		return shim.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
 			"failed to unmarshal File").Error())
 	}
+	s := resp.Payload
+	// the latest version not deleted is returned if the latest one was deleted
+	if f.DeleteTime > 0 && !versioned {
+		if f, err = x.getLatestUndeletedFile(stub, owner, ns, name); err != nil {
+			return shim.Error(err.Error())
+		}
+		if s, err = json.Marshal(f); err != nil {
+			return shim.Error(errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal File").Error())
+		}
+	}
+	if f.DeleteTime > 0 {
+		return shim.Error(errorx.New(errorx.ErrCodeNotFound, "file already deleted").Error())
+	}
 	if f.ExpireTime < ctime {
 		return shim.Error(errorx.New(errorx.ErrCodeNotFound, "file already expire").Error())
 	}
-	return shim.Success(resp.Payload)
+	return shim.Success(s)
 }
 
 // GetFileByID gets file by id from fabric
@@ -382,6 +461,9 @@ func (x *Xdata) GetFileByID(stub shim.ChaincodeStubInterface, args []string) pb.
 		return shim.Error(errorx.NewCode(err, errorx.ErrCodeInternal, "failed to unmarshal File").Error())
 	}
 
+	if f.DeleteTime > 0 {
+		return shim.Error(errorx.New(errorx.ErrCodeNotFound, "file already deleted").Error())
+	}
 	if f.ExpireTime < ctime {
 		return shim.Error(errorx.New(errorx.ErrCodeExpired, "file already expire").Error())
 	}
@@ -406,6 +488,9 @@ func (x *Xdata) UpdateFileExpireTime(stub shim.ChaincodeStubInterface, args []st
 		return shim.Error(err.Error())
 	}
 
+	if f.DeleteTime > 0 {
+		return shim.Error(errorx.New(errorx.ErrCodeNotFound, "file already deleted").Error())
+	}
 	if f.ExpireTime+blockchain.FileRetainPeriod.Nanoseconds() <= opt.CurrentTime {
 		return shim.Error(errorx.New(errorx.ErrCodeParam, "file already expired over 7 days").Error())
 	}
@@ -423,6 +508,10 @@ func (x *Xdata) UpdateFileExpireTime(stub shim.ChaincodeStubInterface, args []st
 		return shim.Error(err.Error())
 	}
 
+	// node-slice indexes are sorted by expire time
+	if err := x.deleteNodeSliceIndexes(stub, f); err != nil {
+		return shim.Error(err.Error())
+	}
 	// marshal file
 	f.ExpireTime = opt.NewExpireTime
 	nf, err := json.Marshal(f)
@@ -434,6 +523,95 @@ func (x *Xdata) UpdateFileExpireTime(stub shim.ChaincodeStubInterface, args []st
 		return shim.Error(errorx.New(errorx.ErrCodeWriteBlockchain,
 			"failed to set id-file on chain: %s", resp.Message).Error())
 	}
+	if err := x.putNodeSliceIndexes(stub, f); err != nil {
+		return shim.Error(err.Error())
+	}
+	e := blockchain.FileAuditEvent{
+		FileID:   f.ID,
+		Type:     blockchain.FileAuditExpireTimeUpdated,
+		Operator: hex.EncodeToString(f.Owner),
+		Detail:   fmt.Sprintf("new expire time %d", f.ExpireTime),
+		Time:     opt.CurrentTime,
+	}
+	if err := x.recordFileAudit(stub, e, opt.Signature); err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success(nf)
+}
+
+// DeleteFile marks a file as deleted before it expires,
+// and moves its slices to the deleted slice index of storage nodes, so that they can be cleared right away
+func (x *Xdata) DeleteFile(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) < 1 {
+		return shim.Error("invalid arguments. expecting DeleteFileOptions")
+	}
+
+	// unmarshal opt
+	var opt blockchain.DeleteFileOptions
+	if err := json.Unmarshal([]byte(args[0]), &opt); err != nil {
+		return shim.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to unmarshal DeleteFileOptions").Error())
+	}
+	//get file from id
+	f, err := x.getFileByID(stub, opt.FileID)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// verify sig
+	msg, err := util.GetSigMessage(opt)
+	if err != nil {
+		return shim.Error(errorx.Internal(err, "failed to get the message to sign").Error())
+	}
+	err = x.checkSign(opt.Signature, f.Owner, []byte(msg))
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	if f.DeleteTime > 0 {
+		return shim.Error(errorx.New(errorx.ErrCodeAlreadyExists, "file already deleted").Error())
+	}
+	// the time is signed by the owner, and must fall between the publish time and the end of the retain period
+	if opt.CurrentTime < f.PublishTime {
+		return shim.Error(errorx.New(errorx.ErrCodeParam, "bad param:currentTime, earlier than the publish time").Error())
+	}
+	if f.ExpireTime+blockchain.FileRetainPeriod.Nanoseconds() <= opt.CurrentTime {
+		return shim.Error(errorx.New(errorx.ErrCodeParam, "file already expired over 7 days").Error())
+	}
+
+	// marshal file
+	f.DeleteTime = opt.CurrentTime
+	nf, err := json.Marshal(f)
+	if err != nil {
+		return shim.Error(errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal File").Error())
+	}
+	// set id-file on chain
+	if resp := x.SetValue(stub, []string{f.ID, string(nf)}); resp.Status == shim.ERROR {
+		return shim.Error(errorx.New(errorx.ErrCodeWriteBlockchain,
+			"failed to set id-file on chain: %s", resp.Message).Error())
+	}
+
+	// add node-sliceID to deleteTime index, the expireTime index is kept so that slices missed
+	// by scanners of the deleteTime index are still cleared when the file expires
+	for nodeID, sliceL := range groupNodeSlices(f) {
+		prefixNodeDeletedSlice := packNodeDeletedSliceIndex(nodeID, f)
+		if resp := x.SetValue(stub, []string{prefixNodeDeletedSlice, strings.Join(sliceL, ",")}); resp.Status == shim.ERROR {
+			return shim.Error(errorx.New(errorx.ErrCodeWriteBlockchain,
+				"failed to set index-id on chain: %s", resp.Message).Error())
+		}
+	}
+	if err := x.resolveSliceScrubs(stub, f.Slices, opt.CurrentTime); err != nil {
+		return shim.Error(err.Error())
+	}
+	e := blockchain.FileAuditEvent{
+		FileID:   f.ID,
+		Type:     blockchain.FileAuditDeleted,
+		Operator: hex.EncodeToString(f.Owner),
+		Time:     opt.CurrentTime,
+	}
+	if err := x.recordFileAudit(stub, e, opt.Signature); err != nil {
+		return shim.Error(err.Error())
+	}
 	return shim.Success(nf)
 }
 
@@ -480,9 +658,119 @@ func (x *Xdata) SliceMigrateRecord(stub shim.ChaincodeStubInterface, args []stri
 		return shim.Error(errorx.New(errorx.ErrCodeWriteBlockchain,
 			"failed to put index-migrate on chain: %s", resp.Message).Error())
 	}
+	e := blockchain.FileAuditEvent{
+		FileID:   f.ID,
+		Type:     blockchain.FileAuditSliceMigrated,
+		Operator: hex.EncodeToString(f.Owner),
+		Ref:      opt.SliceID,
+		Detail:   fmt.Sprintf("migrated from node %s", opt.NodeID),
+		Time:     opt.CurrentTime,
+	}
+	if err := x.recordFileAudit(stub, e, opt.Signature); err != nil {
+		return shim.Error(err.Error())
+	}
 	return shim.Success([]byte("ok"))
 }
 
+// getFileVersionIndex gets the index of the specified version of a file,
+// the file published before versioning is regarded as version 1, and only has filename index
+func (x *Xdata) getFileVersionIndex(stub shim.ChaincodeStubInterface, owner []byte, ns, name string, version int) string {
+	index := packFileVersionIndex(owner, ns, name, version)
+	if resp := x.GetValue(stub, []string{index}); len(resp.Payload) != 0 || version != 1 {
+		return index
+	}
+	return packFileNameIndex(owner, ns, name)
+}
+
+// getLatestUndeletedFile gets the latest version of a file which is not deleted
+func (x *Xdata) getLatestUndeletedFile(stub shim.ChaincodeStubInterface, owner []byte, ns, name string) (
+	f blockchain.File, err error) {
+
+	prefix, attr := packFileVersionFilter(owner, ns, name)
+	iterator, err := stub.GetStateByPartialCompositeKey(prefix, attr)
+	if err != nil {
+		return f, errorx.NewCode(err, errorx.ErrCodeReadBlockchain, "failed to get file versions")
+	}
+	defer iterator.Close()
+
+	for iterator.HasNext() {
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return f, errorx.NewCode(err, errorx.ErrCodeReadBlockchain, "failed to get file versions")
+		}
+		if f, err = x.getFileByID(stub, string(queryResponse.Value)); err != nil {
+			return f, err
+		}
+		if f.Namespace != ns || f.Name != name {
+			continue
+		}
+		if f.DeleteTime == 0 {
+			return f, nil
+		}
+	}
+	return f, errorx.New(errorx.ErrCodeNotFound, "file already deleted")
+}
+
+// ListFileVersions lists versions of a file from fabric, the latest version comes first
+func (x *Xdata) ListFileVersions(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) < 1 {
+		return shim.Error("invalid arguments. expecting ListFileVersionsOptions")
+	}
+
+	// unmarshal opt
+	var opt blockchain.ListFileVersionsOptions
+	if err := json.Unmarshal([]byte(args[0]), &opt); err != nil {
+		return shim.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to unmarshal ListFileVersionsOptions").Error())
+	}
+
+	// get iter by prefix
+	prefix, attr := packFileVersionFilter(opt.Owner, opt.Namespace, opt.Name)
+	iterator, err := stub.GetStateByPartialCompositeKey(prefix, attr)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	defer iterator.Close()
+
+	// iterate iter
+	var fs []blockchain.File
+	for iterator.HasNext() {
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+
+		if opt.Limit > 0 && int64(len(fs)) >= opt.Limit {
+			break
+		}
+		f, err := x.getFileByID(stub, string(queryResponse.Value))
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		if f.Namespace != opt.Namespace || f.Name != opt.Name {
+			continue
+		}
+		fs = append(fs, f)
+	}
+	// the file published before versioning has no version index
+	if len(fs) == 0 {
+		if resp := x.GetValue(stub, []string{packFileNameIndex(opt.Owner, opt.Namespace, opt.Name)}); len(resp.Payload) != 0 {
+			f, err := x.getFileByID(stub, string(resp.Payload))
+			if err != nil {
+				return shim.Error(err.Error())
+			}
+			fs = append(fs, f)
+		}
+	}
+
+	s, err := json.Marshal(fs)
+	if err != nil {
+		return shim.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to marshal Files").Error())
+	}
+	return shim.Success(s)
+}
+
 // ListFiles lists files from fabric
 func (x *Xdata) ListFiles(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 	if len(args) < 1 {
@@ -520,7 +808,8 @@ func (x *Xdata) ListFiles(stub shim.ChaincodeStubInterface, args []string) pb.Re
 		if err != nil {
 			return shim.Error(err.Error())
 		}
-		if f.PublishTime < opt.TimeStart || (opt.TimeEnd > 0 && f.PublishTime > opt.TimeEnd) || f.ExpireTime <= opt.CurrentTime {
+		if f.PublishTime < opt.TimeStart || (opt.TimeEnd > 0 && f.PublishTime > opt.TimeEnd) || f.ExpireTime <= opt.CurrentTime ||
+			f.DeleteTime > 0 {
 			continue
 		}
 		fs = append(fs, f)
@@ -572,7 +861,7 @@ func (x *Xdata) ListExpiredFiles(stub shim.ChaincodeStubInterface, args []string
 			return shim.Error(err.Error())
 		}
 
-		if f.PublishTime < opt.TimeStart || (opt.TimeEnd > 0 && f.PublishTime > opt.TimeEnd) {
+		if f.PublishTime < opt.TimeStart || (opt.TimeEnd > 0 && f.PublishTime > opt.TimeEnd) || f.DeleteTime > 0 {
 			continue
 		}
 		if f.ExpireTime < opt.CurrentTime-blockchain.FileRetainPeriod.Nanoseconds() || f.ExpireTime > opt.CurrentTime {
@@ -688,3 +977,23 @@ func (x *Xdata) checkSign(sign, owner, mes []byte) (err error) {
 	}
 	return nil
 }
+
+// putNodeSliceIndexes sets node-sliceID-expireTime indexes of the file, by which storage nodes find their slices
+func (x *Xdata) putNodeSliceIndexes(stub shim.ChaincodeStubInterface, f blockchain.File) error {
+	for nodeID, sliceL := range groupNodeSlices(f) {
+		if resp := x.SetValue(stub, []string{packNodeSliceIndex(nodeID, f), strings.Join(sliceL, ",")}); resp.Status == shim.ERROR {
+			return errorx.New(errorx.ErrCodeWriteBlockchain, "failed to set index-id on chain: %s", resp.Message)
+		}
+	}
+	return nil
+}
+
+// deleteNodeSliceIndexes removes node-sliceID-expireTime indexes of the file
+func (x *Xdata) deleteNodeSliceIndexes(stub shim.ChaincodeStubInterface, f blockchain.File) error {
+	for nodeID := range groupNodeSlices(f) {
+		if err := stub.DelState(packNodeSliceIndex(nodeID, f)); err != nil {
+			return errorx.NewCode(err, errorx.ErrCodeWriteBlockchain, "failed to delete index-id on chain")
+		}
+	}
+	return nil
+}
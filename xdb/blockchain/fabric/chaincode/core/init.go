@@ -14,16 +14,34 @@
 package core
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 	pb "github.com/hyperledger/fabric/protos/peer"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
 )
 
 type Xdata struct{}
 
 func (x *Xdata) Init(stub shim.ChaincodeStubInterface) pb.Response {
 	fmt.Println("init xdata chaincode")
+	// reputation policy of storage nodes is optional, given by args "reputationPolicy" and its json,
+	// the default one is used if not given
+	_, args := stub.GetFunctionAndParameters()
+	if len(args) >= 2 && args[0] == "reputationPolicy" {
+		var policy blockchain.ReputationPolicy
+		if err := json.Unmarshal([]byte(args[1]), &policy); err != nil {
+			return shim.Error(err.Error())
+		}
+		if err := checkReputationPolicy(policy); err != nil {
+			return shim.Error(err.Error())
+		}
+		if resp := x.SetValue(stub, []string{reputationPolicyKey, args[1]}); resp.Status == shim.ERROR {
+			return resp
+		}
+	}
 	return shim.Success(nil)
 }
 
@@ -44,14 +62,30 @@ func (x *Xdata) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
 		return x.NodeOffline(stub, args)
 	case "NodeOnline":
 		return x.NodeOnline(stub, args)
+	case "NodeDrain":
+		return x.NodeDrain(stub, args)
 	case "Heartbeat":
 		return x.Heartbeat(stub, args)
+	case "HeartbeatTimeout":
+		return x.HeartbeatTimeout(stub, args)
 	case "GetHeartbeatNum":
 		return x.GetHeartbeatNum(stub, args)
 	case "ListNodesExpireSlice":
 		return x.ListNodesExpireSlice(stub, args)
+	case "ListNodesDeletedSlice":
+		return x.ListNodesDeletedSlice(stub, args)
 	case "GetSliceMigrateRecords":
 		return x.GetSliceMigrateRecords(stub, args)
+	case "ListNodeSliceFiles":
+		return x.ListNodeSliceFiles(stub, args)
+	case "ReportSliceScrub":
+		return x.ReportSliceScrub(stub, args)
+	case "ListSliceScrubReports":
+		return x.ListSliceScrubReports(stub, args)
+	case "GetNodeReputation":
+		return x.GetNodeReputation(stub, args)
+	case "ListNodeReputations":
+		return x.ListNodeReputations(stub, args)
 	case "PublishFile":
 		return x.PublishFile(stub, args)
 	case "AddFileNs":
@@ -64,8 +98,12 @@ func (x *Xdata) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
 		return x.GetFileByName(stub, args)
 	case "GetFileByID":
 		return x.GetFileByID(stub, args)
+	case "ListFileVersions":
+		return x.ListFileVersions(stub, args)
 	case "UpdateFileExpireTime":
 		return x.UpdateFileExpireTime(stub, args)
+	case "DeleteFile":
+		return x.DeleteFile(stub, args)
 	case "SliceMigrateRecord":
 		return x.SliceMigrateRecord(stub, args)
 	case "ListFiles":
@@ -76,12 +114,20 @@ func (x *Xdata) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
 		return x.ListFileNs(stub, args)
 	case "GetNsByName":
 		return x.GetNsByName(stub, args)
+	case "GetSliceRef":
+		return x.GetSliceRef(stub, args)
+	case "RecordFileDownload":
+		return x.RecordFileDownload(stub, args)
+	case "ListFileAuditEvents":
+		return x.ListFileAuditEvents(stub, args)
 	case "PublishFileAuthApplication":
 		return x.PublishFileAuthApplication(stub, args)
 	case "ConfirmFileAuthApplication":
 		return x.ConfirmFileAuthApplication(stub, args)
 	case "RejectFileAuthApplication":
 		return x.RejectFileAuthApplication(stub, args)
+	case "RevokeFileAuth":
+		return x.RevokeFileAuth(stub, args)
 	case "ListFileAuthApplications":
 		return x.ListFileAuthApplications(stub, args)
 	case "GetAuthApplicationByID":
@@ -92,6 +138,8 @@ func (x *Xdata) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
 		return x.ChallengeRequest(stub, args)
 	case "ChallengeAnswer":
 		return x.ChallengeAnswer(stub, args)
+	case "ChallengeTimeout":
+		return x.ChallengeTimeout(stub, args)
 	case "GetChallengeByID":
 		return x.GetChallengeByID(stub, args)
 	case "GetChallengeNum":
@@ -28,6 +28,7 @@ const (
 
 	// Define the contract prefix key of file and file namespace operations
 	prefixFilenameIndex        = "index_fn"
+	prefixFileVersionIndex     = "index_fver"
 	prefixFileListByOwnerIndex = "index_fo_list"
 	prefixFileListByNsIndex    = "index_fs_list"
 	prefixFileNsIndex          = "index_fns"
@@ -47,7 +48,21 @@ const (
 	prefixNodeHeartbeatIndex    = "index_hbnode"
 	prefixNodeSliceMigrateIndex = "index_slicemigrate"
 	prefixNodeFileSlice         = "index_fslice"
+	prefixNodeDeletedSlice      = "index_dslice"
 	prefixNodeNonceIndex        = "index_ndnonce"
+	// Define the contract prefix key of storage node reputation operations
+	prefixNodeReputationIndex   = "index_nrep"
+	prefixNodeReputationHistory = "index_nrep_hist"
+	// Define the contract prefix key of deduplicated slice operations
+	prefixSliceRefIndex  = "index_sliceref"
+	prefixSliceHashIndex = "index_slicehash"
+	// Define the contract prefix key of slice scrub report operations
+	prefixSliceScrubIndex      = "index_scrub"
+	prefixSliceScrubNodeIndex  = "index_scrub_node"
+	prefixSliceScrubOwnerIndex = "index_scrub_owner"
+	prefixSliceScrubOpenIndex  = "index_scrub_open"
+	// Define the contract prefix key of file audit trail operations
+	prefixFileAuditIndex = "index_faudit"
 )
 
 func packNodeIndex(nodeID []byte) string {
@@ -69,15 +84,61 @@ func packNonceIndex(node []byte, nonce int64) string {
 	return createCompositeKey(prefixNodeNonceIndex, []string{fmt.Sprintf("%x", node), fmt.Sprintf("%d", nonce)})
 }
 
+func packNodeReputationIndex(nodeID []byte) string {
+	return createCompositeKey(prefixNodeReputationIndex, []string{string(nodeID)})
+}
+
+func packNodeReputationHistoryIndex(e blockchain.ReputationEvent) string {
+	attributes := []string{string(e.NodeID), fmt.Sprintf("%d", subByInt64Max(e.Time)), e.Type, e.Ref}
+	return createCompositeKey(prefixNodeReputationHistory, attributes)
+}
+
+func packNodeReputationHistoryFilter(nodeID []byte) (string, []string) {
+	return prefixNodeReputationHistory, []string{string(nodeID)}
+}
+
 func packNodeSliceIndex(node string, f blockchain.File) string {
 	attributes := []string{node, fmt.Sprintf("%d", f.ExpireTime), f.ID}
 	return createCompositeKey(prefixNodeFileSlice, attributes)
 }
 
+// groupNodeSlices groups slices of the file by storage node, values are the "sliceID:storIndex" list of node-slice index
+func groupNodeSlices(f blockchain.File) map[string][]string {
+	nodeSlice := make(map[string][]string)
+	for _, slice := range f.Slices {
+		nodeSlice[string(slice.NodeID)] = append(nodeSlice[string(slice.NodeID)], slice.ID+":"+slice.StorIndex)
+	}
+	return nodeSlice
+}
+
+// removedSlices returns slices no longer stored on their nodes after slices are updated
+func removedSlices(old, slices []blockchain.PublicSliceMeta) []blockchain.PublicSliceMeta {
+	kept := make(map[string]struct{})
+	for _, s := range slices {
+		kept[s.ID+"/"+string(s.NodeID)] = struct{}{}
+	}
+	var removed []blockchain.PublicSliceMeta
+	for _, s := range old {
+		if _, ok := kept[s.ID+"/"+string(s.NodeID)]; !ok {
+			removed = append(removed, s)
+		}
+	}
+	return removed
+}
+
 func packNodeSliceFilter(target string) (string, []string) {
 	return prefixNodeFileSlice, []string{target}
 }
 
+func packNodeDeletedSliceIndex(node string, f blockchain.File) string {
+	attributes := []string{node, fmt.Sprintf("%d", f.DeleteTime), f.ID}
+	return createCompositeKey(prefixNodeDeletedSlice, attributes)
+}
+
+func packNodeDeletedSliceFilter(target string) (string, []string) {
+	return prefixNodeDeletedSlice, []string{target}
+}
+
 // getNodeSliceFileID example: string(key) = \x00 index_fslice/ 0 node_id 0 1625039335453720000 0 fileid11 0
 func getNodeSliceFileID(key []byte) int64 {
 	strArr := strings.Split(string(key), string(minUnicodeRuneValue))
@@ -88,6 +149,15 @@ func getNodeSliceFileID(key []byte) int64 {
 	return expireTime
 }
 
+// getNodeSliceKeyFileID example: string(key) = \x00 index_fslice/ 0 node_id 0 1625039335453720000 0 fileid11 0
+func getNodeSliceKeyFileID(key []byte) string {
+	strArr := strings.Split(string(key), string(rune(minUnicodeRuneValue)))
+	if len(strArr) < 5 {
+		return ""
+	}
+	return strArr[4]
+}
+
 func packNodeSliceMigrateIndex(target string, ctime int64) string {
 	attributes := []string{target, fmt.Sprintf("%d", subByInt64Max(ctime))}
 	return createCompositeKey(prefixNodeSliceMigrateIndex, attributes)
@@ -106,11 +176,74 @@ func getNodeSliceMigrateTime(key []byte) int64 {
 	return subByInt64Max(expireTime)
 }
 
+func packSliceRefIndex(sliceID string) string {
+	return createCompositeKey(prefixSliceRefIndex, []string{sliceID})
+}
+
+func packSliceHashIndex(owner, sealedHash []byte) string {
+	attributes := []string{fmt.Sprintf("%x", owner), fmt.Sprintf("%x", sealedHash)}
+	return createCompositeKey(prefixSliceHashIndex, attributes)
+}
+
+func packSliceScrubIndex(node, sliceID string) string {
+	return createCompositeKey(prefixSliceScrubIndex, []string{node, sliceID})
+}
+
+func packSliceScrubNodeIndex(r blockchain.SliceScrubReport) string {
+	attributes := []string{string(r.NodeID), fmt.Sprintf("%d", subByInt64Max(r.ScrubTime)), r.SliceID}
+	return createCompositeKey(prefixSliceScrubNodeIndex, attributes)
+}
+
+func packSliceScrubOwnerIndex(r blockchain.SliceScrubReport) string {
+	attributes := []string{fmt.Sprintf("%x", r.Owner), fmt.Sprintf("%d", subByInt64Max(r.ScrubTime)), r.SliceID, string(r.NodeID)}
+	return createCompositeKey(prefixSliceScrubOwnerIndex, attributes)
+}
+
+func packSliceScrubOpenIndex(r blockchain.SliceScrubReport) string {
+	attributes := []string{fmt.Sprintf("%x", r.Owner), r.FileID, r.SliceID, string(r.NodeID)}
+	return createCompositeKey(prefixSliceScrubOpenIndex, attributes)
+}
+
+func packSliceScrubOpenFilter(owner []byte) (string, []string) {
+	return prefixSliceScrubOpenIndex, []string{fmt.Sprintf("%x", owner)}
+}
+
+// packSliceScrubFilter used to list scrub reports of a node if target is given, otherwise of an owner
+func packSliceScrubFilter(target, owner []byte) (string, []string) {
+	if len(target) > 0 {
+		return prefixSliceScrubNodeIndex, []string{string(target)}
+	}
+	return prefixSliceScrubOwnerIndex, []string{fmt.Sprintf("%x", owner)}
+}
+
+// packFileAuditEventID makes ID of the event sortable by time
+func packFileAuditEventID(e blockchain.FileAuditEvent) string {
+	return fmt.Sprintf("%019d_%s", e.Time, e.Digest[:16])
+}
+
+func packFileAuditIndex(fileID, eventID string) string {
+	return createCompositeKey(prefixFileAuditIndex, []string{fileID, eventID})
+}
+
+func packFileAuditFilter(fileID string) (string, []string) {
+	return prefixFileAuditIndex, []string{fileID}
+}
+
 func packFileNameIndex(owner []byte, ns, name string) string {
 	attributes := []string{fmt.Sprintf("%x", owner), ns, name}
 	return createCompositeKey(prefixFilenameIndex, attributes)
 }
 
+func packFileVersionIndex(owner []byte, ns, name string, version int) string {
+	attributes := []string{fmt.Sprintf("%x", owner), ns, name, fmt.Sprintf("%d", subByInt64Max(int64(version)))}
+	return createCompositeKey(prefixFileVersionIndex, attributes)
+}
+
+// packFileVersionFilter used to list versions of a file, the latest version comes first
+func packFileVersionFilter(owner []byte, ns, name string) (prefix string, attr []string) {
+	return prefixFileVersionIndex, []string{fmt.Sprintf("%x", owner), ns, name}
+}
+
 func packFileListByOwnerIndex(owner []byte, ns, name string, pubTime int64) string {
 	attributes := []string{fmt.Sprintf("%x", owner), ns, fmt.Sprintf("%d", subByInt64Max(pubTime)), name}
 	return createCompositeKey(prefixFileListByOwnerIndex, attributes)
@@ -14,8 +14,10 @@
 package core
 
 import (
+	"bytes"
 	"encoding/hex"
 	"encoding/json"
+	"sort"
 	"strconv"
 
 	"github.com/PaddlePaddle/PaddleDTX/crypto/core/ecdsa"
@@ -186,6 +188,23 @@ func (x *Xdata) setNodeOnlineStatus(stub shim.ChaincodeStubInterface, args []str
 			"failed to unmarshal node").Error())
 	}
 
+	// draining node goes offline only after all its slices are moved, and then it is decommissioned,
+	// files are judged expired by heartbeats of the other nodes instead of the time signed by the node
+	if !online && node.Draining {
+		now, err := x.othersHeartbeatTime(stub, opt.NodeID)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		num, err := x.countNodeSlices(stub, opt.NodeID, now)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		if num > 0 {
+			return shim.Error(errorx.New(errorx.ErrCodeParam, "draining node still has %d slices", num).Error())
+		}
+		node.Draining = false
+	}
+
 	if node.Online != online {
 		// change status
 		node.Online = online
@@ -210,6 +229,157 @@ func (x *Xdata) setNodeOnlineStatus(stub shim.ChaincodeStubInterface, args []str
 	return shim.Success([]byte("OK"))
 }
 
+// NodeDrain sets the online node to draining, so that its slices are moved to other nodes by data owners
+func (x *Xdata) NodeDrain(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) < 1 {
+		return shim.Error("invalid arguments. expecting NodeOperateOptions")
+	}
+	var opt blockchain.NodeOperateOptions
+	if err := json.Unmarshal([]byte(args[0]), &opt); err != nil {
+		return shim.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to unmarshal NodeOperateOptions").Error())
+	}
+	// check nonce
+	if err := x.checkAndSetNonce(stub, opt.NodeID, opt.Nonce); err != nil {
+		return shim.Error(err.Error())
+	}
+	// verify sig
+	npk, err := hex.DecodeString(string(opt.NodeID))
+	if err != nil {
+		return shim.Error(errorx.NewCode(err, errorx.ErrCodeInternal, "failed to decode nodeID").Error())
+	}
+	msg, err := util.GetSigMessage(opt)
+	if err != nil {
+		return shim.Error(errorx.Internal(err, "failed to get the message to sign").Error())
+	}
+	if err := x.checkSign(opt.Signature, npk, []byte(msg)); err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// get node
+	index := packNodeIndex(opt.NodeID)
+	resp := x.GetValue(stub, []string{index})
+	if len(resp.Payload) == 0 {
+		return shim.Error(errorx.New(errorx.ErrCodeNotFound, "node not found: %s", resp.Message).Error())
+	}
+	var node blockchain.Node
+	if err := json.Unmarshal(resp.Payload, &node); err != nil {
+		return shim.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to unmarshal node").Error())
+	}
+	// slices are pulled from the draining node when they are moved, so it must be online
+	if !node.Online {
+		return shim.Error(errorx.New(errorx.ErrCodeParam, "node is offline").Error())
+	}
+	if node.Draining {
+		return shim.Success([]byte("OK"))
+	}
+
+	node.Draining = true
+	newn, err := json.Marshal(node)
+	if err != nil {
+		return shim.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to marshal node").Error())
+	}
+	// put index-node on fabric
+	if resp := x.SetValue(stub, []string{index, string(newn)}); resp.Status == shim.ERROR {
+		return shim.Error(errorx.New(errorx.ErrCodeWriteBlockchain,
+			"failed to put index-Node on chain: %s", resp.Message).Error())
+	}
+	// put listIndex-node on chain
+	index = packNodeListIndex(node)
+	if resp := x.SetValue(stub, []string{index, string(newn)}); resp.Status == shim.ERROR {
+		return shim.Error(errorx.New(errorx.ErrCodeWriteBlockchain,
+			"failed to put listIndex-Node on chain: %s", resp.Message).Error())
+	}
+	return shim.Success([]byte("OK"))
+}
+
+// getNode gets the node by its ID
+func (x *Xdata) getNode(stub shim.ChaincodeStubInterface, nodeID []byte) (n blockchain.Node, err error) {
+	resp := x.GetValue(stub, []string{packNodeIndex(nodeID)})
+	if len(resp.Payload) == 0 {
+		return n, errorx.New(errorx.ErrCodeNotFound, "node not found: %s", resp.Message)
+	}
+	if err = json.Unmarshal(resp.Payload, &n); err != nil {
+		return n, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to unmarshal node")
+	}
+	return n, nil
+}
+
+// countNodeSlices counts slices of the undeleted files stored on the node and unexpired by the time,
+// the node slice index follows slice updates, and slices are checked against the files it points to
+func (x *Xdata) countNodeSlices(stub shim.ChaincodeStubInterface, nodeID []byte, currentTime int64) (int, error) {
+	prefix, attr := packNodeSliceFilter(string(nodeID))
+	iterator, err := stub.GetStateByPartialCompositeKey(prefix, attr)
+	if err != nil {
+		return 0, err
+	}
+	defer iterator.Close()
+
+	num := 0
+	counted := make(map[string]struct{})
+	for iterator.HasNext() {
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return 0, err
+		}
+		fileID := getNodeSliceKeyFileID([]byte(queryResponse.Key))
+		if _, exist := counted[fileID]; exist {
+			continue
+		}
+		counted[fileID] = struct{}{}
+		resp := x.GetValue(stub, []string{fileID})
+		if len(resp.Payload) == 0 {
+			continue
+		}
+		var f blockchain.File
+		if err := json.Unmarshal(resp.Payload, &f); err != nil {
+			return 0, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to unmarshal File")
+		}
+		if f.DeleteTime > 0 || f.ExpireTime <= currentTime {
+			continue
+		}
+		for _, slice := range f.Slices {
+			if bytes.Equal(slice.NodeID, nodeID) {
+				num++
+			}
+		}
+	}
+	return num, nil
+}
+
+// othersHeartbeatTime gets the median of the latest heartbeats of online nodes other than the given one,
+// it's a time no single node can move, and 0 if there is no other online node
+func (x *Xdata) othersHeartbeatTime(stub shim.ChaincodeStubInterface, nodeID []byte) (int64, error) {
+	iterator, err := stub.GetStateByPartialCompositeKey(prefixNodeListIndex, []string{})
+	if err != nil {
+		return 0, err
+	}
+	defer iterator.Close()
+
+	var times []int64
+	for iterator.HasNext() {
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return 0, err
+		}
+		var node blockchain.Node
+		if err := json.Unmarshal(queryResponse.Value, &node); err != nil {
+			return 0, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to unmarshal node")
+		}
+		if !node.Online || node.UpdateAt == 0 || bytes.Equal(node.ID, nodeID) {
+			continue
+		}
+		times = append(times, node.UpdateAt)
+	}
+	if len(times) == 0 {
+		return 0, nil
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+	return times[len(times)/2], nil
+}
+
 // Heartbeat updates heartbeat of node
 func (x *Xdata) Heartbeat(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 	if len(args) < 1 {
@@ -273,8 +443,11 @@ func (x *Xdata) Heartbeat(stub shim.ChaincodeStubInterface, args []string) pb.Re
 		return shim.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
 			"failed to unmarshal node").Error())
 	}
-	// update node heartbeat time
+	// update node heartbeat time, and the capacity and zone advertised by node
 	node.UpdateAt = opt.CurrentTime
+	node.Capacity = opt.Capacity
+	node.Used = opt.Used
+	node.Zone = opt.Zone
 	newNode, err := json.Marshal(node)
 	if err != nil {
 		return shim.Error(errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal node").Error())
@@ -293,6 +466,138 @@ func (x *Xdata) Heartbeat(stub shim.ChaincodeStubInterface, args []string) pb.Re
 	return shim.Success(nil)
 }
 
+// HeartbeatTimeout penalizes the heartbeats missed by an online storage node since its last heartbeat,
+// it's called by data owners storing files on the node, so that a node going silent is penalized as well.
+// Heartbeats missed before HeartbeatCheckedAt of the node have been penalized and are not counted again,
+// nor are the ones HeartbeatTimeoutWindow after the last heartbeat, as the time is signed by the data owner
+// args = {HeartbeatTimeoutOptions}
+func (x *Xdata) HeartbeatTimeout(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) < 1 {
+		return shim.Error("incorrect arguments. expecting HeartbeatTimeoutOptions")
+	}
+	var opt blockchain.HeartbeatTimeoutOptions
+	if err := json.Unmarshal([]byte(args[0]), &opt); err != nil {
+		return shim.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to unmarshal HeartbeatTimeoutOptions").Error())
+	}
+	// verify signature of the data owner
+	msg, err := util.GetSigMessage(opt)
+	if err != nil {
+		return shim.Error(errorx.Internal(err, "failed to get the message to sign").Error())
+	}
+	if err := x.checkSign(opt.Signature, opt.FileOwner, []byte(msg)); err != nil {
+		return shim.Error(err.Error())
+	}
+
+	index := packNodeIndex(opt.NodeID)
+	resp := x.GetValue(stub, []string{index})
+	if len(resp.Payload) == 0 {
+		return shim.Error(errorx.New(errorx.ErrCodeNotFound, "node not found: %s", resp.Message).Error())
+	}
+	var node blockchain.Node
+	if err := json.Unmarshal(resp.Payload, &node); err != nil {
+		return shim.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to unmarshal node").Error())
+	}
+	if !node.Online || node.UpdateAt == 0 {
+		return shim.Error(errorx.New(errorx.ErrCodeParam,
+			"bad param, node is offline or never sent heartbeats").Error())
+	}
+	// the time is signed by the data owner, it's bounded by the latest heartbeat of the node
+	if opt.CurrentTime > node.UpdateAt+int64(blockchain.HeartbeatTimeoutWindow) {
+		return shim.Error(errorx.New(errorx.ErrCodeParam,
+			"bad param:currentTime, too late after the latest heartbeat").Error())
+	}
+	// and by heartbeats of the other nodes, so that the data owner can't run the time ahead of a healthy node
+	now, err := x.othersHeartbeatTime(stub, opt.NodeID)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if now == 0 {
+		return shim.Error(errorx.New(errorx.ErrCodeParam,
+			"bad param, no other online node to time heartbeats").Error())
+	}
+	if opt.CurrentTime < now {
+		now = opt.CurrentTime
+	}
+	stored, err := x.hasOwnerSlices(stub, opt.NodeID, opt.FileOwner, now)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if !stored {
+		return shim.Error(errorx.New(errorx.ErrCodeNotAuthorized, "no files of the owner stored on node").Error())
+	}
+
+	// the heartbeat expected right after the last one is tolerated
+	freq := int64(blockchain.HeartBeatFreq)
+	checkFrom := node.UpdateAt + freq
+	if node.HeartbeatCheckedAt > checkFrom {
+		checkFrom = node.HeartbeatCheckedAt
+	}
+	missed := (now - checkFrom) / freq
+	if missed <= 0 {
+		return shim.Error(errorx.New(errorx.ErrCodeParam, "bad param, no heartbeat missed").Error())
+	}
+	if missed > blockchain.MaxMissedHeartbeats {
+		missed = blockchain.MaxMissedHeartbeats
+	}
+	// the event is timed by the checkpoint, which moves on with every penalty
+	node.HeartbeatCheckedAt = checkFrom + missed*freq
+	if err := x.updateNodeReputation(stub, opt.NodeID, blockchain.ReputationHeartbeatMissed,
+		"", node.HeartbeatCheckedAt, missed); err != nil {
+		return shim.Error(err.Error())
+	}
+
+	newNode, err := json.Marshal(node)
+	if err != nil {
+		return shim.Error(errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal node").Error())
+	}
+	if resp := x.SetValue(stub, []string{index, string(newNode)}); resp.Status == shim.ERROR {
+		return shim.Error(errorx.New(errorx.ErrCodeWriteBlockchain,
+			"failed to put index-Node on chain: %s", resp.Message).Error())
+	}
+	if resp := x.SetValue(stub, []string{packNodeListIndex(node), string(newNode)}); resp.Status == shim.ERROR {
+		return shim.Error(errorx.New(errorx.ErrCodeWriteBlockchain,
+			"failed to put listIndex-Node on chain: %s", resp.Message).Error())
+	}
+	return shim.Success(nil)
+}
+
+// hasOwnerSlices checks whether the node stores slices of any unexpired and undeleted file of the owner
+func (x *Xdata) hasOwnerSlices(stub shim.ChaincodeStubInterface, nodeID, owner []byte, currentTime int64) (bool, error) {
+	prefix, attr := packNodeSliceFilter(string(nodeID))
+	iterator, err := stub.GetStateByPartialCompositeKey(prefix, attr)
+	if err != nil {
+		return false, err
+	}
+	defer iterator.Close()
+
+	for iterator.HasNext() {
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return false, err
+		}
+		fileID := getNodeSliceKeyFileID([]byte(queryResponse.Key))
+		resp := x.GetValue(stub, []string{fileID})
+		if len(resp.Payload) == 0 {
+			continue
+		}
+		var f blockchain.File
+		if err := json.Unmarshal(resp.Payload, &f); err != nil {
+			return false, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to unmarshal File")
+		}
+		if !bytes.Equal(f.Owner, owner) || f.DeleteTime > 0 || f.ExpireTime <= currentTime {
+			continue
+		}
+		for _, slice := range f.Slices {
+			if bytes.Equal(slice.NodeID, nodeID) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
 // GetHeartbeatNum gets heartbeat by time
 func (x *Xdata) GetHeartbeatNum(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 	if len(args) < 2 {
@@ -377,6 +682,63 @@ func (x *Xdata) ListNodesExpireSlice(stub shim.ChaincodeStubInterface, args []st
 	return shim.Success(rs)
 }
 
+// ListNodesDeletedSlice lists slices of the files deleted by owners from fabric
+func (x *Xdata) ListNodesDeletedSlice(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) < 1 {
+		return shim.Error("incorrect arguments. expecting ListNodeSliceOptions")
+	}
+
+	// unmarshal opt
+	var opt blockchain.ListNodeSliceOptions
+	if err := json.Unmarshal([]byte(args[0]), &opt); err != nil {
+		return shim.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to unmarshal ListNodeSlice").Error())
+	}
+	pubkey, err := hex.DecodeString(string(opt.Target))
+	if err != nil {
+		return shim.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to decode nodeID").Error())
+	}
+	if len(pubkey) != ecdsa.PublicKeyLength ||
+		opt.StartTime < 0 || opt.EndTime <= 0 || opt.EndTime <= opt.StartTime {
+		return shim.Error(errorx.New(errorx.ErrCodeParam, "bad param").Error())
+	}
+
+	// pack prefix
+	prefix, attr := packNodeDeletedSliceFilter(string(opt.Target))
+	// iterate
+	iterator, err := stub.GetStateByPartialCompositeKey(prefix, attr)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	defer iterator.Close()
+
+	var sl []string
+	for iterator.HasNext() {
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+
+		deleteTime := getNodeSliceFileID([]byte(queryResponse.Key))
+		if (opt.Limit > 0 && int64(len(sl)) >= opt.Limit) || deleteTime == 0 {
+			break
+		}
+		if deleteTime < opt.StartTime || deleteTime > opt.EndTime {
+			continue
+		}
+
+		sl = append(sl, string(queryResponse.Value))
+	}
+
+	rs, err := json.Marshal(sl)
+	if err != nil {
+		return shim.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to marshal slices").Error())
+	}
+	return shim.Success(rs)
+}
+
 // GetSliceMigrateRecords is used to query node slice migration records
 func (x *Xdata) GetSliceMigrateRecords(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 	if len(args) < 1 {
@@ -0,0 +1,228 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+)
+
+const reputationPolicyKey = "reputation_policy"
+
+// GetNodeReputation gets the reputation of a storage node with its history
+func (x *Xdata) GetNodeReputation(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) < 1 {
+		return shim.Error("invalid arguments. expecting GetNodeReputationOptions")
+	}
+	var opt blockchain.GetNodeReputationOptions
+	if err := json.Unmarshal([]byte(args[0]), &opt); err != nil {
+		return shim.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to unmarshal GetNodeReputationOptions").Error())
+	}
+	if len(opt.NodeID) == 0 || opt.StartTime < 0 || opt.EndTime < opt.StartTime {
+		return shim.Error(errorx.New(errorx.ErrCodeParam, "bad param").Error())
+	}
+	if resp := x.GetValue(stub, []string{packNodeIndex(opt.NodeID)}); len(resp.Payload) == 0 {
+		return shim.Error(errorx.New(errorx.ErrCodeNotFound, "node not found: %s", resp.Message).Error())
+	}
+	r, err := x.getNodeReputation(stub, opt.NodeID)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// iterate history, the latest event comes first
+	prefix, attr := packNodeReputationHistoryFilter(opt.NodeID)
+	iterator, err := stub.GetStateByPartialCompositeKey(prefix, attr)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	defer iterator.Close()
+
+	for opt.Limit > 0 && iterator.HasNext() {
+		if int64(len(r.History)) >= opt.Limit {
+			break
+		}
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		var e blockchain.ReputationEvent
+		if err := json.Unmarshal(queryResponse.Value, &e); err != nil {
+			return shim.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+				"failed to unmarshal ReputationEvent").Error())
+		}
+		if e.Time < opt.StartTime || (opt.EndTime > 0 && e.Time > opt.EndTime) {
+			continue
+		}
+		r.History = append(r.History, e)
+	}
+	b, err := json.Marshal(r)
+	if err != nil {
+		return shim.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to marshal NodeReputation").Error())
+	}
+	return shim.Success(b)
+}
+
+// ListNodeReputations lists the reputations of all storage nodes without history
+func (x *Xdata) ListNodeReputations(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var rs []blockchain.NodeReputation
+
+	iterator, err := stub.GetStateByPartialCompositeKey(prefixNodeListIndex, []string{})
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	defer iterator.Close()
+
+	for iterator.HasNext() {
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		var node blockchain.Node
+		if err := json.Unmarshal(queryResponse.Value, &node); err != nil {
+			return shim.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+				"failed to unmarshal node").Error())
+		}
+		r, err := x.getNodeReputation(stub, node.ID)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		rs = append(rs, r)
+	}
+	b, err := json.Marshal(rs)
+	if err != nil {
+		return shim.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to marshal NodeReputations").Error())
+	}
+	return shim.Success(b)
+}
+
+// getReputationPolicy gets the policy set when the chaincode is initialized, or the default one
+func (x *Xdata) getReputationPolicy(stub shim.ChaincodeStubInterface) (blockchain.ReputationPolicy, error) {
+	policy := blockchain.DefaultReputationPolicy
+	resp := x.GetValue(stub, []string{reputationPolicyKey})
+	if len(resp.Payload) == 0 {
+		return policy, nil
+	}
+	if err := json.Unmarshal(resp.Payload, &policy); err != nil {
+		return policy, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to unmarshal ReputationPolicy")
+	}
+	return policy, nil
+}
+
+// getNodeReputation gets the reputation of a storage node, a node without any event has the initial score
+func (x *Xdata) getNodeReputation(stub shim.ChaincodeStubInterface, nodeID []byte) (blockchain.NodeReputation, error) {
+	resp := x.GetValue(stub, []string{packNodeReputationIndex(nodeID)})
+	if len(resp.Payload) == 0 {
+		policy, err := x.getReputationPolicy(stub)
+		if err != nil {
+			return blockchain.NodeReputation{}, err
+		}
+		return blockchain.NodeReputation{
+			NodeID:   nodeID,
+			Score:    policy.InitialScore,
+			Excluded: policy.InitialScore < policy.ExcludeThreshold,
+		}, nil
+	}
+	var r blockchain.NodeReputation
+	if err := json.Unmarshal(resp.Payload, &r); err != nil {
+		return r, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to unmarshal NodeReputation")
+	}
+	return r, nil
+}
+
+// updateNodeReputation applies count times of an event to the reputation of a storage node,
+// and records the event into the node's reputation history
+func (x *Xdata) updateNodeReputation(stub shim.ChaincodeStubInterface, nodeID []byte, eventType, ref string,
+	eventTime, count int64) error {
+	policy, err := x.getReputationPolicy(stub)
+	if err != nil {
+		return err
+	}
+	r, err := x.getNodeReputation(stub, nodeID)
+	if err != nil {
+		return err
+	}
+	e := newReputationEvent(policy, &r, eventType, ref, eventTime, count)
+
+	re, err := json.Marshal(e)
+	if err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal ReputationEvent")
+	}
+	if resp := x.SetValue(stub, []string{packNodeReputationHistoryIndex(e), string(re)}); resp.Status == shim.ERROR {
+		return errorx.New(errorx.ErrCodeWriteBlockchain,
+			"failed to put index-reputationEvent on chain: %s", resp.Message)
+	}
+	rs, err := json.Marshal(r)
+	if err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal NodeReputation")
+	}
+	if resp := x.SetValue(stub, []string{packNodeReputationIndex(nodeID), string(rs)}); resp.Status == shim.ERROR {
+		return errorx.New(errorx.ErrCodeWriteBlockchain,
+			"failed to put index-reputation on chain: %s", resp.Message)
+	}
+	return nil
+}
+
+// newReputationEvent changes the score of r by count times of the event, the score is kept in [0, MaxScore]
+func newReputationEvent(policy blockchain.ReputationPolicy, r *blockchain.NodeReputation, eventType, ref string,
+	eventTime, count int64) blockchain.ReputationEvent {
+	var delta int64
+	switch eventType {
+	case blockchain.ReputationChallengeFailed:
+		delta = -policy.ChallengeFailedPenalty * count
+	case blockchain.ReputationHeartbeatMissed:
+		delta = -policy.HeartbeatMissedPenalty * count
+	case blockchain.ReputationSliceLost:
+		delta = -policy.SliceLostPenalty * count
+	case blockchain.ReputationChallengeProved:
+		delta = policy.ChallengeProvedReward * count
+	}
+	score := r.Score + delta
+	if score < 0 {
+		score = 0
+	}
+	if score > policy.MaxScore {
+		score = policy.MaxScore
+	}
+
+	r.Score = score
+	r.Excluded = score < policy.ExcludeThreshold
+	r.UpdateTime = eventTime
+	return blockchain.ReputationEvent{
+		NodeID: r.NodeID,
+		Type:   eventType,
+		Delta:  delta,
+		Score:  score,
+		Ref:    ref,
+		Time:   eventTime,
+	}
+}
+
+// checkReputationPolicy checks the policy given when the chaincode is initialized
+func checkReputationPolicy(p blockchain.ReputationPolicy) error {
+	if p.MaxScore <= 0 || p.InitialScore < 0 || p.InitialScore > p.MaxScore ||
+		p.ExcludeThreshold < 0 || p.ExcludeThreshold > p.MaxScore ||
+		p.ChallengeFailedPenalty < 0 || p.HeartbeatMissedPenalty < 0 ||
+		p.SliceLostPenalty < 0 || p.ChallengeProvedReward < 0 {
+		return errorx.New(errorx.ErrCodeParam, "bad param:reputationPolicy")
+	}
+	return nil
+}
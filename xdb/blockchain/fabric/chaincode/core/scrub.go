@@ -0,0 +1,260 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/ecdsa"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+	util "github.com/PaddlePaddle/PaddleDTX/xdb/pkgs/strings"
+)
+
+// ReportSliceScrub is used by storage node to report a slice found corrupted or missing by scrubbing,
+// a slice is reported only once by a node until the report is resolved
+func (x *Xdata) ReportSliceScrub(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) < 1 {
+		return shim.Error("invalid arguments. expecting ReportSliceScrubOptions")
+	}
+	var opt blockchain.ReportSliceScrubOptions
+	if err := json.Unmarshal([]byte(args[0]), &opt); err != nil {
+		return shim.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to unmarshal ReportSliceScrubOptions").Error())
+	}
+	if opt.Status != blockchain.SliceScrubCorrupted && opt.Status != blockchain.SliceScrubMissing {
+		return shim.Error(errorx.New(errorx.ErrCodeParam, "bad param:status").Error())
+	}
+	// verify sig
+	npk, err := hex.DecodeString(string(opt.NodeID))
+	if err != nil {
+		return shim.Error(errorx.NewCode(err, errorx.ErrCodeParam, "failed to decode nodeID").Error())
+	}
+	msg, err := util.GetSigMessage(opt)
+	if err != nil {
+		return shim.Error(errorx.Internal(err, "failed to get the message to sign").Error())
+	}
+	if err := x.checkSign(opt.Signature, npk, []byte(msg)); err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// check the slice is stored on the node
+	file, err := x.getFileByID(stub, opt.FileID)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if !hasNodeSlice(file, opt.NodeID, opt.SliceID) {
+		return shim.Error(errorx.New(errorx.ErrCodeNotFound, "slice not found on node").Error())
+	}
+
+	index := packSliceScrubIndex(string(opt.NodeID), opt.SliceID)
+	if resp := x.GetValue(stub, []string{index}); len(resp.Payload) != 0 {
+		return shim.Error(errorx.New(errorx.ErrCodeAlreadyExists, "slice already reported").Error())
+	}
+	report := blockchain.SliceScrubReport{
+		NodeID:    opt.NodeID,
+		Owner:     file.Owner,
+		FileID:    opt.FileID,
+		SliceID:   opt.SliceID,
+		Status:    opt.Status,
+		ScrubTime: opt.CurrentTime,
+	}
+	r, err := json.Marshal(report)
+	if err != nil {
+		return shim.Error(errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal SliceScrubReport").Error())
+	}
+	for _, index := range []string{
+		index,
+		packSliceScrubNodeIndex(report),
+		packSliceScrubOwnerIndex(report),
+		packSliceScrubOpenIndex(report),
+	} {
+		if resp := x.SetValue(stub, []string{index, string(r)}); resp.Status == shim.ERROR {
+			return shim.Error(errorx.New(errorx.ErrCodeWriteBlockchain,
+				"failed to put index-scrubReport on chain: %s", resp.Message).Error())
+		}
+	}
+	if err := x.updateNodeReputation(stub, opt.NodeID, blockchain.ReputationSliceLost,
+		opt.SliceID, opt.CurrentTime, 1); err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success([]byte("ok"))
+}
+
+// resolveSliceScrubs resolves the scrub reports of slices no longer stored on their nodes,
+// so that the owner stops migrating them, and the nodes can report them again if they are stored there later
+func (x *Xdata) resolveSliceScrubs(stub shim.ChaincodeStubInterface, slices []blockchain.PublicSliceMeta,
+	resolveTime int64) error {
+	for _, slice := range slices {
+		index := packSliceScrubIndex(string(slice.NodeID), slice.ID)
+		resp := x.GetValue(stub, []string{index})
+		if len(resp.Payload) == 0 {
+			continue
+		}
+		var report blockchain.SliceScrubReport
+		if err := json.Unmarshal(resp.Payload, &report); err != nil {
+			return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to unmarshal SliceScrubReport")
+		}
+		for _, i := range []string{index, packSliceScrubOpenIndex(report)} {
+			if err := stub.DelState(i); err != nil {
+				return errorx.NewCode(err, errorx.ErrCodeWriteBlockchain, "failed to delete index-scrubReport on chain")
+			}
+		}
+		report.ResolveTime = resolveTime
+		r, err := json.Marshal(report)
+		if err != nil {
+			return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal SliceScrubReport")
+		}
+		for _, i := range []string{packSliceScrubNodeIndex(report), packSliceScrubOwnerIndex(report)} {
+			if resp := x.SetValue(stub, []string{i, string(r)}); resp.Status == shim.ERROR {
+				return errorx.New(errorx.ErrCodeWriteBlockchain,
+					"failed to put index-scrubReport on chain: %s", resp.Message)
+			}
+		}
+	}
+	return nil
+}
+
+// ListSliceScrubReports lists scrub reports of a storage node or of the files of an owner,
+// the latest report comes first, unresolved reports are listed in no particular order
+func (x *Xdata) ListSliceScrubReports(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) < 1 {
+		return shim.Error("invalid arguments. expecting ListSliceScrubReportsOptions")
+	}
+	var opt blockchain.ListSliceScrubReportsOptions
+	if err := json.Unmarshal([]byte(args[0]), &opt); err != nil {
+		return shim.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to unmarshal ListSliceScrubReportsOptions").Error())
+	}
+	if (len(opt.Target) == 0 && len(opt.Owner) == 0) || (opt.Unresolved && len(opt.Owner) == 0) ||
+		opt.StartTime < 0 || opt.EndTime <= 0 || opt.EndTime <= opt.StartTime {
+		return shim.Error(errorx.New(errorx.ErrCodeParam, "bad param").Error())
+	}
+
+	// iterate
+	prefix, attr := packSliceScrubFilter(opt.Target, opt.Owner)
+	if opt.Unresolved {
+		prefix, attr = packSliceScrubOpenFilter(opt.Owner)
+	}
+	iterator, err := stub.GetStateByPartialCompositeKey(prefix, attr)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	defer iterator.Close()
+
+	var rs []blockchain.SliceScrubReport
+	for iterator.HasNext() {
+		if opt.Limit > 0 && int64(len(rs)) >= opt.Limit {
+			break
+		}
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		var r blockchain.SliceScrubReport
+		if err := json.Unmarshal(queryResponse.Value, &r); err != nil {
+			return shim.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+				"failed to unmarshal SliceScrubReport").Error())
+		}
+		if r.ScrubTime < opt.StartTime || r.ScrubTime > opt.EndTime {
+			continue
+		}
+		if len(opt.Target) > 0 && len(opt.Owner) > 0 && !bytes.Equal(r.Owner, opt.Owner) {
+			continue
+		}
+		if opt.Unresolved && len(opt.Target) > 0 && !bytes.Equal(r.NodeID, opt.Target) {
+			continue
+		}
+		rs = append(rs, r)
+	}
+	b, err := json.Marshal(rs)
+	if err != nil {
+		return shim.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to marshal SliceScrubReports").Error())
+	}
+	return shim.Success(b)
+}
+
+// ListNodeSliceFiles lists IDs of the files which have slices stored on the node,
+// and expire between startTime and endTime, deleted files are skipped
+func (x *Xdata) ListNodeSliceFiles(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) < 1 {
+		return shim.Error("incorrect arguments. expecting ListNodeSliceOptions")
+	}
+	var opt blockchain.ListNodeSliceOptions
+	if err := json.Unmarshal([]byte(args[0]), &opt); err != nil {
+		return shim.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to unmarshal ListNodeSlice").Error())
+	}
+	pubkey, err := hex.DecodeString(string(opt.Target))
+	if err != nil {
+		return shim.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to decode nodeID").Error())
+	}
+	if len(pubkey) != ecdsa.PublicKeyLength ||
+		opt.StartTime < 0 || opt.EndTime <= 0 || opt.EndTime <= opt.StartTime {
+		return shim.Error(errorx.New(errorx.ErrCodeParam, "bad param").Error())
+	}
+
+	// iterate
+	prefix, attr := packNodeSliceFilter(string(opt.Target))
+	iterator, err := stub.GetStateByPartialCompositeKey(prefix, attr)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	defer iterator.Close()
+
+	var fl []string
+	for iterator.HasNext() {
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+
+		expireTime := getNodeSliceFileID([]byte(queryResponse.Key))
+		if (opt.Limit > 0 && int64(len(fl)) >= opt.Limit) || expireTime == 0 {
+			break
+		}
+		if expireTime < opt.StartTime || expireTime > opt.EndTime {
+			continue
+		}
+		// the node-slice index of a deleted file is kept until it expires
+		fileID := getNodeSliceKeyFileID([]byte(queryResponse.Key))
+		if f, err := x.getFileByID(stub, fileID); err == nil && f.DeleteTime > 0 {
+			continue
+		}
+		fl = append(fl, fileID)
+	}
+	b, err := json.Marshal(fl)
+	if err != nil {
+		return shim.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to marshal file IDs").Error())
+	}
+	return shim.Success(b)
+}
+
+// hasNodeSlice checks whether the slice of the file is stored on the node
+func hasNodeSlice(f blockchain.File, nodeID []byte, sliceID string) bool {
+	for _, slice := range f.Slices {
+		if slice.ID == sliceID && bytes.Equal(slice.NodeID, nodeID) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+)
+
+// GetSliceRef gets a deduplicated slice and the number of files referencing it
+func (x *Xdata) GetSliceRef(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) < 1 {
+		return shim.Error("invalid arguments. expecting GetSliceRefOptions")
+	}
+	var opt blockchain.GetSliceRefOptions
+	if err := json.Unmarshal([]byte(args[0]), &opt); err != nil {
+		return shim.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to unmarshal GetSliceRefOptions").Error())
+	}
+
+	sliceID := opt.SliceID
+	if sliceID == "" {
+		resp := x.GetValue(stub, []string{packSliceHashIndex(opt.Owner, opt.SealedHash)})
+		if len(resp.Payload) == 0 {
+			return shim.Error(errorx.New(errorx.ErrCodeNotFound, "slice not found: %s", resp.Message).Error())
+		}
+		sliceID = string(resp.Payload)
+	}
+	ref, err := x.getSliceRef(stub, sliceID)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if ref.RefCount, err = x.countSliceRef(stub, ref, opt.CurrentTime); err != nil {
+		return shim.Error(err.Error())
+	}
+
+	r, err := json.Marshal(ref)
+	if err != nil {
+		return shim.Error(errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal SliceRef").Error())
+	}
+	return shim.Success(r)
+}
+
+// addSliceRefs records the file as a reference of deduplicated slices,
+// a slice no longer referenced by any live file may have been cleared by storage nodes, so it can't be referenced again
+func (x *Xdata) addSliceRefs(stub shim.ChaincodeStubInterface, f blockchain.File, refs []blockchain.SliceRef) error {
+	sliceIDs := make(map[string]bool)
+	for _, slice := range f.Slices {
+		sliceIDs[slice.ID] = true
+	}
+	for _, r := range refs {
+		if !sliceIDs[r.SliceID] {
+			return errorx.New(errorx.ErrCodeParam, "bad param, slice[%s] not in file", r.SliceID)
+		}
+
+		ref, err := x.getSliceRef(stub, r.SliceID)
+		if err != nil {
+			if !errorx.Is(err, errorx.ErrCodeNotFound) {
+				return err
+			}
+			ref = blockchain.SliceRef{
+				SliceID:    r.SliceID,
+				Owner:      f.Owner,
+				SealedHash: r.SealedHash,
+			}
+		} else {
+			if !bytes.Equal(ref.Owner, f.Owner) || !bytes.Equal(ref.SealedHash, r.SealedHash) {
+				return errorx.New(errorx.ErrCodeParam, "bad param, slice[%s] mismatched", r.SliceID)
+			}
+			// drop files no longer referencing the slice
+			var files []string
+			for _, id := range ref.Files {
+				if id == f.ID {
+					continue
+				}
+				if fc, err := x.getFileByID(stub, id); err == nil && isFileAlive(fc, f.PublishTime) {
+					files = append(files, id)
+				}
+			}
+			if len(files) == 0 {
+				return errorx.New(errorx.ErrCodeNotFound, "slice[%s] already released", r.SliceID)
+			}
+			ref.Files = files
+		}
+		ref.Files = append(ref.Files, f.ID)
+
+		s, err := json.Marshal(ref)
+		if err != nil {
+			return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal SliceRef")
+		}
+		if resp := x.SetValue(stub, []string{packSliceRefIndex(ref.SliceID), string(s)}); resp.Status == shim.ERROR {
+			return errorx.New(errorx.ErrCodeWriteBlockchain, "failed to set sliceref on chain: %s", resp.Message)
+		}
+		hashIndex := packSliceHashIndex(ref.Owner, ref.SealedHash)
+		if resp := x.SetValue(stub, []string{hashIndex, ref.SliceID}); resp.Status == shim.ERROR {
+			return errorx.New(errorx.ErrCodeWriteBlockchain, "failed to set slicehash index on chain: %s", resp.Message)
+		}
+	}
+	return nil
+}
+
+// getSliceRef gets SliceRef by sliceID, RefCount is not set
+func (x *Xdata) getSliceRef(stub shim.ChaincodeStubInterface, sliceID string) (ref blockchain.SliceRef, err error) {
+	resp := x.GetValue(stub, []string{packSliceRefIndex(sliceID)})
+	if len(resp.Payload) == 0 {
+		return ref, errorx.New(errorx.ErrCodeNotFound, "slice[%s] not found", sliceID)
+	}
+	if err := json.Unmarshal(resp.Payload, &ref); err != nil {
+		return ref, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to unmarshal SliceRef")
+	}
+	return ref, nil
+}
+
+// countSliceRef counts files referencing the slice at ctime
+func (x *Xdata) countSliceRef(stub shim.ChaincodeStubInterface, ref blockchain.SliceRef, ctime int64) (int, error) {
+	count := 0
+	for _, id := range ref.Files {
+		f, err := x.getFileByID(stub, id)
+		if err != nil {
+			return 0, err
+		}
+		if isFileAlive(f, ctime) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// isFileAlive returns whether slices of the file are still kept by storage nodes at ctime
+func isFileAlive(f blockchain.File, ctime int64) bool {
+	return f.DeleteTime == 0 && f.ExpireTime+blockchain.FileRetainPeriod.Nanoseconds() > ctime
+}
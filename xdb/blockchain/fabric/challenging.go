@@ -70,6 +70,19 @@ func (f *Fabric) ChallengeAnswer(opt *blockchain.ChallengeAnswerOptions) ([]byte
 	return resp, nil
 }
 
+// ChallengeTimeout marks a time-bounded challenge not answered before its deadline as failed on chain
+func (f *Fabric) ChallengeTimeout(opt *blockchain.ChallengeTimeoutOptions) error {
+	opts, err := json.Marshal(*opt)
+	if err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal ChallengeTimeoutOptions")
+	}
+
+	if _, err = f.InvokeContract([][]byte{opts}, "ChallengeTimeout"); err != nil {
+		return err
+	}
+	return nil
+}
+
 // GetChallengeByID gets a challenge by challengeID
 func (f *Fabric) GetChallengeByID(id string) (blockchain.Challenge, error) {
 	var c blockchain.Challenge
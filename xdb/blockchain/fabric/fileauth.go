@@ -61,6 +61,19 @@ func (f *Fabric) setFileAuthConfirmStatus(opt *blockchain.ConfirmFileAuthOptions
 	return nil
 }
 
+// RevokeFileAuth dataOwner node revokes the approved file authorization application
+func (f *Fabric) RevokeFileAuth(opt *blockchain.RevokeFileAuthOptions) error {
+	opts, err := json.Marshal(*opt)
+	if err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeInternal,
+			"fail to marshal RevokeFileAuthOptions")
+	}
+	if _, err := f.InvokeContract([][]byte{opts}, "RevokeFileAuth"); err != nil {
+		return err
+	}
+	return nil
+}
+
 // ListFileAuthApplications query the list of authorization applications
 // Support query by time range and fileID
 func (f *Fabric) ListFileAuthApplications(opt *blockchain.ListFileAuthOptions) (blockchain.FileAuthApplications, error) {
@@ -52,6 +52,44 @@ func (f *Fabric) GetFileByName(owner []byte, ns, name string) (blockchain.File,
 	return file, nil
 }
 
+// GetFileByVersion gets the specified version of a file by name from fabric
+func (f *Fabric) GetFileByVersion(owner []byte, ns, name string, version int) (blockchain.File, error) {
+	var file blockchain.File
+
+	args := [][]byte{owner, []byte(ns), []byte(name), []byte(strconv.FormatInt(time.Now().UnixNano(), 10)),
+		[]byte(strconv.Itoa(version))}
+	s, err := f.QueryContract(args, "GetFileByName")
+	if err != nil {
+		return file, err
+	}
+	if err = json.Unmarshal(s, &file); err != nil {
+		return file, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to unmarshal File")
+	}
+
+	return file, nil
+}
+
+// ListFileVersions lists versions of a file from fabric, the latest version comes first
+func (f *Fabric) ListFileVersions(opt *blockchain.ListFileVersionsOptions) ([]blockchain.File, error) {
+	var fs []blockchain.File
+
+	opts, err := json.Marshal(*opt)
+	if err != nil {
+		return fs, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal ListFileVersionsOptions")
+	}
+
+	s, err := f.QueryContract([][]byte{opts}, "ListFileVersions")
+	if err != nil {
+		return fs, err
+	}
+	if err = json.Unmarshal(s, &fs); err != nil {
+		return fs, errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to unmarshal Files")
+	}
+
+	return fs, nil
+}
+
 // GetFileByID gets file by id from fabric
 func (f *Fabric) GetFileByID(id string) (blockchain.File, error) {
 	var file blockchain.File
@@ -87,6 +125,19 @@ func (f *Fabric) UpdateFileExpireTime(opt *blockchain.UpdateExptimeOptions) (blo
 	return file, nil
 }
 
+// DeleteFile deletes file before it expires
+func (f *Fabric) DeleteFile(opt *blockchain.DeleteFileOptions) error {
+	s, err := json.Marshal(*opt)
+	if err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal DeleteFileOptions")
+	}
+
+	if _, err = f.InvokeContract([][]byte{s}, "DeleteFile"); err != nil {
+		return err
+	}
+	return nil
+}
+
 // AddFileNs adds file namespace
 func (f *Fabric) AddFileNs(opt *blockchain.AddNsOptions) error {
 	s, err := json.Marshal(*opt)
@@ -220,3 +271,58 @@ func (f *Fabric) ListExpiredFiles(opt *blockchain.ListFileOptions) ([]blockchain
 
 	return fs, nil
 }
+
+// GetSliceRef gets a deduplicated slice and the number of files referencing it
+func (f *Fabric) GetSliceRef(opt *blockchain.GetSliceRefOptions) (blockchain.SliceRef, error) {
+	var ref blockchain.SliceRef
+
+	opts, err := json.Marshal(*opt)
+	if err != nil {
+		return ref, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal GetSliceRefOptions")
+	}
+
+	s, err := f.QueryContract([][]byte{opts}, "GetSliceRef")
+	if err != nil {
+		return ref, err
+	}
+	if err = json.Unmarshal(s, &ref); err != nil {
+		return ref, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to unmarshal SliceRef")
+	}
+
+	return ref, nil
+}
+
+// RecordFileDownload records a download of the file in its audit trail
+func (f *Fabric) RecordFileDownload(opt *blockchain.RecordFileDownloadOptions) error {
+	s, err := json.Marshal(*opt)
+	if err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to marshal RecordFileDownloadOptions")
+	}
+
+	if _, err := f.InvokeContract([][]byte{s}, "RecordFileDownload"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ListFileAuditEvents lists events in the audit trail of a file from fabric, the earliest event comes first
+func (f *Fabric) ListFileAuditEvents(opt *blockchain.ListFileAuditEventsOptions) ([]blockchain.FileAuditEvent, error) {
+	var es []blockchain.FileAuditEvent
+
+	opts, err := json.Marshal(*opt)
+	if err != nil {
+		return es, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal ListFileAuditEventsOptions")
+	}
+
+	s, err := f.QueryContract([][]byte{opts}, "ListFileAuditEvents")
+	if err != nil {
+		return es, err
+	}
+	if err = json.Unmarshal(s, &es); err != nil {
+		return es, errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to unmarshal FileAuditEvents")
+	}
+
+	return es, nil
+}
@@ -93,6 +93,18 @@ func (f *Fabric) NodeOnline(opt *blockchain.NodeOperateOptions) error {
 	return f.setNodeOnlineStatus(opt, true)
 }
 
+// NodeDrain set node status on chain to draining, so that its slices are moved to other nodes
+func (f *Fabric) NodeDrain(opt *blockchain.NodeOperateOptions) error {
+	s, err := json.Marshal(*opt)
+	if err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal NodeOperateOptions")
+	}
+	if _, err := f.InvokeContract([][]byte{s}, "NodeDrain"); err != nil {
+		return err
+	}
+	return nil
+}
+
 // Heartbeat updates heartbeat of storage node
 func (f *Fabric) Heartbeat(opt *blockchain.NodeHeartBeatOptions) error {
 	s, err := json.Marshal(*opt)
@@ -105,6 +117,18 @@ func (f *Fabric) Heartbeat(opt *blockchain.NodeHeartBeatOptions) error {
 	return nil
 }
 
+// HeartbeatTimeout penalizes the heartbeats missed by a storage node storing files of the data owner
+func (f *Fabric) HeartbeatTimeout(opt *blockchain.HeartbeatTimeoutOptions) error {
+	s, err := json.Marshal(*opt)
+	if err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal HeartbeatTimeoutOptions")
+	}
+	if _, err := f.InvokeContract([][]byte{s}, "HeartbeatTimeout"); err != nil {
+		return err
+	}
+	return nil
+}
+
 // GetHeartbeatNum gets storage heartbeat number by time
 func (f *Fabric) GetHeartbeatNum(id []byte, timestamp int64) (int, error) {
 	args := [][]byte{id, []byte(strconv.FormatInt(common.TodayBeginning(timestamp), 10))}
@@ -137,6 +161,16 @@ func (f *Fabric) GetSliceMigrateRecords(opt *blockchain.NodeSliceMigrateOptions)
 // ListNodesExpireSlice lists expired slices from fabric
 // returns a list of sliceID(first value of [2]string) and its StorageIndex(second value of [2]string)
 func (f *Fabric) ListNodesExpireSlice(opt *blockchain.ListNodeSliceOptions) ([][2]string, error) {
+	return f.listNodeSlices(opt, "ListNodesExpireSlice")
+}
+
+// ListNodesDeletedSlice lists slices of the files deleted by owners
+func (f *Fabric) ListNodesDeletedSlice(opt *blockchain.ListNodeSliceOptions) ([][2]string, error) {
+	return f.listNodeSlices(opt, "ListNodesDeletedSlice")
+}
+
+// listNodeSlices queries slices of node by chaincode method mName, returns pairs of sliceID and storIndex
+func (f *Fabric) listNodeSlices(opt *blockchain.ListNodeSliceOptions, mName string) ([][2]string, error) {
 	var sliceL []string
 
 	sliceID2StorIndex := make([][2]string, 0)
@@ -147,7 +181,7 @@ func (f *Fabric) ListNodesExpireSlice(opt *blockchain.ListNodeSliceOptions) ([][
 			"failed to marshal ListFileOptions")
 	}
 
-	s, err := f.QueryContract([][]byte{opts}, "ListNodesExpireSlice")
+	s, err := f.QueryContract([][]byte{opts}, mName)
 	if err != nil {
 		return sliceID2StorIndex, err
 	}
@@ -222,3 +256,91 @@ func (f *Fabric) GetNodeHealth(id []byte) (string, error) {
 	}
 	return blockchain.NodeHealthMedium, nil
 }
+
+// ListNodeSliceFiles lists IDs of the files which have slices stored on the node
+func (f *Fabric) ListNodeSliceFiles(opt *blockchain.ListNodeSliceOptions) ([]string, error) {
+	var fl []string
+	s, err := json.Marshal(*opt)
+	if err != nil {
+		return fl, errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to marshal ListNodeSliceOptions")
+	}
+
+	b, err := f.QueryContract([][]byte{s}, "ListNodeSliceFiles")
+	if err != nil {
+		return fl, err
+	}
+	if err = json.Unmarshal(b, &fl); err != nil {
+		return fl, errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to unmarshal file IDs")
+	}
+	return fl, nil
+}
+
+// ReportSliceScrub is used by storage node to report a slice found corrupted or missing by scrubbing
+func (f *Fabric) ReportSliceScrub(opt *blockchain.ReportSliceScrubOptions) error {
+	s, err := json.Marshal(*opt)
+	if err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to marshal ReportSliceScrubOptions")
+	}
+
+	if _, err := f.InvokeContract([][]byte{s}, "ReportSliceScrub"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ListSliceScrubReports lists scrub reports of a storage node or of the files of an owner
+func (f *Fabric) ListSliceScrubReports(opt *blockchain.ListSliceScrubReportsOptions) ([]blockchain.SliceScrubReport, error) {
+	var rs []blockchain.SliceScrubReport
+	s, err := json.Marshal(*opt)
+	if err != nil {
+		return rs, errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to marshal ListSliceScrubReportsOptions")
+	}
+
+	b, err := f.QueryContract([][]byte{s}, "ListSliceScrubReports")
+	if err != nil {
+		return rs, err
+	}
+	if err = json.Unmarshal(b, &rs); err != nil {
+		return rs, errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to unmarshal SliceScrubReports")
+	}
+	return rs, nil
+}
+
+// GetNodeReputation gets the reputation of a storage node with its history
+func (f *Fabric) GetNodeReputation(opt *blockchain.GetNodeReputationOptions) (blockchain.NodeReputation, error) {
+	var r blockchain.NodeReputation
+	s, err := json.Marshal(*opt)
+	if err != nil {
+		return r, errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to marshal GetNodeReputationOptions")
+	}
+
+	b, err := f.QueryContract([][]byte{s}, "GetNodeReputation")
+	if err != nil {
+		return r, err
+	}
+	if err = json.Unmarshal(b, &r); err != nil {
+		return r, errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to unmarshal NodeReputation")
+	}
+	return r, nil
+}
+
+// ListNodeReputations lists the reputations of all storage nodes without history
+func (f *Fabric) ListNodeReputations() ([]blockchain.NodeReputation, error) {
+	var rs []blockchain.NodeReputation
+	b, err := f.QueryContract([][]byte{}, "ListNodeReputations")
+	if err != nil {
+		return rs, err
+	}
+	if err = json.Unmarshal(b, &rs); err != nil {
+		return rs, errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to unmarshal NodeReputations")
+	}
+	return rs, nil
+}
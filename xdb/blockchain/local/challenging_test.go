@@ -0,0 +1,236 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/ecdsa"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/hash"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
+	ctype "github.com/PaddlePaddle/PaddleDTX/xdb/engine/challenger/merkle/types"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/types"
+	util "github.com/PaddlePaddle/PaddleDTX/xdb/pkgs/strings"
+)
+
+// addNode registers a storage node signed by its private key
+func (o *testOwner) addNode(t *testing.T, sk ecdsa.PrivateKey, pk ecdsa.PublicKey) []byte {
+	now := time.Now().UnixNano()
+	opt := blockchain.AddNodeOptions{
+		Node: blockchain.Node{
+			ID:       []byte(pk.String()),
+			Name:     "storage",
+			Address:  "127.0.0.1:8122",
+			Online:   true,
+			RegTime:  now,
+			UpdateAt: now,
+		},
+	}
+	msg, err := util.GetSigMessage(opt)
+	require.NoError(t, err)
+	sig, err := ecdsa.Sign(sk, hash.HashUsingSha256([]byte(msg)))
+	require.NoError(t, err)
+	opt.Signature = sig[:]
+	require.NoError(t, o.AddNode(&opt))
+	return opt.Node.ID
+}
+
+// challenge requests a merkle challenge on the file stored on the node, which should be answered before deadline
+func (o *testOwner) challenge(t *testing.T, f blockchain.File, node []byte, deadline int64) string {
+	opt := blockchain.ChallengeRequestOptions{
+		ChallengeID:        uuid.NewString(),
+		FileOwner:          o.pk[:],
+		TargetNode:         node,
+		FileID:             f.ID,
+		ChallengeTime:      time.Now().UnixNano(),
+		ChallengeAlgorithm: types.MerkleChallengeAlgorithm,
+		Deadline:           deadline,
+		SliceID:            f.Slices[0].ID,
+		SliceStorIndex:     f.Slices[0].StorIndex,
+	}
+	opt.Signature = o.sign(t, opt)
+	require.NoError(t, o.ChallengeRequest(&opt))
+	return opt.ChallengeID
+}
+
+// heartbeat sends a heartbeat of the node signed at the time
+func (o *testOwner) heartbeat(t *testing.T, sk ecdsa.PrivateKey, node []byte, ctime int64) {
+	freq := int64(blockchain.HeartBeatFreq)
+	opt := blockchain.NodeHeartBeatOptions{NodeID: node, CurrentTime: ctime, BeginningTime: ctime - ctime%freq}
+	msg, err := util.GetSigMessage(opt)
+	require.NoError(t, err)
+	sig, err := ecdsa.Sign(sk, hash.HashUsingSha256([]byte(msg)))
+	require.NoError(t, err)
+	opt.Signature = sig[:]
+	require.NoError(t, o.Heartbeat(&opt))
+}
+
+func TestChallengeDeadline(t *testing.T) {
+	o := newTestOwner(t)
+	o.addNs(t, "ns")
+	nodeSk, nodePk, err := ecdsa.GenerateKeyPair()
+	require.NoError(t, err)
+	node := o.addNode(t, nodeSk, nodePk)
+	f := o.newFile("ns", "file", 1)
+	f.Slices[0].NodeID = node
+	require.NoError(t, o.publish(t, f))
+
+	reputation := func() blockchain.NodeReputation {
+		r, err := o.GetNodeReputation(&blockchain.GetNodeReputationOptions{NodeID: node, EndTime: math.MaxInt64, Limit: 10})
+		require.NoError(t, err)
+		return r
+	}
+	answer := func(id string, answerTime int64) error {
+		aopt := blockchain.ChallengeAnswerOptions{ChallengeID: id, AnswerTime: answerTime}
+		msg, err := util.GetSigMessage(aopt)
+		require.NoError(t, err)
+		sig, err := ecdsa.Sign(nodeSk, hash.HashUsingSha256([]byte(msg)))
+		require.NoError(t, err)
+		aopt.Signature = sig[:]
+		_, err = o.ChallengeAnswer(&aopt)
+		return err
+	}
+	timeout := func(id string, ctime int64) error {
+		topt := blockchain.ChallengeTimeoutOptions{ChallengeID: id, CurrentTime: ctime}
+		topt.Signature = o.sign(t, topt)
+		return o.ChallengeTimeout(&topt)
+	}
+	initial := reputation()
+	penalty := blockchain.DefaultReputationPolicy.ChallengeFailedPenalty
+
+	// challenges can't start before the latest heartbeat of the node
+	n, err := o.GetNode(node)
+	require.NoError(t, err)
+	deadline := time.Now().Add(blockchain.MinAnswerWindow + time.Minute).UnixNano()
+	ropt := blockchain.ChallengeRequestOptions{
+		ChallengeID:        uuid.NewString(),
+		FileOwner:          o.pk[:],
+		TargetNode:         node,
+		FileID:             f.ID,
+		ChallengeTime:      n.UpdateAt - 1,
+		ChallengeAlgorithm: types.MerkleChallengeAlgorithm,
+		Deadline:           deadline,
+		SliceID:            f.Slices[0].ID,
+		SliceStorIndex:     f.Slices[0].StorIndex,
+	}
+	ropt.Signature = o.sign(t, ropt)
+	require.Error(t, o.ChallengeRequest(&ropt))
+
+	// challenges must leave the node time to answer, instead of failing at its next heartbeat
+	ropt.ChallengeTime = time.Now().UnixNano()
+	ropt.Deadline = ropt.ChallengeTime + int64(blockchain.MinAnswerWindow) - 1
+	ropt.Signature = o.sign(t, ropt)
+	err = o.ChallengeRequest(&ropt)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "deadline should be")
+	_, err = o.GetChallengeByID(ropt.ChallengeID)
+	require.Error(t, err)
+
+	// the deadline passes only after the node sent a heartbeat later than it
+	timedOut := o.challenge(t, f, node, deadline)
+	late := o.challenge(t, f, node, deadline)
+	require.Error(t, timeout(timedOut, deadline-1))
+	require.Error(t, timeout(timedOut, deadline+1))
+	o.heartbeat(t, nodeSk, node, deadline+1)
+
+	// challenges timed out are failed and penalized, and can't be answered any more
+	require.NoError(t, timeout(timedOut, deadline+1))
+	c, err := o.GetChallengeByID(timedOut)
+	require.NoError(t, err)
+	require.Equal(t, blockchain.ChallengeFailed, c.Status)
+	require.Equal(t, initial.Score-penalty, reputation().Score)
+	err = answer(timedOut, deadline-1)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "timed out")
+
+	// late answers are failed and penalized, though the node signs an answer time before deadline
+	require.NoError(t, answer(late, deadline-1))
+	c, err = o.GetChallengeByID(late)
+	require.NoError(t, err)
+	require.Equal(t, blockchain.ChallengeFailed, c.Status)
+	require.Error(t, timeout(late, deadline+1))
+
+	r := reputation()
+	require.Equal(t, initial.Score-2*penalty, r.Score)
+	require.Len(t, r.History, 2)
+	for _, e := range r.History {
+		require.Equal(t, blockchain.ReputationChallengeFailed, e.Type)
+	}
+}
+
+func TestNodeReputations(t *testing.T) {
+	o := newTestOwner(t)
+	o.addNs(t, "ns")
+	nodeSk, nodePk, err := ecdsa.GenerateKeyPair()
+	require.NoError(t, err)
+	node := o.addNode(t, nodeSk, nodePk)
+	otherSk, otherPk, err := ecdsa.GenerateKeyPair()
+	require.NoError(t, err)
+	other := o.addNode(t, otherSk, otherPk)
+	f := o.newFile("ns", "file", 1)
+	f.Slices[0].NodeID = node
+	require.NoError(t, o.publish(t, f))
+
+	reputations := func() map[string]blockchain.NodeReputation {
+		rs, err := o.ListNodeReputations()
+		require.NoError(t, err)
+		m := make(map[string]blockchain.NodeReputation)
+		for _, r := range rs {
+			require.Empty(t, r.History)
+			m[string(r.NodeID)] = r
+		}
+		return m
+	}
+	// nodes without any event have the initial score
+	policy := blockchain.DefaultReputationPolicy
+	rs := reputations()
+	require.Len(t, rs, 2)
+	require.Equal(t, policy.InitialScore, rs[string(node)].Score)
+	require.Equal(t, policy.InitialScore, rs[string(other)].Score)
+
+	// wrong answers given in time are penalized, until the node is excluded
+	failures := (policy.InitialScore-policy.ExcludeThreshold)/policy.ChallengeFailedPenalty + 1
+	deadline := time.Now().Add(blockchain.MinAnswerWindow + time.Minute).UnixNano()
+	proof, err := json.Marshal(ctype.AnswerCalculateOptions{RangeHashes: [][]byte{[]byte("wrong")}})
+	require.NoError(t, err)
+	for i := int64(0); i < failures; i++ {
+		require.False(t, reputations()[string(node)].Excluded)
+		id := o.challenge(t, f, node, deadline)
+		aopt := blockchain.ChallengeAnswerOptions{ChallengeID: id, Proof: proof, AnswerTime: deadline - failures + i}
+		msg, err := util.GetSigMessage(aopt)
+		require.NoError(t, err)
+		sig, err := ecdsa.Sign(nodeSk, hash.HashUsingSha256([]byte(msg)))
+		require.NoError(t, err)
+		aopt.Signature = sig[:]
+		_, err = o.ChallengeAnswer(&aopt)
+		require.NoError(t, err)
+	}
+	rs = reputations()
+	require.True(t, rs[string(node)].Excluded)
+	require.Equal(t, policy.InitialScore-failures*policy.ChallengeFailedPenalty, rs[string(node)].Score)
+	require.False(t, rs[string(other)].Excluded)
+
+	r, err := o.GetNodeReputation(&blockchain.GetNodeReputationOptions{NodeID: node, EndTime: math.MaxInt64, Limit: 100})
+	require.NoError(t, err)
+	require.Len(t, r.History, int(failures))
+	require.Equal(t, blockchain.ReputationChallengeFailed, r.History[0].Type)
+	require.Equal(t, r.Score, r.History[0].Score)
+}
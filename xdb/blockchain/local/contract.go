@@ -0,0 +1,354 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	"github.com/xuperchain/xuperchain/core/contractsdk/go/code"
+	"github.com/xuperchain/xuperchain/core/contractsdk/go/pb"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/hash"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+)
+
+const (
+	// states of the contract and transactions are kept under different prefixes
+	prefixState = "s/"
+	prefixTx    = "t/"
+)
+
+// reader reads states from the LevelDB, or from the transaction running the contract method
+type reader interface {
+	Get(key []byte, ro *opt.ReadOptions) ([]byte, error)
+	NewIterator(slice *util.Range, ro *opt.ReadOptions) iterator.Iterator
+}
+
+// Runtime runs methods of the contract in-process, states of the contract are kept in LevelDB.
+// Each invocation runs in a LevelDB transaction, which is committed only if the method succeeds,
+// invocations are run one by one as if they were packed in blocks of one transaction
+type Runtime struct {
+	contract  interface{}
+	db        *leveldb.DB
+	path      string
+	initiator string
+
+	lock sync.Mutex
+}
+
+// Invoke runs the contract method and commits the changes of states, the ID of the transaction is returned
+func (r *Runtime) Invoke(args map[string]string, mName string) ([]byte, string, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	tr, err := r.db.OpenTransaction()
+	if err != nil {
+		return nil, "", errorx.NewCode(err, errorx.ErrCodeInternal, "failed to open leveldb transaction")
+	}
+	ctx := newContractContext(args, r.initiator, tr, tr)
+	resp := r.run(r.contract, ctx, mName)
+	if code.IsStatusError(resp.Status) {
+		tr.Discard()
+		return nil, "", parseResponseError(resp, "failed to invoke local contract")
+	}
+
+	txID, err := r.putTx(tr, args, mName)
+	if err != nil {
+		tr.Discard()
+		return nil, "", err
+	}
+	if err := tr.Commit(); err != nil {
+		return nil, "", errorx.NewCode(err, errorx.ErrCodeInternal, "failed to commit leveldb transaction")
+	}
+	return resp.Body, txID, nil
+}
+
+// Query runs the contract method without changing states
+func (r *Runtime) Query(args map[string]string, mName string) ([]byte, error) {
+	snap, err := r.db.GetSnapshot()
+	if err != nil {
+		return nil, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to get leveldb snapshot")
+	}
+	defer snap.Release()
+
+	r.lock.Lock()
+	contract := r.contract
+	r.lock.Unlock()
+
+	ctx := newContractContext(args, r.initiator, snap, nil)
+	resp := r.run(contract, ctx, mName)
+	if code.IsStatusError(resp.Status) {
+		return nil, parseResponseError(resp, "failed to query local contract")
+	}
+	return resp.Body, nil
+}
+
+// PutState puts a state of the contract without running the contract, it's used to
+// prepare states written by other contracts, such as files published on xdb
+func (r *Runtime) PutState(key, value []byte) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if err := r.db.Put(append([]byte(prefixState), key...), value, nil); err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to put state")
+	}
+	return nil
+}
+
+// Close closes the LevelDB, the runtime can be opened again by OpenRuntime
+func (r *Runtime) Close() error {
+	runtimesLock.Lock()
+	defer runtimesLock.Unlock()
+
+	delete(runtimes, r.path)
+	if err := r.db.Close(); err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to close leveldb")
+	}
+	return nil
+}
+
+// bind makes the runtime run the contract if it has all methods of the running one,
+// so that both can be invoked on the runtime
+func (r *Runtime) bind(contract interface{}) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	switch {
+	case hasMethods(r.contract, contract):
+	case hasMethods(contract, r.contract):
+		r.contract = contract
+	default:
+		return errorx.New(errorx.ErrCodeConfig, "contract %T mismatches %T running on %s", contract, r.contract, r.path)
+	}
+	return nil
+}
+
+// hasMethods checks whether contract a has all methods of contract b
+func hasMethods(a, b interface{}) bool {
+	ta, tb := reflect.TypeOf(a), reflect.TypeOf(b)
+	for i := 0; i < tb.NumMethod(); i++ {
+		if _, ok := ta.MethodByName(tb.Method(i).Name); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// run finds the contract method by name and runs it, the same as the xchain contract driver does
+func (r *Runtime) run(contract interface{}, ctx code.Context, mName string) (resp code.Response) {
+	defer func() {
+		if err := recover(); err != nil {
+			buf := make([]byte, 64<<10)
+			n := runtime.Stack(buf, false)
+			resp = code.Errors(fmt.Sprintf("panic: %v\n%s", err, buf[:n]))
+		}
+	}()
+
+	methodv := reflect.ValueOf(contract).MethodByName(strings.Title(mName))
+	if !methodv.IsValid() {
+		return code.Errors("bad method " + mName)
+	}
+	method, ok := methodv.Interface().(func(code.Context) code.Response)
+	if !ok {
+		return code.Errors("bad method type " + mName)
+	}
+	return method(ctx)
+}
+
+// putTx records the transaction of the invocation, so that it can be queried by the contract later
+func (r *Runtime) putTx(tr *leveldb.Transaction, args map[string]string, mName string) (string, error) {
+	s, err := json.Marshal(map[string]interface{}{
+		"method": mName,
+		"args":   args,
+		"time":   time.Now().UnixNano(),
+	})
+	if err != nil {
+		return "", errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal transaction")
+	}
+	txID := hex.EncodeToString(hash.HashUsingSha256(s))
+	tx, err := json.Marshal(pb.Transaction{
+		Txid:      txID,
+		Desc:      s,
+		Initiator: r.initiator,
+	})
+	if err != nil {
+		return "", errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal transaction")
+	}
+	if err := tr.Put([]byte(prefixTx+txID), tx, nil); err != nil {
+		return "", errorx.NewCode(err, errorx.ErrCodeInternal, "failed to save transaction")
+	}
+	return txID, nil
+}
+
+// parseResponseError gets the error from response of the contract, which is marshaled by errorx mostly
+func parseResponseError(resp code.Response, msg string) error {
+	if c, m, ok := errorx.TryParseFromString(resp.Message); ok {
+		return errorx.Wrap(errorx.New(c, m), msg)
+	}
+	return errorx.New(errorx.ErrCodeInternal, "%s: %s", msg, resp.Message)
+}
+
+// contractContext implements code.Context over the LevelDB, writer is nil when querying the contract
+type contractContext struct {
+	args      map[string][]byte
+	initiator string
+	reader    reader
+	writer    *leveldb.Transaction
+}
+
+func newContractContext(args map[string]string, initiator string, r reader, w *leveldb.Transaction) *contractContext {
+	bargs := make(map[string][]byte, len(args))
+	for k, v := range args {
+		bargs[k] = []byte(v)
+	}
+	return &contractContext{
+		args:      bargs,
+		initiator: initiator,
+		reader:    r,
+		writer:    w,
+	}
+}
+
+func (c *contractContext) Args() map[string][]byte {
+	return c.args
+}
+
+func (c *contractContext) Caller() string {
+	return ""
+}
+
+func (c *contractContext) Initiator() string {
+	return c.initiator
+}
+
+func (c *contractContext) AuthRequire() []string {
+	return []string{c.initiator}
+}
+
+func (c *contractContext) PutObject(key []byte, value []byte) error {
+	if c.writer == nil {
+		return errorx.New(errorx.ErrCodeInternal, "states can not be changed when querying contract")
+	}
+	return c.writer.Put(append([]byte(prefixState), key...), value, nil)
+}
+
+func (c *contractContext) GetObject(key []byte) ([]byte, error) {
+	return c.reader.Get(append([]byte(prefixState), key...), nil)
+}
+
+func (c *contractContext) DeleteObject(key []byte) error {
+	if c.writer == nil {
+		return errorx.New(errorx.ErrCodeInternal, "states can not be changed when querying contract")
+	}
+	return c.writer.Delete(append([]byte(prefixState), key...), nil)
+}
+
+func (c *contractContext) NewIterator(start, limit []byte) code.Iterator {
+	r := &util.Range{
+		Start: append([]byte(prefixState), start...),
+		Limit: append([]byte(prefixState), limit...),
+	}
+	// nil limit means no upper bound
+	if limit == nil {
+		r.Limit = util.BytesPrefix([]byte(prefixState)).Limit
+	}
+	return &stateIterator{iter: c.reader.NewIterator(r, nil)}
+}
+
+func (c *contractContext) QueryTx(txid string) (*pb.Transaction, error) {
+	s, err := c.reader.Get([]byte(prefixTx+txid), nil)
+	if err != nil {
+		return nil, err
+	}
+	var tx pb.Transaction
+	if err := json.Unmarshal(s, &tx); err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
+
+func (c *contractContext) QueryBlock(blockid string) (*pb.Block, error) {
+	return nil, errNotSupported("QueryBlock")
+}
+
+func (c *contractContext) Transfer(to string, amount *big.Int) error {
+	return errNotSupported("Transfer")
+}
+
+func (c *contractContext) TransferAmount() (*big.Int, error) {
+	return new(big.Int), nil
+}
+
+func (c *contractContext) Call(module, contract, method string, args map[string][]byte) (*code.Response, error) {
+	return nil, errNotSupported("Call")
+}
+
+func (c *contractContext) CrossQuery(uri string, args map[string][]byte) (*code.Response, error) {
+	return nil, errNotSupported("CrossQuery")
+}
+
+func (c *contractContext) EmitEvent(name string, body []byte) error {
+	return nil
+}
+
+func (c *contractContext) EmitJSONEvent(name string, body interface{}) error {
+	return nil
+}
+
+func (c *contractContext) Logf(fmt string, args ...interface{}) {
+	logger.Debugf(fmt, args...)
+}
+
+func errNotSupported(method string) error {
+	return errorx.New(errorx.ErrCodeInternal, "%s is not supported by local blockchain", method)
+}
+
+// stateIterator iterates over states of the contract, the prefix of keys is trimmed
+type stateIterator struct {
+	iter iterator.Iterator
+}
+
+func (i *stateIterator) Key() []byte {
+	k := i.iter.Key()
+	return append([]byte{}, k[len(prefixState):]...)
+}
+
+func (i *stateIterator) Value() []byte {
+	return append([]byte{}, i.iter.Value()...)
+}
+
+func (i *stateIterator) Next() bool {
+	return i.iter.Next()
+}
+
+func (i *stateIterator) Error() error {
+	return i.iter.Error()
+}
+
+func (i *stateIterator) Close() {
+	i.iter.Release()
+}
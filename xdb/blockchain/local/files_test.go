@@ -0,0 +1,285 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/ecdsa"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/hash"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/config"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+	util "github.com/PaddlePaddle/PaddleDTX/xdb/pkgs/strings"
+)
+
+// testOwner publishes namespaces and files on the local blockchain
+type testOwner struct {
+	*Local
+	sk ecdsa.PrivateKey
+	pk ecdsa.PublicKey
+}
+
+func newTestOwner(t *testing.T) *testOwner {
+	l, err := New(&config.LocalChainConf{Path: filepath.Join(t.TempDir(), "chain")})
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Invoker.(*Runtime).Close() })
+	sk, pk, err := ecdsa.GenerateKeyPair()
+	require.NoError(t, err)
+	return &testOwner{Local: l, sk: sk, pk: pk}
+}
+
+func (o *testOwner) sign(t *testing.T, opt interface{}) []byte {
+	msg, err := util.GetSigMessage(opt)
+	require.NoError(t, err)
+	sig, err := ecdsa.Sign(o.sk, hash.HashUsingSha256([]byte(msg)))
+	require.NoError(t, err)
+	return sig[:]
+}
+
+func (o *testOwner) addNs(t *testing.T, name string) {
+	now := time.Now().UnixNano()
+	opt := blockchain.AddNsOptions{
+		Namespace: blockchain.Namespace{
+			Name:       name,
+			Owner:      o.pk[:],
+			Replica:    1,
+			CreateTime: now,
+			UpdateTime: now,
+		},
+	}
+	opt.Signature = o.sign(t, opt)
+	require.NoError(t, o.AddFileNs(&opt))
+}
+
+func (o *testOwner) newFile(ns, name string, version int) blockchain.File {
+	now := time.Now().UnixNano()
+	return blockchain.File{
+		ID:          uuid.NewString(),
+		Name:        name,
+		Namespace:   ns,
+		Owner:       o.pk[:],
+		Slices:      []blockchain.PublicSliceMeta{{ID: uuid.NewString(), NodeID: []byte("node"), StorIndex: "index"}},
+		PublishTime: now,
+		ExpireTime:  now + time.Hour.Nanoseconds(),
+		Version:     version,
+	}
+}
+
+func (o *testOwner) publish(t *testing.T, f blockchain.File) error {
+	opt := blockchain.PublishFileOptions{File: f}
+	opt.Signature = o.sign(t, opt)
+	return o.PublishFile(&opt)
+}
+
+// putLegacyFile stores a file as published before versioning, which has only the filename index
+func (o *testOwner) putLegacyFile(t *testing.T, f blockchain.File) {
+	s, err := json.Marshal(f)
+	require.NoError(t, err)
+	r := o.Invoker.(*Runtime)
+	require.NoError(t, r.PutState([]byte(f.ID), s))
+	index := fmt.Sprintf("index_fn/%x/%s/%s", f.Owner, f.Namespace, f.Name)
+	require.NoError(t, r.PutState([]byte(index), []byte(f.ID)))
+}
+
+func (o *testOwner) versions(t *testing.T, ns, name string, limit int64) []string {
+	fs, err := o.ListFileVersions(&blockchain.ListFileVersionsOptions{
+		Owner:     o.pk[:],
+		Namespace: ns,
+		Name:      name,
+		Limit:     limit,
+	})
+	require.NoError(t, err)
+	var ids []string
+	for _, f := range fs {
+		ids = append(ids, f.ID)
+	}
+	return ids
+}
+
+func TestFileVersions(t *testing.T) {
+	o := newTestOwner(t)
+	o.addNs(t, "ns")
+	require.Empty(t, o.versions(t, "ns", "file", 0))
+
+	// versions start from 1 and increase one by one
+	for _, v := range []int{0, 2} {
+		require.True(t, errorx.Is(o.publish(t, o.newFile("ns", "file", v)), errorx.ErrCodeAlreadyExists))
+	}
+	v1 := o.newFile("ns", "file", 1)
+	require.NoError(t, o.publish(t, v1))
+	v2 := o.newFile("ns", "file", 2)
+	require.NoError(t, o.publish(t, v2))
+	for _, v := range []int{1, 2, 4} {
+		require.True(t, errorx.Is(o.publish(t, o.newFile("ns", "file", v)), errorx.ErrCodeAlreadyExists))
+	}
+
+	// the latest version comes first
+	require.Equal(t, []string{v2.ID, v1.ID}, o.versions(t, "ns", "file", 0))
+	require.Equal(t, []string{v2.ID}, o.versions(t, "ns", "file", 1))
+
+	f, err := o.GetFileByName(o.pk[:], "ns", "file")
+	require.NoError(t, err)
+	require.Equal(t, v2.ID, f.ID)
+	f, err = o.GetFileByVersion(o.pk[:], "ns", "file", 1)
+	require.NoError(t, err)
+	require.Equal(t, v1.ID, f.ID)
+	_, err = o.GetFileByVersion(o.pk[:], "ns", "file", 3)
+	require.Error(t, err)
+
+	// files with other names are versioned separately
+	other := o.newFile("ns", "other", 1)
+	require.NoError(t, o.publish(t, other))
+	require.Equal(t, []string{other.ID}, o.versions(t, "ns", "other", 0))
+	require.Equal(t, []string{v2.ID, v1.ID}, o.versions(t, "ns", "file", 0))
+
+	// the latest version not deleted is got by name
+	del := func(f blockchain.File) {
+		opt := blockchain.DeleteFileOptions{FileID: f.ID, CurrentTime: time.Now().UnixNano()}
+		opt.Signature = o.sign(t, opt)
+		require.NoError(t, o.DeleteFile(&opt))
+	}
+	del(v2)
+	f, err = o.GetFileByName(o.pk[:], "ns", "file")
+	require.NoError(t, err)
+	require.Equal(t, v1.ID, f.ID)
+	_, err = o.GetFileByVersion(o.pk[:], "ns", "file", 2)
+	require.True(t, errorx.Is(err, errorx.ErrCodeNotFound))
+	del(v1)
+	_, err = o.GetFileByName(o.pk[:], "ns", "file")
+	require.True(t, errorx.Is(err, errorx.ErrCodeNotFound))
+}
+
+func TestNestedFileNameVersions(t *testing.T) {
+	o := newTestOwner(t)
+	o.addNs(t, "ns")
+	a := o.newFile("ns", "a", 1)
+	require.NoError(t, o.publish(t, a))
+	ab := o.newFile("ns", "a/b", 1)
+	require.NoError(t, o.publish(t, ab))
+
+	// versions of "a/b" are not taken as versions of "a"
+	require.Equal(t, []string{a.ID}, o.versions(t, "ns", "a", 0))
+	require.Equal(t, []string{ab.ID}, o.versions(t, "ns", "a/b", 0))
+	opt := blockchain.DeleteFileOptions{FileID: a.ID, CurrentTime: time.Now().UnixNano()}
+	opt.Signature = o.sign(t, opt)
+	require.NoError(t, o.DeleteFile(&opt))
+	_, err := o.GetFileByName(o.pk[:], "ns", "a")
+	require.True(t, errorx.Is(err, errorx.ErrCodeNotFound))
+	f, err := o.GetFileByName(o.pk[:], "ns", "a/b")
+	require.NoError(t, err)
+	require.Equal(t, ab.ID, f.ID)
+}
+
+func TestLegacyFileVersions(t *testing.T) {
+	o := newTestOwner(t)
+	o.addNs(t, "ns")
+	legacy := o.newFile("ns", "file", 0)
+	o.putLegacyFile(t, legacy)
+
+	// the file published before versioning is regarded as version 1
+	require.Equal(t, []string{legacy.ID}, o.versions(t, "ns", "file", 0))
+	f, err := o.GetFileByVersion(o.pk[:], "ns", "file", 1)
+	require.NoError(t, err)
+	require.Equal(t, legacy.ID, f.ID)
+
+	require.True(t, errorx.Is(o.publish(t, o.newFile("ns", "file", 1)), errorx.ErrCodeAlreadyExists))
+	v2 := o.newFile("ns", "file", 2)
+	require.NoError(t, o.publish(t, v2))
+	require.Equal(t, []string{v2.ID, legacy.ID}, o.versions(t, "ns", "file", 0))
+	f, err = o.GetFileByVersion(o.pk[:], "ns", "file", 1)
+	require.NoError(t, err)
+	require.Equal(t, legacy.ID, f.ID)
+}
+
+func TestNodeSliceIndexes(t *testing.T) {
+	o := newTestOwner(t)
+	o.addNs(t, "ns")
+	_, npk, err := ecdsa.GenerateKeyPair()
+	require.NoError(t, err)
+	node := []byte(npk.String())
+	f := o.newFile("ns", "file", 1)
+	f.Slices[0].NodeID = node
+	require.NoError(t, o.publish(t, f))
+
+	now := time.Now().UnixNano()
+	files := func(start, end int64) []string {
+		ids, err := o.ListNodeSliceFiles(&blockchain.ListNodeSliceOptions{
+			Target:    node,
+			StartTime: start,
+			EndTime:   end,
+		})
+		require.NoError(t, err)
+		return ids
+	}
+	require.Equal(t, []string{f.ID}, files(now, now+2*time.Hour.Nanoseconds()))
+
+	// the node-slice indexes follow the new expire time
+	opt := blockchain.UpdateExptimeOptions{
+		FileID:        f.ID,
+		NewExpireTime: now + 3*time.Hour.Nanoseconds(),
+		CurrentTime:   now,
+	}
+	opt.Signature = o.sign(t, opt)
+	_, err = o.UpdateFileExpireTime(&opt)
+	require.NoError(t, err)
+	require.Empty(t, files(now, now+2*time.Hour.Nanoseconds()))
+	require.Equal(t, []string{f.ID}, files(now+2*time.Hour.Nanoseconds(), now+4*time.Hour.Nanoseconds()))
+
+	// deleted files are no longer listed
+	dopt := blockchain.DeleteFileOptions{FileID: f.ID, CurrentTime: now}
+	dopt.Signature = o.sign(t, dopt)
+	require.NoError(t, o.DeleteFile(&dopt))
+	require.Empty(t, files(now, now+4*time.Hour.Nanoseconds()))
+}
+
+func TestDeletedSliceIndexes(t *testing.T) {
+	o := newTestOwner(t)
+	o.addNs(t, "ns")
+	_, npk, err := ecdsa.GenerateKeyPair()
+	require.NoError(t, err)
+	node := []byte(npk.String())
+	f := o.newFile("ns", "file", 1)
+	f.Slices[0].NodeID = node
+	require.NoError(t, o.publish(t, f))
+
+	del := func(ctime int64) error {
+		opt := blockchain.DeleteFileOptions{FileID: f.ID, CurrentTime: ctime}
+		opt.Signature = o.sign(t, opt)
+		return o.DeleteFile(&opt)
+	}
+	// the delete time can't be earlier than the publish time
+	require.True(t, errorx.Is(del(f.PublishTime-1), errorx.ErrCodeParam))
+	require.NoError(t, del(f.PublishTime+1))
+
+	opt := &blockchain.ListNodeSliceOptions{Target: node, StartTime: f.PublishTime, EndTime: f.PublishTime + 2}
+	deleted, err := o.ListNodesDeletedSlice(opt)
+	require.NoError(t, err)
+	require.Len(t, deleted, 1)
+	require.Equal(t, f.Slices[0].ID, deleted[0][0])
+
+	// slices missed by scanners of deleted slices are still listed when the file expires
+	opt = &blockchain.ListNodeSliceOptions{Target: node, StartTime: f.ExpireTime, EndTime: f.ExpireTime + 1}
+	expired, err := o.ListNodesExpireSlice(opt)
+	require.NoError(t, err)
+	require.Equal(t, deleted, expired)
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"path/filepath"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"github.com/syndtr/goleveldb/leveldb"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain/xchain"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain/xchain/contract/core"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/config"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+)
+
+// initiator is the only account of the local blockchain, it's also the creator of the contract
+const initiator = "local"
+
+var (
+	logger = logrus.WithField("module", "blockchain.local")
+
+	// runtimes opened in the process by path, nodes running in the same process share the blockchain
+	runtimes     = make(map[string]*Runtime)
+	runtimesLock sync.Mutex
+)
+
+// Local is a single-node blockchain which runs the xchain contract in-process,
+// states of the contract are kept in a LevelDB under the configured path.
+//  It's used in the same way as XChain, and is suitable for tests and single-node use.
+//  The LevelDB can not be opened by more than one process at the same time
+type Local struct {
+	*xchain.XChain
+}
+
+// New opens the local blockchain, the contract is initialized when it's opened the first time
+func New(conf *config.LocalChainConf) (*Local, error) {
+	if conf == nil || len(conf.Path) == 0 {
+		return nil, errorx.New(errorx.ErrCodeConfig, "missing path")
+	}
+	r, err := OpenRuntime(conf.Path, new(core.Xdata))
+	if err != nil {
+		return nil, err
+	}
+	return &Local{
+		XChain: &xchain.XChain{Invoker: r},
+	}, nil
+}
+
+// OpenRuntime opens the runtime of the contract on the LevelDB under path, or returns the one already
+// opened in the process. The contract is initialized when it's opened the first time.
+// A runtime already opened runs the contract extending the other one, such as the contract of dai
+// which extends the one of xdb, and contracts not extending each other can't share a runtime
+func OpenRuntime(path string, contract interface{}) (*Runtime, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, errorx.NewCode(err, errorx.ErrCodeConfig, "invalid path")
+	}
+
+	runtimesLock.Lock()
+	defer runtimesLock.Unlock()
+	if r, ok := runtimes[path]; ok {
+		if err := r.bind(contract); err != nil {
+			return nil, err
+		}
+		return r, nil
+	}
+
+	db, err := leveldb.OpenFile(path, nil)
+	if err != nil {
+		return nil, errorx.NewCode(err, errorx.ErrCodeInternal, "cannot open leveldb")
+	}
+	r := &Runtime{
+		contract:  contract,
+		db:        db,
+		path:      path,
+		initiator: initiator,
+	}
+	// initialize the contract the same as deploying it on xchain
+	if _, err := db.Get([]byte(prefixState+"creator"), nil); err == leveldb.ErrNotFound {
+		if _, _, err := r.Invoke(map[string]string{"creator": initiator}, "Initialize"); err != nil {
+			db.Close()
+			return nil, errorx.Wrap(err, "failed to initialize contract")
+		}
+		logger.WithField("path", path).Info("local blockchain initialized")
+	} else if err != nil {
+		db.Close()
+		return nil, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to read leveldb")
+	}
+
+	runtimes[path] = r
+	return r, nil
+}
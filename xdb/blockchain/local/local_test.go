@@ -0,0 +1,92 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/ecdsa"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/hash"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/config"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+	util "github.com/PaddlePaddle/PaddleDTX/xdb/pkgs/strings"
+)
+
+func TestLocal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chain")
+	l, err := New(&config.LocalChainConf{Path: path})
+	require.NoError(t, err)
+
+	sk, pk, err := ecdsa.GenerateKeyPair()
+	require.NoError(t, err)
+	_, err = l.GetNode([]byte(pk.String()))
+	require.True(t, errorx.Is(err, errorx.ErrCodeNotFound))
+
+	now := time.Now().UnixNano()
+	opt := blockchain.AddNodeOptions{
+		Node: blockchain.Node{
+			ID:       []byte(pk.String()),
+			Name:     "storage1",
+			Address:  "127.0.0.1:8122",
+			Online:   true,
+			RegTime:  now,
+			UpdateAt: now,
+		},
+	}
+	msg, err := util.GetSigMessage(opt)
+	require.NoError(t, err)
+	sig, err := ecdsa.Sign(sk, hash.HashUsingSha256([]byte(msg)))
+	require.NoError(t, err)
+
+	// failed invocation changes nothing
+	opt.Signature = sig[:1]
+	require.Error(t, l.AddNode(&opt))
+	nodes, err := l.ListNodes()
+	require.NoError(t, err)
+	require.Empty(t, nodes)
+
+	opt.Signature = sig[:]
+	require.NoError(t, l.AddNode(&opt))
+	require.True(t, errorx.Is(l.AddNode(&opt), errorx.ErrCodeAlreadyExists))
+
+	// nodes in the same process share the blockchain
+	l2, err := New(&config.LocalChainConf{Path: path})
+	require.NoError(t, err)
+	node, err := l2.GetNode([]byte(pk.String()))
+	require.NoError(t, err)
+	require.Equal(t, "storage1", node.Name)
+
+	// states are kept after reopening
+	require.NoError(t, l.Invoker.(*Runtime).Close())
+	l3, err := New(&config.LocalChainConf{Path: path})
+	require.NoError(t, err)
+	nodes, err = l3.ListNodes()
+	require.NoError(t, err)
+	require.Len(t, nodes, 1)
+	require.Equal(t, opt.Node.ID, nodes[0].ID)
+
+	// contracts not extending the running one can't share the blockchain
+	_, err = OpenRuntime(path, new(bytes.Buffer))
+	require.True(t, errorx.Is(err, errorx.ErrCodeConfig))
+
+	_, err = New(&config.LocalChainConf{})
+	require.True(t, errorx.Is(err, errorx.ErrCodeConfig))
+}
@@ -0,0 +1,216 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/ecdsa"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/hash"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+	util "github.com/PaddlePaddle/PaddleDTX/xdb/pkgs/strings"
+)
+
+// nodeOperate signs the options of a node operation by the private key of the node
+func nodeOperate(t *testing.T, sk ecdsa.PrivateKey, id []byte) *blockchain.NodeOperateOptions {
+	opt := blockchain.NodeOperateOptions{
+		NodeID:      id,
+		Nonce:       time.Now().UnixNano(),
+		CurrentTime: time.Now().UnixNano(),
+	}
+	msg, err := util.GetSigMessage(opt)
+	require.NoError(t, err)
+	sig, err := ecdsa.Sign(sk, hash.HashUsingSha256([]byte(msg)))
+	require.NoError(t, err)
+	opt.Signature = sig[:]
+	return &opt
+}
+
+// updateSlices moves the slices of the file onto the node
+func (o *testOwner) updateSlices(t *testing.T, f *blockchain.File, node []byte, idx ...int) {
+	for _, i := range idx {
+		f.Slices[i].NodeID = node
+		f.Slices[i].StorIndex = uuid.NewString()
+	}
+	opt := blockchain.UpdateFilePSMOptions{
+		FileID:      f.ID,
+		Owner:       o.pk[:],
+		Slices:      f.Slices,
+		CurrentTime: time.Now().UnixNano(),
+	}
+	opt.Signature = o.sign(t, opt)
+	require.NoError(t, o.UpdateFilePublicSliceMeta(&opt))
+}
+
+func TestDrainedNodeOffline(t *testing.T) {
+	o := newTestOwner(t)
+	o.addNs(t, "ns")
+	sk, pk, err := ecdsa.GenerateKeyPair()
+	require.NoError(t, err)
+	node := o.addNode(t, sk, pk)
+	otherSk, otherPk, err := ecdsa.GenerateKeyPair()
+	require.NoError(t, err)
+	other := o.addNode(t, otherSk, otherPk)
+
+	f := o.newFile("ns", "file", 1)
+	f.Slices = []blockchain.PublicSliceMeta{
+		{ID: uuid.NewString(), NodeID: node, StorIndex: "index"},
+		{ID: uuid.NewString(), NodeID: node, StorIndex: "index"},
+		{ID: uuid.NewString(), NodeID: other, StorIndex: "index"},
+	}
+	require.NoError(t, o.publish(t, f))
+	// slices of deleted files are not counted
+	deleted := o.newFile("ns", "deleted", 1)
+	deleted.Slices[0].NodeID = node
+	require.NoError(t, o.publish(t, deleted))
+	dopt := blockchain.DeleteFileOptions{FileID: deleted.ID, CurrentTime: time.Now().UnixNano()}
+	dopt.Signature = o.sign(t, dopt)
+	require.NoError(t, o.DeleteFile(&dopt))
+
+	require.NoError(t, o.NodeDrain(nodeOperate(t, sk, node)))
+	require.Error(t, o.NodeOffline(nodeOperate(t, sk, node)))
+
+	// the node is kept online until all its slices are moved away
+	o.updateSlices(t, &f, other, 0)
+	err = o.NodeOffline(nodeOperate(t, sk, node))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "still has 1 slices")
+
+	// the time signed by the draining node doesn't expire files on it
+	future := blockchain.NodeOperateOptions{NodeID: node, Nonce: time.Now().UnixNano(), CurrentTime: f.ExpireTime + 1}
+	msg, err := util.GetSigMessage(future)
+	require.NoError(t, err)
+	sig, err := ecdsa.Sign(sk, hash.HashUsingSha256([]byte(msg)))
+	require.NoError(t, err)
+	future.Signature = sig[:]
+	err = o.NodeOffline(&future)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "still has 1 slices")
+
+	// slices moved onto the draining node count again
+	o.updateSlices(t, &f, node, 2)
+	err = o.NodeOffline(nodeOperate(t, sk, node))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "still has 2 slices")
+
+	o.updateSlices(t, &f, other, 1, 2)
+	require.NoError(t, o.NodeOffline(nodeOperate(t, sk, node)))
+	n, err := o.GetNode(node)
+	require.NoError(t, err)
+	require.False(t, n.Online)
+	require.False(t, n.Draining)
+
+	// files expired by heartbeats of the other nodes are not counted
+	expiringSk, expiringPk, err := ecdsa.GenerateKeyPair()
+	require.NoError(t, err)
+	expiring := o.addNode(t, expiringSk, expiringPk)
+	g := o.newFile("ns", "expiring", 1)
+	g.Slices[0].NodeID = expiring
+	require.NoError(t, o.publish(t, g))
+	require.NoError(t, o.NodeDrain(nodeOperate(t, expiringSk, expiring)))
+	require.Error(t, o.NodeOffline(nodeOperate(t, expiringSk, expiring)))
+	o.heartbeat(t, otherSk, other, g.ExpireTime+1)
+	require.NoError(t, o.NodeOffline(nodeOperate(t, expiringSk, expiring)))
+}
+
+func TestHeartbeatTimeout(t *testing.T) {
+	o := newTestOwner(t)
+	o.addNs(t, "ns")
+	sk, pk, err := ecdsa.GenerateKeyPair()
+	require.NoError(t, err)
+	node := o.addNode(t, sk, pk)
+	f := o.newFile("ns", "file", 1)
+	f.Slices[0].NodeID = node
+	require.NoError(t, o.publish(t, f))
+
+	n, err := o.GetNode(node)
+	require.NoError(t, err)
+	freq := int64(blockchain.HeartBeatFreq)
+	timeout := func(owner *testOwner, ctime int64) error {
+		opt := blockchain.HeartbeatTimeoutOptions{NodeID: node, FileOwner: owner.pk[:], CurrentTime: ctime}
+		opt.Signature = owner.sign(t, opt)
+		return owner.HeartbeatTimeout(&opt)
+	}
+	reputation := func() blockchain.NodeReputation {
+		r, err := o.GetNodeReputation(&blockchain.GetNodeReputationOptions{NodeID: node, EndTime: math.MaxInt64, Limit: 100})
+		require.NoError(t, err)
+		return r
+	}
+	policy := blockchain.DefaultReputationPolicy
+	window := int64(blockchain.HeartbeatTimeoutWindow)
+
+	// the time signed by the data owner is bounded by heartbeats of the other nodes
+	err = timeout(o, n.UpdateAt+4*freq)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "no other online node")
+	witnessSk, witnessPk, err := ecdsa.GenerateKeyPair()
+	require.NoError(t, err)
+	witness := o.addNode(t, witnessSk, witnessPk)
+	o.heartbeat(t, witnessSk, witness, n.UpdateAt+window)
+
+	// the heartbeat expected right after the last one is tolerated
+	require.Error(t, timeout(o, n.UpdateAt+2*freq-1))
+	// owners not storing files on the node can't penalize it
+	otherSk, otherPk, err := ecdsa.GenerateKeyPair()
+	require.NoError(t, err)
+	other := &testOwner{Local: o.Local, sk: otherSk, pk: otherPk}
+	err = timeout(other, n.UpdateAt+4*freq)
+	require.True(t, errorx.Is(err, errorx.ErrCodeNotAuthorized))
+
+	// a silent node is penalized by the data owner, and missed heartbeats are not penalized twice
+	require.NoError(t, timeout(o, n.UpdateAt+4*freq))
+	require.Equal(t, policy.InitialScore-3*policy.HeartbeatMissedPenalty, reputation().Score)
+	require.Error(t, timeout(o, n.UpdateAt+4*freq))
+	require.NoError(t, timeout(o, n.UpdateAt+5*freq))
+	require.Equal(t, policy.InitialScore-4*policy.HeartbeatMissedPenalty, reputation().Score)
+
+	// the time signed by the data owner can't run far ahead of the latest heartbeat,
+	// and the missed heartbeats penalized at a time are capped
+	require.Error(t, timeout(o, n.UpdateAt+window+1))
+	require.NoError(t, timeout(o, n.UpdateAt+window))
+	require.Equal(t, policy.InitialScore-(4+blockchain.MaxMissedHeartbeats)*policy.HeartbeatMissedPenalty, reputation().Score)
+	require.NoError(t, timeout(o, n.UpdateAt+window))
+	require.Equal(t, policy.InitialScore-9*policy.HeartbeatMissedPenalty, reputation().Score)
+	require.Error(t, timeout(o, n.UpdateAt+window))
+
+	// heartbeats don't penalize the node by the time signed by itself
+	now := n.UpdateAt + window + freq
+	o.heartbeat(t, sk, node, now)
+	require.Error(t, timeout(o, now+2*freq-1))
+
+	// nor by a time the data owner runs ahead of a node which just sent a heartbeat
+	o.heartbeat(t, witnessSk, witness, now)
+	for i := 0; i < 3; i++ {
+		require.Error(t, timeout(o, now+window))
+	}
+	require.Equal(t, policy.InitialScore-9*policy.HeartbeatMissedPenalty, reputation().Score)
+	// heartbeats missed are timed by the other nodes
+	o.heartbeat(t, witnessSk, witness, now+3*freq)
+	require.NoError(t, timeout(o, now+window))
+	require.Error(t, timeout(o, now+window))
+
+	r := reputation()
+	require.Equal(t, policy.InitialScore-11*policy.HeartbeatMissedPenalty, r.Score)
+	require.Len(t, r.History, 5)
+	for _, e := range r.History {
+		require.Equal(t, blockchain.ReputationHeartbeatMissed, e.Type)
+	}
+}
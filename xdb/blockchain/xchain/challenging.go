@@ -58,7 +58,7 @@ func (x *XChain) ChallengeRequest(opt *blockchain.ChallengeRequestOptions) error
 		"opt": string(opts),
 	}
 	mName := "ChallengeRequest"
-	if _, err = x.InvokeContract(args, mName); err != nil {
+	if _, err = x.invokeAuditedContract(args, mName, opt.Signature); err != nil {
 		return err
 	}
 	return nil
@@ -76,13 +76,31 @@ func (x *XChain) ChallengeAnswer(opt *blockchain.ChallengeAnswerOptions) ([]byte
 		"opt": string(opts),
 	}
 	mName := "ChallengeAnswer"
-	resp, err := x.InvokeContract(args, mName)
+	resp, err := x.invokeAuditedContract(args, mName, opt.Signature)
 	if err != nil {
 		return nil, err
 	}
 	return resp, nil
 }
 
+// ChallengeTimeout marks a time-bounded challenge not answered before its deadline as failed on chain
+func (x *XChain) ChallengeTimeout(opt *blockchain.ChallengeTimeoutOptions) error {
+
+	opts, err := json.Marshal(*opt)
+	if err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to marshal ChallengeTimeoutOptions")
+	}
+	args := map[string]string{
+		"opt": string(opts),
+	}
+	mName := "ChallengeTimeout"
+	if _, err = x.invokeAuditedContract(args, mName, opt.Signature); err != nil {
+		return err
+	}
+	return nil
+}
+
 // GetChallengeByID gets a challenge by challengeID
 func (x *XChain) GetChallengeByID(id string) (blockchain.Challenge, error) {
 
@@ -0,0 +1,208 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/hash"
+	"github.com/xuperchain/xuperchain/core/contractsdk/go/code"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+	util "github.com/PaddlePaddle/PaddleDTX/xdb/pkgs/strings"
+)
+
+// fileAuditTx links audit events to the transaction they were recorded in, contracts on xchain
+// can not get the transaction ID, so the initiator fills it in by SetFileAuditTx after the transaction is posted
+type fileAuditTx struct {
+	Initiator string `json:"initiator"`
+	TxID      string `json:"txID"`
+}
+
+// RecordFileDownload records a download of the file in its audit trail
+func (x *Xdata) RecordFileDownload(ctx code.Context) code.Response {
+	// get opt
+	s, ok := ctx.Args()["opt"]
+	if !ok {
+		return code.Error(errorx.New(errorx.ErrCodeParam, "missing param:opt"))
+	}
+	var opt blockchain.RecordFileDownloadOptions
+	if err := json.Unmarshal(s, &opt); err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to unmarshal RecordFileDownloadOptions"))
+	}
+	f, err := x.getFileByID(ctx, []byte(opt.FileID))
+	if err != nil {
+		return code.Error(err)
+	}
+	// verify sig
+	msg, err := util.GetSigMessage(opt)
+	if err != nil {
+		return code.Error(errorx.Internal(err, "failed to get the message to sign"))
+	}
+	if err := x.checkSign(opt.Signature, opt.User, []byte(msg)); err != nil {
+		return code.Error(err)
+	}
+	// appliers download the file by an approved authorization which is not expired
+	if !bytes.Equal(opt.User, f.Owner) {
+		fa, err := x.getFileAuthByID(ctx, opt.AuthID)
+		if err != nil {
+			return code.Error(err)
+		}
+		if fa.FileID != f.ID || !bytes.Equal(fa.Applier, opt.User) || fa.Status != blockchain.FileAuthApproved ||
+			fa.ExpireTime <= opt.CurrentTime {
+			return code.Error(errorx.New(errorx.ErrCodeNotAuthorized, "download of the file is not authorized"))
+		}
+	}
+
+	e := blockchain.FileAuditEvent{
+		FileID:   f.ID,
+		Type:     blockchain.FileAuditDownloaded,
+		Operator: hex.EncodeToString(opt.User),
+		Ref:      opt.AuthID,
+		Time:     opt.CurrentTime,
+	}
+	if err := x.recordFileAudit(ctx, e, opt.Signature); err != nil {
+		return code.Error(err)
+	}
+	return code.OK([]byte("OK"))
+}
+
+// SetFileAuditTx fills in the transaction ID of audit events recorded by an operation,
+// only the initiator of the transaction is allowed to fill it in, and only once
+func (x *Xdata) SetFileAuditTx(ctx code.Context) code.Response {
+	// get opt
+	s, ok := ctx.Args()["opt"]
+	if !ok {
+		return code.Error(errorx.New(errorx.ErrCodeParam, "missing param:opt"))
+	}
+	var opt blockchain.SetFileAuditTxOptions
+	if err := json.Unmarshal(s, &opt); err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to unmarshal SetFileAuditTxOptions"))
+	}
+	index := packFileAuditTxIndex(opt.Digest)
+	v, err := ctx.GetObject([]byte(index))
+	if err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeNotFound, "audit events not found"))
+	}
+	var t fileAuditTx
+	if err := json.Unmarshal(v, &t); err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal, "failed to unmarshal fileAuditTx"))
+	}
+	if t.TxID != "" {
+		return code.Error(errorx.New(errorx.ErrCodeAlreadyExists, "transaction of audit events already set"))
+	}
+	if t.Initiator != ctx.Initiator() {
+		return code.Error(errorx.New(errorx.ErrCodeNotAuthorized, "not the initiator of audit events"))
+	}
+	tx, err := ctx.QueryTx(opt.TxID)
+	if err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeNotFound, "transaction not found"))
+	}
+	if tx.Initiator != t.Initiator {
+		return code.Error(errorx.New(errorx.ErrCodeParam, "transaction is not initiated by the initiator of audit events"))
+	}
+
+	t.TxID = opt.TxID
+	v, err = json.Marshal(t)
+	if err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal fileAuditTx"))
+	}
+	if err := ctx.PutObject([]byte(index), v); err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeWriteBlockchain, "failed to put index-auditTx on xchain"))
+	}
+	return code.OK([]byte("OK"))
+}
+
+// ListFileAuditEvents lists events in the audit trail of a file in time order
+func (x *Xdata) ListFileAuditEvents(ctx code.Context) code.Response {
+	// get opt
+	s, ok := ctx.Args()["opt"]
+	if !ok {
+		return code.Error(errorx.New(errorx.ErrCodeParam, "missing param:opt"))
+	}
+	var opt blockchain.ListFileAuditEventsOptions
+	if err := json.Unmarshal(s, &opt); err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to unmarshal ListFileAuditEventsOptions"))
+	}
+	if opt.StartTime < 0 || (opt.EndTime > 0 && opt.EndTime < opt.StartTime) {
+		return code.Error(errorx.New(errorx.ErrCodeParam, "bad param"))
+	}
+	if _, err := x.getFileByID(ctx, []byte(opt.FileID)); err != nil {
+		return code.Error(err)
+	}
+
+	// get events by prefix, the earliest event comes first
+	var events []blockchain.FileAuditEvent
+	iter := ctx.NewIterator(code.PrefixRange([]byte(packFileAuditFilter(opt.FileID))))
+	defer iter.Close()
+	for iter.Next() {
+		if opt.Limit > 0 && int64(len(events)) >= opt.Limit {
+			break
+		}
+		var e blockchain.FileAuditEvent
+		if err := json.Unmarshal(iter.Value(), &e); err != nil {
+			return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal, "failed to unmarshal FileAuditEvent"))
+		}
+		if e.Time < opt.StartTime || (opt.EndTime > 0 && e.Time > opt.EndTime) {
+			continue
+		}
+		if v, err := ctx.GetObject([]byte(packFileAuditTxIndex(e.Digest))); err == nil {
+			var t fileAuditTx
+			if err := json.Unmarshal(v, &t); err != nil {
+				return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal, "failed to unmarshal fileAuditTx"))
+			}
+			e.TxID = t.TxID
+		}
+		events = append(events, e)
+	}
+	b, err := json.Marshal(events)
+	if err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal FileAuditEvents"))
+	}
+	return code.OK(b)
+}
+
+// recordFileAudit records an event in the audit trail of the file, sig is the signature of
+// the operation causing the event, its digest links the event to the transaction
+func (x *Xdata) recordFileAudit(ctx code.Context, e blockchain.FileAuditEvent, sig []byte) error {
+	e.Digest = hex.EncodeToString(hash.HashUsingSha256(sig))
+	e.ID = packFileAuditEventID(e)
+	s, err := json.Marshal(e)
+	if err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal FileAuditEvent")
+	}
+	if err := ctx.PutObject([]byte(packFileAuditIndex(e.FileID, e.ID)), s); err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeWriteBlockchain, "failed to put index-auditEvent on xchain")
+	}
+
+	// events of an operation covering many files share the same transaction
+	index := packFileAuditTxIndex(e.Digest)
+	if _, err := ctx.GetObject([]byte(index)); err == nil {
+		return nil
+	}
+	t, err := json.Marshal(fileAuditTx{Initiator: ctx.Initiator()})
+	if err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal fileAuditTx")
+	}
+	if err := ctx.PutObject([]byte(index), t); err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeWriteBlockchain, "failed to put index-auditTx on xchain")
+	}
+	return nil
+}
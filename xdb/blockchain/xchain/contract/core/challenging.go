@@ -74,7 +74,7 @@ func (x *Xdata) ListChallengeRequests(ctx code.Context) code.Response {
 		if c.Status != opt.Status || c.ChallengeTime < opt.TimeStart || c.ChallengeTime > opt.TimeEnd {
 			continue
 		}
-		if len(opt.FileID) != 0 && c.FileID != opt.FileID {
+		if len(opt.FileID) != 0 && !c.CoversFile(opt.FileID) {
 			continue
 		}
 		cs = append(cs, c)
@@ -116,6 +116,26 @@ func (x *Xdata) ChallengeRequest(ctx code.Context) code.Response {
 	if err != nil {
 		return code.Error(err)
 	}
+	// deleted files are no longer challenged
+	if f, err := x.getFileByID(ctx, []byte(opt.FileID)); err == nil && f.DeleteTime > 0 {
+		return code.Error(errorx.New(errorx.ErrCodeParam, "bad param, file already deleted"))
+	}
+	if opt.Deadline != 0 && opt.Deadline-opt.ChallengeTime < int64(blockchain.MinAnswerWindow) {
+		return code.Error(errorx.New(errorx.ErrCodeParam,
+			"bad param, deadline should be %s later than challenge time at least", blockchain.MinAnswerWindow))
+	}
+	// time-bounded challenges can't start before the latest heartbeat of the target node,
+	// so that the file owner can't shorten the time to answer by a challenge time in the past
+	if opt.Deadline != 0 {
+		node, err := x.getNode(ctx, opt.TargetNode)
+		if err != nil {
+			return code.Error(err)
+		}
+		if opt.ChallengeTime < node.UpdateAt {
+			return code.Error(errorx.New(errorx.ErrCodeParam,
+				"bad param, challenge time earlier than the latest heartbeat of target node"))
+		}
+	}
 
 	// make challenge
 	c := blockchain.Challenge{
@@ -126,6 +146,7 @@ func (x *Xdata) ChallengeRequest(ctx code.Context) code.Response {
 		Status:             blockchain.ChallengeToProve,
 		ChallengeTime:      opt.ChallengeTime,
 		ChallengeAlgorithm: opt.ChallengeAlgorithm,
+		Deadline:           opt.Deadline,
 	}
 
 	if opt.ChallengeAlgorithm == types.PairingChallengeAlgorithm {
@@ -135,6 +156,12 @@ func (x *Xdata) ChallengeRequest(ctx code.Context) code.Response {
 		c.Round = opt.Round
 		c.RandThisRound = opt.RandThisRound
 		c.Vs = opt.Vs
+		if len(opt.Items) > 0 {
+			if err := x.checkChallengeItems(ctx, &opt); err != nil {
+				return code.Error(err)
+			}
+			c.Items = opt.Items
+		}
 
 	} else if opt.ChallengeAlgorithm == types.MerkleChallengeAlgorithm {
 		c.SliceID = opt.SliceID
@@ -168,6 +195,19 @@ func (x *Xdata) ChallengeRequest(ctx code.Context) code.Response {
 		return code.Error(errorx.NewCode(err, errorx.ErrCodeWriteBlockchain,
 			"failed to set index4Target-ChallengeID on xchain"))
 	}
+	for _, fileID := range c.FileIDs() {
+		e := blockchain.FileAuditEvent{
+			FileID:   fileID,
+			Type:     blockchain.FileAuditChallenged,
+			Operator: hex.EncodeToString(c.FileOwner),
+			Ref:      c.ID,
+			Detail:   fmt.Sprintf("%s challenge to node %s", c.ChallengeAlgorithm, c.TargetNode),
+			Time:     c.ChallengeTime,
+		}
+		if err := x.recordFileAudit(ctx, e, opt.Signature); err != nil {
+			return code.Error(err)
+		}
+	}
 
 	return code.OK([]byte("requested"))
 }
@@ -198,10 +238,15 @@ func (x *Xdata) ChallengeAnswer(ctx code.Context) code.Response {
 		return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
 			"failed to unmarshal Challenge"))
 	}
-	if c.Status == blockchain.ChallengeProved || c.Status == blockchain.ChallengeFailed {
+	if c.Status == blockchain.ChallengeProved {
 		return code.Error(errorx.New(errorx.ErrCodeAlreadyExists,
 			"challenge already answered"))
 	}
+	// answers are rejected once the challenge failed, including the timeout recorded by the file owner
+	if c.Status == blockchain.ChallengeFailed {
+		return code.Error(errorx.New(errorx.ErrCodeAlreadyExists,
+			"challenge already failed or timed out"))
+	}
 
 	// verify signature
 	msg, err := util.GetSigMessage(opt)
@@ -217,8 +262,8 @@ func (x *Xdata) ChallengeAnswer(ctx code.Context) code.Response {
 		return code.Error(err)
 	}
 
-	// judge if file exists
-	f, err := ctx.GetObject([]byte(c.FileID))
+	// judge if file exists, files covered by an aggregated challenge share pairing params with the first one
+	f, err := ctx.GetObject([]byte(c.FileIDs()[0]))
 	if err != nil {
 		return code.Error(errorx.NewCode(err, errorx.ErrCodeNotFound, "File not found"))
 	}
@@ -231,11 +276,29 @@ func (x *Xdata) ChallengeAnswer(ctx code.Context) code.Response {
 	c.AnswerTime = opt.AnswerTime
 	c.Status = blockchain.ChallengeProved
 
-	// sig verification
+	// sig verification, answers of time-bounded challenges fail without verification once the node
+	// sent a heartbeat after the deadline, the answer time signed by the node is not trusted for lateness
 	var verifyErr error
-	if c.ChallengeAlgorithm == types.PairingChallengeAlgorithm {
-		// verify pairing based challenge
-		v, err := xchainClient.VerifyPairingProof(opt.Sigma, opt.Mu, file.RandV, file.RandU, file.PdpPubkey, c.Indices, c.Vs)
+	node, err := x.getNode(ctx, c.TargetNode)
+	if err != nil {
+		return code.Error(err)
+	}
+	late := c.Deadline > 0 && node.UpdateAt > c.Deadline
+	if late {
+		ctx.Logf("bad proof, answered after heartbeat at %d later than deadline %d", node.UpdateAt, c.Deadline)
+		verifyErr = errorx.New(errorx.ErrCodeExpired, "answer deadline missed")
+		c.Status = blockchain.ChallengeFailed
+	} else if c.ChallengeAlgorithm == types.PairingChallengeAlgorithm {
+		// verify pairing based challenge, an aggregated challenge is verified with one proof over all its files
+		indices, vs := c.Indices, c.Vs
+		if len(c.Items) > 0 {
+			indices, vs = nil, nil
+			for _, item := range c.Items {
+				indices = append(indices, item.Indices...)
+				vs = append(vs, item.Vs...)
+			}
+		}
+		v, err := xchainClient.VerifyPairingProof(opt.Sigma, opt.Mu, file.RandV, file.RandU, file.PdpPubkey, indices, vs)
 		if err != nil || !v {
 			ctx.Logf("bad proof, pairing challenge answer wrong, err:%v", err)
 			e := fmt.Errorf("verify pairing based challenge proof failed: %v", err)
@@ -276,12 +339,156 @@ func (x *Xdata) ChallengeAnswer(ctx code.Context) code.Response {
 			"failed to update ChallengeID-Challenge on xchain"))
 	}
 
+	// reward or penalize the target node, late answers are penalized as well
+	eventType := blockchain.ReputationChallengeProved
+	if c.Status == blockchain.ChallengeFailed {
+		eventType = blockchain.ReputationChallengeFailed
+	}
+	if err := x.updateNodeReputation(ctx, c.TargetNode, eventType, c.ID, opt.AnswerTime, 1); err != nil {
+		return code.Error(err)
+	}
+	if err := x.recordChallengeResult(ctx, c, opt.AnswerTime, opt.Signature, verifyErr); err != nil {
+		return code.Error(err)
+	}
+
 	if c.Status == blockchain.ChallengeProved {
 		return code.OK([]byte("answered"))
 	}
 	return code.OK([]byte(verifyErr.Error()))
 }
 
+// ChallengeTimeout marks a time-bounded challenge not answered before its deadline as failed and penalizes
+// the target node, the deadline must have passed by the latest heartbeat of the node as well
+func (x *Xdata) ChallengeTimeout(ctx code.Context) code.Response {
+	var opt blockchain.ChallengeTimeoutOptions
+	// get opt
+	s, ok := ctx.Args()["opt"]
+	if !ok {
+		return code.Error(errorx.New(errorx.ErrCodeParam, "missing param:opt"))
+	}
+	// unmarshal opt
+	if err := json.Unmarshal(s, &opt); err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to unmarshal ChallengeTimeoutOptions"))
+	}
+
+	// judge if challenge exists
+	index := packChallengeIndex(opt.ChallengeID)
+	s, err := ctx.GetObject([]byte(index))
+	if err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeNotFound, "Challenge not found"))
+	}
+	var c blockchain.Challenge
+	if err = json.Unmarshal(s, &c); err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to unmarshal Challenge"))
+	}
+	if c.Status != blockchain.ChallengeToProve {
+		return code.Error(errorx.New(errorx.ErrCodeAlreadyExists, "challenge already answered"))
+	}
+	if c.Deadline == 0 || opt.CurrentTime <= c.Deadline {
+		return code.Error(errorx.New(errorx.ErrCodeParam, "bad param, challenge deadline not reached"))
+	}
+
+	// verify signature
+	msg, err := util.GetSigMessage(opt)
+	if err != nil {
+		return code.Error(errorx.Internal(err, "failed to get the message to sign"))
+	}
+	if err = x.checkSign(opt.Signature, c.FileOwner, []byte(msg)); err != nil {
+		return code.Error(err)
+	}
+	// the time signed by the file owner is not trusted alone, or it could fail the challenge early
+	node, err := x.getNode(ctx, c.TargetNode)
+	if err != nil {
+		return code.Error(err)
+	}
+	if node.UpdateAt <= c.Deadline {
+		return code.Error(errorx.New(errorx.ErrCodeParam, "bad param, no heartbeat of target node after deadline"))
+	}
+
+	c.Status = blockchain.ChallengeFailed
+	s, err = json.Marshal(c)
+	if err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to marshal Challenge"))
+	}
+	if err = ctx.PutObject([]byte(index), s); err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeWriteBlockchain,
+			"failed to update ChallengeID-Challenge on xchain"))
+	}
+	if err := x.updateNodeReputation(ctx, c.TargetNode, blockchain.ReputationChallengeFailed,
+		c.ID, opt.CurrentTime, 1); err != nil {
+		return code.Error(err)
+	}
+	if err := x.recordChallengeResult(ctx, c, opt.CurrentTime, opt.Signature,
+		errorx.New(errorx.ErrCodeExpired, "answer deadline missed")); err != nil {
+		return code.Error(err)
+	}
+	return code.OK([]byte("OK"))
+}
+
+// recordChallengeResult records the result of a challenge in the audit trail of files it covers
+func (x *Xdata) recordChallengeResult(ctx code.Context, c blockchain.Challenge, ctime int64, sig []byte,
+	verifyErr error) error {
+	e := blockchain.FileAuditEvent{
+		Type:     blockchain.FileAuditChallengeProved,
+		Operator: string(c.TargetNode),
+		Ref:      c.ID,
+		Time:     ctime,
+	}
+	if c.Status == blockchain.ChallengeFailed {
+		e.Type = blockchain.FileAuditChallengeFailed
+		e.Detail = verifyErr.Error()
+	}
+	for _, fileID := range c.FileIDs() {
+		e.FileID = fileID
+		if err := x.recordFileAudit(ctx, e, sig); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkChallengeItems checks files covered by an aggregated challenge, they must be alive,
+//  belong to the challenge owner and share pairing params so that one proof answers all of them
+func (x *Xdata) checkChallengeItems(ctx code.Context, opt *blockchain.ChallengeRequestOptions) error {
+	if len(opt.FileID) != 0 || len(opt.SliceIDs) != 0 {
+		return errorx.New(errorx.ErrCodeParam, "bad param, aggregated challenge with fileID or sliceIDs")
+	}
+	var first blockchain.File
+	fileIDs := make(map[string]struct{})
+	for i, item := range opt.Items {
+		if len(item.Indices) == 0 || len(item.Indices) != len(item.Vs) || len(item.Indices) != len(item.SliceIDs) ||
+			len(item.SliceIDs) != len(item.SliceStorIndexes) {
+			return errorx.New(errorx.ErrCodeParam, "bad param, invalid challenge for file %s", item.FileID)
+		}
+		if _, exist := fileIDs[item.FileID]; exist {
+			return errorx.New(errorx.ErrCodeParam, "bad param, duplicated file %s", item.FileID)
+		}
+		fileIDs[item.FileID] = struct{}{}
+
+		f, err := x.getFileByID(ctx, []byte(item.FileID))
+		if err != nil {
+			return err
+		}
+		if f.DeleteTime > 0 {
+			return errorx.New(errorx.ErrCodeParam, "bad param, file %s already deleted", item.FileID)
+		}
+		if !bytes.Equal(f.Owner, opt.FileOwner) {
+			return errorx.New(errorx.ErrCodeParam, "bad param, file %s not owned by challenger", item.FileID)
+		}
+		if i == 0 {
+			first = f
+			continue
+		}
+		if !bytes.Equal(f.RandU, first.RandU) || !bytes.Equal(f.RandV, first.RandV) || !bytes.Equal(f.PdpPubkey, first.PdpPubkey) {
+			return errorx.New(errorx.ErrCodeParam, "bad param, file %s has different pairing params", item.FileID)
+		}
+	}
+	return nil
+}
+
 // GetChallengeByID queries challenge result
 func (x *Xdata) GetChallengeByID(ctx code.Context) code.Response {
 	// get id
@@ -14,7 +14,9 @@
 package core
 
 import (
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 
 	"github.com/xuperchain/xuperchain/core/contractsdk/go/code"
 
@@ -83,6 +85,16 @@ func (x *Xdata) PublishFileAuthApplication(ctx code.Context) code.Response {
 	if err := ctx.PutObject([]byte(authListIndex), []byte(fa.ID)); err != nil {
 		return code.Error(errorx.NewCode(err, errorx.ErrCodeWriteBlockchain, "failed to set index_fileauth_list_applier_authorizer on chain"))
 	}
+	e := blockchain.FileAuditEvent{
+		FileID:   fa.FileID,
+		Type:     blockchain.FileAuditAuthApplied,
+		Operator: hex.EncodeToString(fa.Applier),
+		Ref:      fa.ID,
+		Time:     fa.CreateTime,
+	}
+	if err := x.recordFileAudit(ctx, e, opt.Signature); err != nil {
+		return code.Error(err)
+	}
 
 	return code.OK([]byte("OK"))
 }
@@ -148,6 +160,85 @@ func (x *Xdata) setFileAuthConfirmStatus(ctx code.Context, isConfirm bool) code.
 		return code.Error(errorx.NewCode(err, errorx.ErrCodeWriteBlockchain,
 			"fail to confirm index_fileauth on xchain"))
 	}
+	e := blockchain.FileAuditEvent{
+		FileID:   fa.FileID,
+		Type:     blockchain.FileAuditAuthApproved,
+		Operator: hex.EncodeToString(fa.Authorizer),
+		Ref:      fa.ID,
+		Detail:   fmt.Sprintf("applier %x, expire time %d", fa.Applier, fa.ExpireTime),
+		Time:     opt.CurrentTime,
+	}
+	if !isConfirm {
+		e.Type = blockchain.FileAuditAuthRejected
+		e.Detail = fmt.Sprintf("applier %x, reason: %s", fa.Applier, fa.RejectReason)
+	}
+	if err := x.recordFileAudit(ctx, e, opt.Signature); err != nil {
+		return code.Error(err)
+	}
+	return code.OK([]byte("OK"))
+}
+
+// RevokeFileAuth is called when the dataOwner node revokes the approved file's authorization,
+// the authorization key is removed and appliers can no longer use the file
+func (x *Xdata) RevokeFileAuth(ctx code.Context) code.Response {
+	var opt blockchain.RevokeFileAuthOptions
+	// get opt
+	p, ok := ctx.Args()["opt"]
+	if !ok {
+		return code.Error(errorx.New(errorx.ErrCodeParam, "missing param:opt"))
+	}
+	if err := json.Unmarshal(p, &opt); err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+			"fail to unmarshal RevokeFileAuthOptions"))
+	}
+	// query authorization application detail by authID
+	fa, err := x.getFileAuthByID(ctx, opt.ID)
+	if err != nil {
+		return code.Error(err)
+	}
+	// verify signature by authorizer's public key
+	msg, err := util.GetSigMessage(opt)
+	if err != nil {
+		return code.Error(errorx.Internal(err, "failed to get the message to sign"))
+	}
+	if err := x.checkSign(opt.Signature, fa.Authorizer, []byte(msg)); err != nil {
+		return code.Error(err)
+	}
+
+	// check status
+	if fa.Status != blockchain.FileAuthApproved {
+		return code.Error(errorx.New(errorx.ErrCodeParam,
+			"revoke file auth error, fileAuthStatus is not Approved, authID: %s, fileAuthStatus: %s", fa.ID, fa.Status))
+	}
+	if fa.ExpireTime <= opt.CurrentTime {
+		return code.Error(errorx.New(errorx.ErrCodeParam, "revoke file auth error, authorization already expired, authID: %s", fa.ID))
+	}
+	// update authorization status
+	fa.Status = blockchain.FileAuthRevoked
+	fa.RevokeReason = opt.RevokeReason
+	fa.RevokeTime = opt.CurrentTime
+	fa.AuthKey = nil
+	s, err := json.Marshal(fa)
+	if err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal, "fail to marshal FileAuthApplication"))
+	}
+	// update index_fileauth on xchain
+	index := packFileAuthIndex(fa.ID)
+	if err := ctx.PutObject([]byte(index), s); err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeWriteBlockchain,
+			"fail to revoke index_fileauth on xchain"))
+	}
+	e := blockchain.FileAuditEvent{
+		FileID:   fa.FileID,
+		Type:     blockchain.FileAuditAuthRevoked,
+		Operator: hex.EncodeToString(fa.Authorizer),
+		Ref:      fa.ID,
+		Detail:   fmt.Sprintf("applier %x, reason: %s", fa.Applier, fa.RevokeReason),
+		Time:     opt.CurrentTime,
+	}
+	if err := x.recordFileAudit(ctx, e, opt.Signature); err != nil {
+		return code.Error(err)
+	}
 	return code.OK([]byte("OK"))
 }
 
@@ -14,7 +14,9 @@
 package core
 
 import (
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"strconv"
 	"strings"
 
@@ -74,16 +76,25 @@ func (x *Xdata) PublishFile(ctx code.Context) code.Response {
 			"failed to unmarshal namespace"))
 	}
 
-	// judge if filenameIndex exists
+	// publishing a file with an existing name creates a new version of it
 	filenameIndex := packFileNameIndex(f.Owner, f.Namespace, f.Name)
+	version := 1
 	if id, err := ctx.GetObject([]byte(filenameIndex)); err == nil {
 		fc, err := x.getFileByID(ctx, id)
 		if err != nil {
 			return code.Error(err)
 		}
-		if fc.ExpireTime+blockchain.FileRetainPeriod.Nanoseconds() > opt.File.PublishTime {
-			return code.Error(errorx.New(errorx.ErrCodeAlreadyExists, "duplicated file name"))
+		// files published before versioning have no version index
+		if fc.Version == 0 {
+			if err := ctx.PutObject([]byte(packFileVersionIndex(fc.Owner, fc.Namespace, fc.Name, fc.GetVersion())),
+				[]byte(fc.ID)); err != nil {
+				return code.Error(errorx.NewCode(err, errorx.ErrCodeWriteBlockchain, "failed to set versionIndex-id on chain"))
+			}
 		}
+		version = fc.GetVersion() + 1
+	}
+	if f.Version != version {
+		return code.Error(errorx.New(errorx.ErrCodeAlreadyExists, "file version conflicts, expected version: %d", version))
 	}
 
 	// marshal file
@@ -92,7 +103,6 @@ func (x *Xdata) PublishFile(ctx code.Context) code.Response {
 		return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal File"))
 	}
 
-	// if there's already a file with the same name in user's storage, overwrite it with the new one directly
 	// set id-file on chain
 	if err := ctx.PutObject([]byte(f.ID), s); err != nil {
 		return code.Error(errorx.NewCode(err, errorx.ErrCodeWriteBlockchain, "failed to set id-file on chain"))
@@ -101,6 +111,10 @@ func (x *Xdata) PublishFile(ctx code.Context) code.Response {
 	if err := ctx.PutObject([]byte(filenameIndex), []byte(f.ID)); err != nil {
 		return code.Error(errorx.NewCode(err, errorx.ErrCodeWriteBlockchain, "failed to set index-id on chain"))
 	}
+	// set versionIndex-id on chain
+	if err := ctx.PutObject([]byte(packFileVersionIndex(f.Owner, f.Namespace, f.Name, f.Version)), []byte(f.ID)); err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeWriteBlockchain, "failed to set versionIndex-id on chain"))
+	}
 	// set filenameListIndex-id on chain
 	filenameListIndex := packFileListByOwnerIndex(f.Owner, f.Namespace, f.Name, f.PublishTime)
 	if err := ctx.PutObject([]byte(filenameListIndex), []byte(f.ID)); err != nil {
@@ -130,15 +144,22 @@ func (x *Xdata) PublishFile(ctx code.Context) code.Response {
 	}
 
 	// set node-sliceID-expireTime on chain
-	nodeSice := make(map[string][]string)
-	for _, slice := range f.Slices {
-		nodeSice[string(slice.NodeID)] = append(nodeSice[string(slice.NodeID)], slice.ID+":"+slice.StorIndex)
+	if err := x.putNodeSliceIndexes(ctx, f); err != nil {
+		return code.Error(err)
 	}
-	for nodeID, sliceL := range nodeSice {
-		prefixNodeFileSlice := packNodeSliceIndex(nodeID, f)
-		if err := ctx.PutObject([]byte(prefixNodeFileSlice), []byte(strings.Join(sliceL, ","))); err != nil {
-			return code.Error(errorx.NewCode(err, errorx.ErrCodeWriteBlockchain, "failed to set index-id on chain"))
-		}
+	// set sliceref of deduplicated slices on chain
+	if err := x.addSliceRefs(ctx, f, opt.SliceRefs); err != nil {
+		return code.Error(err)
+	}
+	e := blockchain.FileAuditEvent{
+		FileID:   f.ID,
+		Type:     blockchain.FileAuditPublished,
+		Operator: hex.EncodeToString(f.Owner),
+		Detail:   fmt.Sprintf("version %d, %d slices", f.Version, len(f.Slices)),
+		Time:     f.PublishTime,
+	}
+	if err := x.recordFileAudit(ctx, e, opt.Signature); err != nil {
+		return code.Error(err)
 	}
 
 	return code.OK([]byte("Published"))
@@ -283,8 +304,23 @@ func (x *Xdata) UpdateFilePublicSliceMeta(ctx code.Context) code.Response {
 	if string(f.Owner) != string(opt.Owner) {
 		return code.Error(errorx.New(errorx.ErrCodeNotAuthorized, "bad param, file owner is wrong"))
 	}
+	// slices encrypted under an older key version are not allowed to overwrite the re-encrypted ones
+	if opt.SliceKeyVersion > 0 && opt.SliceKeyVersion < f.GetSliceKeyVersion() {
+		return code.Error(errorx.New(errorx.ErrCodeParam,
+			"slices of the file are already encrypted under a newer key version"))
+	}
+
 	// update slices
+	removed := removedSlices(f.Slices, opt.Slices)
+	if f.DeleteTime == 0 {
+		if err := x.deleteNodeSliceIndexes(ctx, f); err != nil {
+			return code.Error(err)
+		}
+	}
 	f.Slices = opt.Slices
+	if opt.SliceKeyVersion > f.SliceKeyVersion {
+		f.SliceKeyVersion = opt.SliceKeyVersion
+	}
 	nfs, err := json.Marshal(f)
 	if err != nil {
 		return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal Namespaces"))
@@ -293,6 +329,26 @@ func (x *Xdata) UpdateFilePublicSliceMeta(ctx code.Context) code.Response {
 	if err := ctx.PutObject([]byte(f.ID), nfs); err != nil {
 		return code.Error(errorx.NewCode(err, errorx.ErrCodeWriteBlockchain, "failed to set id-file on chain"))
 	}
+	// storage nodes find slices to scrub and to clear by node-slice indexes, so they follow migrated slices
+	if f.DeleteTime == 0 {
+		if err := x.putNodeSliceIndexes(ctx, f); err != nil {
+			return code.Error(err)
+		}
+	}
+	// slices moved away from nodes are no longer to be migrated for their scrub reports
+	if err := x.resolveSliceScrubs(ctx, removed, opt.CurrentTime); err != nil {
+		return code.Error(err)
+	}
+	e := blockchain.FileAuditEvent{
+		FileID:   f.ID,
+		Type:     blockchain.FileAuditSlicesUpdated,
+		Operator: hex.EncodeToString(f.Owner),
+		Detail:   fmt.Sprintf("%d slices, slice key version %d", len(f.Slices), f.GetSliceKeyVersion()),
+		Time:     opt.CurrentTime,
+	}
+	if err := x.recordFileAudit(ctx, e, opt.Signature); err != nil {
+		return code.Error(err)
+	}
 	return code.OK([]byte("OK"))
 }
 
@@ -322,8 +378,19 @@ func (x *Xdata) GetFileByName(ctx code.Context) code.Response {
 	if _, err := ctx.GetObject([]byte(fileNsIndex)); err != nil {
 		return code.Error(errorx.NewCode(err, errorx.ErrCodeParam, "bad param, ns not found"))
 	}
-	// pack filenameindex
+	// pack filenameindex, the latest version is returned if version is not specified
 	index := packFileNameIndex(owner, string(ns), string(name))
+	v, versioned := ctx.Args()["version"]
+	if versioned {
+		version, err := strconv.Atoi(string(v))
+		if err != nil || version <= 0 {
+			return code.Error(errorx.New(errorx.ErrCodeParam, "bad param:version"))
+		}
+		index, err = x.getFileVersionIndex(ctx, owner, string(ns), string(name), version)
+		if err != nil {
+			return code.Error(err)
+		}
+	}
 	// get id from index
 	id, err := ctx.GetObject([]byte(index))
 	if err != nil {
@@ -340,6 +407,18 @@ func (x *Xdata) GetFileByName(ctx code.Context) code.Response {
 		return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
 			"failed to unmarshal File"))
 	}
+	// the latest version not deleted is returned if the latest one was deleted
+	if f.DeleteTime > 0 && !versioned {
+		if f, err = x.getLatestUndeletedFile(ctx, owner, string(ns), string(name)); err != nil {
+			return code.Error(err)
+		}
+		if s, err = json.Marshal(f); err != nil {
+			return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal File"))
+		}
+	}
+	if f.DeleteTime > 0 {
+		return code.Error(errorx.New(errorx.ErrCodeNotFound, "file already deleted"))
+	}
 	if f.ExpireTime < ctime {
 		return code.Error(errorx.New(errorx.ErrCodeNotFound, "file already expire"))
 	}
@@ -366,6 +445,9 @@ func (x *Xdata) GetFileByID(ctx code.Context) code.Response {
 	if err = json.Unmarshal(fs, &f); err != nil {
 		return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal, "failed to unmarshal File"))
 	}
+	if f.DeleteTime > 0 {
+		return code.Error(errorx.New(errorx.ErrCodeNotFound, "file already deleted"))
+	}
 	if f.ExpireTime < ctime {
 		return code.Error(errorx.New(errorx.ErrCodeExpired, "file already expire"))
 	}
@@ -391,6 +473,9 @@ func (x *Xdata) UpdateFileExpireTime(ctx code.Context) code.Response {
 		return code.Error(err)
 	}
 
+	if f.DeleteTime > 0 {
+		return code.Error(errorx.New(errorx.ErrCodeNotFound, "file already deleted"))
+	}
 	if f.ExpireTime+blockchain.FileRetainPeriod.Nanoseconds() <= opt.CurrentTime {
 		return code.Error(errorx.New(errorx.ErrCodeParam, "file already expired over 7 days"))
 	}
@@ -408,6 +493,10 @@ func (x *Xdata) UpdateFileExpireTime(ctx code.Context) code.Response {
 		return code.Error(err)
 	}
 
+	// node-slice indexes are sorted by expire time
+	if err := x.deleteNodeSliceIndexes(ctx, f); err != nil {
+		return code.Error(err)
+	}
 	// marshal file
 	f.ExpireTime = opt.NewExpireTime
 	nf, err := json.Marshal(f)
@@ -418,6 +507,94 @@ func (x *Xdata) UpdateFileExpireTime(ctx code.Context) code.Response {
 	if err := ctx.PutObject([]byte(f.ID), nf); err != nil {
 		return code.Error(errorx.NewCode(err, errorx.ErrCodeWriteBlockchain, "failed to set id-file on chain"))
 	}
+	if err := x.putNodeSliceIndexes(ctx, f); err != nil {
+		return code.Error(err)
+	}
+	e := blockchain.FileAuditEvent{
+		FileID:   f.ID,
+		Type:     blockchain.FileAuditExpireTimeUpdated,
+		Operator: hex.EncodeToString(f.Owner),
+		Detail:   fmt.Sprintf("new expire time %d", f.ExpireTime),
+		Time:     opt.CurrentTime,
+	}
+	if err := x.recordFileAudit(ctx, e, opt.Signature); err != nil {
+		return code.Error(err)
+	}
+	return code.OK(nf)
+}
+
+// DeleteFile marks a file as deleted before it expires,
+// and moves its slices to the deleted slice index of storage nodes, so that they can be cleared right away
+func (x *Xdata) DeleteFile(ctx code.Context) code.Response {
+	// get DeleteFileOptions
+	s, ok := ctx.Args()["opt"]
+	if !ok {
+		return code.Error(errorx.New(errorx.ErrCodeParam, "missing param:opt"))
+	}
+	// unmarshal opt
+	var opt blockchain.DeleteFileOptions
+	if err := json.Unmarshal(s, &opt); err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to unmarshal DeleteFileOptions"))
+	}
+	// get file from id
+	f, err := x.getFileByID(ctx, []byte(opt.FileID))
+	if err != nil {
+		return code.Error(err)
+	}
+
+	// verify sig
+	msg, err := util.GetSigMessage(opt)
+	if err != nil {
+		return code.Error(errorx.Internal(err, "failed to get the message to sign"))
+	}
+	err = x.checkSign(opt.Signature, f.Owner, []byte(msg))
+	if err != nil {
+		return code.Error(err)
+	}
+
+	if f.DeleteTime > 0 {
+		return code.Error(errorx.New(errorx.ErrCodeAlreadyExists, "file already deleted"))
+	}
+	// the time is signed by the owner, and must fall between the publish time and the end of the retain period
+	if opt.CurrentTime < f.PublishTime {
+		return code.Error(errorx.New(errorx.ErrCodeParam, "bad param:currentTime, earlier than the publish time"))
+	}
+	if f.ExpireTime+blockchain.FileRetainPeriod.Nanoseconds() <= opt.CurrentTime {
+		return code.Error(errorx.New(errorx.ErrCodeParam, "file already expired over 7 days"))
+	}
+
+	// marshal file
+	f.DeleteTime = opt.CurrentTime
+	nf, err := json.Marshal(f)
+	if err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal File"))
+	}
+	// set id-file on chain
+	if err := ctx.PutObject([]byte(f.ID), nf); err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeWriteBlockchain, "failed to set id-file on chain"))
+	}
+
+	// add node-sliceID to deleteTime index, the expireTime index is kept so that slices missed
+	// by scanners of the deleteTime index are still cleared when the file expires
+	for nodeID, sliceL := range groupNodeSlices(f) {
+		prefixNodeDeletedSlice := packNodeDeletedSliceIndex(nodeID, f)
+		if err := ctx.PutObject([]byte(prefixNodeDeletedSlice), []byte(strings.Join(sliceL, ","))); err != nil {
+			return code.Error(errorx.NewCode(err, errorx.ErrCodeWriteBlockchain, "failed to set index-id on chain"))
+		}
+	}
+	if err := x.resolveSliceScrubs(ctx, f.Slices, opt.CurrentTime); err != nil {
+		return code.Error(err)
+	}
+	e := blockchain.FileAuditEvent{
+		FileID:   f.ID,
+		Type:     blockchain.FileAuditDeleted,
+		Operator: hex.EncodeToString(f.Owner),
+		Time:     opt.CurrentTime,
+	}
+	if err := x.recordFileAudit(ctx, e, opt.Signature); err != nil {
+		return code.Error(err)
+	}
 	return code.OK(nf)
 }
 
@@ -462,9 +639,103 @@ func (x *Xdata) SliceMigrateRecord(ctx code.Context) code.Response {
 	if err := ctx.PutObject([]byte(hindex), b); err != nil {
 		return code.Error(errorx.NewCode(err, errorx.ErrCodeWriteBlockchain, "failed to put index-mirgate on xchain"))
 	}
+	e := blockchain.FileAuditEvent{
+		FileID:   file.ID,
+		Type:     blockchain.FileAuditSliceMigrated,
+		Operator: hex.EncodeToString(file.Owner),
+		Ref:      opt.SliceID,
+		Detail:   fmt.Sprintf("migrated from node %s", opt.NodeID),
+		Time:     opt.CurrentTime,
+	}
+	if err := x.recordFileAudit(ctx, e, opt.Signature); err != nil {
+		return code.Error(err)
+	}
 	return code.OK([]byte("ok"))
 }
 
+// getFileVersionIndex gets the index of the specified version of a file,
+// the file published before versioning is regarded as version 1, and only has filename index
+func (x *Xdata) getFileVersionIndex(ctx code.Context, owner []byte, ns, name string, version int) (string, error) {
+	index := packFileVersionIndex(owner, ns, name, version)
+	if _, err := ctx.GetObject([]byte(index)); err == nil || version != 1 {
+		return index, nil
+	}
+	return packFileNameIndex(owner, ns, name), nil
+}
+
+// getLatestUndeletedFile gets the latest version of a file which is not deleted
+func (x *Xdata) getLatestUndeletedFile(ctx code.Context, owner []byte, ns, name string) (f blockchain.File, err error) {
+	iter := ctx.NewIterator(code.PrefixRange([]byte(packFileVersionFilter(owner, ns, name))))
+	defer iter.Close()
+
+	for iter.Next() {
+		if f, err = x.getFileByID(ctx, iter.Value()); err != nil {
+			return f, err
+		}
+		// the prefix also matches versions of files whose names continue with "/"
+		if f.Namespace != ns || f.Name != name {
+			continue
+		}
+		if f.DeleteTime == 0 {
+			return f, nil
+		}
+	}
+	return f, errorx.New(errorx.ErrCodeNotFound, "file already deleted")
+}
+
+// ListFileVersions lists versions of a file from xchain, the latest version comes first
+func (x *Xdata) ListFileVersions(ctx code.Context) code.Response {
+	// get opt
+	s, ok := ctx.Args()["opt"]
+	if !ok {
+		return code.Error(errorx.New(errorx.ErrCodeParam, "missing param:opt"))
+	}
+	// unmarshal opt
+	var opt blockchain.ListFileVersionsOptions
+	if err := json.Unmarshal(s, &opt); err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to unmarshal ListFileVersionsOptions"))
+	}
+
+	// get iter by prefix
+	iter := ctx.NewIterator(code.PrefixRange([]byte(packFileVersionFilter(opt.Owner, opt.Namespace, opt.Name))))
+	defer iter.Close()
+
+	// iterate iter
+	var fs []blockchain.File
+	for iter.Next() {
+		if opt.Limit > 0 && int64(len(fs)) >= opt.Limit {
+			break
+		}
+		f, err := x.getFileByID(ctx, iter.Value())
+		if err != nil {
+			return code.Error(err)
+		}
+		// the prefix also matches versions of files whose names continue with "/"
+		if f.Namespace != opt.Namespace || f.Name != opt.Name {
+			continue
+		}
+		fs = append(fs, f)
+	}
+	// the file published before versioning has no version index
+	if len(fs) == 0 {
+		if id, err := ctx.GetObject([]byte(packFileNameIndex(opt.Owner, opt.Namespace, opt.Name))); err == nil {
+			f, err := x.getFileByID(ctx, id)
+			if err != nil {
+				return code.Error(err)
+			}
+			fs = append(fs, f)
+		}
+	}
+
+	s, err := json.Marshal(fs)
+	if err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to marshal Files"))
+	}
+	return code.OK(s)
+}
+
 // ListFiles lists files from xchain
 func (x *Xdata) ListFiles(ctx code.Context) code.Response {
 	// get opt
@@ -495,7 +766,8 @@ func (x *Xdata) ListFiles(ctx code.Context) code.Response {
 		if err != nil {
 			return code.Error(err)
 		}
-		if f.PublishTime < opt.TimeStart || (opt.TimeEnd > 0 && f.PublishTime > opt.TimeEnd) || f.ExpireTime <= opt.CurrentTime {
+		if f.PublishTime < opt.TimeStart || (opt.TimeEnd > 0 && f.PublishTime > opt.TimeEnd) || f.ExpireTime <= opt.CurrentTime ||
+			f.DeleteTime > 0 {
 			continue
 		}
 		fs = append(fs, f)
@@ -539,7 +811,7 @@ func (x *Xdata) ListExpiredFiles(ctx code.Context) code.Response {
 		if err != nil {
 			return code.Error(err)
 		}
-		if f.PublishTime < opt.TimeStart || (opt.TimeEnd > 0 && f.PublishTime > opt.TimeEnd) {
+		if f.PublishTime < opt.TimeStart || (opt.TimeEnd > 0 && f.PublishTime > opt.TimeEnd) || f.DeleteTime > 0 {
 			continue
 		}
 		if f.ExpireTime < opt.CurrentTime-blockchain.FileRetainPeriod.Nanoseconds() || f.ExpireTime > opt.CurrentTime {
@@ -661,3 +933,23 @@ func (x *Xdata) checkSign(sign, owner, mes []byte) (err error) {
 	}
 	return nil
 }
+
+// putNodeSliceIndexes sets node-sliceID-expireTime indexes of the file, by which storage nodes find their slices
+func (x *Xdata) putNodeSliceIndexes(ctx code.Context, f blockchain.File) error {
+	for nodeID, sliceL := range groupNodeSlices(f) {
+		if err := ctx.PutObject([]byte(packNodeSliceIndex(nodeID, f)), []byte(strings.Join(sliceL, ","))); err != nil {
+			return errorx.NewCode(err, errorx.ErrCodeWriteBlockchain, "failed to set index-id on chain")
+		}
+	}
+	return nil
+}
+
+// deleteNodeSliceIndexes removes node-sliceID-expireTime indexes of the file
+func (x *Xdata) deleteNodeSliceIndexes(ctx code.Context, f blockchain.File) error {
+	for nodeID := range groupNodeSlices(f) {
+		if err := ctx.DeleteObject([]byte(packNodeSliceIndex(nodeID, f))); err != nil {
+			return errorx.NewCode(err, errorx.ErrCodeWriteBlockchain, "failed to delete index-id on chain")
+		}
+	}
+	return nil
+}
@@ -14,7 +14,11 @@
 package core
 
 import (
+	"encoding/json"
+
 	"github.com/xuperchain/xuperchain/core/contractsdk/go/code"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
 )
 
 type Xdata struct {
@@ -28,5 +32,18 @@ func (x *Xdata) Initialize(ctx code.Context) code.Response {
 	if err := ctx.PutObject([]byte("creator"), creator); err != nil {
 		return code.Error(err)
 	}
+	// reputation policy of storage nodes is optional, the default one is used if not given
+	if p, ok := ctx.Args()["reputationPolicy"]; ok {
+		var policy blockchain.ReputationPolicy
+		if err := json.Unmarshal(p, &policy); err != nil {
+			return code.Error(err)
+		}
+		if err := checkReputationPolicy(policy); err != nil {
+			return code.Error(err)
+		}
+		if err := ctx.PutObject([]byte(reputationPolicyKey), p); err != nil {
+			return code.Error(err)
+		}
+	}
 	return code.OK(nil)
 }
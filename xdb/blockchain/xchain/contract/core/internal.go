@@ -25,6 +25,7 @@ import (
 const (
 	// Define the contract prefix key of file and file namespace operations
 	prefixFilenameIndex        = "index_fn"
+	prefixFileVersionIndex     = "index_fver"
 	prefixFileListByOwnerIndex = "index_fo_list"
 	prefixFileListByNsIndex    = "index_fs_list"
 	prefixFileNsIndex          = "index_fns"
@@ -44,7 +45,22 @@ const (
 	prefixNodeHeartbeatIndex    = "index_hbnode"
 	prefixNodeSliceMigrateIndex = "index_slicemigrate"
 	prefixNodeFileSlice         = "index_fslice"
+	prefixNodeDeletedSlice      = "index_dslice"
 	prefixNodeNonceIndex        = "index_ndnonce"
+	// Define the contract prefix key of storage node reputation operations
+	prefixNodeReputationIndex   = "index_nrep"
+	prefixNodeReputationHistory = "index_nrep_hist"
+	// Define the contract prefix key of deduplicated slice operations
+	prefixSliceRefIndex  = "index_sliceref"
+	prefixSliceHashIndex = "index_slicehash"
+	// Define the contract prefix key of slice scrub report operations
+	prefixSliceScrubIndex      = "index_scrub"
+	prefixSliceScrubNodeIndex  = "index_scrub_node"
+	prefixSliceScrubOwnerIndex = "index_scrub_owner"
+	prefixSliceScrubOpenIndex  = "index_scrub_open"
+	// Define the contract prefix key of file audit trail operations
+	prefixFileAuditIndex   = "index_faudit"
+	prefixFileAuditTxIndex = "index_faudit_tx"
 )
 
 func packNodeIndex(nodeID []byte) string {
@@ -63,16 +79,61 @@ func packNonceIndex(node []byte, nonce int64) string {
 	return fmt.Sprintf("%s/%x/%d", prefixNodeNonceIndex, node, nonce)
 }
 
+func packNodeReputationIndex(nodeID []byte) string {
+	return fmt.Sprintf("%s/%s", prefixNodeReputationIndex, nodeID)
+}
+
+func packNodeReputationHistoryIndex(e blockchain.ReputationEvent) string {
+	return fmt.Sprintf("%s/%s/%d/%s/%s", prefixNodeReputationHistory, e.NodeID, subByInt64Max(e.Time), e.Type, e.Ref)
+}
+
+func packNodeReputationHistoryFilter(nodeID []byte) string {
+	return fmt.Sprintf("%s/%s/", prefixNodeReputationHistory, nodeID)
+}
+
 func packNodeSliceIndex(node string, f blockchain.File) string {
 	return fmt.Sprintf("%s/%s/%d/%s", prefixNodeFileSlice, node, f.ExpireTime, f.ID)
 }
 
+// groupNodeSlices groups slices of the file by storage node, values are the "sliceID:storIndex" list of node-slice index
+func groupNodeSlices(f blockchain.File) map[string][]string {
+	nodeSlice := make(map[string][]string)
+	for _, slice := range f.Slices {
+		nodeSlice[string(slice.NodeID)] = append(nodeSlice[string(slice.NodeID)], slice.ID+":"+slice.StorIndex)
+	}
+	return nodeSlice
+}
+
+// removedSlices returns slices no longer stored on their nodes after slices are updated
+func removedSlices(old, slices []blockchain.PublicSliceMeta) []blockchain.PublicSliceMeta {
+	kept := make(map[string]struct{})
+	for _, s := range slices {
+		kept[s.ID+"/"+string(s.NodeID)] = struct{}{}
+	}
+	var removed []blockchain.PublicSliceMeta
+	for _, s := range old {
+		if _, ok := kept[s.ID+"/"+string(s.NodeID)]; !ok {
+			removed = append(removed, s)
+		}
+	}
+	return removed
+}
+
 func packNodeSliceFilter(target string) string {
 	return fmt.Sprintf("%s/%s/", prefixNodeFileSlice, target)
 }
 
+func packNodeDeletedSliceIndex(node string, f blockchain.File) string {
+	return fmt.Sprintf("%s/%s/%d/%s", prefixNodeDeletedSlice, node, f.DeleteTime, f.ID)
+}
+
+func packNodeDeletedSliceFilter(target string) string {
+	return fmt.Sprintf("%s/%s/", prefixNodeDeletedSlice, target)
+}
+
 // getNodeSliceFileID return fileID and file's expireTime by contract key
 // Example: string(key) = index_fslice/nodeID/expireTime/fileID
+// For deleted slices, string(key) = index_dslice/nodeID/deleteTime/fileID, and deleteTime is returned
 func getNodeSliceFileID(key []byte) (string, int64) {
 	strArr := strings.Split(string(key), "/")
 	expireTime, err := strconv.ParseInt(strArr[2], 10, 64)
@@ -101,10 +162,72 @@ func getNodeSliceMigrateTime(key []byte) int64 {
 	return subByInt64Max(expireTime)
 }
 
+func packSliceRefIndex(sliceID string) string {
+	return fmt.Sprintf("%s/%s", prefixSliceRefIndex, sliceID)
+}
+
+func packSliceHashIndex(owner, sealedHash []byte) string {
+	return fmt.Sprintf("%s/%x/%x", prefixSliceHashIndex, owner, sealedHash)
+}
+
+func packSliceScrubIndex(node, sliceID string) string {
+	return fmt.Sprintf("%s/%s/%s", prefixSliceScrubIndex, node, sliceID)
+}
+
+func packSliceScrubNodeIndex(r blockchain.SliceScrubReport) string {
+	return fmt.Sprintf("%s/%s/%d/%s", prefixSliceScrubNodeIndex, r.NodeID, subByInt64Max(r.ScrubTime), r.SliceID)
+}
+
+func packSliceScrubOwnerIndex(r blockchain.SliceScrubReport) string {
+	return fmt.Sprintf("%s/%x/%d/%s/%s", prefixSliceScrubOwnerIndex, r.Owner, subByInt64Max(r.ScrubTime), r.SliceID, r.NodeID)
+}
+
+func packSliceScrubOpenIndex(r blockchain.SliceScrubReport) string {
+	return fmt.Sprintf("%s/%x/%s/%s/%s", prefixSliceScrubOpenIndex, r.Owner, r.FileID, r.SliceID, r.NodeID)
+}
+
+func packSliceScrubOpenFilter(owner []byte) string {
+	return fmt.Sprintf("%s/%x/", prefixSliceScrubOpenIndex, owner)
+}
+
+// packSliceScrubFilter used to list scrub reports of a node if target is given, otherwise of an owner
+func packSliceScrubFilter(target, owner []byte) string {
+	if len(target) > 0 {
+		return fmt.Sprintf("%s/%s/", prefixSliceScrubNodeIndex, target)
+	}
+	return fmt.Sprintf("%s/%x/", prefixSliceScrubOwnerIndex, owner)
+}
+
+// packFileAuditEventID makes ID of the event sortable by time
+func packFileAuditEventID(e blockchain.FileAuditEvent) string {
+	return fmt.Sprintf("%019d_%s", e.Time, e.Digest[:16])
+}
+
+func packFileAuditIndex(fileID, eventID string) string {
+	return fmt.Sprintf("%s/%s/%s", prefixFileAuditIndex, fileID, eventID)
+}
+
+func packFileAuditFilter(fileID string) string {
+	return fmt.Sprintf("%s/%s/", prefixFileAuditIndex, fileID)
+}
+
+func packFileAuditTxIndex(digest string) string {
+	return fmt.Sprintf("%s/%s", prefixFileAuditTxIndex, digest)
+}
+
 func packFileNameIndex(owner []byte, ns, name string) string {
 	return fmt.Sprintf("%s/%x/%s/%s", prefixFilenameIndex, owner, ns, name)
 }
 
+func packFileVersionIndex(owner []byte, ns, name string, version int) string {
+	return fmt.Sprintf("%s/%x/%s/%s/%d", prefixFileVersionIndex, owner, ns, name, subByInt64Max(int64(version)))
+}
+
+// packFileVersionFilter used to list versions of a file, the latest version comes first
+func packFileVersionFilter(owner []byte, ns, name string) string {
+	return fmt.Sprintf("%s/%x/%s/%s/", prefixFileVersionIndex, owner, ns, name)
+}
+
 func packFileListByOwnerIndex(owner []byte, ns, name string, pubTime int64) string {
 	return fmt.Sprintf("%s/%x/%s/%d/%s", prefixFileListByOwnerIndex, owner, ns, subByInt64Max(pubTime), name)
 }
@@ -14,8 +14,10 @@
 package core
 
 import (
+	"bytes"
 	"encoding/hex"
 	"encoding/json"
+	"sort"
 	"strconv"
 
 	"github.com/PaddlePaddle/PaddleDTX/crypto/core/ecdsa"
@@ -175,6 +177,23 @@ func (x *Xdata) setNodeOnlineStatus(ctx code.Context, online bool) code.Response
 			"failed to unmarshal node"))
 	}
 
+	// draining node goes offline only after all its slices are moved, and then it is decommissioned,
+	// files are judged expired by heartbeats of the other nodes instead of the time signed by the node
+	if !online && node.Draining {
+		now, err := x.othersHeartbeatTime(ctx, opt.NodeID)
+		if err != nil {
+			return code.Error(err)
+		}
+		num, err := x.countNodeSlices(ctx, opt.NodeID, now)
+		if err != nil {
+			return code.Error(err)
+		}
+		if num > 0 {
+			return code.Error(errorx.New(errorx.ErrCodeParam, "draining node still has %d slices", num))
+		}
+		node.Draining = false
+	}
+
 	if node.Online != online {
 		// change status
 		node.Online = online
@@ -199,6 +218,137 @@ func (x *Xdata) setNodeOnlineStatus(ctx code.Context, online bool) code.Response
 	return code.OK([]byte("OK"))
 }
 
+// NodeDrain sets the online node to draining, so that its slices are moved to other nodes by data owners
+func (x *Xdata) NodeDrain(ctx code.Context) code.Response {
+	s, ok := ctx.Args()["opt"]
+	if !ok {
+		return code.Error(errorx.New(errorx.ErrCodeParam, "missing param:opt"))
+	}
+	var opt blockchain.NodeOperateOptions
+	if err := json.Unmarshal(s, &opt); err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal, "failed to unmarshal NodeOperateOptions"))
+	}
+	// check nonce
+	if err := checkAndSetNonce(ctx, opt.NodeID, opt.Nonce); err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal, "failed to check nonce"))
+	}
+	// verify sig
+	npk, err := hex.DecodeString(string(opt.NodeID))
+	if err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal, "failed to decode nodeID"))
+	}
+	msg, err := util.GetSigMessage(opt)
+	if err != nil {
+		return code.Error(errorx.Internal(err, "failed to get the message to sign"))
+	}
+	if err := x.checkSign(opt.Signature, npk, []byte(msg)); err != nil {
+		return code.Error(err)
+	}
+
+	// get node by index
+	index := packNodeIndex(opt.NodeID)
+	oldn, err := ctx.GetObject([]byte(index))
+	if err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeNotFound, "node not found"))
+	}
+	var node blockchain.Node
+	if err := json.Unmarshal(oldn, &node); err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal, "failed to unmarshal node"))
+	}
+	// slices are pulled from the draining node when they are moved, so it must be online
+	if !node.Online {
+		return code.Error(errorx.New(errorx.ErrCodeParam, "node is offline"))
+	}
+	if node.Draining {
+		return code.OK([]byte("OK"))
+	}
+
+	node.Draining = true
+	newn, err := json.Marshal(node)
+	if err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal node"))
+	}
+	// put index-node on xchain
+	if err := ctx.PutObject([]byte(index), newn); err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeWriteBlockchain, "failed to put index-Node on xchain"))
+	}
+	// put listIndex-node on xchain
+	index = packNodeListIndex(node)
+	if err := ctx.PutObject([]byte(index), newn); err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeWriteBlockchain, "failed to put listIndex-Node on xchain"))
+	}
+	return code.OK([]byte("OK"))
+}
+
+// getNode gets the node by its ID
+func (x *Xdata) getNode(ctx code.Context, nodeID []byte) (n blockchain.Node, err error) {
+	s, err := ctx.GetObject([]byte(packNodeIndex(nodeID)))
+	if err != nil {
+		return n, errorx.NewCode(err, errorx.ErrCodeNotFound, "node not found")
+	}
+	if err = json.Unmarshal(s, &n); err != nil {
+		return n, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to unmarshal node")
+	}
+	return n, nil
+}
+
+// countNodeSlices counts slices of the undeleted files stored on the node and unexpired by the time,
+// the node slice index follows slice updates, and slices are checked against the files it points to
+func (x *Xdata) countNodeSlices(ctx code.Context, nodeID []byte, currentTime int64) (int, error) {
+	iter := ctx.NewIterator(code.PrefixRange([]byte(packNodeSliceFilter(string(nodeID)))))
+	defer iter.Close()
+
+	num := 0
+	counted := make(map[string]struct{})
+	for iter.Next() {
+		fileID, _ := getNodeSliceFileID(iter.Key())
+		if _, exist := counted[fileID]; exist {
+			continue
+		}
+		counted[fileID] = struct{}{}
+		f, err := x.getFileByID(ctx, []byte(fileID))
+		if err != nil {
+			if errorx.Is(err, errorx.ErrCodeNotFound) {
+				continue
+			}
+			return 0, err
+		}
+		if f.DeleteTime > 0 || f.ExpireTime <= currentTime {
+			continue
+		}
+		for _, slice := range f.Slices {
+			if bytes.Equal(slice.NodeID, nodeID) {
+				num++
+			}
+		}
+	}
+	return num, nil
+}
+
+// othersHeartbeatTime gets the median of the latest heartbeats of online nodes other than the given one,
+// it's a time no single node can move, and 0 if there is no other online node
+func (x *Xdata) othersHeartbeatTime(ctx code.Context, nodeID []byte) (int64, error) {
+	iter := ctx.NewIterator(code.PrefixRange([]byte(prefixNodeListIndex)))
+	defer iter.Close()
+
+	var times []int64
+	for iter.Next() {
+		var node blockchain.Node
+		if err := json.Unmarshal(iter.Value(), &node); err != nil {
+			return 0, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to unmarshal node")
+		}
+		if !node.Online || node.UpdateAt == 0 || bytes.Equal(node.ID, nodeID) {
+			continue
+		}
+		times = append(times, node.UpdateAt)
+	}
+	if len(times) == 0 {
+		return 0, nil
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+	return times[len(times)/2], nil
+}
+
 // Heartbeat updates heartbeat of node
 func (x *Xdata) Heartbeat(ctx code.Context) code.Response {
 	s, ok := ctx.Args()["opt"]
@@ -259,8 +409,11 @@ func (x *Xdata) Heartbeat(ctx code.Context) code.Response {
 		return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal, "failed to unmarshal node"))
 	}
 
-	// update node heartbeat time
+	// update node heartbeat time, and the capacity and zone advertised by node
 	node.UpdateAt = opt.CurrentTime
+	node.Capacity = opt.Capacity
+	node.Used = opt.Used
+	node.Zone = opt.Zone
 	newn, err := json.Marshal(node)
 	if err != nil {
 		return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal node"))
@@ -277,6 +430,125 @@ func (x *Xdata) Heartbeat(ctx code.Context) code.Response {
 	return code.OK(nil)
 }
 
+// HeartbeatTimeout penalizes the heartbeats missed by an online storage node since its last heartbeat,
+// it's called by data owners storing files on the node, so that a node going silent is penalized as well.
+// Heartbeats missed before HeartbeatCheckedAt of the node have been penalized and are not counted again,
+// nor are the ones HeartbeatTimeoutWindow after the last heartbeat, as the time is signed by the data owner
+func (x *Xdata) HeartbeatTimeout(ctx code.Context) code.Response {
+	s, ok := ctx.Args()["opt"]
+	if !ok {
+		return code.Error(errorx.New(errorx.ErrCodeParam, "missing param:opt"))
+	}
+	var opt blockchain.HeartbeatTimeoutOptions
+	if err := json.Unmarshal(s, &opt); err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal, "failed to unmarshal HeartbeatTimeoutOptions"))
+	}
+	// verify signature of the data owner
+	msg, err := util.GetSigMessage(opt)
+	if err != nil {
+		return code.Error(errorx.Internal(err, "failed to get the message to sign"))
+	}
+	if err := x.checkSign(opt.Signature, opt.FileOwner, []byte(msg)); err != nil {
+		return code.Error(err)
+	}
+
+	index := packNodeIndex(opt.NodeID)
+	oldn, err := ctx.GetObject([]byte(index))
+	if err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeNotFound, "node not found"))
+	}
+	var node blockchain.Node
+	if err := json.Unmarshal(oldn, &node); err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal, "failed to unmarshal node"))
+	}
+	if !node.Online || node.UpdateAt == 0 {
+		return code.Error(errorx.New(errorx.ErrCodeParam, "bad param, node is offline or never sent heartbeats"))
+	}
+	// the time is signed by the data owner, it's bounded by the latest heartbeat of the node
+	if opt.CurrentTime > node.UpdateAt+int64(blockchain.HeartbeatTimeoutWindow) {
+		return code.Error(errorx.New(errorx.ErrCodeParam, "bad param:currentTime, too late after the latest heartbeat"))
+	}
+	// and by heartbeats of the other nodes, so that the data owner can't run the time ahead of a healthy node
+	now, err := x.othersHeartbeatTime(ctx, opt.NodeID)
+	if err != nil {
+		return code.Error(err)
+	}
+	if now == 0 {
+		return code.Error(errorx.New(errorx.ErrCodeParam, "bad param, no other online node to time heartbeats"))
+	}
+	if opt.CurrentTime < now {
+		now = opt.CurrentTime
+	}
+	stored, err := x.hasOwnerSlices(ctx, opt.NodeID, opt.FileOwner, now)
+	if err != nil {
+		return code.Error(err)
+	}
+	if !stored {
+		return code.Error(errorx.New(errorx.ErrCodeNotAuthorized, "no files of the owner stored on node"))
+	}
+
+	// the heartbeat expected right after the last one is tolerated
+	freq := int64(blockchain.HeartBeatFreq)
+	checkFrom := node.UpdateAt + freq
+	if node.HeartbeatCheckedAt > checkFrom {
+		checkFrom = node.HeartbeatCheckedAt
+	}
+	missed := (now - checkFrom) / freq
+	if missed <= 0 {
+		return code.Error(errorx.New(errorx.ErrCodeParam, "bad param, no heartbeat missed"))
+	}
+	if missed > blockchain.MaxMissedHeartbeats {
+		missed = blockchain.MaxMissedHeartbeats
+	}
+	// the event is timed by the checkpoint, which moves on with every penalty
+	node.HeartbeatCheckedAt = checkFrom + missed*freq
+	if err := x.updateNodeReputation(ctx, opt.NodeID, blockchain.ReputationHeartbeatMissed,
+		"", node.HeartbeatCheckedAt, missed); err != nil {
+		return code.Error(err)
+	}
+
+	newn, err := json.Marshal(node)
+	if err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal node"))
+	}
+	if err := ctx.PutObject([]byte(index), newn); err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeWriteBlockchain, "failed to put index-Node on xchain"))
+	}
+	if err := ctx.PutObject([]byte(packNodeListIndex(node)), newn); err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeWriteBlockchain, "failed to put listIndex-Node on xchain"))
+	}
+	return code.OK(nil)
+}
+
+// hasOwnerSlices checks whether the node stores slices of any unexpired and undeleted file of the owner
+func (x *Xdata) hasOwnerSlices(ctx code.Context, nodeID, owner []byte, currentTime int64) (bool, error) {
+	iter := ctx.NewIterator(code.PrefixRange([]byte(packNodeSliceFilter(string(nodeID)))))
+	defer iter.Close()
+
+	for iter.Next() {
+		fileID, expireTime := getNodeSliceFileID(iter.Key())
+		if expireTime <= currentTime {
+			continue
+		}
+		f, err := x.getFileByID(ctx, []byte(fileID))
+		if err != nil {
+			if errorx.Is(err, errorx.ErrCodeNotFound) {
+				continue
+			}
+			return false, err
+		}
+		if !bytes.Equal(f.Owner, owner) || f.DeleteTime > 0 || f.ExpireTime <= currentTime {
+			continue
+		}
+		for _, slice := range f.Slices {
+			if bytes.Equal(slice.NodeID, nodeID) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
 // GetHeartbeatNum gets heartbeat by time
 func (x *Xdata) GetHeartbeatNum(ctx code.Context) code.Response {
 	// get id
@@ -355,6 +627,55 @@ func (x *Xdata) ListNodesExpireSlice(ctx code.Context) code.Response {
 	return code.OK(rs)
 }
 
+// ListNodesDeletedSlice lists slices of the files deleted by owners from xchain
+func (x *Xdata) ListNodesDeletedSlice(ctx code.Context) code.Response {
+	// get opt
+	s, ok := ctx.Args()["opt"]
+	if !ok {
+		return code.Error(errorx.New(errorx.ErrCodeParam, "missing param:opt"))
+	}
+	// unmarshal opt
+	var opt blockchain.ListNodeSliceOptions
+	if err := json.Unmarshal(s, &opt); err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to unmarshal ListNodeSlice"))
+	}
+	pubkey, err := hex.DecodeString(string(opt.Target))
+	if err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeParam, "wrong target node"))
+	}
+	if len(pubkey) != ecdsa.PublicKeyLength ||
+		opt.StartTime < 0 || opt.EndTime <= 0 || opt.EndTime <= opt.StartTime {
+		return code.Error(errorx.New(errorx.ErrCodeParam, "bad param"))
+	}
+	// pack prefix
+	prefix := packNodeDeletedSliceFilter(string(opt.Target))
+
+	// get iter by prefix
+	iter := ctx.NewIterator(code.PrefixRange([]byte(prefix)))
+	defer iter.Close()
+
+	// iterate iter
+	var sl []string
+	for iter.Next() {
+		_, deleteTime := getNodeSliceFileID(iter.Key())
+		if (opt.Limit > 0 && int64(len(sl)) >= opt.Limit) || deleteTime == 0 {
+			break
+		}
+		if deleteTime < opt.StartTime || deleteTime > opt.EndTime {
+			continue
+		}
+
+		sl = append(sl, string(iter.Value()))
+	}
+	rs, err := json.Marshal(sl)
+	if err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to marshal Files"))
+	}
+	return code.OK(rs)
+}
+
 // GetSliceMigrateRecords queries node slice migration records
 func (x *Xdata) GetSliceMigrateRecords(ctx code.Context) code.Response {
 	// get opt
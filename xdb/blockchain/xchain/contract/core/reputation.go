@@ -0,0 +1,206 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"encoding/json"
+
+	"github.com/xuperchain/xuperchain/core/contractsdk/go/code"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+)
+
+const reputationPolicyKey = "reputation_policy"
+
+// GetNodeReputation gets the reputation of a storage node with its history
+func (x *Xdata) GetNodeReputation(ctx code.Context) code.Response {
+	// get opt
+	s, ok := ctx.Args()["opt"]
+	if !ok {
+		return code.Error(errorx.New(errorx.ErrCodeParam, "missing param:opt"))
+	}
+	var opt blockchain.GetNodeReputationOptions
+	if err := json.Unmarshal(s, &opt); err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to unmarshal GetNodeReputationOptions"))
+	}
+	if len(opt.NodeID) == 0 || opt.StartTime < 0 || opt.EndTime < opt.StartTime {
+		return code.Error(errorx.New(errorx.ErrCodeParam, "bad param"))
+	}
+	if _, err := ctx.GetObject([]byte(packNodeIndex(opt.NodeID))); err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeNotFound, "node not found"))
+	}
+	r, err := x.getNodeReputation(ctx, opt.NodeID)
+	if err != nil {
+		return code.Error(err)
+	}
+
+	// get history by prefix, the latest event comes first
+	iter := ctx.NewIterator(code.PrefixRange([]byte(packNodeReputationHistoryFilter(opt.NodeID))))
+	defer iter.Close()
+	for opt.Limit > 0 && iter.Next() {
+		if int64(len(r.History)) >= opt.Limit {
+			break
+		}
+		var e blockchain.ReputationEvent
+		if err := json.Unmarshal(iter.Value(), &e); err != nil {
+			return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal, "failed to unmarshal ReputationEvent"))
+		}
+		if e.Time < opt.StartTime || (opt.EndTime > 0 && e.Time > opt.EndTime) {
+			continue
+		}
+		r.History = append(r.History, e)
+	}
+	b, err := json.Marshal(r)
+	if err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal NodeReputation"))
+	}
+	return code.OK(b)
+}
+
+// ListNodeReputations lists the reputations of all storage nodes without history
+func (x *Xdata) ListNodeReputations(ctx code.Context) code.Response {
+	var rs []blockchain.NodeReputation
+
+	iter := ctx.NewIterator(code.PrefixRange([]byte(prefixNodeListIndex)))
+	defer iter.Close()
+	for iter.Next() {
+		var node blockchain.Node
+		if err := json.Unmarshal(iter.Value(), &node); err != nil {
+			return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal, "failed to unmarshal node"))
+		}
+		r, err := x.getNodeReputation(ctx, node.ID)
+		if err != nil {
+			return code.Error(err)
+		}
+		rs = append(rs, r)
+	}
+	b, err := json.Marshal(rs)
+	if err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal NodeReputations"))
+	}
+	return code.OK(b)
+}
+
+// getReputationPolicy gets the policy set when the contract is initialized, or the default one
+func (x *Xdata) getReputationPolicy(ctx code.Context) (blockchain.ReputationPolicy, error) {
+	policy := blockchain.DefaultReputationPolicy
+	s, err := ctx.GetObject([]byte(reputationPolicyKey))
+	if err != nil {
+		return policy, nil
+	}
+	if err := json.Unmarshal(s, &policy); err != nil {
+		return policy, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to unmarshal ReputationPolicy")
+	}
+	return policy, nil
+}
+
+// getNodeReputation gets the reputation of a storage node, a node without any event has the initial score
+func (x *Xdata) getNodeReputation(ctx code.Context, nodeID []byte) (blockchain.NodeReputation, error) {
+	s, err := ctx.GetObject([]byte(packNodeReputationIndex(nodeID)))
+	if err != nil {
+		policy, err := x.getReputationPolicy(ctx)
+		if err != nil {
+			return blockchain.NodeReputation{}, err
+		}
+		return blockchain.NodeReputation{
+			NodeID:   nodeID,
+			Score:    policy.InitialScore,
+			Excluded: policy.InitialScore < policy.ExcludeThreshold,
+		}, nil
+	}
+	var r blockchain.NodeReputation
+	if err := json.Unmarshal(s, &r); err != nil {
+		return r, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to unmarshal NodeReputation")
+	}
+	return r, nil
+}
+
+// updateNodeReputation applies count times of an event to the reputation of a storage node,
+// and records the event into the node's reputation history
+func (x *Xdata) updateNodeReputation(ctx code.Context, nodeID []byte, eventType, ref string,
+	eventTime, count int64) error {
+	policy, err := x.getReputationPolicy(ctx)
+	if err != nil {
+		return err
+	}
+	r, err := x.getNodeReputation(ctx, nodeID)
+	if err != nil {
+		return err
+	}
+	e := newReputationEvent(policy, &r, eventType, ref, eventTime, count)
+
+	re, err := json.Marshal(e)
+	if err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal ReputationEvent")
+	}
+	if err := ctx.PutObject([]byte(packNodeReputationHistoryIndex(e)), re); err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeWriteBlockchain, "failed to put index-reputationEvent on xchain")
+	}
+	rs, err := json.Marshal(r)
+	if err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal NodeReputation")
+	}
+	if err := ctx.PutObject([]byte(packNodeReputationIndex(nodeID)), rs); err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeWriteBlockchain, "failed to put index-reputation on xchain")
+	}
+	return nil
+}
+
+// newReputationEvent changes the score of r by count times of the event, the score is kept in [0, MaxScore]
+func newReputationEvent(policy blockchain.ReputationPolicy, r *blockchain.NodeReputation, eventType, ref string,
+	eventTime, count int64) blockchain.ReputationEvent {
+	var delta int64
+	switch eventType {
+	case blockchain.ReputationChallengeFailed:
+		delta = -policy.ChallengeFailedPenalty * count
+	case blockchain.ReputationHeartbeatMissed:
+		delta = -policy.HeartbeatMissedPenalty * count
+	case blockchain.ReputationSliceLost:
+		delta = -policy.SliceLostPenalty * count
+	case blockchain.ReputationChallengeProved:
+		delta = policy.ChallengeProvedReward * count
+	}
+	score := r.Score + delta
+	if score < 0 {
+		score = 0
+	}
+	if score > policy.MaxScore {
+		score = policy.MaxScore
+	}
+
+	r.Score = score
+	r.Excluded = score < policy.ExcludeThreshold
+	r.UpdateTime = eventTime
+	return blockchain.ReputationEvent{
+		NodeID: r.NodeID,
+		Type:   eventType,
+		Delta:  delta,
+		Score:  score,
+		Ref:    ref,
+		Time:   eventTime,
+	}
+}
+
+// checkReputationPolicy checks the policy given when the contract is initialized
+func checkReputationPolicy(p blockchain.ReputationPolicy) error {
+	if p.MaxScore <= 0 || p.InitialScore < 0 || p.InitialScore > p.MaxScore ||
+		p.ExcludeThreshold < 0 || p.ExcludeThreshold > p.MaxScore ||
+		p.ChallengeFailedPenalty < 0 || p.HeartbeatMissedPenalty < 0 ||
+		p.SliceLostPenalty < 0 || p.ChallengeProvedReward < 0 {
+		return errorx.New(errorx.ErrCodeParam, "bad param:reputationPolicy")
+	}
+	return nil
+}
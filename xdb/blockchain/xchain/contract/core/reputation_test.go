@@ -0,0 +1,78 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
+)
+
+func TestNewReputationEvent(t *testing.T) {
+	policy := blockchain.DefaultReputationPolicy
+	r := blockchain.NodeReputation{NodeID: []byte("node"), Score: policy.InitialScore}
+
+	// penalties are multiplied by count
+	e := newReputationEvent(policy, &r, blockchain.ReputationHeartbeatMissed, "", 1, 3)
+	require.Equal(t, -3*policy.HeartbeatMissedPenalty, e.Delta)
+	require.Equal(t, policy.InitialScore-3*policy.HeartbeatMissedPenalty, r.Score)
+	require.Equal(t, r.Score, e.Score)
+	require.Equal(t, int64(1), r.UpdateTime)
+	require.False(t, r.Excluded)
+
+	e = newReputationEvent(policy, &r, blockchain.ReputationSliceLost, "slice", 2, 1)
+	require.Equal(t, -policy.SliceLostPenalty, e.Delta)
+	require.Equal(t, "slice", e.Ref)
+
+	// rewards are dropped beyond the max score
+	e = newReputationEvent(policy, &r, blockchain.ReputationChallengeProved, "challenge", 3, 100)
+	require.Equal(t, 100*policy.ChallengeProvedReward, e.Delta)
+	require.Equal(t, policy.MaxScore, r.Score)
+
+	// nodes are excluded once the score is below the threshold, and not before
+	failures := (policy.MaxScore - policy.ExcludeThreshold) / policy.ChallengeFailedPenalty
+	newReputationEvent(policy, &r, blockchain.ReputationChallengeFailed, "challenge", 4, failures)
+	require.Equal(t, policy.MaxScore-failures*policy.ChallengeFailedPenalty, r.Score)
+	require.False(t, r.Excluded)
+	newReputationEvent(policy, &r, blockchain.ReputationChallengeFailed, "challenge", 5, 1)
+	require.True(t, r.Excluded)
+
+	// the score is not below zero, and rewards bring the node back
+	e = newReputationEvent(policy, &r, blockchain.ReputationChallengeFailed, "challenge", 6, 100)
+	require.Zero(t, e.Score)
+	require.Zero(t, r.Score)
+	newReputationEvent(policy, &r, blockchain.ReputationChallengeProved, "challenge", 7,
+		policy.ExcludeThreshold/policy.ChallengeProvedReward)
+	require.Equal(t, policy.ExcludeThreshold, r.Score)
+	require.False(t, r.Excluded)
+}
+
+func TestCheckReputationPolicy(t *testing.T) {
+	require.NoError(t, checkReputationPolicy(blockchain.DefaultReputationPolicy))
+
+	for _, change := range []func(p *blockchain.ReputationPolicy){
+		func(p *blockchain.ReputationPolicy) { p.MaxScore = 0 },
+		func(p *blockchain.ReputationPolicy) { p.InitialScore = p.MaxScore + 1 },
+		func(p *blockchain.ReputationPolicy) { p.ExcludeThreshold = -1 },
+		func(p *blockchain.ReputationPolicy) { p.ExcludeThreshold = p.MaxScore + 1 },
+		func(p *blockchain.ReputationPolicy) { p.ChallengeFailedPenalty = -1 },
+		func(p *blockchain.ReputationPolicy) { p.ChallengeProvedReward = -1 },
+	} {
+		p := blockchain.DefaultReputationPolicy
+		change(&p)
+		require.Error(t, checkReputationPolicy(p))
+	}
+}
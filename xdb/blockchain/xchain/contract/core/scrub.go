@@ -0,0 +1,244 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/ecdsa"
+	"github.com/xuperchain/xuperchain/core/contractsdk/go/code"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+	util "github.com/PaddlePaddle/PaddleDTX/xdb/pkgs/strings"
+)
+
+// ReportSliceScrub is used by storage node to report a slice found corrupted or missing by scrubbing,
+// a slice is reported only once by a node until the report is resolved
+func (x *Xdata) ReportSliceScrub(ctx code.Context) code.Response {
+	// get ReportSliceScrubOptions
+	s, ok := ctx.Args()["opt"]
+	if !ok {
+		return code.Error(errorx.New(errorx.ErrCodeParam, "missing param:opt"))
+	}
+	var opt blockchain.ReportSliceScrubOptions
+	if err := json.Unmarshal(s, &opt); err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to unmarshal ReportSliceScrubOptions"))
+	}
+	if opt.Status != blockchain.SliceScrubCorrupted && opt.Status != blockchain.SliceScrubMissing {
+		return code.Error(errorx.New(errorx.ErrCodeParam, "bad param:status"))
+	}
+	// verify sig
+	npk, err := hex.DecodeString(string(opt.NodeID))
+	if err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeParam, "failed to decode nodeID"))
+	}
+	msg, err := util.GetSigMessage(opt)
+	if err != nil {
+		return code.Error(errorx.Internal(err, "failed to get the message to sign"))
+	}
+	if err := x.checkSign(opt.Signature, npk, []byte(msg)); err != nil {
+		return code.Error(err)
+	}
+
+	// check the slice is stored on the node
+	file, err := x.getFileByID(ctx, []byte(opt.FileID))
+	if err != nil {
+		return code.Error(err)
+	}
+	if !hasNodeSlice(file, opt.NodeID, opt.SliceID) {
+		return code.Error(errorx.New(errorx.ErrCodeNotFound, "slice not found on node"))
+	}
+
+	index := packSliceScrubIndex(string(opt.NodeID), opt.SliceID)
+	if _, err := ctx.GetObject([]byte(index)); err == nil {
+		return code.Error(errorx.New(errorx.ErrCodeAlreadyExists, "slice already reported"))
+	}
+	report := blockchain.SliceScrubReport{
+		NodeID:    opt.NodeID,
+		Owner:     file.Owner,
+		FileID:    opt.FileID,
+		SliceID:   opt.SliceID,
+		Status:    opt.Status,
+		ScrubTime: opt.CurrentTime,
+	}
+	r, err := json.Marshal(report)
+	if err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal SliceScrubReport"))
+	}
+	for _, index := range []string{
+		index,
+		packSliceScrubNodeIndex(report),
+		packSliceScrubOwnerIndex(report),
+		packSliceScrubOpenIndex(report),
+	} {
+		if err := ctx.PutObject([]byte(index), r); err != nil {
+			return code.Error(errorx.NewCode(err, errorx.ErrCodeWriteBlockchain,
+				"failed to put index-scrubReport on xchain"))
+		}
+	}
+	if err := x.updateNodeReputation(ctx, opt.NodeID, blockchain.ReputationSliceLost,
+		opt.SliceID, opt.CurrentTime, 1); err != nil {
+		return code.Error(err)
+	}
+	return code.OK([]byte("ok"))
+}
+
+// resolveSliceScrubs resolves the scrub reports of slices no longer stored on their nodes,
+// so that the owner stops migrating them, and the nodes can report them again if they are stored there later
+func (x *Xdata) resolveSliceScrubs(ctx code.Context, slices []blockchain.PublicSliceMeta, resolveTime int64) error {
+	for _, slice := range slices {
+		index := packSliceScrubIndex(string(slice.NodeID), slice.ID)
+		v, err := ctx.GetObject([]byte(index))
+		if err != nil {
+			continue
+		}
+		var report blockchain.SliceScrubReport
+		if err := json.Unmarshal(v, &report); err != nil {
+			return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to unmarshal SliceScrubReport")
+		}
+		for _, i := range []string{index, packSliceScrubOpenIndex(report)} {
+			if err := ctx.DeleteObject([]byte(i)); err != nil {
+				return errorx.NewCode(err, errorx.ErrCodeWriteBlockchain, "failed to delete index-scrubReport on xchain")
+			}
+		}
+		report.ResolveTime = resolveTime
+		r, err := json.Marshal(report)
+		if err != nil {
+			return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal SliceScrubReport")
+		}
+		for _, i := range []string{packSliceScrubNodeIndex(report), packSliceScrubOwnerIndex(report)} {
+			if err := ctx.PutObject([]byte(i), r); err != nil {
+				return errorx.NewCode(err, errorx.ErrCodeWriteBlockchain, "failed to put index-scrubReport on xchain")
+			}
+		}
+	}
+	return nil
+}
+
+// ListSliceScrubReports lists scrub reports of a storage node or of the files of an owner,
+// the latest report comes first, unresolved reports are listed in no particular order
+func (x *Xdata) ListSliceScrubReports(ctx code.Context) code.Response {
+	// get opt
+	s, ok := ctx.Args()["opt"]
+	if !ok {
+		return code.Error(errorx.New(errorx.ErrCodeParam, "missing param:opt"))
+	}
+	var opt blockchain.ListSliceScrubReportsOptions
+	if err := json.Unmarshal(s, &opt); err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to unmarshal ListSliceScrubReportsOptions"))
+	}
+	if (len(opt.Target) == 0 && len(opt.Owner) == 0) || (opt.Unresolved && len(opt.Owner) == 0) ||
+		opt.StartTime < 0 || opt.EndTime <= 0 || opt.EndTime <= opt.StartTime {
+		return code.Error(errorx.New(errorx.ErrCodeParam, "bad param"))
+	}
+
+	// get iter by prefix
+	prefix := packSliceScrubFilter(opt.Target, opt.Owner)
+	if opt.Unresolved {
+		prefix = packSliceScrubOpenFilter(opt.Owner)
+	}
+	iter := ctx.NewIterator(code.PrefixRange([]byte(prefix)))
+	defer iter.Close()
+
+	// iterate iter
+	var rs []blockchain.SliceScrubReport
+	for iter.Next() {
+		if opt.Limit > 0 && int64(len(rs)) >= opt.Limit {
+			break
+		}
+		var r blockchain.SliceScrubReport
+		if err := json.Unmarshal(iter.Value(), &r); err != nil {
+			return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal, "failed to unmarshal SliceScrubReport"))
+		}
+		if r.ScrubTime < opt.StartTime || r.ScrubTime > opt.EndTime {
+			continue
+		}
+		if len(opt.Target) > 0 && len(opt.Owner) > 0 && !bytes.Equal(r.Owner, opt.Owner) {
+			continue
+		}
+		if opt.Unresolved && len(opt.Target) > 0 && !bytes.Equal(r.NodeID, opt.Target) {
+			continue
+		}
+		rs = append(rs, r)
+	}
+	b, err := json.Marshal(rs)
+	if err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal SliceScrubReports"))
+	}
+	return code.OK(b)
+}
+
+// ListNodeSliceFiles lists IDs of the files which have slices stored on the node,
+// and expire between startTime and endTime, deleted files are skipped
+func (x *Xdata) ListNodeSliceFiles(ctx code.Context) code.Response {
+	// get opt
+	s, ok := ctx.Args()["opt"]
+	if !ok {
+		return code.Error(errorx.New(errorx.ErrCodeParam, "missing param:opt"))
+	}
+	var opt blockchain.ListNodeSliceOptions
+	if err := json.Unmarshal(s, &opt); err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to unmarshal ListNodeSlice"))
+	}
+	pubkey, err := hex.DecodeString(string(opt.Target))
+	if err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeParam, "wrong target node"))
+	}
+	if len(pubkey) != ecdsa.PublicKeyLength ||
+		opt.StartTime < 0 || opt.EndTime <= 0 || opt.EndTime <= opt.StartTime {
+		return code.Error(errorx.New(errorx.ErrCodeParam, "bad param"))
+	}
+
+	// get iter by prefix
+	iter := ctx.NewIterator(code.PrefixRange([]byte(packNodeSliceFilter(string(opt.Target)))))
+	defer iter.Close()
+
+	// iterate iter
+	var fl []string
+	for iter.Next() {
+		fileID, expireTime := getNodeSliceFileID(iter.Key())
+		if (opt.Limit > 0 && int64(len(fl)) >= opt.Limit) || expireTime == 0 {
+			break
+		}
+		if expireTime < opt.StartTime || expireTime > opt.EndTime {
+			continue
+		}
+		// the node-slice index of a deleted file is kept until it expires
+		if f, err := x.getFileByID(ctx, []byte(fileID)); err == nil && f.DeleteTime > 0 {
+			continue
+		}
+		fl = append(fl, fileID)
+	}
+	b, err := json.Marshal(fl)
+	if err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal file IDs"))
+	}
+	return code.OK(b)
+}
+
+// hasNodeSlice checks whether the slice of the file is stored on the node
+func hasNodeSlice(f blockchain.File, nodeID []byte, sliceID string) bool {
+	for _, slice := range f.Slices {
+		if slice.ID == sliceID && bytes.Equal(slice.NodeID, nodeID) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/xuperchain/xuperchain/core/contractsdk/go/code"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+)
+
+// GetSliceRef gets a deduplicated slice and the number of files referencing it
+func (x *Xdata) GetSliceRef(ctx code.Context) code.Response {
+	// get GetSliceRefOptions
+	s, ok := ctx.Args()["opt"]
+	if !ok {
+		return code.Error(errorx.New(errorx.ErrCodeParam, "missing param:opt"))
+	}
+	var opt blockchain.GetSliceRefOptions
+	if err := json.Unmarshal(s, &opt); err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to unmarshal GetSliceRefOptions"))
+	}
+
+	sliceID := opt.SliceID
+	if sliceID == "" {
+		id, err := ctx.GetObject([]byte(packSliceHashIndex(opt.Owner, opt.SealedHash)))
+		if err != nil {
+			return code.Error(errorx.NewCode(err, errorx.ErrCodeNotFound, "slice not found"))
+		}
+		sliceID = string(id)
+	}
+	ref, err := x.getSliceRef(ctx, sliceID)
+	if err != nil {
+		return code.Error(err)
+	}
+	if ref.RefCount, err = x.countSliceRef(ctx, ref, opt.CurrentTime); err != nil {
+		return code.Error(err)
+	}
+
+	r, err := json.Marshal(ref)
+	if err != nil {
+		return code.Error(errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal SliceRef"))
+	}
+	return code.OK(r)
+}
+
+// addSliceRefs records the file as a reference of deduplicated slices,
+// a slice no longer referenced by any live file may have been cleared by storage nodes, so it can't be referenced again
+func (x *Xdata) addSliceRefs(ctx code.Context, f blockchain.File, refs []blockchain.SliceRef) error {
+	sliceIDs := make(map[string]bool)
+	for _, slice := range f.Slices {
+		sliceIDs[slice.ID] = true
+	}
+	for _, r := range refs {
+		if !sliceIDs[r.SliceID] {
+			return errorx.New(errorx.ErrCodeParam, "bad param, slice[%s] not in file", r.SliceID)
+		}
+
+		ref, err := x.getSliceRef(ctx, r.SliceID)
+		if err != nil {
+			if !errorx.Is(err, errorx.ErrCodeNotFound) {
+				return err
+			}
+			ref = blockchain.SliceRef{
+				SliceID:    r.SliceID,
+				Owner:      f.Owner,
+				SealedHash: r.SealedHash,
+			}
+		} else {
+			if !bytes.Equal(ref.Owner, f.Owner) || !bytes.Equal(ref.SealedHash, r.SealedHash) {
+				return errorx.New(errorx.ErrCodeParam, "bad param, slice[%s] mismatched", r.SliceID)
+			}
+			// drop files no longer referencing the slice
+			var files []string
+			for _, id := range ref.Files {
+				if id == f.ID {
+					continue
+				}
+				if fc, err := x.getFileByID(ctx, []byte(id)); err == nil && isFileAlive(fc, f.PublishTime) {
+					files = append(files, id)
+				}
+			}
+			if len(files) == 0 {
+				return errorx.New(errorx.ErrCodeNotFound, "slice[%s] already released", r.SliceID)
+			}
+			ref.Files = files
+		}
+		ref.Files = append(ref.Files, f.ID)
+
+		s, err := json.Marshal(ref)
+		if err != nil {
+			return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal SliceRef")
+		}
+		if err := ctx.PutObject([]byte(packSliceRefIndex(ref.SliceID)), s); err != nil {
+			return errorx.NewCode(err, errorx.ErrCodeWriteBlockchain, "failed to set sliceref on chain")
+		}
+		if err := ctx.PutObject([]byte(packSliceHashIndex(ref.Owner, ref.SealedHash)), []byte(ref.SliceID)); err != nil {
+			return errorx.NewCode(err, errorx.ErrCodeWriteBlockchain, "failed to set slicehash index on chain")
+		}
+	}
+	return nil
+}
+
+// getSliceRef gets SliceRef by sliceID, RefCount is not set
+func (x *Xdata) getSliceRef(ctx code.Context, sliceID string) (ref blockchain.SliceRef, err error) {
+	s, err := ctx.GetObject([]byte(packSliceRefIndex(sliceID)))
+	if err != nil {
+		return ref, errorx.NewCode(err, errorx.ErrCodeNotFound, "slice[%s] not found", sliceID)
+	}
+	if err := json.Unmarshal(s, &ref); err != nil {
+		return ref, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to unmarshal SliceRef")
+	}
+	return ref, nil
+}
+
+// countSliceRef counts files referencing the slice at ctime
+func (x *Xdata) countSliceRef(ctx code.Context, ref blockchain.SliceRef, ctime int64) (int, error) {
+	count := 0
+	for _, id := range ref.Files {
+		f, err := x.getFileByID(ctx, []byte(id))
+		if err != nil {
+			return 0, err
+		}
+		if isFileAlive(f, ctime) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// isFileAlive returns whether slices of the file are still kept by storage nodes at ctime
+func isFileAlive(f blockchain.File, ctime int64) bool {
+	return f.DeleteTime == 0 && f.ExpireTime+blockchain.FileRetainPeriod.Nanoseconds() > ctime
+}
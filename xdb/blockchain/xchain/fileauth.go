@@ -31,7 +31,7 @@ func (x *XChain) PublishFileAuthApplication(opt *blockchain.PublishFileAuthOptio
 		"opt": string(s),
 	}
 	mName := "PublishFileAuthApplication"
-	if _, err = x.InvokeContract(args, mName); err != nil {
+	if _, err = x.invokeAuditedContract(args, mName, opt.Signature); err != nil {
 		return err
 	}
 	return nil
@@ -62,7 +62,23 @@ func (x *XChain) setFileAuthConfirmStatus(opt *blockchain.ConfirmFileAuthOptions
 	if isConfirm {
 		mName = "ConfirmFileAuthApplication"
 	}
-	if _, err := x.InvokeContract(args, mName); err != nil {
+	if _, err := x.invokeAuditedContract(args, mName, opt.Signature); err != nil {
+		return err
+	}
+	return nil
+}
+
+// RevokeFileAuth dataOwner node revokes the approved file authorization application
+func (x *XChain) RevokeFileAuth(opt *blockchain.RevokeFileAuthOptions) error {
+	opts, err := json.Marshal(*opt)
+	if err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeInternal,
+			"fail to marshal RevokeFileAuthOptions")
+	}
+	args := map[string]string{
+		"opt": string(opts),
+	}
+	if _, err := x.invokeAuditedContract(args, "RevokeFileAuth", opt.Signature); err != nil {
 		return err
 	}
 	return nil
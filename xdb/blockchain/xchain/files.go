@@ -33,7 +33,7 @@ func (x *XChain) PublishFile(opt *blockchain.PublishFileOptions) error {
 		"opt": string(s),
 	}
 	mName := "PublishFile"
-	if _, err = x.InvokeContract(args, mName); err != nil {
+	if _, err = x.invokeAuditedContract(args, mName, opt.Signature); err != nil {
 		return err
 	}
 	return nil
@@ -61,6 +61,54 @@ func (x *XChain) GetFileByName(owner []byte, ns, name string) (blockchain.File,
 	return f, nil
 }
 
+// GetFileByVersion gets the specified version of a file by name from xchain
+func (x *XChain) GetFileByVersion(owner []byte, ns, name string, version int) (blockchain.File, error) {
+	var f blockchain.File
+	args := map[string]string{
+		"owner":       string(owner),
+		"ns":          ns,
+		"name":        name,
+		"version":     strconv.Itoa(version),
+		"currentTime": strconv.FormatInt(time.Now().UnixNano(), 10),
+	}
+	mName := "GetFileByName"
+	s, err := x.QueryContract(args, mName)
+	if err != nil {
+		return f, err
+	}
+	if err = json.Unmarshal([]byte(s), &f); err != nil {
+		return f, errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to unmarshal File")
+	}
+
+	return f, nil
+}
+
+// ListFileVersions lists versions of a file from xchain, the latest version comes first
+func (x *XChain) ListFileVersions(opt *blockchain.ListFileVersionsOptions) ([]blockchain.File, error) {
+	var fs []blockchain.File
+
+	opts, err := json.Marshal(*opt)
+	if err != nil {
+		return fs, errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to marshal ListFileVersionsOptions")
+	}
+	args := map[string]string{
+		"opt": string(opts),
+	}
+	mName := "ListFileVersions"
+	s, err := x.QueryContract(args, mName)
+	if err != nil {
+		return fs, err
+	}
+	if err = json.Unmarshal(s, &fs); err != nil {
+		return fs, errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to unmarshal Files")
+	}
+
+	return fs, nil
+}
+
 // GetFileByID gets file by id from xchain
 func (x *XChain) GetFileByID(id string) (blockchain.File, error) {
 	var f blockchain.File
@@ -93,7 +141,7 @@ func (x *XChain) UpdateFileExpireTime(opt *blockchain.UpdateExptimeOptions) (blo
 		"opt": string(s),
 	}
 	mName := "UpdateFileExpireTime"
-	resp, err := x.InvokeContract(args, mName)
+	resp, err := x.invokeAuditedContract(args, mName, opt.Signature)
 	if err != nil {
 		return file, err
 	}
@@ -104,6 +152,22 @@ func (x *XChain) UpdateFileExpireTime(opt *blockchain.UpdateExptimeOptions) (blo
 	return file, nil
 }
 
+// DeleteFile deletes file before it expires
+func (x *XChain) DeleteFile(opt *blockchain.DeleteFileOptions) error {
+	s, err := json.Marshal(*opt)
+	if err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal DeleteFileOptions")
+	}
+	args := map[string]string{
+		"opt": string(s),
+	}
+	mName := "DeleteFile"
+	if _, err = x.invokeAuditedContract(args, mName, opt.Signature); err != nil {
+		return err
+	}
+	return nil
+}
+
 // AddFileNs adds file namespace
 func (x *XChain) AddFileNs(opt *blockchain.AddNsOptions) error {
 	s, err := json.Marshal(*opt)
@@ -149,7 +213,7 @@ func (x *XChain) UpdateFilePublicSliceMeta(opt *blockchain.UpdateFilePSMOptions)
 		"opt": string(s),
 	}
 	mName := "UpdateFilePublicSliceMeta"
-	if _, err := x.InvokeContract(args, mName); err != nil {
+	if _, err := x.invokeAuditedContract(args, mName, opt.Signature); err != nil {
 		return err
 	}
 	return nil
@@ -166,7 +230,7 @@ func (x *XChain) SliceMigrateRecord(opt *blockchain.SliceMigrateOptions) error {
 		"opt": string(s),
 	}
 	mName := "SliceMigrateRecord"
-	if _, err := x.InvokeContract(args, mName); err != nil {
+	if _, err := x.invokeAuditedContract(args, mName, opt.Signature); err != nil {
 		return err
 	}
 	return nil
@@ -266,3 +330,70 @@ func (x *XChain) ListExpiredFiles(opt *blockchain.ListFileOptions) ([]blockchain
 
 	return fs, nil
 }
+
+// GetSliceRef gets a deduplicated slice and the number of files referencing it
+func (x *XChain) GetSliceRef(opt *blockchain.GetSliceRefOptions) (blockchain.SliceRef, error) {
+	var ref blockchain.SliceRef
+
+	opts, err := json.Marshal(*opt)
+	if err != nil {
+		return ref, errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to marshal GetSliceRefOptions")
+	}
+	args := map[string]string{
+		"opt": string(opts),
+	}
+	mName := "GetSliceRef"
+	s, err := x.QueryContract(args, mName)
+	if err != nil {
+		return ref, err
+	}
+	if err = json.Unmarshal(s, &ref); err != nil {
+		return ref, errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to unmarshal SliceRef")
+	}
+
+	return ref, nil
+}
+
+// RecordFileDownload records a download of the file in its audit trail
+func (x *XChain) RecordFileDownload(opt *blockchain.RecordFileDownloadOptions) error {
+	s, err := json.Marshal(*opt)
+	if err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to marshal RecordFileDownloadOptions")
+	}
+	args := map[string]string{
+		"opt": string(s),
+	}
+	mName := "RecordFileDownload"
+	if _, err = x.invokeAuditedContract(args, mName, opt.Signature); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ListFileAuditEvents lists events in the audit trail of a file from xchain, the earliest event comes first
+func (x *XChain) ListFileAuditEvents(opt *blockchain.ListFileAuditEventsOptions) ([]blockchain.FileAuditEvent, error) {
+	var es []blockchain.FileAuditEvent
+
+	opts, err := json.Marshal(*opt)
+	if err != nil {
+		return es, errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to marshal ListFileAuditEventsOptions")
+	}
+	args := map[string]string{
+		"opt": string(opts),
+	}
+	mName := "ListFileAuditEvents"
+	s, err := x.QueryContract(args, mName)
+	if err != nil {
+		return es, err
+	}
+	if err = json.Unmarshal(s, &es); err != nil {
+		return es, errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to unmarshal FileAuditEvents")
+	}
+
+	return es, nil
+}
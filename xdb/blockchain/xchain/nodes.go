@@ -105,6 +105,21 @@ func (x *XChain) NodeOnline(opt *blockchain.NodeOperateOptions) error {
 	return x.setNodeOnlineStatus(opt, true)
 }
 
+// NodeDrain set node status on chain to draining, so that its slices are moved to other nodes
+func (x *XChain) NodeDrain(opt *blockchain.NodeOperateOptions) error {
+	s, err := json.Marshal(*opt)
+	if err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal NodeOperateOptions")
+	}
+	args := map[string]string{
+		"opt": string(s),
+	}
+	if _, err := x.InvokeContract(args, "NodeDrain"); err != nil {
+		return err
+	}
+	return nil
+}
+
 // Heartbeat updates heartbeat of storage node
 func (x *XChain) Heartbeat(opt *blockchain.NodeHeartBeatOptions) error {
 	s, err := json.Marshal(*opt)
@@ -121,6 +136,22 @@ func (x *XChain) Heartbeat(opt *blockchain.NodeHeartBeatOptions) error {
 	return nil
 }
 
+// HeartbeatTimeout penalizes the heartbeats missed by a storage node storing files of the data owner
+func (x *XChain) HeartbeatTimeout(opt *blockchain.HeartbeatTimeoutOptions) error {
+	s, err := json.Marshal(*opt)
+	if err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal HeartbeatTimeoutOptions")
+	}
+	args := map[string]string{
+		"opt": string(s),
+	}
+	mName := "HeartbeatTimeout"
+	if _, err := x.InvokeContract(args, mName); err != nil {
+		return err
+	}
+	return nil
+}
+
 // GetHeartbeatNum gets storage heartbeat number by time
 func (x *XChain) GetHeartbeatNum(id []byte, timestamp int64) (int, error) {
 	args := map[string]string{
@@ -160,6 +191,16 @@ func (x *XChain) GetSliceMigrateRecords(opt *blockchain.NodeSliceMigrateOptions)
 // ListNodesExpireSlice lists expired slices from xchain
 // returns a list of sliceID(first value of [2]string) and its StorageIndex(second value of [2]string)
 func (x *XChain) ListNodesExpireSlice(opt *blockchain.ListNodeSliceOptions) ([][2]string, error) {
+	return x.listNodeSlices(opt, "ListNodesExpireSlice")
+}
+
+// ListNodesDeletedSlice lists slices of the files deleted by owners
+func (x *XChain) ListNodesDeletedSlice(opt *blockchain.ListNodeSliceOptions) ([][2]string, error) {
+	return x.listNodeSlices(opt, "ListNodesDeletedSlice")
+}
+
+// listNodeSlices queries slices of node by contract method mName, returns pairs of sliceID and storIndex
+func (x *XChain) listNodeSlices(opt *blockchain.ListNodeSliceOptions, mName string) ([][2]string, error) {
 	var sliceL []string
 
 	sliceID2StorIndex := make([][2]string, 0)
@@ -172,7 +213,6 @@ func (x *XChain) ListNodesExpireSlice(opt *blockchain.ListNodeSliceOptions) ([][
 	args := map[string]string{
 		"opt": string(opts),
 	}
-	mName := "ListNodesExpireSlice"
 	s, err := x.QueryContract(args, mName)
 	if err != nil {
 		return sliceID2StorIndex, err
@@ -246,3 +286,105 @@ func (x *XChain) GetNodeHealth(id []byte) (string, error) {
 	}
 	return blockchain.NodeHealthMedium, nil
 }
+
+// ListNodeSliceFiles lists IDs of the files which have slices stored on the node
+func (x *XChain) ListNodeSliceFiles(opt *blockchain.ListNodeSliceOptions) ([]string, error) {
+	var fl []string
+	s, err := json.Marshal(*opt)
+	if err != nil {
+		return fl, errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to marshal ListNodeSliceOptions")
+	}
+	args := map[string]string{
+		"opt": string(s),
+	}
+	mName := "ListNodeSliceFiles"
+	b, err := x.QueryContract(args, mName)
+	if err != nil {
+		return fl, err
+	}
+	if err = json.Unmarshal(b, &fl); err != nil {
+		return fl, errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to unmarshal file IDs")
+	}
+	return fl, nil
+}
+
+// ReportSliceScrub is used by storage node to report a slice found corrupted or missing by scrubbing
+func (x *XChain) ReportSliceScrub(opt *blockchain.ReportSliceScrubOptions) error {
+	s, err := json.Marshal(*opt)
+	if err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to marshal ReportSliceScrubOptions")
+	}
+	args := map[string]string{
+		"opt": string(s),
+	}
+	mName := "ReportSliceScrub"
+	if _, err := x.InvokeContract(args, mName); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ListSliceScrubReports lists scrub reports of a storage node or of the files of an owner
+func (x *XChain) ListSliceScrubReports(opt *blockchain.ListSliceScrubReportsOptions) ([]blockchain.SliceScrubReport, error) {
+	var rs []blockchain.SliceScrubReport
+	s, err := json.Marshal(*opt)
+	if err != nil {
+		return rs, errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to marshal ListSliceScrubReportsOptions")
+	}
+	args := map[string]string{
+		"opt": string(s),
+	}
+	mName := "ListSliceScrubReports"
+	b, err := x.QueryContract(args, mName)
+	if err != nil {
+		return rs, err
+	}
+	if err = json.Unmarshal(b, &rs); err != nil {
+		return rs, errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to unmarshal SliceScrubReports")
+	}
+	return rs, nil
+}
+
+// GetNodeReputation gets the reputation of a storage node with its history
+func (x *XChain) GetNodeReputation(opt *blockchain.GetNodeReputationOptions) (blockchain.NodeReputation, error) {
+	var r blockchain.NodeReputation
+	s, err := json.Marshal(*opt)
+	if err != nil {
+		return r, errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to marshal GetNodeReputationOptions")
+	}
+	args := map[string]string{
+		"opt": string(s),
+	}
+	mName := "GetNodeReputation"
+	b, err := x.QueryContract(args, mName)
+	if err != nil {
+		return r, err
+	}
+	if err = json.Unmarshal(b, &r); err != nil {
+		return r, errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to unmarshal NodeReputation")
+	}
+	return r, nil
+}
+
+// ListNodeReputations lists the reputations of all storage nodes without history
+func (x *XChain) ListNodeReputations() ([]blockchain.NodeReputation, error) {
+	var rs []blockchain.NodeReputation
+	args := map[string]string{}
+	mName := "ListNodeReputations"
+	b, err := x.QueryContract(args, mName)
+	if err != nil {
+		return rs, err
+	}
+	if err = json.Unmarshal(b, &rs); err != nil {
+		return rs, errorx.NewCode(err, errorx.ErrCodeInternal,
+			"failed to unmarshal NodeReputations")
+	}
+	return rs, nil
+}
@@ -15,23 +15,38 @@ package xchain
 
 import (
 	"context"
+	"encoding/hex"
+	"encoding/json"
 	"strings"
 
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/hash"
+	"github.com/sirupsen/logrus"
 	"github.com/xuperchain/xuper-sdk-go/account"
 	"github.com/xuperchain/xuper-sdk-go/contract"
 	"github.com/xuperchain/xuper-sdk-go/pb"
 	"github.com/xuperchain/xuper-sdk-go/xchain"
 
+	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/config"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
 )
 
+var logger = logrus.WithField("module", "blockchain.xchain")
+
+// ContractInvoker runs methods of the contract in place of the xchain network, see blockchain/local
+//  Invoke returns the response body and ID of the transaction
+type ContractInvoker interface {
+	Invoke(args map[string]string, mName string) ([]byte, string, error)
+	Query(args map[string]string, mName string) ([]byte, error)
+}
+
 type XChain struct {
 	ContractName    string              // ContractName is name of contract
 	ContractAccount string              // ContractAccount is a contract account
 	ChainName       string              // ChainName is name of blockchain
 	Account         *account.Account    // Account is the local account, and is also the client account to request blockchain
 	XchainClient    *xchain.XuperClient // XchainClient is the util used to connect and request blockchain
+	Invoker         ContractInvoker     // Invoker runs the contract instead of the xchain network if it's set
 }
 
 // New creates a XChain client which is used for connecting and requesting blockchain
@@ -74,6 +89,42 @@ func New(conf *config.XchainConf) (*XChain, error) {
 
 // InvokeContract invokes the contract
 func (x *XChain) InvokeContract(args map[string]string, mName string) ([]byte, error) {
+	body, _, err := x.invokeContract(args, mName)
+	return body, err
+}
+
+// invokeAuditedContract invokes the contract method which records audit events of files,
+// and then fills in the transaction ID of the events, sig is the signature of the operation.
+// Contracts on xchain can not get the ID of the transaction they are running in, so it is
+// filled in by another transaction, failures of which are only logged
+func (x *XChain) invokeAuditedContract(args map[string]string, mName string, sig []byte) ([]byte, error) {
+	body, txID, err := x.invokeContract(args, mName)
+	if err != nil {
+		return nil, err
+	}
+	opt := blockchain.SetFileAuditTxOptions{
+		Digest: hex.EncodeToString(hash.HashUsingSha256(sig)),
+		TxID:   txID,
+	}
+	s, err := json.Marshal(opt)
+	if err != nil {
+		logger.WithField("tx_id", txID).WithError(err).Warn("failed to marshal SetFileAuditTxOptions")
+		return body, nil
+	}
+	if _, _, err := x.invokeContract(map[string]string{"opt": string(s)}, "SetFileAuditTx"); err != nil {
+		logger.WithFields(logrus.Fields{
+			"method": mName,
+			"tx_id":  txID,
+		}).WithError(err).Warn("failed to set transaction of audit events")
+	}
+	return body, nil
+}
+
+// invokeContract invokes the contract, and returns the response body and ID of the transaction
+func (x *XChain) invokeContract(args map[string]string, mName string) ([]byte, string, error) {
+	if x.Invoker != nil {
+		return x.Invoker.Invoke(args, mName)
+	}
 	// initiate client for native contract
 	nativeContract := contract.InitNativeContractWithClient(
 		x.Account, x.ChainName, x.ContractName, x.ContractAccount, x.XchainClient)
@@ -87,22 +138,26 @@ func (x *XChain) InvokeContract(args map[string]string, mName string) ([]byte, e
 			message := err.Error()[indexStart : indexStop+1]
 			if len(message) > 0 {
 				if c, m, ok := errorx.TryParseFromString(message); ok {
-					return nil, errorx.Wrap(errorx.New(c, m), "failed to PreInvokeNativeContract")
+					return nil, "", errorx.Wrap(errorx.New(c, m), "failed to PreInvokeNativeContract")
 				}
 			}
 		}
-		return nil, errorx.ParseAndWrap(err, "failed to PreInvokeNativeContract")
+		return nil, "", errorx.ParseAndWrap(err, "failed to PreInvokeNativeContract")
 	}
 	// invoke contract
-	if _, err := nativeContract.PostNativeContract(preSelectUTXOResponse); err != nil {
-		return nil, errorx.NewCode(err, errorx.ErrCodeInternal,
+	txID, err := nativeContract.PostNativeContract(preSelectUTXOResponse)
+	if err != nil {
+		return nil, "", errorx.NewCode(err, errorx.ErrCodeInternal,
 			"PostNativeContract failed, err: %v", err)
 	}
-	return preSelectUTXOResponse.GetResponse().GetResponses()[0].GetBody(), nil
+	return preSelectUTXOResponse.GetResponse().GetResponses()[0].GetBody(), txID, nil
 }
 
 // QueryContract queries the contract
 func (x *XChain) QueryContract(args map[string]string, mName string) ([]byte, error) {
+	if x.Invoker != nil {
+		return x.Invoker.Query(args, mName)
+	}
 	// initiate client for native contract
 	nativeContract := contract.InitNativeContractWithClient(
 		x.Account, x.ChainName, x.ContractName, x.ContractAccount, x.XchainClient)
@@ -0,0 +1,333 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package e2e
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/ecdsa"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/ecies"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+)
+
+// Files encrypted in the client are opaque to the dataOwner node, the layout is:
+//  header: magic(6) | version(1) | reserved(1) | salt(32) | key check(8)
+//  chunks: AES-GCM sealed plaintext chunks of ChunkSize, the last one may be shorter or empty
+// Each file is encrypted by its own file key derived from the user key and the random salt,
+// so that the file key can be shared with an applier without exposing the user key.
+// The nonce of a chunk is made of its index and a flag marking the last chunk,
+// and the header is authenticated as additional data, so chunks can't be reordered or truncated.
+const (
+	KeySize    = 32        // size of the user key and the file key
+	HeaderSize = 48        // size of header before chunks
+	ChunkSize  = 64 * 1024 // size of plaintext chunk
+
+	magic     = "XDBE2E"
+	version   = 1
+	saltSize  = 32
+	checkSize = 8
+	tagSize   = 16
+	nonceSize = 12
+
+	fileKeyLabel  = "xdb-e2e-file-key"
+	keyCheckLabel = "xdb-e2e-key-check"
+)
+
+// GenerateKey generates a random user key
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, errorx.NewCode(err, errorx.ErrCodeCrypto, "failed to generate key")
+	}
+	return key, nil
+}
+
+// DecodeKey decodes a hex encoded user key or file key
+func DecodeKey(s string) ([]byte, error) {
+	key, err := hex.DecodeString(strings.TrimSpace(s))
+	if err != nil || len(key) != KeySize {
+		return nil, errorx.New(errorx.ErrCodeParam, "invalid key, must be %d bytes hex encoded", KeySize)
+	}
+	return key, nil
+}
+
+// FileKey returns the key of the file whose header is given, and checks if it's derived from userKey
+func FileKey(header, userKey []byte) ([]byte, error) {
+	salt, check, err := parseHeader(header)
+	if err != nil {
+		return nil, err
+	}
+	fileKey := mac(userKey, fileKeyLabel, salt)
+	if !hmac.Equal(mac(fileKey, keyCheckLabel)[:checkSize], check) {
+		return nil, errorx.New(errorx.ErrCodeNotAuthorized, "the file is not encrypted by the key")
+	}
+	return fileKey, nil
+}
+
+// WrapFileKey encrypts the file key for the applier's public key with ECIES
+func WrapFileKey(fileKey []byte, applier ecdsa.PublicKey) ([]byte, error) {
+	pubkey, err := ecdsa.ParsePublicKey(applier)
+	if err != nil {
+		return nil, errorx.NewCode(err, errorx.ErrCodeParam, "failed to parse applier's public key")
+	}
+	wrapped, err := ecies.Encrypt(&pubkey, fileKey)
+	if err != nil {
+		return nil, errorx.NewCode(err, errorx.ErrCodeCrypto, "failed to wrap file key")
+	}
+	return wrapped, nil
+}
+
+// UnwrapFileKey decrypts the file key wrapped for the applier by its private key
+func UnwrapFileKey(wrapped []byte, applier ecdsa.PrivateKey) ([]byte, error) {
+	privkey := ecdsa.ParsePrivateKey(applier)
+	fileKey, err := ecies.Decrypt(&privkey, wrapped)
+	if err != nil {
+		return nil, errorx.NewCode(err, errorx.ErrCodeCrypto, "failed to unwrap file key")
+	}
+	if len(fileKey) != KeySize {
+		return nil, errorx.New(errorx.ErrCodeCrypto, "invalid file key")
+	}
+	return fileKey, nil
+}
+
+// NewEncryptReader returns a reader of the ciphertext of r encrypted by a new file key derived from userKey
+func NewEncryptReader(r io.Reader, userKey []byte) (io.Reader, error) {
+	if len(userKey) != KeySize {
+		return nil, errorx.New(errorx.ErrCodeParam, "invalid key size")
+	}
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, errorx.NewCode(err, errorx.ErrCodeCrypto, "failed to generate salt")
+	}
+	fileKey := mac(userKey, fileKeyLabel, salt)
+
+	header := make([]byte, 0, HeaderSize)
+	header = append(header, magic...)
+	header = append(header, version, 0)
+	header = append(header, salt...)
+	header = append(header, mac(fileKey, keyCheckLabel)[:checkSize]...)
+
+	aead, err := newAEAD(fileKey)
+	if err != nil {
+		return nil, err
+	}
+	return &encryptReader{
+		r:      bufio.NewReaderSize(r, ChunkSize),
+		aead:   aead,
+		header: header,
+		out:    header,
+		plain:  make([]byte, ChunkSize),
+		sealed: make([]byte, 0, ChunkSize+tagSize),
+	}, nil
+}
+
+// NewDecryptReader returns a reader of the plaintext of r encrypted by a file key derived from userKey
+func NewDecryptReader(r io.Reader, userKey []byte) (io.Reader, error) {
+	return newDecryptReader(r, func(header []byte) ([]byte, error) {
+		return FileKey(header, userKey)
+	})
+}
+
+// NewDecryptReaderWithFileKey returns a reader of the plaintext of r encrypted by fileKey,
+// used by appliers who are given the file key instead of the user key
+func NewDecryptReaderWithFileKey(r io.Reader, fileKey []byte) (io.Reader, error) {
+	return newDecryptReader(r, func(header []byte) ([]byte, error) {
+		_, check, err := parseHeader(header)
+		if err != nil {
+			return nil, err
+		}
+		if !hmac.Equal(mac(fileKey, keyCheckLabel)[:checkSize], check) {
+			return nil, errorx.New(errorx.ErrCodeNotAuthorized, "the file is not encrypted by the key")
+		}
+		return fileKey, nil
+	})
+}
+
+func newDecryptReader(r io.Reader, getKey func(header []byte) ([]byte, error)) (io.Reader, error) {
+	header := make([]byte, HeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, errorx.NewCode(err, errorx.ErrCodeParam, "failed to read header of client encrypted file")
+	}
+	fileKey, err := getKey(header)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(fileKey)
+	if err != nil {
+		return nil, err
+	}
+	return &decryptReader{
+		r:      bufio.NewReaderSize(r, ChunkSize+tagSize),
+		aead:   aead,
+		header: header,
+		sealed: make([]byte, ChunkSize+tagSize),
+	}, nil
+}
+
+// encryptReader seals plaintext chunk by chunk
+type encryptReader struct {
+	r      *bufio.Reader
+	aead   cipher.AEAD
+	header []byte
+	index  uint64
+	done   bool
+
+	plain  []byte
+	sealed []byte
+	out    []byte // sealed data not read yet
+}
+
+func (er *encryptReader) Read(p []byte) (int, error) {
+	for len(er.out) == 0 {
+		if er.done {
+			return 0, io.EOF
+		}
+		n, err := io.ReadFull(er.r, er.plain)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return 0, err
+		}
+		last := n < ChunkSize
+		if !last {
+			if _, err := er.r.Peek(1); err == io.EOF {
+				last = true
+			} else if err != nil {
+				return 0, err
+			}
+		}
+		er.out = er.aead.Seal(er.sealed[:0], nonce(er.index, last), er.plain[:n], er.header)
+		er.index++
+		er.done = last
+	}
+	n := copy(p, er.out)
+	er.out = er.out[n:]
+	return n, nil
+}
+
+// decryptReader opens sealed chunks one by one
+type decryptReader struct {
+	r      *bufio.Reader
+	aead   cipher.AEAD
+	header []byte
+	index  uint64
+	done   bool
+
+	sealed []byte
+	out    []byte // plaintext not read yet
+}
+
+func (dr *decryptReader) Read(p []byte) (int, error) {
+	for len(dr.out) == 0 {
+		if dr.done {
+			return 0, io.EOF
+		}
+		n, err := io.ReadFull(dr.r, dr.sealed)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return 0, err
+		}
+		if n < tagSize {
+			return 0, errorx.New(errorx.ErrCodeCrypto, "client encrypted file is truncated")
+		}
+		last := n < len(dr.sealed)
+		if !last {
+			if _, err := dr.r.Peek(1); err == io.EOF {
+				last = true
+			} else if err != nil {
+				return 0, err
+			}
+		}
+		plain, err := dr.aead.Open(dr.sealed[:0], nonce(dr.index, last), dr.sealed[:n], dr.header)
+		if err != nil {
+			return 0, errorx.NewCode(err, errorx.ErrCodeCrypto, "failed to decrypt chunk %d", dr.index)
+		}
+		dr.out = plain
+		dr.index++
+		dr.done = last
+	}
+	n := copy(p, dr.out)
+	dr.out = dr.out[n:]
+	return n, nil
+}
+
+// Encrypt encrypts plaintext in memory, see NewEncryptReader
+func Encrypt(plaintext, userKey []byte) ([]byte, error) {
+	r, err := NewEncryptReader(bytes.NewReader(plaintext), userKey)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(r)
+}
+
+// Decrypt decrypts ciphertext in memory, see NewDecryptReader
+func Decrypt(ciphertext, userKey []byte) ([]byte, error) {
+	r, err := NewDecryptReader(bytes.NewReader(ciphertext), userKey)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(r)
+}
+
+func parseHeader(header []byte) (salt, check []byte, err error) {
+	if len(header) < HeaderSize || string(header[:len(magic)]) != magic {
+		return nil, nil, errorx.New(errorx.ErrCodeParam, "not a client encrypted file")
+	}
+	if header[len(magic)] != version {
+		return nil, nil, errorx.New(errorx.ErrCodeParam, "unsupported version of client encrypted file: %d", header[len(magic)])
+	}
+	salt = header[len(magic)+2 : len(magic)+2+saltSize]
+	check = header[len(magic)+2+saltSize : HeaderSize]
+	return salt, check, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errorx.NewCode(err, errorx.ErrCodeCrypto, "invalid key")
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errorx.NewCode(err, errorx.ErrCodeCrypto, "failed to create cipher")
+	}
+	return aead, nil
+}
+
+// nonce of the chunk of index, the first byte marks the last chunk
+func nonce(index uint64, last bool) []byte {
+	n := make([]byte, nonceSize)
+	if last {
+		n[0] = 1
+	}
+	binary.BigEndian.PutUint64(n[nonceSize-8:], index)
+	return n
+}
+
+func mac(key []byte, label string, data ...[]byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(label))
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
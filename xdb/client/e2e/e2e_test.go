@@ -0,0 +1,115 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package e2e
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io/ioutil"
+	"testing"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/ecdsa"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	userKey, err := GenerateKey()
+	require.NoError(t, err)
+
+	for _, size := range []int{0, 1, ChunkSize - 1, ChunkSize, ChunkSize + 1, 3*ChunkSize + 100} {
+		plaintext := make([]byte, size)
+		_, err := rand.Read(plaintext)
+		require.NoError(t, err)
+
+		ciphertext, err := Encrypt(plaintext, userKey)
+		require.NoError(t, err)
+		chunks := (size + ChunkSize - 1) / ChunkSize
+		if chunks == 0 {
+			chunks = 1
+		}
+		require.Equal(t, HeaderSize+size+chunks*tagSize, len(ciphertext))
+
+		recovered, err := Decrypt(ciphertext, userKey)
+		require.NoError(t, err)
+		require.Equal(t, plaintext, recovered, "size %d", size)
+
+		// the same plaintext is encrypted by different file keys
+		another, err := Encrypt(plaintext, userKey)
+		require.NoError(t, err)
+		require.NotEqual(t, ciphertext, another)
+	}
+}
+
+func TestTamper(t *testing.T) {
+	userKey, err := GenerateKey()
+	require.NoError(t, err)
+	plaintext := make([]byte, 2*ChunkSize+10)
+	ciphertext, err := Encrypt(plaintext, userKey)
+	require.NoError(t, err)
+
+	// wrong key
+	otherKey, err := GenerateKey()
+	require.NoError(t, err)
+	_, err = Decrypt(ciphertext, otherKey)
+	require.Error(t, err)
+
+	// truncated at the boundary of chunks
+	_, err = Decrypt(ciphertext[:HeaderSize+2*(ChunkSize+tagSize)], userKey)
+	require.Error(t, err)
+
+	// chunks reordered
+	reordered := append([]byte{}, ciphertext[:HeaderSize]...)
+	reordered = append(reordered, ciphertext[HeaderSize+ChunkSize+tagSize:HeaderSize+2*(ChunkSize+tagSize)]...)
+	reordered = append(reordered, ciphertext[HeaderSize:HeaderSize+ChunkSize+tagSize]...)
+	reordered = append(reordered, ciphertext[HeaderSize+2*(ChunkSize+tagSize):]...)
+	_, err = Decrypt(reordered, userKey)
+	require.Error(t, err)
+
+	// not a client encrypted file
+	_, err = Decrypt(plaintext, userKey)
+	require.Error(t, err)
+}
+
+func TestWrapFileKey(t *testing.T) {
+	userKey, err := GenerateKey()
+	require.NoError(t, err)
+	plaintext := []byte("b66ba2a42e96f93beb07f194026d3b3e7ed363e99c098089fc611747d845c9b1")
+	ciphertext, err := Encrypt(plaintext, userKey)
+	require.NoError(t, err)
+
+	fileKey, err := FileKey(ciphertext[:HeaderSize], userKey)
+	require.NoError(t, err)
+	require.NotEqual(t, userKey, fileKey)
+
+	privkey, pubkey, err := ecdsa.GenerateKeyPair()
+	require.NoError(t, err)
+	wrapped, err := WrapFileKey(fileKey, pubkey)
+	require.NoError(t, err)
+	unwrapped, err := UnwrapFileKey(wrapped, privkey)
+	require.NoError(t, err)
+	require.Equal(t, fileKey, unwrapped)
+
+	// the applier decrypts the file with the file key only
+	r, err := NewDecryptReaderWithFileKey(bytes.NewReader(ciphertext), unwrapped)
+	require.NoError(t, err)
+	recovered, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, plaintext, recovered)
+
+	// file key of another file doesn't work
+	another, err := Encrypt(plaintext, userKey)
+	require.NoError(t, err)
+	_, err = NewDecryptReaderWithFileKey(bytes.NewReader(another), unwrapped)
+	require.Error(t, err)
+}
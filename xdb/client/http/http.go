@@ -15,7 +15,9 @@ package http
 
 import (
 	"context"
+	"encoding/hex"
 	"io"
+	"io/ioutil"
 	"net/url"
 	"path"
 	"strconv"
@@ -25,6 +27,7 @@ import (
 	"github.com/PaddlePaddle/PaddleDTX/crypto/core/hash"
 
 	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/client/e2e"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
 	httpkg "github.com/PaddlePaddle/PaddleDTX/xdb/pkgs/http"
 	util "github.com/PaddlePaddle/PaddleDTX/xdb/pkgs/strings"
@@ -81,6 +84,13 @@ func (c *Client) Write(ctx context.Context, r io.Reader, opt WriteOptions) (
 		"ext":        opt.Extra,
 		"expireTime": strconv.FormatInt(opt.ExpireTime, 10),
 	}
+	// encrypt the file in the client, the node treats the ciphertext as an opaque file
+	if opt.E2EKey != nil {
+		if r, err = e2e.NewEncryptReader(r, opt.E2EKey); err != nil {
+			return servertypes.WriteResponse{}, err
+		}
+		reqParams["e2e"] = "true"
+	}
 	msg, err := util.GetSigMessage(reqParams)
 	if err != nil {
 		return servertypes.WriteResponse{}, errorx.Internal(err, "failed to get the message to sign")
@@ -101,12 +111,15 @@ func (c *Client) Write(ctx context.Context, r io.Reader, opt WriteOptions) (
 	return resp, nil
 }
 
-// Read download a file
+// Read download a file, client encrypted file is decrypted if opt.E2EKey is set
 func (c *Client) Read(ctx context.Context, opt ReadOptions) (io.ReadCloser, error) {
 	privkey, err := ecdsa.DecodePrivateKeyFromString(opt.PrivateKey)
 	if err != nil {
 		return nil, err
 	}
+	if opt.E2EKey != nil && (opt.Offset > 0 || opt.Length > 0) {
+		return nil, errorx.New(errorx.ErrCodeParam, "range is not supported for client encrypted file")
+	}
 	reqParams := map[string]string{
 		"user":      ecdsa.PublicKeyFromPrivateKey(privkey).String(),
 		"ns":        opt.Namespace,
@@ -114,6 +127,9 @@ func (c *Client) Read(ctx context.Context, opt ReadOptions) (io.ReadCloser, erro
 		"file_id":   opt.FileID,
 		"timestamp": strconv.FormatInt(time.Now().UnixNano(), 10),
 	}
+	if opt.Version > 0 {
+		reqParams["version"] = strconv.Itoa(opt.Version)
+	}
 	msg, err := util.GetSigMessage(reqParams)
 	if err != nil {
 		return nil, errorx.Internal(err, "failed to get the message to sign")
@@ -123,16 +139,146 @@ func (c *Client) Read(ctx context.Context, opt ReadOptions) (io.ReadCloser, erro
 		return nil, errorx.Wrap(err, "failed to sign")
 	}
 	reqParams["token"] = sig.String()
+	// range is not signed
+	if opt.Offset > 0 || opt.Length > 0 {
+		reqParams["offset"] = strconv.FormatUint(opt.Offset, 10)
+		reqParams["length"] = strconv.FormatUint(opt.Length, 10)
+	}
 
 	url := c.getRequestsUrl([]string{"file", "read"}, reqParams)
 	reader, err := httpkg.Get(ctx, url.String())
 	if err != nil {
 		return nil, err
 	}
+	if opt.E2EKey != nil {
+		plain, err := e2e.NewDecryptReader(reader, opt.E2EKey)
+		if err != nil {
+			reader.Close()
+			return nil, err
+		}
+		return struct {
+			io.Reader
+			io.Closer
+		}{plain, reader}, nil
+	}
 
 	return reader, nil
 }
 
+// InitUpload creates a resumable upload session, file is uploaded later by ranges
+func (c *Client) InitUpload(ctx context.Context, opt WriteOptions) (servertypes.UploadSessionResponse, error) {
+	// ranges of client encrypted file can not be re-encrypted identically when the upload is resumed
+	if opt.E2EKey != nil {
+		return servertypes.UploadSessionResponse{}, errorx.New(errorx.ErrCodeParam,
+			"resumable upload is not supported for client encrypted file")
+	}
+	privkey, err := ecdsa.DecodePrivateKeyFromString(opt.PrivateKey)
+	if err != nil {
+		return servertypes.UploadSessionResponse{}, err
+	}
+	reqParams := map[string]string{
+		"user":       ecdsa.PublicKeyFromPrivateKey(privkey).String(),
+		"ns":         opt.Namespace,
+		"name":       opt.FileName,
+		"desc":       opt.Description,
+		"ext":        opt.Extra,
+		"expireTime": strconv.FormatInt(opt.ExpireTime, 10),
+	}
+	msg, err := util.GetSigMessage(reqParams)
+	if err != nil {
+		return servertypes.UploadSessionResponse{}, errorx.Internal(err, "failed to get the message to sign")
+	}
+	sig, err := ecdsa.Sign(privkey, hash.HashUsingSha256([]byte(msg)))
+	if err != nil {
+		return servertypes.UploadSessionResponse{}, errorx.Wrap(err, "failed to sign")
+	}
+	reqParams["token"] = sig.String()
+
+	url := c.getRequestsUrl([]string{"file", "upload", "init"}, reqParams)
+	var resp servertypes.UploadSessionResponse
+	if err := httpkg.PostResponse(ctx, url.String(), nil, &resp); err != nil {
+		return resp, err
+	}
+	return resp, nil
+}
+
+// UploadRange uploads a range of file into the session, offset is the position of the range in the file
+func (c *Client) UploadRange(ctx context.Context, privateKey, sessionID string, offset uint64, r io.Reader) (
+	servertypes.UploadSessionResponse, error) {
+
+	reqParams := map[string]string{
+		"session": sessionID,
+		"offset":  strconv.FormatUint(offset, 10),
+	}
+	var resp servertypes.UploadSessionResponse
+	url, err := c.getUploadSessionUrl("range", privateKey, reqParams)
+	if err != nil {
+		return resp, err
+	}
+	if err := httpkg.PostResponse(ctx, url.String(), r, &resp); err != nil {
+		return resp, err
+	}
+	return resp, nil
+}
+
+// GetUploadSession queries the progress of the upload session
+func (c *Client) GetUploadSession(ctx context.Context, privateKey, sessionID string) (servertypes.UploadSessionResponse, error) {
+	var resp servertypes.UploadSessionResponse
+	url, err := c.getUploadSessionUrl("session", privateKey, map[string]string{"session": sessionID})
+	if err != nil {
+		return resp, err
+	}
+	if err := httpkg.GetResponse(ctx, url.String(), &resp); err != nil {
+		return resp, err
+	}
+	return resp, nil
+}
+
+// CommitUpload finishes the upload session and publishes the file
+func (c *Client) CommitUpload(ctx context.Context, privateKey, sessionID string) (servertypes.WriteResponse, error) {
+	var resp servertypes.WriteResponse
+	url, err := c.getUploadSessionUrl("commit", privateKey, map[string]string{"session": sessionID})
+	if err != nil {
+		return resp, err
+	}
+	if err := httpkg.PostResponse(ctx, url.String(), nil, &resp); err != nil {
+		return resp, err
+	}
+	return resp, nil
+}
+
+// AbortUpload cancels the upload session, slices already uploaded are removed from storage nodes
+func (c *Client) AbortUpload(ctx context.Context, privateKey, sessionID string) error {
+	url, err := c.getUploadSessionUrl("abort", privateKey, map[string]string{"session": sessionID})
+	if err != nil {
+		return err
+	}
+	if _, err := httpkg.Post(ctx, url.String(), nil); err != nil {
+		return err
+	}
+	return nil
+}
+
+// getUploadSessionUrl signs parameters of upload session operations and generates request url
+func (c *Client) getUploadSessionUrl(op, privateKey string, reqParams map[string]string) (url.URL, error) {
+	privkey, err := ecdsa.DecodePrivateKeyFromString(privateKey)
+	if err != nil {
+		return url.URL{}, err
+	}
+	reqParams["user"] = ecdsa.PublicKeyFromPrivateKey(privkey).String()
+	msg, err := util.GetSigMessage(reqParams)
+	if err != nil {
+		return url.URL{}, errorx.Internal(err, "failed to get the message to sign")
+	}
+	sig, err := ecdsa.Sign(privkey, hash.HashUsingSha256([]byte(msg)))
+	if err != nil {
+		return url.URL{}, errorx.Wrap(err, "failed to sign")
+	}
+	reqParams["token"] = sig.String()
+
+	return c.getRequestsUrl([]string{"file", "upload", op}, reqParams), nil
+}
+
 // ListNodes list all storage nodes in system
 func (c *Client) ListNodes(ctx context.Context) (blockchain.Nodes, error) {
 	var nodes blockchain.Nodes
@@ -182,6 +328,22 @@ func (c *Client) GetMigrateRecords(ctx context.Context, id string, start, end in
 	return ms, nil
 }
 
+// ListScrubReports lists reports of slices found corrupted or missing by scrubbing on storage node
+func (c *Client) ListScrubReports(ctx context.Context, id string, start, end int64, limit int64) ([]blockchain.SliceScrubReport, error) {
+	reqParams := map[string]string{
+		"id":    id,
+		"start": strconv.FormatInt(start, 10),
+		"end":   strconv.FormatInt(end, 10),
+		"limit": strconv.FormatInt(limit, 10),
+	}
+	var reports []blockchain.SliceScrubReport
+	url := c.getRequestsUrl([]string{"node", "scrubreport"}, reqParams)
+	if err := httpkg.GetResponse(ctx, url.String(), &reports); err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
 // GetNodeHealth get storage node health status by node id
 func (c *Client) GetNodeHealth(ctx context.Context, id string) (string, error) {
 	var status string
@@ -192,8 +354,24 @@ func (c *Client) GetNodeHealth(ctx context.Context, id string) (string, error) {
 	return status, nil
 }
 
-// setNodeOnlineStatus set storage node status online/offline
-func (c *Client) setNodeOnlineStatus(ctx context.Context, privateKey string, online bool) error {
+// GetNodeReputation get storage node reputation and its history events by node id
+func (c *Client) GetNodeReputation(ctx context.Context, id string, start, end int64, limit int64) (blockchain.NodeReputation, error) {
+	reqParams := map[string]string{
+		"id":    id,
+		"start": strconv.FormatInt(start, 10),
+		"end":   strconv.FormatInt(end, 10),
+		"limit": strconv.FormatInt(limit, 10),
+	}
+	var r blockchain.NodeReputation
+	url := c.getRequestsUrl([]string{"node", "reputation"}, reqParams)
+	if err := httpkg.GetResponse(ctx, url.String(), &r); err != nil {
+		return r, err
+	}
+	return r, nil
+}
+
+// setNodeStatus set storage node status by operation, which is 'online', 'offline' or 'drain'
+func (c *Client) setNodeStatus(ctx context.Context, privateKey string, operation string) error {
 	private, err := ecdsa.DecodePrivateKeyFromString(privateKey)
 	if err != nil {
 		return err
@@ -213,12 +391,7 @@ func (c *Client) setNodeOnlineStatus(ctx context.Context, privateKey string, onl
 	}
 	reqParams["token"] = sig.String()
 
-	var url url.URL
-	if online {
-		url = c.getRequestsUrl([]string{"node", "online"}, reqParams)
-	} else {
-		url = c.getRequestsUrl([]string{"node", "offline"}, reqParams)
-	}
+	url := c.getRequestsUrl([]string{"node", operation}, reqParams)
 	if _, err := httpkg.Post(ctx, url.String(), nil); err != nil {
 		return err
 	}
@@ -227,12 +400,17 @@ func (c *Client) setNodeOnlineStatus(ctx context.Context, privateKey string, onl
 
 // NodeOffline set storage node status offline
 func (c *Client) NodeOffline(ctx context.Context, privkey string) error {
-	return c.setNodeOnlineStatus(ctx, privkey, false)
+	return c.setNodeStatus(ctx, privkey, "offline")
 }
 
 // NodeOnline set storage node status online
 func (c *Client) NodeOnline(ctx context.Context, privkey string) error {
-	return c.setNodeOnlineStatus(ctx, privkey, true)
+	return c.setNodeStatus(ctx, privkey, "online")
+}
+
+// NodeDrain set storage node status draining, slices on it will be moved to other nodes
+func (c *Client) NodeDrain(ctx context.Context, privkey string) error {
+	return c.setNodeStatus(ctx, privkey, "drain")
 }
 
 // ListFiles list unexpired files
@@ -267,16 +445,61 @@ func (c *Client) GetFileByID(ctx context.Context, id string) (blockchain.FileH,
 	return hfile, nil
 }
 
-// GetFileByName get file info by file name, owner and namespace
-func (c *Client) GetFileByName(ctx context.Context, owner, ns, name string) (blockchain.FileH, error) {
+// GetFileAuditReport get the signed audit report of a file owned by the dataOwner node
+func (c *Client) GetFileAuditReport(ctx context.Context, id string) (servertypes.FileAuditReportResponse, error) {
+	var report servertypes.FileAuditReportResponse
+	url := c.getRequestsUrl([]string{"file", "audit"}, map[string]string{"id": id})
+	if err := httpkg.GetResponse(ctx, url.String(), &report); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+// GetFileAuditReportHTML get the signed audit report of a file rendered as html
+func (c *Client) GetFileAuditReportHTML(ctx context.Context, id string) ([]byte, error) {
+	url := c.getRequestsUrl([]string{"file", "audit"}, map[string]string{"id": id, "format": "html"})
+	body, err := httpkg.Get(ctx, url.String())
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	bs, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to read response")
+	}
+	return bs, nil
+}
+
+// GetFileByName get file info by file name, owner and namespace, version 0 means the latest version
+func (c *Client) GetFileByName(ctx context.Context, owner, ns, name string, version int) (blockchain.FileH, error) {
 	var hfile blockchain.FileH
-	url := c.getRequestsUrl([]string{"file", "getbyname"}, map[string]string{"owner": owner, "ns": ns, "name": name})
+	reqParams := map[string]string{"owner": owner, "ns": ns, "name": name}
+	if version > 0 {
+		reqParams["version"] = strconv.Itoa(version)
+	}
+	url := c.getRequestsUrl([]string{"file", "getbyname"}, reqParams)
 	if err := httpkg.GetResponse(ctx, url.String(), &hfile); err != nil {
 		return hfile, err
 	}
 	return hfile, nil
 }
 
+// ListFileVersions list versions of a file by file name, owner and namespace, the latest version comes first
+func (c *Client) ListFileVersions(ctx context.Context, owner, ns, name string, limit int64) ([]blockchain.File, error) {
+	reqParams := map[string]string{
+		"owner": owner,
+		"ns":    ns,
+		"name":  name,
+		"limit": strconv.FormatInt(limit, 10),
+	}
+	url := c.getRequestsUrl([]string{"file", "listversions"}, reqParams)
+	var files []blockchain.File
+	if err := httpkg.GetResponse(ctx, url.String(), &files); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
 // UpdateExpTimeByID update file expire time by file id
 func (c *Client) UpdateExpTimeByID(ctx context.Context, id, privateKey string, expireTime int64) error {
 	private, err := ecdsa.DecodePrivateKeyFromString(privateKey)
@@ -307,6 +530,75 @@ func (c *Client) UpdateExpTimeByID(ctx context.Context, id, privateKey string, e
 	return nil
 }
 
+// DeleteFileByID deletes file by file id before it expires
+func (c *Client) DeleteFileByID(ctx context.Context, id, privateKey string) error {
+	private, err := ecdsa.DecodePrivateKeyFromString(privateKey)
+	if err != nil {
+		return err
+	}
+	reqParams := map[string]string{
+		"id":    id,
+		"user":  ecdsa.PublicKeyFromPrivateKey(private).String(),
+		"ctime": strconv.FormatInt(time.Now().UnixNano(), 10),
+	}
+	msg, err := util.GetSigMessage(reqParams)
+	if err != nil {
+		return errorx.Internal(err, "failed to get the message to sign")
+	}
+
+	sig, err := ecdsa.Sign(private, hash.HashUsingSha256([]byte(msg)))
+	if err != nil {
+		return errorx.Wrap(err, "failed to sign file deletion")
+	}
+	reqParams["token"] = sig.String()
+
+	url := c.getRequestsUrl([]string{"file", "delete"}, reqParams)
+	if _, err := httpkg.Post(ctx, url.String(), nil); err != nil {
+		return err
+	}
+	return nil
+}
+
+// StartRekey starts re-encrypting slices of files in the namespace under the current password of dataOwner node,
+// files of all namespaces are re-encrypted if ns is empty
+func (c *Client) StartRekey(ctx context.Context, privateKey, ns string) (servertypes.RekeyJobResponse, error) {
+	var resp servertypes.RekeyJobResponse
+	private, err := ecdsa.DecodePrivateKeyFromString(privateKey)
+	if err != nil {
+		return resp, err
+	}
+	reqParams := map[string]string{
+		"ns":    ns,
+		"user":  ecdsa.PublicKeyFromPrivateKey(private).String(),
+		"ctime": strconv.FormatInt(time.Now().UnixNano(), 10),
+	}
+	msg, err := util.GetSigMessage(reqParams)
+	if err != nil {
+		return resp, errorx.Internal(err, "failed to get the message to sign")
+	}
+	sig, err := ecdsa.Sign(private, hash.HashUsingSha256([]byte(msg)))
+	if err != nil {
+		return resp, errorx.Wrap(err, "failed to sign rekey")
+	}
+	reqParams["token"] = sig.String()
+
+	url := c.getRequestsUrl([]string{"file", "rekey"}, reqParams)
+	if err := httpkg.PostResponse(ctx, url.String(), nil, &resp); err != nil {
+		return resp, err
+	}
+	return resp, nil
+}
+
+// GetRekeyJob queries the progress of the latest rekey job
+func (c *Client) GetRekeyJob(ctx context.Context) (servertypes.RekeyJobResponse, error) {
+	var resp servertypes.RekeyJobResponse
+	url := c.getRequestsUrl([]string{"file", "rekey"}, map[string]string{})
+	if err := httpkg.GetResponse(ctx, url.String(), &resp); err != nil {
+		return resp, err
+	}
+	return resp, nil
+}
+
 // AddFileNs add a file namespace
 func (c *Client) AddFileNs(ctx context.Context, owner, priKey, ns, des string, replica int) error {
 	private, err := ecdsa.DecodePrivateKeyFromString(priKey)
@@ -321,6 +613,50 @@ func (c *Client) AddFileNs(ctx context.Context, owner, priKey, ns, des string, r
 		"ctime":   strconv.FormatInt(time.Now().UnixNano(), 10),
 		"desc":    des,
 	}
+	return c.addFileNs(ctx, private, reqParams)
+}
+
+// AddErasureCodedFileNs add a file namespace whose files are erasure coded,
+// every dataShards slices of a file are grouped into a stripe with parityShards parity slices
+func (c *Client) AddErasureCodedFileNs(ctx context.Context, owner, priKey, ns, des string, dataShards, parityShards int) error {
+	private, err := ecdsa.DecodePrivateKeyFromString(priKey)
+	if err != nil {
+		return err
+	}
+
+	reqParams := map[string]string{
+		"ns":           ns,
+		"user":         ecdsa.PublicKeyFromPrivateKey(private).String(),
+		"replica":      "1",
+		"dataShards":   strconv.Itoa(dataShards),
+		"parityShards": strconv.Itoa(parityShards),
+		"ctime":        strconv.FormatInt(time.Now().UnixNano(), 10),
+		"desc":         des,
+	}
+	return c.addFileNs(ctx, private, reqParams)
+}
+
+// AddDedupFileNs add a file namespace whose identical slices are stored only once,
+// files in the namespace can't be shredded on deletion
+func (c *Client) AddDedupFileNs(ctx context.Context, owner, priKey, ns, des string, replica int) error {
+	private, err := ecdsa.DecodePrivateKeyFromString(priKey)
+	if err != nil {
+		return err
+	}
+
+	reqParams := map[string]string{
+		"ns":      ns,
+		"user":    ecdsa.PublicKeyFromPrivateKey(private).String(),
+		"replica": strconv.Itoa(replica),
+		"dedup":   "true",
+		"ctime":   strconv.FormatInt(time.Now().UnixNano(), 10),
+		"desc":    des,
+	}
+	return c.addFileNs(ctx, private, reqParams)
+}
+
+// addFileNs signs the namespace info and requests dataOwner node to add the namespace
+func (c *Client) addFileNs(ctx context.Context, private ecdsa.PrivateKey, reqParams map[string]string) error {
 	msg, err := util.GetSigMessage(reqParams)
 	if err != nil {
 		return errorx.Internal(err, "failed to get the message to sign")
@@ -417,6 +753,7 @@ func (c *Client) GetAuth(ctx context.Context, authID string) (blockchain.FileAut
 }
 
 // ConfirmOrRejectAuth confirm or reject applier's file authorization application by opt.Status
+//  When confirming authorization of client encrypted file, the file key is wrapped for the applier in the client
 func (c *Client) ConfirmOrRejectAuth(ctx context.Context, opt ConfirmAuthOptions) error {
 	private, err := ecdsa.DecodePrivateKeyFromString(opt.PrivateKey)
 	if err != nil {
@@ -429,6 +766,13 @@ func (c *Client) ConfirmOrRejectAuth(ctx context.Context, opt ConfirmAuthOptions
 		"expireTime":   strconv.FormatInt(opt.ExpireTime, 10),
 		"rejectReason": opt.RejectReason,
 	}
+	if opt.Status && opt.E2EKey != nil {
+		wrappedKey, err := c.wrapFileKey(ctx, opt)
+		if err != nil {
+			return err
+		}
+		reqParams["e2eKey"] = hex.EncodeToString(wrappedKey)
+	}
 	msg, err := util.GetSigMessage(reqParams)
 	if err != nil {
 		return errorx.Internal(err, "failed to get the message to sign")
@@ -446,6 +790,64 @@ func (c *Client) ConfirmOrRejectAuth(ctx context.Context, opt ConfirmAuthOptions
 	return nil
 }
 
+// wrapFileKey reads the header of the client encrypted file to derive its key,
+// and wraps the key for the applier's public key
+func (c *Client) wrapFileKey(ctx context.Context, opt ConfirmAuthOptions) ([]byte, error) {
+	fa, err := c.GetAuth(ctx, opt.AuthID)
+	if err != nil {
+		return nil, errorx.Wrap(err, "failed to get file authorization application")
+	}
+	reader, err := c.Read(ctx, ReadOptions{
+		PrivateKey: opt.PrivateKey,
+		FileID:     fa.FileID,
+		Length:     e2e.HeaderSize,
+	})
+	if err != nil {
+		return nil, errorx.Wrap(err, "failed to read header of the file")
+	}
+	defer reader.Close()
+
+	header := make([]byte, e2e.HeaderSize)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return nil, errorx.NewCode(err, errorx.ErrCodeParam, "failed to read header of the file")
+	}
+	fileKey, err := e2e.FileKey(header, opt.E2EKey)
+	if err != nil {
+		return nil, err
+	}
+	var applier ecdsa.PublicKey
+	copy(applier[:], fa.Applier)
+	return e2e.WrapFileKey(fileKey, applier)
+}
+
+// RevokeAuth revoke applier's approved file authorization application
+func (c *Client) RevokeAuth(ctx context.Context, privateKey, authID, revokeReason string) error {
+	private, err := ecdsa.DecodePrivateKeyFromString(privateKey)
+	if err != nil {
+		return err
+	}
+	reqParams := map[string]string{
+		"authID":       authID,
+		"user":         ecdsa.PublicKeyFromPrivateKey(private).String(),
+		"revokeReason": revokeReason,
+	}
+	msg, err := util.GetSigMessage(reqParams)
+	if err != nil {
+		return errorx.Internal(err, "failed to get the message to sign")
+	}
+	sig, err := ecdsa.Sign(private, hash.HashUsingSha256([]byte(msg)))
+	if err != nil {
+		return errorx.Wrap(err, "failed to sign revoke file authorization application")
+	}
+	reqParams["token"] = sig.String()
+
+	url := c.getRequestsUrl([]string{"file", "revokeauth"}, reqParams)
+	if _, err := httpkg.Post(ctx, url.String(), nil); err != nil {
+		return err
+	}
+	return nil
+}
+
 // ListFileAuths get the list of file authorization applications
 func (c *Client) ListFileAuths(ctx context.Context, opt ListFileAuthOptions) (blockchain.FileAuthApplications, error) {
 	reqParams := map[string]string{
@@ -32,6 +32,9 @@ type WriteOptions struct {
 	ExpireTime  int64
 	Description string
 	Extra       string
+
+	// user-held key to encrypt the file in the client, the node only sees ciphertext, see e2e.NewEncryptReader
+	E2EKey []byte
 }
 
 // ReadOptions download files using FileID or Namespace+FileName
@@ -40,8 +43,17 @@ type ReadOptions struct {
 
 	Namespace string
 	FileName  string
+	// version of the file located by name, 0 means the latest version
+	Version int
 
 	FileID string
+
+	// download a range of the file, Length 0 means to the end of the file
+	Offset uint64
+	Length uint64
+
+	// user-held key to decrypt the client encrypted file, ranges are not supported then
+	E2EKey []byte
 }
 
 // ListFileOptions support paging query
@@ -81,6 +93,10 @@ type ConfirmAuthOptions struct {
 	ExpireTime   int64
 	RejectReason string
 	Status       bool
+
+	// user-held key of the client encrypted file, the file key derived from it
+	// is wrapped for the applier's public key when confirming
+	E2EKey []byte
 }
 
 // GetChallengesOptions support paging query
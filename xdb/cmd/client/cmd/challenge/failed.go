@@ -17,6 +17,7 @@ import (
 	"context"
 	"encoding/hex"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -75,7 +76,7 @@ var failedCmd = &cobra.Command{
 				cTime := time.Unix(0, c.ChallengeTime).Format(timeTemplate)
 				aTime := time.Unix(0, c.AnswerTime).Format(timeTemplate)
 				fmt.Printf("ChallengeID: %s\nFileID: %s\nOwner: %s\nStorageNode: %s\nChallengeTime: %s\nAnswerTime: %s\n\n",
-					c.ID, c.FileID, fileOwner, string(c.TargetNode), cTime, aTime)
+					c.ID, strings.Join(c.FileIDs(), ","), fileOwner, string(c.TargetNode), cTime, aTime)
 			}
 		}
 		fmt.Printf("Failed challenges from %s to %s\nNum: %d\n\n", start, end, len(challenges))
@@ -17,6 +17,7 @@ import (
 	"context"
 	"encoding/hex"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -48,7 +49,7 @@ var getByIDCmd = &cobra.Command{
 			aTime = time.Unix(0, challenge.AnswerTime).Format(timeTemplate)
 		}
 		fmt.Printf("ID: %s\nFileOwner: %s\nTargetNode: %v\nFileID: %v\nStatus: %v\nChallengeTime: %v\nAnswerTime: %v\n\n",
-			challenge.ID, fileOwner, targetNode, challenge.FileID, challenge.Status, cTime, aTime)
+			challenge.ID, fileOwner, targetNode, strings.Join(challenge.FileIDs(), ","), challenge.Status, cTime, aTime)
 	},
 }
 
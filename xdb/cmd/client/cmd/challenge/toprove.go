@@ -17,6 +17,7 @@ import (
 	"context"
 	"encoding/hex"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -74,7 +75,7 @@ var toProveCmd = &cobra.Command{
 				fileOwner := hex.EncodeToString(c.FileOwner)
 				cTime := time.Unix(0, c.ChallengeTime).Format(timeTemplate)
 				fmt.Printf("ChallengeID: %s\nFileID: %s\nOwner: %s\nStorageNode: %s\nChallengeTime: %s\n\n",
-					c.ID, c.FileID, fileOwner, string(c.TargetNode), cTime)
+					c.ID, strings.Join(c.FileIDs(), ","), fileOwner, string(c.TargetNode), cTime)
 			}
 		}
 		fmt.Printf("ToProve challenges from %s to %s\nNum: %d\n\n", start, end, len(challenges))
@@ -25,7 +25,10 @@ import (
 )
 
 var (
-	replica int
+	replica      int
+	dataShards   int
+	parityShards int
+	dedup        bool
 )
 
 // addNsCmd represents the command to add namespace
@@ -38,10 +41,19 @@ var addNsCmd = &cobra.Command{
 			fmt.Printf("err：%v\n", err)
 			return
 		}
-		if replica <= 0 || len(namespace) == 0 {
+		isErasureCoded := dataShards > 0 || parityShards > 0
+		if (replica <= 0 && !isErasureCoded) || len(namespace) == 0 {
 			fmt.Printf("err: bad param, replica and ns length must greater than 0")
 			return
 		}
+		if isErasureCoded && (dataShards <= 0 || parityShards <= 0) {
+			fmt.Printf("err: bad param, dataShards and parityShards must greater than 0")
+			return
+		}
+		if isErasureCoded && dedup {
+			fmt.Printf("err: bad param, erasure coded namespace can't be deduplicated")
+			return
+		}
 
 		if privateKey == "" {
 			privateKeyBytes, err := file.ReadFile(keyPath, file.PrivateKeyFileName)
@@ -52,7 +64,13 @@ var addNsCmd = &cobra.Command{
 			privateKey = strings.TrimSpace(string(privateKeyBytes))
 		}
 
-		err = client.AddFileNs(context.Background(), owner, privateKey, namespace, description, replica)
+		if isErasureCoded {
+			err = client.AddErasureCodedFileNs(context.Background(), owner, privateKey, namespace, description, dataShards, parityShards)
+		} else if dedup {
+			err = client.AddDedupFileNs(context.Background(), owner, privateKey, namespace, description, replica)
+		} else {
+			err = client.AddFileNs(context.Background(), owner, privateKey, namespace, description, replica)
+		}
 		if err != nil {
 			fmt.Printf("err：%v\n", err)
 			return
@@ -70,7 +88,9 @@ func init() {
 	addNsCmd.Flags().StringVarP(&namespace, "namespace", "n", "", "namespace for file")
 	addNsCmd.Flags().StringVarP(&description, "description", "d", "", "description")
 	addNsCmd.Flags().IntVarP(&replica, "replica", "r", 0, "replica")
+	addNsCmd.Flags().IntVarP(&dataShards, "dataShards", "", 0, "number of data slices of a stripe, files are erasure coded instead of replicated if set")
+	addNsCmd.Flags().IntVarP(&parityShards, "parityShards", "", 0, "number of parity slices of a stripe, required if dataShards is set")
+	addNsCmd.Flags().BoolVarP(&dedup, "dedup", "", false, "store identical slices only once, files in the namespace can't be shredded on deletion")
 
 	addNsCmd.MarkFlagRequired("namespace")
-	addNsCmd.MarkFlagRequired("replica")
 }
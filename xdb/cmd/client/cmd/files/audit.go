@@ -0,0 +1,84 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package files
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/spf13/cobra"
+
+	httpclient "github.com/PaddlePaddle/PaddleDTX/xdb/client/http"
+)
+
+var format string
+
+// auditCmd represents the command to export the signed audit report of a file
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "export the signed audit report of a file's full lifecycle, in json or html",
+	Run: func(cmd *cobra.Command, args []string) {
+		client, err := httpclient.New(host)
+		if err != nil {
+			fmt.Printf("err：%v\n", err)
+			return
+		}
+
+		var content []byte
+		switch format {
+		case "json":
+			report, err := client.GetFileAuditReport(context.Background(), id)
+			if err != nil {
+				fmt.Printf("err：%v\n", err)
+				return
+			}
+			content, err = json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				fmt.Printf("err：%v\n", err)
+				return
+			}
+		case "html":
+			content, err = client.GetFileAuditReportHTML(context.Background(), id)
+			if err != nil {
+				fmt.Printf("err：%v\n", err)
+				return
+			}
+		default:
+			fmt.Printf("err：invalid format %s, must be json or html\n", format)
+			return
+		}
+
+		if output == "" {
+			fmt.Printf("%s\n", content)
+			return
+		}
+		if err := ioutil.WriteFile(output, content, 0644); err != nil {
+			fmt.Printf("err：%v\n", err)
+			return
+		}
+		fmt.Printf("audit report of file %s saved to %s\n", id, output)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+
+	auditCmd.Flags().StringVarP(&id, "id", "i", "", "id for file")
+	auditCmd.Flags().StringVarP(&format, "format", "f", "json", "format of the report, json or html")
+	auditCmd.Flags().StringVarP(&output, "output", "o", "", "output file path, print the report if not set")
+
+	auditCmd.MarkFlagRequired("id")
+}
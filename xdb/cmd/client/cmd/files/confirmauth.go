@@ -27,6 +27,7 @@ import (
 
 var (
 	rejectReason string
+	revokeReason string
 )
 
 // confirmAuthCmd represents the command to confirm applier's file authorization application
@@ -52,11 +53,17 @@ var confirmAuthCmd = &cobra.Command{
 			}
 			privateKey = strings.TrimSpace(string(privateKeyBytes))
 		}
+		e2eKey, err := readE2EKey()
+		if err != nil {
+			fmt.Printf("Read e2e key failed, err: %v\n", err)
+			return
+		}
 		opt := httpclient.ConfirmAuthOptions{
 			PrivateKey: privateKey,
 			AuthID:     authID,
 			ExpireTime: stamp.UnixNano(),
 			Status:     true,
+			E2EKey:     e2eKey,
 		}
 		if err := client.ConfirmOrRejectAuth(context.Background(), opt); err != nil {
 			fmt.Printf("err：%v\n", err)
@@ -98,23 +105,60 @@ var rejectAuthCmd = &cobra.Command{
 	},
 }
 
+// revokeAuthCmd represents the command to revoke applier's approved file authorization application
+var revokeAuthCmd = &cobra.Command{
+	Use:   "revokeauth",
+	Short: "revoke the applier's approved file authorization application",
+	Run: func(cmd *cobra.Command, args []string) {
+		client, err := httpclient.New(host)
+		if err != nil {
+			fmt.Printf("err：%v\n", err)
+			return
+		}
+		if privateKey == "" {
+			privateKeyBytes, err := file.ReadFile(keyPath, file.PrivateKeyFileName)
+			if err != nil {
+				fmt.Printf("Read privateKey failed, err: %v\n", err)
+				return
+			}
+			privateKey = strings.TrimSpace(string(privateKeyBytes))
+		}
+		if err := client.RevokeAuth(context.Background(), privateKey, authID, revokeReason); err != nil {
+			fmt.Printf("err：%v\n", err)
+			return
+		}
+		fmt.Println("OK")
+	},
+}
+
 func init() {
 	rootCmd.AddCommand(confirmAuthCmd)
 	rootCmd.AddCommand(rejectAuthCmd)
+	rootCmd.AddCommand(revokeAuthCmd)
 
 	confirmAuthCmd.Flags().StringVarP(&privateKey, "privkey", "k", "", "private key")
 	confirmAuthCmd.Flags().StringVarP(&keyPath, "keyPath", "", "./ukeys", "key path")
 	confirmAuthCmd.Flags().StringVarP(&authID, "authID", "i", "", "id for file authorization application")
 	confirmAuthCmd.Flags().StringVarP(&expireTime, "expireTime", "e", "", "expire time, example '2022-07-10 12:00:00'")
+	confirmAuthCmd.Flags().BoolVar(&e2eMode, "e2e", false,
+		"for client encrypted file, wrap its key for the applier by the key 'e2e.key' in key path")
 
 	rejectAuthCmd.Flags().StringVarP(&privateKey, "privkey", "k", "", "private key")
 	rejectAuthCmd.Flags().StringVarP(&keyPath, "keyPath", "", "./ukeys", "key path")
 	rejectAuthCmd.Flags().StringVarP(&authID, "authID", "i", "", "id for file authorization application")
 	rejectAuthCmd.Flags().StringVarP(&rejectReason, "rejectReason", "r", "", "reason for reject the authorization")
 
+	revokeAuthCmd.Flags().StringVarP(&privateKey, "privkey", "k", "", "private key")
+	revokeAuthCmd.Flags().StringVarP(&keyPath, "keyPath", "", "./ukeys", "key path")
+	revokeAuthCmd.Flags().StringVarP(&authID, "authID", "i", "", "id for file authorization application")
+	revokeAuthCmd.Flags().StringVarP(&revokeReason, "revokeReason", "r", "", "reason for revoke the authorization")
+
 	confirmAuthCmd.MarkFlagRequired("authID")
 	confirmAuthCmd.MarkFlagRequired("expireTime")
 
 	rejectAuthCmd.MarkFlagRequired("authID")
 	rejectAuthCmd.MarkFlagRequired("rejectReason")
+
+	revokeAuthCmd.MarkFlagRequired("authID")
+	revokeAuthCmd.MarkFlagRequired("revokeReason")
 }
@@ -0,0 +1,64 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package files
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	httpclient "github.com/PaddlePaddle/PaddleDTX/xdb/client/http"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/pkgs/file"
+)
+
+// deleteCmd represents the command to delete a file before it expires
+var deleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "delete file by id, slices of the file are cleared and its keys are destroyed",
+	Run: func(cmd *cobra.Command, args []string) {
+		client, err := httpclient.New(host)
+		if err != nil {
+			fmt.Printf("err：%v\n", err)
+			return
+		}
+
+		if privateKey == "" {
+			privateKeyBytes, err := file.ReadFile(keyPath, file.PrivateKeyFileName)
+			if err != nil {
+				fmt.Printf("Read privateKey failed, err: %v\n", err)
+				return
+			}
+			privateKey = strings.TrimSpace(string(privateKeyBytes))
+		}
+
+		err = client.DeleteFileByID(context.Background(), id, privateKey)
+		if err != nil {
+			fmt.Printf("err：%v\n", err)
+			return
+		}
+		fmt.Println("OK")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(deleteCmd)
+
+	deleteCmd.Flags().StringVarP(&id, "id", "i", "", "id for file")
+	deleteCmd.Flags().StringVarP(&privateKey, "privkey", "k", "", "private key")
+	deleteCmd.Flags().StringVarP(&keyPath, "keyPath", "", "./ukeys", "key path")
+
+	deleteCmd.MarkFlagRequired("id")
+}
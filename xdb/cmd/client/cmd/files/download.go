@@ -29,6 +29,9 @@ import (
 var (
 	fileID string
 	output string
+
+	offset uint64
+	length uint64
 )
 
 // downloadCmd represents the command to download file from xuper db
@@ -56,11 +59,21 @@ var downloadCmd = &cobra.Command{
 			privateKey = strings.TrimSpace(string(privateKeyBytes))
 		}
 
+		e2eKey, err := readE2EKey()
+		if err != nil {
+			fmt.Printf("Read e2e key failed, err: %v\n", err)
+			return
+		}
+
 		opt := httpclient.ReadOptions{
 			PrivateKey: privateKey,
 			Namespace:  namespace,
 			FileName:   filename,
+			Version:    version,
 			FileID:     fileID,
+			Offset:     offset,
+			Length:     length,
+			E2EKey:     e2eKey,
 		}
 
 		reader, err := client.Read(context.Background(), opt)
@@ -95,6 +108,10 @@ func init() {
 	downloadCmd.Flags().StringVarP(&namespace, "namespace", "n", "", "namespace for file")
 	downloadCmd.Flags().StringVarP(&filename, "filename", "m", "", "file name")
 	downloadCmd.Flags().StringVarP(&fileID, "fileid", "f", "", "file id")
+	downloadCmd.Flags().IntVarP(&version, "version", "v", 0, "version of the file located by name, 0 means the latest version")
+	downloadCmd.Flags().Uint64Var(&offset, "offset", 0, "start position of the range to download")
+	downloadCmd.Flags().Uint64Var(&length, "length", 0, "length of the range to download, 0 means to the end of file")
+	downloadCmd.Flags().BoolVar(&e2eMode, "e2e", false, "decrypt the client encrypted file by the key 'e2e.key' in key path")
 
 	downloadCmd.MarkFlagRequired("output")
 }
@@ -43,8 +43,8 @@ var getByIDCmd = &cobra.Command{
 		slicesMap := getFileSliceMap(f)
 		ptime := time.Unix(0, f.PublishTime).Format(timeTemplate)
 		etime := time.Unix(0, f.ExpireTime).Format(timeTemplate)
-		fmt.Printf("FileID: %s\nOwner: %x\nFileName: %s\nFileDescription: %s\nNamespace: %s\nSlicesMap: %v\nFileLength: %v\n",
-			f.ID, f.Owner, f.Name, f.Description, f.Namespace, slicesMap, f.Length)
+		fmt.Printf("FileID: %s\nOwner: %x\nFileName: %s\nVersion: %d\nFileDescription: %s\nNamespace: %s\nSlicesMap: %v\nFileLength: %v\n",
+			f.ID, f.Owner, f.Name, f.GetVersion(), f.Description, f.Namespace, slicesMap, f.Length)
 		fmt.Printf("Health: %s\nPublishTime: %s\nExpireTime: %s\nExtra: %s\n\n", hf.Health, ptime, etime, f.Ext)
 	},
 }
@@ -60,7 +60,7 @@ var getByNameCmd = &cobra.Command{
 			return
 		}
 
-		hf, err := client.GetFileByName(context.Background(), owner, namespace, filename)
+		hf, err := client.GetFileByName(context.Background(), owner, namespace, filename, version)
 		if err != nil {
 			fmt.Printf("err：%v\n", err)
 			return
@@ -69,8 +69,8 @@ var getByNameCmd = &cobra.Command{
 		slicesMap := getFileSliceMap(f)
 		ptime := time.Unix(0, f.PublishTime).Format(timeTemplate)
 		etime := time.Unix(0, f.ExpireTime).Format(timeTemplate)
-		fmt.Printf("FileID: %s\nFileDescription: %s\nSlicesMap: %v\nFileLength: %v\nHealth: %s\nPublishTimes: %s\nExpireTime: %s\nExtra: %s\n\n",
-			f.ID, f.Description, slicesMap, f.Length, hf.Health, ptime, etime, f.Ext)
+		fmt.Printf("FileID: %s\nVersion: %d\nFileDescription: %s\nSlicesMap: %v\nFileLength: %v\nHealth: %s\nPublishTimes: %s\nExpireTime: %s\nExtra: %s\n\n",
+			f.ID, f.GetVersion(), f.Description, slicesMap, f.Length, hf.Health, ptime, etime, f.Ext)
 	},
 }
 
@@ -91,6 +91,7 @@ func init() {
 	getByNameCmd.Flags().StringVarP(&owner, "owner", "o", "", "owner for file")
 	getByNameCmd.Flags().StringVarP(&namespace, "namespace", "n", "", "namespace for file")
 	getByNameCmd.Flags().StringVarP(&filename, "filename", "m", "", "file name")
+	getByNameCmd.Flags().IntVarP(&version, "version", "v", 0, "version of the file, 0 means the latest version")
 
 	getByIDCmd.MarkFlagRequired("id")
 
@@ -45,6 +45,12 @@ var getNsCmd = &cobra.Command{
 
 		fmt.Printf("Name: %s\nFileTotalNum: %d\nFileNormalNum: %d\nFileExpiredNum: %d\nReplica: %d\nGreenFileNum: %d\nYellowFileNum: %d\nRedFileNum: %d\n",
 			ns.Name, ns.FileTotalNum, nsh.FileNormalNum, nsh.FileExpiredNum, ns.Replica, nsh.GreenFileNum, nsh.YellowFileNum, nsh.RedFileNum)
+		if ns.DataShards > 0 {
+			fmt.Printf("DataShards: %d\nParityShards: %d\n", ns.DataShards, ns.ParityShards)
+		}
+		if ns.Dedup {
+			fmt.Printf("Dedup: true\n")
+		}
 		fmt.Printf("Description: %s\nUpdateTime: %s\nCreateTime: %s\n\n", ns.Description, utime, ctime)
 	},
 }
@@ -69,8 +69,14 @@ var nsListCmd = &cobra.Command{
 		for _, ns := range response {
 			ctime := time.Unix(0, ns.CreateTime).Format(timeTemplate)
 			utime := time.Unix(0, ns.UpdateTime).Format(timeTemplate)
-			fmt.Printf("Name: %s\nFileTotalNum: %d\nReplica: %d\nNsDescription: %s\nUpdateTime: %s\nCreateTime: %s\n\n",
-				ns.Name, ns.FileTotalNum, ns.Replica, ns.Description, utime, ctime)
+			fmt.Printf("Name: %s\nFileTotalNum: %d\nReplica: %d\n", ns.Name, ns.FileTotalNum, ns.Replica)
+			if ns.DataShards > 0 {
+				fmt.Printf("DataShards: %d\nParityShards: %d\n", ns.DataShards, ns.ParityShards)
+			}
+			if ns.Dedup {
+				fmt.Printf("Dedup: true\n")
+			}
+			fmt.Printf("NsDescription: %s\nUpdateTime: %s\nCreateTime: %s\n\n", ns.Description, utime, ctime)
 		}
 		if len(response) == 0 {
 			fmt.Printf("\nno ns, please add first\n\n")
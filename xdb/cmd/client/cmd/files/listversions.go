@@ -0,0 +1,77 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package files
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
+	httpclient "github.com/PaddlePaddle/PaddleDTX/xdb/client/http"
+)
+
+// listVersionsCmd represents the command to list versions of a file by ns+name
+var listVersionsCmd = &cobra.Command{
+	Use:   "listversions",
+	Short: "list versions of the file by name in XuperDB, the latest version comes first",
+	Run: func(cmd *cobra.Command, args []string) {
+		client, err := httpclient.New(host)
+		if err != nil {
+			fmt.Printf("err：%v\n", err)
+			return
+		}
+		if limit > blockchain.ListMaxNumber {
+			fmt.Printf("invalid limit, the value must smaller than %v \n", blockchain.ListMaxNumber)
+			return
+		}
+
+		resp, err := client.ListFileVersions(context.Background(), owner, namespace, filename, limit)
+		if err != nil {
+			fmt.Printf("err：%v\n", err)
+			return
+		}
+		for _, f := range resp {
+			ptime := time.Unix(0, f.PublishTime).Format(timeTemplate)
+			etime := time.Unix(0, f.ExpireTime).Format(timeTemplate)
+			status := "valid"
+			if f.DeleteTime > 0 {
+				status = "deleted"
+			} else if f.ExpireTime <= time.Now().UnixNano() {
+				status = "expired"
+			}
+			fmt.Printf("Version: %d\nFileID: %s\nFileDescription: %s\nFileLength: %v\nPublishTimes: %s\nExpireTime: %s\nStatus: %s\n\n",
+				f.GetVersion(), f.ID, f.Description, f.Length, ptime, etime, status)
+		}
+		if len(resp) == 0 {
+			fmt.Printf("\nno files\n\n")
+		} else {
+			fmt.Printf("\nversions num: %d\n\n", len(resp))
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(listVersionsCmd)
+
+	listVersionsCmd.Flags().StringVarP(&owner, "owner", "o", "", "owner for file")
+	listVersionsCmd.Flags().StringVarP(&namespace, "namespace", "n", "", "namespace for file")
+	listVersionsCmd.Flags().StringVarP(&filename, "filename", "m", "", "file name")
+	listVersionsCmd.Flags().Int64VarP(&limit, "limit", "l", blockchain.ListMaxNumber, "limit for list versions")
+
+	listVersionsCmd.MarkFlagRequired("namespace")
+	listVersionsCmd.MarkFlagRequired("filename")
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package files
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	httpclient "github.com/PaddlePaddle/PaddleDTX/xdb/client/http"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/pkgs/file"
+	servertypes "github.com/PaddlePaddle/PaddleDTX/xdb/server/types"
+)
+
+const rekeyJobRunning = "Running"
+
+var (
+	rekeyStatus   bool
+	rekeyWatch    bool
+	watchInterval int
+)
+
+// rekeyCmd represents the command to re-encrypt slices of files under the current password of dataOwner node
+var rekeyCmd = &cobra.Command{
+	Use:   "rekey",
+	Short: "re-encrypt slices of files under the current password after the password of dataOwner node is changed",
+	Run: func(cmd *cobra.Command, args []string) {
+		client, err := httpclient.New(host)
+		if err != nil {
+			fmt.Printf("err：%v\n", err)
+			return
+		}
+
+		var job servertypes.RekeyJobResponse
+		if rekeyStatus {
+			job, err = client.GetRekeyJob(context.Background())
+		} else {
+			if privateKey == "" {
+				privateKeyBytes, err := file.ReadFile(keyPath, file.PrivateKeyFileName)
+				if err != nil {
+					fmt.Printf("Read privateKey failed, err: %v\n", err)
+					return
+				}
+				privateKey = strings.TrimSpace(string(privateKeyBytes))
+			}
+			job, err = client.StartRekey(context.Background(), privateKey, namespace)
+		}
+		if err != nil {
+			fmt.Printf("err：%v\n", err)
+			return
+		}
+		printRekeyJob(job)
+
+		// poll the progress until the job stops
+		for rekeyWatch && job.State == rekeyJobRunning {
+			time.Sleep(time.Duration(watchInterval) * time.Second)
+			job, err = client.GetRekeyJob(context.Background())
+			if err != nil {
+				fmt.Printf("err：%v\n", err)
+				return
+			}
+			printRekeyJob(job)
+		}
+	},
+}
+
+func printRekeyJob(job servertypes.RekeyJobResponse) {
+	stime := time.Unix(0, job.StartTime).Format(timeTemplate)
+	etime := "-"
+	if job.EndTime > 0 {
+		etime = time.Unix(0, job.EndTime).Format(timeTemplate)
+	}
+	fmt.Printf("JobID: %s  Namespace: %s  KeyVersion: %d  MinKeyVersion: %d  State: %s  Total: %d  Done: %d  Failed: %d  "+
+		"Skipped: %d  Deduplicated: %d  StartTime: %s  EndTime: %s\n", job.ID, job.Namespace, job.KeyVersion,
+		job.MinKeyVersion, job.State, job.Total, job.Done, job.Failed, job.Skipped, job.Deduplicated, stime, etime)
+}
+
+func init() {
+	rootCmd.AddCommand(rekeyCmd)
+
+	rekeyCmd.Flags().StringVarP(&privateKey, "privkey", "k", "", "private key")
+	rekeyCmd.Flags().StringVarP(&keyPath, "keyPath", "", "./ukeys", "key path")
+	rekeyCmd.Flags().StringVarP(&namespace, "namespace", "n", "", "namespace of files to re-encrypt, files of all namespaces are re-encrypted if not set")
+	rekeyCmd.Flags().BoolVar(&rekeyStatus, "status", false, "query the progress of the latest rekey job instead of starting a new one")
+	rekeyCmd.Flags().BoolVarP(&rekeyWatch, "watch", "w", false, "watch the progress until the job stops")
+	rekeyCmd.Flags().IntVar(&watchInterval, "interval", 5, "interval in seconds to query the progress when watching")
+}
@@ -15,6 +15,9 @@ package files
 
 import (
 	"github.com/spf13/cobra"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/client/e2e"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/pkgs/file"
 )
 
 const timeTemplate = "2006-01-02 15:04:05"
@@ -30,6 +33,8 @@ var (
 	end        string
 	limit      int64
 	id         string
+	version    int
+	e2eMode    bool
 )
 
 // rootCmd represents the root command to manage tasks
@@ -41,6 +46,18 @@ var rootCmd = &cobra.Command{
 func RootCmd() *cobra.Command {
 	return rootCmd
 }
+
+// readE2EKey reads the user-held key to encrypt files in the client, nil if client encryption is not enabled
+func readE2EKey() ([]byte, error) {
+	if !e2eMode {
+		return nil, nil
+	}
+	content, err := file.ReadFile(keyPath, file.E2EKeyFileName)
+	if err != nil {
+		return nil, err
+	}
+	return e2e.DecodeKey(string(content))
+}
 func init() {
 	rootCmd.PersistentFlags().StringVar(&host, "host", "", "server address of the dataOwner node, example 'http://127.0.0.1:8121'")
 
@@ -14,8 +14,10 @@
 package files
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"time"
@@ -31,6 +33,11 @@ var (
 	description string
 	extra       string
 	expireTime  string
+
+	resumable  bool
+	sessionID  string
+	chunkSize  int64
+	retryTimes int
 )
 
 // uploadDataCmd represents the command to upload file into xuper db
@@ -66,6 +73,12 @@ var uploadCmd = &cobra.Command{
 			privateKey = strings.TrimSpace(string(privateKeyBytes))
 		}
 
+		e2eKey, err := readE2EKey()
+		if err != nil {
+			fmt.Printf("Read e2e key failed, err: %v\n", err)
+			return
+		}
+
 		opt := httpclient.WriteOptions{
 			PrivateKey:  privateKey,
 			Namespace:   namespace,
@@ -73,6 +86,14 @@ var uploadCmd = &cobra.Command{
 			ExpireTime:  stamp.UnixNano(),
 			Description: description,
 			Extra:       extra,
+			E2EKey:      e2eKey,
+		}
+
+		if resumable || sessionID != "" {
+			if err := uploadBySession(client, f, opt); err != nil {
+				fmt.Printf("err：%v\n", err)
+			}
+			return
 		}
 
 		resp, err := client.Write(context.Background(), f, opt)
@@ -82,11 +103,111 @@ var uploadCmd = &cobra.Command{
 		}
 
 		fmt.Println("FileID:", resp.FileID)
+		fmt.Println("Version:", resp.Version)
+	},
+}
+
+// abortUploadCmd represents the command to abort a resumable upload session
+var abortUploadCmd = &cobra.Command{
+	Use:   "abortupload",
+	Short: "abort a resumable upload session, slices already uploaded are removed",
+	Run: func(cmd *cobra.Command, args []string) {
+		client, err := httpclient.New(host)
+		if err != nil {
+			fmt.Printf("err：%v\n", err)
+			return
+		}
+		if privateKey == "" {
+			privateKeyBytes, err := file.ReadFile(keyPath, file.PrivateKeyFileName)
+			if err != nil {
+				fmt.Printf("Read privateKey failed, err: %v\n", err)
+				return
+			}
+			privateKey = strings.TrimSpace(string(privateKeyBytes))
+		}
+		if err := client.AbortUpload(context.Background(), privateKey, sessionID); err != nil {
+			fmt.Printf("err：%v\n", err)
+			return
+		}
+		fmt.Println("OK")
 	},
 }
 
+// uploadBySession uploads the file by ranges in a resumable upload session,
+// if the upload is interrupted, it can be resumed later by specifying the session ID
+func uploadBySession(client httpclient.Client, f *os.File, opt httpclient.WriteOptions) error {
+	if chunkSize <= 0 {
+		return fmt.Errorf("invalid chunk size %d", chunkSize)
+	}
+	ctx := context.Background()
+	if sessionID == "" {
+		session, err := client.InitUpload(ctx, opt)
+		if err != nil {
+			return err
+		}
+		sessionID = session.SessionID
+	}
+	fmt.Println("SessionID:", sessionID)
+
+	retried := 0
+	for {
+		err := uploadRanges(ctx, client, f, opt.PrivateKey)
+		if err == nil {
+			break
+		}
+		if retried >= retryTimes {
+			fmt.Printf("upload interrupted, resume it by running the same command with '--session %s'\n", sessionID)
+			return err
+		}
+		retried++
+		fmt.Printf("upload interrupted, err: %v, retry %d time(s)...\n", err, retried)
+		time.Sleep(time.Duration(retried) * time.Second)
+	}
+
+	resp, err := client.CommitUpload(ctx, opt.PrivateKey, sessionID)
+	if err != nil {
+		fmt.Printf("failed to commit, retry by running the same command with '--session %s'\n", sessionID)
+		return err
+	}
+	fmt.Println("FileID:", resp.FileID)
+	fmt.Println("Version:", resp.Version)
+	return nil
+}
+
+// uploadRanges queries the progress of upload session, and uploads the rest of the file
+func uploadRanges(ctx context.Context, client httpclient.Client, f *os.File, privkey string) error {
+	session, err := client.GetUploadSession(ctx, privkey, sessionID)
+	if err != nil {
+		return err
+	}
+	offset := session.Offset
+	if _, err := f.Seek(int64(offset), io.SeekStart); err != nil {
+		return err
+	}
+
+	buf := make([]byte, chunkSize)
+	for {
+		n, err := io.ReadFull(f, buf)
+		if n > 0 {
+			resp, err := client.UploadRange(ctx, privkey, sessionID, offset, bytes.NewReader(buf[:n]))
+			if err != nil {
+				return err
+			}
+			offset = resp.Offset
+			fmt.Printf("uploaded %d bytes\n", offset)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(uploadCmd)
+	rootCmd.AddCommand(abortUploadCmd)
 
 	uploadCmd.Flags().StringVarP(&privateKey, "privkey", "k", "", "private key")
 	uploadCmd.Flags().StringVarP(&keyPath, "keyPath", "", "./ukeys", "key path")
@@ -96,9 +217,20 @@ func init() {
 	uploadCmd.Flags().StringVarP(&description, "description", "d", "", "file description")
 	uploadCmd.Flags().StringVarP(&expireTime, "expireTime", "e", "", "expire time, example '2021-06-10 12:00:00'")
 	uploadCmd.Flags().StringVar(&extra, "ext", "", "file extra info")
+	uploadCmd.Flags().BoolVar(&resumable, "resumable", false, "upload file by ranges in a resumable upload session")
+	uploadCmd.Flags().StringVar(&sessionID, "session", "", "resume the upload session with given ID")
+	uploadCmd.Flags().Int64Var(&chunkSize, "chunkSize", 4*1024*1024, "size of each range in bytes when uploading in a session")
+	uploadCmd.Flags().IntVar(&retryTimes, "retry", 3, "retry times when uploading a range fails in a session")
+	uploadCmd.Flags().BoolVar(&e2eMode, "e2e", false, "encrypt the file in the client by the key 'e2e.key' in key path")
 
 	uploadCmd.MarkFlagRequired("input")
 	uploadCmd.MarkFlagRequired("namespace")
 	uploadCmd.MarkFlagRequired("filename")
 	uploadCmd.MarkFlagRequired("expireTime")
+
+	abortUploadCmd.Flags().StringVarP(&privateKey, "privkey", "k", "", "private key")
+	abortUploadCmd.Flags().StringVarP(&keyPath, "keyPath", "", "./ukeys", "key path")
+	abortUploadCmd.Flags().StringVar(&sessionID, "session", "", "upload session ID")
+
+	abortUploadCmd.MarkFlagRequired("session")
 }
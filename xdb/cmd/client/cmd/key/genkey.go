@@ -15,18 +15,21 @@ package key
 
 import (
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 
 	fl_crypto "github.com/PaddlePaddle/PaddleDTX/crypto/client/service/xchain"
 	"github.com/PaddlePaddle/PaddleDTX/crypto/core/ecdsa"
 	"github.com/spf13/cobra"
 
+	"github.com/PaddlePaddle/PaddleDTX/xdb/client/e2e"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/pkgs/file"
 )
 
 var xchainClient = new(fl_crypto.XchainCryptoClient)
 
 var output string
+var e2eOutput string
 
 // genKeyCmd randomly generates node private/public key pair
 var genKeyCmd = &cobra.Command{
@@ -81,9 +84,30 @@ var genPDPKeyCmd = &cobra.Command{
 	},
 }
 
+// genE2EKeyCmd randomly generates the user-held key to encrypt files in the client
+var genE2EKeyCmd = &cobra.Command{
+	Use:   "gene2ekey",
+	Short: "generate the key to encrypt files in the client",
+	Run: func(cmd *cobra.Command, args []string) {
+		key, err := e2e.GenerateKey()
+		if err != nil {
+			fmt.Printf("failed to generate e2e key, err: %v\n", err)
+			return
+		}
+		err = file.WriteFile(e2eOutput, file.E2EKeyFileName, []byte(hex.EncodeToString(key)))
+		if err != nil {
+			fmt.Printf("failed to save e2e.key, err: %v\n", err)
+			return
+		}
+		fmt.Println("OK")
+	},
+}
+
 func init() {
 	rootCmd.AddCommand(genKeyCmd)
 	rootCmd.AddCommand(genPDPKeyCmd)
+	rootCmd.AddCommand(genE2EKeyCmd)
 
 	genKeyCmd.Flags().StringVarP(&output, "output", "o", file.KeyFilePath, "output")
+	genE2EKeyCmd.Flags().StringVarP(&e2eOutput, "output", "o", file.UserKeyFilePath, "output")
 }
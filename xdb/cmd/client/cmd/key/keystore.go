@@ -0,0 +1,120 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package key
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/keyprovider"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/keyprovider/keystore"
+)
+
+var (
+	keystorePath  string
+	passphraseEnv string
+	secretName    string
+	secretValue   string
+)
+
+// putSecretCmd puts a secret into the encrypted keystore, the keystore is created if not exists
+var putSecretCmd = &cobra.Command{
+	Use:   "putsecret",
+	Short: "put a secret into the encrypted keystore, read from stdin if value not given",
+	Run: func(cmd *cobra.Command, args []string) {
+		ks, err := openKeystore()
+		if err != nil {
+			fmt.Printf("failed to open keystore, err: %v\n", err)
+			return
+		}
+		value := secretValue
+		if value == "" {
+			line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+			if err != nil && line == "" {
+				fmt.Printf("failed to read secret from stdin, err: %v\n", err)
+				return
+			}
+			value = strings.TrimSpace(line)
+		}
+		if err := ks.PutSecret(secretName, value); err != nil {
+			fmt.Printf("failed to put secret, err: %v\n", err)
+			return
+		}
+		fmt.Println("OK")
+	},
+}
+
+// delSecretCmd removes a secret from the encrypted keystore
+var delSecretCmd = &cobra.Command{
+	Use:   "delsecret",
+	Short: "remove a secret from the encrypted keystore",
+	Run: func(cmd *cobra.Command, args []string) {
+		ks, err := openKeystore()
+		if err != nil {
+			fmt.Printf("failed to open keystore, err: %v\n", err)
+			return
+		}
+		if err := ks.DeleteSecret(secretName); err != nil {
+			fmt.Printf("failed to delete secret, err: %v\n", err)
+			return
+		}
+		fmt.Println("OK")
+	},
+}
+
+// listSecretsCmd lists names of secrets in the encrypted keystore
+var listSecretsCmd = &cobra.Command{
+	Use:   "listsecrets",
+	Short: "list names of secrets in the encrypted keystore",
+	Run: func(cmd *cobra.Command, args []string) {
+		ks, err := openKeystore()
+		if err != nil {
+			fmt.Printf("failed to open keystore, err: %v\n", err)
+			return
+		}
+		for _, name := range ks.ListSecrets() {
+			fmt.Println(name)
+		}
+	},
+}
+
+// openKeystore unlocks keystore by the passphrase in environment variable
+func openKeystore() (*keystore.Keystore, error) {
+	passphrase, err := keyprovider.GetEnv(passphraseEnv)
+	if err != nil {
+		return nil, err
+	}
+	return keystore.Open(keystorePath, passphrase)
+}
+
+func init() {
+	for _, c := range []*cobra.Command{putSecretCmd, delSecretCmd, listSecretsCmd} {
+		rootCmd.AddCommand(c)
+		c.Flags().StringVarP(&keystorePath, "keystore", "f", "./keys/keystore.json", "path of the keystore file")
+		c.Flags().StringVar(&passphraseEnv, "passphraseEnv", keystore.DefaultPassphraseEnv,
+			"environment variable holding the passphrase of keystore")
+	}
+
+	putSecretCmd.Flags().StringVarP(&secretName, "name", "n", "", "secret name, such as "+keyprovider.SecretPrivateKey+
+		", "+keyprovider.SecretEncryptorPassword+" and "+keyprovider.SecretPairingSk)
+	putSecretCmd.Flags().StringVarP(&secretValue, "value", "v", "", "secret value")
+	delSecretCmd.Flags().StringVarP(&secretName, "name", "n", "", "secret name")
+
+	putSecretCmd.MarkFlagRequired("name")
+	delSecretCmd.MarkFlagRequired("name")
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodes
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/ecdsa"
+	"github.com/spf13/cobra"
+
+	httpclient "github.com/PaddlePaddle/PaddleDTX/xdb/client/http"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/pkgs/file"
+)
+
+// nodeDrainCmd represents the command to decommission node by privatekey
+var nodeDrainCmd = &cobra.Command{
+	Use:   "drain",
+	Short: "set a storage node draining, and move its slices to other nodes before offline",
+	Run: func(cmd *cobra.Command, args []string) {
+		client, err := httpclient.New(host)
+		if err != nil {
+			fmt.Printf("err: %v\n", err)
+			return
+		}
+		if privateKey == "" {
+			privateKeyBytes, err := file.ReadFile(keyPath, file.PrivateKeyFileName)
+			if err != nil {
+				fmt.Printf("Read privateKey failed, err: %v\n", err)
+				return
+			}
+			privateKey = strings.TrimSpace(string(privateKeyBytes))
+		}
+		privKey, err := ecdsa.DecodePrivateKeyFromString(privateKey)
+		if err != nil {
+			fmt.Printf("failed to DecodePrivateKeyFromString, err: %v\n", err)
+			return
+		}
+		if err := client.NodeDrain(context.Background(), privKey.String()); err != nil {
+			fmt.Printf("err: %v\n", err)
+			return
+		}
+		fmt.Println("node draining")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(nodeDrainCmd)
+
+	nodeDrainCmd.Flags().StringVarP(&privateKey, "privateKey", "k", "", "privatekey")
+	nodeDrainCmd.Flags().StringVarP(&keyPath, "keyPath", "", file.KeyFilePath, "node's key path")
+}
@@ -51,7 +51,8 @@ var getNodeCmd = &cobra.Command{
 		}
 		rtime := time.Unix(0, n.RegTime).Format(timeTemplate)
 		utime := time.Unix(0, n.UpdateAt).Format(timeTemplate)
-		fmt.Printf("NodeID: %s\nName: %s\nAddress: %s\nOnline: %v\nRegisterTime: %v\nUpdateTime: %v\n", n.ID, n.Name, n.Address, n.Online, rtime, utime)
+		fmt.Printf("NodeID: %s\nName: %s\nAddress: %s\nOnline: %v\nRegisterTime: %v\nUpdateTime: %v\nZone: %s\nCapacity: %d\nUsed: %d\n",
+			n.ID, n.Name, n.Address, n.Online, rtime, utime, n.Zone, n.Capacity, n.Used)
 	},
 }
 
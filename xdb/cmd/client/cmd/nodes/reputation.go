@@ -0,0 +1,88 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodes
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
+	httpclient "github.com/PaddlePaddle/PaddleDTX/xdb/client/http"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/pkgs/file"
+)
+
+// reputationCmd represents the command to get storage node reputation and its history
+var reputationCmd = &cobra.Command{
+	Use:   "reputation",
+	Short: "get the reputation score of storage node and its history",
+	Run: func(cmd *cobra.Command, args []string) {
+		client, err := httpclient.New(host)
+		if err != nil {
+			fmt.Printf("err: %v\n", err)
+			return
+		}
+		var startTime int64 = 0
+		if start != "" {
+			s, err := time.ParseInLocation(timeTemplate, start, time.Local)
+			if err != nil {
+				fmt.Printf("err: %v\n", err)
+				return
+			}
+			startTime = s.UnixNano()
+		}
+		endTime, err := time.ParseInLocation(timeTemplate, end, time.Local)
+		if err != nil {
+			fmt.Printf("err: %v\n", err)
+			return
+		}
+		if limit < 0 || limit > blockchain.ListMaxNumber {
+			fmt.Printf("invalid limit, the value must be in [0, %v] \n", blockchain.ListMaxNumber)
+			return
+		}
+
+		if id == "" {
+			pubKeyBytes, err := file.ReadFile(keyPath, file.PublicKeyFileName)
+			if err != nil {
+				fmt.Printf("Read publicKey failed, err: %v\n", err)
+				return
+			}
+			id = strings.TrimSpace(string(pubKeyBytes))
+		}
+
+		r, err := client.GetNodeReputation(context.Background(), id, startTime, endTime.UnixNano(), limit)
+		if err != nil {
+			fmt.Printf("err: %v\n", err)
+			return
+		}
+		for _, e := range r.History {
+			eTime := time.Unix(0, e.Time).Format(timeTemplate)
+			fmt.Printf("\ntype: %s, delta: %d, score: %d, ref: %s, time: %s\n", e.Type, e.Delta, e.Score, e.Ref, eTime)
+		}
+		fmt.Printf("\nnode reputation score: %d, excluded: %t\n\n", r.Score, r.Excluded)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(reputationCmd)
+
+	reputationCmd.Flags().StringVarP(&id, "id", "i", "", "id")
+	reputationCmd.Flags().StringVarP(&keyPath, "keyPath", "", file.KeyFilePath, "node's key path")
+	reputationCmd.Flags().StringVarP(&start, "start", "s", "", "reputation history startTime, example '2021-06-10 12:00:00'")
+	reputationCmd.Flags().StringVarP(&end, "end", "e", time.Unix(0, time.Now().UnixNano()).Format(timeTemplate), "reputation history endTime, example '2021-06-10 12:00:00'")
+	reputationCmd.Flags().Int64VarP(&limit, "limit", "l", blockchain.ListMaxNumber, "limit for reputation history events, 0 for no history")
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodes
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
+	httpclient "github.com/PaddlePaddle/PaddleDTX/xdb/client/http"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/pkgs/file"
+)
+
+// scrubReportCmd represents the command to list slices found corrupted or missing by scrubbing on storage node
+var scrubReportCmd = &cobra.Command{
+	Use:   "scrub-report",
+	Short: "list node slices found corrupted or missing by scrubbing",
+	Run: func(cmd *cobra.Command, args []string) {
+		client, err := httpclient.New(host)
+		if err != nil {
+			fmt.Printf("err: %v\n", err)
+			return
+		}
+		var startTime int64 = 0
+		if start != "" {
+			s, err := time.ParseInLocation(timeTemplate, start, time.Local)
+			if err != nil {
+				fmt.Printf("err: %v\n", err)
+				return
+			}
+			startTime = s.UnixNano()
+		}
+		endTime, err := time.ParseInLocation(timeTemplate, end, time.Local)
+		if err != nil {
+			fmt.Printf("err: %v\n", err)
+			return
+		}
+		if limit > blockchain.ListMaxNumber {
+			fmt.Printf("invalid limit, the value must smaller than %v \n", blockchain.ListMaxNumber)
+			return
+		}
+
+		if id == "" {
+			pubKeyBytes, err := file.ReadFile(keyPath, file.PublicKeyFileName)
+			if err != nil {
+				fmt.Printf("Read publicKey failed, err: %v\n", err)
+				return
+			}
+			id = strings.TrimSpace(string(pubKeyBytes))
+		}
+
+		reports, err := client.ListScrubReports(context.Background(), id, startTime, endTime.UnixNano(), limit)
+		if err != nil {
+			fmt.Printf("err: %v\n", err)
+			return
+		}
+		for _, r := range reports {
+			sTime := time.Unix(0, r.ScrubTime).Format(timeTemplate)
+			fmt.Printf("\nfileID: %s, SliceID: %s, status: %s, scrubTime: %s\n", r.FileID, r.SliceID, r.Status, sTime)
+		}
+		fmt.Printf("\nslice scrub report total number: %d\n\n", len(reports))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(scrubReportCmd)
+
+	scrubReportCmd.Flags().StringVarP(&id, "id", "i", "", "id")
+	scrubReportCmd.Flags().StringVarP(&keyPath, "keyPath", "", file.KeyFilePath, "node's key path")
+	scrubReportCmd.Flags().StringVarP(&start, "start", "s", "", "slice scrub startTime, example '2021-06-10 12:00:00'")
+	scrubReportCmd.Flags().StringVarP(&end, "end", "e", time.Unix(0, time.Now().UnixNano()).Format(timeTemplate), "slice scrub endTime, example '2021-06-10 12:00:00'")
+	scrubReportCmd.Flags().Int64VarP(&limit, "limit", "l", blockchain.ListMaxNumber, "limit for list slice scrub reports")
+}
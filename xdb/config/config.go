@@ -40,6 +40,7 @@ type BlockchainConf struct {
 	Type   string
 	Xchain *XchainConf
 	Fabric *FabricConf
+	Local  *LocalChainConf
 }
 
 type XchainConf struct {
@@ -58,12 +59,41 @@ type FabricConf struct {
 	OrgName    string
 }
 
+// LocalChainConf is the configuration of the in-process blockchain, Path is where states of the contract are kept
+type LocalChainConf struct {
+	Path string
+}
+
 type MonitorConf struct {
 	ChallengingSwitch    string
 	NodemaintainerSwitch string
 	FileclearInterval    int
+	ScrubInterval        int
 	FilemaintainerSwitch string
 	FilemigrateInterval  int
+	ChallengeBatchSize   int // max files aggregated in one pairing based challenge, 0 or 1 disables aggregation
+	// ChallengeAnswerInterval is how often storage node checks challenges to answer, unit: second,
+	//  it should be much shorter than answer deadline of time-bounded challenges
+	ChallengeAnswerInterval int
+
+	// ChallengeSchedule enables the challenge scheduling policy, if not set,
+	//  one file of a random namespace is challenged every request interval
+	ChallengeSchedule *ChallengeScheduleConf
+}
+
+// ChallengeScheduleConf defines how often dataOwner node challenges files on each storage node
+type ChallengeScheduleConf struct {
+	Interval     int // default challenge interval of a file on a storage node, unit: minute
+	ExpiryWindow int // files expiring within the window are challenged more often, unit: hour
+	NodeBudget   int // max files challenged on a Green storage node per hour, 0 means no limit
+	Namespaces   []NamespaceScheduleConf
+}
+
+// NamespaceScheduleConf overrides challenge frequency of files under a namespace
+type NamespaceScheduleConf struct {
+	Name     string
+	Interval int  // unit: minute, 0 means the default interval
+	Critical bool // files under critical namespaces are challenged more often
 }
 
 type ServerConf struct {
@@ -72,6 +102,31 @@ type ServerConf struct {
 	PrivateKey    string
 	PublicAddress string
 	AllowCros     bool
+	Zone          string
+	Capacity      uint64 // in GB
+}
+
+// KeyProviderConf defines where secrets absent from the configuration file are fetched
+type KeyProviderConf struct {
+	Type     string
+	Keystore *KeystoreConf
+	Vault    *VaultConf
+}
+
+// KeystoreConf defines an encrypted local keystore file
+type KeystoreConf struct {
+	Path          string
+	PassphraseEnv string // environment variable holding the passphrase to unlock keystore
+}
+
+// VaultConf defines a KMS compatible with the transit secrets engine of HashiCorp Vault
+type VaultConf struct {
+	Address  string
+	TokenEnv string // environment variable holding the token to access KMS
+	Mount    string // mount path of transit secrets engine
+	KeyName  string
+	Secrets  map[string]string // ciphertexts of secrets, by secret name
+	Timeout  int64             // in milliseconds
 }
 
 type Log struct {
@@ -154,12 +209,25 @@ func GetServerConf() *ServerConf {
 			ListenAddress: storageConf.ListenAddress,
 			PrivateKey:    privateKey,
 			PublicAddress: storageConf.PublicAddress,
+			Zone:          storageConf.Zone,
+			Capacity:      storageConf.Capacity,
 		}
 	} else {
 		return nil
 	}
 }
 
+// GetKeyProviderConf
+func GetKeyProviderConf() *KeyProviderConf {
+	if serverType == NodeTypeDataOwner {
+		return dataOwnerConf.KeyProvider
+	} else if serverType == NodeTypeStorage {
+		return storageConf.KeyProvider
+	} else {
+		return nil
+	}
+}
+
 // GetBlockchainConf
 func GetBlockchainConf() *BlockchainConf {
 	if serverType == NodeTypeDataOwner {
@@ -27,11 +27,15 @@ type DataOwnerConf struct {
 	Copier     *DataOwnerCopierConf
 	Monitor    *MonitorConf
 	Challenger *DataOwnerChallenger
+	Session    *UploadSessionConf
+
+	KeyProvider *KeyProviderConf
 }
 
 type DataOwnerSlicerConf struct {
 	Type         string
 	SimpleSlicer *SimpleSlicerConf
+	CDCSlicer    *CDCSlicerConf
 }
 
 type SimpleSlicerConf struct {
@@ -39,19 +43,32 @@ type SimpleSlicerConf struct {
 	QueueSize int64
 }
 
+// CDCSlicerConf defines slice sizes of content defined chunking
+type CDCSlicerConf struct {
+	MinSize   int64
+	AvgSize   int64
+	MaxSize   int64
+	QueueSize int64
+}
+
 type DataOwnerEncryptorConf struct {
 	Type          string
 	SoftEncryptor *SoftEncryptorConf
 }
 
 type SoftEncryptorConf struct {
-	Password string
+	Password        string
+	PasswordVersion int               // version of Password, 0 is regarded as version 1
+	OldPasswords    map[string]string // passwords of previous versions, by version
+	FileKeyPath     string
+	SealRounds      int // rounds of the node-unique sealing transform on slice replicas, 0 disables sealing
 }
 
 type DataOwnerChallenger struct {
-	Type    string
-	Pairing *ChallengerPairingConf
-	Merkle  *ChallengerMerkleConf
+	Type           string
+	AnswerDeadline int // seconds for storage nodes to answer a challenge, 0 disables time-bounded challenges
+	Pairing        *ChallengerPairingConf
+	Merkle         *ChallengerMerkleConf
 }
 
 type ChallengerPairingConf struct {
@@ -68,6 +85,12 @@ type ChallengerMerkleConf struct {
 	SegmentSize int64
 }
 
+// UploadSessionConf defines the local storage of resumable upload sessions
+type UploadSessionConf struct {
+	LocalRoot string
+	TTL       int // hours an upload session is kept since its last update, abandoned sessions are aborted after it
+}
+
 type DataOwnerCopierConf struct {
 	Type string
 }
@@ -19,17 +19,22 @@ type StorageConf struct {
 	PrivateKey    string
 	KeyPath       string
 	PublicAddress string
+	Zone          string
+	Capacity      uint64
 
 	Blockchain *BlockchainConf
 	Monitor    *MonitorConf
 	Mode       *StorageModeConf
 	Prover     *ProverConf
+
+	KeyProvider *KeyProviderConf
 }
 
 type StorageModeConf struct {
 	Type  string
 	Local *LocalConf
 	Ipfs  *IPFSConf
+	S3    *S3Conf
 }
 
 type ProverConf struct {
@@ -44,3 +49,16 @@ type IPFSConf struct {
 	Hosts   []string
 	Timeout int64
 }
+
+// S3Conf is the configuration of S3-compatible object store
+type S3Conf struct {
+	Endpoint         string
+	Region           string
+	Bucket           string
+	Prefix           string
+	AccessKeyID      string
+	SecretAccessKey  string
+	VirtualHostStyle bool
+	PartSize         int64
+	Timeout          int64
+}
@@ -87,6 +87,22 @@ func (s *LevelDBStorage) Update(m types.Material, key []byte) error {
 	return nil
 }
 
+// Delete removes challenge materials whose keys start with prefix
+func (s *LevelDBStorage) Delete(prefix []byte) error {
+	keyList, err := s.NewIterator(prefix)
+	if err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to iterate")
+	}
+	batch := leveldb.Batch{}
+	for _, key := range keyList {
+		batch.Delete(key)
+	}
+	if err := s.db.Write(&batch, nil); err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to write batch")
+	}
+	return nil
+}
+
 func (s *LevelDBStorage) NewIterator(prefix []byte) ([][]byte, error) {
 	iter := s.db.NewIterator(util.BytesPrefix(prefix), nil)
 	var keyList [][]byte
@@ -243,6 +243,14 @@ func (m *RandChallenger) Take(fileID string, sliceID string, nodeID []byte) (
 	return ctype.RangeHash{}, errorx.Wrap(errorx.ErrNotFound, "no available challenger materials")
 }
 
+// Remove removes all challenge materials of the file
+func (m *RandChallenger) Remove(fileID string) error {
+	if err := m.storage.Delete([]byte(fileID + ":")); err != nil {
+		return errorx.Wrap(err, "failed to remove challenge materials")
+	}
+	return nil
+}
+
 func (m *RandChallenger) Close() {
 	m.closeOnce.Do(m.storage.Close)
 }
@@ -46,6 +46,7 @@ type MaterialStorage interface {
 	Load(key []byte) (Material, error)
 	NewIterator(prefix []byte) ([][]byte, error)
 	Update(cms Material, key []byte) error
+	Delete(prefix []byte) error
 
 	Close()
 }
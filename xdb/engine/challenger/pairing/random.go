@@ -26,6 +26,7 @@ import (
 
 	"github.com/PaddlePaddle/PaddleDTX/xdb/config"
 	ctype "github.com/PaddlePaddle/PaddleDTX/xdb/engine/challenger/merkle/types"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/keyprovider"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/types"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
 )
@@ -49,7 +50,7 @@ type RandChallenger struct {
 }
 
 // New new a challenger by challenge related configuration
-func New(conf *config.ChallengerPairingConf, privkey ecdsa.PrivateKey) (*RandChallenger, error) {
+func New(conf *config.ChallengerPairingConf, privkey ecdsa.PrivateKey, kp keyprovider.KeyProvider) (*RandChallenger, error) {
 	maxIdx := int(conf.MaxIndexNum)
 	if maxIdx == 0 {
 		maxIdx = defaultMaxIndexNum
@@ -65,13 +66,21 @@ func New(conf *config.ChallengerPairingConf, privkey ecdsa.PrivateKey) (*RandCha
 
 	var priv, pub, randu, randv []byte
 	var err error
-	if conf.Sk == "" || conf.Pk == "" {
+	sk := conf.Sk
+	if sk == "" && conf.Pk != "" && kp != nil {
+		if sk, err = kp.GetSecret(keyprovider.SecretPairingSk); err != nil {
+			return nil, errorx.Wrap(err, "failed to get pairing private key from key provider")
+		}
+	}
+	if sk == "" || conf.Pk == "" {
 		priv, pub, err = xchainClient.GenPairingKeyPair()
 		if err != nil {
 			return nil, errorx.Wrap(err, "GenPairingKeyPair failed")
 		}
+		// only the generated private key is logged, so that it can be configured
+		logger.WithField("pdpPrivatekey", base64.StdEncoding.EncodeToString(priv)).Info("engine initialization")
 	} else {
-		priv, err = base64.StdEncoding.DecodeString(conf.Sk)
+		priv, err = base64.StdEncoding.DecodeString(sk)
 		if err != nil {
 			return nil, errorx.Wrap(err, "DecodeString failed")
 		}
@@ -80,7 +89,6 @@ func New(conf *config.ChallengerPairingConf, privkey ecdsa.PrivateKey) (*RandCha
 			return nil, errorx.Wrap(err, "DecodeString failed")
 		}
 	}
-	logger.WithField("pdpPrivatekey", base64.StdEncoding.EncodeToString(priv)).Info("engine initialization")
 	logger.WithField("pdpPublickey", base64.StdEncoding.EncodeToString(pub)).Info("engine initialization")
 
 	if conf.Randu == "" {
@@ -167,6 +175,11 @@ func (m *RandChallenger) Save(cms []ctype.Material) error {
 	return errorx.New(errorx.ErrCodeInternal, "pairing not implemented method Save")
 }
 
+// Remove does nothing, as no challenge material is kept locally for random challenge
+func (m *RandChallenger) Remove(fileID string) error {
+	return nil
+}
+
 // Take not implemented for random challenge
 func (m *RandChallenger) Take(fileID string, sliceID string, nodeID []byte) (c ctype.RangeHash, err error) {
 	return c, errorx.New(errorx.ErrCodeInternal, "pairing not implemented method Take")
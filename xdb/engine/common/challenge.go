@@ -30,6 +30,7 @@ import (
 	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/encryptor"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/types"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/storage"
 )
 
 var (
@@ -131,9 +132,10 @@ func AddFileNewMerkleChallenge(ctx context.Context, challenger CommonChallenger,
 					}
 					// decrypt
 					opt := &encryptor.RecoverOptions{
-						FileID:  file.ID,
-						SliceID: target.ID,
-						NodeID:  newNode.ID,
+						FileID:     SliceKeyFileID(file),
+						SliceID:    target.ID,
+						NodeID:     newNode.ID,
+						KeyVersion: file.GetSliceKeyVersion(),
 					}
 					plain, err := chalEncryptor.Recover(r, opt)
 					if err != nil {
@@ -144,9 +146,10 @@ func AddFileNewMerkleChallenge(ctx context.Context, challenger CommonChallenger,
 					r.Close()
 					// encrypt by target nodeID
 					encOpt := &encryptor.EncryptOptions{
-						FileID:  file.ID,
-						SliceID: target.ID,
-						NodeID:  target.NodeID,
+						FileID:     SliceKeyFileID(file),
+						SliceID:    target.ID,
+						NodeID:     target.NodeID,
+						KeyVersion: file.GetSliceKeyVersion(),
 					}
 					cipher, err := chalEncryptor.Encrypt(bytes.NewReader(plain), encOpt)
 					if err != nil {
@@ -233,6 +236,7 @@ func AddSlicesNewPairingChallenge(ctx context.Context, pairingConf types.Pairing
 	segments := (roundEnd - roundStart) / int64(pairingChallSegmentSize)
 	// get slice idx map, from slice+nodeID to slice idx
 	idxMap := getSlicesIdxMap(file)
+	storIndexes := getSlicesStorIndexMap(file)
 	// list nodes from blockchain
 	allNodes, err := chain.ListNodes()
 	if err != nil {
@@ -313,8 +317,8 @@ func AddSlicesNewPairingChallenge(ctx context.Context, pairingConf types.Pairing
 			if pushErr != nil {
 				return
 			}
-			// push sigmas to storage node
-			sliceSigmaID := GetSliceSigmasID(slice.SliceID)
+			// push sigmas to storage node, a copy of the slice re-encrypted on the node has its own sigmas
+			sliceSigmaID := GetSliceSigmasID(storage.KeyOf(slice.SliceID, storIndexes[slice.SliceID+string(slice.NodeID)]))
 			sigmasBytes, err := SigmasToBytes(sigmas)
 			if err != nil {
 				pushErr = errorx.Wrap(err, "failed to marshal sigmas")
@@ -384,8 +388,8 @@ func AddFilePairingChallenges(ctx context.Context, pairingConf types.PairingChal
 			}
 
 			// pull sigmas
-			sliceSigma := GetSliceSigmasID(target.ID)
-			r, err = copier.Pull(ctx, sliceSigma, target.ID, file.ID, &node)
+			sigmaKey := storage.KeyOf(target.ID, target.StorIndex)
+			r, err = copier.Pull(ctx, GetSliceSigmasID(sigmaKey), sigmaKey, file.ID, &node)
 			if err != nil {
 				logger.WithField("slice_id", target.ID).WithError(err).Error("failed to pull slice sigmas")
 				addErr = errorx.NewCode(err, errorx.ErrCodeInternal, "failed to pull slice sigmas")
@@ -449,6 +453,15 @@ func getSlicesIdxMap(file blockchain.File) map[string][]byte {
 	return idxMap
 }
 
+// getSlicesStorIndexMap gets map from slice+nodeID to storage index of the slice
+func getSlicesStorIndexMap(file blockchain.File) map[string]string {
+	m := make(map[string]string)
+	for _, s := range file.Slices {
+		m[s.ID+string(s.NodeID)] = s.StorIndex
+	}
+	return m
+}
+
 // GetSliceSigmasID pack file name to pull sigmas from `Storage Node`
 func GetSliceSigmasID(sliceID string) string {
 	return sliceID + ChallengeFileSuffix
@@ -23,6 +23,7 @@ import (
 	ctype "github.com/PaddlePaddle/PaddleDTX/xdb/engine/challenger/merkle/types"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/copier"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/encryptor"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/slicer"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/types"
 )
 
@@ -43,6 +44,11 @@ type CommonCopier interface {
 		[]blockchain.PublicSliceMeta, []encryptor.EncryptedSlice, error)
 }
 
+// CommonSelector defines how the copier selects Storage Nodes for a slice
+type CommonSelector interface {
+	Select(slice slicer.Slice, nodes blockchain.NodeHs, opt *copier.SelectOptions) (copier.LocatedSlice, error)
+}
+
 // CommonChallenger defines Merkle-Tree based / pairing based challenger
 type CommonChallenger interface {
 	Setup(sliceData []byte, rangeAmount int) ([]ctype.RangeHash, error)
@@ -63,6 +69,7 @@ type CommonChain interface {
 	ListNodes() (blockchain.Nodes, error)
 	GetNode(id []byte) (blockchain.Node, error)
 	GetNodeHealth(id []byte) (string, error)
+	ListNodeReputations() ([]blockchain.NodeReputation, error)
 	GetHeartbeatNum(id []byte, timestamp int64) (int, error)
 
 	ListFiles(opt *blockchain.ListFileOptions) ([]blockchain.File, error)
@@ -0,0 +1,89 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/types"
+)
+
+// DownloadRecordWindow is the time in which reads of a file by the same user are taken as the same download,
+// so that reading a file range by range or its header only doesn't send a transaction each time
+const DownloadRecordWindow = time.Hour
+
+// DownloadRecorder records downloads of files after they are read through,
+// at most once per key in DownloadRecordWindow, the key identifies the file and the user reading it
+type DownloadRecorder struct {
+	lock     sync.Mutex
+	recorded map[string]time.Time // time of the latest record by key
+}
+
+// NewDownloadRecorder creates a DownloadRecorder
+func NewDownloadRecorder() *DownloadRecorder {
+	return &DownloadRecorder{recorded: make(map[string]time.Time)}
+}
+
+// Track returns a reader calling record in background once r is read to the end without error,
+// if the key is not recorded in DownloadRecordWindow. A *types.RangeReader is tracked only if
+// its range reaches the end of the file, so reading the beginning of a file isn't a download
+func (d *DownloadRecorder) Track(key string, r io.ReadCloser, record func()) io.ReadCloser {
+	done := func() {
+		if d.shouldRecord(key) {
+			go record()
+		}
+	}
+	if rr, ok := r.(*types.RangeReader); ok {
+		if rr.Offset+rr.Length < rr.Total {
+			return rr
+		}
+		rr.ReadCloser = &trackedReader{ReadCloser: rr.ReadCloser, done: done}
+		return rr
+	}
+	return &trackedReader{ReadCloser: r, done: done}
+}
+
+// shouldRecord checks and marks whether the key is not recorded in DownloadRecordWindow
+func (d *DownloadRecorder) shouldRecord(key string) bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	now := time.Now()
+	for k, t := range d.recorded {
+		if now.Sub(t) >= DownloadRecordWindow {
+			delete(d.recorded, k)
+		}
+	}
+	if _, ok := d.recorded[key]; ok {
+		return false
+	}
+	d.recorded[key] = now
+	return true
+}
+
+// trackedReader calls done once when the underlying reader returns io.EOF
+type trackedReader struct {
+	io.ReadCloser
+	once sync.Once
+	done func()
+}
+
+func (r *trackedReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if err == io.EOF {
+		r.once.Do(r.done)
+	}
+	return n, err
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/erasure"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+)
+
+// RecoverStripe pulls slices of a stripe from storage nodes and rebuilds the missing ones by Reed-Solomon code.
+// Slices are pulled in the order of stripe.Shards(), and pulling stops once len(stripe.Data) slices are got,
+// so no parity slice is pulled if all data slices are available.
+// Nodes in excludes are skipped, returns plaintext of all slices in the order of stripe.Shards(),
+// keyVersion is the version of password slices are encrypted under
+func RecoverStripe(ctx context.Context, cp CommonCopier, enc CommonEncryptor, fileID string, keyVersion int, stripe erasure.Stripe,
+	slicesPool map[string][]blockchain.PublicSliceMeta, nodesMap map[string]blockchain.Node,
+	excludes map[string]struct{}, l *logrus.Entry) ([][]byte, error) {
+
+	return recoverShards(ctx, stripe, func(s blockchain.PrivateSliceMeta) ([]byte, error) {
+		for _, target := range slicesPool[s.SliceID] {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			default:
+			}
+			if _, exist := excludes[string(target.NodeID)]; exist {
+				continue
+			}
+			node, exist := nodesMap[string(target.NodeID)]
+			if !exist || !node.Online {
+				continue
+			}
+			plaintext, err := PullAndDec(ctx, cp, enc, target, &node, fileID, fileID, keyVersion)
+			if err != nil {
+				l.WithField("slice_id", target.ID).WithError(err).Warn("failed to pull slice of stripe")
+				continue
+			}
+			if !bytes.Equal(xchainClient.HashUsingSha256(plaintext), s.PlainHash) {
+				l.WithField("slice_id", target.ID).Warn("invalid slice plaintext hash")
+				continue
+			}
+			return plaintext, nil
+		}
+		return nil, errorx.New(errorx.ErrCodeNotFound, "failed to pull slice %s", s.SliceID)
+	})
+}
+
+// recoverShards gets slices of a stripe by pull in the order of stripe.Shards() until len(stripe.Data) slices are got,
+// and rebuilds the missing ones by Reed-Solomon code. Returns plaintext of all slices in the order of stripe.Shards()
+func recoverShards(ctx context.Context, stripe erasure.Stripe,
+	pull func(s blockchain.PrivateSliceMeta) ([]byte, error)) ([][]byte, error) {
+
+	shardsMeta := stripe.Shards()
+	shards := make([][]byte, len(shardsMeta))
+	got := 0
+	for i, s := range shardsMeta {
+		if got == len(stripe.Data) {
+			break
+		}
+		plaintext, err := pull(s)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			continue
+		}
+		shards[i] = plaintext
+		got++
+	}
+	if got < len(stripe.Data) {
+		return nil, errorx.New(errorx.ErrCodeNotFound, "failed to pull enough slices of stripe %d, expected %d, got %d",
+			stripe.Index, len(stripe.Data), got)
+	}
+
+	if got < len(shards) {
+		if err := erasure.Reconstruct(shards, len(stripe.Data)); err != nil {
+			return nil, err
+		}
+	}
+	return shards, nil
+}
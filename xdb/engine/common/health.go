@@ -21,24 +21,38 @@ import (
 	"time"
 
 	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/copier"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/slicer"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
 )
 
-// GetHealthNodes gets online healthy(green, yellow) nodes
+// GetHealthNodes gets online healthy(green, yellow) nodes, skips draining and low reputation nodes
 func GetHealthNodes(chain CommonChain) (nodes blockchain.NodeHs, err error) {
 	// Prepare
 	allNodes, err := chain.ListNodes()
 	if err != nil {
 		return nodes, errorx.Wrap(err, "failed to list nodes from blockchain")
 	}
+	// reputations are listed after nodes, so that every listed node has its reputation
+	reputations, err := chain.ListNodeReputations()
+	if err != nil {
+		return nodes, errorx.Wrap(err, "failed to list node reputations from blockchain")
+	}
+	excluded := make(map[string]bool, len(reputations))
+	for _, r := range reputations {
+		excluded[string(r.NodeID)] = r.Excluded
+	}
 	// get online nodes
 	for _, n := range allNodes {
-		if !n.Online {
+		if !n.Online || n.Draining || excluded[string(n.ID)] {
 			continue
 		}
 		// judge node health
 		health, err := chain.GetNodeHealth(n.ID)
-		if err != nil || blockchain.NodeHealthBad == health {
+		if err != nil {
+			return nil, errorx.Wrap(err, "failed to get node health")
+		}
+		if blockchain.NodeHealthBad == health {
 			continue
 		}
 		nh := blockchain.NodeH{
@@ -82,6 +96,31 @@ func FindNewNodes(healthNodes blockchain.NodeHs, selected []string) (blockchain.
 	return newNodes, nil
 }
 
+// SelectNewNodes selects nodes by the copier to store a new copy of the slice, in the order they are tried.
+// Nodes in excluded and the holders of the slice are skipped, and so are zones of the holders for zone-aware copiers
+func SelectNewNodes(s CommonSelector, healthNodes blockchain.NodeHs, slice slicer.Slice, holders blockchain.Nodes,
+	excluded []string) (blockchain.Nodes, error) {
+	excludes := make(map[string]struct{})
+	for _, id := range excluded {
+		excludes[id] = struct{}{}
+	}
+	for _, n := range holders {
+		excludes[string(n.ID)] = struct{}{}
+	}
+	located, err := s.Select(slice, healthNodes, &copier.SelectOptions{
+		Replica:  uint32(len(healthNodes)),
+		Excludes: excludes,
+		Holders:  holders,
+	})
+	if err != nil {
+		return nil, errorx.Wrap(err, "failed to select nodes")
+	}
+	if len(located.Nodes) == 0 {
+		return nil, errorx.New(errorx.ErrCodeNotFound, "no more available healthy nodes")
+	}
+	return located.Nodes, nil
+}
+
 // GetNsFilesHealth gets namespace health conditions
 func GetNsFilesHealth(ctx context.Context, ns blockchain.Namespace, chain CommonChain) (nsh blockchain.NamespaceH, err error) {
 	ctx, cancel := context.WithCancel(ctx)
@@ -32,9 +32,10 @@ import (
 	util "github.com/PaddlePaddle/PaddleDTX/xdb/pkgs/strings"
 )
 
-// PullAndDec pull a slice from healthy node and decrypt
+// PullAndDec pull a slice from healthy node and decrypt, keyFileID derives the key of slice, see SliceKeyFileID,
+// keyVersion is the version of password the slice is encrypted under
 func PullAndDec(ctx context.Context, copier CommonCopier, encrypt CommonEncryptor,
-	slice blockchain.PublicSliceMeta, node *blockchain.Node, fileID string) ([]byte, error) {
+	slice blockchain.PublicSliceMeta, node *blockchain.Node, fileID, keyFileID string, keyVersion int) ([]byte, error) {
 
 	r, err := copier.Pull(ctx, slice.ID, slice.StorIndex, fileID, node)
 	if err != nil {
@@ -60,22 +61,25 @@ func PullAndDec(ctx context.Context, copier CommonCopier, encrypt CommonEncrypto
 
 	// decrypt the slice
 	decOpt := encryptor.RecoverOptions{
-		FileID:  fileID,
-		SliceID: slice.ID,
-		NodeID:  node.ID,
+		FileID:     keyFileID,
+		SliceID:    slice.ID,
+		NodeID:     node.ID,
+		KeyVersion: keyVersion,
 	}
 	return encrypt.Recover(bytes.NewReader(cipherText), &decOpt)
 }
 
-// EncAndPush encrypt a slice and push to specified storage node
+// EncAndPush encrypt a slice under password of keyVersion and push to specified storage node,
+// keyFileID derives the key of slice, see SliceKeyFileID
 // returns `EncryptedSlice` for the specified storage node and `Storage Index` returned by the specified storage node
-func EncAndPush(ctx context.Context, copier CommonCopier, encrypt CommonEncryptor,
-	plaintext []byte, sliceID, sourceID, fileID string, node *blockchain.Node) (encryptor.EncryptedSlice, string, error) {
+func EncAndPush(ctx context.Context, copier CommonCopier, encrypt CommonEncryptor, plaintext []byte,
+	sliceID, sourceID, keyFileID string, keyVersion int, node *blockchain.Node) (encryptor.EncryptedSlice, string, error) {
 
 	encOpt := encryptor.EncryptOptions{
-		FileID:  fileID,
-		SliceID: sliceID,
-		NodeID:  node.ID,
+		FileID:     keyFileID,
+		SliceID:    sliceID,
+		NodeID:     node.ID,
+		KeyVersion: keyVersion,
 	}
 	es, err := encrypt.Encrypt(bytes.NewReader(plaintext), &encOpt)
 	if err != nil {
@@ -115,6 +119,8 @@ func ExpandFileSlices(ctx context.Context, privkey ecdsa.PrivateKey, cp CommonCo
 			NodesList:     healthNodes,
 			PrivateKey:    privkey[:],
 			SliceMetas:    slices,
+			KeyFileID:     SliceKeyFileID(file),
+			KeyVersion:    file.GetSliceKeyVersion(),
 		}
 		if ca == types.PairingChallengeAlgorithm {
 			opt.PairingConf = pairingConf
@@ -172,9 +178,11 @@ func ExpandFileSlices(ctx context.Context, privkey ecdsa.PrivateKey, cp CommonCo
 
 	// update file slices on blockchain
 	opt := blockchain.UpdateFilePSMOptions{
-		FileID: file.ID,
-		Owner:  file.Owner,
-		Slices: slices,
+		FileID:          file.ID,
+		Owner:           file.Owner,
+		Slices:          slices,
+		SliceKeyVersion: file.GetSliceKeyVersion(),
+		CurrentTime:     time.Now().UnixNano(),
 	}
 	msg, err := util.GetSigMessage(opt)
 	if err != nil {
@@ -0,0 +1,411 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+
+	"github.com/cjqpker/slidewindow"
+	"github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/encryptor/chunk"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/encryptor/seal"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/erasure"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/types"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+)
+
+// readConcurrency is the number of slices or stripes pulled at the same time when a file is read
+var readConcurrency uint64 = 10
+
+// cipherOverhead is the length of authentication tag appended to ciphertext by AES-GCM
+const cipherOverhead = 16
+
+// FileDecrypter pulls slices of a file and decrypts its contents when the file is read,
+// the dataOwner node decrypts with keys derived from its password,
+// and executors decrypt with keys authorized by the file owner
+type FileDecrypter interface {
+	// PullSlice pulls a slice from any of its storage nodes in targets, and returns the decrypted content
+	PullSlice(ctx context.Context, targets []blockchain.PublicSliceMeta, nodesMap map[string]blockchain.Node) ([]byte, error)
+	// DecryptChunk decrypts content of a slice of deduplicated file, which is a chunk encrypted separately
+	DecryptChunk(slice blockchain.PrivateSliceMeta, ciphertext []byte) ([]byte, error)
+	// DecryptFile decrypts ciphertext of the file encrypted as a whole
+	DecryptFile(ciphertext []byte) ([]byte, error)
+	// FileCipher returns the cipher of chunked file, see chunk.Cipher
+	FileCipher() (*chunk.Cipher, error)
+}
+
+// ReadFile reads the whole file by pulling slices from storage nodes in nodesMap, fs is the decrypted file structure.
+// Slices, or stripes for erasure coded file, are combined in order and decrypted as a whole.
+// Deduplicated files and chunked files are encrypted chunk by chunk rather than as a whole,
+// they are read by ReadRange
+func ReadFile(ctx context.Context, f blockchain.File, fs blockchain.FileStructure, dec FileDecrypter,
+	nodesMap map[string]blockchain.Node, l *logrus.Entry) (io.ReadCloser, error) {
+	if f.Dedup || f.Chunked {
+		return ReadRange(ctx, f, fs, dec, nodesMap, 0, 0, l)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+
+	// use sliding window
+	sw := slidewindow.SlideWindow{
+		Total:       uint64(len(fs)),
+		Concurrency: readConcurrency,
+	}
+	sw.Init = func(ctx context.Context, s *slidewindow.Session) error {
+		return nil
+	}
+
+	slicesPool := MakeSlicesPool4Read(f.Slices)
+	// for erasure coded file, each task recovers a stripe instead of a slice
+	var stripes []erasure.Stripe
+	if f.DataShards > 0 {
+		stripes = erasure.SplitStripes(fs)
+		sw.Total = uint64(len(stripes))
+	}
+	sw.Task = func(ctx context.Context, s *slidewindow.Session) error {
+		var data []byte
+		if f.DataShards > 0 {
+			shards, err := readStripe(ctx, dec, stripes[int(s.Index())], slicesPool, nodesMap, l)
+			if err != nil {
+				return err
+			}
+			data = bytes.Join(shards[:len(stripes[int(s.Index())].Data)], nil)
+		} else {
+			targetPool, ok := slicesPool[fs[int(s.Index())].SliceID]
+			if !ok {
+				return errorx.Internal(nil, "bad file structure")
+			}
+			var err error
+			if data, err = dec.PullSlice(ctx, targetPool, nodesMap); err != nil {
+				return err
+			}
+		}
+		// trim 0 at the end of file
+		if s.Index() == sw.Total-1 {
+			data = bytes.TrimRight(data, string([]byte{0}))
+		}
+		s.Set("data", data)
+		return nil
+	}
+
+	reader, writer := io.Pipe()
+	sw.Done = func(ctx context.Context, s *slidewindow.Session) error {
+		data, exist := s.Get("data")
+		if !exist {
+			return errorx.New(errorx.ErrCodeNotFound, "failed to find data")
+		}
+
+		if _, err := writer.Write(data.([]byte)); err != nil {
+			return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to write")
+		}
+
+		// exit on success
+		if s.Index() == sw.Total-1 {
+			writer.Close()
+		}
+		return nil
+	}
+
+	go func() {
+		defer cancel()
+		if err := sw.Start(ctx); err != nil {
+			writer.CloseWithError(err)
+		}
+	}()
+
+	// decrypt recovered file
+	ciphertext, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, errorx.Wrap(err, "failed to recover slices of file")
+	}
+	plaintext, err := dec.DecryptFile(ciphertext)
+	if err != nil {
+		return nil, errorx.NewCode(err, errorx.ErrCodeCrypto, "failed to recover original file")
+	}
+	return ioutil.NopCloser(bytes.NewReader(plaintext)), nil
+}
+
+// readUnit is a slice, or a stripe of erasure coded file, that covers part of the file's ciphertext
+type readUnit struct {
+	slice  blockchain.PrivateSliceMeta
+	stripe *erasure.Stripe
+	offset uint64 // position in the file's ciphertext
+	length uint64
+}
+
+// ReadRange reads a range of the file, only slices covering the range are pulled and decrypted,
+// length 0 means reading to the end of the file.
+// For chunked file, chunks covering the range are decrypted and verified, see chunk.Cipher.
+// For deduplicated file, each slice covering the range is a chunk encrypted separately and is decrypted as a whole.
+// Other files were encrypted as a whole, so all slices are pulled to verify the authentication tag
+func ReadRange(ctx context.Context, f blockchain.File, fs blockchain.FileStructure, dec FileDecrypter,
+	nodesMap map[string]blockchain.Node, offset, length uint64, l *logrus.Entry) (io.ReadCloser, error) {
+	// an empty chunked file is read as a whole to verify its only chunk
+	if offset >= f.Length && !(f.Chunked && offset == 0) {
+		return nil, errorx.New(errorx.ErrCodeParam, "offset %d out of range, file length %d", offset, f.Length)
+	}
+	end := f.Length
+	if length > 0 && offset+length < end {
+		end = offset + length
+	}
+
+	// [start, stop) is the position of content to read, in plaintext for deduplicated file and in ciphertext for others
+	var c *chunk.Cipher
+	var start, stop, index uint64
+	switch {
+	case f.Dedup:
+		start, stop = offset, end
+	case f.Chunked:
+		var err error
+		if c, err = dec.FileCipher(); err != nil {
+			return nil, err
+		}
+		start, stop, index = chunk.Range(offset, end, f.Length)
+	default:
+		start, stop = 0, f.Length+cipherOverhead
+	}
+
+	slicesPool := MakeSlicesPool4Read(f.Slices)
+	units, err := splitReadUnits(f, fs, slicesPool)
+	if err != nil {
+		return nil, err
+	}
+	var covered []readUnit
+	for _, u := range units {
+		if u.offset < stop && u.offset+u.length > start {
+			covered = append(covered, u)
+		}
+	}
+	if len(covered) == 0 {
+		return nil, errorx.Internal(nil, "bad file structure")
+	}
+
+	sw := slidewindow.SlideWindow{
+		Total:       uint64(len(covered)),
+		Concurrency: readConcurrency,
+	}
+	sw.Init = func(ctx context.Context, s *slidewindow.Session) error {
+		return nil
+	}
+	sw.Task = func(ctx context.Context, s *slidewindow.Session) error {
+		u := covered[int(s.Index())]
+		var data []byte
+		var err error
+		if u.stripe != nil {
+			shards, err := readStripe(ctx, dec, *u.stripe, slicesPool, nodesMap, l)
+			if err != nil {
+				return err
+			}
+			data = bytes.Join(shards[:len(u.stripe.Data)], nil)
+		} else {
+			data, err = dec.PullSlice(ctx, slicesPool[u.slice.SliceID], nodesMap)
+			if err != nil {
+				return err
+			}
+		}
+		if f.Dedup {
+			if data, err = dec.DecryptChunk(u.slice, data); err != nil {
+				return errorx.NewCode(err, errorx.ErrCodeCrypto, "failed to decrypt chunk")
+			}
+		}
+
+		// cut the part within the range, padding at the end of the last slice is removed as well
+		from, to := u.offset, u.offset+u.length
+		if from < start {
+			from = start
+		}
+		if to > stop {
+			to = stop
+		}
+		if uint64(len(data)) < to-u.offset {
+			return errorx.Internal(nil, "bad length of slice content")
+		}
+		s.Set("data", data[from-u.offset:to-u.offset])
+		return nil
+	}
+
+	// decrypt ciphertext in order, plaintext of chunks starts from pos of the file
+	var buffer []byte
+	pos := index * chunk.Size
+	decrypt := func(isLast bool) ([]byte, error) {
+		switch {
+		case f.Dedup:
+			plaintext := buffer
+			buffer = nil
+			return plaintext, nil
+		case f.Chunked:
+			n := uint64(len(buffer)) / chunk.SealedSize * chunk.SealedSize
+			if isLast {
+				n = uint64(len(buffer))
+			}
+			if n == 0 {
+				return nil, nil
+			}
+			plaintext, err := c.DecryptChunks(buffer[:n], index, isLast && stop == chunk.CipherLength(f.Length))
+			if err != nil {
+				return nil, errorx.Wrap(err, "failed to decrypt range")
+			}
+			buffer = buffer[n:]
+			index += n / chunk.SealedSize
+			// cut the part within the range
+			base := pos
+			pos += uint64(len(plaintext))
+			from, to := base, pos
+			if from < offset {
+				from = offset
+			}
+			if to > end {
+				to = end
+			}
+			if from >= to {
+				return nil, nil
+			}
+			return plaintext[from-base : to-base], nil
+		default:
+			if !isLast {
+				return nil, nil
+			}
+			plaintext, err := dec.DecryptFile(buffer)
+			if err != nil {
+				return nil, errorx.NewCode(err, errorx.ErrCodeCrypto, "failed to recover original file")
+			}
+			return plaintext[offset:end], nil
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	reader, writer := io.Pipe()
+	sw.Done = func(ctx context.Context, s *slidewindow.Session) error {
+		data, exist := s.Get("data")
+		if !exist {
+			return errorx.New(errorx.ErrCodeNotFound, "failed to find data")
+		}
+		buffer = append(buffer, data.([]byte)...)
+		isLast := s.Index() == sw.Total-1
+		plaintext, err := decrypt(isLast)
+		if err != nil {
+			return err
+		}
+		if _, err := writer.Write(plaintext); err != nil {
+			return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to write")
+		}
+		if isLast {
+			writer.Close()
+		}
+		return nil
+	}
+
+	go func() {
+		defer cancel()
+		if err := sw.Start(ctx); err != nil {
+			writer.CloseWithError(err)
+		}
+	}()
+
+	return &types.RangeReader{
+		ReadCloser: reader,
+		Offset:     offset,
+		Length:     end - offset,
+		Total:      f.Length,
+	}, nil
+}
+
+// splitReadUnits splits the file's ciphertext into slices, or stripes for erasure coded file, in order.
+// Length of each slice's content is derived from the length of its encrypted copies.
+// For deduplicated file, units are split by plaintext chunks instead
+func splitReadUnits(f blockchain.File, fs blockchain.FileStructure,
+	slicesPool map[string][]blockchain.PublicSliceMeta) ([]readUnit, error) {
+	overhead := uint64(cipherOverhead)
+	if f.Dedup {
+		overhead += cipherOverhead
+	}
+	sliceLen := func(id string) (uint64, error) {
+		targets := slicesPool[id]
+		if len(targets) == 0 {
+			return 0, errorx.Internal(nil, "bad file structure")
+		}
+		n := overhead
+		if targets[0].Sealed {
+			n += seal.Overhead
+		}
+		if targets[0].Length < n {
+			return 0, errorx.Internal(nil, "bad file structure")
+		}
+		return targets[0].Length - n, nil
+	}
+
+	var units []readUnit
+	var offset uint64
+	if f.DataShards > 0 {
+		stripes := erasure.SplitStripes(fs)
+		for i := range stripes {
+			var l uint64
+			for _, d := range stripes[i].Data {
+				n, err := sliceLen(d.SliceID)
+				if err != nil {
+					return nil, err
+				}
+				l += n
+			}
+			units = append(units, readUnit{stripe: &stripes[i], offset: offset, length: l})
+			offset += l
+		}
+		return units, nil
+	}
+	for _, slice := range fs {
+		l, err := sliceLen(slice.SliceID)
+		if err != nil {
+			return nil, err
+		}
+		units = append(units, readUnit{slice: slice, offset: offset, length: l})
+		offset += l
+	}
+	return units, nil
+}
+
+// readStripe recovers a stripe from slices pulled by dec, see RecoverStripe
+func readStripe(ctx context.Context, dec FileDecrypter, stripe erasure.Stripe,
+	slicesPool map[string][]blockchain.PublicSliceMeta, nodesMap map[string]blockchain.Node,
+	l *logrus.Entry) ([][]byte, error) {
+	shards, err := recoverShards(ctx, stripe, func(s blockchain.PrivateSliceMeta) ([]byte, error) {
+		plaintext, err := dec.PullSlice(ctx, slicesPool[s.SliceID], nodesMap)
+		if err != nil {
+			l.WithField("slice_id", s.SliceID).WithError(err).Warn("failed to pull slice of stripe")
+			return nil, err
+		}
+		if !bytes.Equal(xchainClient.HashUsingSha256(plaintext), s.PlainHash) {
+			l.WithField("slice_id", s.SliceID).Warn("invalid slice plaintext hash")
+			return nil, errorx.New(errorx.ErrCodeCrypto, "invalid slice plaintext hash")
+		}
+		return plaintext, nil
+	})
+	if err != nil {
+		return nil, errorx.Wrap(err, "failed to recover stripe %d", stripe.Index)
+	}
+	return shards, nil
+}
+
+// MakeSlicesPool4Read get the list of storage nodes of slices by blockchain.PublicSliceMeta.
+// return map, key is sliceID, value is storage nodeID lists
+func MakeSlicesPool4Read(srs []blockchain.PublicSliceMeta) map[string][]blockchain.PublicSliceMeta {
+	slicesPool := make(map[string][]blockchain.PublicSliceMeta)
+	for _, s := range srs {
+		slicesPool[s.ID] = append(slicesPool[s.ID], s)
+	}
+	return slicesPool
+}
@@ -0,0 +1,35 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"github.com/google/uuid"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
+)
+
+// GetSliceSourceKey returns the key of the record which stores the dataOwner node who pushed the slice,
+// keys of local storage must be uuid, so the key is a uuid derived from sliceID
+func GetSliceSourceKey(sliceID string) string {
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(sliceID+"/source")).String()
+}
+
+// SliceKeyFileID returns the fileID which derives keys of the file's slices,
+// slices of deduplicated files may be shared with other files of the owner, so their keys don't depend on the file
+func SliceKeyFileID(file blockchain.File) string {
+	if file.Dedup {
+		return ""
+	}
+	return file.ID
+}
@@ -27,10 +27,12 @@ type LocatedSlice struct {
 
 // SelectOptions contains some options for selecting Storage Nodes
 //  Replica is the number of replicas
-//  Excludes is a reserved field, not used
+//  Excludes are nodes never selected
+//  Holders are nodes already storing copies of the slice, zone-aware copiers avoid their zones
 type SelectOptions struct {
 	Replica  uint32
 	Excludes map[string]struct{} // nodeID -> struct{}
+	Holders  blockchain.Nodes
 }
 
 // ReplicaExpOptions contains some options for expanding replicas.
@@ -42,4 +44,6 @@ type ReplicaExpOptions struct {
 	NodesList     blockchain.NodeHs            // all node lists
 	SliceMetas    []blockchain.PublicSliceMeta // slice metas
 	PairingConf   types.PairingChallengeConf   // pairing based challenge config
+	KeyFileID     string                       // fileID deriving keys of slices, see common.SliceKeyFileID
+	KeyVersion    int                          // version of password slices are encrypted under
 }
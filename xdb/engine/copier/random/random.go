@@ -28,6 +28,7 @@ import (
 	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/common"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/copier"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/encryptor"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/encryptor/seal"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/slicer"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/types"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
@@ -78,6 +79,14 @@ func (m *RandomCopier) Select(slice slicer.Slice, nodes blockchain.NodeHs, opt *
 		return copier.LocatedSlice{}, errorx.New(errorx.ErrCodeInternal, "empty replica")
 	}
 
+	var candidates blockchain.NodeHs
+	for _, n := range nodes {
+		if _, exist := opt.Excludes[string(n.Node.ID)]; !exist {
+			candidates = append(candidates, n)
+		}
+	}
+	nodes = candidates
+
 	rand.Seed(time.Now().UnixNano())
 
 	// green nodes first
@@ -164,6 +173,36 @@ func (m *RandomCopier) Pull(ctx context.Context, id, storIndex, fileID string, n
 	return r, nil
 }
 
+// Delete removes a slice which is not published from Storage Node
+func (m *RandomCopier) Delete(ctx context.Context, id, storIndex string, node *blockchain.Node) error {
+	opt := types.DeleteSliceOptions{
+		SliceID:   id,
+		StorIndex: storIndex,
+		SourceID:  ecdsa.PublicKeyFromPrivateKey(m.privateKey).String(),
+		Timestamp: time.Now().UnixNano(),
+	}
+	msg, err := util.GetSigMessage(opt)
+	if err != nil {
+		return errorx.Internal(err, "failed to get the message to sign for delete slices")
+	}
+	sig, err := ecdsa.Sign(m.privateKey, hash.HashUsingSha256([]byte(msg)))
+	if err != nil {
+		return errorx.Wrap(err, "failed to sign slice delete")
+	}
+	url := fmt.Sprintf("http://%s/v1/slice/delete?slice_id=%s&slice_stor_index=%s&source_id=%s&timestamp=%d&signature=%s",
+		node.Address, id, storIndex, opt.SourceID, opt.Timestamp, sig.String())
+
+	if _, err := http.Post(ctx, url, nil); err != nil {
+		return errorx.Wrap(err, "failed to do post")
+	}
+
+	logger.WithFields(logrus.Fields{
+		"SliceId":        id,
+		"SliceStorIndex": storIndex,
+	}).Debug("successfully deleted")
+	return nil
+}
+
 // ReplicaExpansion slice performs Replica-Expand, that is to
 //  pull slices from original nodes and decrypt and re-encrypt those slices,
 //  then push them onto new Storage Nodes.
@@ -177,7 +216,7 @@ func (m *RandomCopier) ReplicaExpansion(ctx context.Context, opt *copier.Replica
 	}
 
 	// 2 pull slices from original nodes and decrypt those slices
-	plainText := m.pullSlice(ctx, opt.SelectedNodes, opt.SliceMetas, opt.SliceID, fileID, enc)
+	plainText := m.pullSlice(ctx, opt.SelectedNodes, opt.SliceMetas, opt.SliceID, fileID, opt.KeyFileID, opt.KeyVersion, enc)
 	if len(plainText) == 0 {
 		return nSlice, eSlices, errorx.New(errorx.ErrCodeInternal, "slice pull from all healthy nodes error")
 	}
@@ -187,7 +226,7 @@ func (m *RandomCopier) ReplicaExpansion(ctx context.Context, opt *copier.Replica
 	for i := 0; i < sliceExpandNum; i++ {
 		pushRes := false
 		for _, n := range nNodes {
-			es, storIndex, err := common.EncAndPush(ctx, m, enc, plainText, opt.SliceID, sourceID, fileID, &n)
+			es, storIndex, err := common.EncAndPush(ctx, m, enc, plainText, opt.SliceID, sourceID, opt.KeyFileID, opt.KeyVersion, &n)
 
 			if err != nil {
 				logger.WithFields(logrus.Fields{
@@ -202,6 +241,7 @@ func (m *RandomCopier) ReplicaExpansion(ctx context.Context, opt *copier.Replica
 				Length:     es.Length,
 				NodeID:     es.NodeID,
 				StorIndex:  storIndex,
+				Sealed:     seal.IsSealed(es.CipherText),
 			}
 			if challengeAlgorithm == types.PairingChallengeAlgorithm {
 				// 4 gets SliceIdx
@@ -240,10 +280,11 @@ func getOptionalNode(selectedNodes blockchain.Nodes, allNodes blockchain.NodeHs)
 
 // pullSlice pull slices from selected Storage Nodes
 func (m *RandomCopier) pullSlice(ctx context.Context, selectedNodes blockchain.Nodes,
-	sliceMetas []blockchain.PublicSliceMeta, sliceID, fileID string, enc common.CommonEncryptor) (plainText []byte) {
+	sliceMetas []blockchain.PublicSliceMeta, sliceID, fileID, keyFileID string, keyVersion int,
+	enc common.CommonEncryptor) (plainText []byte) {
 	for _, n := range selectedNodes {
 		sm := getSliceMetaByID(sliceMetas, sliceID, string(n.ID))
-		plainText, err := common.PullAndDec(ctx, m, enc, sm, &n, fileID)
+		plainText, err := common.PullAndDec(ctx, m, enc, sm, &n, fileID, keyFileID, keyVersion)
 
 		if err != nil {
 			logger.WithError(err).Error("failed to decrypt slice")
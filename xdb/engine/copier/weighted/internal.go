@@ -0,0 +1,218 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weighted
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
+)
+
+const (
+	// bandwidthSmoothing is the weight of the latest observation in the moving average of bandwidth
+	bandwidthSmoothing = 0.3
+
+	// bounds of the factors making up weights of nodes,
+	// so that no node dominates the selection or is never selected
+	minFactor = 0.1
+	maxFactor = 10
+)
+
+// weightedNode is a candidate node and its weight to be selected
+type weightedNode struct {
+	node   blockchain.Node
+	weight float64
+}
+
+// candidates gets healthy nodes having enough free space for a slice of size bytes, grouped by health,
+// nodes in excludes are skipped
+func (m *WeightedCopier) candidates(nodeHs blockchain.NodeHs, excludes map[string]struct{}, size uint64) (
+	green, yellow []weightedNode) {
+	var nodes []blockchain.Node
+	var isGreen []bool
+	for _, n := range nodeHs {
+		if _, exist := excludes[string(n.Node.ID)]; exist {
+			continue
+		}
+		if n.Node.Capacity > 0 && n.Node.Used+size > n.Node.Capacity {
+			continue
+		}
+		if n.Health == blockchain.NodeHealthGood || n.Health == blockchain.NodeHealthMedium {
+			nodes = append(nodes, n.Node)
+			isGreen = append(isGreen, n.Health == blockchain.NodeHealthGood)
+		}
+	}
+
+	weights := m.weights(nodes)
+	for i, n := range nodes {
+		wn := weightedNode{node: n, weight: weights[i]}
+		if isGreen[i] {
+			green = append(green, wn)
+		} else {
+			yellow = append(yellow, wn)
+		}
+	}
+	return green, yellow
+}
+
+// weights calculates weights of nodes, the weight of a node is the product of
+// its free space and bandwidth, both relative to the mean values of the nodes.
+// Nodes with unknown capacity or bandwidth are taken as the mean ones
+func (m *WeightedCopier) weights(nodes []blockchain.Node) []float64 {
+	var freeSum, rateSum float64
+	var freeNum, rateNum int
+	rates := make([]float64, len(nodes))
+	for i, n := range nodes {
+		if n.Capacity > 0 {
+			freeSum += float64(n.Capacity - n.Used)
+			freeNum++
+		}
+		if rate, ok := m.bandwidth.rate(string(n.ID)); ok {
+			rates[i] = rate
+			rateSum += rate
+			rateNum++
+		}
+	}
+
+	weights := make([]float64, len(nodes))
+	for i, n := range nodes {
+		free, bw := 1.0, 1.0
+		if n.Capacity > 0 && freeSum > 0 {
+			free = boundFactor(float64(n.Capacity-n.Used) * float64(freeNum) / freeSum)
+		}
+		if rates[i] > 0 && rateSum > 0 {
+			bw = boundFactor(rates[i] * float64(rateNum) / rateSum)
+		}
+		weights[i] = free * bw
+	}
+	return weights
+}
+
+// expansionCandidates gets nodes in the zones having no replica of the slice to expand replicas,
+// full nodes are skipped
+func (m *WeightedCopier) expansionCandidates(nodeHs blockchain.NodeHs, selected blockchain.Nodes) blockchain.NodeHs {
+	usedZones := make(map[string]struct{})
+	for _, n := range selected {
+		usedZones[zoneOf(n)] = struct{}{}
+	}
+	var nodes blockchain.NodeHs
+	for _, n := range nodeHs {
+		if _, used := usedZones[zoneOf(n.Node)]; used {
+			continue
+		}
+		if n.Node.Capacity > 0 && n.Node.Used >= n.Node.Capacity {
+			continue
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes
+}
+
+// pickNodes picks at most num nodes from candidates randomly by their weights,
+// nodes in usedZones are skipped, and zones of picked nodes are added into usedZones
+func pickNodes(candidates []weightedNode, num int, usedZones map[string]struct{}) blockchain.Nodes {
+	var remaining []weightedNode
+	for _, c := range candidates {
+		if _, used := usedZones[zoneOf(c.node)]; !used {
+			remaining = append(remaining, c)
+		}
+	}
+
+	var picked blockchain.Nodes
+	for len(picked) < num && len(remaining) > 0 {
+		var total float64
+		for _, c := range remaining {
+			total += c.weight
+		}
+		index := len(remaining) - 1
+		r := rand.Float64() * total
+		for i, c := range remaining {
+			if r < c.weight {
+				index = i
+				break
+			}
+			r -= c.weight
+		}
+
+		node := remaining[index].node
+		picked = append(picked, node)
+		zone := zoneOf(node)
+		usedZones[zone] = struct{}{}
+
+		// one replica for each zone
+		var rest []weightedNode
+		for _, c := range remaining {
+			if zoneOf(c.node) != zone {
+				rest = append(rest, c)
+			}
+		}
+		remaining = rest
+	}
+	return picked
+}
+
+// zoneOf returns the failure domain of node, a node without zone label is a failure domain of its own
+func zoneOf(n blockchain.Node) string {
+	if n.Zone == "" {
+		return "node:" + string(n.ID)
+	}
+	return "zone:" + n.Zone
+}
+
+func boundFactor(f float64) float64 {
+	if f < minFactor {
+		return minFactor
+	}
+	if f > maxFactor {
+		return maxFactor
+	}
+	return f
+}
+
+// bandwidthStats records moving averages of bandwidth of nodes observed when pushing slices
+type bandwidthStats struct {
+	lock  sync.RWMutex
+	rates map[string]float64 // nodeID -> bytes per second
+}
+
+func newBandwidthStats() *bandwidthStats {
+	return &bandwidthStats{
+		rates: make(map[string]float64),
+	}
+}
+
+// observe records that n bytes are pushed onto the node in d
+func (b *bandwidthStats) observe(nodeID string, n int64, d time.Duration) {
+	if n <= 0 || d <= 0 {
+		return
+	}
+	rate := float64(n) / d.Seconds()
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if old, exist := b.rates[nodeID]; exist {
+		rate = old*(1-bandwidthSmoothing) + rate*bandwidthSmoothing
+	}
+	b.rates[nodeID] = rate
+}
+
+// rate returns the bandwidth of the node, false is returned if no push onto the node has been observed
+func (b *bandwidthStats) rate(nodeID string) (float64, bool) {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	rate, exist := b.rates[nodeID]
+	return rate, exist
+}
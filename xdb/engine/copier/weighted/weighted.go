@@ -0,0 +1,144 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weighted
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/ecdsa"
+	"github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/common"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/copier"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/copier/random"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/encryptor"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/slicer"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+)
+
+var (
+	logger = logrus.WithField("module", "weighted-copier")
+)
+
+// WeightedCopier selects Storage Nodes from healthy candidates by weights derived from their free space
+// and the bandwidth observed when pushing slices onto them, and never puts two replicas of a slice in the same zone.
+//  Nodes without zone label are taken as zones of their own.
+//  Pull and Delete are the same as RandomCopier, and ReplicaExpansion places new replicas in new zones.
+type WeightedCopier struct {
+	*random.RandomCopier
+
+	bandwidth *bandwidthStats
+}
+
+func New(privkey ecdsa.PrivateKey) *WeightedCopier {
+	c := &WeightedCopier{
+		RandomCopier: random.New(privkey),
+		bandwidth:    newBandwidthStats(),
+	}
+	logger.Info("copier initialization")
+	return c
+}
+
+// Select selects nodes for a slice from healthy candidates, green nodes first,
+// a node is more likely to be selected if it has more free space and higher bandwidth.
+// Zones of the holders of the slice are skipped
+func (m *WeightedCopier) Select(slice slicer.Slice, nodes blockchain.NodeHs, opt *copier.SelectOptions) (
+	copier.LocatedSlice, error) {
+	if len(nodes) == 0 {
+		return copier.LocatedSlice{}, errorx.New(errorx.ErrCodeInternal, "empty nodes array")
+	}
+
+	targetReplica := int(opt.Replica)
+	if targetReplica == 0 {
+		return copier.LocatedSlice{}, errorx.New(errorx.ErrCodeInternal, "empty replica")
+	}
+
+	green, yellow := m.candidates(nodes, opt.Excludes, uint64(len(slice.Data)))
+	usedZones := make(map[string]struct{})
+	for _, n := range opt.Holders {
+		usedZones[zoneOf(n)] = struct{}{}
+	}
+	selected := pickNodes(green, targetReplica, usedZones)
+	if len(selected) < targetReplica {
+		selected = append(selected, pickNodes(yellow, targetReplica-len(selected), usedZones)...)
+	}
+
+	logger.WithFields(logrus.Fields{
+		"slice_id":       slice.ID,
+		"online_nodes":   len(nodes),
+		"selected_nodes": len(selected),
+	}).Debug("selection done")
+
+	ls := copier.LocatedSlice{
+		Slice: slice,
+		Nodes: selected,
+	}
+	return ls, nil
+}
+
+// Push pushes slices onto Storage Node, and records the bandwidth of the node
+// returns storage index of slice
+func (m *WeightedCopier) Push(ctx context.Context, id, sourceID string, r io.Reader, node *blockchain.Node) (string, error) {
+	cr := &countReader{r: r}
+	start := time.Now()
+	storIndex, err := m.RandomCopier.Push(ctx, id, sourceID, cr, node)
+	if err != nil {
+		return "", err
+	}
+	m.bandwidth.observe(string(node.ID), cr.n, time.Since(start))
+	return storIndex, nil
+}
+
+// ReplicaExpansion slice performs Replica-Expand, new replicas are pushed onto nodes
+// in the zones which have no replica of the slice, one replica for each zone
+func (m *WeightedCopier) ReplicaExpansion(ctx context.Context, opt *copier.ReplicaExpOptions,
+	enc common.CommonEncryptor, challengeAlgorithm, sourceID, fileID string) (
+	nSlice []blockchain.PublicSliceMeta, eSlices []encryptor.EncryptedSlice, err error) {
+	nodesList, newReplica := opt.NodesList, opt.NewReplica
+	defer func() {
+		opt.NodesList, opt.NewReplica = nodesList, newReplica
+	}()
+
+	// expand one replica at a time, so that zones of new replicas are excluded as well
+	for len(opt.SelectedNodes) < newReplica {
+		opt.NodesList = m.expansionCandidates(nodesList, opt.SelectedNodes)
+		if len(opt.NodesList) == 0 {
+			return nSlice, eSlices, errorx.New(errorx.ErrCodeNotFound, "no more available nodes in new zones")
+		}
+		opt.NewReplica = len(opt.SelectedNodes) + 1
+
+		ns, es, err := m.RandomCopier.ReplicaExpansion(ctx, opt, enc, challengeAlgorithm, sourceID, fileID)
+		nSlice = append(nSlice, ns...)
+		eSlices = append(eSlices, es...)
+		if err != nil {
+			return nSlice, eSlices, err
+		}
+	}
+	return nSlice, eSlices, nil
+}
+
+// countReader counts bytes read from r
+type countReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weighted
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/copier"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/slicer"
+)
+
+func newNodeH(id byte, zone string, capacity, used uint64, health string) blockchain.NodeH {
+	return blockchain.NodeH{
+		Node: blockchain.Node{
+			ID:       []byte{id},
+			Zone:     zone,
+			Capacity: capacity,
+			Used:     used,
+		},
+		Health: health,
+	}
+}
+
+func TestSelection(t *testing.T) {
+	c := &WeightedCopier{bandwidth: newBandwidthStats()}
+
+	slice := slicer.Slice{}
+	slice.ID = "hello"
+	slice.Data = []byte("0a0b")
+
+	nodes := blockchain.NodeHs{
+		newNodeH(1, "a", 0, 0, blockchain.NodeHealthGood),
+		newNodeH(2, "a", 0, 0, blockchain.NodeHealthGood),
+		newNodeH(3, "b", 0, 0, blockchain.NodeHealthGood),
+		newNodeH(4, "b", 0, 0, blockchain.NodeHealthMedium),
+		newNodeH(5, "c", 100, 98, blockchain.NodeHealthGood), // no enough space
+		newNodeH(6, "", 0, 0, blockchain.NodeHealthBad),
+	}
+	for i := 0; i < 20; i++ {
+		ls, err := c.Select(slice, nodes, &copier.SelectOptions{Replica: 3})
+		require.NoError(t, err)
+		require.Equal(t, ls.Slice, slice)
+		// only two zones available
+		require.Equal(t, 2, len(ls.Nodes))
+		require.NotEqual(t, ls.Nodes[0].Zone, ls.Nodes[1].Zone)
+		for _, n := range ls.Nodes {
+			require.NotEqual(t, []byte{5}, n.ID)
+			require.NotEqual(t, []byte{6}, n.ID)
+		}
+	}
+
+	// nodes without zone label are zones of their own, and green nodes first
+	nodes = blockchain.NodeHs{
+		newNodeH(1, "", 0, 0, blockchain.NodeHealthMedium),
+		newNodeH(2, "", 0, 0, blockchain.NodeHealthGood),
+		newNodeH(3, "", 0, 0, blockchain.NodeHealthGood),
+	}
+	ls, err := c.Select(slice, nodes, &copier.SelectOptions{Replica: 2})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(ls.Nodes))
+	for _, n := range ls.Nodes {
+		require.NotEqual(t, []byte{1}, n.ID)
+	}
+	ls, err = c.Select(slice, nodes, &copier.SelectOptions{Replica: 3})
+	require.NoError(t, err)
+	require.Equal(t, 3, len(ls.Nodes))
+
+	_, err = c.Select(slice, nodes, &copier.SelectOptions{})
+	require.Error(t, err)
+}
+
+func TestSelectNewCopy(t *testing.T) {
+	c := &WeightedCopier{bandwidth: newBandwidthStats()}
+	slice := slicer.Slice{Data: []byte("0a0b")}
+	slice.ID = "hello"
+	nodes := blockchain.NodeHs{
+		newNodeH(1, "a", 0, 0, blockchain.NodeHealthGood),
+		newNodeH(2, "a", 0, 0, blockchain.NodeHealthGood),
+		newNodeH(3, "b", 0, 0, blockchain.NodeHealthGood),
+		newNodeH(4, "c", 0, 0, blockchain.NodeHealthGood),
+	}
+
+	// zones of the holders and the excluded nodes are skipped
+	for i := 0; i < 20; i++ {
+		ls, err := c.Select(slice, nodes, &copier.SelectOptions{
+			Replica:  4,
+			Excludes: map[string]struct{}{string([]byte{1}): {}, string([]byte{4}): {}},
+			Holders:  blockchain.Nodes{nodes[0].Node},
+		})
+		require.NoError(t, err)
+		require.Equal(t, 1, len(ls.Nodes))
+		require.Equal(t, []byte{3}, ls.Nodes[0].ID)
+	}
+
+	// free space of nodes with unknown capacity is taken as the mean one
+	weights := c.weights([]blockchain.Node{{ID: []byte{1}}, {ID: []byte{2}}})
+	require.Equal(t, []float64{1, 1}, weights)
+}
+
+func TestWeights(t *testing.T) {
+	c := &WeightedCopier{bandwidth: newBandwidthStats()}
+	nodes := []blockchain.Node{
+		{ID: []byte{1}, Capacity: 100, Used: 0},
+		{ID: []byte{2}, Capacity: 100, Used: 50},
+		{ID: []byte{3}},
+	}
+	weights := c.weights(nodes)
+	require.InDelta(t, 4.0/3, weights[0], 1e-9)
+	require.InDelta(t, 2.0/3, weights[1], 1e-9)
+	require.InDelta(t, 1.0, weights[2], 1e-9)
+
+	c.bandwidth.observe(string([]byte{1}), 100, time.Second)
+	c.bandwidth.observe(string([]byte{3}), 300, time.Second)
+	weights = c.weights(nodes)
+	require.InDelta(t, 4.0/3*0.5, weights[0], 1e-9)
+	require.InDelta(t, 2.0/3, weights[1], 1e-9)
+	require.InDelta(t, 1.5, weights[2], 1e-9)
+
+	c.bandwidth.observe(string([]byte{3}), 100, time.Second)
+	rate, ok := c.bandwidth.rate(string([]byte{3}))
+	require.True(t, ok)
+	require.InDelta(t, 240, rate, 1e-9)
+}
+
+func TestExpansionCandidates(t *testing.T) {
+	c := &WeightedCopier{bandwidth: newBandwidthStats()}
+	nodes := blockchain.NodeHs{
+		newNodeH(1, "a", 0, 0, blockchain.NodeHealthGood),
+		newNodeH(2, "a", 0, 0, blockchain.NodeHealthGood),
+		newNodeH(3, "b", 100, 100, blockchain.NodeHealthGood),
+		newNodeH(4, "c", 0, 0, blockchain.NodeHealthGood),
+		newNodeH(5, "", 0, 0, blockchain.NodeHealthGood),
+	}
+	cands := c.expansionCandidates(nodes, blockchain.Nodes{nodes[0].Node})
+	require.Equal(t, 2, len(cands))
+	require.Equal(t, []byte{4}, cands[0].Node.ID)
+	require.Equal(t, []byte{5}, cands[1].Node.ID)
+}
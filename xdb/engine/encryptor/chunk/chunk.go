@@ -0,0 +1,152 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package chunk encrypts content of files chunk by chunk using AES-GCM, so that a file can be
+// encrypted range by range, and a range of it can be decrypted and authenticated without the rest.
+//  ciphertext: AES-GCM sealed plaintext chunks of Size, the last one may be shorter or empty
+// Chunks are sealed by a key derived from the key of the file, the nonce of a chunk is made of
+// its index and a flag marking the last chunk, so chunks can't be reordered or truncated.
+package chunk
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+
+	xaes "github.com/PaddlePaddle/PaddleDTX/crypto/core/aes"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+)
+
+const (
+	Size       = 64 * 1024       // size of plaintext chunk
+	Overhead   = 16              // length of authentication tag of each chunk
+	SealedSize = Size + Overhead // size of ciphertext chunk
+
+	nonceSize = 12
+	keyLabel  = "xdb-chunk-key"
+)
+
+// CipherLength returns length of the ciphertext of plaintext of plainLen,
+// the last chunk is never full, so a file ends with an empty chunk if its length is a multiple of Size
+func CipherLength(plainLen uint64) uint64 {
+	return plainLen + (plainLen/Size+1)*Overhead
+}
+
+// Range returns the position [start, stop) of chunks covering plaintext [offset, end) in the ciphertext,
+// and index of the first chunk. plainLen is length of the whole plaintext
+func Range(offset, end, plainLen uint64) (start, stop, index uint64) {
+	index = offset / Size
+	last := index
+	if end > offset {
+		last = (end - 1) / Size
+	}
+	start = index * SealedSize
+	stop = (last + 1) * SealedSize
+	if l := CipherLength(plainLen); stop > l {
+		stop = l
+	}
+	return start, stop, index
+}
+
+// Cipher seals and opens chunks of a file
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// New creates Cipher by the key of the file, only the key is used, the nonce of key is ignored
+func New(key xaes.AESKey) (*Cipher, error) {
+	h := hmac.New(sha256.New, key.Key)
+	h.Write([]byte(keyLabel))
+	block, err := aes.NewCipher(h.Sum(nil))
+	if err != nil {
+		return nil, errorx.NewCode(err, errorx.ErrCodeCrypto, "invalid key")
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errorx.NewCode(err, errorx.ErrCodeCrypto, "failed to create cipher")
+	}
+	return &Cipher{aead: aead}, nil
+}
+
+// Encrypt encrypts the whole plaintext of a file
+func (c *Cipher) Encrypt(plaintext []byte) []byte {
+	ciphertext, _ := c.EncryptChunks(plaintext, 0, true)
+	return ciphertext
+}
+
+// EncryptChunks encrypts plaintext into chunks starting from the chunk of index,
+// last means plaintext ends the file, otherwise it must be made of whole chunks
+func (c *Cipher) EncryptChunks(plaintext []byte, index uint64, last bool) ([]byte, error) {
+	if !last && len(plaintext)%Size != 0 {
+		return nil, errorx.New(errorx.ErrCodeParam, "plaintext is not made of whole chunks")
+	}
+	chunks := len(plaintext) / Size
+	if last {
+		chunks++
+	}
+	ciphertext := make([]byte, 0, len(plaintext)+chunks*Overhead)
+	for i := 0; i < chunks; i++ {
+		end := (i + 1) * Size
+		if end > len(plaintext) {
+			end = len(plaintext)
+		}
+		isLast := last && i == chunks-1
+		ciphertext = c.aead.Seal(ciphertext, nonce(index+uint64(i), isLast), plaintext[i*Size:end], nil)
+	}
+	return ciphertext, nil
+}
+
+// Decrypt decrypts ciphertext of the whole file
+func (c *Cipher) Decrypt(ciphertext []byte) ([]byte, error) {
+	return c.DecryptChunks(ciphertext, 0, true)
+}
+
+// DecryptChunks decrypts chunks starting from the chunk of index and verifies them,
+// last means ciphertext ends with the last chunk of the file, otherwise it must be made of whole chunks
+func (c *Cipher) DecryptChunks(ciphertext []byte, index uint64, last bool) ([]byte, error) {
+	if len(ciphertext) == 0 || (!last && len(ciphertext)%SealedSize != 0) {
+		return nil, errorx.New(errorx.ErrCodeCrypto, "bad length of chunks")
+	}
+	plaintext := make([]byte, 0, len(ciphertext))
+	for i := uint64(0); len(ciphertext) > 0; i++ {
+		n := SealedSize
+		if n > len(ciphertext) {
+			n = len(ciphertext)
+		}
+		isLast := last && n == len(ciphertext)
+		// a full chunk is never the last one
+		if isLast && n == SealedSize {
+			return nil, errorx.New(errorx.ErrCodeCrypto, "file truncated")
+		}
+		var err error
+		plaintext, err = c.aead.Open(plaintext, nonce(index+i, isLast), ciphertext[:n], nil)
+		if err != nil {
+			return nil, errorx.NewCode(err, errorx.ErrCodeCrypto, "failed to decrypt chunk %d", index+i)
+		}
+		ciphertext = ciphertext[n:]
+	}
+	return plaintext, nil
+}
+
+// nonce of the chunk of index, the first byte marks the last chunk
+func nonce(index uint64, last bool) []byte {
+	n := make([]byte, nonceSize)
+	if last {
+		n[0] = 1
+	}
+	binary.BigEndian.PutUint64(n[nonceSize-8:], index)
+	return n
+}
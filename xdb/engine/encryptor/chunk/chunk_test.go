@@ -0,0 +1,111 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	xaes "github.com/PaddlePaddle/PaddleDTX/crypto/core/aes"
+)
+
+func newTestCipher(t *testing.T) *Cipher {
+	c, err := New(xaes.AESKey{Key: []byte("0123456789abcdef0123456789abcdef")})
+	require.NoError(t, err)
+	return c
+}
+
+func testData(n int) []byte {
+	data := make([]byte, n)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	return data
+}
+
+func TestEncrypt(t *testing.T) {
+	c := newTestCipher(t)
+	for _, n := range []int{0, 1, Size - 1, Size, Size + 1, 3*Size + 100} {
+		data := testData(n)
+		ciphertext := c.Encrypt(data)
+		require.Equal(t, int(CipherLength(uint64(n))), len(ciphertext))
+
+		plaintext, err := c.Decrypt(ciphertext)
+		require.NoError(t, err)
+		require.Equal(t, data, append([]byte{}, plaintext...))
+
+		// truncated at a chunk border
+		if n >= Size {
+			_, err = c.Decrypt(ciphertext[:SealedSize])
+			require.Error(t, err)
+		}
+		// tampered
+		ciphertext[len(ciphertext)-1] ^= 1
+		_, err = c.Decrypt(ciphertext)
+		require.Error(t, err)
+	}
+}
+
+func TestEncryptChunks(t *testing.T) {
+	c := newTestCipher(t)
+	data := testData(3*Size + 100)
+
+	// encrypt range by range
+	var ciphertext []byte
+	for i := 0; i < 3; i++ {
+		ct, err := c.EncryptChunks(data[i*Size:(i+1)*Size], uint64(i), false)
+		require.NoError(t, err)
+		ciphertext = append(ciphertext, ct...)
+	}
+	ct, err := c.EncryptChunks(data[3*Size:], 3, true)
+	require.NoError(t, err)
+	ciphertext = append(ciphertext, ct...)
+	require.Equal(t, c.Encrypt(data), ciphertext)
+
+	_, err = c.EncryptChunks(data[:Size+1], 0, false)
+	require.Error(t, err)
+
+	// chunks can't be reordered
+	swapped := append([]byte{}, ciphertext[SealedSize:2*SealedSize]...)
+	swapped = append(swapped, ciphertext[:SealedSize]...)
+	_, err = c.DecryptChunks(swapped, 0, false)
+	require.Error(t, err)
+}
+
+func TestRange(t *testing.T) {
+	c := newTestCipher(t)
+	data := testData(3*Size + 100)
+	ciphertext := c.Encrypt(data)
+	plainLen := uint64(len(data))
+
+	for _, r := range [][2]uint64{{0, plainLen}, {0, 5}, {Size - 1, Size + 1}, {Size, 2 * Size}, {2*Size + 7, 3*Size + 50}, {plainLen - 1, plainLen}} {
+		start, stop, index := Range(r[0], r[1], plainLen)
+		plaintext, err := c.DecryptChunks(ciphertext[start:stop], index, stop == CipherLength(plainLen))
+		require.NoError(t, err)
+		from := r[0] - index*Size
+		require.Equal(t, data[r[0]:r[1]], plaintext[from:from+r[1]-r[0]])
+	}
+
+	// the empty last chunk of a file whose length is a multiple of Size
+	data = testData(2 * Size)
+	ciphertext = c.Encrypt(data)
+	start, stop, index := Range(0, 0, 0)
+	_, err := c.DecryptChunks(c.Encrypt(nil)[start:stop], index, true)
+	require.NoError(t, err)
+	start, stop, index = Range(Size, 2*Size, 2*Size)
+	plaintext, err := c.DecryptChunks(ciphertext[start:stop], index, stop == CipherLength(2*Size))
+	require.NoError(t, err)
+	require.Equal(t, data[Size:], plaintext)
+}
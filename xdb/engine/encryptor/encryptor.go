@@ -15,9 +15,10 @@ package encryptor
 
 // EncryptOptions use fileID, sliceID and nodeID info when encrypting slice content
 type EncryptOptions struct {
-	FileID  string
-	SliceID string
-	NodeID  []byte
+	FileID     string
+	SliceID    string
+	NodeID     []byte
+	KeyVersion int // version of the password to derive key, 0 means the current version
 }
 
 type EncryptedSliceMeta struct {
@@ -35,7 +36,8 @@ type EncryptedSlice struct {
 
 // RecoverOptions use fileID, sliceID and nodeID info when recovering slice content
 type RecoverOptions struct {
-	FileID  string
-	SliceID string
-	NodeID  []byte
+	FileID     string
+	SliceID    string
+	NodeID     []byte
+	KeyVersion int // version of the password to derive key, 0 means the current version
 }
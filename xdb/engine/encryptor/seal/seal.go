@@ -0,0 +1,121 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package seal transforms slice ciphertext into a replica unique to the storage node which stores it.
+//  sealed replica: Magic | rounds(4 bytes, big-endian) | sealed blocks
+// Ciphertext is sealed block by block, a block is xor-ed with the mask hashed from the previous sealed block
+// for rounds times, and the first one from the node's ID. So the mask of each block depends on data before it,
+// and a replica can only be produced by hashing sequentially through the whole slice, which takes time in
+// proportion to rounds, while a stored replica answers challenges at once.
+// Sealing depends on nothing secret, everyone who reads a replica can unseal it with the node's ID.
+package seal
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+)
+
+const (
+	MaxRounds = 1 << 16 // maximum rounds of hashing for each block
+	Overhead  = 12      // length of Magic and rounds before sealed blocks
+
+	blockSize = sha256.Size
+	seedLabel = "xdb-seal"
+)
+
+// Magic starts a sealed replica
+var Magic = []byte("XDBSEAL2")
+
+// Seal seals ciphertext for the storage node identified by nodeID, rounds should be within [1, MaxRounds]
+func Seal(ciphertext, nodeID []byte, rounds int) ([]byte, error) {
+	if rounds <= 0 || rounds > MaxRounds {
+		return nil, errorx.New(errorx.ErrCodeParam, "invalid seal rounds: %d", rounds)
+	}
+	sealed := make([]byte, Overhead+len(ciphertext))
+	copy(sealed, Magic)
+	binary.BigEndian.PutUint32(sealed[len(Magic):], uint32(rounds))
+
+	out := sealed[Overhead:]
+	prev := seed(nodeID)
+	for start := 0; start < len(ciphertext); start += blockSize {
+		end := min(start+blockSize, len(ciphertext))
+		m := mask(prev, start/blockSize, rounds)
+		for i := start; i < end; i++ {
+			out[i] = ciphertext[i] ^ m[i-start]
+		}
+		prev = out[start:end]
+	}
+	return sealed, nil
+}
+
+// Unseal recovers ciphertext from the replica sealed for the storage node identified by nodeID,
+// data not sealed is returned as is
+func Unseal(data, nodeID []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, Magic) {
+		return data, nil
+	}
+	if len(data) < Overhead {
+		return nil, errorx.New(errorx.ErrCodeCrypto, "bad sealed replica")
+	}
+	rounds := binary.BigEndian.Uint32(data[len(Magic):])
+	if rounds == 0 || rounds > MaxRounds {
+		return nil, errorx.New(errorx.ErrCodeCrypto, "bad seal rounds: %d", rounds)
+	}
+
+	sealed := data[Overhead:]
+	ciphertext := make([]byte, len(sealed))
+	prev := seed(nodeID)
+	for start := 0; start < len(sealed); start += blockSize {
+		end := min(start+blockSize, len(sealed))
+		m := mask(prev, start/blockSize, int(rounds))
+		for i := start; i < end; i++ {
+			ciphertext[i] = sealed[i] ^ m[i-start]
+		}
+		prev = sealed[start:end]
+	}
+	return ciphertext, nil
+}
+
+// IsSealed checks whether data is a sealed replica
+func IsSealed(data []byte) bool {
+	return bytes.HasPrefix(data, Magic)
+}
+
+func seed(nodeID []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte(seedLabel))
+	h.Write(nodeID)
+	return h.Sum(nil)
+}
+
+// mask hashes the previous sealed block and the index of block for rounds times
+func mask(prev []byte, index, rounds int) [blockSize]byte {
+	buf := make([]byte, len(prev)+8)
+	copy(buf, prev)
+	binary.BigEndian.PutUint64(buf[len(prev):], uint64(index))
+	m := sha256.Sum256(buf)
+	for r := 1; r < rounds; r++ {
+		m = sha256.Sum256(m[:])
+	}
+	return m
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
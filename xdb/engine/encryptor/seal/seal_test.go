@@ -0,0 +1,69 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package seal
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeal(t *testing.T) {
+	data := bytes.Repeat([]byte("slice ciphertext"), 100)
+	data = append(data, "tail"...)
+	nodeA, nodeB := []byte("node-a"), []byte("node-b")
+
+	sealedA, err := Seal(data, nodeA, 4)
+	require.NoError(t, err)
+	sealedB, err := Seal(data, nodeB, 4)
+	require.NoError(t, err)
+	require.True(t, IsSealed(sealedA))
+	require.Len(t, sealedA, Overhead+len(data))
+	require.NotEqual(t, sealedA, sealedB)
+
+	unsealed, err := Unseal(sealedA, nodeA)
+	require.NoError(t, err)
+	require.Equal(t, data, unsealed)
+	unsealed, err = Unseal(sealedA, nodeB)
+	require.NoError(t, err)
+	require.NotEqual(t, data, unsealed)
+
+	// masks depend on data before them, a change spreads to all blocks after it
+	changed := append([]byte{}, data...)
+	changed[blockSize] ^= 1
+	sealedChanged, err := Seal(changed, nodeA, 4)
+	require.NoError(t, err)
+	body, bodyChanged := sealedA[Overhead:], sealedChanged[Overhead:]
+	require.Equal(t, body[:blockSize], bodyChanged[:blockSize])
+	for start := 2 * blockSize; start < len(data); start += blockSize {
+		end := min(start+blockSize, len(data))
+		require.NotEqual(t, body[start:end], bodyChanged[start:end])
+	}
+
+	// data not sealed is returned as is
+	unsealed, err = Unseal(data, nodeA)
+	require.NoError(t, err)
+	require.Equal(t, data, unsealed)
+
+	// bad rounds are rejected
+	_, err = Seal(data, nodeA, 0)
+	require.Error(t, err)
+	_, err = Seal(data, nodeA, MaxRounds+1)
+	require.Error(t, err)
+	tampered := append([]byte{}, sealedA...)
+	tampered[len(Magic)] = 0xff
+	_, err = Unseal(tampered, nodeA)
+	require.Error(t, err)
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package soft
+
+import (
+	"crypto/rand"
+	"os"
+	"path/filepath"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/pkgs/file"
+)
+
+// fileKeyLength is the length of the random seed of each file
+const fileKeyLength = 32
+
+// CreateFileKey generates a random seed for the file, keys of the file and its slices are derived from
+// both the password and the seed, so that they can be destroyed by DestroyFileKey.
+// Does nothing if fileKeyPath is not configured
+func (se *SoftEncryptor) CreateFileKey(fileID string) error {
+	if len(se.fileKeyPath) == 0 {
+		return nil
+	}
+	seed := make([]byte, fileKeyLength)
+	if _, err := rand.Read(seed); err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeCrypto, "failed to generate file key")
+	}
+	if err := file.WriteFile(se.fileKeyPath, fileID, seed); err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to save file key")
+	}
+	return nil
+}
+
+// DestroyFileKey overwrites and removes the seed of the file, after that
+// the keys of the file and its slices can never be derived again, even with the password
+func (se *SoftEncryptor) DestroyFileKey(fileID string) error {
+	if len(se.fileKeyPath) == 0 {
+		return errorx.New(errorx.ErrCodeConfig, "file key path not configured")
+	}
+	if exist, err := file.IsFileExisted(se.fileKeyPath, fileID); err != nil || !exist {
+		return errorx.New(errorx.ErrCodeNotFound, "key of file %s not found", fileID)
+	}
+
+	filename := filepath.Join(se.fileKeyPath, fileID)
+	f, err := os.OpenFile(filename, os.O_WRONLY, 0)
+	if err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to open file key")
+	}
+	_, err = f.Write(make([]byte, fileKeyLength))
+	if err == nil {
+		err = f.Sync()
+	}
+	f.Close()
+	if err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to overwrite file key")
+	}
+	if err := os.Remove(filename); err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to remove file key")
+	}
+	return nil
+}
+
+// loadFileKey loads the seed of the file, returns nil if the file has no seed,
+// such as files written before fileKeyPath is configured, or files whose key is destroyed
+func (se *SoftEncryptor) loadFileKey(fileID string) []byte {
+	if len(se.fileKeyPath) == 0 || len(fileID) == 0 {
+		return nil
+	}
+	seed, err := file.ReadFile(se.fileKeyPath, fileID)
+	if err != nil || len(seed) != fileKeyLength {
+		return nil
+	}
+	return seed
+}
@@ -21,9 +21,15 @@ import (
 	"github.com/PaddlePaddle/PaddleDTX/crypto/core/hash"
 )
 
-// getKey derive encrypt key by password, fileID, sliceID and NodeID using key derivation function
+// getKey derive encrypt key by the current password, fileID, sliceID and NodeID using key derivation function
 func (se *SoftEncryptor) getKey(fileID, sliceID string, nodeID []byte) []byte {
-	secret := []byte(se.password)
+	return se.deriveKey(se.password, fileID, sliceID, nodeID)
+}
+
+// deriveKey derive encrypt key by password, fileID, sliceID and NodeID using key derivation function,
+// the random seed of the file is also used as secret if it exists
+func (se *SoftEncryptor) deriveKey(password, fileID, sliceID string, nodeID []byte) []byte {
+	secret := append([]byte(password), se.loadFileKey(fileID)...)
 	salt := append(append([]byte(fileID), []byte(sliceID)...), nodeID...)
 	r := hkdf.New(hash.DefaultHasher, secret, salt, nil)
 
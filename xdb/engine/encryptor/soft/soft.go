@@ -16,36 +16,99 @@ package soft
 import (
 	"io"
 	"io/ioutil"
+	"strconv"
 
 	"github.com/PaddlePaddle/PaddleDTX/crypto/core/aes"
 	"github.com/PaddlePaddle/PaddleDTX/crypto/core/hash"
 
 	"github.com/PaddlePaddle/PaddleDTX/xdb/config"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/encryptor"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/encryptor/seal"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/keyprovider"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
 )
 
 // SoftEncryptor encrypts data or decrypts encoded data
 type SoftEncryptor struct {
-	password string
+	password     string
+	version      int            // version of password, 0 is regarded as version 1
+	oldPasswords map[int]string // passwords of previous versions, used to decrypt data not re-encrypted yet
+	fileKeyPath  string         // directory of random seeds of files, used to destroy keys of deleted files
+	sealRounds   int            // rounds of sealing transform on slices encrypted for storage nodes, 0 disables it
 }
 
 // New creat SoftEncryptor by "password" configuration
-func New(conf *config.SoftEncryptorConf) (*SoftEncryptor, error) {
-	if len(conf.Password) == 0 {
+//  If kp is not nil, passwords absent from configuration are fetched from it
+func New(conf *config.SoftEncryptorConf, kp keyprovider.KeyProvider) (*SoftEncryptor, error) {
+	password := conf.Password
+	if len(password) == 0 && kp != nil {
+		var err error
+		if password, err = kp.GetSecret(keyprovider.SecretEncryptorPassword); err != nil {
+			return nil, errorx.Wrap(err, "failed to get password from key provider")
+		}
+	}
+	if len(password) == 0 {
 		return nil, errorx.New(errorx.ErrCodeConfig, "missing password")
 	}
+	if conf.PasswordVersion < 0 {
+		return nil, errorx.New(errorx.ErrCodeConfig, "invalid password version")
+	}
+	if conf.SealRounds < 0 || conf.SealRounds > seal.MaxRounds {
+		return nil, errorx.New(errorx.ErrCodeConfig, "invalid seal rounds, should be within [0, %d]", seal.MaxRounds)
+	}
 
 	se := &SoftEncryptor{
-		password: conf.Password,
+		password:     password,
+		version:      conf.PasswordVersion,
+		oldPasswords: make(map[int]string),
+		fileKeyPath:  conf.FileKeyPath,
+		sealRounds:   conf.SealRounds,
+	}
+	for v, password := range conf.OldPasswords {
+		version, err := strconv.Atoi(v)
+		if err != nil || version <= 0 || version >= se.KeyVersion() {
+			return nil, errorx.New(errorx.ErrCodeConfig, "invalid version of old password: %s", v)
+		}
+		if len(password) == 0 {
+			return nil, errorx.New(errorx.ErrCodeConfig, "missing old password of version %d", version)
+		}
+		se.oldPasswords[version] = password
+	}
+	if kp != nil {
+		for version := 1; version < se.KeyVersion(); version++ {
+			if _, ok := se.oldPasswords[version]; ok {
+				continue
+			}
+			password, err := kp.GetSecret(keyprovider.OldPasswordName(version))
+			if err != nil {
+				if errorx.Is(err, errorx.ErrCodeNotFound) {
+					continue
+				}
+				return nil, errorx.Wrap(err, "failed to get old password of version %d from key provider", version)
+			}
+			se.oldPasswords[version] = password
+		}
 	}
 
 	return se, nil
 }
 
-// GetKey derive key using fileID, nodeID and slice ID
-func (se *SoftEncryptor) GetKey(fileID, sliceID string, nodeID []byte) aes.AESKey {
-	key := se.getKey(fileID, sliceID, nodeID)
+// KeyVersion returns version of the current password, new data is always encrypted under it
+func (se *SoftEncryptor) KeyVersion() int {
+	if se.version == 0 {
+		return 1
+	}
+	return se.version
+}
+
+// GetKey derive key using fileID, nodeID and slice ID under password of keyVersion,
+// 0 means the current version
+func (se *SoftEncryptor) GetKey(fileID, sliceID string, nodeID []byte, keyVersion int) (aes.AESKey, error) {
+	password, err := se.getPassword(keyVersion)
+	if err != nil {
+		return aes.AESKey{}, err
+	}
+	key := se.deriveKey(password, fileID, sliceID, nodeID)
 
 	salt := append(append([]byte(fileID), []byte(sliceID)...), nodeID...)
 	nonce := hash.HashUsingSha256(salt)[:12]
@@ -54,10 +117,23 @@ func (se *SoftEncryptor) GetKey(fileID, sliceID string, nodeID []byte) aes.AESKe
 		Key:   key,
 		Nonce: nonce,
 	}
-	return aesKey
+	return aesKey, nil
 }
 
-// Encrypt derive key using nodeID and slice ID, then encrypt content using AES-GCM
+// getPassword returns password of keyVersion, 0 means the current version
+func (se *SoftEncryptor) getPassword(keyVersion int) (string, error) {
+	if keyVersion == 0 || keyVersion == se.KeyVersion() {
+		return se.password, nil
+	}
+	password, ok := se.oldPasswords[keyVersion]
+	if !ok {
+		return "", errorx.New(errorx.ErrCodeCrypto, "password of version %d not found", keyVersion)
+	}
+	return password, nil
+}
+
+// Encrypt derive key using nodeID and slice ID, then encrypt content using AES-GCM,
+//  slices encrypted for a storage node are sealed into a node-unique replica if sealing is enabled
 func (se *SoftEncryptor) Encrypt(r io.Reader, opt *encryptor.EncryptOptions) (
 	encryptor.EncryptedSlice, error) {
 
@@ -66,11 +142,19 @@ func (se *SoftEncryptor) Encrypt(r io.Reader, opt *encryptor.EncryptOptions) (
 		return encryptor.EncryptedSlice{},
 			errorx.NewCode(err, errorx.ErrCodeInternal, "failed to read plaintext during Encrypt")
 	}
-	aesKey := se.GetKey(opt.FileID, opt.SliceID, opt.NodeID)
+	aesKey, err := se.GetKey(opt.FileID, opt.SliceID, opt.NodeID, opt.KeyVersion)
+	if err != nil {
+		return encryptor.EncryptedSlice{}, err
+	}
 	ciphertext, err := aes.EncryptUsingAESGCM(aesKey, plaintext, nil)
 	if err != nil {
 		return encryptor.EncryptedSlice{}, errorx.Wrap(err, "failed to encrypt")
 	}
+	if se.sealRounds > 0 && len(opt.NodeID) > 0 {
+		if ciphertext, err = seal.Seal(ciphertext, opt.NodeID, se.sealRounds); err != nil {
+			return encryptor.EncryptedSlice{}, errorx.Wrap(err, "failed to seal")
+		}
+	}
 	h := hash.HashUsingSha256(ciphertext)
 
 	es := encryptor.EncryptedSlice{
@@ -86,14 +170,23 @@ func (se *SoftEncryptor) Encrypt(r io.Reader, opt *encryptor.EncryptOptions) (
 	return es, nil
 }
 
-// Recover derive key using nodeID and slice ID, then decrypt content using AES-GCM
+// Recover derive key using nodeID and slice ID, then decrypt content using AES-GCM,
+//  sealed replicas are unsealed first whatever sealing is enabled or not
 func (se *SoftEncryptor) Recover(r io.Reader, opt *encryptor.RecoverOptions) (
 	[]byte, error) {
 	ciphertext, err := ioutil.ReadAll(r)
 	if err != nil {
 		return nil, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to read ciphertext during Recover")
 	}
-	aesKey := se.GetKey(opt.FileID, opt.SliceID, opt.NodeID)
+	aesKey, err := se.GetKey(opt.FileID, opt.SliceID, opt.NodeID, opt.KeyVersion)
+	if err != nil {
+		return nil, err
+	}
+	if len(opt.NodeID) > 0 {
+		if ciphertext, err = seal.Unseal(ciphertext, opt.NodeID); err != nil {
+			return nil, err
+		}
+	}
 	plaintext, err := aes.DecryptUsingAESGCM(aesKey, ciphertext, nil)
 	if err != nil {
 		return nil, errorx.Wrap(err, "failed to decrypt")
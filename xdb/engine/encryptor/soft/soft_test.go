@@ -21,9 +21,23 @@ import (
 	"github.com/PaddlePaddle/PaddleDTX/crypto/core/hash"
 	"github.com/stretchr/testify/require"
 
+	"github.com/PaddlePaddle/PaddleDTX/xdb/config"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/encryptor"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/encryptor/seal"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/keyprovider"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
 )
 
+type mapProvider map[string]string
+
+func (m mapProvider) GetSecret(name string) (string, error) {
+	secret, ok := m[name]
+	if !ok {
+		return "", errorx.New(errorx.ErrCodeNotFound, "secret %s not found", name)
+	}
+	return secret, nil
+}
+
 func TestEncrypt(t *testing.T) {
 	se := SoftEncryptor{
 		password: "hello world",
@@ -62,3 +76,136 @@ func TestEncrypt(t *testing.T) {
 	require.Error(t, err)
 	require.NotEqual(t, data, recovered)
 }
+
+func TestSeal(t *testing.T) {
+	se := SoftEncryptor{
+		password:   "hello world",
+		sealRounds: 4,
+	}
+	data := bytes.Repeat([]byte("slice content "), 100)
+	nodeA, nodeB := []byte("node-a"), []byte("node-b")
+
+	// replicas for storage nodes are sealed, and challenged in their sealed form
+	es, err := se.Encrypt(bytes.NewReader(data), &encryptor.EncryptOptions{SliceID: "s", NodeID: nodeA})
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(es.CipherText, seal.Magic))
+	require.Equal(t, hash.HashUsingSha256(es.CipherText), es.CipherHash)
+	require.Equal(t, int(es.Length), len(es.CipherText))
+
+	recovered, err := se.Recover(bytes.NewReader(es.CipherText), &encryptor.RecoverOptions{SliceID: "s", NodeID: nodeA})
+	require.NoError(t, err)
+	require.Equal(t, data, recovered)
+	// a replica can not be recovered as another node's one
+	_, err = se.Recover(bytes.NewReader(es.CipherText), &encryptor.RecoverOptions{SliceID: "s", NodeID: nodeB})
+	require.Error(t, err)
+
+	// replicas sealed before are still recovered after sealing is disabled, and new ones are not sealed
+	plain := SoftEncryptor{password: "hello world"}
+	recovered, err = plain.Recover(bytes.NewReader(es.CipherText), &encryptor.RecoverOptions{SliceID: "s", NodeID: nodeA})
+	require.NoError(t, err)
+	require.Equal(t, data, recovered)
+	es, err = plain.Encrypt(bytes.NewReader(data), &encryptor.EncryptOptions{SliceID: "s", NodeID: nodeA})
+	require.NoError(t, err)
+	require.False(t, bytes.HasPrefix(es.CipherText, seal.Magic))
+
+	_, err = New(&config.SoftEncryptorConf{Password: "hello world", SealRounds: seal.MaxRounds + 1}, nil)
+	require.Error(t, err)
+}
+
+func TestKeyVersion(t *testing.T) {
+	data := []byte("b66ba2a42e96f93beb07f194026d3b3e7ed363e99c098089fc611747d845c9b1")
+	fileID := "f1bf1a5b-3c5e-4a0c-8b3e-9e5b7f1f6a2d"
+	sliceID := "a80809b9-d8de-4c43-b680-ad3466c33b9d"
+
+	v1, err := New(&config.SoftEncryptorConf{Password: "hello world"}, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, v1.KeyVersion())
+	es1, err := v1.Encrypt(bytes.NewReader(data), &encryptor.EncryptOptions{FileID: fileID, SliceID: sliceID})
+	require.NoError(t, err)
+
+	// old password must be of a previous version
+	_, err = New(&config.SoftEncryptorConf{Password: "hello xdb", PasswordVersion: 2,
+		OldPasswords: map[string]string{"2": "hello world"}}, nil)
+	require.Error(t, err)
+
+	v2, err := New(&config.SoftEncryptorConf{Password: "hello xdb", PasswordVersion: 2,
+		OldPasswords: map[string]string{"1": "hello world"}}, nil)
+	require.NoError(t, err)
+	require.Equal(t, 2, v2.KeyVersion())
+
+	// data encrypted under version 1 is recovered with version 1 only
+	_, err = v2.Recover(bytes.NewReader(es1.CipherText), &encryptor.RecoverOptions{FileID: fileID, SliceID: sliceID})
+	require.Error(t, err)
+	recovered, err := v2.Recover(bytes.NewReader(es1.CipherText),
+		&encryptor.RecoverOptions{FileID: fileID, SliceID: sliceID, KeyVersion: 1})
+	require.NoError(t, err)
+	require.Equal(t, data, recovered)
+
+	// re-encrypt under the current version
+	es2, err := v2.Encrypt(bytes.NewReader(recovered), &encryptor.EncryptOptions{FileID: fileID, SliceID: sliceID})
+	require.NoError(t, err)
+	require.NotEqual(t, es1.CipherText, es2.CipherText)
+	recovered, err = v2.Recover(bytes.NewReader(es2.CipherText),
+		&encryptor.RecoverOptions{FileID: fileID, SliceID: sliceID, KeyVersion: 2})
+	require.NoError(t, err)
+	require.Equal(t, data, recovered)
+
+	// unknown version
+	_, err = v2.GetKey(fileID, sliceID, nil, 3)
+	require.Error(t, err)
+}
+
+func TestKeyProvider(t *testing.T) {
+	// password is required either in configuration or from key provider
+	_, err := New(&config.SoftEncryptorConf{}, mapProvider{})
+	require.Error(t, err)
+
+	kp := mapProvider{
+		keyprovider.SecretEncryptorPassword: "hello xdb",
+		keyprovider.OldPasswordName(1):      "hello world",
+	}
+	se, err := New(&config.SoftEncryptorConf{PasswordVersion: 3}, kp)
+	require.NoError(t, err)
+	require.Equal(t, "hello xdb", se.password)
+	require.Equal(t, map[int]string{1: "hello world"}, se.oldPasswords)
+
+	// configuration takes precedence over key provider
+	se, err = New(&config.SoftEncryptorConf{Password: "hello", PasswordVersion: 2,
+		OldPasswords: map[string]string{"1": "world"}}, kp)
+	require.NoError(t, err)
+	require.Equal(t, "hello", se.password)
+	require.Equal(t, "world", se.oldPasswords[1])
+}
+
+func TestDestroyFileKey(t *testing.T) {
+	se := SoftEncryptor{
+		password:    "hello world",
+		fileKeyPath: t.TempDir(),
+	}
+	data := []byte("b66ba2a42e96f93beb07f194026d3b3e7ed363e99c098089fc611747d845c9b1")
+	fileID := "f1bf1a5b-3c5e-4a0c-8b3e-9e5b7f1f6a2d"
+	sliceID := "a80809b9-d8de-4c43-b680-ad3466c33b9d"
+
+	// destroy a file key not created
+	require.Error(t, se.DestroyFileKey(fileID))
+
+	require.NoError(t, se.CreateFileKey(fileID))
+	es, err := se.Encrypt(bytes.NewReader(data), &encryptor.EncryptOptions{FileID: fileID, SliceID: sliceID})
+	require.NoError(t, err)
+
+	// key derived from password only can not decrypt the slice
+	legacy := SoftEncryptor{password: se.password}
+	_, err = legacy.Recover(bytes.NewReader(es.CipherText), &encryptor.RecoverOptions{FileID: fileID, SliceID: sliceID})
+	require.Error(t, err)
+
+	ropt := encryptor.RecoverOptions{FileID: fileID, SliceID: sliceID}
+	recovered, err := se.Recover(bytes.NewReader(es.CipherText), &ropt)
+	require.NoError(t, err)
+	require.Equal(t, data, recovered)
+
+	// after the file key is destroyed, the slice can no longer be decrypted
+	require.NoError(t, se.DestroyFileKey(fileID))
+	_, err = se.Recover(bytes.NewReader(es.CipherText), &ropt)
+	require.Error(t, err)
+	require.Error(t, se.DestroyFileKey(fileID))
+}
@@ -16,6 +16,8 @@ package engine
 import (
 	"context"
 	"io"
+	"sync"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
@@ -48,9 +50,12 @@ type Slicer interface {
 
 // Encryptor encrypts data and decrypts encoded data
 type Encryptor interface {
-	GetKey(fileID, sliceID string, nodeID []byte) aes.AESKey
+	KeyVersion() int
+	GetKey(fileID, sliceID string, nodeID []byte, keyVersion int) (aes.AESKey, error)
 	Encrypt(r io.Reader, opt *encryptor.EncryptOptions) (encryptor.EncryptedSlice, error)
 	Recover(r io.Reader, opt *encryptor.RecoverOptions) ([]byte, error)
+	CreateFileKey(fileID string) error
+	DestroyFileKey(fileID string) error
 }
 
 // Challenger generates challenge requests as dataOwner-node for storage-nodes to answer
@@ -64,6 +69,7 @@ type Challenger interface {
 	Setup(sliceData []byte, rangeAmount int) ([]ctype.RangeHash, error)
 	Save(cms []ctype.Material) error
 	Take(fileID string, sliceID string, nodeID []byte) (ctype.RangeHash, error)
+	Remove(fileID string) error
 
 	GetChallengeConf() (string, types.PairingChallengeConf)
 	Close()
@@ -71,6 +77,7 @@ type Challenger interface {
 
 // Copier selects Storage Nodes randomly from healthy candidates.
 //  You can call Push() to push slices onto Storage Node, and Pull() to pull slices from Storage Node.
+//  Delete() removes slices of aborted uploads from Storage Node.
 //  If you want more Storage Nodes, you can call ReplicaExpansion(),
 //  and it pulls slices from original nodes and decrypts and re-encrypts those slices,
 //  then push them onto new Storage Nodes.
@@ -78,6 +85,7 @@ type Copier interface {
 	Select(slice slicer.Slice, nodes blockchain.NodeHs, opt *copier.SelectOptions) (copier.LocatedSlice, error)
 	Push(ctx context.Context, id, sourceID string, r io.Reader, node *blockchain.Node) (string, error)
 	Pull(ctx context.Context, id, storIndex, fileID string, node *blockchain.Node) (io.ReadCloser, error)
+	Delete(ctx context.Context, id, storIndex string, node *blockchain.Node) error
 	ReplicaExpansion(ctx context.Context, opt *copier.ReplicaExpOptions, enc common.CommonEncryptor,
 		challengeAlgorithm, sourceID, fileID string) ([]blockchain.PublicSliceMeta, []encryptor.EncryptedSlice, error)
 }
@@ -93,17 +101,27 @@ type Blockchain interface {
 	GetNode(id []byte) (blockchain.Node, error)
 	NodeOffline(opt *blockchain.NodeOperateOptions) error
 	NodeOnline(opt *blockchain.NodeOperateOptions) error
+	NodeDrain(opt *blockchain.NodeOperateOptions) error
 	Heartbeat(opt *blockchain.NodeHeartBeatOptions) error
 	GetHeartbeatNum(id []byte, timestamp int64) (int, error)
 	GetNodeHealth(id []byte) (string, error)
+	GetNodeReputation(opt *blockchain.GetNodeReputationOptions) (blockchain.NodeReputation, error)
+	ListNodeReputations() ([]blockchain.NodeReputation, error)
 	ListNodesExpireSlice(opt *blockchain.ListNodeSliceOptions) ([][2]string, error)
+	ListNodesDeletedSlice(opt *blockchain.ListNodeSliceOptions) ([][2]string, error)
 	GetSliceMigrateRecords(opt *blockchain.NodeSliceMigrateOptions) (string, error)
+	ListNodeSliceFiles(opt *blockchain.ListNodeSliceOptions) ([]string, error)
+	ReportSliceScrub(opt *blockchain.ReportSliceScrubOptions) error
+	ListSliceScrubReports(opt *blockchain.ListSliceScrubReportsOptions) ([]blockchain.SliceScrubReport, error)
 
 	// The following contract methods are used by dataOwner node
 	PublishFile(file *blockchain.PublishFileOptions) error
 	GetFileByName(owner []byte, ns, name string) (blockchain.File, error)
+	GetFileByVersion(owner []byte, ns, name string, version int) (blockchain.File, error)
+	ListFileVersions(opt *blockchain.ListFileVersionsOptions) ([]blockchain.File, error)
 	GetFileByID(id string) (blockchain.File, error)
 	UpdateFileExpireTime(opt *blockchain.UpdateExptimeOptions) (blockchain.File, error)
+	DeleteFile(opt *blockchain.DeleteFileOptions) error
 	AddFileNs(opt *blockchain.AddNsOptions) error
 	UpdateNsReplica(opt *blockchain.UpdateNsReplicaOptions) error
 	UpdateFilePublicSliceMeta(opt *blockchain.UpdateFilePSMOptions) error
@@ -112,15 +130,21 @@ type Blockchain interface {
 	ListFileNs(opt *blockchain.ListNsOptions) ([]blockchain.Namespace, error)
 	ListFiles(opt *blockchain.ListFileOptions) ([]blockchain.File, error)
 	ListExpiredFiles(opt *blockchain.ListFileOptions) ([]blockchain.File, error)
+	GetSliceRef(opt *blockchain.GetSliceRefOptions) (blockchain.SliceRef, error)
+	RecordFileDownload(opt *blockchain.RecordFileDownloadOptions) error
+	ListFileAuditEvents(opt *blockchain.ListFileAuditEventsOptions) ([]blockchain.FileAuditEvent, error)
 	// The following contract methods used for authorizers to operate the file authorization application
 	GetAuthApplicationByID(authID string) (blockchain.FileAuthApplication, error)
 	ListFileAuthApplications(opt *blockchain.ListFileAuthOptions) (blockchain.FileAuthApplications, error)
 	ConfirmFileAuthApplication(opt *blockchain.ConfirmFileAuthOptions) error
 	RejectFileAuthApplication(opt *blockchain.ConfirmFileAuthOptions) error
+	RevokeFileAuth(opt *blockchain.RevokeFileAuthOptions) error
 
 	ListChallengeRequests(opt *blockchain.ListChallengeOptions) ([]blockchain.Challenge, error)
 	ChallengeRequest(opt *blockchain.ChallengeRequestOptions) error
 	ChallengeAnswer(opt *blockchain.ChallengeAnswerOptions) ([]byte, error)
+	ChallengeTimeout(opt *blockchain.ChallengeTimeoutOptions) error
+	HeartbeatTimeout(opt *blockchain.HeartbeatTimeoutOptions) error
 	GetChallengeByID(id string) (blockchain.Challenge, error)
 }
 
@@ -131,6 +155,7 @@ type SliceStorage interface {
 	Exist(key string, index string) (bool, error)
 	Delete(key string, index string) error
 	LoadStr(key string, index string) (string, error)
+	Usage() (uint64, error)
 }
 
 // ProveStorage is local storage
@@ -154,6 +179,16 @@ type Engine struct {
 	sliceStorage SliceStorage
 
 	monitor *Monitor
+
+	sessionLocks     sync.Map      // locks of resumable upload sessions, by session id
+	sessionIndexLock sync.Mutex    // lock of the index of upload sessions
+	sessionTTL       time.Duration // upload sessions not updated within it are aborted, 0 means never
+
+	rekeyLock   sync.Mutex
+	rekeyJob    *types.RekeyJob // the latest job re-encrypting slices under the current password
+	rekeyCancel context.CancelFunc
+
+	downloads *common.DownloadRecorder // records reads of files read through on blockchain
 }
 
 // NewEngineOption contains parameters for initiating Engine
@@ -167,6 +202,9 @@ type NewEngineOption struct {
 	Copier     Copier
 	ProveStor  ProveStorage
 	SliceStor  SliceStorage
+
+	AnswerDeadline time.Duration // storage nodes must answer challenges within it, 0 means no deadline
+	SessionTTL     time.Duration // upload sessions not updated within it are aborted, 0 means never
 }
 
 // NewEngine initiates Engine by the node's configuration file
@@ -184,16 +222,22 @@ func NewEngine(conf *config.MonitorConf, opt *NewEngineOption) (*Engine, error)
 		proveStorage: opt.ProveStor,
 		sliceStorage: opt.SliceStor,
 		monitor:      monitor,
+		sessionTTL:   opt.SessionTTL,
+		downloads:    common.NewDownloadRecorder(),
 	}
 	return e, nil
 }
 
 // Start starts Engine
 func (e *Engine) Start(ctx context.Context) error {
+	if e.proveStorage != nil && e.sessionTTL > 0 {
+		go e.cleanUploadSessions(ctx)
+	}
 	return e.monitor.Start(ctx)
 }
 
 func (e *Engine) Close() {
+	e.stopRekey()
 	if e.challenger != nil {
 		e.challenger.Close()
 	}
@@ -0,0 +1,310 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/ecdsa"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/hash"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain/local"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/config"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/challenger/merkle"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/copier/random"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/encryptor/soft"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/slicer/simple"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/types"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/peer"
+	util "github.com/PaddlePaddle/PaddleDTX/xdb/pkgs/strings"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/storage"
+	localstorage "github.com/PaddlePaddle/PaddleDTX/xdb/storage/local"
+)
+
+// testBlockSize is small so that test files are cut into several slices
+const testBlockSize = 64
+
+// testChain is the local blockchain whose storage nodes are always healthy, as heartbeats are not sent in tests
+type testChain struct {
+	*local.Local
+}
+
+func (c *testChain) GetNodeHealth(id []byte) (string, error) {
+	return blockchain.NodeHealthGood, nil
+}
+
+// memCopier keeps slices pushed onto storage nodes in memory
+type memCopier struct {
+	*random.RandomCopier
+
+	lock   sync.Mutex
+	slices map[string][]byte // by node ID and key of the slice
+	pulls  int               // number of slices pulled
+}
+
+func newMemCopier(privkey ecdsa.PrivateKey) *memCopier {
+	return &memCopier{
+		RandomCopier: random.New(privkey),
+		slices:       make(map[string][]byte),
+	}
+}
+
+// storage returns the slice storage of the node, which works like storage on storage nodes
+func (c *memCopier) storage(node *blockchain.Node) storage.Storage {
+	return storage.NewStorage(&memNodeStorage{slices: c.slices, prefix: string(node.ID) + "/"})
+}
+
+func (c *memCopier) Push(ctx context.Context, id, sourceID string, r io.Reader, node *blockchain.Node) (string, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.storage(node).Save(id, r)
+}
+
+func (c *memCopier) Pull(ctx context.Context, id, storIndex, fileID string, node *blockchain.Node) (io.ReadCloser, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	rc, err := c.storage(node).Load(id, storIndex)
+	if err != nil {
+		return nil, errorx.Wrap(err, "slice %s not found on node %s", id, node.Name)
+	}
+	c.pulls++
+	return rc, nil
+}
+
+func (c *memCopier) Delete(ctx context.Context, id, storIndex string, node *blockchain.Node) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.storage(node).Delete(id, storIndex)
+}
+
+// memNodeStorage keeps slices of a node in memCopier, the index of a slice is its key
+type memNodeStorage struct {
+	slices map[string][]byte
+	prefix string
+}
+
+func (s *memNodeStorage) Save(key string, value io.Reader) (string, error) {
+	data, err := ioutil.ReadAll(value)
+	if err != nil {
+		return "", err
+	}
+	s.slices[s.prefix+key] = data
+	return key, nil
+}
+
+func (s *memNodeStorage) Load(key string, index string) (io.ReadCloser, error) {
+	data, ok := s.slices[s.prefix+key]
+	if !ok {
+		return nil, errorx.New(errorx.ErrCodeNotFound, "slice not found")
+	}
+	return ioutil.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *memNodeStorage) Exist(key string, index string) (bool, error) {
+	_, ok := s.slices[s.prefix+key]
+	return ok, nil
+}
+
+func (s *memNodeStorage) Delete(key string, index string) error {
+	delete(s.slices, s.prefix+key)
+	return nil
+}
+
+func (s *memNodeStorage) Update(key string, index string, value io.Reader) (string, error) {
+	return s.Save(key, value)
+}
+
+// testEngine is an Engine of a dataOwner node on the local blockchain, with storage nodes in memory
+type testEngine struct {
+	*Engine
+
+	chain  *testChain
+	copier *memCopier
+	dir    string
+
+	privkey ecdsa.PrivateKey
+	pubkey  ecdsa.PublicKey
+	nodes   []ecdsa.PrivateKey // private keys of storage nodes
+}
+
+func newTestEngine(t *testing.T, nodeNum int) *testEngine {
+	dir := t.TempDir()
+	l, err := local.New(&config.LocalChainConf{Path: filepath.Join(dir, "chain")})
+	require.NoError(t, err)
+	privkey, pubkey, err := ecdsa.GenerateKeyPair()
+	require.NoError(t, err)
+
+	te := &testEngine{
+		chain:   &testChain{Local: l},
+		copier:  newMemCopier(privkey),
+		dir:     dir,
+		privkey: privkey,
+		pubkey:  pubkey,
+	}
+	for i := 0; i < nodeNum; i++ {
+		te.addNode(t, fmt.Sprintf("storage%d", i))
+	}
+
+	enc, err := soft.New(&config.SoftEncryptorConf{
+		Password:    "test password",
+		FileKeyPath: filepath.Join(dir, "keys"),
+	}, nil)
+	require.NoError(t, err)
+	sl, err := simple.New(&config.SimpleSlicerConf{BlockSize: testBlockSize})
+	require.NoError(t, err)
+	challenger, err := merkle.New(&config.ChallengerMerkleConf{LeveldbRoot: filepath.Join(dir, "challenger")}, privkey)
+	require.NoError(t, err)
+	proveStor, err := localstorage.New(filepath.Join(dir, "prove"))
+	require.NoError(t, err)
+
+	e, err := NewEngine(&config.MonitorConf{ChallengingSwitch: "on"}, &NewEngineOption{
+		LocalNode:  peer.Local{ID: pubkey[:], PrivateKey: privkey},
+		Slicer:     sl,
+		Encryptor:  enc,
+		Challenger: challenger,
+		Chain:      te.chain,
+		Copier:     te.copier,
+		ProveStor:  proveStor,
+	})
+	require.NoError(t, err)
+	t.Cleanup(e.Close)
+	te.Engine = e
+	return te
+}
+
+// addNode registers a storage node on blockchain
+func (te *testEngine) addNode(t *testing.T, name string) {
+	privkey, pubkey, err := ecdsa.GenerateKeyPair()
+	require.NoError(t, err)
+	now := time.Now().UnixNano()
+	opt := blockchain.AddNodeOptions{
+		Node: blockchain.Node{
+			ID:       []byte(pubkey.String()),
+			Name:     name,
+			Address:  name,
+			Online:   true,
+			RegTime:  now,
+			UpdateAt: now,
+		},
+	}
+	opt.Signature = sign(t, privkey, opt)
+	require.NoError(t, te.chain.AddNode(&opt))
+	te.nodes = append(te.nodes, privkey)
+}
+
+// token signs the request as the dataOwner node
+func (te *testEngine) token(t *testing.T, opt interface{}) string {
+	sig := signature(t, te.privkey, opt)
+	return sig.String()
+}
+
+// addNs adds a namespace of the dataOwner node
+func (te *testEngine) addNs(t *testing.T, name string, replica int) {
+	opt := types.AddNsOptions{
+		Namespace:  name,
+		Replica:    replica,
+		CreateTime: time.Now().UnixNano(),
+		User:       te.pubkey.String(),
+	}
+	opt.Token = te.token(t, opt)
+	require.NoError(t, te.AddFileNs(opt))
+}
+
+// write uploads a file and returns its ID
+func (te *testEngine) write(t *testing.T, ns, name string, content []byte) string {
+	opt := types.WriteOptions{
+		User:       te.pubkey.String(),
+		Namespace:  ns,
+		FileName:   name,
+		ExpireTime: time.Now().Add(time.Hour).UnixNano(),
+	}
+	opt.Token = te.token(t, opt)
+	resp, err := te.Write(context.Background(), opt, bytes.NewReader(content))
+	require.NoError(t, err)
+	return resp.FileID
+}
+
+// read reads a range of the file, length 0 means reading to the end of the file
+func (te *testEngine) read(t *testing.T, fileID string, offset, length uint64) ([]byte, error) {
+	opt := types.ReadOptions{
+		User:      te.pubkey.String(),
+		Timestamp: time.Now().UnixNano(),
+		FileID:    fileID,
+	}
+	opt.Token = te.token(t, opt)
+	opt.Offset = offset
+	opt.Length = length
+	r, err := te.Read(context.Background(), opt)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+// publishAuth publishes a file authorization application of the applier and returns its ID
+func (te *testEngine) publishAuth(t *testing.T, applier ecdsa.PrivateKey, fileID string) string {
+	pubkey := ecdsa.PublicKeyFromPrivateKey(applier)
+	opt := blockchain.PublishFileAuthOptions{
+		FileAuthApplication: blockchain.FileAuthApplication{
+			ID:         fmt.Sprintf("auth-%d", time.Now().UnixNano()),
+			FileID:     fileID,
+			Name:       "test",
+			Applier:    pubkey[:],
+			Authorizer: te.pubkey[:],
+			CreateTime: time.Now().UnixNano(),
+		},
+	}
+	opt.Signature = sign(t, applier, opt)
+	require.NoError(t, te.chain.PublishFileAuthApplication(&opt))
+	return opt.FileAuthApplication.ID
+}
+
+// confirmAuth approves the file authorization application
+func (te *testEngine) confirmAuth(t *testing.T, authID string) {
+	opt := types.ConfirmAuthOptions{
+		User:       te.pubkey.String(),
+		AuthID:     authID,
+		ExpireTime: time.Now().Add(time.Minute).UnixNano(),
+		Status:     true,
+	}
+	opt.Token = te.token(t, opt)
+	require.NoError(t, te.ConfirmAuth(opt))
+}
+
+// sign signs the options for blockchain
+func sign(t *testing.T, privkey ecdsa.PrivateKey, opt interface{}) []byte {
+	sig := signature(t, privkey, opt)
+	return sig[:]
+}
+
+func signature(t *testing.T, privkey ecdsa.PrivateKey, opt interface{}) ecdsa.Signature {
+	msg, err := util.GetSigMessage(opt)
+	require.NoError(t, err)
+	sig, err := ecdsa.Sign(privkey, hash.HashUsingSha256([]byte(msg)))
+	require.NoError(t, err)
+	return sig
+}
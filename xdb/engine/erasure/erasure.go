@@ -0,0 +1,171 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package erasure
+
+import (
+	"sort"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/hash"
+	"github.com/google/uuid"
+	"github.com/klauspost/reedsolomon"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/slicer"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+)
+
+// maxShards is the maximum number of slices in a stripe supported by Reed-Solomon code over GF(2^8)
+const maxShards = 256
+
+// Stripe is a group of data slices and the parity slices generated from them,
+// the original data slices can be rebuilt from any len(Data) slices of the stripe
+type Stripe struct {
+	Index  int
+	Data   []blockchain.PrivateSliceMeta
+	Parity []blockchain.PrivateSliceMeta
+}
+
+// Shards returns all slices of the stripe, data slices first
+func (s Stripe) Shards() []blockchain.PrivateSliceMeta {
+	shards := make([]blockchain.PrivateSliceMeta, 0, len(s.Data)+len(s.Parity))
+	shards = append(shards, s.Data...)
+	return append(shards, s.Parity...)
+}
+
+// CheckShards checks if the numbers of data slices and parity slices are valid
+func CheckShards(dataShards, parityShards int) error {
+	if dataShards <= 0 || parityShards <= 0 {
+		return errorx.New(errorx.ErrCodeParam, "invalid param, dataShards and parityShards must be greater than 0")
+	}
+	if dataShards+parityShards > maxShards {
+		return errorx.New(errorx.ErrCodeParam, "invalid param, dataShards plus parityShards must be no greater than %d", maxShards)
+	}
+	return nil
+}
+
+// EncodeStripe generates parity slices for data slices of the stripe,
+// data slices must have the same length, which is guaranteed by slicer.
+// Returns data slices followed by parity slices, all of them are marked with the stripe index
+func EncodeStripe(data []slicer.Slice, stripe, parityShards int) ([]slicer.Slice, error) {
+	if len(data) == 0 {
+		return nil, errorx.New(errorx.ErrCodeParam, "empty stripe")
+	}
+	shards := make([][]byte, len(data), len(data)+parityShards)
+	for i, s := range data {
+		shards[i] = s.Data
+	}
+	parity, err := Encode(shards, parityShards)
+	if err != nil {
+		return nil, err
+	}
+
+	slices := make([]slicer.Slice, 0, len(data)+parityShards)
+	for _, s := range data {
+		s.Stripe = stripe
+		slices = append(slices, s)
+	}
+	for _, p := range parity {
+		id, err := uuid.NewRandom()
+		if err != nil {
+			return nil, errorx.Internal(err, "failed to get uuid")
+		}
+		slices = append(slices, slicer.Slice{
+			SliceMeta: slicer.SliceMeta{
+				ID:     id.String(),
+				Hash:   hash.HashUsingSha256(p),
+				Length: uint64(len(p)),
+				Stripe: stripe,
+				Parity: true,
+			},
+			Data: p,
+		})
+	}
+	return slices, nil
+}
+
+// Encode computes parityShards parity shards for data shards
+func Encode(data [][]byte, parityShards int) ([][]byte, error) {
+	if err := CheckShards(len(data), parityShards); err != nil {
+		return nil, err
+	}
+	enc, err := reedsolomon.New(len(data), parityShards)
+	if err != nil {
+		return nil, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to create Reed-Solomon encoder")
+	}
+
+	shards := make([][]byte, len(data)+parityShards)
+	copy(shards, data)
+	for i := len(data); i < len(shards); i++ {
+		shards[i] = make([]byte, len(data[0]))
+	}
+	if err := enc.Encode(shards); err != nil {
+		return nil, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to encode stripe")
+	}
+	return shards[len(data):], nil
+}
+
+// Reconstruct rebuilds missing shards in place, missing shards must be nil,
+// at least dataShards shards must be present
+func Reconstruct(shards [][]byte, dataShards int) error {
+	if err := CheckShards(dataShards, len(shards)-dataShards); err != nil {
+		return err
+	}
+	enc, err := reedsolomon.New(dataShards, len(shards)-dataShards)
+	if err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to create Reed-Solomon encoder")
+	}
+	if err := enc.Reconstruct(shards); err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to reconstruct stripe")
+	}
+	return nil
+}
+
+// SplitStripes groups the slices of an erasure coded file structure by stripe,
+// the order of data slices in each stripe and the order of stripes are kept
+func SplitStripes(fs blockchain.FileStructure) []Stripe {
+	stripes := make(map[int]*Stripe)
+	for _, s := range fs {
+		st, ok := stripes[s.Stripe]
+		if !ok {
+			st = &Stripe{Index: s.Stripe}
+			stripes[s.Stripe] = st
+		}
+		if s.Parity {
+			st.Parity = append(st.Parity, s)
+		} else {
+			st.Data = append(st.Data, s)
+		}
+	}
+
+	ret := make([]Stripe, 0, len(stripes))
+	for _, st := range stripes {
+		ret = append(ret, *st)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Index < ret[j].Index
+	})
+	return ret
+}
+
+// FindStripe finds the stripe which contains the slice
+func FindStripe(stripes []Stripe, sliceID string) (Stripe, bool) {
+	for _, st := range stripes {
+		for _, s := range st.Shards() {
+			if s.SliceID == sliceID {
+				return st, true
+			}
+		}
+	}
+	return Stripe{}, false
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package erasure
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/slicer"
+)
+
+func TestCheckShards(t *testing.T) {
+	require.NoError(t, CheckShards(4, 2))
+	require.Error(t, CheckShards(0, 2))
+	require.Error(t, CheckShards(4, 0))
+	require.Error(t, CheckShards(200, 57))
+}
+
+func TestEncodeAndReconstruct(t *testing.T) {
+	data := make([][]byte, 4)
+	for i := range data {
+		data[i] = make([]byte, 64)
+		_, err := rand.Read(data[i])
+		require.NoError(t, err)
+	}
+	parity, err := Encode(data, 2)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(parity))
+
+	// lose any two shards
+	shards := append(append([][]byte{}, data...), parity...)
+	shards[0] = nil
+	shards[5] = nil
+	require.NoError(t, Reconstruct(shards, 4))
+	for i := range data {
+		require.Equal(t, data[i], shards[i])
+	}
+	require.Equal(t, parity[1], shards[5])
+
+	// lose too many shards
+	shards[0], shards[1], shards[2] = nil, nil, nil
+	require.Error(t, Reconstruct(shards, 4))
+}
+
+func TestEncodeStripe(t *testing.T) {
+	var data []slicer.Slice
+	for i := 0; i < 3; i++ {
+		bs := make([]byte, 16)
+		_, err := rand.Read(bs)
+		require.NoError(t, err)
+		data = append(data, slicer.Slice{
+			SliceMeta: slicer.SliceMeta{ID: string(rune('a' + i)), Length: 16},
+			Data:      bs,
+		})
+	}
+
+	slices, err := EncodeStripe(data, 7, 2)
+	require.NoError(t, err)
+	require.Equal(t, 5, len(slices))
+	for i, s := range slices {
+		require.Equal(t, 7, s.Stripe)
+		require.Equal(t, i >= 3, s.Parity)
+		require.Equal(t, uint64(16), s.Length)
+	}
+}
+
+func TestSplitStripes(t *testing.T) {
+	fs := blockchain.FileStructure{
+		{SliceID: "d0", Stripe: 0},
+		{SliceID: "d1", Stripe: 0},
+		{SliceID: "p0", Stripe: 0, Parity: true},
+		{SliceID: "d2", Stripe: 1},
+		{SliceID: "p1", Stripe: 1, Parity: true},
+	}
+	stripes := SplitStripes(fs)
+	require.Equal(t, 2, len(stripes))
+	require.Equal(t, 2, len(stripes[0].Data))
+	require.Equal(t, 1, len(stripes[0].Parity))
+	require.Equal(t, "d2", stripes[1].Data[0].SliceID)
+	require.Equal(t, "p1", stripes[1].Shards()[1].SliceID)
+
+	st, ok := FindStripe(stripes, "p0")
+	require.True(t, ok)
+	require.Equal(t, 0, st.Index)
+	_, ok = FindStripe(stripes, "x")
+	require.False(t, ok)
+}
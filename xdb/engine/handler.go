@@ -17,15 +17,20 @@ import (
 	"bytes"
 	"encoding/hex"
 	"io"
+	"math"
+	"strings"
 	"time"
 
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/ecdsa"
 	"github.com/PaddlePaddle/PaddleDTX/crypto/core/hash"
 	"github.com/sirupsen/logrus"
 
 	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/common"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/types"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
 	util "github.com/PaddlePaddle/PaddleDTX/xdb/pkgs/strings"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/storage"
 )
 
 // Push receive slices from dataOwner nodes
@@ -53,6 +58,13 @@ func (e *Engine) Push(opt types.PushOptions, r io.Reader) (
 			logger.WithError(err).Errorf("push %s", opt.SliceID)
 			return resp, errorx.Wrap(err, "failed to save slice")
 		}
+		// record who pushed the slice, so that the slice can be removed by its source before it is published
+		sourceKey := common.GetSliceSourceKey(opt.SliceID)
+		if exist, _ := e.proveStorage.Exist(sourceKey); !exist && len(opt.SourceID) > 0 {
+			if err := e.proveStorage.SaveAndUpdate(sourceKey, strings.NewReader(opt.SourceID)); err != nil {
+				return resp, errorx.Wrap(err, "failed to save slice source")
+			}
+		}
 	}
 
 	logger.WithFields(logrus.Fields{
@@ -119,6 +131,88 @@ func (e *Engine) Pull(opt types.PullOptions) (io.ReadCloser, error) {
 	return rc, nil
 }
 
+// DeleteSlice removes a slice and its challenge material from local storage,
+// the request must be signed by the dataOwner node who pushed the slice, and valid for five minutes.
+// Only slices no live file on chain refers to can be removed, such as slices of aborted uploads
+// and copies replaced by re-encryption, the others are cleared when their files expire or are deleted
+func (e *Engine) DeleteSlice(opt types.DeleteSliceOptions) error {
+	var requestExpiredTime time.Duration = 5 * time.Minute
+	if opt.Timestamp < (time.Now().UnixNano() - requestExpiredTime.Nanoseconds()) {
+		return errorx.New(errorx.ErrCodeParam, "request has expired")
+	}
+	// copies of a slice kept on the node have their own sources and challenge materials
+	key := storage.KeyOf(opt.SliceID, opt.StorIndex)
+	sourceKey := common.GetSliceSourceKey(key)
+	source, err := e.proveStorage.LoadStr(sourceKey)
+	if err != nil {
+		return errorx.Wrap(err, "failed to load slice source")
+	}
+	if source != opt.SourceID {
+		return errorx.New(errorx.ErrCodeNotAuthorized, "the slice is not pushed by %s", opt.SourceID)
+	}
+	// Verify Signature
+	msg, err := util.GetSigMessage(opt)
+	if err != nil {
+		return errorx.Internal(err, "failed to get the message to sign for delete slice")
+	}
+	if err := verifyUserToken(opt.SourceID, opt.Signature, hash.HashUsingSha256([]byte(msg))); err != nil {
+		return errorx.Wrap(err, "failed to verify slice delete token")
+	}
+	if err := e.checkSliceUnreferenced(opt.SliceID, opt.StorIndex); err != nil {
+		return err
+	}
+
+	if exist, _ := e.sliceStorage.Exist(opt.SliceID, opt.StorIndex); exist {
+		if err := e.sliceStorage.Delete(opt.SliceID, opt.StorIndex); err != nil {
+			return errorx.Wrap(err, "failed to delete slice")
+		}
+	}
+	// delete pairing based challenge material if exists
+	if exist, _ := e.proveStorage.Exist(key); exist {
+		if _, err := e.proveStorage.Delete(key); err != nil {
+			return errorx.Wrap(err, "failed to delete slice sigmas")
+		}
+	}
+	if _, err := e.proveStorage.Delete(sourceKey); err != nil {
+		return errorx.Wrap(err, "failed to delete slice source")
+	}
+
+	logger.WithFields(logrus.Fields{
+		"slice_id": opt.SliceID,
+		"from":     opt.SourceID,
+	}).Debug("slice deleted")
+	return nil
+}
+
+// checkSliceUnreferenced checks that no unexpired and undeleted file on chain refers to the slice stored on local node
+func (e *Engine) checkSliceUnreferenced(sliceID, storIndex string) error {
+	pubkey := ecdsa.PublicKeyFromPrivateKey(e.monitor.challengingMonitor.PrivateKey)
+	nodeID := []byte(pubkey.String())
+	fileIDs, err := e.chain.ListNodeSliceFiles(&blockchain.ListNodeSliceOptions{
+		Target:    nodeID,
+		StartTime: time.Now().UnixNano(),
+		EndTime:   math.MaxInt64,
+	})
+	if err != nil {
+		return errorx.Wrap(err, "failed to list files of node slices")
+	}
+	for _, fileID := range fileIDs {
+		file, err := e.chain.GetFileByID(fileID)
+		if err != nil {
+			if errorx.Is(err, errorx.ErrCodeNotFound) || errorx.Is(err, errorx.ErrCodeExpired) {
+				continue
+			}
+			return errorx.Wrap(err, "failed to get file from blockchain")
+		}
+		for _, slice := range file.Slices {
+			if slice.ID == sliceID && slice.StorIndex == storIndex && bytes.Equal(slice.NodeID, nodeID) {
+				return errorx.New(errorx.ErrCodeNotAuthorized, "the slice is referred by file %s", fileID)
+			}
+		}
+	}
+	return nil
+}
+
 // checkApplierFileAuth used to check applier's file authorization application
 // In addition to allowing file owners to download slice, authorized appliers can also download
 func (e *Engine) checkApplierFileAuth(applier, authorizer []byte, fileID string) error {
@@ -0,0 +1,146 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"context"
+	"encoding/hex"
+	"time"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/ecdsa"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/hash"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/types"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+	util "github.com/PaddlePaddle/PaddleDTX/xdb/pkgs/strings"
+)
+
+// GetFileAuditReport makes the audit trail of a file owned by the node, covering publishing,
+// slice changes, migrations, challenges, authorizations and downloads, and signs it by the node's private key
+func (e *Engine) GetFileAuditReport(ctx context.Context, id string) (report types.FileAuditReport, err error) {
+	// deleted and expired files are not returned, but they are still audited
+	f, err := e.chain.GetFileByID(id)
+	if err != nil && !errorx.Is(err, errorx.ErrCodeNotFound) && !errorx.Is(err, errorx.ErrCodeExpired) {
+		return report, errorx.Wrap(err, "failed to read blockchain")
+	}
+	events, err := e.listFileAuditEvents(id)
+	if err != nil {
+		if errorx.Is(err, errorx.ErrCodeNotFound) {
+			return report, errorx.New(errorx.ErrCodeNotFound, "file not found")
+		}
+		return report, err
+	}
+	owner := hex.EncodeToString(f.Owner)
+	if f.ID == "" {
+		f.ID = id
+		owner = getFileAuditOwner(events)
+	}
+	pubkey := ecdsa.PublicKeyFromPrivateKey(e.monitor.challengingMonitor.PrivateKey)
+	if owner != pubkey.String() {
+		return report, errorx.New(errorx.ErrCodeNotAuthorized, "file is not owned by the node")
+	}
+
+	report = types.FileAuditReport{
+		File:         f,
+		Events:       events,
+		GenerateTime: time.Now().UnixNano(),
+		Signer:       pubkey.String(),
+	}
+	for _, ev := range events {
+		switch ev.Type {
+		case blockchain.FileAuditChallengeProved:
+			report.ProvedNum++
+		case blockchain.FileAuditChallengeFailed:
+			report.FailedNum++
+		}
+	}
+
+	msg, err := util.GetSigMessage(report)
+	if err != nil {
+		return report, errorx.Internal(err, "failed to get the message to sign")
+	}
+	sig, err := ecdsa.Sign(e.monitor.challengingMonitor.PrivateKey, hash.HashUsingSha256([]byte(msg)))
+	if err != nil {
+		return report, errorx.NewCode(err, errorx.ErrCodeCrypto, "failed to sign audit report")
+	}
+	report.Signature = sig.String()
+	return report, nil
+}
+
+// listFileAuditEvents lists all events in the audit trail of a file page by page
+func (e *Engine) listFileAuditEvents(fileID string) ([]blockchain.FileAuditEvent, error) {
+	var events []blockchain.FileAuditEvent
+	seen := make(map[string]bool)
+	opt := &blockchain.ListFileAuditEventsOptions{
+		FileID: fileID,
+		Limit:  blockchain.ListMaxNumber,
+	}
+	for {
+		page, err := e.chain.ListFileAuditEvents(opt)
+		if err != nil {
+			return nil, errorx.Wrap(err, "failed to list file audit events")
+		}
+		var added int
+		for _, ev := range page {
+			if seen[ev.ID] {
+				continue
+			}
+			seen[ev.ID] = true
+			events = append(events, ev)
+			added++
+		}
+		// the next page starts from the time of the last event, events already got are skipped
+		if len(page) < int(opt.Limit) || added == 0 {
+			break
+		}
+		opt.StartTime = page[len(page)-1].Time
+	}
+	return events, nil
+}
+
+// getFileAuditOwner gets the owner of a file from its publishing event
+func getFileAuditOwner(events []blockchain.FileAuditEvent) string {
+	for _, ev := range events {
+		if ev.Type == blockchain.FileAuditPublished {
+			return ev.Operator
+		}
+	}
+	return ""
+}
+
+// recordFileDownload records a download of the file by the node in its audit trail, failures are only logged
+func (e *Engine) recordFileDownload(f blockchain.File) {
+	pubkey := ecdsa.PublicKeyFromPrivateKey(e.monitor.challengingMonitor.PrivateKey)
+	opt := &blockchain.RecordFileDownloadOptions{
+		FileID:      f.ID,
+		User:        pubkey[:],
+		CurrentTime: time.Now().UnixNano(),
+	}
+	l := logger.WithField("file_id", f.ID)
+	msg, err := util.GetSigMessage(opt)
+	if err != nil {
+		l.WithError(err).Warn("failed to get the message to sign for download record")
+		return
+	}
+	sig, err := ecdsa.Sign(e.monitor.challengingMonitor.PrivateKey, hash.HashUsingSha256([]byte(msg)))
+	if err != nil {
+		l.WithError(err).Warn("failed to sign download record")
+		return
+	}
+	opt.Signature = sig[:]
+	if err := e.chain.RecordFileDownload(opt); err != nil {
+		l.WithError(err).Warn("failed to record file download on blockchain")
+	}
+}
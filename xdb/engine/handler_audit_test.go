@@ -0,0 +1,161 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/ecdsa"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/hash"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+	util "github.com/PaddlePaddle/PaddleDTX/xdb/pkgs/strings"
+)
+
+// recordDownload records a download of the file signed by the user
+func (te *testEngine) recordDownload(user ecdsa.PrivateKey, fileID, authID string, t int64) error {
+	pubkey := ecdsa.PublicKeyFromPrivateKey(user)
+	opt := blockchain.RecordFileDownloadOptions{
+		FileID:      fileID,
+		User:        pubkey[:],
+		AuthID:      authID,
+		CurrentTime: t,
+	}
+	msg, err := util.GetSigMessage(opt)
+	if err != nil {
+		return err
+	}
+	sig, err := ecdsa.Sign(user, hash.HashUsingSha256([]byte(msg)))
+	if err != nil {
+		return err
+	}
+	opt.Signature = sig[:]
+	return te.chain.RecordFileDownload(&opt)
+}
+
+// auditEvents returns events of the type in the audit trail of the file
+func auditEvents(report []blockchain.FileAuditEvent, eventType string) []blockchain.FileAuditEvent {
+	var events []blockchain.FileAuditEvent
+	for _, ev := range report {
+		if ev.Type == eventType {
+			events = append(events, ev)
+		}
+	}
+	return events
+}
+
+func TestFileAuditReport(t *testing.T) {
+	te := newTestEngine(t, 3)
+	te.addNs(t, "ns", 2)
+	id := te.write(t, "ns", "file", testContent(300))
+
+	// reads failed or not reaching the end of the file are not recorded
+	te.setPassword(t, nil)
+	_, err := te.read(t, id, 0, 0)
+	require.Error(t, err)
+	te.rotatePassword(t)
+	_, err = te.read(t, id, 100, 10)
+	require.NoError(t, err)
+	// reads of the owner are recorded once the file is read through, reading it again is the same download
+	_, err = te.read(t, id, 0, 0)
+	require.NoError(t, err)
+	_, err = te.read(t, id, 200, 0)
+	require.NoError(t, err)
+
+	// downloads of appliers are recorded only with an approved authorization
+	applier, applierPub, err := ecdsa.GenerateKeyPair()
+	require.NoError(t, err)
+	authID := te.publishAuth(t, applier, id)
+	require.True(t, errorx.Is(te.recordDownload(applier, id, authID, time.Now().UnixNano()), errorx.ErrCodeNotAuthorized))
+	require.Error(t, te.recordDownload(applier, id, "", time.Now().UnixNano()))
+	te.confirmAuth(t, authID)
+	require.NoError(t, te.recordDownload(applier, id, authID, time.Now().UnixNano()))
+	require.True(t, errorx.Is(te.recordDownload(applier, id, authID, time.Now().Add(time.Hour).UnixNano()),
+		errorx.ErrCodeNotAuthorized))
+	// nor are downloads signed by others on behalf of the owner
+	other, _, err := ecdsa.GenerateKeyPair()
+	require.NoError(t, err)
+	opt := blockchain.RecordFileDownloadOptions{FileID: id, User: te.pubkey[:], CurrentTime: time.Now().UnixNano()}
+	opt.Signature = sign(t, other, opt)
+	require.Error(t, te.chain.RecordFileDownload(&opt))
+
+	var report []blockchain.FileAuditEvent
+	require.Eventually(t, func() bool {
+		r, err := te.GetFileAuditReport(context.Background(), id)
+		require.NoError(t, err)
+		report = r.Events
+		return len(auditEvents(report, blockchain.FileAuditDownloaded)) >= 2
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Len(t, auditEvents(report, blockchain.FileAuditDownloaded), 2)
+	operators := make(map[string]string)
+	for _, ev := range auditEvents(report, blockchain.FileAuditDownloaded) {
+		operators[ev.Operator] = ev.Ref
+	}
+	require.Equal(t, map[string]string{te.pubkey.String(): "", applierPub.String(): authID}, operators)
+	require.Len(t, auditEvents(report, blockchain.FileAuditPublished), 1)
+	require.Len(t, auditEvents(report, blockchain.FileAuditAuthApproved), 1)
+
+	// the report is signed by the node
+	r, err := te.GetFileAuditReport(context.Background(), id)
+	require.NoError(t, err)
+	require.Equal(t, id, r.File.ID)
+	require.Equal(t, te.pubkey.String(), r.Signer)
+	sig, err := hex.DecodeString(r.Signature)
+	require.NoError(t, err)
+	r.Signature = ""
+	msg, err := util.GetSigMessage(r)
+	require.NoError(t, err)
+	var signature ecdsa.Signature
+	copy(signature[:], sig)
+	require.NoError(t, ecdsa.Verify(te.pubkey, hash.HashUsingSha256([]byte(msg)), signature))
+
+	_, err = te.GetFileAuditReport(context.Background(), "unknown")
+	require.True(t, errorx.Is(err, errorx.ErrCodeNotFound))
+}
+
+func TestFileAuditEventsPages(t *testing.T) {
+	te := newTestEngine(t, 3)
+	te.addNs(t, "ns", 2)
+	id := te.write(t, "ns", "file", testContent(100))
+
+	// events of the same time span the end of pages
+	num := 2*blockchain.ListMaxNumber + 10
+	start := time.Now().Add(time.Minute).UnixNano()
+	for i := 0; i < num; i++ {
+		at := start + int64(i)
+		if i >= blockchain.ListMaxNumber-3 && i < blockchain.ListMaxNumber+3 {
+			at = start + int64(blockchain.ListMaxNumber)
+		}
+		require.NoError(t, te.recordDownload(te.privkey, id, "", at))
+	}
+
+	events, err := te.listFileAuditEvents(id)
+	require.NoError(t, err)
+	downloads := auditEvents(events, blockchain.FileAuditDownloaded)
+	require.Len(t, downloads, num)
+	seen := make(map[string]bool)
+	for i, ev := range events {
+		require.False(t, seen[ev.ID])
+		seen[ev.ID] = true
+		if i > 0 {
+			require.True(t, events[i-1].ID < ev.ID)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"io"
+	"time"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/hash"
+	"github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/copier"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/encryptor"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/slicer"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/types"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+)
+
+// contentDefinedSlicer returns whether the slicer cuts data by content
+func (e *Engine) contentDefinedSlicer() bool {
+	cd, ok := e.slicer.(slicer.ContentDefined)
+	return ok && cd.ContentDefined()
+}
+
+// checkSlicer checks if the slicer can be used for files in the namespace,
+// slices of a stripe must have the same length, which content defined slicer can't guarantee
+func (e *Engine) checkSlicer(ns blockchain.Namespace) error {
+	if ns.DataShards > 0 && e.contentDefinedSlicer() {
+		return errorx.New(errorx.ErrCodeParam, "content defined slicer can't be used in erasure coded namespace")
+	}
+	return nil
+}
+
+// dedupEnabled returns whether slices of files in the namespace are deduplicated.
+// Only namespaces created with Dedup are deduplicated, as their files can't be shredded.
+// Pairing based challenge is excluded, as its materials depend on the index of slice in a file
+func (e *Engine) dedupEnabled(ns blockchain.Namespace) bool {
+	ca, _ := e.challenger.GetChallengeConf()
+	return ns.Dedup && e.contentDefinedSlicer() && ns.DataShards == 0 && ca == types.MerkleChallengeAlgorithm
+}
+
+// dedupSlice is a chunk of the file encrypted and pushed to storage nodes, or found stored before
+type dedupSlice struct {
+	meta        slicer.SliceMeta
+	encSlices   []encryptor.EncryptedSlice
+	storIndexes []string
+}
+
+// writeDedup uploads a file whose slices are deduplicated among files of the owner.
+// The file is cut into chunks by content, and each chunk is encrypted by a key derived from its hash,
+// so that identical chunks produce identical slices. A slice already stored and still referenced by
+// other files is referenced again instead of being pushed, and the blockchain counts its references.
+// Copies of slices are encrypted without fileID, so their ciphertext can be regenerated from the chunk.
+// No key of the file protects its slices, so destroying the file key doesn't shred the file
+func (e *Engine) writeDedup(ctx context.Context, opt types.WriteOptions, ns blockchain.Namespace,
+	nodes blockchain.NodeHs, fileID string, r io.Reader) (resp types.WriteResponse, err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	owner, _ := hex.DecodeString(opt.User)
+	var sliceErr error
+	sliceQueue := e.slicer.Slice(ctx, r, &slicer.SliceOptions{}, func(err error) {
+		logger.WithError(err).Error("slicing stopped")
+		sliceErr = err
+		cancel()
+	})
+
+	var (
+		metas       []slicer.SliceMeta
+		encSlices   []encryptor.EncryptedSlice
+		storIndexes []string
+		failed      []encryptor.EncryptedSlice
+		refs        []blockchain.SliceRef
+		length      int
+		reused      int
+	)
+	// identical chunks in the file share the same slice
+	stored := make(map[string]string)
+	for chunk := range sliceQueue {
+		length += len(chunk.Data)
+		sealed, err := e.encryptor.Encrypt(bytes.NewReader(chunk.Data), &encryptor.EncryptOptions{
+			SliceID: hex.EncodeToString(chunk.Hash),
+		})
+		if err != nil {
+			return resp, errorx.NewCode(err, errorx.ErrCodeCrypto, "chunk encryption failed")
+		}
+		content := sealed.CipherText
+		meta := slicer.SliceMeta{
+			ID:        chunk.ID,
+			Hash:      hash.HashUsingSha256(content),
+			Length:    uint64(len(content)),
+			ChunkHash: chunk.Hash,
+		}
+		if id, ok := stored[string(meta.Hash)]; ok {
+			meta.ID = id
+			metas = append(metas, meta)
+			continue
+		}
+
+		ds, ok := e.findDedupSlice(owner, meta, content, ns.Replica, nodes)
+		if ok {
+			reused++
+		} else {
+			ds, failed, err = e.pushDedupSlice(ctx, meta, content, ns.Replica, nodes, opt.User, failed)
+			if err != nil {
+				return resp, err
+			}
+		}
+		stored[string(meta.Hash)] = ds.meta.ID
+		metas = append(metas, ds.meta)
+		encSlices = append(encSlices, ds.encSlices...)
+		storIndexes = append(storIndexes, ds.storIndexes...)
+		refs = append(refs, blockchain.SliceRef{
+			SliceID:    ds.meta.ID,
+			SealedHash: ds.meta.Hash,
+		})
+	}
+	if sliceErr != nil {
+		return resp, errorx.Wrap(sliceErr, "error occurred in slicing")
+	}
+	if len(metas) == 0 {
+		// nothing to deduplicate for empty file
+		return e.writeWhole(ctx, opt, ns, nodes, fileID, bytes.NewReader(nil))
+	}
+
+	// push failed copies to other nodes, keys of copies are derived without fileID
+	if len(failed) > 0 {
+		var pushErr error
+		finished := e.pushToOtherNode(ctx, opt.User, "", e.encryptor.KeyVersion(), failed, encSlices, nodes, func(err error) {
+			pushErr = err
+		})
+		if pushErr != nil {
+			return resp, errorx.Wrap(pushErr, "error occurred in writing")
+		}
+		for _, m := range finished {
+			encSlices = append(encSlices, m.eSlice)
+			storIndexes = append(storIndexes, m.storIndex)
+		}
+	}
+
+	ca, pairingConf := e.challenger.GetChallengeConf()
+	if err := e.generateAndSaveMerkle(encSlices, fileID, opt.ExpireTime); err != nil {
+		return resp, err
+	}
+	chainFile, err := e.packChainFile(fileID, ca, opt, metas, length, encSlices, storIndexes, pairingConf,
+		e.encryptor.KeyVersion())
+	if err != nil {
+		return resp, errorx.Wrap(err, "failed to pack chain file")
+	}
+	chainFile.Dedup = true
+	version, err := e.publishFile(chainFile, refs)
+	if err != nil {
+		return resp, err
+	}
+
+	logger.WithFields(logrus.Fields{
+		"file_id":       fileID,
+		"total_slices":  len(refs),
+		"reused_slices": reused,
+	}).Debug("deduplicated file uploaded")
+	resp.FileID = fileID
+	resp.Version = version
+	return resp, nil
+}
+
+// findDedupSlice looks up a slice with the same content stored by the owner before.
+// The slice is reused only if it is still referenced by a live file, and enough copies on healthy nodes
+// are found, whose ciphertext are regenerated from content to verify and generate challenge materials
+func (e *Engine) findDedupSlice(owner []byte, meta slicer.SliceMeta, content []byte, replica int,
+	nodes blockchain.NodeHs) (ds dedupSlice, ok bool) {
+	ref, err := e.chain.GetSliceRef(&blockchain.GetSliceRefOptions{
+		Owner:       owner,
+		SealedHash:  meta.Hash,
+		CurrentTime: time.Now().UnixNano(),
+	})
+	if err != nil {
+		if !errorx.Is(err, errorx.ErrCodeNotFound) {
+			logger.WithError(err).Warn("failed to get slice reference")
+		}
+		return ds, false
+	}
+	if ref.RefCount == 0 {
+		return ds, false
+	}
+
+	healthy := make(map[string]bool)
+	for _, n := range nodes {
+		healthy[string(n.Node.ID)] = true
+	}
+	// copies of the slice are taken from the latest referencing file
+	for i := len(ref.Files) - 1; i >= 0; i-- {
+		f, err := e.chain.GetFileByID(ref.Files[i])
+		if err != nil {
+			continue
+		}
+		ds = dedupSlice{meta: meta}
+		ds.meta.ID = ref.SliceID
+		for _, psm := range f.Slices {
+			if psm.ID != ref.SliceID || !healthy[string(psm.NodeID)] {
+				continue
+			}
+			es, err := e.encryptor.Encrypt(bytes.NewReader(content), &encryptor.EncryptOptions{
+				SliceID: ref.SliceID,
+				NodeID:  psm.NodeID,
+			})
+			if err != nil || !bytes.Equal(es.CipherHash, psm.CipherHash) {
+				continue
+			}
+			ds.encSlices = append(ds.encSlices, es)
+			ds.storIndexes = append(ds.storIndexes, psm.StorIndex)
+		}
+		if len(ds.encSlices) >= replica {
+			return ds, true
+		}
+	}
+	return ds, false
+}
+
+// pushDedupSlice selects storage nodes for a new slice, encrypts and pushes copies of it,
+// copies failed to push are appended to failed and returned
+func (e *Engine) pushDedupSlice(ctx context.Context, meta slicer.SliceMeta, content []byte, replica int,
+	nodes blockchain.NodeHs, owner string, failed []encryptor.EncryptedSlice) (
+	ds dedupSlice, _ []encryptor.EncryptedSlice, err error) {
+	ds.meta = meta
+	located, err := e.copier.Select(slicer.Slice{SliceMeta: meta, Data: content}, nodes,
+		&copier.SelectOptions{Replica: uint32(replica)})
+	if err != nil {
+		return ds, failed, errorx.Wrap(err, "failed to select nodes for slice %x", meta.Hash)
+	}
+	for _, node := range located.Nodes {
+		node := node
+		es, err := e.encryptor.Encrypt(bytes.NewReader(content), &encryptor.EncryptOptions{
+			SliceID: meta.ID,
+			NodeID:  node.ID,
+		})
+		if err != nil {
+			return ds, failed, errorx.Wrap(err, "failed to encrypt slice")
+		}
+		storIndex, err := e.copier.Push(ctx, es.SliceID, owner, bytes.NewReader(es.CipherText), &node)
+		if err != nil {
+			logger.WithError(err).Errorf("failed to push to: %v, slice: %s", node, es.SliceID)
+			failed = append(failed, es)
+			continue
+		}
+		ds.encSlices = append(ds.encSlices, es)
+		ds.storIndexes = append(ds.storIndexes, storIndex)
+	}
+	return ds, failed, nil
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/config"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/slicer/cdc"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/types"
+)
+
+// addDedupNs adds a namespace whose slices are deduplicated
+func (te *testEngine) addDedupNs(t *testing.T, name string, replica int) {
+	opt := types.AddNsOptions{
+		Namespace:  name,
+		Replica:    replica,
+		CreateTime: time.Now().UnixNano(),
+		User:       te.pubkey.String(),
+		Dedup:      true,
+	}
+	opt.Token = te.token(t, opt)
+	require.NoError(t, te.AddFileNs(opt))
+}
+
+func TestDedupNamespace(t *testing.T) {
+	te := newTestEngine(t, 3)
+	sl, err := cdc.New(&config.CDCSlicerConf{MinSize: 64, AvgSize: 256, MaxSize: 1024})
+	require.NoError(t, err)
+	te.slicer = sl
+	te.addNs(t, "plain", 2)
+	te.addDedupNs(t, "dedup", 2)
+	content := testContent(4096)
+
+	// files in namespaces without Dedup are neither deduplicated nor left unshredded
+	plain1 := te.write(t, "plain", "file1", content)
+	plain2 := te.write(t, "plain", "file2", content)
+	f1, err := te.chain.GetFileByID(plain1)
+	require.NoError(t, err)
+	f2, err := te.chain.GetFileByID(plain2)
+	require.NoError(t, err)
+	require.False(t, f1.Dedup)
+	require.False(t, f2.Dedup)
+	require.NotEqual(t, f1.Slices[0].ID, f2.Slices[0].ID)
+
+	// identical slices in the deduplicated namespace are stored once
+	dedup1 := te.write(t, "dedup", "file1", content)
+	dedup2 := te.write(t, "dedup", "file2", content)
+	d1, err := te.chain.GetFileByID(dedup1)
+	require.NoError(t, err)
+	d2, err := te.chain.GetFileByID(dedup2)
+	require.NoError(t, err)
+	require.True(t, d1.Dedup)
+	require.True(t, d2.Dedup)
+	ids := make(map[string]bool)
+	for _, s := range d1.Slices {
+		ids[s.ID] = true
+	}
+	for _, s := range d2.Slices {
+		require.True(t, ids[s.ID])
+	}
+	data, err := te.read(t, dedup2, 0, 0)
+	require.NoError(t, err)
+	require.Equal(t, content, data)
+}
+
+func TestAddDedupNsOptions(t *testing.T) {
+	te := newTestEngine(t, 3)
+	opt := types.AddNsOptions{
+		Namespace:    "ec",
+		Replica:      1,
+		CreateTime:   time.Now().UnixNano(),
+		User:         te.pubkey.String(),
+		DataShards:   2,
+		ParityShards: 1,
+		Dedup:        true,
+	}
+	opt.Token = te.token(t, opt)
+	require.Error(t, opt.Valid())
+
+	opt.DataShards, opt.ParityShards, opt.Replica = 0, 0, 2
+	opt.Token = te.token(t, opt)
+	require.NoError(t, opt.Valid())
+}
@@ -15,6 +15,7 @@ package engine
 
 import (
 	"context"
+	"encoding/hex"
 	"encoding/json"
 	"sync"
 	"time"
@@ -101,15 +102,19 @@ func (e *Engine) GetFileByID(ctx context.Context, id string) (hfile blockchain.F
 	return hfile, nil
 }
 
-// GetFileByName gets file by name from blockchain
-func (e *Engine) GetFileByName(ctx context.Context, pubkey, ns, name string) (
+// GetFileByName gets file by name from blockchain, version 0 means the latest version
+func (e *Engine) GetFileByName(ctx context.Context, pubkey, ns, name string, version int) (
 	hfile blockchain.FileH, err error) {
 	var file blockchain.File
 	owner, err := e.getPubKey(pubkey)
 	if err != nil {
 		return hfile, err
 	}
-	file, err = e.chain.GetFileByName(owner, ns, name)
+	if version > 0 {
+		file, err = e.chain.GetFileByVersion(owner, ns, name, version)
+	} else {
+		file, err = e.chain.GetFileByName(owner, ns, name)
+	}
 	if err != nil {
 		if errorx.Is(err, errorx.ErrCodeNotFound) {
 			return hfile, err
@@ -133,6 +138,25 @@ func (e *Engine) GetFileByName(ctx context.Context, pubkey, ns, name string) (
 	return hfile, nil
 }
 
+// ListFileVersions lists versions of a file from blockchain, the latest version comes first,
+// deleted and expired versions are also listed
+func (e *Engine) ListFileVersions(opt types.ListFileVersionsOptions) ([]blockchain.File, error) {
+	owner, err := e.getPubKey(opt.Owner)
+	if err != nil {
+		return nil, err
+	}
+	files, err := e.chain.ListFileVersions(&blockchain.ListFileVersionsOptions{
+		Owner:     owner,
+		Namespace: opt.Namespace,
+		Name:      opt.Name,
+		Limit:     opt.Limit,
+	})
+	if err != nil {
+		return nil, errorx.Wrap(err, "failed to list file versions from blockchain")
+	}
+	return files, nil
+}
+
 // UpdateFileExpireTime updates file expire time
 func (e *Engine) UpdateFileExpireTime(ctx context.Context, opt types.UpdateFileEtimeOptions) (err error) {
 	if err := e.verifyUserID(opt.User); err != nil {
@@ -212,6 +236,80 @@ func (e *Engine) UpdateFileExpireTime(ctx context.Context, opt types.UpdateFileE
 	return nil
 }
 
+// DeleteFile deletes a file before it expires, the file is marked deleted on blockchain,
+// its slices are cleared by storage nodes, and its keys are destroyed so that leftover ciphertext can not be decrypted
+func (e *Engine) DeleteFile(ctx context.Context, opt types.DeleteFileOptions) (err error) {
+	if err := e.verifyUserID(opt.User); err != nil {
+		return err
+	}
+	// get the message to sign
+	msg, err := util.GetSigMessage(opt)
+	if err != nil {
+		return errorx.Internal(err, "failed to get the message to sign")
+	}
+	if err := verifyUserToken(opt.User, opt.Token, hash.HashUsingSha256([]byte(msg))); err != nil {
+		return err
+	}
+
+	if opt.CurrentTime+5*time.Second.Nanoseconds() < time.Now().UnixNano() {
+		return errorx.New(errorx.ErrCodeExpired, "request expired")
+	}
+
+	file, err := e.chain.GetFileByID(opt.FileID)
+	if err != nil {
+		if errorx.Is(err, errorx.ErrCodeNotFound) {
+			return err
+		} else if !errorx.Is(err, errorx.ErrCodeExpired) {
+			return errorx.Wrap(err, "failed to read blockchain")
+		}
+	}
+	if opt.User != hex.EncodeToString(file.Owner) {
+		return errorx.New(errorx.ErrCodeNotAuthorized, "bad param, file owner is wrong")
+	}
+
+	dopt := &blockchain.DeleteFileOptions{
+		FileID:      opt.FileID,
+		CurrentTime: opt.CurrentTime,
+	}
+	msg, err = util.GetSigMessage(dopt)
+	if err != nil {
+		return errorx.Internal(err, "failed to get the message to sign for delete file")
+	}
+	sig, err := ecdsa.Sign(e.monitor.challengingMonitor.PrivateKey, hash.HashUsingSha256([]byte(msg)))
+	if err != nil {
+		return errorx.Wrap(err, "failed to sign")
+	}
+	dopt.Signature = sig[:]
+
+	if err := e.chain.DeleteFile(dopt); err != nil {
+		if errorx.Is(err, errorx.ErrCodeNotFound) {
+			return err
+		}
+		return errorx.Wrap(err, "failed to delete file on blockchain")
+	}
+
+	// destroy keys of the file if shredding is enabled, i.e. the file key path is configured,
+	// files written before that or by aborted writes have no seed to destroy
+	if err := e.encryptor.DestroyFileKey(opt.FileID); err != nil {
+		if errorx.Is(err, errorx.ErrCodeNotFound) {
+			logger.WithField("file_id", opt.FileID).Warn("file has no key seed, key not destroyed")
+		} else if !errorx.Is(err, errorx.ErrCodeConfig) {
+			return errorx.Wrap(err, "file deleted but its key was not destroyed")
+		} else {
+			logger.WithField("file_id", opt.FileID).Debug("file shredding not enabled, key not destroyed")
+		}
+	}
+	// slices of deduplicated file are encrypted by keys derived from their content, see writeDedup
+	if file.Dedup {
+		logger.WithField("file_id", opt.FileID).Warn("deduplicated file can't be shredded, slices are cleared only")
+	}
+	logger.WithFields(logrus.Fields{
+		"file_id":     opt.FileID,
+		"delete_time": time.Unix(0, opt.CurrentTime).Format("2006-01-02 15:04:05"),
+	}).Info("deleted file")
+	return nil
+}
+
 // AddFileNs adds file namespace, opt.User is dataOwner node client's public key
 func (e *Engine) AddFileNs(opt types.AddNsOptions) (err error) {
 	if err := e.verifyUserID(opt.User); err != nil {
@@ -237,6 +335,9 @@ func (e *Engine) AddFileNs(opt types.AddNsOptions) (err error) {
 			UpdateTime:   opt.CreateTime,
 			Replica:      opt.Replica,
 			FileTotalNum: 0,
+			DataShards:   opt.DataShards,
+			ParityShards: opt.ParityShards,
+			Dedup:        opt.Dedup,
 		},
 	}
 	msg, err = util.GetSigMessage(namespace)
@@ -281,6 +382,9 @@ func (e *Engine) UpdateNsReplica(ctx context.Context, opt types.UpdateNsOptions)
 	if err != nil {
 		return errorx.Wrap(err, "failed to get ns from blockchain")
 	}
+	if ns.DataShards > 0 {
+		return errorx.New(errorx.ErrCodeParam, "bad param: replica of erasure coded namespace cannot be updated")
+	}
 	if ns.Replica >= opt.Replica {
 		return errorx.New(errorx.ErrCodeParam, "bad param: replica")
 	}
@@ -468,7 +572,7 @@ func (e *Engine) ConfirmAuth(opt types.ConfirmAuthOptions) error {
 	}
 	if opt.Status {
 		// Obtain an encryption key once and twice
-		authKey, err := e.getAuthKey(fileAuth.FileID, fileAuth.Applier, opt.ExpireTime)
+		authKey, err := e.getAuthKey(fileAuth.FileID, fileAuth.Applier, opt.ExpireTime, opt.E2EKey)
 		if err != nil {
 			return errorx.Wrap(err, "failed to get file authorization encryption key")
 		}
@@ -497,8 +601,57 @@ func (e *Engine) ConfirmAuth(opt types.ConfirmAuthOptions) error {
 	return nil
 }
 
+// RevokeAuth the dataOwner node revokes the approved file authorization application,
+// after that the applier can no longer get the authorization key from blockchain
+func (e *Engine) RevokeAuth(opt types.RevokeAuthOptions) error {
+	// check whether opt.User is equal to the dataOwner node public key or authorized client's public key
+	if err := e.verifyUserID(opt.User); err != nil {
+		return err
+	}
+	// get the message to sign
+	msg, err := util.GetSigMessage(opt)
+	if err != nil {
+		return errorx.Internal(err, "failed to get the message to sign")
+	}
+	if err := verifyUserToken(opt.User, opt.Token, hash.HashUsingSha256([]byte(msg))); err != nil {
+		return errorx.Wrap(err, "failed to verify user token")
+	}
+
+	fileAuth, err := e.GetAuthByID(opt.AuthID)
+	if err != nil {
+		return errorx.Wrap(err, "failed to get file authorization application by authID")
+	}
+	if fileAuth.Status != blockchain.FileAuthApproved {
+		return errorx.New(errorx.ErrCodeParam, "only approved authorization can be revoked, authID: %s, status: %s",
+			fileAuth.ID, fileAuth.Status)
+	}
+
+	ropt := &blockchain.RevokeFileAuthOptions{
+		ID:           opt.AuthID,
+		RevokeReason: opt.RevokeReason,
+		CurrentTime:  time.Now().UnixNano(),
+	}
+	// Sign revoke info
+	msg, err = util.GetSigMessage(ropt)
+	if err != nil {
+		return errorx.Internal(err, "failed to get the message to sign for revoke authorization")
+	}
+	sig, err := ecdsa.Sign(e.monitor.challengingMonitor.PrivateKey, hash.HashUsingSha256([]byte(msg)))
+	if err != nil {
+		return errorx.Wrap(err, "failed to sign file authorization revocation")
+	}
+	ropt.Signature = sig[:]
+
+	if err := e.chain.RevokeFileAuth(ropt); err != nil {
+		return errorx.Wrap(err, "failed to revoke the applier's authorization on blockchain")
+	}
+	return nil
+}
+
 // GetAuthKey get the authorization key for file decryption
-func (e *Engine) getAuthKey(fileID string, applier []byte, expireTime int64) ([]byte, error) {
+//  For client encrypted file, the file key wrapped by the authorizer for the applier is attached,
+//  the node never knows the file key
+func (e *Engine) getAuthKey(fileID string, applier []byte, expireTime int64, e2eKey string) ([]byte, error) {
 	// Query file details
 	file, err := e.chain.GetFileByID(fileID)
 	if err != nil {
@@ -508,22 +661,61 @@ func (e *Engine) getAuthKey(fileID string, applier []byte, expireTime int64) ([]
 	if file.ExpireTime < expireTime {
 		return nil, errorx.New(errorx.ErrCodeParam, "authorization expireTime cannot be later than file expireTime")
 	}
+	if file.ClientEncrypted && e2eKey == "" {
+		return nil, errorx.New(errorx.ErrCodeParam, "wrapped key is required for client encrypted file")
+	}
+	if !file.ClientEncrypted && e2eKey != "" {
+		return nil, errorx.New(errorx.ErrCodeParam, "wrapped key is only for client encrypted file")
+	}
 	authKey := make(map[string]interface{})
 	// Get the first-level derived key
-	firstEncSecret := e.encryptor.GetKey(fileID, "", []byte{})
+	firstEncSecret, err := e.encryptor.GetKey(fileID, "", []byte{}, file.GetKeyVersion())
+	if err != nil {
+		return nil, errorx.Wrap(err, "failed to get the first-level derived key")
+	}
 	authKey["firstEncSecret"] = firstEncSecret
 
 	// Get the second-level derived key
+	keyFileID := common.SliceKeyFileID(file)
 	secondEncSecret := make(map[string]map[string]interface{})
-	slicesPool := makeSlicesPool4Read(file.Slices)
+	slicesPool := common.MakeSlicesPool4Read(file.Slices)
 	for sliceID, targetPools := range slicesPool {
 		secondEncSecret[sliceID] = make(map[string]interface{})
 		for _, slice := range targetPools {
-			secondEncSecret[sliceID][string(slice.NodeID)] = e.encryptor.GetKey(fileID, sliceID, slice.NodeID)
+			key, err := e.encryptor.GetKey(keyFileID, sliceID, slice.NodeID, file.GetSliceKeyVersion())
+			if err != nil {
+				return nil, errorx.Wrap(err, "failed to get the second-level derived key")
+			}
+			secondEncSecret[sliceID][string(slice.NodeID)] = key
 		}
 	}
 	authKey["secondEncSecret"] = secondEncSecret
 
+	// Deduplicated file is encrypted chunk by chunk, get the key of each chunk instead of the first-level key
+	if file.Dedup {
+		fs, err := e.recoverChainFileStructure(file)
+		if err != nil {
+			return nil, errorx.Wrap(err, "failed to recover file structure")
+		}
+		chunkEncSecret := make(map[string]interface{})
+		for _, s := range fs {
+			key, err := e.encryptor.GetKey("", hex.EncodeToString(s.ChunkHash), nil, file.GetKeyVersion())
+			if err != nil {
+				return nil, errorx.Wrap(err, "failed to get the chunk key")
+			}
+			chunkEncSecret[s.SliceID] = key
+		}
+		authKey["chunkEncSecret"] = chunkEncSecret
+	}
+
+	if file.ClientEncrypted {
+		wrappedKey, err := hex.DecodeString(e2eKey)
+		if err != nil {
+			return nil, errorx.NewCode(err, errorx.ErrCodeParam, "invalid wrapped key")
+		}
+		authKey["e2eEncSecret"] = wrappedKey
+	}
+
 	authKeyBytes, err := json.Marshal(authKey)
 	if err != nil {
 		return nil, errorx.NewCode(err, errorx.ErrCodeInternal, "fail to marshal authKey")
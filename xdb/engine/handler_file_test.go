@@ -0,0 +1,117 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/ecdsa"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/types"
+)
+
+func TestRevokeAuth(t *testing.T) {
+	te := newTestEngine(t, 3)
+	te.addNs(t, "ns", 2)
+	fileID := te.write(t, "ns", "file", []byte("some content of the file"))
+
+	applier, _, err := ecdsa.GenerateKeyPair()
+	require.NoError(t, err)
+	authID := te.publishAuth(t, applier, fileID)
+
+	revoke := func(user string, privkey ecdsa.PrivateKey) error {
+		opt := types.RevokeAuthOptions{
+			User:         user,
+			AuthID:       authID,
+			RevokeReason: "no longer needed",
+		}
+		opt.Token = signature(t, privkey, opt).String()
+		return te.RevokeAuth(opt)
+	}
+
+	// only approved authorization can be revoked
+	require.Error(t, revoke(te.pubkey.String(), te.privkey))
+	te.confirmAuth(t, authID)
+	fa, err := te.GetAuthByID(authID)
+	require.NoError(t, err)
+	require.Equal(t, blockchain.FileAuthApproved, fa.Status)
+	require.NotEmpty(t, fa.AuthKey)
+
+	// others can not revoke the authorization
+	other, otherPub, err := ecdsa.GenerateKeyPair()
+	require.NoError(t, err)
+	require.Error(t, revoke(otherPub.String(), other))
+	require.Error(t, revoke(te.pubkey.String(), other))
+	ropt := &blockchain.RevokeFileAuthOptions{
+		ID:           authID,
+		RevokeReason: "no longer needed",
+		CurrentTime:  time.Now().UnixNano(),
+	}
+	ropt.Signature = sign(t, applier, ropt)
+	require.Error(t, te.chain.RevokeFileAuth(ropt))
+
+	require.NoError(t, revoke(te.pubkey.String(), te.privkey))
+	fa, err = te.GetAuthByID(authID)
+	require.NoError(t, err)
+	require.Equal(t, blockchain.FileAuthRevoked, fa.Status)
+	require.Equal(t, "no longer needed", fa.RevokeReason)
+	require.NotZero(t, fa.RevokeTime)
+	require.Empty(t, fa.AuthKey)
+
+	// revoked authorization can not be revoked again
+	require.Error(t, revoke(te.pubkey.String(), te.privkey))
+
+	events, err := te.chain.ListFileAuditEvents(&blockchain.ListFileAuditEventsOptions{
+		FileID:  fileID,
+		EndTime: time.Now().UnixNano(),
+	})
+	require.NoError(t, err)
+	var revokeEvents int
+	for _, e := range events {
+		if e.Type == blockchain.FileAuditAuthRevoked {
+			require.Equal(t, authID, e.Ref)
+			revokeEvents++
+		}
+	}
+	require.Equal(t, 1, revokeEvents)
+}
+
+func TestDeleteFileWithoutSeed(t *testing.T) {
+	te := newTestEngine(t, 3)
+	te.addNs(t, "ns", 2)
+	fileID := te.write(t, "ns", "file", []byte("some content of the file"))
+	// files written before the file key path is configured have no seed
+	require.NoError(t, os.Remove(filepath.Join(te.dir, "keys", fileID)))
+
+	del := func() error {
+		opt := types.DeleteFileOptions{
+			FileID:      fileID,
+			CurrentTime: time.Now().UnixNano(),
+			User:        te.pubkey.String(),
+		}
+		opt.Token = te.token(t, opt)
+		return te.DeleteFile(context.Background(), opt)
+	}
+	require.NoError(t, del())
+	err := del()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "already deleted")
+}
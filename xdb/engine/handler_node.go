@@ -47,17 +47,23 @@ func (e *Engine) GetNode(id []byte) (blockchain.Node, error) {
 	return node, nil
 }
 
-// NodeOffline set storage node status to offline
+// NodeOffline set storage node status to offline,
+//  a draining node is allowed to go offline only after all its slices are moved
 func (e *Engine) NodeOffline(opt types.NodeOperateOptions) error {
-	return e.storageNodeOperate(opt, false)
+	return e.storageNodeOperate(opt, e.chain.NodeOffline)
 }
 
 // NodeOnline set storage node status to online
 func (e *Engine) NodeOnline(opt types.NodeOperateOptions) error {
-	return e.storageNodeOperate(opt, true)
+	return e.storageNodeOperate(opt, e.chain.NodeOnline)
 }
 
-func (e *Engine) storageNodeOperate(opt types.NodeOperateOptions, isOnline bool) error {
+// NodeDrain set storage node status to draining, data owners will move slices on it to other nodes
+func (e *Engine) NodeDrain(opt types.NodeOperateOptions) error {
+	return e.storageNodeOperate(opt, e.chain.NodeDrain)
+}
+
+func (e *Engine) storageNodeOperate(opt types.NodeOperateOptions, operate func(*blockchain.NodeOperateOptions) error) error {
 	if err := e.verifyUserIDIsLocalNodeID(opt.NodeID); err != nil {
 		return err
 	}
@@ -71,8 +77,9 @@ func (e *Engine) storageNodeOperate(opt types.NodeOperateOptions, isOnline bool)
 	}
 	// invoke contract
 	nodeOpts := &blockchain.NodeOperateOptions{
-		NodeID:    []byte(opt.NodeID),
-		Nonce:     opt.Nonce,
+		NodeID:      []byte(opt.NodeID),
+		Nonce:       opt.Nonce,
+		CurrentTime: time.Now().UnixNano(),
 	}
 	msg, err = util.GetSigMessage(nodeOpts)
 	if err != nil {
@@ -84,12 +91,7 @@ func (e *Engine) storageNodeOperate(opt types.NodeOperateOptions, isOnline bool)
 	}
 	nodeOpts.Signature = sig[:]
 
-	if isOnline {
-		err = e.chain.NodeOnline(nodeOpts)
-	} else {
-		err = e.chain.NodeOffline(nodeOpts)
-	}
-	if err != nil {
+	if err := operate(nodeOpts); err != nil {
 		if errorx.Is(err, errorx.ErrCodeNotFound) {
 			return errorx.New(errorx.ErrCodeNotFound, "node not found")
 		}
@@ -107,6 +109,21 @@ func (e *Engine) GetNodeHealth(id []byte) (string, error) {
 	return status, nil
 }
 
+// GetNodeReputation gets storage node reputation and its history
+func (e *Engine) GetNodeReputation(opt *blockchain.GetNodeReputationOptions) (blockchain.NodeReputation, error) {
+	if _, err := e.chain.GetNode(opt.NodeID); err != nil {
+		if errorx.Is(err, errorx.ErrCodeNotFound) {
+			return blockchain.NodeReputation{}, errorx.New(errorx.ErrCodeNotFound, "node not found")
+		}
+		return blockchain.NodeReputation{}, errorx.Wrap(err, "failed to read blockchain")
+	}
+	r, err := e.chain.GetNodeReputation(opt)
+	if err != nil {
+		return r, errorx.Wrap(err, "failed to get node reputation")
+	}
+	return r, nil
+}
+
 // GetSliceMigrateRecords gets storage node slice migration record
 func (e *Engine) GetSliceMigrateRecords(opt *blockchain.NodeSliceMigrateOptions) (string, error) {
 	if _, err := e.chain.GetNode(opt.Target); err != nil {
@@ -122,6 +139,21 @@ func (e *Engine) GetSliceMigrateRecords(opt *blockchain.NodeSliceMigrateOptions)
 	return result, nil
 }
 
+// ListSliceScrubReports lists reports of slices found corrupted or missing by scrubbing on storage node
+func (e *Engine) ListSliceScrubReports(opt *blockchain.ListSliceScrubReportsOptions) ([]blockchain.SliceScrubReport, error) {
+	if _, err := e.chain.GetNode(opt.Target); err != nil {
+		if errorx.Is(err, errorx.ErrCodeNotFound) {
+			return nil, errorx.New(errorx.ErrCodeNotFound, "node not found")
+		}
+		return nil, errorx.Wrap(err, "failed to read blockchain")
+	}
+	reports, err := e.chain.ListSliceScrubReports(opt)
+	if err != nil {
+		return nil, errorx.Wrap(err, "failed to list slice scrub reports")
+	}
+	return reports, nil
+}
+
 // GetHeartbeatNum gets storage node heartbeats number of given time
 // The total number of heartbeats is obtained from the blockchain and
 // the maximum number of heartbeats is estimated by given time,
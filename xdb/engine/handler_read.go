@@ -18,24 +18,21 @@ import (
 	"context"
 	"encoding/hex"
 	"io"
-	"io/ioutil"
 	"time"
 
 	"github.com/PaddlePaddle/PaddleDTX/crypto/core/ecdsa"
 	"github.com/PaddlePaddle/PaddleDTX/crypto/core/hash"
-	"github.com/cjqpker/slidewindow"
 	"github.com/sirupsen/logrus"
 
 	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/common"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/encryptor"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/encryptor/chunk"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/types"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
 	util "github.com/PaddlePaddle/PaddleDTX/xdb/pkgs/strings"
 )
 
-var defaultConcurrency uint64 = 10
-
 func verifyReadToken(opt types.ReadOptions) error {
 	// check timestamp
 	var requestExpiredTime = 5 * time.Minute
@@ -64,17 +61,16 @@ func verifyReadToken(opt types.ReadOptions) error {
 // 4. download slices from the storage node, if request fails, pull slices from other storage nodes
 // 5. slices decryption and combination
 // 6. decrypt the combined slices to get the original file
+// If a range of the file is requested, only slices covering the range are pulled, see common.ReadRange.
+// Deduplicated files and chunked files are encrypted chunk by chunk rather than as a whole,
+// they are always read by common.ReadRange
 func (e *Engine) Read(ctx context.Context, opt types.ReadOptions) (io.ReadCloser, error) {
-	ctx, cancel := context.WithCancel(ctx)
-
 	// check key match
 	if err := e.verifyUserID(opt.User); err != nil {
-		cancel()
 		return nil, err
 	}
 	// verify token
 	if err := verifyReadToken(opt); err != nil {
-		cancel()
 		return nil, err
 	}
 	pubkey := ecdsa.PublicKeyFromPrivateKey(e.monitor.challengingMonitor.PrivateKey)
@@ -83,7 +79,6 @@ func (e *Engine) Read(ctx context.Context, opt types.ReadOptions) (io.ReadCloser
 	// prepare
 	allNodes, err := e.chain.ListNodes()
 	if err != nil {
-		cancel()
 		return nil, errorx.Wrap(err, "failed to get nodes from blockchain")
 	}
 	// get online nodes
@@ -94,7 +89,6 @@ func (e *Engine) Read(ctx context.Context, opt types.ReadOptions) (io.ReadCloser
 		}
 	}
 	if len(nodes) == 0 {
-		cancel()
 		return nil, errorx.New(errorx.ErrCodeInternal, "empty online nodes")
 	}
 	nodesMap := common.ToNodesMap(nodes)
@@ -102,145 +96,94 @@ func (e *Engine) Read(ctx context.Context, opt types.ReadOptions) (io.ReadCloser
 	// find file from blockchain
 	f, err := getBlockchainFile4Read(e.chain, &opt)
 	if err != nil {
-		cancel()
 		return nil, err
 	}
 	if opt.User != hex.EncodeToString(f.Owner) {
-		cancel()
 		return nil, errorx.New(errorx.ErrCodeNotAuthorized, "not authorized")
 	}
 	opt.FileID = f.ID
 
 	// recover structure
-	fs, err := e.recoverChainFileStructure(f.Structure, opt.FileID)
+	fs, err := e.recoverChainFileStructure(f)
 	if err != nil {
-		cancel()
 		return nil, err
 	}
 
-	// use sliding window
-	sw := slidewindow.SlideWindow{
-		Total:       uint64(len(fs)),
-		Concurrency: defaultConcurrency,
+	dec := &fileDecrypter{e: e, f: f}
+	var r io.ReadCloser
+	if opt.Ranged() {
+		r, err = common.ReadRange(ctx, f, fs, dec, nodesMap, opt.Offset, opt.Length, logger)
+	} else {
+		r, err = common.ReadFile(ctx, f, fs, dec, nodesMap, logger)
 	}
-
-	sw.Init = func(ctx context.Context, s *slidewindow.Session) error {
-		return nil
+	if err != nil {
+		return nil, err
 	}
+	// the download is recorded once the file is read through, reads are all by the node itself
+	return e.downloads.Track(f.ID, r, func() { e.recordFileDownload(f) }), nil
+}
 
-	slicesPool := makeSlicesPool4Read(f.Slices)
-	sw.Task = func(ctx context.Context, s *slidewindow.Session) error {
-		slice := fs[int(s.Index())]
+// fileDecrypter decrypts the file with keys derived from the password of the node, see common.FileDecrypter
+type fileDecrypter struct {
+	e *Engine
+	f blockchain.File
+}
 
-		targetPool, ok := slicesPool[slice.SliceID]
-		if !ok {
-			return errorx.Internal(nil, "bad file structure")
+// PullSlice pulls a slice from any of its storage nodes, and returns the decrypted content
+func (d *fileDecrypter) PullSlice(ctx context.Context, targetPool []blockchain.PublicSliceMeta,
+	nodesMap map[string]blockchain.Node) ([]byte, error) {
+	fileID := d.f.ID
+	for _, target := range targetPool {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
 		}
-		for _, target := range targetPool {
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			default:
-			}
-
-			// pull slice
-			node, exist := nodesMap[string(target.NodeID)]
-			if !exist || !node.Online {
-				logger.WithField("node_id", string(target.NodeID)).Warn("abnormal node")
-				continue
-			}
 
-			r, err := e.copier.Pull(ctx, target.ID, target.StorIndex, f.ID, &node)
-			if err != nil {
-				logger.WithFields(logrus.Fields{
-					"slice_id":         target.ID,
-					"slice_stor_index": target.StorIndex,
-					"file_id":          opt.FileID,
-					"target_node":      string(node.ID),
-				}).WithError(err).Warn("failed to pull slice")
-				continue
-			}
-			defer r.Close()
-
-			// read
-			cipherText, err := ioutil.ReadAll(r)
-			if err != nil {
-				logger.WithError(err).Warn("failed to read slice from target node")
-				continue
-			}
-			if len(cipherText) != int(target.Length) {
-				logger.WithFields(logrus.Fields{"expected": target.Length, "got": len(cipherText)}).
-					Warn("invalid slice length.")
-				continue
-			}
-			hGot := hash.HashUsingSha256(cipherText)
-			if !bytes.Equal(hGot, target.CipherHash) {
-				logger.WithFields(logrus.Fields{"expected": target.CipherHash, "got": hGot}).
-					Warn("invalid slice hash.")
-				continue
-			}
-
-			// decrypt
-			eOpt := encryptor.RecoverOptions{
-				FileID:  opt.FileID,
-				SliceID: target.ID,
-				NodeID:  target.NodeID,
-			}
-			plainText, err := e.encryptor.Recover(bytes.NewReader(cipherText), &eOpt)
-			if err != nil {
-				logger.WithError(err).Error("failed to decrypt slice")
-				continue
-			}
-
-			// trim 0 at the end of file
-			if s.Index() != sw.Total-1 {
-				s.Set("data", plainText)
-			} else {
-				s.Set("data", bytes.TrimRight(plainText, string([]byte{0})))
-			}
-
-			break
+		node, exist := nodesMap[string(target.NodeID)]
+		if !exist || !node.Online {
+			logger.WithField("node_id", string(target.NodeID)).Warn("abnormal node")
+			continue
 		}
-
-		if _, exist := s.Get("data"); !exist {
-			return errorx.New(errorx.ErrCodeNotFound, "failed to pull slice %s", slice.SliceID)
+		plainText, err := common.PullAndDec(ctx, d.e.copier, d.e.encryptor, target, &node, fileID,
+			common.SliceKeyFileID(d.f), d.f.GetSliceKeyVersion())
+		if err != nil {
+			logger.WithFields(logrus.Fields{
+				"slice_id":         target.ID,
+				"slice_stor_index": target.StorIndex,
+				"file_id":          fileID,
+				"target_node":      string(node.ID),
+			}).WithError(err).Warn("failed to pull slice")
+			continue
 		}
-
-		return nil
+		return plainText, nil
 	}
 
-	reader, writer := io.Pipe()
-	sw.Done = func(ctx context.Context, s *slidewindow.Session) error {
-		data, exist := s.Get("data")
-		if !exist {
-			return errorx.New(errorx.ErrCodeNotFound, "failed to find data")
-		}
-
-		if _, err := writer.Write(data.([]byte)); err != nil {
-			return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to write")
-		}
-
-		// exit on success
-		if s.Index() == uint64(len(fs)-1) {
-			writer.Close()
-		}
-
-		return nil
+	if len(targetPool) > 0 {
+		return nil, errorx.New(errorx.ErrCodeNotFound, "failed to pull slice %s", targetPool[0].ID)
 	}
+	return nil, errorx.New(errorx.ErrCodeNotFound, "failed to pull slice")
+}
 
-	go func() {
-		defer cancel()
-		if err := sw.Start(ctx); err != nil {
-			writer.CloseWithError(err)
-		}
-	}()
+// DecryptChunk decrypts a chunk of deduplicated file, keys of chunks are derived from their hashes
+func (d *fileDecrypter) DecryptChunk(slice blockchain.PrivateSliceMeta, ciphertext []byte) ([]byte, error) {
+	return d.e.encryptor.Recover(bytes.NewReader(ciphertext), &encryptor.RecoverOptions{
+		SliceID:    hex.EncodeToString(slice.ChunkHash),
+		KeyVersion: d.f.GetKeyVersion(),
+	})
+}
 
-	// decrypt recovered file
-	plain, err := e.encryptor.Recover(reader, &encryptor.RecoverOptions{FileID: opt.FileID})
-	if err != nil {
-		return nil, errorx.NewCode(err, errorx.ErrCodeCrypto, "failed to recover original file")
-	}
-	return ioutil.NopCloser(bytes.NewReader(plain)), nil
+// DecryptFile decrypts the file encrypted as a whole
+func (d *fileDecrypter) DecryptFile(ciphertext []byte) ([]byte, error) {
+	return d.e.encryptor.Recover(bytes.NewReader(ciphertext), &encryptor.RecoverOptions{
+		FileID:     d.f.ID,
+		KeyVersion: d.f.GetKeyVersion(),
+	})
+}
+
+// FileCipher returns the cipher of chunked file
+func (d *fileDecrypter) FileCipher() (*chunk.Cipher, error) {
+	return d.e.fileCipher(d.f.ID, d.f.GetKeyVersion())
 }
 
 // getBlockchainFile4Read query file details by fileID or fileName from blockchain
@@ -252,7 +195,11 @@ func getBlockchainFile4Read(chain Blockchain, opt *types.ReadOptions) (
 		f, err = chain.GetFileByID(opt.FileID)
 	} else {
 		pubkey, _ := hex.DecodeString(opt.User)
-		f, err = chain.GetFileByName(pubkey, opt.Namespace, opt.FileName)
+		if opt.Version > 0 {
+			f, err = chain.GetFileByVersion(pubkey, opt.Namespace, opt.FileName, opt.Version)
+		} else {
+			f, err = chain.GetFileByName(pubkey, opt.Namespace, opt.FileName)
+		}
 	}
 	if err != nil {
 		return f, errorx.Wrap(err, "failed to read file from blockchain")
@@ -260,19 +207,3 @@ func getBlockchainFile4Read(chain Blockchain, opt *types.ReadOptions) (
 
 	return f, nil
 }
-
-// makeSlicesPool4Read get the list of storage nodes of slices by blockchain.PublicSliceMeta.
-// return map, key is sliceID, value is storage nodeID lists
-func makeSlicesPool4Read(srs []blockchain.PublicSliceMeta) map[string][]blockchain.PublicSliceMeta {
-	slicesPool := make(map[string][]blockchain.PublicSliceMeta)
-	for _, s := range srs {
-		var ss []blockchain.PublicSliceMeta
-		if v, exist := slicesPool[s.ID]; exist {
-			ss = v
-		}
-		ss = append(ss, s)
-		slicesPool[s.ID] = ss
-	}
-
-	return slicesPool
-}
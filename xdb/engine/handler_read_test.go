@@ -0,0 +1,155 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/config"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/encryptor/chunk"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/encryptor/seal"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/encryptor/soft"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/types"
+)
+
+func TestReadRange(t *testing.T) {
+	te := newTestEngine(t, 3)
+	te.addNs(t, "ns", 2)
+
+	for _, n := range []int{1, 100, chunk.Size, 2*chunk.Size + 5} {
+		content := testContent(n)
+		fileID := te.write(t, "ns", "file", content)
+
+		data, err := te.read(t, fileID, 0, 0)
+		require.NoError(t, err)
+		require.Equal(t, content, data)
+
+		ranges := [][2]int{{0, 1}, {0, n}, {n - 1, 1}, {n / 2, 0}, {n / 3, n / 3}, {n - 10, 100}, {chunk.Size - 1, 2}}
+		for _, r := range ranges {
+			if r[0] < 0 || r[0] >= n {
+				continue
+			}
+			end := n
+			if r[1] > 0 && r[0]+r[1] < n {
+				end = r[0] + r[1]
+			}
+			data, err := te.read(t, fileID, uint64(r[0]), uint64(r[1]))
+			require.NoError(t, err, "file %d range %v", n, r)
+			require.Equal(t, content[r[0]:end], data, "file %d range %v", n, r)
+		}
+
+		_, err = te.read(t, fileID, uint64(n), 0)
+		require.Error(t, err)
+	}
+}
+
+func TestReadSealedReplicas(t *testing.T) {
+	te := newTestEngine(t, 3)
+	enc, err := soft.New(&config.SoftEncryptorConf{
+		Password:    "test password",
+		FileKeyPath: filepath.Join(te.dir, "keys"),
+		SealRounds:  8,
+	}, nil)
+	require.NoError(t, err)
+	te.encryptor = enc
+	te.addNs(t, "ns", 2)
+	content := testContent(300)
+	fileID := te.write(t, "ns", "file", content)
+
+	// replicas are stored sealed, and unsealed when read
+	require.NotEmpty(t, te.copier.slices)
+	for _, s := range te.copier.slices {
+		require.True(t, bytes.HasPrefix(s, seal.Magic))
+	}
+	f, err := te.chain.GetFileByID(fileID)
+	require.NoError(t, err)
+	for _, s := range f.Slices {
+		require.True(t, s.Sealed)
+	}
+	data, err := te.read(t, fileID, 0, 0)
+	require.NoError(t, err)
+	require.Equal(t, content, data)
+	data, err = te.read(t, fileID, 100, 50)
+	require.NoError(t, err)
+	require.Equal(t, content[100:150], data)
+}
+
+func TestReadEmptyFile(t *testing.T) {
+	te := newTestEngine(t, 3)
+	te.addNs(t, "ns", 2)
+	fileID := te.write(t, "ns", "empty", nil)
+
+	data, err := te.read(t, fileID, 0, 0)
+	require.NoError(t, err)
+	require.Empty(t, data)
+}
+
+func TestReadExplicitRange(t *testing.T) {
+	te := newTestEngine(t, 3)
+	te.addNs(t, "ns", 2)
+	content := testContent(1000)
+	fileID := te.write(t, "ns", "file", content)
+
+	// a range covering the whole file, like HTTP Range "bytes=0-"
+	opt := types.ReadOptions{
+		User:      te.pubkey.String(),
+		Timestamp: time.Now().UnixNano(),
+		FileID:    fileID,
+	}
+	opt.Token = te.token(t, opt)
+	opt.Range = true
+	r, err := te.Read(context.Background(), opt)
+	require.NoError(t, err)
+	defer r.Close()
+	rr, ok := r.(*types.RangeReader)
+	require.True(t, ok)
+	require.Equal(t, uint64(0), rr.Offset)
+	require.Equal(t, uint64(len(content)), rr.Length)
+	require.Equal(t, uint64(len(content)), rr.Total)
+	data, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, content, data)
+}
+
+func TestReadErasureCodedRange(t *testing.T) {
+	te := newTestEngine(t, 4)
+	opt := types.AddNsOptions{
+		Namespace:    "ec",
+		CreateTime:   time.Now().UnixNano(),
+		User:         te.pubkey.String(),
+		DataShards:   2,
+		ParityShards: 1,
+	}
+	opt.Token = te.token(t, opt)
+	require.NoError(t, te.AddFileNs(opt))
+
+	content := testContent(chunk.Size + 300)
+	fileID := te.write(t, "ec", "file", content)
+	for _, r := range [][2]int{{0, 0}, {10, 100}, {chunk.Size - 50, 100}, {chunk.Size + 299, 0}} {
+		end := len(content)
+		if r[1] > 0 {
+			end = r[0] + r[1]
+		}
+		data, err := te.read(t, fileID, uint64(r[0]), uint64(r[1]))
+		require.NoError(t, err)
+		require.Equal(t, content[r[0]:end], data)
+	}
+}
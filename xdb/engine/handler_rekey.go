@@ -0,0 +1,411 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"time"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/ecdsa"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/hash"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/common"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/encryptor"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/encryptor/seal"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/types"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+	util "github.com/PaddlePaddle/PaddleDTX/xdb/pkgs/strings"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/storage"
+)
+
+// StartRekey starts a job to re-encrypt slices of the owner's files under the current password.
+// Structures and contents of files stay encrypted under the password they were written with,
+// so only old passwords of versions below MinKeyVersion of the finished job can be removed from configuration.
+// Only one job runs at a time, and its progress is queried by GetRekeyJob
+func (e *Engine) StartRekey(opt types.RekeyOptions) (job types.RekeyJob, err error) {
+	if err := e.verifyUserID(opt.User); err != nil {
+		return job, err
+	}
+	// get the message to sign
+	msg, err := util.GetSigMessage(opt)
+	if err != nil {
+		return job, errorx.Internal(err, "failed to get the message to sign")
+	}
+	if err := verifyUserToken(opt.User, opt.Token, hash.HashUsingSha256([]byte(msg))); err != nil {
+		return job, err
+	}
+	if opt.CurrentTime+5*time.Second.Nanoseconds() < time.Now().UnixNano() {
+		return job, errorx.New(errorx.ErrCodeExpired, "request expired")
+	}
+
+	e.rekeyLock.Lock()
+	defer e.rekeyLock.Unlock()
+	if e.rekeyJob != nil && e.rekeyJob.State == types.RekeyJobRunning {
+		return job, errorx.New(errorx.ErrCodeAlreadyExists, "rekey job %s is running", e.rekeyJob.ID)
+	}
+
+	files, err := e.listRekeyFiles(opt.Namespace)
+	if err != nil {
+		return job, err
+	}
+	jobID, err := uuid.NewRandom()
+	if err != nil {
+		return job, errorx.Internal(err, "failed to get uuid")
+	}
+	e.rekeyJob = &types.RekeyJob{
+		ID:            jobID.String(),
+		Namespace:     opt.Namespace,
+		KeyVersion:    e.encryptor.KeyVersion(),
+		MinKeyVersion: e.encryptor.KeyVersion(),
+		State:         types.RekeyJobRunning,
+		Total:         len(files),
+		StartTime:     time.Now().UnixNano(),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	e.rekeyCancel = cancel
+	go e.rekey(ctx, e.rekeyJob, files)
+
+	logger.WithFields(logrus.Fields{
+		"job_id":      e.rekeyJob.ID,
+		"namespace":   opt.Namespace,
+		"key_version": e.rekeyJob.KeyVersion,
+		"files":       len(files),
+	}).Info("rekey job started")
+	return *e.rekeyJob, nil
+}
+
+// GetRekeyJob returns the progress of the latest rekey job
+func (e *Engine) GetRekeyJob() (types.RekeyJob, error) {
+	e.rekeyLock.Lock()
+	defer e.rekeyLock.Unlock()
+	if e.rekeyJob == nil {
+		return types.RekeyJob{}, errorx.New(errorx.ErrCodeNotFound, "no rekey job found")
+	}
+	return *e.rekeyJob, nil
+}
+
+// stopRekey cancels the running rekey job
+func (e *Engine) stopRekey() {
+	e.rekeyLock.Lock()
+	defer e.rekeyLock.Unlock()
+	if e.rekeyCancel != nil {
+		e.rekeyCancel()
+	}
+}
+
+// listRekeyFiles lists unexpired files of the namespace, or of all namespaces if ns is empty
+func (e *Engine) listRekeyFiles(ns string) ([]blockchain.File, error) {
+	pubkey := ecdsa.PublicKeyFromPrivateKey(e.monitor.challengingMonitor.PrivateKey)
+	nsList := []string{ns}
+	if len(ns) == 0 {
+		nss, err := e.chain.ListFileNs(&blockchain.ListNsOptions{
+			Owner:   pubkey[:],
+			TimeEnd: time.Now().UnixNano(),
+		})
+		if err != nil {
+			return nil, errorx.Wrap(err, "failed to list namespaces")
+		}
+		nsList = nsList[:0]
+		for _, n := range nss {
+			nsList = append(nsList, n.Name)
+		}
+	}
+
+	var files []blockchain.File
+	for _, name := range nsList {
+		fs, err := e.chain.ListFiles(&blockchain.ListFileOptions{
+			Owner:       pubkey[:],
+			Namespace:   name,
+			TimeEnd:     time.Now().UnixNano(),
+			CurrentTime: time.Now().UnixNano(),
+		})
+		if err != nil {
+			return nil, errorx.Wrap(err, "failed to list files of namespace %s", name)
+		}
+		files = append(files, fs...)
+	}
+	return files, nil
+}
+
+// rekey re-encrypts files one by one and records the progress into job.
+// Deduplicated files are left under the old password, because their slices are shared with other files,
+// so the job ends Incomplete while any of them remains, as well as when any file fails.
+// MinKeyVersion is lowered to the oldest password any processed file still needs
+func (e *Engine) rekey(ctx context.Context, job *types.RekeyJob, files []blockchain.File) {
+	l := logger.WithField("job_id", job.ID)
+	state := types.RekeyJobFinished
+	for _, f := range files {
+		select {
+		case <-ctx.Done():
+			state = types.RekeyJobCanceled
+		default:
+		}
+		if state == types.RekeyJobCanceled {
+			break
+		}
+
+		skipped := f.GetSliceKeyVersion() >= job.KeyVersion
+		deduplicated := !skipped && f.Dedup
+		var err error
+		if !skipped && !deduplicated {
+			err = e.rekeyFile(ctx, f, job.KeyVersion)
+			if err != nil {
+				l.WithField("file_id", f.ID).WithError(err).Error("failed to rekey file")
+			} else {
+				l.WithField("file_id", f.ID).Info("file rekeyed")
+			}
+		}
+
+		e.rekeyLock.Lock()
+		if v := minKeyVersion(f, job.KeyVersion, err == nil && !skipped && !deduplicated); v < job.MinKeyVersion {
+			job.MinKeyVersion = v
+		}
+		switch {
+		case skipped:
+			job.Skipped++
+		case deduplicated:
+			job.Deduplicated++
+		case err != nil:
+			job.Failed++
+		default:
+			job.Done++
+		}
+		e.rekeyLock.Unlock()
+	}
+
+	e.rekeyLock.Lock()
+	if state == types.RekeyJobFinished && (job.Failed > 0 || job.Deduplicated > 0) {
+		state = types.RekeyJobIncomplete
+	}
+	job.State = state
+	job.EndTime = time.Now().UnixNano()
+	e.rekeyLock.Unlock()
+	l.WithFields(logrus.Fields{
+		"state":           job.State,
+		"done":            job.Done,
+		"failed":          job.Failed,
+		"skipped":         job.Skipped,
+		"deduplicated":    job.Deduplicated,
+		"min_key_version": job.MinKeyVersion,
+	}).Info("rekey job stopped")
+}
+
+// rekeyFile re-encrypts every copy of the file's slices under password of keyVersion on the node storing it.
+// The new copy is kept beside the old one on the node, then challenge materials are generated for the new copies,
+// and slices of the file on blockchain are replaced by the new copies, at last the old copies are deleted.
+// All nodes storing the file must be online, and new copies are deleted if any step fails before
+// the file on blockchain is updated, so that the file is left unchanged
+func (e *Engine) rekeyFile(ctx context.Context, file blockchain.File, keyVersion int) (err error) {
+	allNodes, err := e.chain.ListNodes()
+	if err != nil {
+		return errorx.Wrap(err, "failed to list nodes from blockchain")
+	}
+	nodesMap := common.ToNodesMap(allNodes)
+
+	ca, pairingConf := e.challenger.GetChallengeConf()
+	sourceID := hex.EncodeToString(file.Owner)
+	keyFileID := common.SliceKeyFileID(file)
+	plaintexts := make(map[string][]byte)
+
+	var newSlices []blockchain.PublicSliceMeta
+	var encSlices []encryptor.EncryptedSlice
+	updated := false
+	defer func() {
+		if err != nil && !updated {
+			e.deleteSliceCopies(newSlices, nodesMap)
+		}
+	}()
+	for _, slice := range file.Slices {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		node, exist := nodesMap[string(slice.NodeID)]
+		if !exist || !node.Online {
+			return errorx.New(errorx.ErrCodeNotFound, "node %s storing slice %s is offline", string(slice.NodeID), slice.ID)
+		}
+		plaintext, ok := plaintexts[slice.ID]
+		if !ok {
+			plaintext, err = e.pullRekeySlice(ctx, file, slice.ID, nodesMap)
+			if err != nil {
+				return err
+			}
+			plaintexts[slice.ID] = plaintext
+		}
+
+		es, err := e.encryptor.Encrypt(bytes.NewReader(plaintext), &encryptor.EncryptOptions{
+			FileID:     keyFileID,
+			SliceID:    slice.ID,
+			NodeID:     node.ID,
+			KeyVersion: keyVersion,
+		})
+		if err != nil {
+			return errorx.Wrap(err, "failed to encrypt slice %s", slice.ID)
+		}
+		storIndex, err := e.copier.Push(ctx, storage.CopyKey(slice.ID, uuid.NewString()), sourceID,
+			bytes.NewReader(es.CipherText), &node)
+		if err != nil {
+			return errorx.Wrap(err, "failed to push the new copy of slice %s", slice.ID)
+		}
+		newSlices = append(newSlices, blockchain.PublicSliceMeta{
+			ID:         slice.ID,
+			CipherHash: es.CipherHash,
+			Length:     es.Length,
+			NodeID:     slice.NodeID,
+			SliceIdx:   slice.SliceIdx,
+			StorIndex:  storIndex,
+			Sealed:     seal.IsSealed(es.CipherText),
+		})
+		encSlices = append(encSlices, es)
+	}
+
+	newFile := file
+	newFile.Slices = newSlices
+	interval := e.monitor.challengingMonitor.RequestInterval.Nanoseconds()
+	// sigmas are kept by the nodes beside the new copies
+	if ca == types.PairingChallengeAlgorithm {
+		if err := common.AddSlicesNewPairingChallenge(ctx, pairingConf, e.copier, encSlices, newFile, e.chain, sourceID,
+			interval, time.Now().UnixNano(), file.ExpireTime, nil, logger); err != nil {
+			return errorx.Wrap(err, "failed to add pairing based challenge materials")
+		}
+	}
+
+	// slices may have been migrated by the file maintainer meanwhile
+	latest, err := e.chain.GetFileByID(file.ID)
+	if err != nil {
+		return errorx.Wrap(err, "failed to get file from blockchain")
+	}
+	if !sameSlices(latest.Slices, file.Slices) {
+		return errorx.New(errorx.ErrCodeAlreadyUpdate, "slices of the file changed during re-encryption")
+	}
+	if err := e.updateRekeyedSlices(file, newSlices, keyVersion); err != nil {
+		return err
+	}
+	updated = true
+
+	// merkle materials are located by slice and node, which are the same for old and new copies
+	if ca == types.MerkleChallengeAlgorithm {
+		if err := e.challenger.Remove(file.ID); err != nil {
+			return errorx.Wrap(err, "failed to remove merkle challenge materials of the old copies")
+		}
+		if err := common.AddSlicesNewMerkleChallenge(e.challenger, newFile, encSlices, interval, logger); err != nil {
+			return errorx.Wrap(err, "failed to add merkle challenge materials")
+		}
+	}
+
+	// old copies are no longer referenced by the file
+	e.deleteSliceCopies(file.Slices, nodesMap)
+	return nil
+}
+
+// pullRekeySlice pulls a slice from any of its copies and decrypts it under the file's slice key version
+func (e *Engine) pullRekeySlice(ctx context.Context, file blockchain.File, sliceID string,
+	nodesMap map[string]blockchain.Node) ([]byte, error) {
+	for _, s := range file.Slices {
+		if s.ID != sliceID {
+			continue
+		}
+		node, exist := nodesMap[string(s.NodeID)]
+		if !exist || !node.Online {
+			continue
+		}
+		plaintext, err := common.PullAndDec(ctx, e.copier, e.encryptor, s, &node, file.ID,
+			common.SliceKeyFileID(file), file.GetSliceKeyVersion())
+		if err != nil {
+			logger.WithFields(logrus.Fields{
+				"slice_id":    sliceID,
+				"target_node": string(node.ID),
+			}).WithError(err).Warn("failed to pull slice")
+			continue
+		}
+		return plaintext, nil
+	}
+	return nil, errorx.New(errorx.ErrCodeNotFound, "failed to pull slice %s", sliceID)
+}
+
+// updateRekeyedSlices replaces slices of the file on blockchain with the re-encrypted ones
+func (e *Engine) updateRekeyedSlices(file blockchain.File, slices []blockchain.PublicSliceMeta, keyVersion int) error {
+	opt := &blockchain.UpdateFilePSMOptions{
+		FileID:          file.ID,
+		Owner:           file.Owner,
+		Slices:          slices,
+		SliceKeyVersion: keyVersion,
+		CurrentTime:     time.Now().UnixNano(),
+	}
+	msg, err := util.GetSigMessage(opt)
+	if err != nil {
+		return errorx.Internal(err, "failed to get the message to sign")
+	}
+	sig, err := ecdsa.Sign(e.monitor.challengingMonitor.PrivateKey, hash.HashUsingSha256([]byte(msg)))
+	if err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeCrypto, "failed to sign slices")
+	}
+	opt.Signature = sig[:]
+	if err := e.chain.UpdateFilePublicSliceMeta(opt); err != nil {
+		return errorx.Wrap(err, "failed to update file slices on blockchain")
+	}
+	return nil
+}
+
+// deleteSliceCopies removes copies of slices from storage nodes, failures are only logged,
+// leftover copies are cleaned by storage nodes after the file expires
+func (e *Engine) deleteSliceCopies(slices []blockchain.PublicSliceMeta, nodesMap map[string]blockchain.Node) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	for _, s := range slices {
+		node, exist := nodesMap[string(s.NodeID)]
+		if !exist {
+			continue
+		}
+		if err := e.copier.Delete(ctx, s.ID, s.StorIndex, &node); err != nil {
+			logger.WithFields(logrus.Fields{
+				"slice_id":    s.ID,
+				"target_node": string(s.NodeID),
+			}).WithError(err).Warn("failed to delete slice copy")
+		}
+	}
+}
+
+// minKeyVersion returns the oldest password version the file needs, the structure and contents of the file
+// keep the key version it was written with, while its slices are under keyVersion once rekeyed
+func minKeyVersion(f blockchain.File, keyVersion int, rekeyed bool) int {
+	v := f.GetSliceKeyVersion()
+	if rekeyed {
+		v = keyVersion
+	}
+	if f.GetKeyVersion() < v {
+		return f.GetKeyVersion()
+	}
+	return v
+}
+
+// sameSlices checks if two lists of slices are the same copies in the same order
+func sameSlices(a, b []blockchain.PublicSliceMeta) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].ID != b[i].ID || !bytes.Equal(a[i].NodeID, b[i].NodeID) || a[i].StorIndex != b[i].StorIndex {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/config"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/encryptor/soft"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/slicer/cdc"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/types"
+)
+
+// rotatePassword makes the password of version 2 current, and keeps the initial one as an old password
+func (te *testEngine) rotatePassword(t *testing.T) {
+	te.setPassword(t, map[string]string{"1": "test password"})
+}
+
+// setPassword makes the password of version 2 current with the given old passwords
+func (te *testEngine) setPassword(t *testing.T, oldPasswords map[string]string) {
+	enc, err := soft.New(&config.SoftEncryptorConf{
+		Password:        "new password",
+		PasswordVersion: 2,
+		OldPasswords:    oldPasswords,
+		FileKeyPath:     filepath.Join(te.dir, "keys"),
+	}, nil)
+	require.NoError(t, err)
+	te.encryptor = enc
+}
+
+// rekey runs a rekey job of the namespace and waits for it to stop
+func (te *testEngine) rekey(t *testing.T, ns string) types.RekeyJob {
+	opt := types.RekeyOptions{
+		Namespace:   ns,
+		CurrentTime: time.Now().UnixNano(),
+		User:        te.pubkey.String(),
+	}
+	opt.Token = te.token(t, opt)
+	_, err := te.StartRekey(opt)
+	require.NoError(t, err)
+
+	var job types.RekeyJob
+	require.Eventually(t, func() bool {
+		job, err = te.GetRekeyJob()
+		require.NoError(t, err)
+		return job.State != types.RekeyJobRunning
+	}, 10*time.Second, 10*time.Millisecond)
+	return job
+}
+
+func TestRekeyInPlace(t *testing.T) {
+	te := newTestEngine(t, 3)
+	te.addNs(t, "ns", 2)
+	content := testContent(300)
+	id := te.write(t, "ns", "file", content)
+	old, err := te.chain.GetFileByID(id)
+	require.NoError(t, err)
+
+	te.rotatePassword(t)
+	job := te.rekey(t, "ns")
+	require.Equal(t, types.RekeyJobFinished, job.State)
+	require.Equal(t, 1, job.Done)
+	// the structure and contents of the file stay under the password it was written with
+	require.Equal(t, 1, job.MinKeyVersion)
+
+	// copies are re-encrypted on the nodes storing them
+	f, err := te.chain.GetFileByID(id)
+	require.NoError(t, err)
+	require.Equal(t, 2, f.GetSliceKeyVersion())
+	require.Len(t, f.Slices, len(old.Slices))
+	for i, s := range f.Slices {
+		require.Equal(t, old.Slices[i].ID, s.ID)
+		require.Equal(t, old.Slices[i].NodeID, s.NodeID)
+		require.NotEqual(t, old.Slices[i].StorIndex, s.StorIndex)
+		require.NotEqual(t, old.Slices[i].CipherHash, s.CipherHash)
+	}
+	// old copies are deleted
+	require.Len(t, te.copier.slices, len(f.Slices))
+	data, err := te.read(t, id, 0, 0)
+	require.NoError(t, err)
+	require.Equal(t, content, data)
+
+	// files already under the current password are skipped
+	job = te.rekey(t, "ns")
+	require.Equal(t, types.RekeyJobFinished, job.State)
+	require.Equal(t, 1, job.Skipped)
+	require.Equal(t, 1, job.MinKeyVersion)
+
+	// so the old password is still needed to read it
+	te.setPassword(t, nil)
+	_, err = te.read(t, id, 0, 0)
+	require.Error(t, err)
+	te.rotatePassword(t)
+	data, err = te.read(t, id, 0, 0)
+	require.NoError(t, err)
+	require.Equal(t, content, data)
+
+	// files written under the current password need no old password
+	te.addNs(t, "new", 2)
+	newID := te.write(t, "new", "file", content)
+	job = te.rekey(t, "new")
+	require.Equal(t, 1, job.Skipped)
+	require.Equal(t, 2, job.MinKeyVersion)
+	te.setPassword(t, nil)
+	data, err = te.read(t, newID, 0, 0)
+	require.NoError(t, err)
+	require.Equal(t, content, data)
+}
+
+func TestRekeyDeduplicated(t *testing.T) {
+	te := newTestEngine(t, 3)
+	sl, err := cdc.New(&config.CDCSlicerConf{MinSize: 64, AvgSize: 256, MaxSize: 1024})
+	require.NoError(t, err)
+	te.slicer = sl
+	te.addDedupNs(t, "dedup", 2)
+	id := te.write(t, "dedup", "file", testContent(300))
+
+	// the job is not reported finished while deduplicated files are under the old password
+	te.rotatePassword(t)
+	job := te.rekey(t, "dedup")
+	require.Equal(t, types.RekeyJobIncomplete, job.State)
+	require.Equal(t, 1, job.Deduplicated)
+	require.Equal(t, 1, job.MinKeyVersion)
+	require.Zero(t, job.Done)
+	f, err := te.chain.GetFileByID(id)
+	require.NoError(t, err)
+	require.True(t, f.Dedup)
+	require.Equal(t, 1, f.GetSliceKeyVersion())
+}
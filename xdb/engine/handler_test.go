@@ -0,0 +1,104 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/ecdsa"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/config"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/common"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/types"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/peer"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/storage"
+	localstorage "github.com/PaddlePaddle/PaddleDTX/xdb/storage/local"
+)
+
+// storageEngine returns the Engine of the storage node, which keeps slices pushed by te
+func (te *testEngine) storageEngine(t *testing.T, privkey ecdsa.PrivateKey) (*Engine, *blockchain.Node) {
+	pubkey := ecdsa.PublicKeyFromPrivateKey(privkey)
+	node := &blockchain.Node{ID: []byte(pubkey.String())}
+	proveStor, err := localstorage.New(filepath.Join(te.dir, "prove-"+pubkey.String()))
+	require.NoError(t, err)
+
+	e, err := NewEngine(&config.MonitorConf{ChallengingSwitch: "on"}, &NewEngineOption{
+		LocalNode: peer.Local{ID: pubkey[:], PrivateKey: privkey},
+		Chain:     te.chain,
+		ProveStor: proveStor,
+		SliceStor: te.copier.storage(node),
+	})
+	require.NoError(t, err)
+	t.Cleanup(e.Close)
+	return e, node
+}
+
+// deleteSlice asks the storage node to delete the slice pushed by te
+func (te *testEngine) deleteSlice(t *testing.T, e *Engine, sliceID, storIndex string) error {
+	key := common.GetSliceSourceKey(storage.KeyOf(sliceID, storIndex))
+	require.NoError(t, e.proveStorage.SaveAndUpdate(key, strings.NewReader(te.pubkey.String())))
+
+	opt := types.DeleteSliceOptions{
+		SliceID:   sliceID,
+		StorIndex: storIndex,
+		SourceID:  te.pubkey.String(),
+		Timestamp: time.Now().UnixNano(),
+	}
+	opt.Signature = te.token(t, opt)
+	return e.DeleteSlice(opt)
+}
+
+func TestDeleteSlice(t *testing.T) {
+	te := newTestEngine(t, 3)
+	te.addNs(t, "ns", 1)
+	fileID := te.write(t, "ns", "file", bytes.Repeat([]byte("x"), 3*testBlockSize))
+	f, err := te.chain.GetFileByID(fileID)
+	require.NoError(t, err)
+
+	// slices are placed on random nodes, take the node storing the first one
+	slice := f.Slices[0]
+	var privkey ecdsa.PrivateKey
+	for _, sk := range te.nodes {
+		pk := ecdsa.PublicKeyFromPrivateKey(sk)
+		if string(slice.NodeID) == pk.String() {
+			privkey = sk
+		}
+	}
+	e, node := te.storageEngine(t, privkey)
+	require.Equal(t, slice.NodeID, node.ID)
+	stor := te.copier.storage(node)
+
+	// slices of live files are kept
+	err = te.deleteSlice(t, e, slice.ID, slice.StorIndex)
+	require.True(t, errorx.Is(err, errorx.ErrCodeNotAuthorized), "%v", err)
+	exist, err := stor.Exist(slice.ID, slice.StorIndex)
+	require.NoError(t, err)
+	require.True(t, exist)
+
+	// slices no file refers to are deleted, such as slices of aborted uploads
+	index, err := stor.Save("orphan", strings.NewReader("orphan slice"))
+	require.NoError(t, err)
+	require.NoError(t, te.deleteSlice(t, e, "orphan", index))
+	exist, err = stor.Exist("orphan", index)
+	require.NoError(t, err)
+	require.False(t, exist)
+}
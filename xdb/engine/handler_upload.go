@@ -0,0 +1,793 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/aes"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/ecdsa"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/hash"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/common"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/encryptor"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/encryptor/chunk"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/slicer"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/types"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+	util "github.com/PaddlePaddle/PaddleDTX/xdb/pkgs/strings"
+)
+
+const (
+	// pendingKeyID is the slice id to derive the key of plaintext pending in upload sessions
+	pendingKeyID = "upload-pending"
+
+	defaultSessionCleanInterval = time.Hour
+)
+
+// uploadSessionIndexID is the key of ids of all upload sessions in ProveStorage, keys of which must be uuid
+var uploadSessionIndexID = uuid.NewSHA1(uuid.NameSpaceOID, []byte("xdb-upload-sessions")).String()
+
+// uploadSession is the progress of a resumable upload, persisted in ProveStorage by session id.
+// The file is encrypted chunk by chunk, see chunk.Cipher, and ciphertext is pushed once it fills whole slices.
+// The rest of ciphertext and plaintext after the last whole chunk are kept in ProveStorage by TailID
+// until next range arrives or the session is committed, see uploadTail.
+// Session id is also the id of the file to be published
+type uploadSession struct {
+	ID           string             `json:"id"`
+	Options      types.WriteOptions `json:"options"`
+	Replica      int                `json:"replica"`
+	DataShards   int                `json:"dataShards"`
+	ParityShards int                `json:"parityShards"`
+	Offset       uint64             `json:"offset"` // length of plaintext uploaded
+	TailID       string             `json:"tailID"`
+	Stripes      int                `json:"stripes"`    // number of stripes pushed, for erasure coded namespace
+	Sealed       bool               `json:"sealed"`     // whether the tail and the last chunk are pushed
+	KeyVersion   int                `json:"keyVersion"` // version of password the file is encrypted under
+	SliceMetas   []slicer.SliceMeta `json:"sliceMetas"`
+	Slices       []sessionSlice     `json:"slices"`
+	CreateTime   int64              `json:"createTime"`
+	UpdateTime   int64              `json:"updateTime"`
+
+	// nonces of chunks are derived from their indexes, so a chunk is never encrypted again from other plaintext.
+	// Chunks before index Encrypted have been encrypted, and EncryptedDigest is the digest of plaintext
+	// of chunks encrypted from Offset, which may have been pushed by an attempt failed to save the session.
+	// LastSealing is whether the last chunk has been encrypted by a commit
+	Encrypted       uint64 `json:"encrypted,omitempty"`
+	EncryptedDigest []byte `json:"encryptedDigest,omitempty"`
+	LastSealing     bool   `json:"lastSealing,omitempty"`
+}
+
+// uploadTail is the part of an upload session not pushed yet
+type uploadTail struct {
+	Ciphertext []byte `json:"ciphertext"` // ciphertext shorter than a slice
+	Pending    []byte `json:"pending"`    // plaintext after the last whole chunk, encrypted by sealPending
+}
+
+// sessionSlice is a ciphertext slice pushed in an upload session
+type sessionSlice struct {
+	encryptor.EncryptedSliceMeta
+	StorIndex string `json:"storIndex"`
+	SliceIdx  int    `json:"sliceIdx"` // index of the slice among all slices on the node, for pairing based challenge
+}
+
+// namespace returns the storage configuration of the namespace when the session is created
+func (s *uploadSession) namespace() blockchain.Namespace {
+	return blockchain.Namespace{
+		Replica:      s.Replica,
+		DataShards:   s.DataShards,
+		ParityShards: s.ParityShards,
+	}
+}
+
+// InitUpload creates a resumable upload session, the file is published after all ranges
+// are uploaded by UploadRange and the session is committed by CommitUpload
+func (e *Engine) InitUpload(ctx context.Context, opt types.WriteOptions) (resp types.UploadSessionResponse, err error) {
+	if e.proveStorage == nil {
+		return resp, errorx.New(errorx.ErrCodeConfig, "upload session is not enabled")
+	}
+	// check key match
+	if err := e.verifyUserID(opt.User); err != nil {
+		return resp, err
+	}
+	// verify token
+	msg, err := util.GetSigMessage(opt)
+	if err != nil {
+		return resp, errorx.Internal(err, "failed to get the message to sign for upload files")
+	}
+	if err := verifyUserToken(opt.User, opt.Token, hash.HashUsingSha256([]byte(msg))); err != nil {
+		return resp, errorx.Wrap(err, "failed to verify token")
+	}
+
+	pubkey := ecdsa.PublicKeyFromPrivateKey(e.monitor.challengingMonitor.PrivateKey)
+	opt.User = pubkey.String()
+
+	ns, err := e.chain.GetNsByName(pubkey[:], opt.Namespace)
+	if err != nil {
+		return resp, errorx.Wrap(err, "failed to get ns from blockchain")
+	}
+	nodes, err := common.GetHealthNodes(e.chain)
+	if err != nil {
+		return resp, err
+	}
+	if err := checkHealthNodes(ns, nodes); err != nil {
+		return resp, err
+	}
+	if err := e.checkSlicer(ns); err != nil {
+		return resp, err
+	}
+	fileID, err := uuid.NewRandom()
+	if err != nil {
+		return resp, errorx.Internal(err, "failed to get uuid")
+	}
+	tailID, err := uuid.NewRandom()
+	if err != nil {
+		return resp, errorx.Internal(err, "failed to get uuid")
+	}
+
+	// generate the key seed of file, so that keys of file can be destroyed after the file is deleted
+	if err := e.encryptor.CreateFileKey(fileID.String()); err != nil {
+		return resp, errorx.Wrap(err, "failed to create file key")
+	}
+	session := &uploadSession{
+		ID:           fileID.String(),
+		Options:      opt,
+		Replica:      ns.Replica,
+		DataShards:   ns.DataShards,
+		ParityShards: ns.ParityShards,
+		TailID:       tailID.String(),
+		KeyVersion:   e.encryptor.KeyVersion(),
+		CreateTime:   time.Now().UnixNano(),
+	}
+	if err := e.saveUploadSession(session); err != nil {
+		return resp, err
+	}
+	if err := e.updateUploadSessionIndex(session.ID, true); err != nil {
+		e.removeUploadSession(session)
+		return resp, err
+	}
+
+	logger.WithFields(logrus.Fields{
+		"session_id": session.ID,
+		"file_name":  opt.FileName,
+		"namespace":  opt.Namespace,
+	}).Info("upload session created")
+
+	return e.uploadSessionResponse(session), nil
+}
+
+// UploadRange uploads a range of the file into the session, ranges must be uploaded in order,
+// so opt.Offset must be equal to the length of the file already uploaded.
+// The range is encrypted and pushed into storage nodes once it fills whole slices
+func (e *Engine) UploadRange(ctx context.Context, opt types.UploadRangeOptions, r io.Reader) (
+	resp types.UploadSessionResponse, err error) {
+
+	if err := e.verifySessionToken(opt.User, opt.Token, opt); err != nil {
+		return resp, err
+	}
+	unlock := e.lockUploadSession(opt.SessionID)
+	defer unlock()
+
+	session, err := e.loadUploadSession(opt.SessionID)
+	if err != nil {
+		return resp, err
+	}
+	if session.Sealed || session.LastSealing {
+		return resp, errorx.New(errorx.ErrCodeParam, "upload session is being committed")
+	}
+	if opt.Offset != session.Offset {
+		return resp, errorx.New(errorx.ErrCodeParam, "unexpected offset %d, the next range should start from %d",
+			opt.Offset, session.Offset)
+	}
+	plaintext, err := ioutil.ReadAll(r)
+	if err != nil {
+		return resp, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to read range")
+	}
+
+	// encrypt whole chunks of the range along with plaintext pending from previous ranges,
+	// the session is not updated until slices are pushed
+	tail, err := e.loadUploadTail(session)
+	if err != nil {
+		return resp, err
+	}
+	pending, err := e.openPending(session, tail.Pending)
+	if err != nil {
+		return resp, err
+	}
+	data := append(pending, plaintext...)
+	n := len(data) / chunk.Size * chunk.Size
+	if err := e.reserveChunks(session, data[:n]); err != nil {
+		return resp, err
+	}
+	c, err := e.fileCipher(session.ID, session.KeyVersion)
+	if err != nil {
+		return resp, err
+	}
+	ciphertext, err := c.EncryptChunks(data[:n], session.Offset/chunk.Size, false)
+	if err != nil {
+		return resp, errorx.NewCode(err, errorx.ErrCodeCrypto, "range encryption failed")
+	}
+	ciphertext = append(tail.Ciphertext, ciphertext...)
+
+	// push whole slices, keep the rest as tail
+	blockSize := e.slicer.GetBlockSize()
+	m := len(ciphertext) / blockSize * blockSize
+	if m > 0 {
+		if err := e.pushSessionSlices(ctx, session, ciphertext[:m]); err != nil {
+			return resp, err
+		}
+	}
+	newTail := uploadTail{Ciphertext: ciphertext[m:]}
+	if newTail.Pending, err = e.sealPending(session, data[n:]); err != nil {
+		return resp, err
+	}
+	// the tail is saved with a new key, so that the session is consistent if saving session failed
+	tailID, err := e.saveUploadTail(newTail)
+	if err != nil {
+		return resp, err
+	}
+	oldTailID := session.TailID
+	session.TailID = tailID
+	session.Offset += uint64(len(plaintext))
+	session.EncryptedDigest = nil
+	session.UpdateTime = time.Now().UnixNano()
+	if err := e.saveUploadSession(session); err != nil {
+		return resp, err
+	}
+	if exist, _ := e.proveStorage.Exist(oldTailID); exist {
+		if _, err := e.proveStorage.Delete(oldTailID); err != nil {
+			logger.WithError(err).WithField("session_id", session.ID).Warn("failed to remove upload tail")
+		}
+	}
+
+	return e.uploadSessionResponse(session), nil
+}
+
+// GetUploadSession returns the progress of the session, which is used to resume an interrupted upload
+func (e *Engine) GetUploadSession(opt types.UploadSessionOptions) (resp types.UploadSessionResponse, err error) {
+	if err := e.verifySessionToken(opt.User, opt.Token, opt); err != nil {
+		return resp, err
+	}
+	session, err := e.loadUploadSession(opt.SessionID)
+	if err != nil {
+		return resp, err
+	}
+	return e.uploadSessionResponse(session), nil
+}
+
+// CommitUpload finishes the session, the tail of the file is pushed into storage nodes along with
+// the last chunk, then the file's digest info is stored into blockchain
+func (e *Engine) CommitUpload(ctx context.Context, opt types.UploadSessionOptions) (resp types.WriteResponse, err error) {
+	if err := e.verifySessionToken(opt.User, opt.Token, opt); err != nil {
+		return resp, err
+	}
+	unlock := e.lockUploadSession(opt.SessionID)
+	defer unlock()
+
+	session, err := e.loadUploadSession(opt.SessionID)
+	if err != nil {
+		return resp, err
+	}
+	if session.Options.ExpireTime <= time.Now().UnixNano() {
+		return resp, errorx.New(errorx.ErrCodeExpired, "file expire time has passed, please abort the session")
+	}
+
+	// push the tail of ciphertext and the last chunk,
+	// and save progress so that they are not pushed again if publishing failed
+	if !session.Sealed {
+		tail, err := e.loadUploadTail(session)
+		if err != nil {
+			return resp, err
+		}
+		pending, err := e.openPending(session, tail.Pending)
+		if err != nil {
+			return resp, err
+		}
+		// ranges can't be uploaded once the last chunk is encrypted, so that a retried commit
+		// encrypts the last chunk from the same plaintext
+		if !session.LastSealing {
+			session.LastSealing = true
+			if err := e.saveUploadSession(session); err != nil {
+				return resp, err
+			}
+		}
+		c, err := e.fileCipher(session.ID, session.KeyVersion)
+		if err != nil {
+			return resp, err
+		}
+		last, err := c.EncryptChunks(pending, session.Offset/chunk.Size, true)
+		if err != nil {
+			return resp, errorx.NewCode(err, errorx.ErrCodeCrypto, "failed to encrypt the last chunk")
+		}
+		if err := e.pushSessionSlices(ctx, session, append(tail.Ciphertext, last...)); err != nil {
+			return resp, err
+		}
+		session.Sealed = true
+		if err := e.saveUploadSession(session); err != nil {
+			return resp, err
+		}
+	}
+
+	// Write meta info to blockchain
+	ca, pairingConf := e.challenger.GetChallengeConf()
+	encSlices := make([]encryptor.EncryptedSlice, 0, len(session.Slices))
+	storIndexes := make([]string, 0, len(session.Slices))
+	sliceIdxMap := make(map[string]int)
+	for _, s := range session.Slices {
+		encSlices = append(encSlices, encryptor.EncryptedSlice{EncryptedSliceMeta: s.EncryptedSliceMeta})
+		storIndexes = append(storIndexes, s.StorIndex)
+		sliceIdxMap[s.SliceID+string(s.NodeID)] = s.SliceIdx
+	}
+	chainFile, err := e.packChainFile(session.ID, ca, session.Options, session.SliceMetas, int(session.Offset),
+		encSlices, storIndexes, pairingConf, session.KeyVersion)
+	if err != nil {
+		return resp, errorx.Wrap(err, "failed to pack chain file")
+	}
+	chainFile.DataShards = session.DataShards
+	chainFile.ParityShards = session.ParityShards
+	chainFile.Chunked = true
+	// slice indexes were assigned when pairing based challenge materials were generated
+	if ca == types.PairingChallengeAlgorithm {
+		for i, s := range chainFile.Slices {
+			chainFile.Slices[i].SliceIdx = sliceIdxMap[s.ID+string(s.NodeID)]
+		}
+	}
+	version, err := e.publishFile(chainFile, nil)
+	if err != nil {
+		return resp, err
+	}
+
+	e.removeUploadSession(session)
+	logger.WithField("file_id", session.ID).Debug("file uploaded")
+	resp.FileID = session.ID
+	resp.Version = version
+	return resp, nil
+}
+
+// AbortUpload cancels the session and removes slices already pushed from storage nodes.
+// If some slices failed to be removed, they are kept in the session, and the session can be aborted again
+func (e *Engine) AbortUpload(ctx context.Context, opt types.UploadSessionOptions) error {
+	if err := e.verifySessionToken(opt.User, opt.Token, opt); err != nil {
+		return err
+	}
+	unlock := e.lockUploadSession(opt.SessionID)
+	defer unlock()
+
+	session, err := e.loadUploadSession(opt.SessionID)
+	if err != nil {
+		return err
+	}
+	if err := e.abortUploadSession(ctx, session); err != nil {
+		return err
+	}
+	logger.WithField("session_id", session.ID).Info("upload session aborted")
+	return nil
+}
+
+// abortUploadSession removes slices of the session from storage nodes, and challenge materials,
+// the file key and the session from local. The session must be locked by caller
+func (e *Engine) abortUploadSession(ctx context.Context, session *uploadSession) error {
+	nodes, err := e.chain.ListNodes()
+	if err != nil {
+		return errorx.Wrap(err, "failed to list nodes from blockchain")
+	}
+	nodesMap := common.ToNodesMap(nodes)
+
+	var remained []sessionSlice
+	for _, s := range session.Slices {
+		node, exist := nodesMap[string(s.NodeID)]
+		if !exist {
+			remained = append(remained, s)
+			continue
+		}
+		if err := e.copier.Delete(ctx, s.SliceID, s.StorIndex, &node); err != nil {
+			logger.WithFields(logrus.Fields{
+				"slice_id":    s.SliceID,
+				"target_node": string(s.NodeID),
+			}).WithError(err).Warn("failed to delete slice of aborted session")
+			remained = append(remained, s)
+		}
+	}
+	if len(remained) > 0 {
+		session.Slices = remained
+		if err := e.saveUploadSession(session); err != nil {
+			return err
+		}
+		return errorx.New(errorx.ErrCodeInternal, "failed to delete %d slices, please retry later", len(remained))
+	}
+
+	if err := e.challenger.Remove(session.ID); err != nil {
+		logger.WithError(err).WithField("session_id", session.ID).Warn("failed to remove challenge materials")
+	}
+	if err := e.encryptor.DestroyFileKey(session.ID); err != nil {
+		logger.WithError(err).WithField("session_id", session.ID).Warn("failed to destroy file key")
+	}
+	e.removeUploadSession(session)
+	return nil
+}
+
+// cleanUploadSessions aborts sessions not updated within sessionTTL periodically until ctx is done,
+// so that slices, challenge materials and file keys of abandoned uploads don't stay forever
+func (e *Engine) cleanUploadSessions(ctx context.Context) {
+	interval := defaultSessionCleanInterval
+	if e.sessionTTL < interval {
+		interval = e.sessionTTL
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		e.cleanExpiredSessions(ctx)
+	}
+}
+
+// cleanExpiredSessions aborts sessions not updated within sessionTTL
+func (e *Engine) cleanExpiredSessions(ctx context.Context) {
+	ids, err := e.listUploadSessions()
+	if err != nil {
+		logger.WithError(err).Warn("failed to list upload sessions")
+		return
+	}
+	deadline := time.Now().Add(-e.sessionTTL).UnixNano()
+	for _, id := range ids {
+		unlock := e.lockUploadSession(id)
+		session, err := e.loadUploadSession(id)
+		if err != nil {
+			if errorx.Is(err, errorx.ErrCodeNotFound) {
+				e.sessionLocks.Delete(id)
+				if err := e.updateUploadSessionIndex(id, false); err != nil {
+					logger.WithError(err).WithField("session_id", id).Warn("failed to remove upload session from index")
+				}
+			} else {
+				logger.WithError(err).WithField("session_id", id).Warn("failed to load upload session")
+			}
+			unlock()
+			continue
+		}
+		updateTime := session.UpdateTime
+		if updateTime == 0 {
+			updateTime = session.CreateTime
+		}
+		if updateTime < deadline {
+			if err := e.abortUploadSession(ctx, session); err != nil {
+				logger.WithError(err).WithField("session_id", id).Warn("failed to abort expired upload session")
+			} else {
+				logger.WithField("session_id", id).Info("expired upload session aborted")
+			}
+		}
+		unlock()
+	}
+}
+
+// pushSessionSlices pushes ciphertext into storage nodes and records slices in the session,
+// challenge materials are generated at the same time, as ciphertext slices are not kept.
+// Slices pushed are removed if error occurred
+func (e *Engine) pushSessionSlices(ctx context.Context, session *uploadSession, ciphertext []byte) error {
+	nodes, err := common.GetHealthNodes(e.chain)
+	if err != nil {
+		return err
+	}
+	ns := session.namespace()
+	if err := checkHealthNodes(ns, nodes); err != nil {
+		return err
+	}
+	pushed, err := e.pushSlices(ctx, bytes.NewReader(ciphertext), session.ID, session.Options.User, ns, nodes,
+		session.Stripes, session.KeyVersion)
+
+	// slice index is the order of slice among all slices of the file on the same node
+	count := make(map[string]int)
+	for _, s := range session.Slices {
+		count[string(s.NodeID)]++
+	}
+	slices := make([]sessionSlice, 0, len(pushed.encSlices))
+	for i, s := range pushed.encSlices {
+		count[string(s.NodeID)]++
+		slices = append(slices, sessionSlice{
+			EncryptedSliceMeta: s.EncryptedSliceMeta,
+			StorIndex:          pushed.storIndexes[i],
+			SliceIdx:           count[string(s.NodeID)],
+		})
+	}
+	if err == nil {
+		err = e.generateSessionChallenges(ctx, session, pushed.encSlices, slices)
+	}
+	if err != nil {
+		nodesMap := common.ToNodeHsMap(nodes)
+		for i, s := range pushed.encSlices {
+			node := nodesMap[string(s.NodeID)]
+			if err := e.copier.Delete(ctx, s.SliceID, pushed.storIndexes[i], &node); err != nil {
+				logger.WithError(err).WithField("slice_id", s.SliceID).Warn("failed to delete slice")
+			}
+		}
+		return err
+	}
+
+	session.SliceMetas = append(session.SliceMetas, pushed.metas...)
+	session.Slices = append(session.Slices, slices...)
+	for _, m := range pushed.metas {
+		if m.Stripe >= session.Stripes {
+			session.Stripes = m.Stripe + 1
+		}
+	}
+	session.UpdateTime = time.Now().UnixNano()
+	return nil
+}
+
+// generateSessionChallenges generates challenge materials for ciphertext slices pushed in the session,
+// slices are the records of encSlices in the session
+func (e *Engine) generateSessionChallenges(ctx context.Context, session *uploadSession,
+	encSlices []encryptor.EncryptedSlice, slices []sessionSlice) error {
+
+	ca, pairingConf := e.challenger.GetChallengeConf()
+	switch ca {
+	case types.MerkleChallengeAlgorithm:
+		return e.generateAndSaveMerkle(encSlices, session.ID, session.Options.ExpireTime)
+	case types.PairingChallengeAlgorithm:
+		file := blockchain.File{ID: session.ID}
+		for _, s := range slices {
+			file.Slices = append(file.Slices, blockchain.PublicSliceMeta{
+				ID:       s.SliceID,
+				NodeID:   s.NodeID,
+				SliceIdx: s.SliceIdx,
+			})
+		}
+		return common.AddSlicesNewPairingChallenge(ctx, pairingConf, e.copier, encSlices, file, e.chain,
+			session.Options.User, e.monitor.challengingMonitor.RequestInterval.Nanoseconds(), time.Now().UnixNano(),
+			session.Options.ExpireTime, nil, logger)
+	}
+	return nil
+}
+
+// reserveChunks records whole chunks of plaintext to be encrypted from the offset of the session,
+// and saves the session before their ciphertext is pushed. Chunks already encrypted by a failed attempt
+// can only be encrypted again from the same plaintext, which results in the same ciphertext
+func (e *Engine) reserveChunks(session *uploadSession, plaintext []byte) error {
+	index := session.Offset / chunk.Size
+	end := index + uint64(len(plaintext)/chunk.Size)
+	if session.Encrypted > index {
+		used := (session.Encrypted - index) * chunk.Size
+		if uint64(len(plaintext)) < used {
+			return errorx.New(errorx.ErrCodeParam, "range is shorter than the one failed at offset %d, "+
+				"please upload the same content", session.Offset)
+		}
+		digest := sha256.Sum256(plaintext[:used])
+		if !bytes.Equal(digest[:], session.EncryptedDigest) {
+			return errorx.New(errorx.ErrCodeParam, "range differs from the one failed at offset %d, "+
+				"please upload the same content", session.Offset)
+		}
+	}
+	if end <= session.Encrypted {
+		return nil
+	}
+	digest := sha256.Sum256(plaintext)
+	session.Encrypted = end
+	session.EncryptedDigest = digest[:]
+	return e.saveUploadSession(session)
+}
+
+// verifySessionToken checks if the user is allowed to operate upload sessions and the token is valid
+func (e *Engine) verifySessionToken(user, token string, opt interface{}) error {
+	if e.proveStorage == nil {
+		return errorx.New(errorx.ErrCodeConfig, "upload session is not enabled")
+	}
+	if err := e.verifyUserID(user); err != nil {
+		return err
+	}
+	msg, err := util.GetSigMessage(opt)
+	if err != nil {
+		return errorx.Internal(err, "failed to get the message to sign for upload session")
+	}
+	if err := verifyUserToken(user, token, hash.HashUsingSha256([]byte(msg))); err != nil {
+		return errorx.Wrap(err, "failed to verify token")
+	}
+	return nil
+}
+
+// lockUploadSession prevents a session from being operated concurrently, returns the unlock function
+func (e *Engine) lockUploadSession(id string) func() {
+	l, _ := e.sessionLocks.LoadOrStore(id, &sync.Mutex{})
+	mu := l.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
+
+// loadUploadSession loads session from ProveStorage
+func (e *Engine) loadUploadSession(id string) (*uploadSession, error) {
+	s, err := e.proveStorage.LoadStr(id)
+	if err != nil {
+		if errorx.Is(err, errorx.ErrCodeNotFound) {
+			return nil, errorx.New(errorx.ErrCodeNotFound, "upload session not found")
+		}
+		return nil, errorx.Wrap(err, "failed to load upload session")
+	}
+	var session uploadSession
+	if err := json.Unmarshal([]byte(s), &session); err != nil {
+		return nil, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to unmarshal upload session")
+	}
+	return &session, nil
+}
+
+// saveUploadSession saves session into ProveStorage
+func (e *Engine) saveUploadSession(session *uploadSession) error {
+	s, err := json.Marshal(session)
+	if err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal upload session")
+	}
+	if err := e.proveStorage.SaveAndUpdate(session.ID, bytes.NewReader(s)); err != nil {
+		return errorx.Wrap(err, "failed to save upload session")
+	}
+	return nil
+}
+
+// loadUploadTail loads the part of the session which is not pushed yet
+func (e *Engine) loadUploadTail(session *uploadSession) (tail uploadTail, err error) {
+	if exist, _ := e.proveStorage.Exist(session.TailID); !exist {
+		return tail, nil
+	}
+	s, err := e.proveStorage.LoadStr(session.TailID)
+	if err != nil {
+		return tail, errorx.Wrap(err, "failed to load upload tail")
+	}
+	if err := json.Unmarshal([]byte(s), &tail); err != nil {
+		return tail, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to unmarshal upload tail")
+	}
+	return tail, nil
+}
+
+// saveUploadTail saves the tail with a new id and returns the id
+func (e *Engine) saveUploadTail(tail uploadTail) (string, error) {
+	tailID, err := uuid.NewRandom()
+	if err != nil {
+		return "", errorx.Internal(err, "failed to get uuid")
+	}
+	s, err := json.Marshal(tail)
+	if err != nil {
+		return "", errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal upload tail")
+	}
+	if err := e.proveStorage.Save(tailID.String(), bytes.NewReader(s)); err != nil {
+		return "", errorx.Wrap(err, "failed to save upload tail")
+	}
+	return tailID.String(), nil
+}
+
+// sealPending encrypts plaintext pending in the session, so that it's not kept in local storage in clear.
+// It may be sealed many times before filling a chunk, so a random nonce is used instead of the chunk's
+func (e *Engine) sealPending(session *uploadSession, plaintext []byte) ([]byte, error) {
+	if len(plaintext) == 0 {
+		return nil, nil
+	}
+	key, err := e.encryptor.GetKey(session.ID, pendingKeyID, nil, session.KeyVersion)
+	if err != nil {
+		return nil, errorx.Wrap(err, "failed to get key of pending plaintext")
+	}
+	key.Nonce = make([]byte, len(key.Nonce))
+	if _, err := rand.Read(key.Nonce); err != nil {
+		return nil, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to generate nonce")
+	}
+	sealed, err := aes.EncryptUsingAESGCM(key, plaintext, key.Nonce)
+	if err != nil {
+		return nil, errorx.NewCode(err, errorx.ErrCodeCrypto, "failed to encrypt pending plaintext")
+	}
+	return sealed, nil
+}
+
+// openPending decrypts plaintext pending in the session, see sealPending
+func (e *Engine) openPending(session *uploadSession, sealed []byte) ([]byte, error) {
+	if len(sealed) == 0 {
+		return nil, nil
+	}
+	key, err := e.encryptor.GetKey(session.ID, pendingKeyID, nil, session.KeyVersion)
+	if err != nil {
+		return nil, errorx.Wrap(err, "failed to get key of pending plaintext")
+	}
+	if len(sealed) < len(key.Nonce) {
+		return nil, errorx.New(errorx.ErrCodeCrypto, "bad pending plaintext")
+	}
+	key.Nonce = sealed[:len(key.Nonce)]
+	plaintext, err := aes.DecryptUsingAESGCM(key, sealed[len(key.Nonce):], nil)
+	if err != nil {
+		return nil, errorx.NewCode(err, errorx.ErrCodeCrypto, "failed to decrypt pending plaintext")
+	}
+	return plaintext, nil
+}
+
+// listUploadSessions returns ids of all upload sessions
+func (e *Engine) listUploadSessions() ([]string, error) {
+	e.sessionIndexLock.Lock()
+	defer e.sessionIndexLock.Unlock()
+	return e.loadUploadSessionIndex()
+}
+
+// updateUploadSessionIndex adds the session into or removes it from the index of upload sessions
+func (e *Engine) updateUploadSessionIndex(id string, add bool) error {
+	e.sessionIndexLock.Lock()
+	defer e.sessionIndexLock.Unlock()
+
+	ids, err := e.loadUploadSessionIndex()
+	if err != nil {
+		return err
+	}
+	var newIDs []string
+	for _, i := range ids {
+		if i != id {
+			newIDs = append(newIDs, i)
+		}
+	}
+	if add {
+		newIDs = append(newIDs, id)
+	}
+	s, err := json.Marshal(newIDs)
+	if err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal upload session index")
+	}
+	if err := e.proveStorage.SaveAndUpdate(uploadSessionIndexID, bytes.NewReader(s)); err != nil {
+		return errorx.Wrap(err, "failed to save upload session index")
+	}
+	return nil
+}
+
+// loadUploadSessionIndex loads ids of all upload sessions, sessionIndexLock must be held by caller
+func (e *Engine) loadUploadSessionIndex() ([]string, error) {
+	if exist, _ := e.proveStorage.Exist(uploadSessionIndexID); !exist {
+		return nil, nil
+	}
+	s, err := e.proveStorage.LoadStr(uploadSessionIndexID)
+	if err != nil {
+		return nil, errorx.Wrap(err, "failed to load upload session index")
+	}
+	var ids []string
+	if err := json.Unmarshal([]byte(s), &ids); err != nil {
+		return nil, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to unmarshal upload session index")
+	}
+	return ids, nil
+}
+
+// removeUploadSession removes session and its tail from ProveStorage
+func (e *Engine) removeUploadSession(session *uploadSession) {
+	for _, key := range []string{session.TailID, session.ID} {
+		if exist, _ := e.proveStorage.Exist(key); exist {
+			if _, err := e.proveStorage.Delete(key); err != nil {
+				logger.WithError(err).WithField("session_id", session.ID).Warn("failed to remove upload session")
+			}
+		}
+	}
+	if err := e.updateUploadSessionIndex(session.ID, false); err != nil {
+		logger.WithError(err).WithField("session_id", session.ID).Warn("failed to remove upload session from index")
+	}
+	e.sessionLocks.Delete(session.ID)
+}
+
+// uploadSessionResponse packs response of session operations
+func (e *Engine) uploadSessionResponse(session *uploadSession) types.UploadSessionResponse {
+	return types.UploadSessionResponse{
+		SessionID: session.ID,
+		Offset:    session.Offset,
+		BlockSize: e.slicer.GetBlockSize(),
+	}
+}
@@ -0,0 +1,218 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/encryptor/chunk"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/types"
+)
+
+// initUpload creates an upload session and returns its ID
+func (te *testEngine) initUpload(t *testing.T, ns, name string) string {
+	opt := types.WriteOptions{
+		User:       te.pubkey.String(),
+		Namespace:  ns,
+		FileName:   name,
+		ExpireTime: time.Now().Add(time.Hour).UnixNano(),
+	}
+	opt.Token = te.token(t, opt)
+	resp, err := te.InitUpload(context.Background(), opt)
+	require.NoError(t, err)
+	return resp.SessionID
+}
+
+// uploadRange uploads a range of the file into the session
+func (te *testEngine) uploadRange(t *testing.T, sessionID string, offset uint64, data []byte) error {
+	opt := types.UploadRangeOptions{
+		User:      te.pubkey.String(),
+		SessionID: sessionID,
+		Offset:    offset,
+	}
+	opt.Token = te.token(t, opt)
+	_, err := te.UploadRange(context.Background(), opt, bytes.NewReader(data))
+	return err
+}
+
+// sessionOptions returns signed options to operate the session
+func (te *testEngine) sessionOptions(t *testing.T, sessionID string) types.UploadSessionOptions {
+	opt := types.UploadSessionOptions{
+		User:      te.pubkey.String(),
+		SessionID: sessionID,
+	}
+	opt.Token = te.token(t, opt)
+	return opt
+}
+
+func testContent(n int) []byte {
+	content := make([]byte, n)
+	for i := range content {
+		content[i] = byte(i*7 + i/251)
+	}
+	return content
+}
+
+func TestUploadSession(t *testing.T) {
+	te := newTestEngine(t, 3)
+	te.addNs(t, "ns", 2)
+	content := testContent(2*chunk.Size + 1000)
+
+	sessionID := te.initUpload(t, "ns", "file")
+	ranges := [][2]int{{0, 100}, {100, chunk.Size + 7}, {chunk.Size + 7, chunk.Size + 7}, {chunk.Size + 7, len(content)}}
+	for _, r := range ranges {
+		require.NoError(t, te.uploadRange(t, sessionID, uint64(r[0]), content[r[0]:r[1]]))
+	}
+	// ranges must be uploaded in order
+	require.Error(t, te.uploadRange(t, sessionID, 100, content[100:200]))
+
+	resp, err := te.GetUploadSession(te.sessionOptions(t, sessionID))
+	require.NoError(t, err)
+	require.Equal(t, uint64(len(content)), resp.Offset)
+
+	// plaintext pending in the session is not kept in clear
+	session, err := te.loadUploadSession(sessionID)
+	require.NoError(t, err)
+	tail, err := te.loadUploadTail(session)
+	require.NoError(t, err)
+	require.NotEmpty(t, tail.Pending)
+	require.False(t, bytes.Contains(tail.Pending, content[len(content)-100:]))
+
+	wresp, err := te.CommitUpload(context.Background(), te.sessionOptions(t, sessionID))
+	require.NoError(t, err)
+	f, err := te.chain.GetFileByID(wresp.FileID)
+	require.NoError(t, err)
+	require.True(t, f.Chunked)
+	require.Equal(t, uint64(len(content)), f.Length)
+
+	data, err := te.read(t, wresp.FileID, 0, 0)
+	require.NoError(t, err)
+	require.Equal(t, content, data)
+	data, err = te.read(t, wresp.FileID, chunk.Size-10, 20)
+	require.NoError(t, err)
+	require.Equal(t, content[chunk.Size-10:chunk.Size+10], data)
+
+	// the session is removed after committed
+	_, err = te.GetUploadSession(te.sessionOptions(t, sessionID))
+	require.Error(t, err)
+	ids, err := te.listUploadSessions()
+	require.NoError(t, err)
+	require.Empty(t, ids)
+}
+
+func TestUploadRetry(t *testing.T) {
+	te := newTestEngine(t, 3)
+	te.addNs(t, "ns", 2)
+	content := testContent(chunk.Size + 1000)
+
+	// chunks reserved by an attempt which may have pushed them can only be encrypted from the same plaintext
+	sessionID := te.initUpload(t, "ns", "file")
+	session, err := te.loadUploadSession(sessionID)
+	require.NoError(t, err)
+	require.NoError(t, te.reserveChunks(session, content[:chunk.Size]))
+	other := append([]byte{content[0] + 1}, content[1:]...)
+	require.Error(t, te.uploadRange(t, sessionID, 0, other))
+	require.Error(t, te.uploadRange(t, sessionID, 0, content[:chunk.Size-1]))
+	require.NoError(t, te.uploadRange(t, sessionID, 0, content))
+
+	// ranges can't be uploaded once the last chunk is encrypted by a commit
+	session, err = te.loadUploadSession(sessionID)
+	require.NoError(t, err)
+	require.Empty(t, session.EncryptedDigest)
+	session.LastSealing = true
+	require.NoError(t, te.saveUploadSession(session))
+	require.Error(t, te.uploadRange(t, sessionID, uint64(len(content)), content[:10]))
+
+	wresp, err := te.CommitUpload(context.Background(), te.sessionOptions(t, sessionID))
+	require.NoError(t, err)
+	data, err := te.read(t, wresp.FileID, 0, 0)
+	require.NoError(t, err)
+	require.Equal(t, content, data)
+}
+
+func TestUploadAfterPasswordRotation(t *testing.T) {
+	te := newTestEngine(t, 3)
+	te.addNs(t, "ns", 2)
+	content := testContent(chunk.Size + 1000)
+
+	// the session keeps encrypting under the password it was created with
+	sessionID := te.initUpload(t, "ns", "file")
+	require.NoError(t, te.uploadRange(t, sessionID, 0, content[:chunk.Size+100]))
+	te.rotatePassword(t)
+	require.NoError(t, te.uploadRange(t, sessionID, chunk.Size+100, content[chunk.Size+100:]))
+
+	wresp, err := te.CommitUpload(context.Background(), te.sessionOptions(t, sessionID))
+	require.NoError(t, err)
+	f, err := te.chain.GetFileByID(wresp.FileID)
+	require.NoError(t, err)
+	require.Equal(t, 1, f.GetKeyVersion())
+	require.Equal(t, 1, f.GetSliceKeyVersion())
+	data, err := te.read(t, wresp.FileID, 0, 0)
+	require.NoError(t, err)
+	require.Equal(t, content, data)
+}
+
+func TestAbortUpload(t *testing.T) {
+	te := newTestEngine(t, 3)
+	te.addNs(t, "ns", 2)
+	content := testContent(chunk.Size + 1000)
+
+	sessionID := te.initUpload(t, "ns", "file")
+	require.NoError(t, te.uploadRange(t, sessionID, 0, content))
+	session, err := te.loadUploadSession(sessionID)
+	require.NoError(t, err)
+	require.NotEmpty(t, session.Slices)
+	s := session.Slices[0]
+	_, err = te.challenger.Take(sessionID, s.SliceID, s.NodeID)
+	require.NoError(t, err)
+
+	require.NoError(t, te.AbortUpload(context.Background(), te.sessionOptions(t, sessionID)))
+	require.Empty(t, te.copier.slices)
+	// challenge materials and the file key are removed
+	_, err = te.challenger.Take(sessionID, s.SliceID, s.NodeID)
+	require.Error(t, err)
+	require.Error(t, te.encryptor.DestroyFileKey(sessionID))
+	_, err = te.GetUploadSession(te.sessionOptions(t, sessionID))
+	require.Error(t, err)
+}
+
+func TestCleanExpiredSessions(t *testing.T) {
+	te := newTestEngine(t, 3)
+	te.addNs(t, "ns", 2)
+	te.sessionTTL = time.Hour
+
+	expired := te.initUpload(t, "ns", "expired")
+	require.NoError(t, te.uploadRange(t, expired, 0, testContent(chunk.Size+1000)))
+	active := te.initUpload(t, "ns", "active")
+
+	session, err := te.loadUploadSession(expired)
+	require.NoError(t, err)
+	session.UpdateTime = time.Now().Add(-2 * time.Hour).UnixNano()
+	require.NoError(t, te.saveUploadSession(session))
+
+	te.cleanExpiredSessions(context.Background())
+	_, err = te.GetUploadSession(te.sessionOptions(t, expired))
+	require.Error(t, err)
+	require.Empty(t, te.copier.slices)
+	_, err = te.GetUploadSession(te.sessionOptions(t, active))
+	require.NoError(t, err)
+	ids, err := te.listUploadSessions()
+	require.NoError(t, err)
+	require.Equal(t, []string{active}, ids)
+}
@@ -17,6 +17,7 @@ import (
 	"bytes"
 	"context"
 	"io"
+	"io/ioutil"
 	"sync"
 	"time"
 
@@ -30,6 +31,7 @@ import (
 	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/common"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/copier"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/encryptor"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/erasure"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/slicer"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/types"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
@@ -63,13 +65,12 @@ type finishWrittenSlice struct {
 // 4. second encryption of ciphertext slices
 // 5. push slices into storage nodes, retry five times if push failed
 // 6. store file's digest info into blockchain
+// If the slicer cuts data by content, slices are deduplicated among files of the owner, see writeDedup
 func (e *Engine) Write(ctx context.Context, opt types.WriteOptions,
 	r io.Reader) (resp types.WriteResponse, err error) {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	var errOccurred error
-
 	// check key match
 	if err := e.verifyUserID(opt.User); err != nil {
 		return resp, err
@@ -86,12 +87,6 @@ func (e *Engine) Write(ctx context.Context, opt types.WriteOptions,
 	pubkey := ecdsa.PublicKeyFromPrivateKey(e.monitor.challengingMonitor.PrivateKey)
 	opt.User = pubkey.String()
 
-	// duplicate check
-	if _, err := e.chain.GetFileByName(pubkey[:], opt.Namespace, opt.FileName); err == nil {
-		return resp, errorx.New(errorx.ErrCodeAlreadyExists, "duplicated name")
-	} else if !errorx.Is(err, errorx.ErrCodeNotFound) {
-		return resp, errorx.Wrap(err, "failed to read blockchain")
-	}
 	ns, err := e.chain.GetNsByName(pubkey[:], opt.Namespace)
 	if err != nil {
 		return resp, errorx.Wrap(err, "failed to get ns from blockchain")
@@ -104,11 +99,12 @@ func (e *Engine) Write(ctx context.Context, opt types.WriteOptions,
 	if err != nil {
 		return resp, err
 	}
-	if len(nodes) < ns.Replica {
-		return resp, errorx.Internal(err, "available healthy nodes smaller than replica")
+	if err := checkHealthNodes(ns, nodes); err != nil {
+		return resp, err
+	}
+	if err := e.checkSlicer(ns); err != nil {
+		return resp, err
 	}
-	nodesMap := common.ToNodeHsMap(nodes)
-
 	logger.WithFields(logrus.Fields{
 		"file_id":       fileID.String(),
 		"file_name":     opt.FileName,
@@ -117,14 +113,112 @@ func (e *Engine) Write(ctx context.Context, opt types.WriteOptions,
 		"exp_time":      time.Unix(0, opt.ExpireTime).Format("2006-01-02 15:04:05"),
 	}).Info("write file")
 
+	// generate the key seed of file, so that keys of file can be destroyed after the file is deleted
+	if err := e.encryptor.CreateFileKey(fileID.String()); err != nil {
+		return resp, errorx.Wrap(err, "failed to create file key")
+	}
+
+	// client encrypted content is random, so it's never deduplicated
+	if e.dedupEnabled(ns) && !opt.ClientEncrypted {
+		return e.writeDedup(ctx, opt, ns, nodes, fileID.String(), r)
+	}
+	return e.writeWhole(ctx, opt, ns, nodes, fileID.String(), r)
+}
+
+// writeWhole encrypts the whole file chunk by chunk, then slices the ciphertext and pushes slices to storage nodes
+func (e *Engine) writeWhole(ctx context.Context, opt types.WriteOptions, ns blockchain.Namespace,
+	nodes blockchain.NodeHs, fileID string, r io.Reader) (resp types.WriteResponse, err error) {
 	// encrypt file first
-	cipher, err := e.encryptor.Encrypt(r, &encryptor.EncryptOptions{FileID: fileID.String()})
+	plaintext, err := ioutil.ReadAll(r)
+	if err != nil {
+		return resp, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to read file")
+	}
+	keyVersion := e.encryptor.KeyVersion()
+	c, err := e.fileCipher(fileID, keyVersion)
 	if err != nil {
 		logger.WithError(err).Error("file encryption failed")
 		return resp, errorx.NewCode(err, errorx.ErrCodeCrypto, "file encryption failed")
 	}
-	r = bytes.NewReader(cipher.CipherText)
-	originalLen := len(cipher.CipherText) - 16
+	ciphertext := c.Encrypt(plaintext)
+	originalLen := len(plaintext)
+
+	pushed, err := e.pushSlices(ctx, bytes.NewReader(ciphertext), fileID, opt.User, ns, nodes, 0, keyVersion)
+	if err != nil {
+		return resp, err
+	}
+	sliceMetas, finishedEncSlices, storIndexes := pushed.metas, pushed.encSlices, pushed.storIndexes
+
+	// get challenge config
+	ca, pairingConf := e.challenger.GetChallengeConf()
+
+	// generate and save merkle challenge material for each slice and storage node
+	if ca == types.MerkleChallengeAlgorithm {
+		if err := e.generateAndSaveMerkle(finishedEncSlices, fileID, opt.ExpireTime); err != nil {
+			return resp, err
+		}
+	}
+
+	// Write meta info to blockchain
+	chainFile, err := e.packChainFile(fileID, ca, opt, sliceMetas, originalLen, finishedEncSlices, storIndexes, pairingConf,
+		keyVersion)
+	if err != nil {
+		return resp, errorx.Wrap(err, "failed to pack chain file")
+	}
+	chainFile.DataShards = ns.DataShards
+	chainFile.ParityShards = ns.ParityShards
+	chainFile.Chunked = true
+	// generate and push pairing based challenge material for each slice and storage node
+	// slice index is required in calculation, which is obtained after packChainFile
+	if ca == types.PairingChallengeAlgorithm {
+		if err := common.AddSlicesNewPairingChallenge(ctx, pairingConf, e.copier, finishedEncSlices, chainFile, e.chain, opt.User,
+			e.monitor.challengingMonitor.RequestInterval.Nanoseconds(), time.Now().UnixNano(), opt.ExpireTime, nil, logger); err != nil {
+			return resp, err
+		}
+	}
+
+	version, err := e.publishFile(chainFile, nil)
+	if err != nil {
+		return resp, err
+	}
+
+	logger.WithField("file_id", fileID).Debug("file uploaded")
+	resp.FileID = fileID
+	resp.Version = version
+	return resp, nil
+}
+
+// checkHealthNodes checks if there are enough healthy nodes to store slices of files in the namespace
+func checkHealthNodes(ns blockchain.Namespace, nodes blockchain.NodeHs) error {
+	// each slice of a stripe is stored on a different node for erasure coded namespace
+	if ns.DataShards > 0 {
+		if len(nodes) < ns.DataShards+ns.ParityShards {
+			return errorx.New(errorx.ErrCodeInternal, "available healthy nodes smaller than slices of a stripe")
+		}
+	} else if len(nodes) < ns.Replica {
+		return errorx.New(errorx.ErrCodeInternal, "available healthy nodes smaller than replica")
+	}
+	return nil
+}
+
+// pushedSlices slices pushed to storage nodes
+type pushedSlices struct {
+	metas       []slicer.SliceMeta         // metas of plaintext slices, including parity slices
+	encSlices   []encryptor.EncryptedSlice // ciphertext slices pushed
+	storIndexes []string                   // storage indexes of ciphertext slices returned by storage nodes
+}
+
+// pushSlices divides the ciphertext of a file into slices, generates copies or parity slices,
+// encrypts and pushes them into storage nodes, retries if push failed.
+// For erasure coded namespace, stripe is the index of the first stripe.
+// Slices are encrypted under the password of keyVersion.
+// If error occurred, slices already pushed are returned along with the error
+func (e *Engine) pushSlices(ctx context.Context, r io.Reader, fileID, owner string, ns blockchain.Namespace,
+	nodes blockchain.NodeHs, stripe, keyVersion int) (pushed pushedSlices, err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var errOccurred error
+	nodesMap := common.ToNodeHsMap(nodes)
 
 	// Slice. sliceQueue will be closed when slicer get EOF
 	sliceOpts := slicer.SliceOptions{}
@@ -137,39 +231,41 @@ func (e *Engine) Write(ctx context.Context, opt types.WriteOptions,
 	// Both sliceMetaQueue and locatedSliceQueue will be closed when sliceQueue is closed
 	sliceMetaQueue := make(chan slicer.SliceMeta, 10)
 	locatedSliceQueue := make(chan copier.LocatedSlice, defaultLocatorAmount*2)
-	go e.locateRoutine(ctx, ns.Replica, nodes, sliceQueue, locatedSliceQueue, sliceMetaQueue, func(err error) {
+	onLocateErr := func(err error) {
 		logger.WithError(err).Error("slice location stopped")
 		errOccurred = err
 		cancel()
-	})
-	var sliceMetas []slicer.SliceMeta
+	}
+	if ns.DataShards > 0 {
+		go e.erasureLocateRoutine(ctx, ns.DataShards, ns.ParityShards, stripe, nodes, sliceQueue, locatedSliceQueue,
+			sliceMetaQueue, onLocateErr)
+	} else {
+		go e.locateRoutine(ctx, ns.Replica, nodes, sliceQueue, locatedSliceQueue, sliceMetaQueue, onLocateErr)
+	}
+	metasDone := make(chan struct{})
 	go func() {
+		defer close(metasDone)
 		for s := range sliceMetaQueue {
-			sliceMetas = append(sliceMetas, s)
+			pushed.metas = append(pushed.metas, s)
 		}
 	}()
 
 	// Encrypt. encryptedSliceQueue will be closed when locatedSliceQueue is closed
 	encryptedSliceQueue := make(chan encryptor.EncryptedSlice, 10)
-	go e.encryptRoutine(ctx, fileID.String(), locatedSliceQueue, encryptedSliceQueue, func(err error) {
+	go e.encryptRoutine(ctx, fileID, keyVersion, locatedSliceQueue, encryptedSliceQueue, func(err error) {
 		logger.WithError(err).Error("slice encryption stopped")
 		errOccurred = err
 		cancel()
 	})
 
-	// get challenge config
-	ca, pairingConf := e.challenger.GetChallengeConf()
-
-	// Setup challenging materials && Distribute
+	// Distribute
 	// both finishedQueue and failedQueue will be closed when encryptedSliceQueue is closed
 	finishedQueue := make(chan finishWrittenSlice, 10)
 	failedQueue := make(chan encryptor.EncryptedSlice, 10)
-	go e.distributeRoutine(ctx, nodesMap, encryptedSliceQueue, finishedQueue, failedQueue, opt.User)
-	var finishedEncSlices []encryptor.EncryptedSlice
-	var storIndexes []string
+	go e.distributeRoutine(ctx, nodesMap, encryptedSliceQueue, finishedQueue, failedQueue, owner)
 	for m := range finishedQueue {
-		finishedEncSlices = append(finishedEncSlices, m.eSlice)
-		storIndexes = append(storIndexes, m.storIndex)
+		pushed.encSlices = append(pushed.encSlices, m.eSlice)
+		pushed.storIndexes = append(pushed.storIndexes, m.storIndex)
 	}
 
 	// retry push
@@ -179,10 +275,10 @@ func (e *Engine) Write(ctx context.Context, opt types.WriteOptions,
 	}
 	finishedQueue2 := make(chan finishWrittenSlice, 10)
 	failedQueue2 := make(chan encryptor.EncryptedSlice, 10)
-	e.retryRoutine(ctx, failedSlices, finishedQueue2, failedQueue2, nodesMap, opt.User)
+	e.retryRoutine(ctx, failedSlices, finishedQueue2, failedQueue2, nodesMap, owner)
 	for m := range finishedQueue2 {
-		finishedEncSlices = append(finishedEncSlices, m.eSlice)
-		storIndexes = append(storIndexes, m.storIndex)
+		pushed.encSlices = append(pushed.encSlices, m.eSlice)
+		pushed.storIndexes = append(pushed.storIndexes, m.storIndex)
 	}
 	var failedTwice []encryptor.EncryptedSlice
 	for m := range failedQueue2 {
@@ -190,66 +286,70 @@ func (e *Engine) Write(ctx context.Context, opt types.WriteOptions,
 	}
 
 	// if push fails again, push to another node
-	finishedQueue3 := e.pushToOtherNode(ctx, opt.User, fileID.String(),
-		failedTwice, finishedEncSlices, nodes, func(err error) {
+	finishedQueue3 := e.pushToOtherNode(ctx, owner, fileID, keyVersion,
+		failedTwice, pushed.encSlices, nodes, func(err error) {
 			logger.WithError(err).Error("pushToOtherNode failed")
 			errOccurred = err
 			cancel()
 		})
-
-	// check writing error
-	if errOccurred != nil {
-		return resp, errorx.Wrap(errOccurred, "error occurred in writing")
-	}
-
 	// all pushed slice info
 	for _, m := range finishedQueue3 {
-		finishedEncSlices = append(finishedEncSlices, m.eSlice)
-		storIndexes = append(storIndexes, m.storIndex)
+		pushed.encSlices = append(pushed.encSlices, m.eSlice)
+		pushed.storIndexes = append(pushed.storIndexes, m.storIndex)
 	}
+	<-metasDone
 
-	// generate and save merkle challenge material for each slice and storage node
-	if ca == types.MerkleChallengeAlgorithm {
-		if err := e.generateAndSaveMerkle(finishedEncSlices, fileID.String(), opt.ExpireTime); err != nil {
-			return resp, err
-		}
+	// check writing error
+	if errOccurred != nil {
+		return pushed, errorx.Wrap(errOccurred, "error occurred in writing")
 	}
+	return pushed, nil
+}
 
-	// Write meta info to blockchain
-	chainFile, err := e.packChainFile(fileID.String(), ca, opt, sliceMetas, originalLen, finishedEncSlices, storIndexes, pairingConf)
+// publishFile signs the file info and publishes it into blockchain, refs are deduplicated slices referenced by the file
+func (e *Engine) publishFile(chainFile blockchain.File, refs []blockchain.SliceRef) (int, error) {
+	// publishing a file with an existing name creates a new version of it
+	version, err := e.nextFileVersion(chainFile.Owner, chainFile.Namespace, chainFile.Name)
 	if err != nil {
-		return resp, errorx.Wrap(err, "failed to pack chain file")
+		return 0, err
 	}
-	// generate and push pairing based challenge material for each slice and storage node
-	// slice index is required in calculation, which is obtained after packChainFile
-	if ca == types.PairingChallengeAlgorithm {
-		if err := common.AddSlicesNewPairingChallenge(ctx, pairingConf, e.copier, finishedEncSlices, chainFile, e.chain, opt.User,
-			e.monitor.challengingMonitor.RequestInterval.Nanoseconds(), time.Now().UnixNano(), opt.ExpireTime, nil, logger); err != nil {
-			return resp, err
-		}
-	}
-
-	// sign file info
+	chainFile.Version = version
 	publishFileOpt := blockchain.PublishFileOptions{
-		File: chainFile,
+		File:      chainFile,
+		SliceRefs: refs,
 	}
 	// get the message to sign
-	msg, err = util.GetSigMessage(publishFileOpt)
+	msg, err := util.GetSigMessage(publishFileOpt)
 	if err != nil {
-		return resp, errorx.Internal(err, "failed to get the message to sign for upload files")
+		return 0, errorx.Internal(err, "failed to get the message to sign for upload files")
 	}
 	sig, err := ecdsa.Sign(e.monitor.challengingMonitor.PrivateKey, hash.HashUsingSha256([]byte(msg)))
 	if err != nil {
-		return resp, errorx.Wrap(err, "failed to sign File")
+		return 0, errorx.Wrap(err, "failed to sign File")
 	}
 	publishFileOpt.Signature = sig[:]
 	if err := e.chain.PublishFile(&publishFileOpt); err != nil {
-		return resp, errorx.Wrap(err, "failed to write file to blockchain")
+		return 0, errorx.Wrap(err, "failed to write file to blockchain")
 	}
+	return version, nil
+}
 
-	logger.WithField("file_id", fileID.String()).Debug("file uploaded")
-	resp.FileID = fileID.String()
-	return resp, nil
+// nextFileVersion gets the version of the file to be published with the name,
+// which is the latest version of files with the same name plus 1
+func (e *Engine) nextFileVersion(owner []byte, ns, name string) (int, error) {
+	fs, err := e.chain.ListFileVersions(&blockchain.ListFileVersionsOptions{
+		Owner:     owner,
+		Namespace: ns,
+		Name:      name,
+		Limit:     1,
+	})
+	if err != nil {
+		return 0, errorx.Wrap(err, "failed to list file versions from blockchain")
+	}
+	if len(fs) == 0 {
+		return 1, nil
+	}
+	return fs[0].GetVersion() + 1, nil
 }
 
 // locateRoutine block current routine, used to select storage nodes for slices
@@ -306,8 +406,70 @@ func (e *Engine) locateRoutine(ctx context.Context, replica int, nodes blockchai
 	close(locatedQueue)
 }
 
+// erasureLocateRoutine block current routine, used to group every dataShards slices into a stripe,
+// generate parityShards parity slices for each stripe by Reed-Solomon code,
+// and select a different storage node for each slice of the stripe, stripes are indexed from stripe
+func (e *Engine) erasureLocateRoutine(ctx context.Context, dataShards, parityShards, stripe int, nodes blockchain.NodeHs,
+	sliceQueue <-chan slicer.Slice, locatedQueue chan<- copier.LocatedSlice, metaQueue chan<- slicer.SliceMeta,
+	onErr func(err error)) {
+	defer close(locatedQueue)
+	defer close(metaQueue)
+
+	locate := func(data []slicer.Slice, stripe int) error {
+		slices, err := erasure.EncodeStripe(data, stripe, parityShards)
+		if err != nil {
+			return errorx.Wrap(err, "failed to encode stripe %d", stripe)
+		}
+		// select len(slices) nodes at once, so that slices of the stripe are stored on different nodes
+		located, err := e.copier.Select(slices[0], nodes, &copier.SelectOptions{Replica: uint32(len(slices))})
+		if err != nil {
+			return errorx.Wrap(err, "failed to select nodes for stripe %d", stripe)
+		}
+		if len(located.Nodes) < len(slices) {
+			return errorx.New(errorx.ErrCodeInternal, "not enough nodes for stripe %d, expected %d, got %d",
+				stripe, len(slices), len(located.Nodes))
+		}
+		for i, s := range slices {
+			metaQueue <- s.SliceMeta
+			locatedQueue <- copier.LocatedSlice{
+				Slice: s,
+				Nodes: blockchain.Nodes{located.Nodes[i]},
+			}
+		}
+		return nil
+	}
+
+	var data []slicer.Slice
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case s, ok := <-sliceQueue:
+			if !ok {
+				// the last stripe may have less data slices
+				if len(data) > 0 {
+					if err := locate(data, stripe); err != nil {
+						onErr(err)
+					}
+				}
+				return
+			}
+			data = append(data, s)
+			if len(data) < dataShards {
+				continue
+			}
+			if err := locate(data, stripe); err != nil {
+				onErr(err)
+				return
+			}
+			data = nil
+			stripe++
+		}
+	}
+}
+
 // encryptRoutine Secondary encryption of slice copies
-func (e *Engine) encryptRoutine(ctx context.Context, fileID string, locatedQueue <-chan copier.LocatedSlice,
+func (e *Engine) encryptRoutine(ctx context.Context, fileID string, keyVersion int, locatedQueue <-chan copier.LocatedSlice,
 	encryptedQueue chan<- encryptor.EncryptedSlice, onErr func(err error)) {
 	wg := sync.WaitGroup{}
 
@@ -354,9 +516,10 @@ func (e *Engine) encryptRoutine(ctx context.Context, fileID string, locatedQueue
 				}
 
 				eopt := encryptor.EncryptOptions{
-					FileID:  fileID,
-					SliceID: lSlice.Slice.ID,
-					NodeID:  lSlice.Nodes.ID,
+					FileID:     fileID,
+					SliceID:    lSlice.Slice.ID,
+					NodeID:     lSlice.Nodes.ID,
+					KeyVersion: keyVersion,
 				}
 				es, err := e.encryptor.Encrypt(bytes.NewReader(lSlice.Slice.Data), &eopt)
 				if err != nil {
@@ -472,8 +635,8 @@ func (e *Engine) retryRoutine(ctx context.Context, failedSlices []encryptor.Encr
 	close(failedQueue)
 }
 
-// pushToOtherNode re-push failed slice to another node
-func (e *Engine) pushToOtherNode(ctx context.Context, owner, fileID string, failedSlices []encryptor.EncryptedSlice,
+// pushToOtherNode re-push failed slice to another node, slices are encrypted under the password of keyVersion
+func (e *Engine) pushToOtherNode(ctx context.Context, owner, fileID string, keyVersion int, failedSlices []encryptor.EncryptedSlice,
 	finishedEncSlices []encryptor.EncryptedSlice, nodes blockchain.NodeHs, onErr func(error)) []finishWrittenSlice {
 
 	var finishedSlices []finishWrittenSlice
@@ -500,9 +663,10 @@ func (e *Engine) pushToOtherNode(ctx context.Context, owner, fileID string, fail
 
 			// decrypt
 			ropt := encryptor.RecoverOptions{
-				FileID:  fileID,
-				SliceID: slice.SliceID,
-				NodeID:  slice.NodeID,
+				FileID:     fileID,
+				SliceID:    slice.SliceID,
+				NodeID:     slice.NodeID,
+				KeyVersion: keyVersion,
 			}
 			plain, err := e.encryptor.Recover(bytes.NewReader(slice.CipherText), &ropt)
 			if err != nil {
@@ -512,9 +676,10 @@ func (e *Engine) pushToOtherNode(ctx context.Context, owner, fileID string, fail
 
 			// re-encrypt
 			eopt := encryptor.EncryptOptions{
-				FileID:  fileID,
-				SliceID: slice.SliceID,
-				NodeID:  node.ID,
+				FileID:     fileID,
+				SliceID:    slice.SliceID,
+				NodeID:     node.ID,
+				KeyVersion: keyVersion,
 			}
 			es, err := e.encryptor.Encrypt(bytes.NewReader(plain), &eopt)
 			if err != nil {
@@ -0,0 +1,101 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/types"
+)
+
+// readByName reads the version of the file located by name, version 0 means the latest version
+func (te *testEngine) readByName(t *testing.T, ns, name string, version int) ([]byte, error) {
+	opt := types.ReadOptions{
+		User:      te.pubkey.String(),
+		Timestamp: time.Now().UnixNano(),
+		Namespace: ns,
+		FileName:  name,
+		Version:   version,
+	}
+	opt.Token = te.token(t, opt)
+	r, err := te.Read(context.Background(), opt)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+func TestFileVersions(t *testing.T) {
+	te := newTestEngine(t, 3)
+	te.addNs(t, "ns", 2)
+	te.addNs(t, "other", 2)
+
+	version, err := te.nextFileVersion(te.pubkey[:], "ns", "file")
+	require.NoError(t, err)
+	require.Equal(t, 1, version)
+
+	// writing a file with an existing name publishes a new version of it
+	var ids []string
+	contents := [][]byte{testContent(100), testContent(200), testContent(300)}
+	for i, content := range contents {
+		ids = append(ids, te.write(t, "ns", "file", content))
+		f, err := te.chain.GetFileByID(ids[i])
+		require.NoError(t, err)
+		require.Equal(t, i+1, f.Version)
+	}
+	version, err = te.nextFileVersion(te.pubkey[:], "ns", "file")
+	require.NoError(t, err)
+	require.Equal(t, 4, version)
+
+	// the latest version comes first
+	fs, err := te.ListFileVersions(types.ListFileVersionsOptions{
+		Owner:     te.pubkey.String(),
+		Namespace: "ns",
+		Name:      "file",
+	})
+	require.NoError(t, err)
+	require.Len(t, fs, 3)
+	for i, f := range fs {
+		require.Equal(t, ids[2-i], f.ID)
+	}
+
+	fh, err := te.GetFileByName(context.Background(), te.pubkey.String(), "ns", "file", 2)
+	require.NoError(t, err)
+	require.Equal(t, ids[1], fh.File.ID)
+	fh, err = te.GetFileByName(context.Background(), te.pubkey.String(), "ns", "file", 0)
+	require.NoError(t, err)
+	require.Equal(t, ids[2], fh.File.ID)
+
+	// read the latest version or a pinned one by name
+	data, err := te.readByName(t, "ns", "file", 0)
+	require.NoError(t, err)
+	require.Equal(t, contents[2], data)
+	data, err = te.readByName(t, "ns", "file", 1)
+	require.NoError(t, err)
+	require.Equal(t, contents[0], data)
+	_, err = te.readByName(t, "ns", "file", 4)
+	require.Error(t, err)
+
+	// versions are counted per namespace
+	id := te.write(t, "other", "file", contents[0])
+	f, err := te.chain.GetFileByID(id)
+	require.NoError(t, err)
+	require.Equal(t, 1, f.Version)
+}
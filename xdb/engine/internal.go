@@ -23,6 +23,8 @@ import (
 
 	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/encryptor"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/encryptor/chunk"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/encryptor/seal"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/slicer"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/types"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
@@ -34,7 +36,8 @@ import (
 // slices meta, used to pull slices from storage nodes
 // slices structure, ensure the file can be recovered in a correct slice order
 func (e *Engine) packChainFile(fileID, challengeAlgorithm string, opt types.WriteOptions, originalSlices slicer.SliceMetas,
-	originalLen int, encryptedSlices []encryptor.EncryptedSlice, storIndexes []string, pairingConf types.PairingChallengeConf) (blockchain.File, error) {
+	originalLen int, encryptedSlices []encryptor.EncryptedSlice, storIndexes []string, pairingConf types.PairingChallengeConf,
+	keyVersion int) (blockchain.File, error) {
 
 	sliceIdxMap := make(map[string]int)
 	chainSlices := make([]blockchain.PublicSliceMeta, 0, len(originalSlices))
@@ -48,6 +51,7 @@ func (e *Engine) packChainFile(fileID, challengeAlgorithm string, opt types.Writ
 			NodeID:     s.NodeID,
 			CipherHash: s.CipherHash,
 			StorIndex:  randStorIndexes[i],
+			Sealed:     seal.IsSealed(s.CipherText),
 		}
 		if challengeAlgorithm == types.PairingChallengeAlgorithm {
 			// denote slice index for each node (for pairing based challenge)
@@ -61,7 +65,7 @@ func (e *Engine) packChainFile(fileID, challengeAlgorithm string, opt types.Writ
 		}
 		chainSlices = append(chainSlices, sps)
 	}
-	structure, err := e.packChainFileStructure(originalSlices, fileID)
+	structure, err := e.packChainFileStructure(originalSlices, fileID, keyVersion)
 	if err != nil {
 		return blockchain.File{}, errorx.Wrap(err, "failed to pack chain file structure")
 	}
@@ -82,6 +86,10 @@ func (e *Engine) packChainFile(fileID, challengeAlgorithm string, opt types.Writ
 		PublishTime: time.Now().UnixNano(),
 		ExpireTime:  opt.ExpireTime,
 		Ext:         []byte(opt.Extra),
+
+		KeyVersion:      keyVersion,
+		SliceKeyVersion: keyVersion,
+		ClientEncrypted: opt.ClientEncrypted,
 	}
 	if challengeAlgorithm == types.PairingChallengeAlgorithm {
 		chainFile.PdpPubkey = pairingConf.Pubkey
@@ -93,12 +101,15 @@ func (e *Engine) packChainFile(fileID, challengeAlgorithm string, opt types.Writ
 }
 
 // packChainFileStructure pack file private structure and encrypt it
-func (e *Engine) packChainFileStructure(originalSlices slicer.SliceMetas, fileID string) ([]byte, error) {
+func (e *Engine) packChainFileStructure(originalSlices slicer.SliceMetas, fileID string, keyVersion int) ([]byte, error) {
 	structure := make(blockchain.FileStructure, 0, len(originalSlices))
 	for _, s := range originalSlices {
 		structure = append(structure, blockchain.PrivateSliceMeta{
 			SliceID:   s.ID,
 			PlainHash: s.Hash,
+			Stripe:    s.Stripe,
+			Parity:    s.Parity,
+			ChunkHash: s.ChunkHash,
 		})
 	}
 	raw, err := structure.Marshal()
@@ -107,7 +118,8 @@ func (e *Engine) packChainFileStructure(originalSlices slicer.SliceMetas, fileID
 	}
 	// encrypt structure
 	encStruct, err := e.encryptor.Encrypt(bytes.NewReader(raw), &encryptor.EncryptOptions{
-		FileID: fileID,
+		FileID:     fileID,
+		KeyVersion: keyVersion,
 	})
 	if err != nil {
 		return nil, err
@@ -116,10 +128,11 @@ func (e *Engine) packChainFileStructure(originalSlices slicer.SliceMetas, fileID
 }
 
 // recoverChainFileStructure get file structure from blockchain and decrypt it
-func (e *Engine) recoverChainFileStructure(bs []byte, fileID string) (blockchain.FileStructure, error) {
+func (e *Engine) recoverChainFileStructure(file blockchain.File) (blockchain.FileStructure, error) {
 	// decrypt structure
-	decStruct, err := e.encryptor.Recover(bytes.NewReader(bs), &encryptor.RecoverOptions{
-		FileID: fileID,
+	decStruct, err := e.encryptor.Recover(bytes.NewReader(file.Structure), &encryptor.RecoverOptions{
+		FileID:     file.ID,
+		KeyVersion: file.GetKeyVersion(),
 	})
 	if err != nil {
 		return nil, err
@@ -133,9 +146,22 @@ func (e *Engine) recoverChainFileStructure(bs []byte, fileID string) (blockchain
 	return fs, nil
 }
 
+// fileCipher returns the cipher to encrypt content of the file chunk by chunk, see chunk.Cipher
+func (e *Engine) fileCipher(fileID string, keyVersion int) (*chunk.Cipher, error) {
+	key, err := e.encryptor.GetKey(fileID, "", nil, keyVersion)
+	if err != nil {
+		return nil, errorx.Wrap(err, "failed to get key of file")
+	}
+	return chunk.New(key)
+}
+
 func calculateMerkleRoot(slices slicer.SliceMetas) []byte {
 	hashes := make([][]byte, 0, len(slices))
 	for _, s := range slices {
+		// parity slices of erasure coded file are not part of the original file
+		if s.Parity {
+			continue
+		}
 		hashes = append(hashes, s.Hash)
 	}
 
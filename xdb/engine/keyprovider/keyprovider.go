@@ -0,0 +1,50 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keyprovider
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+)
+
+// Names of secrets fetched from KeyProvider when they are absent from the configuration file
+const (
+	SecretPrivateKey        = "node-private-key"   // private key of the node, hex encoded
+	SecretEncryptorPassword = "encryptor-password" // password of the soft encryptor
+	SecretPairingSk         = "pairing-sk"         // private key of the pairing based challenger, base64 encoded
+)
+
+// KeyProvider provides secrets by name, so that they never have to live in configuration files
+//  An encrypted local keystore and a Vault transit compatible KMS are both supported,
+//  see more from keyprovider.keystore and keyprovider.vault
+type KeyProvider interface {
+	// GetSecret returns the secret of name, errorx.ErrCodeNotFound is returned if it does not exist
+	GetSecret(name string) (string, error)
+}
+
+// OldPasswordName returns the secret name of the soft encryptor password of a previous version
+func OldPasswordName(version int) string {
+	return fmt.Sprintf("%s-v%d", SecretEncryptorPassword, version)
+}
+
+// GetEnv reads a credential used to unlock KeyProvider from the environment variable
+func GetEnv(name string) (string, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return "", errorx.New(errorx.ErrCodeConfig, "missing environment variable %s", name)
+	}
+	return value, nil
+}
@@ -0,0 +1,286 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keystore
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/aes"
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/config"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/keyprovider"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+)
+
+const (
+	// DefaultPassphraseEnv is the environment variable holding the passphrase if not configured
+	DefaultPassphraseEnv = "XDB_KEYSTORE_PASSPHRASE"
+
+	keystoreVersion = 1
+	keyLen          = 32
+	nonceLen        = 12
+	saltLen         = 32
+
+	// scrypt parameters recommended for interactive logins
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+
+	// checkAD is the additional data of the ciphertext used to verify the passphrase
+	checkAD = "xdb-keystore"
+)
+
+// keystoreFile is the content of the keystore file
+//  Each secret is encrypted by AES-GCM with the key derived from the passphrase by scrypt,
+//  and the secret name is used as additional data so that ciphertexts cannot be swapped
+type keystoreFile struct {
+	Version int               `json:"version"`
+	Salt    string            `json:"salt"`
+	N       int               `json:"n"`
+	R       int               `json:"r"`
+	P       int               `json:"p"`
+	Check   string            `json:"check"`
+	Secrets map[string]string `json:"secrets"`
+}
+
+// Keystore is a local file keeping secrets encrypted, unlocked by a passphrase
+type Keystore struct {
+	path string
+	key  []byte
+
+	lock sync.RWMutex
+	file keystoreFile
+}
+
+// New opens an existing keystore by configuration, the passphrase is read from the environment variable
+func New(conf *config.KeystoreConf) (*Keystore, error) {
+	if conf == nil || conf.Path == "" {
+		return nil, errorx.New(errorx.ErrCodeConfig, "missing config: keystore path")
+	}
+	if _, err := os.Stat(conf.Path); err != nil {
+		return nil, errorx.NewCode(err, errorx.ErrCodeConfig, "failed to find keystore")
+	}
+
+	env := conf.PassphraseEnv
+	if env == "" {
+		env = DefaultPassphraseEnv
+	}
+	passphrase, err := keyprovider.GetEnv(env)
+	if err != nil {
+		return nil, err
+	}
+	return Open(conf.Path, passphrase)
+}
+
+// Open unlocks the keystore file of path by passphrase,
+// an empty keystore is created if the file does not exist, and it is saved when secrets are put
+func Open(path, passphrase string) (*Keystore, error) {
+	if passphrase == "" {
+		return nil, errorx.New(errorx.ErrCodeParam, "empty passphrase")
+	}
+
+	ks := &Keystore{path: path}
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		salt := make([]byte, saltLen)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, errorx.NewCode(err, errorx.ErrCodeCrypto, "failed to generate salt")
+		}
+		ks.file = keystoreFile{
+			Version: keystoreVersion,
+			Salt:    base64.StdEncoding.EncodeToString(salt),
+			N:       scryptN,
+			R:       scryptR,
+			P:       scryptP,
+			Secrets: make(map[string]string),
+		}
+		if ks.key, err = deriveKey(passphrase, ks.file); err != nil {
+			return nil, err
+		}
+		if ks.file.Check, err = ks.seal(checkAD, nil); err != nil {
+			return nil, err
+		}
+		return ks, nil
+	}
+	if err != nil {
+		return nil, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to read keystore")
+	}
+
+	if err := json.Unmarshal(content, &ks.file); err != nil {
+		return nil, errorx.NewCode(err, errorx.ErrCodeEncoding, "failed to decode keystore")
+	}
+	if ks.file.Version != keystoreVersion {
+		return nil, errorx.New(errorx.ErrCodeParam, "unsupported keystore version: %d", ks.file.Version)
+	}
+	if ks.file.Secrets == nil {
+		ks.file.Secrets = make(map[string]string)
+	}
+	if ks.key, err = deriveKey(passphrase, ks.file); err != nil {
+		return nil, err
+	}
+	if _, err := ks.open(checkAD, ks.file.Check); err != nil {
+		return nil, errorx.New(errorx.ErrCodeNotAuthorized, "wrong passphrase of keystore")
+	}
+	return ks, nil
+}
+
+// GetSecret decrypts the secret of name
+func (ks *Keystore) GetSecret(name string) (string, error) {
+	ks.lock.RLock()
+	defer ks.lock.RUnlock()
+
+	ciphertext, ok := ks.file.Secrets[name]
+	if !ok {
+		return "", errorx.New(errorx.ErrCodeNotFound, "secret %s not found in keystore", name)
+	}
+	secret, err := ks.open(name, ciphertext)
+	if err != nil {
+		return "", err
+	}
+	return string(secret), nil
+}
+
+// PutSecret encrypts the secret of name and saves the keystore file, an existing one is overwritten
+func (ks *Keystore) PutSecret(name, secret string) error {
+	if name == "" || secret == "" {
+		return errorx.New(errorx.ErrCodeParam, "empty secret name or value")
+	}
+
+	ks.lock.Lock()
+	defer ks.lock.Unlock()
+
+	ciphertext, err := ks.seal(name, []byte(secret))
+	if err != nil {
+		return err
+	}
+	old, existed := ks.file.Secrets[name]
+	ks.file.Secrets[name] = ciphertext
+	if err := ks.save(); err != nil {
+		if existed {
+			ks.file.Secrets[name] = old
+		} else {
+			delete(ks.file.Secrets, name)
+		}
+		return err
+	}
+	return nil
+}
+
+// DeleteSecret removes the secret of name and saves the keystore file
+func (ks *Keystore) DeleteSecret(name string) error {
+	ks.lock.Lock()
+	defer ks.lock.Unlock()
+
+	old, ok := ks.file.Secrets[name]
+	if !ok {
+		return errorx.New(errorx.ErrCodeNotFound, "secret %s not found in keystore", name)
+	}
+	delete(ks.file.Secrets, name)
+	if err := ks.save(); err != nil {
+		ks.file.Secrets[name] = old
+		return err
+	}
+	return nil
+}
+
+// ListSecrets returns names of secrets in keystore
+func (ks *Keystore) ListSecrets() []string {
+	ks.lock.RLock()
+	defer ks.lock.RUnlock()
+
+	var names []string
+	for name := range ks.file.Secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// save writes the keystore into a temporary file and renames it, so the file is never half written
+func (ks *Keystore) save() error {
+	content, err := json.MarshalIndent(ks.file, "", "  ")
+	if err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeEncoding, "failed to encode keystore")
+	}
+	dir := filepath.Dir(ks.path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to create keystore dir")
+	}
+	tmp, err := ioutil.TempFile(dir, filepath.Base(ks.path)+".tmp")
+	if err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to create keystore file")
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to write keystore file")
+	}
+	if err := tmp.Close(); err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to write keystore file")
+	}
+	if err := os.Rename(tmp.Name(), ks.path); err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to save keystore file")
+	}
+	return nil
+}
+
+// seal encrypts plaintext with ad, returns base64 encoded nonce and ciphertext
+func (ks *Keystore) seal(ad string, plaintext []byte) (string, error) {
+	nonce := make([]byte, nonceLen)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", errorx.NewCode(err, errorx.ErrCodeCrypto, "failed to generate nonce")
+	}
+	key := aes.AESKey{Key: ks.key, Nonce: nonce, AD: []byte(ad)}
+	ciphertext, err := aes.EncryptUsingAESGCM(key, plaintext, nil)
+	if err != nil {
+		return "", errorx.NewCode(err, errorx.ErrCodeCrypto, "failed to encrypt secret")
+	}
+	return base64.StdEncoding.EncodeToString(append(nonce, ciphertext...)), nil
+}
+
+// open decrypts base64 encoded nonce and ciphertext with ad
+func (ks *Keystore) open(ad, sealed string) ([]byte, error) {
+	raw, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(raw) < nonceLen {
+		return nil, errorx.New(errorx.ErrCodeEncoding, "invalid ciphertext in keystore")
+	}
+	key := aes.AESKey{Key: ks.key, Nonce: raw[:nonceLen], AD: []byte(ad)}
+	plaintext, err := aes.DecryptUsingAESGCM(key, raw[nonceLen:], nil)
+	if err != nil {
+		return nil, errorx.NewCode(err, errorx.ErrCodeCrypto, "failed to decrypt secret")
+	}
+	return plaintext, nil
+}
+
+// deriveKey derives the encryption key from passphrase by scrypt
+func deriveKey(passphrase string, file keystoreFile) ([]byte, error) {
+	salt, err := base64.StdEncoding.DecodeString(file.Salt)
+	if err != nil {
+		return nil, errorx.NewCode(err, errorx.ErrCodeEncoding, "invalid salt in keystore")
+	}
+	key, err := scrypt.Key([]byte(passphrase), salt, file.N, file.R, file.P, keyLen)
+	if err != nil {
+		return nil, errorx.NewCode(err, errorx.ErrCodeCrypto, "failed to derive key from passphrase")
+	}
+	return key, nil
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keystore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/config"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/keyprovider"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+)
+
+func TestKeystore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keystore.json")
+
+	ks, err := Open(path, "passphrase")
+	require.NoError(t, err)
+	require.Empty(t, ks.ListSecrets())
+	_, err = ks.GetSecret(keyprovider.SecretPrivateKey)
+	require.True(t, errorx.Is(err, errorx.ErrCodeNotFound))
+
+	require.NoError(t, ks.PutSecret(keyprovider.SecretPrivateKey, "5572e2fa0c259fe7"))
+	require.NoError(t, ks.PutSecret(keyprovider.SecretEncryptorPassword, "abcdefg"))
+	require.Error(t, ks.PutSecret(keyprovider.SecretPairingSk, ""))
+
+	// reopen with the right passphrase
+	ks, err = Open(path, "passphrase")
+	require.NoError(t, err)
+	require.Equal(t, []string{keyprovider.SecretEncryptorPassword, keyprovider.SecretPrivateKey}, ks.ListSecrets())
+	secret, err := ks.GetSecret(keyprovider.SecretPrivateKey)
+	require.NoError(t, err)
+	require.Equal(t, "5572e2fa0c259fe7", secret)
+
+	// wrong passphrase
+	_, err = Open(path, "wrong passphrase")
+	require.True(t, errorx.Is(err, errorx.ErrCodeNotAuthorized))
+
+	// ciphertexts of secrets are bound to their names
+	ks.file.Secrets[keyprovider.SecretPairingSk] = ks.file.Secrets[keyprovider.SecretPrivateKey]
+	_, err = ks.GetSecret(keyprovider.SecretPairingSk)
+	require.Error(t, err)
+
+	require.NoError(t, ks.DeleteSecret(keyprovider.SecretEncryptorPassword))
+	require.Error(t, ks.DeleteSecret(keyprovider.SecretEncryptorPassword))
+}
+
+func TestNew(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keystore.json")
+	ks, err := Open(path, "passphrase")
+	require.NoError(t, err)
+	require.NoError(t, ks.PutSecret(keyprovider.SecretPairingSk, "Fudm9gDXNlEdCkieMid1WHIHd9K"))
+
+	conf := &config.KeystoreConf{Path: path, PassphraseEnv: "XDB_TEST_KEYSTORE_PASSPHRASE"}
+	// passphrase not set
+	_, err = New(conf)
+	require.Error(t, err)
+
+	os.Setenv(conf.PassphraseEnv, "passphrase")
+	defer os.Unsetenv(conf.PassphraseEnv)
+	kp, err := New(conf)
+	require.NoError(t, err)
+	secret, err := kp.GetSecret(keyprovider.SecretPairingSk)
+	require.NoError(t, err)
+	require.Equal(t, "Fudm9gDXNlEdCkieMid1WHIHd9K", secret)
+
+	// keystore file must exist
+	_, err = New(&config.KeystoreConf{Path: path + ".missing", PassphraseEnv: conf.PassphraseEnv})
+	require.Error(t, err)
+}
@@ -0,0 +1,185 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/config"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/keyprovider"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+)
+
+const (
+	// DefaultTokenEnv is the environment variable holding the token if not configured
+	DefaultTokenEnv = "VAULT_TOKEN"
+
+	defaultMount   = "transit"
+	defaultTimeout = 10 * time.Second
+	tokenHeader    = "X-Vault-Token"
+)
+
+// Transit fetches secrets by decrypting their ciphertexts with a KMS
+// compatible with the transit secrets engine of HashiCorp Vault
+//  Only ciphertexts like "vault:v1:..." are kept in configuration file, and
+//  the key encrypting them never leaves the KMS
+type Transit struct {
+	address string
+	token   string
+	mount   string
+	keyName string
+	secrets map[string]string // ciphertexts by secret name
+
+	client *http.Client
+}
+
+type encryptRequest struct {
+	Plaintext string `json:"plaintext"`
+}
+
+type decryptRequest struct {
+	Ciphertext string `json:"ciphertext"`
+}
+
+type transitResponse struct {
+	Data struct {
+		Plaintext  string `json:"plaintext"`
+		Ciphertext string `json:"ciphertext"`
+	} `json:"data"`
+	Errors []string `json:"errors"`
+}
+
+// New creates Transit by configuration, the token is read from the environment variable
+func New(conf *config.VaultConf) (*Transit, error) {
+	if conf == nil || conf.Address == "" {
+		return nil, errorx.New(errorx.ErrCodeConfig, "missing config: vault address")
+	}
+	if conf.KeyName == "" {
+		return nil, errorx.New(errorx.ErrCodeConfig, "missing config: vault keyName")
+	}
+
+	env := conf.TokenEnv
+	if env == "" {
+		env = DefaultTokenEnv
+	}
+	token, err := keyprovider.GetEnv(env)
+	if err != nil {
+		return nil, err
+	}
+
+	mount := strings.Trim(conf.Mount, "/")
+	if mount == "" {
+		mount = defaultMount
+	}
+	timeout := time.Duration(conf.Timeout) * time.Millisecond
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+
+	// viper lowercases keys of maps, so secret names are looked up case-insensitively
+	secrets := make(map[string]string)
+	for name, ciphertext := range conf.Secrets {
+		secrets[strings.ToLower(name)] = ciphertext
+	}
+
+	return &Transit{
+		address: strings.TrimRight(conf.Address, "/"),
+		token:   token,
+		mount:   mount,
+		keyName: conf.KeyName,
+		secrets: secrets,
+		client:  &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// GetSecret decrypts the configured ciphertext of name by the KMS
+func (t *Transit) GetSecret(name string) (string, error) {
+	ciphertext, ok := t.secrets[strings.ToLower(name)]
+	if !ok || ciphertext == "" {
+		return "", errorx.New(errorx.ErrCodeNotFound, "secret %s not configured", name)
+	}
+
+	resp, err := t.request("decrypt", decryptRequest{Ciphertext: ciphertext})
+	if err != nil {
+		return "", errorx.Wrap(err, "failed to decrypt secret %s", name)
+	}
+	plaintext, err := base64.StdEncoding.DecodeString(resp.Data.Plaintext)
+	if err != nil {
+		return "", errorx.NewCode(err, errorx.ErrCodeEncoding, "failed to decode plaintext of secret %s", name)
+	}
+	return string(plaintext), nil
+}
+
+// Encrypt encrypts secret by the KMS, and the ciphertext returned can be put into configuration file
+func (t *Transit) Encrypt(secret string) (string, error) {
+	req := encryptRequest{Plaintext: base64.StdEncoding.EncodeToString([]byte(secret))}
+	resp, err := t.request("encrypt", req)
+	if err != nil {
+		return "", errorx.Wrap(err, "failed to encrypt secret")
+	}
+	return resp.Data.Ciphertext, nil
+}
+
+// request posts to "/v1/<mount>/<operation>/<keyName>" of the KMS
+func (t *Transit) request(operation string, body interface{}) (transitResponse, error) {
+	var resp transitResponse
+	input, err := json.Marshal(body)
+	if err != nil {
+		return resp, errorx.NewCode(err, errorx.ErrCodeEncoding, "failed to encode request")
+	}
+
+	url := fmt.Sprintf("%s/v1/%s/%s/%s", t.address, t.mount, operation, t.keyName)
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, url, bytes.NewReader(input))
+	if err != nil {
+		return resp, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to new request")
+	}
+	req.Header.Set(tokenHeader, t.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	r, err := t.client.Do(req)
+	if err != nil {
+		return resp, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to do request")
+	}
+	defer r.Body.Close()
+
+	content, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return resp, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to read response")
+	}
+	// error details of Vault are returned in "errors" field
+	if err := json.Unmarshal(content, &resp); err != nil && r.StatusCode == http.StatusOK {
+		return resp, errorx.NewCode(err, errorx.ErrCodeEncoding, "failed to decode response")
+	}
+	if r.StatusCode != http.StatusOK {
+		code := errorx.ErrCodeInternal
+		switch r.StatusCode {
+		case http.StatusUnauthorized, http.StatusForbidden:
+			code = errorx.ErrCodeNotAuthorized
+		case http.StatusNotFound:
+			code = errorx.ErrCodeNotFound
+		case http.StatusBadRequest:
+			code = errorx.ErrCodeParam
+		}
+		return resp, errorx.New(code, "kms responded %d: %s", r.StatusCode, strings.Join(resp.Errors, "; "))
+	}
+	return resp, nil
+}
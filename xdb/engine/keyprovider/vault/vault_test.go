@@ -0,0 +1,121 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/config"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/keyprovider"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+)
+
+// newTransitServer starts a stand-in of Vault transit secrets engine,
+// ciphertexts are the reversed base64 plaintexts prefixed by "vault:v1:"
+func newTransitServer(t *testing.T, token string) *httptest.Server {
+	reverse := func(s string) string {
+		b := []byte(s)
+		for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+			b[i], b[j] = b[j], b[i]
+		}
+		return string(b)
+	}
+	writeErr := func(w http.ResponseWriter, status int, msg string) {
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string][]string{"errors": {msg}})
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != token {
+			writeErr(w, http.StatusForbidden, "permission denied")
+			return
+		}
+		var req map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeErr(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		data := make(map[string]string)
+		switch r.URL.Path {
+		case "/v1/transit/encrypt/xdb":
+			data["ciphertext"] = "vault:v1:" + reverse(req["plaintext"])
+		case "/v1/transit/decrypt/xdb":
+			if !strings.HasPrefix(req["ciphertext"], "vault:v1:") {
+				writeErr(w, http.StatusBadRequest, "invalid ciphertext")
+				return
+			}
+			data["plaintext"] = reverse(strings.TrimPrefix(req["ciphertext"], "vault:v1:"))
+		default:
+			writeErr(w, http.StatusNotFound, "no handler for route")
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}))
+}
+
+func TestTransit(t *testing.T) {
+	srv := newTransitServer(t, "s.token")
+	defer srv.Close()
+
+	conf := &config.VaultConf{
+		Address:  srv.URL,
+		TokenEnv: "XDB_TEST_VAULT_TOKEN",
+		KeyName:  "xdb",
+	}
+	// token not set
+	_, err := New(conf)
+	require.Error(t, err)
+
+	os.Setenv(conf.TokenEnv, "s.token")
+	defer os.Unsetenv(conf.TokenEnv)
+	transit, err := New(conf)
+	require.NoError(t, err)
+
+	ciphertext, err := transit.Encrypt("abcdefg")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(ciphertext, "vault:v1:"))
+	require.NotContains(t, ciphertext, base64.StdEncoding.EncodeToString([]byte("abcdefg")))
+
+	// secret names are case-insensitive as viper lowercases keys of maps
+	conf.Secrets = map[string]string{
+		"Encryptor-Password":         ciphertext,
+		keyprovider.SecretPairingSk:  "bad ciphertext",
+		keyprovider.SecretPrivateKey: "",
+	}
+	transit, err = New(conf)
+	require.NoError(t, err)
+	secret, err := transit.GetSecret(keyprovider.SecretEncryptorPassword)
+	require.NoError(t, err)
+	require.Equal(t, "abcdefg", secret)
+
+	_, err = transit.GetSecret(keyprovider.SecretPairingSk)
+	require.True(t, errorx.Is(err, errorx.ErrCodeParam))
+	_, err = transit.GetSecret(keyprovider.SecretPrivateKey)
+	require.True(t, errorx.Is(err, errorx.ErrCodeNotFound))
+
+	// wrong token
+	os.Setenv(conf.TokenEnv, "s.wrong")
+	transit, err = New(conf)
+	require.NoError(t, err)
+	_, err = transit.GetSecret(keyprovider.SecretEncryptorPassword)
+	require.True(t, errorx.Is(err, errorx.ErrCodeNotAuthorized))
+}
@@ -26,8 +26,8 @@ import (
 // Monitor includes ChallengingMonitor, NodeMaintainer, and FileMaintainer
 //  ChallengingMonitor's main work is to publish challenge requests if local node is dataOwner-node,
 //     otherwise is to listen challenge requests and answer them in order to prove specified files are stored
-//  NodeMaintainer runs if local node is storage-node, and its main work is to clean expired encrypted slices
-//     and to send heartbeats regularly in order to claim it's alive
+//  NodeMaintainer runs if local node is storage-node, and its main work is to clean expired encrypted slices,
+//     to send heartbeats regularly in order to claim it's alive, and to scrub slices to find corrupted ones
 //  FileMaintainer runs if local node is dataOwner-node, and its main work is to check storage-nodes health conditions
 //     and migrate slices from bad nodes to healthy nodes.
 type Monitor struct {
@@ -80,6 +80,8 @@ func newChallengingMonitor(conf *config.MonitorConf, opt *NewEngineOption) (
 		ChallengeDB:  opt.Challenger,
 		SliceStorage: opt.SliceStor,
 		ProveStorage: opt.ProveStor,
+
+		AnswerDeadline: opt.AnswerDeadline,
 	}
 	challengingMonitor, err := challenging.New(conf, &cmOpt)
 	if err != nil {
@@ -91,7 +93,7 @@ func newChallengingMonitor(conf *config.MonitorConf, opt *NewEngineOption) (
 
 // newNodeMaintainer initiates NodeMaintainer
 // for storage node, nodeMaintainer can register node's address into blockchain,
-// clean expired file's slices and heartbeat
+// clean expired file's slices, heartbeat and scrub slices
 func newNodeMaintainer(conf *config.MonitorConf, opt *NewEngineOption) (*nodemaintainer.NodeMaintainer, error) {
 	nodemaintainerSwitch := conf.NodemaintainerSwitch
 	if nodemaintainerSwitch != "on" {
@@ -150,6 +152,7 @@ func (m *Monitor) Start(ctx context.Context) error {
 			return err
 		}
 		m.nodeMaintainer.StartFileClear(ctx)
+		m.nodeMaintainer.StartScrub(ctx)
 		m.nodeMaintainer.HeartBeat(ctx)
 		if m.challengingMonitor != nil {
 			m.challengingMonitor.StartChallengeAnswer(ctx)
@@ -173,6 +176,7 @@ func (m *Monitor) Close() {
 
 	if m.nodeMaintainer != nil {
 		m.nodeMaintainer.StopFileClear()
+		m.nodeMaintainer.StopScrub()
 		m.nodeMaintainer.StopHeartBeat()
 	}
 }
@@ -17,7 +17,7 @@ import (
 	"context"
 	"encoding/json"
 	"io/ioutil"
-	"strconv"
+	"sort"
 	"time"
 
 	"github.com/PaddlePaddle/PaddleDTX/crypto/core/ecdsa"
@@ -30,6 +30,7 @@ import (
 	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/types"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
 	util "github.com/PaddlePaddle/PaddleDTX/xdb/pkgs/strings"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/storage"
 )
 
 // loopAnswer listens challenge requests and answer them in order to prove it's storing related files
@@ -39,10 +40,12 @@ func (c *ChallengingMonitor) loopAnswer(ctx context.Context) {
 	l := logger.WithField("runner", "answer loop")
 	defer func() {
 		nonce := time.Now().UnixNano()
-		msg, err := util.GetSigMessage(map[string]string{
-			"nodeID": pubkey.String(),
-			"nonce":  strconv.FormatInt(nonce, 10),
-		})
+		nodeOpts := &blockchain.NodeOperateOptions{
+			NodeID:      []byte(pubkey.String()),
+			Nonce:       nonce,
+			CurrentTime: nonce,
+		}
+		msg, err := util.GetSigMessage(nodeOpts)
 		if err != nil {
 			l.WithError(err).Error("failed to get the message to sign")
 			return
@@ -52,12 +55,9 @@ func (c *ChallengingMonitor) loopAnswer(ctx context.Context) {
 			l.WithError(err).Error("failed to sign node")
 			return
 		}
+		nodeOpts.Signature = sig[:]
 
-		nodeOpts := &blockchain.NodeOperateOptions{
-			NodeID:    []byte(pubkey.String()),
-			Nonce:     nonce,
-			Signature: sig[:],
-		}
+		// a draining node with slices left is not allowed to go offline
 		if err := c.blockchain.NodeOffline(nodeOpts); err != nil {
 			l.WithError(err).Error("failed to offline the node")
 		}
@@ -92,7 +92,15 @@ func (c *ChallengingMonitor) loopAnswer(ctx context.Context) {
 		if len(requests) == 0 {
 			continue
 		}
+		// time-bounded challenges go first, the most urgent first, and those already missed are skipped
+		sort.SliceStable(requests, func(i, j int) bool {
+			return requests[i].Deadline > 0 && (requests[j].Deadline == 0 || requests[i].Deadline < requests[j].Deadline)
+		})
 		for _, r := range requests {
+			if r.Deadline > 0 && r.Deadline < time.Now().UnixNano() {
+				l.WithField("challenge_id", r.ID).Warn("challenge deadline missed, skip it")
+				continue
+			}
 			if r.ChallengeAlgorithm == types.PairingChallengeAlgorithm {
 				c.doPairingChallengeAnswer(r, l)
 			} else if r.ChallengeAlgorithm == types.MerkleChallengeAlgorithm {
@@ -107,7 +115,11 @@ func (c *ChallengingMonitor) loopAnswer(ctx context.Context) {
 func (c *ChallengingMonitor) doPairingChallengeAnswer(r blockchain.Challenge, l *logrus.Entry) error {
 	// answer for each request
 	// calculate proof
-	l.WithField("challenge_id", r.ID).Infof("indices: %v, slices: %v", r.Indices, r.SliceIDs)
+	if len(r.Items) > 0 {
+		l.WithField("challenge_id", r.ID).Infof("aggregated challenge, files: %v", r.FileIDs())
+	} else {
+		l.WithField("challenge_id", r.ID).Infof("indices: %v, slices: %v", r.Indices, r.SliceIDs)
+	}
 	proof, err := c.doPairingCalculateProof(&r)
 	if err != nil {
 		l.WithError(err).Warnf("failed to calculate pairing proof for round: %d", r.Round)
@@ -186,14 +198,28 @@ func (c *ChallengingMonitor) doMerkleChallengeAnswer(r blockchain.Challenge, l *
 	return err
 }
 
-// doPairingCalculateProof calculate proof using stored files and random challenge
+// doPairingCalculateProof calculate proof using stored files and random challenge,
+//  slices of all files covered by an aggregated challenge are proved together
 func (c *ChallengingMonitor) doPairingCalculateProof(req *blockchain.Challenge) (randomProof, error) {
+	sliceIDs, storIndexes, indices, vs := req.SliceIDs, req.SliceStorIndexes, req.Indices, req.Vs
+	if len(req.Items) > 0 {
+		sliceIDs, storIndexes, indices, vs = nil, nil, nil, nil
+		for _, item := range req.Items {
+			if len(item.SliceIDs) != len(item.SliceStorIndexes) {
+				return randomProof{}, errorx.New(errorx.ErrCodeInternal, "invalid challenge for file %s", item.FileID)
+			}
+			sliceIDs = append(sliceIDs, item.SliceIDs...)
+			storIndexes = append(storIndexes, item.SliceStorIndexes...)
+			indices = append(indices, item.Indices...)
+			vs = append(vs, item.Vs...)
+		}
+	}
 
 	var content [][]byte
 	var sigmaContent [][]byte
-	for i, sliceID := range req.SliceIDs {
+	for i, sliceID := range sliceIDs {
 		// read slice content
-		dataReader, err := c.sliceStorage.Load(sliceID, req.SliceStorIndexes[i])
+		dataReader, err := c.sliceStorage.Load(sliceID, storIndexes[i])
 		if err != nil {
 			return randomProof{}, errorx.Wrap(err, "failed to load local slice %s", sliceID)
 		}
@@ -203,8 +229,8 @@ func (c *ChallengingMonitor) doPairingCalculateProof(req *blockchain.Challenge)
 		}
 		content = append(content, data)
 
-		// read sigmas content
-		sigmaFile := sliceID
+		// read sigmas content, a copy of the slice re-encrypted on the node has its own sigmas
+		sigmaFile := storage.KeyOf(sliceID, storIndexes[i])
 		dataReader, err = c.proveStorage.Load(sigmaFile)
 		if err != nil {
 			return randomProof{}, errorx.Wrap(err, "failed to load local slice sigmas %s", sliceID)
@@ -217,7 +243,7 @@ func (c *ChallengingMonitor) doPairingCalculateProof(req *blockchain.Challenge)
 		dataReader.Close()
 	}
 
-	if req.Indices == nil || req.Vs == nil {
+	if indices == nil || vs == nil {
 		return randomProof{}, errorx.New(errorx.ErrCodeInternal, "invalid challenge")
 	}
 
@@ -234,7 +260,7 @@ func (c *ChallengingMonitor) doPairingCalculateProof(req *blockchain.Challenge)
 		sigmas = append(sigmas, sigmaMap[req.Round])
 	}
 
-	sigma, mu, err := common.AnswerPairingChallenge(content, req.Indices, req.Vs, sigmas, req.RandThisRound)
+	sigma, mu, err := common.AnswerPairingChallenge(content, indices, vs, sigmas, req.RandThisRound)
 	if err != nil {
 		return randomProof{}, errorx.NewCode(err, errorx.ErrCodeInternal, "AnswerChallenge failed")
 	}
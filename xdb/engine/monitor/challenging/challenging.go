@@ -25,11 +25,13 @@ import (
 	"github.com/PaddlePaddle/PaddleDTX/xdb/config"
 	ctype "github.com/PaddlePaddle/PaddleDTX/xdb/engine/challenger/merkle/types"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/types"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
 )
 
 const (
 	DefaultRequestInterval = time.Minute * 60
 	defaultAnswerInterval  = time.Minute * 10
+	defaultBatchSize       = 1
 )
 
 var (
@@ -61,6 +63,10 @@ type Blockchain interface {
 	ListChallengeRequests(opt *blockchain.ListChallengeOptions) ([]blockchain.Challenge, error)
 	ChallengeRequest(opt *blockchain.ChallengeRequestOptions) error
 	ChallengeAnswer(opt *blockchain.ChallengeAnswerOptions) ([]byte, error)
+	ChallengeTimeout(opt *blockchain.ChallengeTimeoutOptions) error
+	HeartbeatTimeout(opt *blockchain.HeartbeatTimeoutOptions) error
+	ListNodes() (blockchain.Nodes, error)
+	GetNodeHealth(id []byte) (string, error)
 	NodeOffline(opt *blockchain.NodeOperateOptions) error
 }
 
@@ -71,6 +77,8 @@ type NewChallengingMonitorOptions struct {
 	ChallengeDB  ChallengeDB
 	SliceStorage SliceStorage
 	ProveStorage ProveStorage
+
+	AnswerDeadline time.Duration // time for storage nodes to answer a challenge, 0 means no deadline
 }
 
 // ChallengingMonitor's main work is to publish challenge requests if local node is dataOwner-node,
@@ -80,6 +88,10 @@ type ChallengingMonitor struct {
 
 	AnswerInterval  time.Duration
 	RequestInterval time.Duration
+	BatchSize       int           // max files aggregated in one pairing based challenge
+	AnswerDeadline  time.Duration // time-bounded challenges must be answered within it, 0 means no deadline
+
+	scheduler *Scheduler // if set, challenge requests are published by the scheduling policy
 
 	blockchain   Blockchain
 	challengeDB  ChallengeDB
@@ -93,10 +105,24 @@ type ChallengingMonitor struct {
 func New(conf *config.MonitorConf, opt *NewChallengingMonitorOptions) (*ChallengingMonitor, error) {
 	requestInterval := DefaultRequestInterval
 	answerInterval := defaultAnswerInterval
+	if conf.ChallengeAnswerInterval > 0 {
+		answerInterval = time.Duration(conf.ChallengeAnswerInterval) * time.Second
+	}
+	batchSize := conf.ChallengeBatchSize
+	if batchSize < defaultBatchSize {
+		batchSize = defaultBatchSize
+	}
+	// the contract rejects challenges leaving storage nodes less time to answer
+	if opt.AnswerDeadline > 0 && opt.AnswerDeadline < blockchain.MinAnswerWindow {
+		return nil, errorx.New(errorx.ErrCodeConfig, "answer deadline should be %s at least", blockchain.MinAnswerWindow)
+	}
 
 	logger.WithFields(logrus.Fields{
 		"request-interval": requestInterval.String(),
 		"answer-interval":  answerInterval.String(),
+		"batch-size":       batchSize,
+		"answer-deadline":  opt.AnswerDeadline.String(),
+		"scheduled":        conf.ChallengeSchedule != nil,
 	}).Info("monitor initialize...")
 
 	cm := &ChallengingMonitor{
@@ -104,12 +130,17 @@ func New(conf *config.MonitorConf, opt *NewChallengingMonitorOptions) (*Challeng
 
 		RequestInterval: requestInterval,
 		AnswerInterval:  answerInterval,
+		BatchSize:       batchSize,
+		AnswerDeadline:  opt.AnswerDeadline,
 
 		blockchain:   opt.Blockchain,
 		challengeDB:  opt.ChallengeDB,
 		sliceStorage: opt.SliceStorage,
 		proveStorage: opt.ProveStorage,
 	}
+	if conf.ChallengeSchedule != nil {
+		cm.scheduler = NewScheduler(conf.ChallengeSchedule)
+	}
 
 	return cm, nil
 }
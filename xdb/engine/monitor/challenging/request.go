@@ -19,6 +19,7 @@ import (
 	"math/big"
 	"math/rand"
 	"reflect"
+	"sort"
 	"time"
 
 	"github.com/PaddlePaddle/PaddleDTX/crypto/core/ecdsa"
@@ -36,6 +37,10 @@ import (
 // loopRequest publishes challenge requests if local node is dataOwner-node,
 //  and blocks current routine
 func (c *ChallengingMonitor) loopRequest(ctx context.Context) {
+	if c.scheduler != nil {
+		c.loopScheduledRequest(ctx)
+		return
+	}
 	pubkey := ecdsa.PublicKeyFromPrivateKey(c.PrivateKey)
 	rand.Seed(time.Now().UnixNano())
 	challengeAlgorithm, _ := c.challengeDB.GetChallengeConf()
@@ -46,6 +51,15 @@ func (c *ChallengingMonitor) loopRequest(ctx context.Context) {
 	ticker := time.NewTicker(c.RequestInterval)
 	defer ticker.Stop()
 
+	// timeouts are checked as often as nodes send heartbeats instead of by the request interval,
+	// challenges not answered in time stay answerable until their timeouts are recorded
+	var timeoutC <-chan time.Time
+	if c.AnswerDeadline > 0 {
+		timeoutTicker := time.NewTicker(blockchain.HeartBeatFreq)
+		defer timeoutTicker.Stop()
+		timeoutC = timeoutTicker.C
+	}
+
 	c.doneLoopReqC = make(chan struct{})
 	defer close(c.doneLoopReqC)
 
@@ -53,9 +67,14 @@ func (c *ChallengingMonitor) loopRequest(ctx context.Context) {
 		select {
 		case <-ctx.Done():
 			return
+		case <-timeoutC:
+			c.failTimeoutChallenges(pubkey, l)
+			continue
 		case <-ticker.C:
 		}
 
+		c.penalizeSilentNodes(pubkey, l)
+
 		nsopt := blockchain.ListNsOptions{
 			Owner:       pubkey[:],
 			TimeEnd:     time.Now().UnixNano(),
@@ -113,7 +132,9 @@ func (c *ChallengingMonitor) loopRequest(ctx context.Context) {
 		if len(files) == 0 {
 			continue
 		}
-		if challengeAlgorithm == types.PairingChallengeAlgorithm {
+		if challengeAlgorithm == types.PairingChallengeAlgorithm && c.BatchSize > 1 {
+			c.doPairingBatchChallengeRequest(challengeAlgorithm, files, pubkey, l)
+		} else if challengeAlgorithm == types.PairingChallengeAlgorithm {
 			c.doPairingChallengeRequest(challengeAlgorithm, files, pubkey, l)
 		} else {
 			c.doMerkleChallengeRequest(challengeAlgorithm, files, pubkey, l)
@@ -121,6 +142,109 @@ func (c *ChallengingMonitor) loopRequest(ctx context.Context) {
 	}
 }
 
+// loopScheduledRequest publishes challenge requests chosen by the scheduling policy,
+//  and blocks current routine
+func (c *ChallengingMonitor) loopScheduledRequest(ctx context.Context) {
+	pubkey := ecdsa.PublicKeyFromPrivateKey(c.PrivateKey)
+	challengeAlgorithm, _ := c.challengeDB.GetChallengeConf()
+
+	l := logger.WithField("runner", "scheduled request loop")
+	defer l.Info("runner stopped")
+
+	ticker := time.NewTicker(defaultScheduleTick)
+	defer ticker.Stop()
+
+	c.doneLoopReqC = make(chan struct{})
+	defer close(c.doneLoopReqC)
+
+	var files []blockchain.File
+	var health map[string]string
+	var refreshTime int64
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if c.AnswerDeadline > 0 {
+			c.failTimeoutChallenges(pubkey, l)
+		}
+
+		// files and node health are reloaded from blockchain periodically
+		now := time.Now().UnixNano()
+		if now-refreshTime >= int64(scheduleRefreshInterval) {
+			c.penalizeSilentNodes(pubkey, l)
+			fs, hs, err := c.loadScheduleState(pubkey)
+			if err != nil {
+				l.WithError(err).Warn("failed to load files and node health from blockchain")
+				continue
+			}
+			files, health, refreshTime = fs, hs, now
+			l.WithField("amount", len(files)).Debug("files loaded")
+		}
+
+		targets := c.scheduler.Schedule(files, health, now)
+		if len(targets) == 0 {
+			continue
+		}
+		l.WithField("amount", len(targets)).Info("challenge targets scheduled")
+
+		// group targets by storage node, so that pairing based challenges can be aggregated
+		var nodes []string
+		nodeFiles := make(map[string][]blockchain.File)
+		for _, t := range targets {
+			if _, exist := nodeFiles[string(t.NodeID)]; !exist {
+				nodes = append(nodes, string(t.NodeID))
+			}
+			nodeFiles[string(t.NodeID)] = append(nodeFiles[string(t.NodeID)], t.File)
+		}
+		for _, node := range nodes {
+			c.challengeNode(challengeAlgorithm, nodeFiles[node], []byte(node), pubkey, l)
+		}
+	}
+}
+
+// loadScheduleState lists files of all namespaces owned by local node, and health status of nodes storing them
+func (c *ChallengingMonitor) loadScheduleState(pubkey ecdsa.PublicKey) ([]blockchain.File, map[string]string, error) {
+	nss, err := c.blockchain.ListFileNs(&blockchain.ListNsOptions{
+		Owner:       pubkey[:],
+		TimeEnd:     time.Now().UnixNano(),
+		CurrentTime: time.Now().UnixNano(),
+	})
+	if err != nil {
+		return nil, nil, errorx.Wrap(err, "failed to list file ns")
+	}
+
+	var files []blockchain.File
+	health := make(map[string]string)
+	for _, ns := range nss {
+		fs, err := c.blockchain.ListFiles(&blockchain.ListFileOptions{
+			Owner:       pubkey[:],
+			Namespace:   ns.Name,
+			TimeEnd:     time.Now().UnixNano(),
+			CurrentTime: time.Now().UnixNano(),
+		})
+		if err != nil {
+			return nil, nil, errorx.Wrap(err, "failed to list files of ns %s", ns.Name)
+		}
+		for _, f := range fs {
+			for _, slice := range f.Slices {
+				if _, exist := health[string(slice.NodeID)]; exist {
+					continue
+				}
+				h, err := c.blockchain.GetNodeHealth(slice.NodeID)
+				if err != nil {
+					logger.WithField("node", string(slice.NodeID)).WithError(err).Warn("failed to get node health")
+				}
+				health[string(slice.NodeID)] = h
+			}
+		}
+		files = append(files, fs...)
+	}
+	return files, health, nil
+}
+
 func (c *ChallengingMonitor) doPairingChallengeRequest(challengeAlgorithm string, files []blockchain.File,
 	pubkey ecdsa.PublicKey, l *logrus.Entry) error {
 
@@ -128,89 +252,170 @@ func (c *ChallengingMonitor) doPairingChallengeRequest(challengeAlgorithm string
 	rand.Seed(time.Now().UnixNano())
 	fileSelected := files[rand.Int()%len(files)]
 	sliceSelected := fileSelected.Slices[rand.Int()%len(fileSelected.Slices)]
-	nodeSelected := sliceSelected.NodeID
 
 	l.WithField("fileID", fileSelected.ID).Info("file selected")
 
-	// find slice idx list for selected node
-	// get map from sliceIdx to sliceID
-	var sliceList []int
-	sliceMap := make(map[int]blockchain.PublicSliceMeta)
-	for _, slice := range fileSelected.Slices {
-		if reflect.DeepEqual(slice.NodeID, nodeSelected) {
-			sliceList = append(sliceList, slice.SliceIdx)
-			sliceMap[slice.SliceIdx] = slice
+	return c.publishPairingChallenge(challengeAlgorithm, fileSelected, sliceSelected.NodeID, pubkey, l)
+}
+
+// doPairingBatchChallengeRequest publishes one aggregated challenge which covers up to BatchSize files
+//  having slices on a randomly selected storage node, so that the node answers them with a single proof
+func (c *ChallengingMonitor) doPairingBatchChallengeRequest(challengeAlgorithm string, files []blockchain.File,
+	pubkey ecdsa.PublicKey, l *logrus.Entry) error {
+
+	// select the target node by a random slice of a random file
+	rand.Seed(time.Now().UnixNano())
+	fileSelected := files[rand.Int()%len(files)]
+	nodeSelected := fileSelected.Slices[rand.Int()%len(fileSelected.Slices)].NodeID
+
+	// the selected file goes first, then the others in random order
+	candidates := []blockchain.File{fileSelected}
+	for _, i := range rand.Perm(len(files)) {
+		if files[i].ID != fileSelected.ID {
+			candidates = append(candidates, files[i])
 		}
 	}
+	return c.publishPairingBatchChallenge(challengeAlgorithm, candidates, nodeSelected, pubkey, l)
+}
 
-	// generate challenge info, which includes sliceIdx list, random number list, round number and random number for this round
-	indices, vs, round, randNum, err := c.challengeDB.GenerateChallenge(sliceList, c.RequestInterval.Nanoseconds())
+func (c *ChallengingMonitor) doMerkleChallengeRequest(challengeAlgorithm string, files []blockchain.File,
+	pubkey ecdsa.PublicKey, l *logrus.Entry) error {
+	// select just one slice
+	fileSelected := files[rand.Int()%len(files)]
+	sliceSelected := fileSelected.Slices[rand.Int()%len(fileSelected.Slices)]
+
+	return c.publishMerkleChallenge(challengeAlgorithm, fileSelected, sliceSelected, pubkey, l)
+}
+
+// challengeNode publishes challenges for files on the storage node, pairing based challenges
+//  are aggregated by BatchSize, merkle challenges take a random slice of each file on the node
+func (c *ChallengingMonitor) challengeNode(challengeAlgorithm string, files []blockchain.File, nodeID []byte,
+	pubkey ecdsa.PublicKey, l *logrus.Entry) {
+
+	if challengeAlgorithm == types.PairingChallengeAlgorithm && c.BatchSize > 1 {
+		for len(files) > 0 {
+			n := c.BatchSize
+			if n > len(files) {
+				n = len(files)
+			}
+			c.publishPairingBatchChallenge(challengeAlgorithm, files[:n], nodeID, pubkey, l)
+			files = files[n:]
+		}
+		return
+	}
+	for _, file := range files {
+		if challengeAlgorithm == types.PairingChallengeAlgorithm {
+			c.publishPairingChallenge(challengeAlgorithm, file, nodeID, pubkey, l)
+			continue
+		}
+		var slices []blockchain.PublicSliceMeta
+		for _, slice := range file.Slices {
+			if reflect.DeepEqual(slice.NodeID, nodeID) {
+				slices = append(slices, slice)
+			}
+		}
+		if len(slices) > 0 {
+			c.publishMerkleChallenge(challengeAlgorithm, file, slices[rand.Int()%len(slices)], pubkey, l)
+		}
+	}
+}
+
+// publishPairingChallenge publishes a pairing based challenge for slices of the file on the node
+func (c *ChallengingMonitor) publishPairingChallenge(challengeAlgorithm string, file blockchain.File, nodeID []byte,
+	pubkey ecdsa.PublicKey, l *logrus.Entry) error {
+
+	item, round, randNum, err := c.generatePairingChallengeItem(file, nodeID)
 	if err != nil {
 		l.WithError(err).Warn("failed GenerateChallenge")
 		return err
 	}
 
-	// get sliceID for each idx
-	var sliceIDs []string
-	var storIndexes []string
-	for _, idx := range indices {
-		slice := sliceMap[int(new(big.Int).SetBytes(idx).Int64())]
-		sliceIDs = append(sliceIDs, slice.ID)
-		storIndexes = append(storIndexes, slice.StorIndex)
-	}
-	if len(sliceIDs) == 0 {
-		l.Warn("failed GenerateChallenge, sliceIDs is empty")
-		return errorx.New(errorx.ErrCodeInternal, "GenerateChallenge failed")
-	}
-
 	// publish challenge request
 	requestOpt := blockchain.ChallengeRequestOptions{
 		ChallengeID:        uuid.NewString(),
 		FileOwner:          pubkey[:],
-		TargetNode:         sliceSelected.NodeID,
-		FileID:             fileSelected.ID,
-		SliceIDs:           sliceIDs,
-		SliceStorIndexes:   storIndexes,
+		TargetNode:         nodeID,
+		FileID:             item.FileID,
+		SliceIDs:           item.SliceIDs,
+		SliceStorIndexes:   item.SliceStorIndexes,
 		ChallengeTime:      time.Now().UnixNano(),
-		Indices:            indices,
-		Vs:                 vs,
+		Indices:            item.Indices,
+		Vs:                 item.Vs,
 		Round:              round,
 		RandThisRound:      randNum,
 		ChallengeAlgorithm: challengeAlgorithm,
 	}
-
-	// sign request
-	msg, err := util.GetSigMessage(requestOpt)
-	if err != nil {
-		l.WithField("challenge_id", requestOpt.ChallengeID).WithError(err).Warn("failed to get the message to sign")
+	if err := c.publishChallengeRequest(&requestOpt, l); err != nil {
 		return err
 	}
-	sig, err := ecdsa.Sign(c.PrivateKey, hash.HashUsingSha256([]byte(msg)))
-	if err != nil {
-		l.WithField("challenge_id", requestOpt.ChallengeID).WithError(err).Warn("failed to sign request")
-		return err
+	l.WithFields(logrus.Fields{
+		"challenge_id": requestOpt.ChallengeID,
+		"target_node":  string(requestOpt.TargetNode),
+		"round":        requestOpt.Round,
+		"indices":      requestOpt.Indices,
+		"slices":       requestOpt.SliceIDs,
+	}).Info("successfully published challenge request")
+	return nil
+}
+
+// publishPairingBatchChallenge publishes one aggregated challenge which covers up to BatchSize files
+//  of candidates having slices on the node
+func (c *ChallengingMonitor) publishPairingBatchChallenge(challengeAlgorithm string, candidates []blockchain.File,
+	nodeID []byte, pubkey ecdsa.PublicKey, l *logrus.Entry) error {
+
+	var items []blockchain.ChallengeItem
+	var round int64
+	var randNum []byte
+	for _, file := range candidates {
+		if len(items) >= c.BatchSize {
+			break
+		}
+		if !hasSliceOnNode(file, nodeID) {
+			continue
+		}
+		item, r, rn, err := c.generatePairingChallengeItem(file, nodeID)
+		if err != nil {
+			l.WithField("fileID", file.ID).WithError(err).Warn("failed GenerateChallenge")
+			continue
+		}
+		// challenges generated after the round changed can not be aggregated with previous ones
+		if len(items) > 0 && r != round {
+			continue
+		}
+		round, randNum = r, rn
+		items = append(items, item)
+	}
+	if len(items) == 0 {
+		l.Warn("failed GenerateChallenge, no file to challenge")
+		return errorx.New(errorx.ErrCodeInternal, "GenerateChallenge failed")
 	}
-	requestOpt.Signature = sig[:]
 
-	if err := c.blockchain.ChallengeRequest(&requestOpt); err != nil {
-		l.WithField("challenge_id", requestOpt.ChallengeID).WithError(err).Warn("failed to publish challenge request")
+	// publish challenge request
+	requestOpt := blockchain.ChallengeRequestOptions{
+		ChallengeID:        uuid.NewString(),
+		FileOwner:          pubkey[:],
+		TargetNode:         nodeID,
+		ChallengeTime:      time.Now().UnixNano(),
+		Round:              round,
+		RandThisRound:      randNum,
+		ChallengeAlgorithm: challengeAlgorithm,
+		Items:              items,
+	}
+	if err := c.publishChallengeRequest(&requestOpt, l); err != nil {
 		return err
 	}
 	l.WithFields(logrus.Fields{
 		"challenge_id": requestOpt.ChallengeID,
 		"target_node":  string(requestOpt.TargetNode),
 		"round":        requestOpt.Round,
-		"indices":      requestOpt.Indices,
-		"slices":       requestOpt.SliceIDs,
-	}).Info("successfully published challenge request")
+		"file_num":     len(items),
+	}).Info("successfully published aggregated challenge request")
 	return nil
 }
 
-func (c *ChallengingMonitor) doMerkleChallengeRequest(challengeAlgorithm string, files []blockchain.File,
-	pubkey ecdsa.PublicKey, l *logrus.Entry) error {
-	// select just one slice
-	fileSelected := files[rand.Int()%len(files)]
-	sliceSelected := fileSelected.Slices[rand.Int()%len(fileSelected.Slices)]
+// publishMerkleChallenge takes a range of the slice and publishes a merkle challenge for it
+func (c *ChallengingMonitor) publishMerkleChallenge(challengeAlgorithm string, fileSelected blockchain.File,
+	sliceSelected blockchain.PublicSliceMeta, pubkey ecdsa.PublicKey, l *logrus.Entry) error {
 
 	// take one range
 	rangeSelected, err := c.challengeDB.Take(fileSelected.ID, sliceSelected.ID, sliceSelected.NodeID)
@@ -256,6 +461,66 @@ func (c *ChallengingMonitor) doMerkleChallengeRequest(challengeAlgorithm string,
 		ChallengeAlgorithm: challengeAlgorithm,
 	}
 
+	if err := c.publishChallengeRequest(&requestOpt, l); err != nil {
+		return err
+	}
+
+	l.WithFields(logrus.Fields{
+		"challenge_id":   requestOpt.ChallengeID,
+		"target_node":    string(sliceSelected.NodeID),
+		"file_selected":  fileSelected.ID,
+		"slice_selected": sliceSelected.ID,
+		"range_list":     requestOpt.Ranges,
+	}).Info("successfully published merkle challenge request")
+	return nil
+}
+
+// generatePairingChallengeItem generates challenge for slices of the file stored on the node,
+//  returns challenge round and random number of this round as well
+func (c *ChallengingMonitor) generatePairingChallengeItem(file blockchain.File, nodeID []byte) (
+	blockchain.ChallengeItem, int64, []byte, error) {
+
+	// find slice idx list for selected node
+	// get map from sliceIdx to sliceID
+	var sliceList []int
+	sliceMap := make(map[int]blockchain.PublicSliceMeta)
+	for _, slice := range file.Slices {
+		if reflect.DeepEqual(slice.NodeID, nodeID) {
+			sliceList = append(sliceList, slice.SliceIdx)
+			sliceMap[slice.SliceIdx] = slice
+		}
+	}
+
+	// generate challenge info, which includes sliceIdx list, random number list, round number and random number for this round
+	indices, vs, round, randNum, err := c.challengeDB.GenerateChallenge(sliceList, c.RequestInterval.Nanoseconds())
+	if err != nil {
+		return blockchain.ChallengeItem{}, 0, nil, err
+	}
+
+	// get sliceID for each idx
+	item := blockchain.ChallengeItem{
+		FileID:  file.ID,
+		Indices: indices,
+		Vs:      vs,
+	}
+	for _, idx := range indices {
+		slice := sliceMap[int(new(big.Int).SetBytes(idx).Int64())]
+		item.SliceIDs = append(item.SliceIDs, slice.ID)
+		item.SliceStorIndexes = append(item.SliceStorIndexes, slice.StorIndex)
+	}
+	if len(item.SliceIDs) == 0 {
+		return blockchain.ChallengeItem{}, 0, nil, errorx.New(errorx.ErrCodeInternal, "GenerateChallenge failed, sliceIDs is empty")
+	}
+	return item, round, randNum, nil
+}
+
+// publishChallengeRequest signs the request and publishes it on chain,
+//  the request is time-bounded if AnswerDeadline is set
+func (c *ChallengingMonitor) publishChallengeRequest(requestOpt *blockchain.ChallengeRequestOptions, l *logrus.Entry) error {
+	if c.AnswerDeadline > 0 {
+		requestOpt.Deadline = requestOpt.ChallengeTime + c.AnswerDeadline.Nanoseconds()
+	}
+
 	// sign request
 	msg, err := util.GetSigMessage(requestOpt)
 	if err != nil {
@@ -269,17 +534,151 @@ func (c *ChallengingMonitor) doMerkleChallengeRequest(challengeAlgorithm string,
 	}
 	requestOpt.Signature = sig[:]
 
-	if err := c.blockchain.ChallengeRequest(&requestOpt); err != nil {
+	if err := c.blockchain.ChallengeRequest(requestOpt); err != nil {
 		l.WithField("challenge_id", requestOpt.ChallengeID).WithError(err).Warn("failed to publish challenge request")
 		return err
 	}
-
-	l.WithFields(logrus.Fields{
-		"challenge_id":   requestOpt.ChallengeID,
-		"target_node":    string(sliceSelected.NodeID),
-		"file_selected":  fileSelected.ID,
-		"slice_selected": sliceSelected.ID,
-		"range_list":     requestOpt.Ranges,
-	}).Info("successfully published merkle challenge request")
 	return nil
 }
+
+// failTimeoutChallenges marks time-bounded challenges of local node not answered before deadline as failed,
+//  the contract accepts timeouts only after the target nodes sent heartbeats later than the deadlines
+func (c *ChallengingMonitor) failTimeoutChallenges(pubkey ecdsa.PublicKey, l *logrus.Entry) {
+	now := time.Now().UnixNano()
+	challenges, err := c.blockchain.ListChallengeRequests(&blockchain.ListChallengeOptions{
+		FileOwner: pubkey[:],
+		Status:    blockchain.ChallengeToProve,
+		TimeEnd:   now - c.AnswerDeadline.Nanoseconds(),
+	})
+	if err != nil {
+		l.WithError(err).Warn("failed to list challenges to prove from blockchain")
+		return
+	}
+	if len(challenges) == 0 {
+		return
+	}
+	nodes, err := c.blockchain.ListNodes()
+	if err != nil {
+		l.WithError(err).Warn("failed to list nodes from blockchain")
+		return
+	}
+	heartbeats := make(map[string]int64)
+	for _, node := range nodes {
+		heartbeats[string(node.ID)] = node.UpdateAt
+	}
+	for _, ch := range challenges {
+		if ch.Deadline == 0 || ch.Deadline >= now || heartbeats[string(ch.TargetNode)] <= ch.Deadline {
+			continue
+		}
+		opt := blockchain.ChallengeTimeoutOptions{
+			ChallengeID: ch.ID,
+			CurrentTime: now,
+		}
+		msg, err := util.GetSigMessage(opt)
+		if err != nil {
+			l.WithField("challenge_id", ch.ID).WithError(err).Warn("failed to get the message to sign")
+			continue
+		}
+		sig, err := ecdsa.Sign(c.PrivateKey, hash.HashUsingSha256([]byte(msg)))
+		if err != nil {
+			l.WithField("challenge_id", ch.ID).WithError(err).Warn("failed to sign")
+			continue
+		}
+		opt.Signature = sig[:]
+		if err := c.blockchain.ChallengeTimeout(&opt); err != nil {
+			l.WithField("challenge_id", ch.ID).WithError(err).Warn("failed to mark timeout challenge as failed")
+			continue
+		}
+		l.WithFields(logrus.Fields{
+			"challenge_id": ch.ID,
+			"target_node":  string(ch.TargetNode),
+		}).Info("challenge missed deadline")
+	}
+}
+
+// penalizeSilentNodes penalizes the heartbeats missed by online storage nodes since their last heartbeats,
+//  nodes not storing files of local node refuse the penalty and are skipped
+func (c *ChallengingMonitor) penalizeSilentNodes(pubkey ecdsa.PublicKey, l *logrus.Entry) {
+	nodes, err := c.blockchain.ListNodes()
+	if err != nil {
+		l.WithError(err).Warn("failed to list nodes from blockchain")
+		return
+	}
+	now := time.Now().UnixNano()
+	freq := int64(blockchain.HeartBeatFreq)
+	for _, node := range nodes {
+		if !node.Online || node.UpdateAt == 0 {
+			continue
+		}
+		checkFrom := node.UpdateAt + freq
+		if node.HeartbeatCheckedAt > checkFrom {
+			checkFrom = node.HeartbeatCheckedAt
+		}
+		// the contract accepts no time later than the window after the last heartbeat,
+		// and times missed heartbeats by heartbeats of the other nodes
+		ctime := now
+		if end := node.UpdateAt + int64(blockchain.HeartbeatTimeoutWindow); ctime > end {
+			ctime = end
+		}
+		if others := othersHeartbeatTime(nodes, node.ID); ctime > others {
+			ctime = others
+		}
+		if ctime-checkFrom < freq {
+			continue
+		}
+		opt := blockchain.HeartbeatTimeoutOptions{
+			NodeID:      node.ID,
+			FileOwner:   pubkey[:],
+			CurrentTime: ctime,
+		}
+		msg, err := util.GetSigMessage(opt)
+		if err != nil {
+			l.WithField("target_node", string(node.ID)).WithError(err).Warn("failed to get the message to sign")
+			continue
+		}
+		sig, err := ecdsa.Sign(c.PrivateKey, hash.HashUsingSha256([]byte(msg)))
+		if err != nil {
+			l.WithField("target_node", string(node.ID)).WithError(err).Warn("failed to sign")
+			continue
+		}
+		opt.Signature = sig[:]
+		if err := c.blockchain.HeartbeatTimeout(&opt); err != nil {
+			if errorx.Is(err, errorx.ErrCodeNotAuthorized) {
+				continue
+			}
+			l.WithField("target_node", string(node.ID)).WithError(err).Warn("failed to penalize missed heartbeats")
+			continue
+		}
+		l.WithFields(logrus.Fields{
+			"target_node": string(node.ID),
+			"update_at":   node.UpdateAt,
+		}).Info("node missed heartbeats")
+	}
+}
+
+// othersHeartbeatTime gets the median of the latest heartbeats of online nodes other than the given one,
+// the same as the contract does, and 0 if there is no other online node
+func othersHeartbeatTime(nodes blockchain.Nodes, nodeID []byte) int64 {
+	var times []int64
+	for _, n := range nodes {
+		if !n.Online || n.UpdateAt == 0 || reflect.DeepEqual(n.ID, nodeID) {
+			continue
+		}
+		times = append(times, n.UpdateAt)
+	}
+	if len(times) == 0 {
+		return 0
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+	return times[len(times)/2]
+}
+
+// hasSliceOnNode checks whether any slice of the file is stored on the node
+func hasSliceOnNode(file blockchain.File, nodeID []byte) bool {
+	for _, slice := range file.Slices {
+		if reflect.DeepEqual(slice.NodeID, nodeID) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,202 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package challenging
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/config"
+)
+
+const (
+	defaultScheduleInterval = time.Hour * 24
+	defaultScheduleTick     = time.Minute
+	scheduleRefreshInterval = time.Minute * 10
+	budgetWindow            = time.Hour
+
+	criticalWeight = 4 // files under critical namespaces are challenged 4 times as often
+	expiryWeight   = 2 // files near expiry are challenged twice as often
+)
+
+// healthWeights speeds up challenges of slices on unhealthy nodes and enlarges their budgets accordingly
+var healthWeights = map[string]int{
+	blockchain.NodeHealthGood:   1,
+	blockchain.NodeHealthMedium: 2,
+	blockchain.NodeHealthBad:    4,
+}
+
+// Target is a file to be challenged on a storage node
+type Target struct {
+	File   blockchain.File
+	NodeID []byte
+}
+
+// Scheduler decides which files are challenged on which storage nodes.
+// Slices on Yellow or Red nodes, files near expiry and files under critical namespaces are challenged more often,
+//  and every node has a challenge budget per hour so that healthy nodes are not over-challenged
+type Scheduler struct {
+	interval     time.Duration
+	expiryWindow time.Duration
+	nodeBudget   int
+	namespaces   map[string]config.NamespaceScheduleConf
+
+	lastChallenged map[string]int64   // from file and node to its latest challenge time
+	nodeChallenges map[string][]int64 // from node to challenge times within the budget window
+}
+
+// NewScheduler creates a Scheduler by the policy configuration
+func NewScheduler(conf *config.ChallengeScheduleConf) *Scheduler {
+	interval := time.Duration(conf.Interval) * time.Minute
+	if interval <= 0 {
+		interval = defaultScheduleInterval
+	}
+	namespaces := make(map[string]config.NamespaceScheduleConf)
+	for _, ns := range conf.Namespaces {
+		namespaces[ns.Name] = ns
+	}
+	return &Scheduler{
+		interval:     interval,
+		expiryWindow: time.Duration(conf.ExpiryWindow) * time.Hour,
+		nodeBudget:   conf.NodeBudget,
+		namespaces:   namespaces,
+
+		lastChallenged: make(map[string]int64),
+		nodeChallenges: make(map[string][]int64),
+	}
+}
+
+// Interval returns how often the file is challenged on a storage node with the health status at now
+func (s *Scheduler) Interval(file blockchain.File, health string, now int64) time.Duration {
+	interval := s.interval
+	weight := healthWeight(health)
+	if ns, ok := s.namespaces[file.Namespace]; ok {
+		if ns.Interval > 0 {
+			interval = time.Duration(ns.Interval) * time.Minute
+		}
+		if ns.Critical {
+			weight *= criticalWeight
+		}
+	}
+	if s.expiryWindow > 0 && file.ExpireTime-now < int64(s.expiryWindow) {
+		weight *= expiryWeight
+	}
+	return interval / time.Duration(weight)
+}
+
+// Schedule returns targets due at now, the most overdue first, and records them as challenged.
+// Targets beyond the budget of their nodes are left for later rounds. health maps node ID to its health status
+func (s *Scheduler) Schedule(files []blockchain.File, health map[string]string, now int64) []Target {
+	type candidate struct {
+		target  Target
+		key     string
+		overdue float64
+	}
+	var candidates []candidate
+	seen := make(map[string]struct{})
+	for _, file := range files {
+		if file.ExpireTime <= now || file.DeleteTime > 0 {
+			continue
+		}
+		for _, nodeID := range fileNodes(file) {
+			key := file.ID + ":" + string(nodeID)
+			seen[key] = struct{}{}
+
+			interval := int64(s.Interval(file, health[string(nodeID)], now))
+			last, ok := s.lastChallenged[key]
+			if !ok {
+				// spread targets seen for the first time over one interval, rather than challenging them all at once
+				last = now - rand.Int63n(interval)
+				s.lastChallenged[key] = last
+			}
+			if now-last < interval {
+				continue
+			}
+			candidates = append(candidates, candidate{
+				target:  Target{File: file, NodeID: nodeID},
+				key:     key,
+				overdue: float64(now-last) / float64(interval),
+			})
+		}
+	}
+	// forget files deleted or expired
+	for key := range s.lastChallenged {
+		if _, ok := seen[key]; !ok {
+			delete(s.lastChallenged, key)
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].overdue > candidates[j].overdue
+	})
+	var targets []Target
+	for _, c := range candidates {
+		nodeID := string(c.target.NodeID)
+		if !s.takeBudget(nodeID, health[nodeID], now) {
+			continue
+		}
+		s.lastChallenged[c.key] = now
+		targets = append(targets, c.target)
+	}
+	return targets
+}
+
+// takeBudget consumes one challenge of the node's budget within the budget window,
+//  returns false if the budget is used up
+func (s *Scheduler) takeBudget(nodeID, health string, now int64) bool {
+	if s.nodeBudget <= 0 {
+		return true
+	}
+	var recent []int64
+	for _, t := range s.nodeChallenges[nodeID] {
+		if now-t < int64(budgetWindow) {
+			recent = append(recent, t)
+		}
+	}
+	if len(recent) >= s.nodeBudget*healthWeight(health) {
+		s.nodeChallenges[nodeID] = recent
+		return false
+	}
+	s.nodeChallenges[nodeID] = append(recent, now)
+	return true
+}
+
+// healthWeight returns the weight of the health status, unknown status is taken as Green
+func healthWeight(health string) int {
+	if w, ok := healthWeights[health]; ok {
+		return w
+	}
+	return 1
+}
+
+// fileNodes returns distinct storage nodes holding slices of the file
+func fileNodes(file blockchain.File) [][]byte {
+	var nodes [][]byte
+	for _, slice := range file.Slices {
+		exist := false
+		for _, n := range nodes {
+			if reflect.DeepEqual(n, slice.NodeID) {
+				exist = true
+				break
+			}
+		}
+		if !exist {
+			nodes = append(nodes, slice.NodeID)
+		}
+	}
+	return nodes
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package challenging
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/config"
+)
+
+func newFile(id, ns string, expireTime int64, nodes ...string) blockchain.File {
+	f := blockchain.File{
+		ID:         id,
+		Namespace:  ns,
+		ExpireTime: expireTime,
+	}
+	for i, n := range nodes {
+		f.Slices = append(f.Slices, blockchain.PublicSliceMeta{
+			ID:       id + "-" + n,
+			NodeID:   []byte(n),
+			SliceIdx: i,
+		})
+	}
+	return f
+}
+
+func TestSchedulerInterval(t *testing.T) {
+	s := NewScheduler(&config.ChallengeScheduleConf{
+		Interval:     60,
+		ExpiryWindow: 24,
+		Namespaces: []config.NamespaceScheduleConf{
+			{Name: "critical", Critical: true},
+			{Name: "hourly", Interval: 10},
+		},
+	})
+	now := time.Now().UnixNano()
+	far := now + int64(time.Hour*24*30)
+	near := now + int64(time.Hour)
+
+	require.Equal(t, time.Hour, s.Interval(newFile("f", "ns", far), blockchain.NodeHealthGood, now))
+	require.Equal(t, time.Hour, s.Interval(newFile("f", "ns", far), "", now))
+	require.Equal(t, time.Minute*30, s.Interval(newFile("f", "ns", far), blockchain.NodeHealthMedium, now))
+	require.Equal(t, time.Minute*15, s.Interval(newFile("f", "ns", far), blockchain.NodeHealthBad, now))
+	require.Equal(t, time.Minute*30, s.Interval(newFile("f", "ns", near), blockchain.NodeHealthGood, now))
+	require.Equal(t, time.Minute*15, s.Interval(newFile("f", "critical", far), blockchain.NodeHealthGood, now))
+	require.Equal(t, time.Minute*10, s.Interval(newFile("f", "hourly", far), blockchain.NodeHealthGood, now))
+	require.Equal(t, time.Second*150, s.Interval(newFile("f", "hourly", near), blockchain.NodeHealthMedium, now))
+}
+
+func TestSchedule(t *testing.T) {
+	s := NewScheduler(&config.ChallengeScheduleConf{
+		Interval:   60,
+		NodeBudget: 1,
+	})
+	now := time.Now().UnixNano()
+	expireTime := now + int64(time.Hour*24*30)
+	files := []blockchain.File{
+		newFile("f1", "ns", expireTime, "green", "red"),
+		newFile("f2", "ns", expireTime, "green", "red"),
+		newFile("f3", "ns", now-1, "green", "red"), // expired
+	}
+	health := map[string]string{
+		"green": blockchain.NodeHealthGood,
+		"red":   blockchain.NodeHealthBad,
+	}
+
+	// targets seen for the first time are spread over one interval
+	s.Schedule(files, health, now)
+	require.Equal(t, 4, len(s.lastChallenged))
+
+	// all due after one interval, the green node is limited by its budget while the red one has 4 times of it
+	now += int64(time.Hour)
+	targets := s.Schedule(files, health, now)
+	require.Equal(t, 3, len(targets))
+	nodeNum := make(map[string]int)
+	for _, target := range targets {
+		require.NotEqual(t, "f3", target.File.ID)
+		nodeNum[string(target.NodeID)]++
+	}
+	require.Equal(t, 1, nodeNum["green"])
+	require.Equal(t, 2, nodeNum["red"])
+
+	// the rest of green node waits for the budget window
+	now += int64(time.Minute)
+	require.Equal(t, 0, len(s.Schedule(files, health, now)))
+	now += int64(budgetWindow)
+	targets = s.Schedule(files, health, now)
+	require.NotEmpty(t, targets)
+
+	// deleted files are forgotten
+	s.Schedule(files[:1], health, now)
+	require.Equal(t, 2, len(s.lastChallenged))
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filemaintainer
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/common"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/encryptor"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/erasure"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+)
+
+// getFileStripes decrypts the structure of an erasure coded file and groups its slices by stripe
+func (m FileMaintainer) getFileStripes(file blockchain.File) ([]erasure.Stripe, error) {
+	decStruct, err := m.encryptor.Recover(bytes.NewReader(file.Structure), &encryptor.RecoverOptions{
+		FileID:     file.ID,
+		KeyVersion: file.GetKeyVersion(),
+	})
+	if err != nil {
+		return nil, errorx.NewCode(err, errorx.ErrCodeCrypto, "failed to decrypt file structure")
+	}
+	var fs blockchain.FileStructure
+	if err := fs.Parse(decStruct); err != nil {
+		return nil, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to parse file structure")
+	}
+	return erasure.SplitStripes(fs), nil
+}
+
+// stripeNodes returns nodes which store the other slices of the same stripe,
+// new node for a migrated slice should not be one of them
+func stripeNodes(stripe erasure.Stripe, sliceID string, slices []blockchain.PublicSliceMeta) []string {
+	ids := make(map[string]struct{})
+	for _, s := range stripe.Shards() {
+		if s.SliceID != sliceID {
+			ids[s.SliceID] = struct{}{}
+		}
+	}
+	var nodes []string
+	for _, s := range slices {
+		if _, ok := ids[s.ID]; ok {
+			nodes = append(nodes, string(s.NodeID))
+		}
+	}
+	return nodes
+}
+
+// rebuildSlice rebuilds a slice of erasure coded file from the other slices of its stripe,
+// the node where the slice is stored currently is excluded
+func (m FileMaintainer) rebuildSlice(ctx context.Context, slice blockchain.PublicSliceMeta, stripe erasure.Stripe,
+	slices []blockchain.PublicSliceMeta, healthNodesMap map[string]blockchain.NodeH, fileID string, keyVersion int) ([]byte, error) {
+
+	nodesMap := make(map[string]blockchain.Node, len(healthNodesMap))
+	for id, nh := range healthNodesMap {
+		nodesMap[id] = nh.Node
+	}
+	slicesPool := make(map[string][]blockchain.PublicSliceMeta)
+	for _, s := range slices {
+		slicesPool[s.ID] = append(slicesPool[s.ID], s)
+	}
+	excludes := map[string]struct{}{
+		string(slice.NodeID): {},
+	}
+
+	shards, err := common.RecoverStripe(ctx, m.copier, m.encryptor, fileID, keyVersion, stripe, slicesPool, nodesMap, excludes, l)
+	if err != nil {
+		return nil, err
+	}
+	for i, s := range stripe.Shards() {
+		if s.SliceID == slice.ID {
+			return shards[i], nil
+		}
+	}
+	return nil, errorx.New(errorx.ErrCodeNotFound, "slice %s not found in stripe %d", slice.ID, stripe.Index)
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/common"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/copier"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/encryptor"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/slicer"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/types"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/peer"
 )
@@ -40,6 +41,7 @@ var (
 )
 
 type Copier interface {
+	Select(slice slicer.Slice, nodes blockchain.NodeHs, opt *copier.SelectOptions) (copier.LocatedSlice, error)
 	Push(ctx context.Context, id, sourceID string, r io.Reader, node *blockchain.Node) (string, error)
 	Pull(ctx context.Context, id, storIndex, fileID string, node *blockchain.Node) (io.ReadCloser, error)
 	ReplicaExpansion(ctx context.Context, opt *copier.ReplicaExpOptions, enc common.CommonEncryptor,
@@ -58,10 +60,12 @@ type Blockchain interface {
 	ListFileNs(opt *blockchain.ListNsOptions) ([]blockchain.Namespace, error)
 	UpdateFilePublicSliceMeta(opt *blockchain.UpdateFilePSMOptions) error
 	SliceMigrateRecord(opt *blockchain.SliceMigrateOptions) error
+	ListSliceScrubReports(opt *blockchain.ListSliceScrubReportsOptions) ([]blockchain.SliceScrubReport, error)
 
 	ListNodes() (blockchain.Nodes, error)
 	GetNode(id []byte) (blockchain.Node, error)
 	GetNodeHealth(id []byte) (string, error)
+	ListNodeReputations() ([]blockchain.NodeReputation, error)
 	GetHeartbeatNum(id []byte, timestamp int64) (int, error)
 }
 
@@ -84,7 +88,7 @@ type NewFileMaintainerOptions struct {
 }
 
 // FileMaintainer runs if local node is dataOwner-node, and its main work is to check storage-nodes health conditions
-//  and migrate slices from bad nodes to healthy nodes.
+//  and migrate slices from bad nodes to healthy nodes, slices reported unhealthy by storage-nodes are migrated too.
 type FileMaintainer struct {
 	localNode  peer.Local
 	blockchain Blockchain
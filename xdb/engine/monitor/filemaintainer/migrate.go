@@ -26,6 +26,9 @@ import (
 	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/common"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/encryptor"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/encryptor/seal"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/erasure"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/slicer"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/types"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
 	util "github.com/PaddlePaddle/PaddleDTX/xdb/pkgs/strings"
@@ -92,6 +95,21 @@ func (m *FileMaintainer) migrate(ctx context.Context) {
 			}
 		}
 
+		// slices on draining nodes are moved proactively, and draining nodes are still the sources to pull slices
+		draining, err := m.getDrainingNodes()
+		if err != nil {
+			l.WithError(err).Warn("failed to find draining nodes")
+		}
+		for id, node := range draining {
+			healthNodesMap[id] = blockchain.NodeH{Node: node, Health: blockchain.NodeHealthMedium}
+		}
+
+		// find slices reported corrupted or missing by storage nodes
+		scrubbed, err := m.getScrubbedSlices(pubkey[:])
+		if err != nil {
+			l.WithError(err).Warn("failed to find slice scrub reports")
+		}
+
 		wg := sync.WaitGroup{}
 		wg.Add(len(nsList))
 		for _, ns := range nsList {
@@ -127,7 +145,8 @@ func (m *FileMaintainer) migrate(ctx context.Context) {
 							l.WithField("file_id", file.ID).WithError(err).Error("failed to get file health")
 							return
 						}
-						if health == blockchain.NodeHealthGood {
+						if health == blockchain.NodeHealthGood && len(scrubbed[file.ID]) == 0 &&
+							!hasSliceOnNodes(file, draining) {
 							return
 						}
 
@@ -155,6 +174,16 @@ func (m *FileMaintainer) migrate(ctx context.Context) {
 							}
 						}
 
+						// lost slices of erasure coded file are rebuilt from the other slices of the same stripe
+						var stripes []erasure.Stripe
+						if file.DataShards > 0 {
+							stripes, err = m.getFileStripes(file)
+							if err != nil {
+								l.WithField("file_id", file.ID).WithError(err).Error("failed to get file stripes")
+								return
+							}
+						}
+
 						// find storage nodeIDs for each slice
 						selectedNodes := make(map[string][]string)
 						for _, slice := range file.Slices {
@@ -174,9 +203,12 @@ func (m *FileMaintainer) migrate(ctx context.Context) {
 								l.WithField("slice_id", slice.ID).WithError(err).Error("failed to get slice node health")
 								continue
 							}
-							if nh == blockchain.NodeHealthBad {
+							// slices reported unhealthy are migrated like the slices on red nodes
+							_, isScrubbed := scrubbed[file.ID][scrubbedSliceKey(slice.ID, slice.NodeID)]
+							_, isDraining := draining[string(slice.NodeID)]
+							if nh == blockchain.NodeHealthBad || isScrubbed || isDraining {
 								newSlices, mSlice, selectedNodes, err = m.migrateSliceToNewNode(ctx, slice, nodeSliceMap, healthNodes,
-									healthNodesMap, selectedNodes, file.ID, newSlices, challengeAlgorithm, hex.EncodeToString(file.Owner))
+									healthNodesMap, selectedNodes, file, newSlices, stripes, challengeAlgorithm, hex.EncodeToString(file.Owner))
 								if err != nil {
 									l.WithFields(logrus.Fields{
 										"file_id":  file.ID,
@@ -187,7 +219,7 @@ func (m *FileMaintainer) migrate(ctx context.Context) {
 									migrateEncSlices = append(migrateEncSlices, mSlice)
 								}
 							}
-							if nh == blockchain.NodeHealthMedium {
+							if nh == blockchain.NodeHealthMedium && !isScrubbed && !isDraining {
 								yellowNodeSlices = append(yellowNodeSlices, slice)
 							}
 						}
@@ -199,7 +231,7 @@ func (m *FileMaintainer) migrate(ctx context.Context) {
 							for _, slice := range yellowNodeSlices {
 								nodeSliceMap := nodeSliceMap(newSlices, slice.ID)
 								newSlices, mSlice, selectedNodes, err = m.migrateSliceToNewNode(ctx, slice, nodeSliceMap, greenNodes,
-									healthNodesMap, selectedNodes, file.ID, newSlices, challengeAlgorithm, hex.EncodeToString(file.Owner))
+									healthNodesMap, selectedNodes, file, newSlices, stripes, challengeAlgorithm, hex.EncodeToString(file.Owner))
 								if err != nil {
 									l.WithFields(logrus.Fields{
 										"file_id":  file.ID,
@@ -238,7 +270,7 @@ func (m *FileMaintainer) migrate(ctx context.Context) {
 							}
 
 							// update file slices
-							if err := m.updateFileSlicesOnChain(file.ID, file.Owner, newSlices); err == nil {
+							if err := m.updateFileSlicesOnChain(file.ID, file.Owner, newSlices, file.GetSliceKeyVersion()); err == nil {
 								l.WithField("file_id", file.ID).Info("file migrate finished")
 							} else {
 								l.WithField("file_id", file.ID).WithError(err).Error("updateFileSlicesOnChain failed")
@@ -265,19 +297,22 @@ func (m *FileMaintainer) migrate(ctx context.Context) {
 // 3. record slice migrated info and update it to the blockchain
 func (m FileMaintainer) migrateSliceToNewNode(ctx context.Context, slice blockchain.PublicSliceMeta,
 	nodeSliceMap map[string]blockchain.PublicSliceMeta, healthNodes blockchain.NodeHs,
-	healthNodesMap map[string]blockchain.NodeH, selectedNodes map[string][]string, fileID string,
-	slices []blockchain.PublicSliceMeta, challengeAlgorithm, sourceID string) ([]blockchain.PublicSliceMeta,
-	encryptor.EncryptedSlice, map[string][]string, error) {
+	healthNodesMap map[string]blockchain.NodeH, selectedNodes map[string][]string, file blockchain.File,
+	slices []blockchain.PublicSliceMeta, stripes []erasure.Stripe, challengeAlgorithm, sourceID string) (
+	[]blockchain.PublicSliceMeta, encryptor.EncryptedSlice, map[string][]string, error) {
+	fileID, keyFileID, keyVersion := file.ID, common.SliceKeyFileID(file), file.GetSliceKeyVersion()
 
 	var newMigrateEnSlice encryptor.EncryptedSlice
-	// find new nodes to migrate slice
-	newNodes, err := common.FindNewNodes(healthNodes, selectedNodes[slice.ID])
-	if err != nil {
-		return slices, newMigrateEnSlice, selectedNodes, errorx.Wrap(err, "failed to find new nodes")
+	// slices of a stripe are stored on different nodes for erasure coded file
+	excluded := selectedNodes[slice.ID]
+	stripe, isErasureCoded := erasure.FindStripe(stripes, slice.ID)
+	if isErasureCoded {
+		excluded = append(stripeNodes(stripe, slice.ID, slices), excluded...)
 	}
 
 	// pull slice from other healthy nodes
 	var plaintext []byte
+	var err error
 	var pullErr error
 	pulled := false
 	for _, node := range selectedNodes[slice.ID] {
@@ -288,7 +323,7 @@ func (m FileMaintainer) migrateSliceToNewNode(ctx context.Context, slice blockch
 
 		// pull slice and decrypt
 		pulled = true
-		plaintext, err = common.PullAndDec(ctx, m.copier, m.encryptor, nodeSliceMap[node], &nodeH.Node, fileID)
+		plaintext, err = common.PullAndDec(ctx, m.copier, m.encryptor, nodeSliceMap[node], &nodeH.Node, fileID, keyFileID, keyVersion)
 		if err != nil {
 			pullErr = err
 			l.WithFields(logrus.Fields{
@@ -299,6 +334,14 @@ func (m FileMaintainer) migrateSliceToNewNode(ctx context.Context, slice blockch
 			break
 		}
 	}
+	// rebuild the slice from the other slices of its stripe for erasure coded file,
+	// instead of pulling a full copy which does not exist
+	if len(plaintext) == 0 && isErasureCoded {
+		plaintext, err = m.rebuildSlice(ctx, slice, stripe, slices, healthNodesMap, fileID, keyVersion)
+		if err != nil {
+			return slices, newMigrateEnSlice, selectedNodes, errorx.Wrap(err, "failed to rebuild slice")
+		}
+	}
 	if len(plaintext) == 0 {
 		if !pulled {
 			return slices, newMigrateEnSlice, selectedNodes, errorx.New(errorx.ErrCodeInternal, "no healthy nodes to recover slice")
@@ -306,6 +349,21 @@ func (m FileMaintainer) migrateSliceToNewNode(ctx context.Context, slice blockch
 		return slices, newMigrateEnSlice, selectedNodes, errorx.NewCode(pullErr, errorx.ErrCodeCrypto, "failed to recover slice")
 	}
 
+	// find new nodes to migrate slice, zones of the healthy nodes keeping the other copies are avoided
+	var holders blockchain.Nodes
+	for _, id := range excluded {
+		if nodeH, exist := healthNodesMap[id]; exist && id != string(slice.NodeID) {
+			holders = append(holders, nodeH.Node)
+		}
+	}
+	newNodes, err := common.SelectNewNodes(m.copier, healthNodes, slicer.Slice{
+		SliceMeta: slicer.SliceMeta{ID: slice.ID},
+		Data:      plaintext,
+	}, holders, excluded)
+	if err != nil {
+		return slices, newMigrateEnSlice, selectedNodes, errorx.Wrap(err, "failed to find new nodes")
+	}
+
 	success := false
 	for _, node := range newNodes {
 		l.WithFields(logrus.Fields{
@@ -315,7 +373,7 @@ func (m FileMaintainer) migrateSliceToNewNode(ctx context.Context, slice blockch
 		}).Debug("migrate slice")
 
 		// push to new node
-		if es, storIndex, err := common.EncAndPush(ctx, m.copier, m.encryptor, plaintext, slice.ID, sourceID, fileID, &node); err == nil {
+		if es, storIndex, err := common.EncAndPush(ctx, m.copier, m.encryptor, plaintext, slice.ID, sourceID, keyFileID, keyVersion, &node); err == nil {
 			l.WithFields(logrus.Fields{
 				"slice_id":    slice.ID,
 				"old_node":    string(slice.NodeID),
@@ -345,6 +403,7 @@ func (m FileMaintainer) migrateSliceToNewNode(ctx context.Context, slice blockch
 					Length:     es.EncryptedSliceMeta.Length,
 					NodeID:     es.EncryptedSliceMeta.NodeID,
 					StorIndex:  storIndex,
+					Sealed:     seal.IsSealed(es.CipherText),
 				}
 				slices = append(slices, newMigrateSlice)
 				slices = removeSlice(slices, slice)
@@ -364,6 +423,57 @@ func (m FileMaintainer) migrateSliceToNewNode(ctx context.Context, slice blockch
 	return slices, newMigrateEnSlice, selectedNodes, nil
 }
 
+// getScrubbedSlices gets slices reported unhealthy by storage nodes and still to be migrated, returns fileID->(sliceID+nodeID)
+// Reports are resolved on chain once the slices are migrated off the nodes, so only unresolved ones are listed
+func (m *FileMaintainer) getScrubbedSlices(owner []byte) (map[string]map[string]struct{}, error) {
+	reports, err := m.blockchain.ListSliceScrubReports(&blockchain.ListSliceScrubReportsOptions{
+		Owner:      owner,
+		Unresolved: true,
+		EndTime:    time.Now().UnixNano(),
+		Limit:      blockchain.ListMaxNumber,
+	})
+	if err != nil {
+		return nil, err
+	}
+	scrubbed := make(map[string]map[string]struct{})
+	for _, r := range reports {
+		if _, exist := scrubbed[r.FileID]; !exist {
+			scrubbed[r.FileID] = make(map[string]struct{})
+		}
+		scrubbed[r.FileID][scrubbedSliceKey(r.SliceID, r.NodeID)] = struct{}{}
+	}
+	return scrubbed, nil
+}
+
+// getDrainingNodes gets online draining nodes, returns nodeID->node
+func (m *FileMaintainer) getDrainingNodes() (map[string]blockchain.Node, error) {
+	nodes, err := m.blockchain.ListNodes()
+	if err != nil {
+		return nil, err
+	}
+	draining := make(map[string]blockchain.Node)
+	for _, n := range nodes {
+		if n.Online && n.Draining {
+			draining[string(n.ID)] = n
+		}
+	}
+	return draining, nil
+}
+
+// hasSliceOnNodes checks whether the file has slices stored on any of the nodes
+func hasSliceOnNodes(file blockchain.File, nodes map[string]blockchain.Node) bool {
+	for _, slice := range file.Slices {
+		if _, exist := nodes[string(slice.NodeID)]; exist {
+			return true
+		}
+	}
+	return false
+}
+
+func scrubbedSliceKey(sliceID string, nodeID []byte) string {
+	return sliceID + "/" + string(nodeID)
+}
+
 // nodeSliceMap map node->sliceMeta for specific sliceID
 func nodeSliceMap(sliceMetas []blockchain.PublicSliceMeta, sliceID string) map[string]blockchain.PublicSliceMeta {
 	ret := make(map[string]blockchain.PublicSliceMeta)
@@ -405,6 +515,7 @@ func (m FileMaintainer) rearrangeSlices(oldSlices []blockchain.PublicSliceMeta,
 		NodeID:     []byte(newNode),
 		SliceIdx:   newNodeLargestIdx + 1,
 		StorIndex:  storIndex,
+		Sealed:     seal.IsSealed(ciphertext),
 	}
 	newSlices = append(newSlices, newNodeSlice)
 	return newSlices, nil
@@ -446,12 +557,16 @@ func (m FileMaintainer) migrateRecordOnChain(nodeID, fileID, sliceID string) {
 	}
 }
 
-// updateFileSlicesOnChain update file slices structure on blockchain
-func (m FileMaintainer) updateFileSlicesOnChain(fileID string, owner []byte, slices []blockchain.PublicSliceMeta) error {
+// updateFileSlicesOnChain update file slices structure on blockchain,
+// keyVersion is the version of password slices are encrypted under
+func (m FileMaintainer) updateFileSlicesOnChain(fileID string, owner []byte, slices []blockchain.PublicSliceMeta,
+	keyVersion int) error {
 	opt := &blockchain.UpdateFilePSMOptions{
-		FileID: fileID,
-		Owner:  owner,
-		Slices: slices,
+		FileID:          fileID,
+		Owner:           owner,
+		Slices:          slices,
+		SliceKeyVersion: keyVersion,
+		CurrentTime:     time.Now().UnixNano(),
 	}
 	msg, err := util.GetSigMessage(opt)
 	if err != nil {
@@ -25,6 +25,7 @@ import (
 	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/common"
 	util "github.com/PaddlePaddle/PaddleDTX/xdb/pkgs/strings"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/storage"
 )
 
 // heartbeat sends heartbeats regularly in order to claim it's alive
@@ -46,12 +47,22 @@ func (m *NodeMaintainer) heartbeat(ctx context.Context) {
 			return
 		case <-ticker.C:
 		}
+		// capacity is not advertised if usage of the storage is unknown,
+		// so that free space of the node is taken as unknown rather than the whole capacity
+		capacity := m.localNode.Capacity
+		used, known := m.usage()
+		if !known {
+			capacity = 0
+		}
 		// invoke contract
 		timestamp := time.Now().UnixNano()
 		opt := &blockchain.NodeHeartBeatOptions{
 			NodeID:        []byte(pubkey.String()),
 			CurrentTime:   timestamp,
 			BeginningTime: common.TodayBeginning(timestamp),
+			Capacity:      capacity,
+			Used:          used,
+			Zone:          m.localNode.Zone,
 		}
 		msg, err := util.GetSigMessage(opt)
 		if err != nil {
@@ -77,3 +88,24 @@ func (m *NodeMaintainer) heartbeat(ctx context.Context) {
 	}
 
 }
+
+// usage returns bytes occupied by slices stored on the node, and false if the storage can't report its usage.
+// It's refreshed every usageRefreshInterval at most because walking the storage may be expensive
+func (m *NodeMaintainer) usage() (uint64, bool) {
+	if time.Since(m.usageUpdateAt) < usageRefreshInterval {
+		return m.used, m.usageKnown
+	}
+	m.usageUpdateAt = time.Now()
+
+	used, err := m.sliceStorage.Usage()
+	if err == storage.ErrUsageNotSupported {
+		m.used, m.usageKnown = 0, false
+		return m.used, m.usageKnown
+	}
+	if err != nil {
+		logger.WithError(err).Warn("failed to get usage of slice storage")
+		return m.used, m.usageKnown
+	}
+	m.used, m.usageKnown = used, true
+	return m.used, m.usageKnown
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodemaintainer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/storage"
+)
+
+// noUsageStorage can't report its usage, like s3 and ipfs
+type noUsageStorage struct {
+	memSliceStorage
+}
+
+func (s *noUsageStorage) Usage() (uint64, error) {
+	return 0, storage.ErrUsageNotSupported
+}
+
+func TestUsage(t *testing.T) {
+	m := &NodeMaintainer{sliceStorage: &memSliceStorage{slices: map[string][]byte{"s": []byte("slice")}}}
+	used, known := m.usage()
+	require.True(t, known)
+	require.Equal(t, uint64(5), used)
+
+	// usage is cached until usageRefreshInterval passes
+	m.sliceStorage = &noUsageStorage{}
+	used, known = m.usage()
+	require.True(t, known)
+	require.Equal(t, uint64(5), used)
+
+	m.usageUpdateAt = time.Now().Add(-usageRefreshInterval)
+	used, known = m.usage()
+	require.False(t, known)
+	require.Zero(t, used)
+}
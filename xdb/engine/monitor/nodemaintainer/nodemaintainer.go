@@ -27,6 +27,9 @@ import (
 
 const (
 	defaultFileClearInterval = time.Hour * 24
+	defaultScrubInterval     = time.Hour * 24
+
+	usageRefreshInterval = time.Minute * 10
 )
 
 var (
@@ -39,12 +42,18 @@ type Blockchain interface {
 	NodeOnline(opt *blockchain.NodeOperateOptions) error
 	Heartbeat(opt *blockchain.NodeHeartBeatOptions) error
 	ListNodesExpireSlice(opt *blockchain.ListNodeSliceOptions) ([][2]string, error)
+	ListNodesDeletedSlice(opt *blockchain.ListNodeSliceOptions) ([][2]string, error)
+	GetSliceRef(opt *blockchain.GetSliceRefOptions) (blockchain.SliceRef, error)
+	GetFileByID(id string) (blockchain.File, error)
+	ListNodeSliceFiles(opt *blockchain.ListNodeSliceOptions) ([]string, error)
+	ReportSliceScrub(opt *blockchain.ReportSliceScrubOptions) error
 }
 type SliceStorage interface {
 	Load(key string, index string) (io.ReadCloser, error)
 	Exist(key string, index string) (bool, error)
 	Delete(key string, index string) error
 	LoadStr(key string, index string) (string, error)
+	Usage() (uint64, error)
 }
 
 // ProveStorage is Storage interface used in `Replication Holding Proof` process
@@ -66,8 +75,8 @@ type NewNodeMaintainerOptions struct {
 	ProveStorage ProveStorage
 }
 
-// NodeMaintainer runs if local node is storage-node, and its main work is to clean expired encrypted slices
-//   and to send heartbeats regularly in order to claim it's alive
+// NodeMaintainer runs if local node is storage-node, and its main work is to clean expired encrypted slices,
+//   to send heartbeats regularly in order to claim it's alive, and to scrub slices to find corrupted ones
 type NodeMaintainer struct {
 	localNode peer.Local
 
@@ -79,9 +88,15 @@ type NodeMaintainer struct {
 	heartbeatInterval  time.Duration
 	fileClearInterval  time.Duration
 	fileRetainInterval time.Duration
+	scrubInterval      time.Duration
+
+	used          uint64    // bytes occupied by slices, advertised in heartbeats
+	usageKnown    bool      // whether the storage reports its usage
+	usageUpdateAt time.Time // the last time used is refreshed
 
 	doneHbC         chan struct{} //doneHbC will be closed when loop breaks
 	doneSliceClearC chan struct{} //doneSliceClearC will be closed when loop breaks
+	doneScrubC      chan struct{} //doneScrubC will be closed when loop breaks
 }
 
 func New(conf *config.MonitorConf, opt *NewNodeMaintainerOptions) (*NodeMaintainer, error) {
@@ -90,11 +105,16 @@ func New(conf *config.MonitorConf, opt *NewNodeMaintainerOptions) (*NodeMaintain
 	if fileClearInterval == 0 {
 		fileClearInterval = defaultFileClearInterval
 	}
+	scrubInterval := time.Duration(int64(conf.ScrubInterval)) * time.Hour
+	if scrubInterval == 0 {
+		scrubInterval = defaultScrubInterval
+	}
 
 	logger.WithFields(logrus.Fields{
 		"heartbeat-interval":  heartbeatInterval,
 		"fileclear-interval":  fileClearInterval,
 		"fileretain-interval": blockchain.FileRetainPeriod,
+		"scrub-interval":      scrubInterval,
 	}).Info("monitor initialize...")
 
 	mm := &NodeMaintainer{
@@ -105,6 +125,7 @@ func New(conf *config.MonitorConf, opt *NewNodeMaintainerOptions) (*NodeMaintain
 		heartbeatInterval:  heartbeatInterval,
 		fileClearInterval:  fileClearInterval,
 		fileRetainInterval: blockchain.FileRetainPeriod,
+		scrubInterval:      scrubInterval,
 	}
 
 	return mm, nil
@@ -158,3 +179,25 @@ func (m *NodeMaintainer) StopFileClear() {
 
 	<-m.doneSliceClearC
 }
+
+// StartScrub starts task to scrub slices
+func (m *NodeMaintainer) StartScrub(ctx context.Context) {
+	go m.scrub(ctx)
+}
+
+// StopScrub stops task scrubbing slices
+func (m *NodeMaintainer) StopScrub() {
+	if m.doneScrubC == nil {
+		return
+	}
+
+	logger.Info("stops task scrubbing slices ...")
+
+	select {
+	case <-m.doneScrubC:
+		return
+	default:
+	}
+
+	<-m.doneScrubC
+}
@@ -0,0 +1,185 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodemaintainer
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"math"
+	"time"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/ecdsa"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/hash"
+	"github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+	util "github.com/PaddlePaddle/PaddleDTX/xdb/pkgs/strings"
+)
+
+// scrub walks slices stored on the node regularly, recomputes their ciphertext hashes and compares them with
+// cipherHash on chain. Corrupted or missing slices are reported on chain, so that file owners can migrate them
+// before the next challenge round
+func (m *NodeMaintainer) scrub(ctx context.Context) {
+	pubkey := ecdsa.PublicKeyFromPrivateKey(m.localNode.PrivateKey)
+
+	l := logger.WithField("runner", "slice scrub loop")
+	defer l.Info("slice scrub stopped")
+
+	ticker := time.NewTicker(m.scrubInterval)
+	defer ticker.Stop()
+
+	m.doneScrubC = make(chan struct{})
+	defer close(m.doneScrubC)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		checked, reports, err := m.scrubSlices(ctx, []byte(pubkey.String()))
+		if err != nil {
+			l.WithError(err).Warn("failed to scrub slices")
+			continue
+		}
+		l.WithFields(logrus.Fields{
+			"checked":  checked,
+			"reported": len(reports),
+		}).Info("slice scrub finished")
+	}
+}
+
+// scrubSlices checks all slices of unexpired files stored on the node, and reports the unhealthy ones,
+// returns the number of slices checked and the reports newly put on chain
+func (m *NodeMaintainer) scrubSlices(ctx context.Context, nodeID []byte) (int, []blockchain.SliceScrubReport, error) {
+	fileIDs, err := m.blockchain.ListNodeSliceFiles(&blockchain.ListNodeSliceOptions{
+		Target:    nodeID,
+		StartTime: time.Now().UnixNano(),
+		EndTime:   math.MaxInt64,
+	})
+	if err != nil {
+		return 0, nil, errorx.Wrap(err, "failed to list files of node slices")
+	}
+
+	var reports []blockchain.SliceScrubReport
+	// deduplicated slices may be shared by files, so that each slice is checked only once
+	checked := make(map[string]struct{})
+	for _, fileID := range fileIDs {
+		select {
+		case <-ctx.Done():
+			return len(checked), reports, nil
+		default:
+		}
+
+		file, err := m.blockchain.GetFileByID(fileID)
+		if err != nil {
+			logger.WithField("file_id", fileID).WithError(err).Warn("failed to get file to scrub")
+			continue
+		}
+		for _, slice := range file.Slices {
+			if !bytes.Equal(slice.NodeID, nodeID) {
+				continue
+			}
+			if _, exist := checked[slice.ID]; exist {
+				continue
+			}
+			checked[slice.ID] = struct{}{}
+
+			status, err := m.checkSlice(slice)
+			if err != nil {
+				logger.WithField("slice_id", slice.ID).WithError(err).Warn("failed to check slice")
+				continue
+			}
+			if status == "" {
+				continue
+			}
+
+			report, err := m.reportSlice(nodeID, fileID, slice.ID, status)
+			if err != nil {
+				if !errorx.Is(err, errorx.ErrCodeAlreadyExists) {
+					logger.WithField("slice_id", slice.ID).WithError(err).Warn("failed to report slice")
+				}
+				continue
+			}
+			logger.WithFields(logrus.Fields{
+				"file_id":  fileID,
+				"slice_id": slice.ID,
+				"status":   status,
+			}).Warn("unhealthy slice reported")
+			reports = append(reports, report)
+		}
+	}
+	return len(checked), reports, nil
+}
+
+// checkSlice loads a slice from storage and verifies its ciphertext,
+// returns the status of an unhealthy slice, or empty string if the slice is healthy
+func (m *NodeMaintainer) checkSlice(slice blockchain.PublicSliceMeta) (string, error) {
+	exist, err := m.sliceStorage.Exist(slice.ID, slice.StorIndex)
+	if err != nil {
+		return "", errorx.Wrap(err, "failed to check existence of slice")
+	}
+	if !exist {
+		return blockchain.SliceScrubMissing, nil
+	}
+
+	r, err := m.sliceStorage.Load(slice.ID, slice.StorIndex)
+	if err != nil {
+		return "", errorx.Wrap(err, "failed to load slice")
+	}
+	defer r.Close()
+	cipherText, err := ioutil.ReadAll(r)
+	if err != nil {
+		return "", errorx.NewCode(err, errorx.ErrCodeInternal, "failed to read slice")
+	}
+	if uint64(len(cipherText)) != slice.Length || !bytes.Equal(hash.HashUsingSha256(cipherText), slice.CipherHash) {
+		return blockchain.SliceScrubCorrupted, nil
+	}
+	return "", nil
+}
+
+// reportSlice puts the scrub report of an unhealthy slice on chain
+func (m *NodeMaintainer) reportSlice(nodeID []byte, fileID, sliceID, status string) (
+	blockchain.SliceScrubReport, error) {
+	opt := &blockchain.ReportSliceScrubOptions{
+		NodeID:      nodeID,
+		FileID:      fileID,
+		SliceID:     sliceID,
+		Status:      status,
+		CurrentTime: time.Now().UnixNano(),
+	}
+	msg, err := util.GetSigMessage(opt)
+	if err != nil {
+		return blockchain.SliceScrubReport{}, errorx.Internal(err, "failed to get the message to sign")
+	}
+	sig, err := ecdsa.Sign(m.localNode.PrivateKey, hash.HashUsingSha256([]byte(msg)))
+	if err != nil {
+		return blockchain.SliceScrubReport{}, errorx.Wrap(err, "failed to sign scrub report")
+	}
+	opt.Signature = sig[:]
+
+	if err := m.blockchain.ReportSliceScrub(opt); err != nil {
+		return blockchain.SliceScrubReport{}, err
+	}
+	return blockchain.SliceScrubReport{
+		NodeID:    nodeID,
+		FileID:    fileID,
+		SliceID:   sliceID,
+		Status:    status,
+		ScrubTime: opt.CurrentTime,
+	}, nil
+}
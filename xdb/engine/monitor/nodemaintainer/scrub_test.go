@@ -0,0 +1,251 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodemaintainer
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/ecdsa"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/hash"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain/local"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/config"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/peer"
+	util "github.com/PaddlePaddle/PaddleDTX/xdb/pkgs/strings"
+)
+
+// memSliceStorage keeps slices in memory
+type memSliceStorage struct {
+	slices map[string][]byte
+}
+
+func (s *memSliceStorage) Load(key string, index string) (io.ReadCloser, error) {
+	v, ok := s.slices[key]
+	if !ok {
+		return nil, errorx.New(errorx.ErrCodeNotFound, "slice not found")
+	}
+	return ioutil.NopCloser(bytes.NewReader(v)), nil
+}
+
+func (s *memSliceStorage) Exist(key string, index string) (bool, error) {
+	_, ok := s.slices[key]
+	return ok, nil
+}
+
+func (s *memSliceStorage) Delete(key string, index string) error {
+	delete(s.slices, key)
+	return nil
+}
+
+func (s *memSliceStorage) LoadStr(key string, index string) (string, error) {
+	return string(s.slices[key]), nil
+}
+
+func (s *memSliceStorage) Usage() (uint64, error) {
+	var used uint64
+	for _, v := range s.slices {
+		used += uint64(len(v))
+	}
+	return used, nil
+}
+
+type testScrub struct {
+	*NodeMaintainer
+	chain   *local.Local
+	storage *memSliceStorage
+	nodeID  []byte
+	ownerSk ecdsa.PrivateKey
+	ownerPk ecdsa.PublicKey
+}
+
+func newTestScrub(t *testing.T) *testScrub {
+	chain, err := local.New(&config.LocalChainConf{Path: filepath.Join(t.TempDir(), "chain")})
+	require.NoError(t, err)
+	nodeSk, nodePk, err := ecdsa.GenerateKeyPair()
+	require.NoError(t, err)
+	ownerSk, ownerPk, err := ecdsa.GenerateKeyPair()
+	require.NoError(t, err)
+
+	storage := &memSliceStorage{slices: make(map[string][]byte)}
+	return &testScrub{
+		NodeMaintainer: &NodeMaintainer{
+			localNode:    peer.Local{ID: []byte(nodePk.String()), PrivateKey: nodeSk},
+			blockchain:   chain,
+			sliceStorage: storage,
+		},
+		chain:   chain,
+		storage: storage,
+		nodeID:  []byte(nodePk.String()),
+		ownerSk: ownerSk,
+		ownerPk: ownerPk,
+	}
+}
+
+func (ts *testScrub) sign(t *testing.T, opt interface{}) []byte {
+	msg, err := util.GetSigMessage(opt)
+	require.NoError(t, err)
+	sig, err := ecdsa.Sign(ts.ownerSk, hash.HashUsingSha256([]byte(msg)))
+	require.NoError(t, err)
+	return sig[:]
+}
+
+// publish publishes a file whose slices are stored on the nodes, and saves the slices stored on the local node
+func (ts *testScrub) publish(t *testing.T, nodes ...[]byte) blockchain.File {
+	now := time.Now().UnixNano()
+	nsOpt := blockchain.AddNsOptions{
+		Namespace: blockchain.Namespace{
+			Name:       "ns",
+			Owner:      ts.ownerPk[:],
+			Replica:    1,
+			CreateTime: now,
+			UpdateTime: now,
+		},
+	}
+	nsOpt.Signature = ts.sign(t, nsOpt)
+	require.NoError(t, ts.chain.AddFileNs(&nsOpt))
+
+	f := blockchain.File{
+		ID:          uuid.NewString(),
+		Name:        "file",
+		Namespace:   "ns",
+		Owner:       ts.ownerPk[:],
+		PublishTime: now,
+		ExpireTime:  now + time.Hour.Nanoseconds(),
+		Version:     1,
+	}
+	for i, node := range nodes {
+		cipherText := []byte(uuid.NewString())
+		slice := blockchain.PublicSliceMeta{
+			ID:         uuid.NewString(),
+			CipherHash: hash.HashUsingSha256(cipherText),
+			Length:     uint64(len(cipherText)),
+			NodeID:     node,
+			SliceIdx:   i,
+		}
+		if bytes.Equal(node, ts.nodeID) {
+			ts.storage.slices[slice.ID] = cipherText
+		}
+		f.Slices = append(f.Slices, slice)
+	}
+	opt := blockchain.PublishFileOptions{File: f}
+	opt.Signature = ts.sign(t, opt)
+	require.NoError(t, ts.chain.PublishFile(&opt))
+	return f
+}
+
+func (ts *testScrub) updateSlices(t *testing.T, f blockchain.File) {
+	opt := blockchain.UpdateFilePSMOptions{
+		FileID:      f.ID,
+		Owner:       f.Owner,
+		Slices:      f.Slices,
+		CurrentTime: time.Now().UnixNano(),
+	}
+	opt.Signature = ts.sign(t, opt)
+	require.NoError(t, ts.chain.UpdateFilePublicSliceMeta(&opt))
+}
+
+func (ts *testScrub) reports(t *testing.T, unresolved bool) map[string]blockchain.SliceScrubReport {
+	rs, err := ts.chain.ListSliceScrubReports(&blockchain.ListSliceScrubReportsOptions{
+		Owner:      ts.ownerPk[:],
+		Unresolved: unresolved,
+		EndTime:    math.MaxInt64,
+	})
+	require.NoError(t, err)
+	m := make(map[string]blockchain.SliceScrubReport)
+	for _, r := range rs {
+		m[r.SliceID] = r
+	}
+	return m
+}
+
+func (ts *testScrub) nodeFiles(t *testing.T, nodeID []byte) []string {
+	ids, err := ts.chain.ListNodeSliceFiles(&blockchain.ListNodeSliceOptions{
+		Target:    nodeID,
+		StartTime: time.Now().UnixNano(),
+		EndTime:   math.MaxInt64,
+	})
+	require.NoError(t, err)
+	return ids
+}
+
+func TestScrubSlices(t *testing.T) {
+	ts := newTestScrub(t)
+	_, otherPk, err := ecdsa.GenerateKeyPair()
+	require.NoError(t, err)
+	other := []byte(otherPk.String())
+	f := ts.publish(t, ts.nodeID, ts.nodeID, ts.nodeID, other)
+	healthy, corrupted, missing := f.Slices[0], f.Slices[1], f.Slices[2]
+	ts.storage.slices[corrupted.ID] = []byte("corrupted")
+	delete(ts.storage.slices, missing.ID)
+
+	// only the unhealthy slices stored on the node are reported
+	checked, reports, err := ts.scrubSlices(context.Background(), ts.nodeID)
+	require.NoError(t, err)
+	require.Equal(t, 3, checked)
+	require.Len(t, reports, 2)
+	rs := ts.reports(t, true)
+	require.Len(t, rs, 2)
+	require.Equal(t, blockchain.SliceScrubCorrupted, rs[corrupted.ID].Status)
+	require.Equal(t, blockchain.SliceScrubMissing, rs[missing.ID].Status)
+	require.NotContains(t, rs, healthy.ID)
+
+	// unresolved slices are not reported twice
+	_, reports, err = ts.scrubSlices(context.Background(), ts.nodeID)
+	require.NoError(t, err)
+	require.Empty(t, reports)
+	_, err = ts.reportSlice(ts.nodeID, f.ID, corrupted.ID, blockchain.SliceScrubCorrupted)
+	require.True(t, errorx.Is(err, errorx.ErrCodeAlreadyExists))
+
+	// migrating the missing slice resolves its report, and the node indexes follow the slice
+	f.Slices[2].NodeID = other
+	ts.updateSlices(t, f)
+	rs = ts.reports(t, true)
+	require.Len(t, rs, 1)
+	require.Contains(t, rs, corrupted.ID)
+	all := ts.reports(t, false)
+	require.NotZero(t, all[missing.ID].ResolveTime)
+	require.Zero(t, all[corrupted.ID].ResolveTime)
+	require.Equal(t, []string{f.ID}, ts.nodeFiles(t, other))
+
+	// the slice migrated back to the node is scrubbed and reported again
+	f.Slices[2].NodeID = ts.nodeID
+	ts.updateSlices(t, f)
+	_, reports, err = ts.scrubSlices(context.Background(), ts.nodeID)
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	require.Equal(t, missing.ID, reports[0].SliceID)
+
+	// slices all moved off the node are no longer scrubbed by it
+	for i := range f.Slices {
+		f.Slices[i].NodeID = other
+	}
+	ts.updateSlices(t, f)
+	require.Empty(t, ts.nodeFiles(t, ts.nodeID))
+	require.Empty(t, ts.reports(t, true))
+	checked, _, err = ts.scrubSlices(context.Background(), ts.nodeID)
+	require.NoError(t, err)
+	require.Zero(t, checked)
+}
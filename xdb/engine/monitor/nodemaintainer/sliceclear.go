@@ -25,13 +25,16 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/common"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/storage"
 )
 
-// sliceClear cleans expired encrypted slices
+// sliceClear cleans expired encrypted slices, and slices of the files deleted by their owners
 func (m *NodeMaintainer) sliceClear(ctx context.Context) {
 	pubkey := ecdsa.PublicKeyFromPrivateKey(m.localNode.PrivateKey)
 	clearKey := m.getClearKey(pubkey)
+	deletedClearKey := m.getDeletedClearKey(pubkey)
 
 	l := logger.WithField("runner", "slice clear loop")
 	node, err := m.blockchain.GetNode([]byte(pubkey.String()))
@@ -54,6 +57,10 @@ func (m *NodeMaintainer) sliceClear(ctx context.Context) {
 		case <-ticker.C:
 		}
 		latestTime := time.Now().UnixNano()
+
+		// slices of deleted files are cleared right away, without waiting for the retain period
+		m.clearDeletedSlices(l, pubkey, deletedClearKey, node.RegTime, latestTime)
+
 		if node.RegTime > (latestTime - m.fileRetainInterval.Nanoseconds()) {
 			l.Info("node register time is later than slice retain time, no slice to clear")
 			continue
@@ -80,39 +87,9 @@ func (m *NodeMaintainer) sliceClear(ctx context.Context) {
 			l.WithError(err).Warn("failed to get expire slice")
 			continue
 		}
-		var deleteSlices []string
-		var deleteContentErr, deleteSigmasErr error
-		var deleteContent, deleteSigmas bool
-		for _, slice := range sliceList {
-			sliceID := slice[0]
-			sliceStorIndex := slice[1]
-			// if slice exists, remove it
-			sliceSigmas := sliceID
-			if exist, _ := m.sliceStorage.Exist(sliceID, sliceStorIndex); exist {
-				deleteContentErr = m.sliceStorage.Delete(sliceID, sliceStorIndex)
-				if deleteContentErr == nil {
-					deleteContent = true
-				} else {
-					deleteContent = false
-				}
-			}
-			// delete pairing based challenge material if exists
-			if exist, _ := m.proveStorage.Exist(sliceSigmas); exist {
-				deleteSigmas, deleteSigmasErr = m.proveStorage.Delete(sliceSigmas)
-			} else {
-				deleteSigmas = true
-			}
-			if !deleteContent || !deleteSigmas {
-				break
-			}
-			deleteSlices = append(deleteSlices, sliceID)
-		}
-		if deleteContentErr != nil {
-			l.WithError(deleteContentErr).Warn("failed to delete node slice")
-			continue
-		}
-		if deleteSigmasErr != nil {
-			l.WithError(deleteSigmasErr).Warn("failed to delete node slice sigmas")
+		deleteSlices, err := m.removeSlices(sliceList)
+		if err != nil {
+			l.WithError(err).Warn("failed to delete node slice")
 			continue
 		}
 
@@ -130,6 +107,106 @@ func (m *NodeMaintainer) sliceClear(ctx context.Context) {
 	}
 }
 
+// clearDeletedSlices cleans slices of the files deleted between the last clear time and latestTime,
+// slices already removed in the overlapped range are skipped
+func (m *NodeMaintainer) clearDeletedSlices(l *logrus.Entry, pubkey ecdsa.PublicKey, deletedClearKey string,
+	regTime, latestTime int64) {
+
+	startTime := regTime
+	if exist, _ := m.proveStorage.Exist(deletedClearKey); exist {
+		ftime, err := m.proveStorage.LoadStr(deletedClearKey)
+		if err != nil {
+			l.WithError(err).Warn("failed to load deleted slice clear time")
+			return
+		}
+		if startTime, err = strconv.ParseInt(ftime, 10, 64); err != nil {
+			l.WithError(err).Warn("failed to parse deleted slice clear time")
+			return
+		}
+	}
+	// deletions are indexed by the time of delete requests, which may be earlier than the time
+	// they are committed, so overlap the last scan to catch deletions committed after it.
+	// Deletions still indexed behind the scanned range are cleared with expired slices
+	if startTime -= blockchain.DeleteTimeSkew.Nanoseconds(); startTime < regTime {
+		startTime = regTime
+	}
+	if startTime >= latestTime {
+		return
+	}
+
+	opt := &blockchain.ListNodeSliceOptions{
+		Target:    []byte(pubkey.String()),
+		StartTime: startTime,
+		EndTime:   latestTime,
+	}
+	sliceList, err := m.blockchain.ListNodesDeletedSlice(opt)
+	if err != nil {
+		l.WithError(err).Warn("failed to get deleted slice")
+		return
+	}
+	deleteSlices, err := m.removeSlices(sliceList)
+	if err != nil {
+		l.WithError(err).Warn("failed to delete slice of deleted file")
+		return
+	}
+
+	r := bytes.NewBufferString(strconv.FormatInt(latestTime, 10))
+	if err := m.proveStorage.SaveAndUpdate(deletedClearKey, r); err != nil {
+		l.WithError(err).Warn("failed to update deleted slice clear time")
+		return
+	}
+	if len(deleteSlices) > 0 {
+		l.WithFields(logrus.Fields{
+			"start_time":     time.Unix(0, startTime).Format("2006-01-02 15:04:05"),
+			"end_time":       time.Unix(0, latestTime).Format("2006-01-02 15:04:05"),
+			"dslice_id_list": strings.Join(deleteSlices, ","),
+		}).Info("successfully cleared slice of deleted files")
+	}
+}
+
+// removeSlices removes slices and their pairing based challenge materials from local storage,
+// sliceList is a list of sliceID and storIndex pairs, returns IDs of removed slices.
+// Deduplicated slices still referenced by other files are kept
+func (m *NodeMaintainer) removeSlices(sliceList [][2]string) ([]string, error) {
+	var deleteSlices []string
+	for _, slice := range sliceList {
+		sliceID := slice[0]
+		sliceStorIndex := slice[1]
+		ref, err := m.blockchain.GetSliceRef(&blockchain.GetSliceRefOptions{
+			SliceID:     sliceID,
+			CurrentTime: time.Now().UnixNano(),
+		})
+		if err != nil && !errorx.Is(err, errorx.ErrCodeNotFound) {
+			return deleteSlices, errorx.Wrap(err, "failed to get slice reference")
+		}
+		if err == nil && ref.RefCount > 0 {
+			continue
+		}
+		// if slice exists, remove it
+		if exist, _ := m.sliceStorage.Exist(sliceID, sliceStorIndex); exist {
+			if err := m.sliceStorage.Delete(sliceID, sliceStorIndex); err != nil {
+				return deleteSlices, err
+			}
+		}
+		// delete pairing based challenge material if exists
+		key := storage.KeyOf(sliceID, sliceStorIndex)
+		if exist, _ := m.proveStorage.Exist(key); exist {
+			if _, err := m.proveStorage.Delete(key); err != nil {
+				return deleteSlices, errorx.Wrap(err, "failed to delete node slice sigmas")
+			}
+		}
+		// delete the record of who pushed the slice if exists
+		sourceKey := common.GetSliceSourceKey(key)
+		if exist, _ := m.proveStorage.Exist(sourceKey); exist {
+			if _, err := m.proveStorage.Delete(sourceKey); err != nil {
+				return deleteSlices, errorx.Wrap(err, "failed to delete node slice source")
+			}
+		}
+		deleteSlices = append(deleteSlices, sliceID)
+	}
+	return deleteSlices, nil
+}
+
 // getExpireRangeTime used to query expired slices in the startTime-endTime
 // clearKey stores the time of the last query
 func (m *NodeMaintainer) getExpireRangeTime(clearKey string, latestTime, regTime int64) (int64, int64, error) {
@@ -160,6 +237,11 @@ func (m *NodeMaintainer) getClearKey(pubkey ecdsa.PublicKey) string {
 	return suid
 }
 
+// getDeletedClearKey returns the key which stores the time of the last query of deleted slices
+func (m *NodeMaintainer) getDeletedClearKey(pubkey ecdsa.PublicKey) string {
+	return m.getClearKey(pubkey) + "-deleted"
+}
+
 // getEndExpireTime gets the endTime of past the file retention period
 func (m *NodeMaintainer) getEndExpireTime(startTime, latestTime int64) (endTime int64) {
 	interH := m.fileRetainInterval.Nanoseconds()
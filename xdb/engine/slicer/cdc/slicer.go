@@ -0,0 +1,221 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdc
+
+import (
+	"context"
+	"io"
+	"math/bits"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/hash"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/config"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/slicer"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+)
+
+const (
+	defaultAvgSize   = 1024 * 1024 // 1MB
+	defaultQueueSize = 4
+
+	// gearSeed generates the gear table, changing it changes all cut points
+	gearSeed = 0x5851f42d4c957f2d
+)
+
+var (
+	logger = logrus.WithField("module", "cdc-slicer")
+
+	gear = newGearTable(gearSeed)
+)
+
+// CDCSlicer cuts a file by content using FastCDC, so that an insertion or deletion
+// only changes slices around it, and unchanged parts of a file produce the same slices
+type CDCSlicer struct {
+	minSize   int
+	avgSize   int
+	maxSize   int
+	queueSize uint32
+
+	maskS uint64 // mask used before avgSize, harder to match
+	maskL uint64 // mask used after avgSize, easier to match
+}
+
+// New create a CDCSlicer instance by configuration,
+// MinSize and MaxSize default to a quarter and four times of AvgSize
+func New(conf *config.CDCSlicerConf) (*CDCSlicer, error) {
+	avgSize := int(conf.AvgSize)
+	if avgSize == 0 {
+		avgSize = defaultAvgSize
+	}
+	minSize := int(conf.MinSize)
+	if minSize == 0 {
+		minSize = avgSize / 4
+	}
+	maxSize := int(conf.MaxSize)
+	if maxSize == 0 {
+		maxSize = avgSize * 4
+	}
+	if minSize <= 0 || minSize > avgSize || avgSize > maxSize {
+		return nil, errorx.New(errorx.ErrCodeConfig,
+			"invalid cdc slicer sizes, require 0 < minSize <= avgSize <= maxSize")
+	}
+	queueSize := uint32(conf.QueueSize)
+	if queueSize == 0 {
+		queueSize = defaultQueueSize
+	}
+	logger.WithFields(logrus.Fields{
+		"minSize":   minSize,
+		"avgSize":   avgSize,
+		"maxSize":   maxSize,
+		"queueSize": queueSize,
+	}).Info("slicer initialization")
+
+	// normalized chunking, one more bit before avgSize and one less bit after it
+	n := bits.Len(uint(avgSize)) - 1
+	return &CDCSlicer{
+		minSize:   minSize,
+		avgSize:   avgSize,
+		maxSize:   maxSize,
+		queueSize: queueSize,
+		maskS:     topBits(n + 1),
+		maskL:     topBits(n - 1),
+	}, nil
+}
+
+// Slice reads from IO and cut the data into slices at content defined boundaries, and digests every slice by sha256
+func (cs *CDCSlicer) Slice(ctx context.Context, r io.Reader, opt *slicer.SliceOptions,
+	onErr func(err error)) chan slicer.Slice {
+	resCh := make(chan slicer.Slice, cs.queueSize)
+
+	go func() {
+		defer close(resCh)
+
+		buf := make([]byte, cs.maxSize)
+		var n int
+		eof := false
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+
+			// fill the buffer up to maxSize
+			if !eof && n < cs.maxSize {
+				m, err := io.ReadFull(r, buf[n:])
+				n += m
+				switch err {
+				case nil:
+				case io.ErrUnexpectedEOF, io.EOF:
+					eof = true
+				default:
+					onErr(errorx.NewCode(err, errorx.ErrCodeInternal, "failed to read file during Slice"))
+					return
+				}
+			}
+			if n == 0 {
+				return
+			}
+
+			cut := cs.cutPoint(buf[:n])
+			data := make([]byte, cut)
+			copy(data, buf[:cut])
+			resCh <- makeSlice(data)
+
+			n = copy(buf, buf[cut:n])
+		}
+	}()
+
+	return resCh
+}
+
+// GetBlockSize returns the max size of slice
+func (cs *CDCSlicer) GetBlockSize() int {
+	return cs.maxSize
+}
+
+// ContentDefined marks the slicer cuts data by content
+func (cs *CDCSlicer) ContentDefined() bool {
+	return true
+}
+
+// cutPoint returns length of the first slice in data using gear rolling hash
+func (cs *CDCSlicer) cutPoint(data []byte) int {
+	n := len(data)
+	if n <= cs.minSize {
+		return n
+	}
+	if n > cs.maxSize {
+		n = cs.maxSize
+	}
+	normal := cs.avgSize
+	if n < normal {
+		normal = n
+	}
+
+	var fp uint64
+	i := cs.minSize
+	for ; i < normal; i++ {
+		fp = (fp << 1) + gear[data[i]]
+		if fp&cs.maskS == 0 {
+			return i + 1
+		}
+	}
+	for ; i < n; i++ {
+		fp = (fp << 1) + gear[data[i]]
+		if fp&cs.maskL == 0 {
+			return i + 1
+		}
+	}
+	return n
+}
+
+// topBits returns a mask with the highest n bits set,
+// the high bits of gear hash are affected by the latest 64 bytes
+func topBits(n int) uint64 {
+	if n <= 0 {
+		return 0
+	}
+	return ^uint64(0) << uint(64-n)
+}
+
+// newGearTable generates random values of gear hash by splitmix64,
+// the table must be stable so that the same content is always cut at the same positions
+func newGearTable(seed uint64) [256]uint64 {
+	var table [256]uint64
+	for i := range table {
+		seed += 0x9e3779b97f4a7c15
+		z := seed
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		table[i] = z ^ (z >> 31)
+	}
+	return table
+}
+
+// makeSlice digests a slice by sha256 to make a SliceMeta
+func makeSlice(bs []byte) slicer.Slice {
+	h := hash.HashUsingSha256(bs)
+	id, _ := uuid.NewRandom()
+	return slicer.Slice{
+		SliceMeta: slicer.SliceMeta{
+			ID:     id.String(),
+			Hash:   h,
+			Length: uint64(len(bs)),
+		},
+		Data: bs,
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdc
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/config"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/slicer"
+)
+
+func sliceAll(t *testing.T, s *CDCSlicer, data []byte) []slicer.Slice {
+	resCh := s.Slice(context.TODO(), bytes.NewReader(data), &slicer.SliceOptions{}, func(err error) {
+		require.NoError(t, err)
+	})
+	var slices []slicer.Slice
+	for sl := range resCh {
+		slices = append(slices, sl)
+	}
+	return slices
+}
+
+func TestCDCSlice(t *testing.T) {
+	logrus.SetLevel(logrus.ErrorLevel)
+
+	s, err := New(&config.CDCSlicerConf{
+		MinSize: 256,
+		AvgSize: 1024,
+		MaxSize: 4096,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 4096, s.GetBlockSize())
+
+	data := make([]byte, 64*1024)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	slices := sliceAll(t, s, data)
+	var joined []byte
+	for i, sl := range slices {
+		require.True(t, len(sl.Data) <= 4096)
+		if i != len(slices)-1 {
+			require.True(t, len(sl.Data) >= 256)
+		}
+		joined = append(joined, sl.Data...)
+	}
+	require.Equal(t, data, joined)
+
+	// insert some bytes in the middle, most slices are unchanged
+	modified := append(append(append([]byte{}, data[:30000]...), []byte("inserted")...), data[30000:]...)
+	modSlices := sliceAll(t, s, modified)
+	hashes := make(map[string]bool)
+	for _, sl := range slices {
+		hashes[string(sl.Hash)] = true
+	}
+	changed := 0
+	for _, sl := range modSlices {
+		if !hashes[string(sl.Hash)] {
+			changed++
+		}
+	}
+	require.True(t, changed <= 2)
+
+	// empty file produces no slice
+	require.Len(t, sliceAll(t, s, nil), 0)
+
+	_, err = New(&config.CDCSlicerConf{MinSize: 2048, AvgSize: 1024})
+	require.Error(t, err)
+}
@@ -17,6 +17,12 @@ package slicer
 type SliceOptions struct {
 }
 
+// ContentDefined is implemented by slicers which cut data at content defined boundaries,
+// identical content produces identical slices, so slices of such slicers can be deduplicated
+type ContentDefined interface {
+	ContentDefined() bool
+}
+
 // Slice defines a file slice
 type Slice struct {
 	SliceMeta
@@ -31,4 +37,11 @@ type SliceMeta struct {
 	ID     string // sliceID, uuid
 	Hash   []byte // hash of slice content
 	Length uint64 // length of slice content
+
+	// for deduplication
+	ChunkHash []byte // hash of the plaintext chunk, which derives the key of the slice
+
+	// for erasure coding
+	Stripe int  // index of the stripe which the slice belongs to
+	Parity bool // parity slice or not
 }
@@ -16,6 +16,9 @@ package types
 import (
 	"time"
 
+	"github.com/google/uuid"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/erasure"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
 )
 
@@ -28,6 +31,9 @@ type WriteOptions struct {
 	Description string `json:"desc"`
 	Extra       string `json:"ext"`
 	Token       string `json:"-"`
+
+	// the file is encrypted in the client by a user-held key, and is opaque to the node
+	ClientEncrypted bool `json:"e2e,omitempty"`
 }
 
 // Valid checks if WriteOptions is valid
@@ -55,6 +61,50 @@ func (o *WriteOptions) Valid() error {
 	return nil
 }
 
+// UploadRangeOptions parameters for uploading a range of file in a resumable upload session,
+// ranges must be uploaded in order, Offset is the position of the range in the file
+type UploadRangeOptions struct {
+	User      string `json:"user"`
+	SessionID string `json:"session"`
+	Offset    uint64 `json:"offset"`
+	Token     string `json:"-"`
+}
+
+// Valid checks if UploadRangeOptions is valid
+func (o *UploadRangeOptions) Valid() error {
+	if len(o.User) == 0 {
+		return errorx.New(errorx.ErrCodeParam, "empty user")
+	}
+	if len(o.Token) == 0 {
+		return errorx.New(errorx.ErrCodeParam, "empty token")
+	}
+	if _, err := uuid.Parse(o.SessionID); err != nil {
+		return errorx.New(errorx.ErrCodeParam, "invalid session id")
+	}
+	return nil
+}
+
+// UploadSessionOptions parameters for querying, committing or aborting a resumable upload session
+type UploadSessionOptions struct {
+	User      string `json:"user"`
+	SessionID string `json:"session"`
+	Token     string `json:"-"`
+}
+
+// Valid checks if UploadSessionOptions is valid
+func (o *UploadSessionOptions) Valid() error {
+	if len(o.User) == 0 {
+		return errorx.New(errorx.ErrCodeParam, "empty user")
+	}
+	if len(o.Token) == 0 {
+		return errorx.New(errorx.ErrCodeParam, "empty token")
+	}
+	if _, err := uuid.Parse(o.SessionID); err != nil {
+		return errorx.New(errorx.ErrCodeParam, "invalid session id")
+	}
+	return nil
+}
+
 // ReadOptions read file from engine
 // use user+namespace+filename or fileID to locate a file
 // will use fileID first if not empty
@@ -64,7 +114,20 @@ type ReadOptions struct {
 	Namespace string `json:"ns"`
 	FileName  string `json:"name"`
 	FileID    string `json:"file_id"`
+	Version   int    `json:"version,omitempty"` // version of the file located by name, 0 means the latest version
 	Token     string `json:"-"`
+
+	// read a range of the file, Length 0 means reading to the end of the file.
+	// range is not signed, as the token already authorizes reading the whole file
+	Offset uint64 `json:"-"`
+	Length uint64 `json:"-"`
+	// a range is requested explicitly, even if it covers the whole file, such as HTTP Range "bytes=0-"
+	Range bool `json:"-"`
+}
+
+// Ranged returns whether a range of the file is requested
+func (r *ReadOptions) Ranged() bool {
+	return r.Range || r.Offset > 0 || r.Length > 0
 }
 
 // Valid check if ReadOptions is valid
@@ -89,6 +152,9 @@ func (r *ReadOptions) Valid() error {
 	if idEmpty && nameEmpty {
 		return errorx.New(errorx.ErrCodeParam, "use id or user+namespace+filename")
 	}
+	if r.Version < 0 {
+		return errorx.New(errorx.ErrCodeParam, "invalid version")
+	}
 
 	return nil
 }
@@ -111,6 +177,16 @@ type PullOptions struct {
 	Signature string `json:"signature"`
 }
 
+// DeleteSliceOptions options for removing a slice from storage node, only the dataOwner node
+// who pushed the slice can remove it, used to clean up slices of aborted upload sessions
+type DeleteSliceOptions struct {
+	SliceID   string `json:"slice_id"`
+	StorIndex string `json:"slice_stor_index"`
+	SourceID  string `json:"source_id"` // dataOwner node id
+	Timestamp int64  `json:"timestamp"`
+	Signature string `json:"signature"`
+}
+
 // NodeOperateOptions options for setting storage node with online or offline status on blockchain
 type NodeOperateOptions struct {
 	NodeID string `json:"node"`
@@ -137,6 +213,25 @@ func (o *ListFileOptions) Valid() error {
 	return nil
 }
 
+// ListFileVersionsOptions options for listing versions of a file from blockchain
+type ListFileVersionsOptions struct {
+	Owner     string // file owner
+	Namespace string // file namespace
+	Name      string // file name
+	Limit     int64  // version limit
+}
+
+// Valid checks if ListFileVersionsOptions is valid
+func (o *ListFileVersionsOptions) Valid() error {
+	if len(o.Namespace) == 0 {
+		return errorx.New(errorx.ErrCodeParam, "empty namespace")
+	}
+	if len(o.Name) == 0 {
+		return errorx.New(errorx.ErrCodeParam, "empty file name")
+	}
+	return nil
+}
+
 // UpdateFileEtimeOptions options for updating file expire time
 type UpdateFileEtimeOptions struct {
 	FileID      string `json:"id"`
@@ -154,6 +249,42 @@ func (o *UpdateFileEtimeOptions) Valid() error {
 	return nil
 }
 
+// DeleteFileOptions options for deleting file before it expires
+type DeleteFileOptions struct {
+	FileID      string `json:"id"`
+	CurrentTime int64  `json:"ctime"`
+	User        string `json:"user"`
+	Token       string `json:"-"`
+}
+
+// Valid checks if DeleteFileOptions is valid
+func (o *DeleteFileOptions) Valid() error {
+	if len(o.FileID) == 0 {
+		return errorx.New(errorx.ErrCodeParam, "invalid param, file id is empty")
+	}
+	return nil
+}
+
+// RekeyOptions options for re-encrypting slices of files under the current password
+//  Namespace is optional, files of all namespaces are re-encrypted if it is empty
+type RekeyOptions struct {
+	Namespace   string `json:"ns"`
+	CurrentTime int64  `json:"ctime"`
+	User        string `json:"user"`
+	Token       string `json:"-"`
+}
+
+// Valid checks if RekeyOptions is valid
+func (o *RekeyOptions) Valid() error {
+	if len(o.User) == 0 {
+		return errorx.New(errorx.ErrCodeParam, "empty user")
+	}
+	if len(o.Token) == 0 {
+		return errorx.New(errorx.ErrCodeParam, "empty token")
+	}
+	return nil
+}
+
 // AddNsOptions options for adding namespace on blockchain
 type AddNsOptions struct {
 	Namespace   string `json:"ns"`
@@ -162,11 +293,31 @@ type AddNsOptions struct {
 	CreateTime  int64  `json:"ctime"`
 	User        string `json:"user"`
 	Token       string `json:"-"`
+
+	// for erasure coded namespace, files are coded into stripes of DataShards data slices and ParityShards parity slices
+	DataShards   int `json:"dataShards,omitempty"`
+	ParityShards int `json:"parityShards,omitempty"`
+
+	// deduplicate slices of files in the namespace, files in a deduplicated namespace can't be shredded on deletion
+	Dedup bool `json:"dedup,omitempty"`
 }
 
 // Valid checks if AddNsOptions is valid
 func (o *AddNsOptions) Valid() error {
-	return checkOperateNsOptions(o.User, o.Namespace, o.Token, o.Replica)
+	if err := checkOperateNsOptions(o.User, o.Namespace, o.Token, o.Replica); err != nil {
+		return err
+	}
+	if o.Dedup && (o.DataShards > 0 || o.ParityShards > 0) {
+		return errorx.New(errorx.ErrCodeParam, "invalid param, erasure coded namespace can't be deduplicated")
+	}
+	if o.DataShards > 0 || o.ParityShards > 0 {
+		// each slice of erasure coded file is stored only once
+		if o.Replica != 1 {
+			return errorx.New(errorx.ErrCodeParam, "invalid param, replica of erasure coded namespace must be 1")
+		}
+		return erasure.CheckShards(o.DataShards, o.ParityShards)
+	}
+	return nil
 }
 
 // UpdateNsOptions options for updating namespace replica
@@ -221,6 +372,29 @@ type ConfirmAuthOptions struct {
 	ExpireTime   int64  `json:"expireTime"`
 	Status       bool   `json:"status"` // file authorization application status
 	Token        string `json:"-"`
+
+	// hex encoded key of client encrypted file wrapped for the applier's public key with ECIES,
+	// required when confirming authorization of client encrypted file
+	E2EKey string `json:"e2eKey,omitempty"`
+}
+
+// RevokeAuthOptions parameters for authorizers to revoke the approved file authorization application
+type RevokeAuthOptions struct {
+	User         string `json:"user"` // authorizer's public key
+	AuthID       string `json:"authID"`
+	RevokeReason string `json:"revokeReason"`
+	Token        string `json:"-"`
+}
+
+// Valid checks if RevokeAuthOptions is valid
+func (o *RevokeAuthOptions) Valid() error {
+	if len(o.AuthID) == 0 {
+		return errorx.New(errorx.ErrCodeParam, "invalid param authID")
+	}
+	if len(o.RevokeReason) == 0 {
+		return errorx.New(errorx.ErrCodeParam, "invalid param revokeReason")
+	}
+	return nil
 }
 
 // Valid checks if ConfirmAuthOptions is valid
@@ -13,9 +13,65 @@
 
 package types
 
+import (
+	"io"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
+)
+
 // WriteResponse is response of uploading a file, only task id
 type WriteResponse struct {
-	FileID string `json:"file_id"`
+	FileID  string `json:"file_id"`
+	Version int    `json:"version"` // version of the file among files with the same name in the namespace
+}
+
+// UploadSessionResponse is response of resumable upload session operations
+//  Offset is the length of the file already uploaded, the next range should start from it
+//  BlockSize is the suggested length of each range
+type UploadSessionResponse struct {
+	SessionID string `json:"session_id"`
+	Offset    uint64 `json:"offset"`
+	BlockSize int    `json:"block_size"`
+}
+
+// Rekey job states
+const (
+	RekeyJobRunning    = "Running"
+	RekeyJobFinished   = "Finished"   // slices of all files are encrypted under KeyVersion
+	RekeyJobIncomplete = "Incomplete" // slices of some files are still encrypted under old passwords
+	RekeyJobCanceled   = "Canceled"
+)
+
+// RekeyJob is the progress of re-encrypting slices of files under the current password
+//  KeyVersion is the version of the current password
+//  Total is the number of files found, Done, Failed, Skipped and Deduplicated count files already processed,
+//  files already encrypted under KeyVersion are skipped, and deduplicated files under old passwords are left,
+//  because their slices are shared with other files
+//  MinKeyVersion is the oldest password version processed files still need, as structures and contents of files
+//  stay under the password they were written with, only old passwords of lower versions can be removed
+type RekeyJob struct {
+	ID            string `json:"id"`
+	Namespace     string `json:"namespace"`
+	KeyVersion    int    `json:"key_version"`
+	MinKeyVersion int    `json:"min_key_version"`
+	State         string `json:"state"`
+	Total         int    `json:"total"`
+	Done          int    `json:"done"`
+	Failed        int    `json:"failed"`
+	Skipped       int    `json:"skipped"`
+	Deduplicated  int    `json:"deduplicated"`
+	StartTime     int64  `json:"start_time"`
+	EndTime       int64  `json:"end_time"`
+}
+
+// RangeReader is returned by reading a range of a file
+//  Offset and Length describe the range actually returned, Total is the length of the whole file
+type RangeReader struct {
+	io.ReadCloser
+
+	Offset uint64
+	Length uint64
+	Total  uint64
 }
 
 // PushResponse is response of receiving a slice
@@ -23,3 +79,17 @@ type WriteResponse struct {
 type PushResponse struct {
 	SliceStorIndex string `json:"slice_stor_index"`
 }
+
+// FileAuditReport is the audit trail of a file signed by the dataOwner node owning it
+//  Events are sorted in time order, each of them can be verified on chain by its TxID and Digest,
+//  ProvedNum and FailedNum count challenges in Events by result
+//  Signature is made by Signer over the message got by GetSigMessage of the report
+type FileAuditReport struct {
+	File         blockchain.File             `json:"file"`
+	Events       []blockchain.FileAuditEvent `json:"events"`
+	ProvedNum    int                         `json:"proved_num"`
+	FailedNum    int                         `json:"failed_num"`
+	GenerateTime int64                       `json:"generate_time"`
+	Signer       string                      `json:"signer"`
+	Signature    string                      `json:"signature"`
+}
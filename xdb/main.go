@@ -30,13 +30,19 @@ import (
 	flag "github.com/spf13/pflag"
 
 	fabricblockchain "github.com/PaddlePaddle/PaddleDTX/xdb/blockchain/fabric"
+	localblockchain "github.com/PaddlePaddle/PaddleDTX/xdb/blockchain/local"
 	xchainblockchain "github.com/PaddlePaddle/PaddleDTX/xdb/blockchain/xchain"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/config"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/engine"
 	merklechallenger "github.com/PaddlePaddle/PaddleDTX/xdb/engine/challenger/merkle"
 	pairingchallenger "github.com/PaddlePaddle/PaddleDTX/xdb/engine/challenger/pairing"
 	randomcopier "github.com/PaddlePaddle/PaddleDTX/xdb/engine/copier/random"
+	weightedcopier "github.com/PaddlePaddle/PaddleDTX/xdb/engine/copier/weighted"
 	softencryptor "github.com/PaddlePaddle/PaddleDTX/xdb/engine/encryptor/soft"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/keyprovider"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/keyprovider/keystore"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/engine/keyprovider/vault"
+	cdcslicer "github.com/PaddlePaddle/PaddleDTX/xdb/engine/slicer/cdc"
 	simpleslicer "github.com/PaddlePaddle/PaddleDTX/xdb/engine/slicer/simple"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
 	"github.com/PaddlePaddle/PaddleDTX/xdb/peer"
@@ -44,12 +50,16 @@ import (
 	storage "github.com/PaddlePaddle/PaddleDTX/xdb/storage"
 	ipfs_storage "github.com/PaddlePaddle/PaddleDTX/xdb/storage/ipfs"
 	local_storage "github.com/PaddlePaddle/PaddleDTX/xdb/storage/local"
+	s3_storage "github.com/PaddlePaddle/PaddleDTX/xdb/storage/s3"
 )
 
 var (
 	configPath string
 )
 
+// defaultSessionTTL is the time an upload session is kept since its last update if not configured
+const defaultSessionTTL = 24 * time.Hour
+
 func appExit(err error) {
 	logrus.WithError(err).Error("app exit")
 	os.Exit(-1)
@@ -84,12 +94,13 @@ func main() {
 
 	serverConf := config.GetServerConf()
 	blockchainConf := config.GetBlockchainConf()
-	localNode := mustGetNode(serverConf)
+	keyProvider := mustGetKeyProvider(config.GetKeyProviderConf())
+	localNode := mustGetNode(serverConf, keyProvider)
 	blockchainEngine := mustGetBlockchain(blockchainConf)
 	var e *engine.Engine
 	switch config.GetServerType() {
 	case config.NodeTypeDataOwner:
-		e = getDataOwnerEngine(localNode, blockchainEngine, config.GetDataOwnerConf())
+		e = getDataOwnerEngine(localNode, blockchainEngine, keyProvider, config.GetDataOwnerConf())
 	case config.NodeTypeStorage:
 		e = getStorageEngine(localNode, blockchainEngine, config.GetStorageConf())
 	default:
@@ -114,15 +125,24 @@ func main() {
 }
 
 // getDataOwnerEngine initiates DataOwner Engine.
-func getDataOwnerEngine(localNode peer.Local, blockchain engine.Blockchain, conf *config.DataOwnerConf) *engine.Engine {
+func getDataOwnerEngine(localNode peer.Local, blockchain engine.Blockchain, kp keyprovider.KeyProvider,
+	conf *config.DataOwnerConf) *engine.Engine {
 	engineOption := engine.NewEngineOption{
 		LocalNode: localNode,
 		Chain:     blockchain,
 	}
 	engineOption.Slicer = mustGetSlicer(conf.Slicer)
-	engineOption.Encryptor = mustGetEncryptor(conf.Encryptor)
-	engineOption.Challenger = mustGetChallenger(conf.Challenger, localNode.PrivateKey)
+	engineOption.Encryptor = mustGetEncryptor(conf.Encryptor, kp)
+	engineOption.Challenger = mustGetChallenger(conf.Challenger, localNode.PrivateKey, kp)
+	engineOption.AnswerDeadline = time.Duration(conf.Challenger.AnswerDeadline) * time.Second
 	engineOption.Copier = mustGetCopier(conf.Copier, localNode.PrivateKey)
+	if conf.Session != nil && conf.Session.LocalRoot != "" {
+		engineOption.ProveStor = mustGetSessionStorage(conf.Session)
+		engineOption.SessionTTL = defaultSessionTTL
+		if conf.Session.TTL > 0 {
+			engineOption.SessionTTL = time.Duration(conf.Session.TTL) * time.Hour
+		}
+	}
 	engine, err := engine.NewEngine(conf.Monitor, &engineOption)
 	if err != nil {
 		appExit(err)
@@ -156,6 +176,12 @@ func mustGetSlicer(conf *config.DataOwnerSlicerConf) engine.Slicer {
 			appExit(err)
 		}
 		s = simpleSlicer
+	case "cdcSlicer":
+		cdcSlicer, err := cdcslicer.New(conf.CDCSlicer)
+		if err != nil {
+			appExit(err)
+		}
+		s = cdcSlicer
 	default:
 		appExit(errors.New("invalid slicer type: " + conf.Type))
 	}
@@ -163,12 +189,12 @@ func mustGetSlicer(conf *config.DataOwnerSlicerConf) engine.Slicer {
 }
 
 // mustGetEncryptor initiates Encryptor to encrypt data or decrypt encoded data
-func mustGetEncryptor(conf *config.DataOwnerEncryptorConf) engine.Encryptor {
+func mustGetEncryptor(conf *config.DataOwnerEncryptorConf, kp keyprovider.KeyProvider) engine.Encryptor {
 	var err error
 	var e engine.Encryptor
 	switch conf.Type {
 	case "softEncryptor":
-		e, err = softencryptor.New(conf.SoftEncryptor)
+		e, err = softencryptor.New(conf.SoftEncryptor, kp)
 	default:
 		appExit(errors.New("invalid encryptor type: " + conf.Type))
 	}
@@ -183,12 +209,12 @@ func mustGetEncryptor(conf *config.DataOwnerEncryptorConf) engine.Encryptor {
 // mustGetChallenger initiates Challenger
 //  Pairing-based and MerkleTree-based are both supported
 //  see more from engine.challenger
-func mustGetChallenger(conf *config.DataOwnerChallenger, signer ecdsa.PrivateKey) engine.Challenger {
+func mustGetChallenger(conf *config.DataOwnerChallenger, signer ecdsa.PrivateKey, kp keyprovider.KeyProvider) engine.Challenger {
 	var err error
 	var c engine.Challenger
 	switch conf.Type {
 	case "pairing":
-		c, err = pairingchallenger.New(conf.Pairing, signer)
+		c, err = pairingchallenger.New(conf.Pairing, signer, kp)
 	case "merkle":
 		c, err = merklechallenger.New(conf.Merkle, signer)
 	default:
@@ -203,7 +229,7 @@ func mustGetChallenger(conf *config.DataOwnerChallenger, signer ecdsa.PrivateKey
 }
 
 // mustGetBlockchain initiates XChain client which is used for connecting and requesting blockchain
-// XChain and Fabric are both supported
+// XChain, Fabric and the in-process local blockchain are supported
 func mustGetBlockchain(conf *config.BlockchainConf) engine.Blockchain {
 	var b engine.Blockchain
 	var err error
@@ -212,6 +238,8 @@ func mustGetBlockchain(conf *config.BlockchainConf) engine.Blockchain {
 		b, err = xchainblockchain.New(conf.Xchain)
 	case "fabric":
 		b, err = fabricblockchain.New(conf.Fabric)
+	case "local":
+		b, err = localblockchain.New(conf.Local)
 	default:
 		appExit(errors.New("invalid blockchain type: " + conf.Type))
 	}
@@ -229,6 +257,8 @@ func mustGetCopier(conf *config.DataOwnerCopierConf, signer ecdsa.PrivateKey) en
 	switch copierType {
 	case "random-copier":
 		c = randomcopier.New(signer)
+	case "weighted-copier":
+		c = weightedcopier.New(signer)
 	default:
 		appExit(errors.New("invalid copier type: " + copierType))
 	}
@@ -253,6 +283,11 @@ func mustGetSliceStorage(conf *config.StorageConf) engine.SliceStorage {
 		if err != nil {
 			appExit(fmt.Errorf("failed to create ipfs storage, err: %v", err))
 		}
+	case "s3":
+		s, err = s3_storage.New(conf.Mode.S3)
+		if err != nil {
+			appExit(fmt.Errorf("failed to create s3 storage, err: %v", err))
+		}
 	default:
 		appExit(errors.New("invalid storage type: " + storageType))
 	}
@@ -270,8 +305,43 @@ func mustGetProveStorage(conf *config.StorageConf) engine.ProveStorage {
 	return s
 }
 
+// mustGetKeyProvider initiates KeyProvider which secrets absent from configuration are fetched from,
+// nil is returned if it's not configured
+//  Encrypted local keystore and Vault transit compatible KMS are both supported
+func mustGetKeyProvider(conf *config.KeyProviderConf) keyprovider.KeyProvider {
+	if conf == nil || conf.Type == "" {
+		return nil
+	}
+
+	var kp keyprovider.KeyProvider
+	var err error
+	switch conf.Type {
+	case "keystore":
+		kp, err = keystore.New(conf.Keystore)
+	case "vault":
+		kp, err = vault.New(conf.Vault)
+	default:
+		appExit(errors.New("invalid key provider type: " + conf.Type))
+	}
+	if err != nil {
+		appExit(errorx.Wrap(err, "failed to create key provider"))
+	}
+	return kp
+}
+
+// mustGetSessionStorage initiates local storage to store progress of resumable upload sessions
+func mustGetSessionStorage(conf *config.UploadSessionConf) engine.ProveStorage {
+	s, err := local_storage.New(conf.LocalRoot)
+	if err != nil {
+		appExit(fmt.Errorf("failed to create local storage, err: %v", err))
+	}
+
+	return s
+}
+
 // mustGetNode initiates local account
-func mustGetNode(conf *config.ServerConf) peer.Local {
+//  The private key is fetched from KeyProvider if it's absent from configuration
+func mustGetNode(conf *config.ServerConf, kp keyprovider.KeyProvider) peer.Local {
 	if conf == nil {
 		appExit(errors.New("missing config"))
 	}
@@ -280,7 +350,14 @@ func mustGetNode(conf *config.ServerConf) peer.Local {
 		appExit(errors.New("missing config: name"))
 	}
 
-	if conf.PrivateKey == "" {
+	privateKey := conf.PrivateKey
+	if privateKey == "" && kp != nil {
+		var err error
+		if privateKey, err = kp.GetSecret(keyprovider.SecretPrivateKey); err != nil {
+			appExit(errorx.Wrap(err, "failed to get private key from key provider"))
+		}
+	}
+	if privateKey == "" {
 		appExit(errors.New("missing config: privateKey"))
 	}
 
@@ -288,7 +365,7 @@ func mustGetNode(conf *config.ServerConf) peer.Local {
 		appExit(errors.New("missing config: publicAddress"))
 	}
 
-	sk, err := ecdsa.DecodePrivateKeyFromString(conf.PrivateKey)
+	sk, err := ecdsa.DecodePrivateKeyFromString(strings.TrimSpace(privateKey))
 	if err != nil {
 		appExit(errorx.Wrap(err, "failed to decode private key"))
 	}
@@ -300,6 +377,8 @@ func mustGetNode(conf *config.ServerConf) peer.Local {
 		ID:         pk[:],
 		Name:       conf.Name,
 		PrivateKey: sk,
+		Zone:       conf.Zone,
+		Capacity:   conf.Capacity << 30,
 	}
 	return local
 }
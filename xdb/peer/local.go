@@ -21,4 +21,6 @@ type Local struct {
 	Name       string
 	PrivateKey ecdsa.PrivateKey
 	Address    string
+	Zone       string // failure domain of storage node
+	Capacity   uint64 // bytes offered by storage node for storing slices
 }
@@ -9,6 +9,7 @@ import (
 
 const PrivateKeyFileName = "private.key"
 const PublicKeyFileName = "public.key"
+const E2EKeyFileName = "e2e.key"
 
 const UserKeyFilePath = "./ukeys"
 const AuthKeyFilePath = "./authkeys"
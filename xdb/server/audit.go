@@ -0,0 +1,86 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"encoding/hex"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/kataras/iris/v12"
+
+	etype "github.com/PaddlePaddle/PaddleDTX/xdb/engine/types"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+)
+
+const auditTimeTemplate = "2006-01-02 15:04:05"
+
+// auditHTMLTemplate renders the file audit report as a self-contained html page, which can be printed
+var auditHTMLTemplate = template.Must(template.New("audit").Funcs(template.FuncMap{
+	"ftime": func(t int64) string {
+		if t == 0 {
+			return "-"
+		}
+		return time.Unix(0, t).Format(auditTimeTemplate)
+	},
+	"hex": hex.EncodeToString,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Audit Report of File {{.File.ID}}</title>
+<style>
+body { font-family: sans-serif; font-size: 13px; margin: 24px; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 16px; }
+th, td { border: 1px solid #999; padding: 4px 6px; text-align: left; vertical-align: top; }
+th { background: #eee; }
+td.mono { font-family: monospace; word-break: break-all; }
+</style>
+</head>
+<body>
+<h2>Audit Report of File {{.File.ID}}</h2>
+<table>
+<tr><th>Name</th><td>{{.File.Name}}</td></tr>
+<tr><th>Namespace</th><td>{{.File.Namespace}}</td></tr>
+<tr><th>Owner</th><td class="mono">{{hex .File.Owner}}</td></tr>
+<tr><th>Length</th><td>{{.File.Length}}</td></tr>
+<tr><th>Publish Time</th><td>{{ftime .File.PublishTime}}</td></tr>
+<tr><th>Expire Time</th><td>{{ftime .File.ExpireTime}}</td></tr>
+<tr><th>Delete Time</th><td>{{ftime .File.DeleteTime}}</td></tr>
+<tr><th>Challenges Proved / Failed</th><td>{{.ProvedNum}} / {{.FailedNum}}</td></tr>
+<tr><th>Generate Time</th><td>{{ftime .GenerateTime}}</td></tr>
+<tr><th>Signer</th><td class="mono">{{.Signer}}</td></tr>
+<tr><th>Signature</th><td class="mono">{{.Signature}}</td></tr>
+</table>
+<h3>Events</h3>
+<table>
+<tr><th>Time</th><th>Type</th><th>Operator</th><th>Ref</th><th>Detail</th><th>TxID</th><th>Digest</th></tr>
+{{range .Events}}<tr><td>{{ftime .Time}}</td><td>{{.Type}}</td><td class="mono">{{.Operator}}</td><td class="mono">{{.Ref}}</td><td>{{.Detail}}</td><td class="mono">{{.TxID}}</td><td class="mono">{{.Digest}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// responseAuditHTML responses the file audit report rendered as html
+func responseAuditHTML(ctx iris.Context, report etype.FileAuditReport) {
+	var buf bytes.Buffer
+	if err := auditHTMLTemplate.Execute(&buf, report); err != nil {
+		responseError(ctx, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to render audit report"))
+		return
+	}
+	ctx.StatusCode(http.StatusOK)
+	ctx.HTML(buf.String())
+}
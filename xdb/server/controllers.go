@@ -16,6 +16,9 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -39,6 +42,8 @@ func (s *Server) write(ictx iris.Context) {
 		ExpireTime:  ictx.URLParamInt64Default("expireTime", 0),
 		Description: ictx.URLParam("desc"),
 		Extra:       ictx.URLParam("ext"),
+
+		ClientEncrypted: ictx.URLParam("e2e") == "true",
 	}
 	if err := req.Valid(); err != nil {
 		responseError(ictx, errorx.Wrap(err, "invalid params"))
@@ -59,6 +64,129 @@ func (s *Server) write(ictx iris.Context) {
 	responseJSON(ictx, resp)
 }
 
+// initUpload creates a resumable upload session
+func (s *Server) initUpload(ictx iris.Context) {
+	req := etype.WriteOptions{
+		User:        ictx.URLParam("user"),
+		Token:       ictx.URLParam("token"),
+		Namespace:   ictx.URLParam("ns"),
+		FileName:    ictx.URLParam("name"),
+		ExpireTime:  ictx.URLParamInt64Default("expireTime", 0),
+		Description: ictx.URLParam("desc"),
+		Extra:       ictx.URLParam("ext"),
+
+		ClientEncrypted: ictx.URLParam("e2e") == "true",
+	}
+	if err := req.Valid(); err != nil {
+		responseError(ictx, errorx.Wrap(err, "invalid params"))
+		return
+	}
+	result, err := s.handler.InitUpload(context.Background(), req)
+	if err != nil {
+		responseError(ictx, errorx.Wrap(err, "failed to init upload session"))
+		return
+	}
+	responseJSON(ictx, toUploadSessionResponse(result))
+}
+
+// uploadRange uploads a range of file into the upload session
+func (s *Server) uploadRange(ictx iris.Context) {
+	offset, err := ictx.URLParamInt64("offset")
+	if err != nil || offset < 0 {
+		responseError(ictx, errorx.New(errorx.ErrCodeParam, "invalid params: offset"))
+		return
+	}
+	req := etype.UploadRangeOptions{
+		User:      ictx.URLParam("user"),
+		Token:     ictx.URLParam("token"),
+		SessionID: ictx.URLParam("session"),
+		Offset:    uint64(offset),
+	}
+	if err := req.Valid(); err != nil {
+		responseError(ictx, errorx.Wrap(err, "invalid params"))
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ictx.OnConnectionClose(func(iris.Context) { cancel() })
+
+	result, err := s.handler.UploadRange(ctx, req, ictx.Request().Body)
+	if err != nil {
+		responseError(ictx, errorx.Wrap(err, "failed to upload range"))
+		return
+	}
+	responseJSON(ictx, toUploadSessionResponse(result))
+}
+
+// getUploadSession queries the progress of the upload session
+func (s *Server) getUploadSession(ictx iris.Context) {
+	req := getUploadSessionOptions(ictx)
+	if err := req.Valid(); err != nil {
+		responseError(ictx, errorx.Wrap(err, "invalid params"))
+		return
+	}
+	result, err := s.handler.GetUploadSession(req)
+	if err != nil {
+		responseError(ictx, errorx.Wrap(err, "failed to get upload session"))
+		return
+	}
+	responseJSON(ictx, toUploadSessionResponse(result))
+}
+
+// commitUpload finishes the upload session and publishes the file
+func (s *Server) commitUpload(ictx iris.Context) {
+	req := getUploadSessionOptions(ictx)
+	if err := req.Valid(); err != nil {
+		responseError(ictx, errorx.Wrap(err, "invalid params"))
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ictx.OnConnectionClose(func(iris.Context) { cancel() })
+
+	result, err := s.handler.CommitUpload(ctx, req)
+	if err != nil {
+		responseError(ictx, errorx.Wrap(err, "failed to commit upload session"))
+		return
+	}
+	resp := types.WriteResponse{
+		FileID: result.FileID,
+	}
+	responseJSON(ictx, resp)
+}
+
+// abortUpload cancels the upload session and removes slices already pushed
+func (s *Server) abortUpload(ictx iris.Context) {
+	req := getUploadSessionOptions(ictx)
+	if err := req.Valid(); err != nil {
+		responseError(ictx, errorx.Wrap(err, "invalid params"))
+		return
+	}
+	if err := s.handler.AbortUpload(context.Background(), req); err != nil {
+		responseError(ictx, errorx.Wrap(err, "failed to abort upload session"))
+		return
+	}
+	responseJSON(ictx, "success")
+}
+
+// getUploadSessionOptions reads parameters of upload session operations
+func getUploadSessionOptions(ictx iris.Context) etype.UploadSessionOptions {
+	return etype.UploadSessionOptions{
+		User:      ictx.URLParam("user"),
+		Token:     ictx.URLParam("token"),
+		SessionID: ictx.URLParam("session"),
+	}
+}
+
+// toUploadSessionResponse converts the result of upload session operations to response
+func toUploadSessionResponse(result etype.UploadSessionResponse) types.UploadSessionResponse {
+	return types.UploadSessionResponse{
+		SessionID: result.SessionID,
+		Offset:    result.Offset,
+		BlockSize: result.BlockSize,
+	}
+}
+
 // read download a file to local
 func (s *Server) read(ictx iris.Context) {
 	req := etype.ReadOptions{
@@ -67,12 +195,31 @@ func (s *Server) read(ictx iris.Context) {
 		Namespace: ictx.URLParam("ns"),
 		FileName:  ictx.URLParam("name"),
 		FileID:    ictx.URLParam("file_id"),
+		Version:   ictx.URLParamIntDefault("version", 0),
 		Timestamp: ictx.URLParamInt64Default("timestamp", 0),
 	}
 	if err := req.Valid(); err != nil {
 		responseError(ictx, errorx.Wrap(err, "invalid params"))
 		return
 	}
+	// a range of the file can be read by HTTP Range header, or by parameters offset and length
+	rangeHeader := ictx.GetHeader("Range")
+	if rangeHeader != "" {
+		offset, length, err := parseRange(rangeHeader)
+		if err != nil {
+			responseError(ictx, err)
+			return
+		}
+		req.Offset, req.Length, req.Range = offset, length, true
+	} else {
+		offset := ictx.URLParamInt64Default("offset", 0)
+		length := ictx.URLParamInt64Default("length", 0)
+		if offset < 0 || length < 0 {
+			responseError(ictx, errorx.New(errorx.ErrCodeParam, "invalid params: offset or length"))
+			return
+		}
+		req.Offset, req.Length = uint64(offset), uint64(length)
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -85,6 +232,18 @@ func (s *Server) read(ictx iris.Context) {
 	}
 	defer reader.Close()
 
+	if rr, ok := reader.(*etype.RangeReader); ok && rangeHeader != "" {
+		// only an empty file has no satisfiable range
+		if rr.Length == 0 {
+			responseError(ictx, errorx.New(errorx.ErrCodeParam, "range not satisfiable: %s", rangeHeader))
+			return
+		}
+		responseStreamWithHeaders(ictx, reader, http.StatusPartialContent, map[string]string{
+			"Content-Range":  fmt.Sprintf("bytes %d-%d/%d", rr.Offset, rr.Offset+rr.Length-1, rr.Total),
+			"Content-Length": strconv.FormatUint(rr.Length, 10),
+		})
+		return
+	}
 	responseStream(ictx, reader)
 }
 
@@ -114,6 +273,22 @@ func (s *Server) push(ictx iris.Context) {
 	responseJSON(ictx, resp)
 }
 
+// deleteSlice removes slices of aborted uploads
+func (s *Server) deleteSlice(ictx iris.Context) {
+	opt := etype.DeleteSliceOptions{
+		SliceID:   ictx.URLParam("slice_id"),
+		StorIndex: ictx.URLParam("slice_stor_index"),
+		SourceID:  ictx.URLParam("source_id"),
+		Timestamp: ictx.URLParamInt64Default("timestamp", 0),
+		Signature: ictx.URLParam("signature"),
+	}
+	if err := s.handler.DeleteSlice(opt); err != nil {
+		responseError(ictx, errorx.Wrap(err, "failed to delete slice"))
+		return
+	}
+	responseJSON(ictx, "success")
+}
+
 // pull offers file slices to owner
 func (s *Server) pull(ictx iris.Context) {
 	opt := etype.PullOptions{
@@ -175,23 +350,17 @@ func (s *Server) getNode(ictx iris.Context) {
 
 // nodeOffline set storage node status to offline
 func (s *Server) nodeOffline(ictx iris.Context) {
-	s.nodeOperate(ictx, false)
+	s.nodeOperate(ictx, s.handler.NodeOffline, "failed to take node offline")
 }
 
-func (s *Server) nodeOperate(ictx iris.Context, isOnline bool) {
+func (s *Server) nodeOperate(ictx iris.Context, operate func(etype.NodeOperateOptions) error, errMsg string) {
 	req := etype.NodeOperateOptions{
 		NodeID: ictx.URLParam("node"),
 		Nonce:  ictx.URLParamInt64Default("nonce", 0),
 		Token:  ictx.URLParam("token"),
 	}
-	var err error
-	if isOnline {
-		err = s.handler.NodeOnline(req)
-	} else {
-		err = s.handler.NodeOffline(req)
-	}
-	if err != nil {
-		responseError(ictx, errorx.Wrap(err, "failed to take node offline"))
+	if err := operate(req); err != nil {
+		responseError(ictx, errorx.Wrap(err, errMsg))
 		return
 	}
 	responseJSON(ictx, "success")
@@ -199,7 +368,12 @@ func (s *Server) nodeOperate(ictx iris.Context, isOnline bool) {
 
 // nodeOnline set storage node status to online
 func (s *Server) nodeOnline(ictx iris.Context) {
-	s.nodeOperate(ictx, true)
+	s.nodeOperate(ictx, s.handler.NodeOnline, "failed to take node online")
+}
+
+// nodeDrain set storage node status to draining
+func (s *Server) nodeDrain(ictx iris.Context) {
+	s.nodeOperate(ictx, s.handler.NodeDrain, "failed to drain node")
 }
 
 // getMRecord get storage node migration records
@@ -223,6 +397,23 @@ func (s *Server) getMRecord(ictx iris.Context) {
 	responseJSON(ictx, ms)
 }
 
+// listScrubReports lists reports of slices found corrupted or missing by scrubbing on storage node
+func (s *Server) listScrubReports(ictx iris.Context) {
+	opt := &blockchain.ListSliceScrubReportsOptions{
+		Target:    []byte(ictx.URLParam("id")),
+		StartTime: ictx.URLParamInt64Default("start", 0),
+		EndTime:   ictx.URLParamInt64Default("end", time.Now().UnixNano()),
+		Limit:     ictx.URLParamInt64Default("limit", blockchain.ListMaxNumber),
+	}
+
+	reports, err := s.handler.ListSliceScrubReports(opt)
+	if err != nil {
+		responseError(ictx, errorx.Wrap(err, "failed to list slice scrub reports"))
+		return
+	}
+	responseJSON(ictx, reports)
+}
+
 // getHeartbeatNum get storage node heartbeat number, param-id is the storage node public key
 func (s *Server) getHeartbeatNum(ictx iris.Context) {
 	id := ictx.URLParam("id")
@@ -300,12 +491,44 @@ func (s *Server) getFileByID(ictx iris.Context) {
 	responseJSON(ictx, resp)
 }
 
+// getFileAuditReport get the signed audit report of a file, format can be "json"(default) or "html"
+func (s *Server) getFileAuditReport(ictx iris.Context) {
+	id := ictx.URLParam("id")
+	if id == "" {
+		responseError(ictx, errorx.New(errorx.ErrCodeParam, "bad params:id is empty"))
+		return
+	}
+	format := ictx.URLParamDefault("format", "json")
+	if format != "json" && format != "html" {
+		responseError(ictx, errorx.New(errorx.ErrCodeParam, "bad params:format must be json or html"))
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ictx.OnConnectionClose(func(iris.Context) { cancel() })
+	report, err := s.handler.GetFileAuditReport(ctx, id)
+	if err != nil {
+		responseError(ictx, errorx.Wrap(err, "failed to get file audit report"))
+		return
+	}
+	if format == "html" {
+		responseAuditHTML(ictx, report)
+		return
+	}
+	responseJSON(ictx, report)
+}
+
 // getFileByName get file by file name and namespace
 func (s *Server) getFileByName(ictx iris.Context) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	ictx.OnConnectionClose(func(iris.Context) { cancel() })
-	resp, err := s.handler.GetFileByName(ctx, ictx.URLParam("owner"), ictx.URLParam("ns"), ictx.URLParam("name"))
+	version := ictx.URLParamIntDefault("version", 0)
+	if version < 0 {
+		responseError(ictx, errorx.New(errorx.ErrCodeParam, "invalid params: version"))
+		return
+	}
+	resp, err := s.handler.GetFileByName(ctx, ictx.URLParam("owner"), ictx.URLParam("ns"), ictx.URLParam("name"), version)
 	if err != nil {
 		responseError(ictx, errorx.Wrap(err, "failed to get file by name"))
 		return
@@ -313,6 +536,26 @@ func (s *Server) getFileByName(ictx iris.Context) {
 	responseJSON(ictx, resp)
 }
 
+// listFileVersions lists versions of a file by file name and namespace, the latest version comes first
+func (s *Server) listFileVersions(ictx iris.Context) {
+	req := etype.ListFileVersionsOptions{
+		Owner:     ictx.URLParam("owner"),
+		Namespace: ictx.URLParam("ns"),
+		Name:      ictx.URLParam("name"),
+		Limit:     ictx.URLParamInt64Default("limit", blockchain.ListMaxNumber),
+	}
+	if err := req.Valid(); err != nil {
+		responseError(ictx, errorx.Wrap(err, "invalid params"))
+		return
+	}
+	resp, err := s.handler.ListFileVersions(req)
+	if err != nil {
+		responseError(ictx, errorx.Wrap(err, "failed to list file versions"))
+		return
+	}
+	responseJSON(ictx, resp)
+}
+
 // updateFileExpireTime update file expire time
 func (s *Server) updateFileExpireTime(ictx iris.Context) {
 	req := etype.UpdateFileEtimeOptions{
@@ -337,6 +580,76 @@ func (s *Server) updateFileExpireTime(ictx iris.Context) {
 	responseJSON(ictx, "success")
 }
 
+// deleteFile deletes file before it expires
+func (s *Server) deleteFile(ictx iris.Context) {
+	req := etype.DeleteFileOptions{
+		FileID:      ictx.URLParam("id"),
+		CurrentTime: ictx.URLParamInt64Default("ctime", 0),
+		User:        ictx.URLParam("user"),
+		Token:       ictx.URLParam("token"),
+	}
+	if err := req.Valid(); err != nil {
+		responseError(ictx, errorx.Wrap(err, "invalid params"))
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ictx.OnConnectionClose(func(iris.Context) { cancel() })
+
+	if err := s.handler.DeleteFile(ctx, req); err != nil {
+		responseError(ictx, errorx.Wrap(err, "failed to delete file"))
+		return
+	}
+	responseJSON(ictx, "success")
+}
+
+// startRekey starts re-encrypting slices of files under the current password
+func (s *Server) startRekey(ictx iris.Context) {
+	req := etype.RekeyOptions{
+		Namespace:   ictx.URLParam("ns"),
+		CurrentTime: ictx.URLParamInt64Default("ctime", 0),
+		User:        ictx.URLParam("user"),
+		Token:       ictx.URLParam("token"),
+	}
+	if err := req.Valid(); err != nil {
+		responseError(ictx, errorx.Wrap(err, "invalid params"))
+		return
+	}
+	result, err := s.handler.StartRekey(req)
+	if err != nil {
+		responseError(ictx, errorx.Wrap(err, "failed to start rekey job"))
+		return
+	}
+	responseJSON(ictx, toRekeyJobResponse(result))
+}
+
+// getRekeyJob queries the progress of the latest rekey job
+func (s *Server) getRekeyJob(ictx iris.Context) {
+	result, err := s.handler.GetRekeyJob()
+	if err != nil {
+		responseError(ictx, errorx.Wrap(err, "failed to get rekey job"))
+		return
+	}
+	responseJSON(ictx, toRekeyJobResponse(result))
+}
+
+func toRekeyJobResponse(job etype.RekeyJob) types.RekeyJobResponse {
+	return types.RekeyJobResponse{
+		ID:            job.ID,
+		Namespace:     job.Namespace,
+		KeyVersion:    job.KeyVersion,
+		MinKeyVersion: job.MinKeyVersion,
+		State:         job.State,
+		Total:         job.Total,
+		Done:          job.Done,
+		Failed:        job.Failed,
+		Skipped:       job.Skipped,
+		Deduplicated:  job.Deduplicated,
+		StartTime:     job.StartTime,
+		EndTime:       job.EndTime,
+	}
+}
+
 // addFileNs add a file namespace
 func (s *Server) addFileNs(ictx iris.Context) {
 	// check files replica of namespace, replica must no greater than nodes number
@@ -350,13 +663,23 @@ func (s *Server) addFileNs(ictx iris.Context) {
 		responseError(ictx, errorx.New(errorx.ErrCodeParam, "invalid param: replica must no greater than nodes number"))
 		return
 	}
+	// slices of a stripe are stored on different nodes for erasure coded namespace
+	dataShards := ictx.URLParamIntDefault("dataShards", 0)
+	parityShards := ictx.URLParamIntDefault("parityShards", 0)
+	if dataShards+parityShards > len(lresp) {
+		responseError(ictx, errorx.New(errorx.ErrCodeParam, "invalid param: dataShards plus parityShards must no greater than nodes number"))
+		return
+	}
 	req := etype.AddNsOptions{
-		Namespace:   ictx.URLParam("ns"),
-		Description: ictx.URLParam("desc"),
-		Replica:     replica,
-		CreateTime:  ictx.URLParamInt64Default("ctime", time.Now().UnixNano()),
-		User:        ictx.URLParam("user"),
-		Token:       ictx.URLParam("token"),
+		Namespace:    ictx.URLParam("ns"),
+		Description:  ictx.URLParam("desc"),
+		Replica:      replica,
+		CreateTime:   ictx.URLParamInt64Default("ctime", time.Now().UnixNano()),
+		User:         ictx.URLParam("user"),
+		Token:        ictx.URLParam("token"),
+		DataShards:   dataShards,
+		ParityShards: parityShards,
+		Dedup:        ictx.URLParam("dedup") == "true",
 	}
 	if err := req.Valid(); err != nil {
 		responseError(ictx, errorx.Wrap(err, "invalid params"))
@@ -477,6 +800,7 @@ func (s *Server) confirmAuth(ictx iris.Context) {
 		ExpireTime:   ictx.URLParamInt64Default("expireTime", 0),
 		Token:        ictx.URLParam("token"),
 		RejectReason: ictx.URLParam("rejectReason"),
+		E2EKey:       ictx.URLParam("e2eKey"),
 	}
 	if err := req.Valid(status); err != nil {
 		responseError(ictx, errorx.Wrap(err, "invalid params"))
@@ -490,6 +814,25 @@ func (s *Server) confirmAuth(ictx iris.Context) {
 	responseJSON(ictx, "success")
 }
 
+// revokeAuth revokes the approved file authorization application
+func (s *Server) revokeAuth(ictx iris.Context) {
+	req := etype.RevokeAuthOptions{
+		User:         ictx.URLParam("user"),
+		AuthID:       ictx.URLParam("authID"),
+		RevokeReason: ictx.URLParam("revokeReason"),
+		Token:        ictx.URLParam("token"),
+	}
+	if err := req.Valid(); err != nil {
+		responseError(ictx, errorx.Wrap(err, "invalid params"))
+		return
+	}
+	if err := s.handler.RevokeAuth(req); err != nil {
+		responseError(ictx, errorx.Wrap(err, "failed to revoke file authorization application"))
+		return
+	}
+	responseJSON(ictx, "success")
+}
+
 // getAuthByID query authorization application detail by authID
 func (s *Server) getAuthByID(ictx iris.Context) {
 	id := ictx.URLParam("authID")
@@ -576,3 +919,25 @@ func (s *Server) getNodeHealth(ictx iris.Context) {
 	}
 	responseJSON(ictx, resp)
 }
+
+// getNodeReputation get storage node reputation score and its latest history events
+func (s *Server) getNodeReputation(ictx iris.Context) {
+	opt := &blockchain.GetNodeReputationOptions{
+		NodeID:    []byte(ictx.URLParam("id")),
+		StartTime: ictx.URLParamInt64Default("start", 0),
+		EndTime:   ictx.URLParamInt64Default("end", time.Now().UnixNano()),
+		Limit:     ictx.URLParamInt64Default("limit", blockchain.ListMaxNumber),
+	}
+	if opt.Limit < 0 || opt.Limit > blockchain.ListMaxNumber {
+		responseError(ictx, errorx.New(errorx.ErrCodeParam, "invalid limit, the value must be in [0, %d]",
+			blockchain.ListMaxNumber))
+		return
+	}
+
+	resp, err := s.handler.GetNodeReputation(opt)
+	if err != nil {
+		responseError(ictx, errorx.Wrap(err, "failed to get node reputation"))
+		return
+	}
+	responseJSON(ictx, resp)
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	etype "github.com/PaddlePaddle/PaddleDTX/xdb/engine/types"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+)
+
+// fileHandler reads a file in memory the way the engine does, other methods are not implemented
+type fileHandler struct {
+	Handler
+	content []byte
+}
+
+func (h *fileHandler) Read(ctx context.Context, opt etype.ReadOptions) (io.ReadCloser, error) {
+	if !opt.Ranged() {
+		return ioutil.NopCloser(bytes.NewReader(h.content)), nil
+	}
+	total := uint64(len(h.content))
+	if opt.Offset >= total && !(opt.Offset == 0 && total == 0) {
+		return nil, errorx.New(errorx.ErrCodeParam, "offset %d out of range", opt.Offset)
+	}
+	end := total
+	if opt.Length > 0 && opt.Offset+opt.Length < end {
+		end = opt.Offset + opt.Length
+	}
+	return &etype.RangeReader{
+		ReadCloser: ioutil.NopCloser(bytes.NewReader(h.content[opt.Offset:end])),
+		Offset:     opt.Offset,
+		Length:     end - opt.Offset,
+		Total:      total,
+	}, nil
+}
+
+func newTestServer(t *testing.T, content []byte) *Server {
+	s, err := New(":0", &fileHandler{content: content})
+	require.NoError(t, err)
+	s.app.Get("/v1/file/read", s.read)
+	require.NoError(t, s.app.Build())
+	return s
+}
+
+func doRead(s *Server, rangeHeader string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/v1/file/read?user=u&file_id=f&timestamp=1", nil)
+	if rangeHeader != "" {
+		req.Header.Set("Range", rangeHeader)
+	}
+	w := httptest.NewRecorder()
+	s.app.ServeHTTP(w, req)
+	return w
+}
+
+func TestReadRange(t *testing.T) {
+	content := []byte("0123456789")
+	s := newTestServer(t, content)
+
+	cases := []struct {
+		header       string
+		status       int
+		body         string
+		contentRange string
+	}{
+		{"", http.StatusOK, "0123456789", ""},
+		{"bytes=0-", http.StatusPartialContent, "0123456789", "bytes 0-9/10"},
+		{"bytes=0-0", http.StatusPartialContent, "0", "bytes 0-0/10"},
+		{"bytes=3-5", http.StatusPartialContent, "345", "bytes 3-5/10"},
+		{"bytes=8-", http.StatusPartialContent, "89", "bytes 8-9/10"},
+		{"bytes=8-100", http.StatusPartialContent, "89", "bytes 8-9/10"},
+	}
+	for _, c := range cases {
+		w := doRead(s, c.header)
+		require.Equal(t, c.status, w.Code, c.header)
+		require.Equal(t, c.body, w.Body.String(), c.header)
+		require.Equal(t, c.contentRange, w.Header().Get("Content-Range"), c.header)
+	}
+
+	// unsupported or unsatisfiable ranges
+	for _, header := range []string{"bytes=10-", "bytes=5-3", "bytes=-5", "bytes=0-1,3-4", "items=0-1"} {
+		requireError(t, doRead(s, header), header)
+	}
+
+	// an empty file has no satisfiable range
+	requireError(t, doRead(newTestServer(t, nil), "bytes=0-"), "empty file")
+}
+
+// requireError checks the server responded with an error code instead of file content
+func requireError(t *testing.T, w *httptest.ResponseRecorder, msg string) {
+	var resp response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp), msg)
+	require.NotEqual(t, errorx.SuccessCode, resp.Code, msg)
+	require.Empty(t, w.Header().Get("Content-Range"), msg)
+}
+
+func TestParseRange(t *testing.T) {
+	cases := []struct {
+		header         string
+		offset, length uint64
+	}{
+		{"bytes=0-", 0, 0},
+		{"bytes=0-499", 0, 500},
+		{"bytes=500-", 500, 0},
+		{" bytes=7-7 ", 7, 1},
+	}
+	for _, c := range cases {
+		offset, length, err := parseRange(c.header)
+		require.NoError(t, err, c.header)
+		require.Equal(t, c.offset, offset, c.header)
+		require.Equal(t, c.length, length, c.header)
+	}
+	for _, header := range []string{"", "bytes=", "bytes=-1", "bytes=a-b", "bytes=5-1", "bytes=0-1,2-3"} {
+		_, _, err := parseRange(header)
+		require.Error(t, err, header)
+	}
+}
@@ -16,6 +16,8 @@ package server
 import (
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/kataras/iris/v12"
 	"github.com/sirupsen/logrus"
@@ -56,6 +58,11 @@ func responseBytes(ctx iris.Context, bs []byte) {
 }
 
 func responseStream(ctx iris.Context, r io.Reader) {
+	responseStreamWithHeaders(ctx, r, http.StatusOK, nil)
+}
+
+// responseStreamWithHeaders responses stream with given status code and headers, headers are not set on error
+func responseStreamWithHeaders(ctx iris.Context, r io.Reader, status int, headers map[string]string) {
 	// check first byte in case of error
 	firstByte := make([]byte, 1)
 	if n, err := r.Read(firstByte); err != nil {
@@ -65,7 +72,35 @@ func responseStream(ctx iris.Context, r io.Reader) {
 		return
 	}
 
-	ctx.StatusCode(http.StatusOK)
+	for k, v := range headers {
+		ctx.Header(k, v)
+	}
+	ctx.StatusCode(status)
 	ctx.ResponseWriter().Write(firstByte)
 	io.Copy(ctx.ResponseWriter(), r)
 }
+
+// parseRange parses HTTP Range header of a single range, like "bytes=0-499" or "bytes=500-",
+// returns offset and length of the range, length 0 means reading to the end of the file
+func parseRange(header string) (uint64, uint64, error) {
+	spec := strings.TrimPrefix(strings.TrimSpace(header), "bytes=")
+	if spec == header || strings.Contains(spec, ",") {
+		return 0, 0, errorx.New(errorx.ErrCodeParam, "unsupported range: %s", header)
+	}
+	parts := strings.SplitN(spec, "-", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return 0, 0, errorx.New(errorx.ErrCodeParam, "unsupported range: %s", header)
+	}
+	start, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return 0, 0, errorx.New(errorx.ErrCodeParam, "invalid range: %s", header)
+	}
+	if parts[1] == "" {
+		return start, 0, nil
+	}
+	last, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil || last < start {
+		return 0, 0, errorx.New(errorx.ErrCodeParam, "invalid range: %s", header)
+	}
+	return start, last - start + 1, nil
+}
@@ -33,12 +33,25 @@ type Handler interface {
 	// The dataOwner node uses Write() and Read() to publish or download files
 	Write(context.Context, etype.WriteOptions, io.Reader) (etype.WriteResponse, error)
 	Read(context.Context, etype.ReadOptions) (io.ReadCloser, error)
+	// The dataOwner node uses upload sessions to upload large files by ranges, and resume interrupted uploads
+	InitUpload(context.Context, etype.WriteOptions) (etype.UploadSessionResponse, error)
+	UploadRange(context.Context, etype.UploadRangeOptions, io.Reader) (etype.UploadSessionResponse, error)
+	GetUploadSession(etype.UploadSessionOptions) (etype.UploadSessionResponse, error)
+	CommitUpload(context.Context, etype.UploadSessionOptions) (etype.WriteResponse, error)
+	AbortUpload(context.Context, etype.UploadSessionOptions) error
 
 	ListUnExpiredFiles(etype.ListFileOptions) ([]blockchain.File, error)
 	ListExpiredFiles(etype.ListFileOptions) ([]blockchain.File, error)
 	GetFileByID(ctx context.Context, id string) (blockchain.FileH, error)
-	GetFileByName(ctx context.Context, pubkey, ns, name string) (blockchain.FileH, error)
+	GetFileByName(ctx context.Context, pubkey, ns, name string, version int) (blockchain.FileH, error)
+	ListFileVersions(etype.ListFileVersionsOptions) ([]blockchain.File, error)
 	UpdateFileExpireTime(ctx context.Context, opt etype.UpdateFileEtimeOptions) error
+	DeleteFile(ctx context.Context, opt etype.DeleteFileOptions) error
+	// The dataOwner node exports the signed audit trail of a file's full lifecycle
+	GetFileAuditReport(ctx context.Context, id string) (etype.FileAuditReport, error)
+	// The dataOwner node re-encrypts slices of files under the current password after the password is changed
+	StartRekey(etype.RekeyOptions) (etype.RekeyJob, error)
+	GetRekeyJob() (etype.RekeyJob, error)
 	AddFileNs(opt etype.AddNsOptions) error
 	UpdateNsReplica(ctx context.Context, opt etype.UpdateNsOptions) error
 	ListFileNs(opt etype.ListNsOptions) ([]blockchain.Namespace, error)
@@ -49,18 +62,23 @@ type Handler interface {
 	// The Storage node uses Push() or Pull() to store or provide ciphertext slices
 	Push(etype.PushOptions, io.Reader) (etype.PushResponse, error)
 	Pull(etype.PullOptions) (io.ReadCloser, error)
+	DeleteSlice(etype.DeleteSliceOptions) error
 	// The dataOwner node uses the following methods to operate the applier's authorization request
 	ListFileAuths(etype.ListFileAuthOptions) (blockchain.FileAuthApplications, error)
 	ConfirmAuth(etype.ConfirmAuthOptions) error
+	RevokeAuth(etype.RevokeAuthOptions) error
 	GetAuthByID(id string) (blockchain.FileAuthApplication, error)
 
 	ListNodes() (blockchain.Nodes, error)
 	GetNode([]byte) (blockchain.Node, error)
 	GetHeartbeatNum([]byte, int64) (int, int, error)
 	GetNodeHealth([]byte) (string, error)
+	GetNodeReputation(opt *blockchain.GetNodeReputationOptions) (blockchain.NodeReputation, error)
 	NodeOffline(etype.NodeOperateOptions) error
 	NodeOnline(etype.NodeOperateOptions) error
+	NodeDrain(etype.NodeOperateOptions) error
 	GetSliceMigrateRecords(opt *blockchain.NodeSliceMigrateOptions) (string, error)
+	ListSliceScrubReports(opt *blockchain.ListSliceScrubReportsOptions) ([]blockchain.SliceScrubReport, error)
 }
 
 // Server http server
@@ -110,7 +128,9 @@ func (s *Server) setRoute(serverType string) (err error) {
 	nodeParty.Get("/list", s.listNodes)
 	nodeParty.Get("/get", s.getNode)
 	nodeParty.Get("/health", s.getNodeHealth)
+	nodeParty.Get("/reputation", s.getNodeReputation)
 	nodeParty.Get("/getmrecord", s.getMRecord)
+	nodeParty.Get("/scrubreport", s.listScrubReports)
 	nodeParty.Get("/gethbnum", s.getHeartbeatNum)
 
 	switch serverType {
@@ -119,27 +139,40 @@ func (s *Server) setRoute(serverType string) (err error) {
 		sliceParty := v1.Party("/slice")
 		sliceParty.Post("/push", s.push)
 		sliceParty.Get("/pull", s.pull)
+		sliceParty.Post("/delete", s.deleteSlice)
 
 		nodeParty.Post("/offline", s.nodeOffline)
 		nodeParty.Post("/online", s.nodeOnline)
+		nodeParty.Post("/drain", s.nodeDrain)
 	// If the dataOwner node, setting the '/v1/file' and '/v1/challenge' routing
 	case config.NodeTypeDataOwner:
 		fileParty := v1.Party("/file")
 		fileParty.Post("/write", s.write)
 		fileParty.Post("/updatexptime", s.updateFileExpireTime)
+		fileParty.Post("/delete", s.deleteFile)
 		fileParty.Post("/addns", s.addFileNs)
 		fileParty.Post("/ureplica", s.updateNsReplica)
+		fileParty.Post("/upload/init", s.initUpload)
+		fileParty.Post("/upload/range", s.uploadRange)
+		fileParty.Get("/upload/session", s.getUploadSession)
+		fileParty.Post("/upload/commit", s.commitUpload)
+		fileParty.Post("/upload/abort", s.abortUpload)
+		fileParty.Post("/rekey", s.startRekey)
+		fileParty.Get("/rekey", s.getRekeyJob)
 
 		fileParty.Get("/read", s.read)
 		fileParty.Get("/list", s.listUnExpiredFiles)
 		fileParty.Get("/listexp", s.listExpiredFiles)
 		fileParty.Get("/getbyid", s.getFileByID)
+		fileParty.Get("/audit", s.getFileAuditReport)
 		fileParty.Get("/getbyname", s.getFileByName)
+		fileParty.Get("/listversions", s.listFileVersions)
 		fileParty.Get("/listns", s.listFileNs)
 		fileParty.Get("/getns", s.getNsByName)
 		fileParty.Get("/getsyshealth", s.getSysHealth)
 		fileParty.Get("/listauth", s.listFileAuths)
 		fileParty.Post("/confirmauth", s.confirmAuth)
+		fileParty.Post("/revokeauth", s.revokeAuth)
 		fileParty.Get("/getauthbyid", s.getAuthByID)
 
 		// Set routing for challenge queries
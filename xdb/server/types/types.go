@@ -13,9 +13,41 @@
 
 package types
 
-// WriteResponse is response of uploading a file, only file id
+import (
+	"github.com/PaddlePaddle/PaddleDTX/xdb/blockchain"
+)
+
+// WriteResponse is response of uploading a file, file id and version of the file
 type WriteResponse struct {
-	FileID string `json:"file_id"`
+	FileID  string `json:"file_id"`
+	Version int    `json:"version"`
+}
+
+// UploadSessionResponse is response of resumable upload session operations
+//  Offset is the length of the file already uploaded, the next range should start from it
+//  BlockSize is the suggested length of each range
+type UploadSessionResponse struct {
+	SessionID string `json:"session_id"`
+	Offset    uint64 `json:"offset"`
+	BlockSize int    `json:"block_size"`
+}
+
+// RekeyJobResponse is the progress of re-encrypting slices of files under the current password
+//  Total is the number of files found, Done, Failed, Skipped and Deduplicated count files already processed
+//  MinKeyVersion is the oldest password version processed files still need
+type RekeyJobResponse struct {
+	ID            string `json:"id"`
+	Namespace     string `json:"namespace"`
+	KeyVersion    int    `json:"key_version"`
+	MinKeyVersion int    `json:"min_key_version"`
+	State         string `json:"state"`
+	Total         int    `json:"total"`
+	Done          int    `json:"done"`
+	Failed        int    `json:"failed"`
+	Skipped       int    `json:"skipped"`
+	Deduplicated  int    `json:"deduplicated"`
+	StartTime     int64  `json:"start_time"`
+	EndTime       int64  `json:"end_time"`
 }
 
 // PushResponse is response of receiving a slice
@@ -23,3 +55,15 @@ type WriteResponse struct {
 type PushResponse struct {
 	SliceStorIndex string `json:"slice_stor_index"`
 }
+
+// FileAuditReportResponse is the audit trail of a file signed by the dataOwner node owning it
+//  each event can be verified on chain by its TxID and Digest
+type FileAuditReportResponse struct {
+	File         blockchain.File             `json:"file"`
+	Events       []blockchain.FileAuditEvent `json:"events"`
+	ProvedNum    int                         `json:"proved_num"`
+	FailedNum    int                         `json:"failed_num"`
+	GenerateTime int64                       `json:"generate_time"`
+	Signer       string                      `json:"signer"`
+	Signature    string                      `json:"signature"`
+}
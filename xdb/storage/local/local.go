@@ -157,6 +157,21 @@ func (s *Storage) SaveAndUpdate(key string, value io.Reader) error {
 	return nil
 }
 
+// Usage returns bytes occupied by files stored in local
+func (s *Storage) Usage() (uint64, error) {
+	infos, err := ioutil.ReadDir(s.RootPath)
+	if err != nil {
+		return 0, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to read dir")
+	}
+	var used uint64
+	for _, info := range infos {
+		if info.Mode().IsRegular() {
+			used += uint64(info.Size())
+		}
+	}
+	return used, nil
+}
+
 func (s *Storage) LoadStr(key string) (string, error) {
 	exist, err := s.Exist(key)
 	if err != nil {
@@ -60,6 +60,12 @@ func TestLoadStrV2(t *testing.T) {
 	require.Equal(t, "test file content", str)
 }
 
+func TestUsageV2(t *testing.T) {
+	used, err := localStorage.Usage()
+	require.NoError(t, err)
+	require.True(t, used >= uint64(len("test file content")))
+}
+
 func UpdateV2(t *testing.T) {
 	reader := bytes.NewReader([]byte("I love China!"))
 	ind, err := localStorage.Update(key, index, reader)
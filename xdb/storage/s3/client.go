@@ -0,0 +1,358 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+)
+
+const (
+	signAlgorithm   = "AWS4-HMAC-SHA256"
+	serviceName     = "s3"
+	amzDateFormat   = "20060102T150405Z"
+	shortDateFormat = "20060102"
+)
+
+// errObjectNotFound is returned when the object or the multipart upload doesn't exist
+var errObjectNotFound = errorx.New(errorx.ErrCodeNotFound, "object not found")
+
+// client requests S3-compatible object store by REST APIs, requests are signed with AWS Signature Version 4
+type client struct {
+	endpoint  *url.URL
+	region    string
+	accessKey string
+	secretKey string
+	// bucket is addressed by host like 'bucket.endpoint' rather than by path like 'endpoint/bucket'
+	virtualHost bool
+
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+// completedPart is a part of multipart upload, used in CompleteMultipartUpload request
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+type completeMultipartUpload struct {
+	XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+	Parts   []completedPart `xml:"Part"`
+}
+
+type initiateMultipartUploadResult struct {
+	Bucket   string `xml:"Bucket"`
+	Key      string `xml:"Key"`
+	UploadID string `xml:"UploadId"`
+}
+
+// errorResponse is the error returned by S3
+type errorResponse struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+// putObject uploads an object as a whole
+func (c *client) putObject(bucket, key string, body []byte) error {
+	resp, err := c.do(http.MethodPut, bucket, key, nil, body)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// getObject downloads an object
+func (c *client) getObject(bucket, key string) (io.ReadCloser, error) {
+	resp, err := c.do(http.MethodGet, bucket, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// headObject checks existence of an object
+func (c *client) headObject(bucket, key string) (bool, error) {
+	resp, err := c.do(http.MethodHead, bucket, key, nil, nil)
+	if err != nil {
+		if errorx.Is(err, errorx.ErrCodeNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	resp.Body.Close()
+	return true, nil
+}
+
+// deleteObject deletes an object, deleting an object not existing succeeds
+func (c *client) deleteObject(bucket, key string) error {
+	resp, err := c.do(http.MethodDelete, bucket, key, nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// createMultipartUpload initiates a multipart upload and returns its upload ID
+func (c *client) createMultipartUpload(bucket, key string) (string, error) {
+	resp, err := c.do(http.MethodPost, bucket, key, url.Values{"uploads": {""}}, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result initiateMultipartUploadResult
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", errorx.NewCode(err, errorx.ErrCodeInternal, "failed to decode InitiateMultipartUploadResult")
+	}
+	if result.UploadID == "" {
+		return "", errorx.New(errorx.ErrCodeInternal, "empty upload id")
+	}
+	return result.UploadID, nil
+}
+
+// uploadPart uploads a part of multipart upload and returns the ETag of the part
+func (c *client) uploadPart(bucket, key, uploadID string, partNumber int, body []byte) (string, error) {
+	query := url.Values{
+		"partNumber": {strconv.Itoa(partNumber)},
+		"uploadId":   {uploadID},
+	}
+	resp, err := c.do(http.MethodPut, bucket, key, query, body)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		return "", errorx.New(errorx.ErrCodeInternal, "empty ETag of part %d", partNumber)
+	}
+	return etag, nil
+}
+
+// completeMultipartUpload assembles uploaded parts into the object
+func (c *client) completeMultipartUpload(bucket, key, uploadID string, parts []completedPart) error {
+	body, err := xml.Marshal(completeMultipartUpload{Parts: parts})
+	if err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to marshal CompleteMultipartUpload")
+	}
+	resp, err := c.do(http.MethodPost, bucket, key, url.Values{"uploadId": {uploadID}}, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// S3 may return an error in the body of a 200 response after parts are being assembled
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to read CompleteMultipartUploadResult")
+	}
+	var errResp errorResponse
+	if xml.Unmarshal(content, &errResp) == nil && errResp.Code != "" {
+		return errorx.New(errorx.ErrCodeInternal, "failed to complete multipart upload, %s: %s", errResp.Code, errResp.Message)
+	}
+	return nil
+}
+
+// abortMultipartUpload aborts a multipart upload, and uploaded parts are removed
+func (c *client) abortMultipartUpload(bucket, key, uploadID string) error {
+	resp, err := c.do(http.MethodDelete, bucket, key, url.Values{"uploadId": {uploadID}}, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// do signs and sends the request, non-2xx responses are converted into errors
+func (c *client) do(method, bucket, key string, query url.Values, body []byte) (*http.Response, error) {
+	req, err := c.newRequest(method, bucket, key, query, body)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to request s3")
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errObjectNotFound
+	}
+	var errResp errorResponse
+	content, _ := ioutil.ReadAll(resp.Body)
+	if err := xml.Unmarshal(content, &errResp); err != nil || errResp.Code == "" {
+		return nil, errorx.New(errorx.ErrCodeInternal, "s3 responded with status %d", resp.StatusCode)
+	}
+	return nil, errorx.New(errorx.ErrCodeInternal, "s3 responded with status %d, %s: %s",
+		resp.StatusCode, errResp.Code, errResp.Message)
+}
+
+// newRequest creates a signed request for the object
+func (c *client) newRequest(method, bucket, key string, query url.Values, body []byte) (*http.Request, error) {
+	u := *c.endpoint
+	if c.virtualHost {
+		u.Host = bucket + "." + u.Host
+		u.Path = "/" + key
+	} else {
+		u.Path = "/" + bucket + "/" + key
+	}
+	// keep the escaped path same as the signed canonical URI
+	u.RawPath = canonicalURI(&u)
+	u.RawQuery = encodeQuery(query)
+
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to create s3 request")
+	}
+	req.ContentLength = int64(len(body))
+	c.sign(req, hashHex(body))
+	return req, nil
+}
+
+// sign signs the request with AWS Signature Version 4 in Authorization header
+func (c *client) sign(req *http.Request, payloadHash string) {
+	t := c.now().UTC()
+	amzDate := t.Format(amzDateFormat)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders, canonicalHeaders := canonicalHeaders(req)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI(req.URL),
+		canonicalQuery(req.URL),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{t.Format(shortDateFormat), c.region, serviceName, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{signAlgorithm, amzDate, scope, hashHex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+c.secretKey), []byte(t.Format(shortDateFormat)))
+	key = hmacSHA256(key, []byte(c.region))
+	key = hmacSHA256(key, []byte(serviceName))
+	key = hmacSHA256(key, []byte("aws4_request"))
+	signature := hex.EncodeToString(hmacSHA256(key, []byte(stringToSign)))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		signAlgorithm, c.accessKey, scope, signedHeaders, signature))
+}
+
+// canonicalHeaders returns signed header names and canonical headers, host and all x-amz-* headers are signed
+func canonicalHeaders(req *http.Request) (string, string) {
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		lk := strings.ToLower(k)
+		if strings.HasPrefix(lk, "x-amz-") || lk == "range" || lk == "content-md5" {
+			headers[lk] = strings.TrimSpace(strings.Join(v, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	for _, k := range names {
+		sb.WriteString(k + ":" + headers[k] + "\n")
+	}
+	return strings.Join(names, ";"), sb.String()
+}
+
+// canonicalURI returns the URI-encoded path, '/' is not encoded
+func canonicalURI(u *url.URL) string {
+	path := u.Path
+	if path == "" {
+		return "/"
+	}
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		segments[i] = uriEncode(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+// canonicalQuery returns the query string sorted by name, with names and values URI-encoded
+func canonicalQuery(u *url.URL) string {
+	return encodeQuery(u.Query())
+}
+
+func encodeQuery(query url.Values) string {
+	if len(query) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var pairs []string
+	for _, k := range keys {
+		vs := query[k]
+		sort.Strings(vs)
+		for _, v := range vs {
+			pairs = append(pairs, uriEncode(k)+"="+uriEncode(v))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// uriEncode encodes every byte except unreserved characters 'A-Za-z0-9-_.~'
+func uriEncode(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			sb.WriteByte(c)
+		} else {
+			fmt.Fprintf(&sb, "%%%02X", c)
+		}
+	}
+	return sb.String()
+}
+
+func hashHex(data []byte) string {
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:])
+}
+
+func hmacSHA256(key, data []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(data)
+	return h.Sum(nil)
+}
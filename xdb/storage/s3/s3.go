@@ -0,0 +1,306 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/config"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+	"github.com/PaddlePaddle/PaddleDTX/xdb/storage"
+)
+
+const (
+	// indexScheme is the scheme of index, and an index looks like 's3://bucket/prefix/key'
+	indexScheme = "s3://"
+
+	defaultRegion   = "us-east-1"
+	defaultPartSize = 16 << 20
+	// minPartSize is the minimum size of a part except the last one in S3 multipart upload
+	minPartSize    = 5 << 20
+	defaultTimeout = 60000
+)
+
+var (
+	logger = logrus.WithField("module", "storage.s3")
+)
+
+// Storage is implemented with S3-compatible object store.
+// Slices are stored as objects named 'prefix + key' in the bucket,
+// and slices larger than partSize are uploaded by multipart upload
+type Storage struct {
+	client   *client
+	bucket   string
+	prefix   string
+	partSize int64
+}
+
+// New creates Storage with S3 configuration
+// returns error if any mistake occured
+func New(conf *config.S3Conf) (storage.BasicStorage, error) {
+	if conf == nil {
+		return nil, errorx.New(errorx.ErrCodeConfig, "missing s3 config")
+	}
+	if conf.Bucket == "" {
+		return nil, errorx.New(errorx.ErrCodeConfig, "missing s3 bucket")
+	}
+	if conf.AccessKeyID == "" || conf.SecretAccessKey == "" {
+		return nil, errorx.New(errorx.ErrCodeConfig, "missing s3 credentials")
+	}
+	endpoint, err := url.Parse(conf.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return nil, errorx.New(errorx.ErrCodeConfig, "invalid s3 endpoint: %s", conf.Endpoint)
+	}
+
+	region := conf.Region
+	if region == "" {
+		region = defaultRegion
+	}
+	partSize := conf.PartSize
+	if partSize == 0 {
+		partSize = defaultPartSize
+	}
+	if partSize < minPartSize {
+		return nil, errorx.New(errorx.ErrCodeConfig, "s3 partSize should be at least %d", minPartSize)
+	}
+	timeout := conf.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	s := &Storage{
+		client: &client{
+			endpoint:    endpoint,
+			region:      region,
+			accessKey:   conf.AccessKeyID,
+			secretKey:   conf.SecretAccessKey,
+			virtualHost: conf.VirtualHostStyle,
+			httpClient:  &http.Client{Timeout: time.Millisecond * time.Duration(timeout)},
+			now:         time.Now,
+		},
+		bucket:   conf.Bucket,
+		prefix:   conf.Prefix,
+		partSize: partSize,
+	}
+	logger.WithFields(logrus.Fields{
+		"endpoint": conf.Endpoint,
+		"bucket":   conf.Bucket,
+		"prefix":   conf.Prefix,
+		"partSize": partSize,
+	}).Info("new storage")
+	return s, nil
+}
+
+// Save saves a piece of `Data`
+// key is the identification of a piece of `Data`, and it's decided by end-users
+// value contains content of a piece of `Data`
+// returns index, which is the index of stored data('s3://bucket/objectKey')
+func (s *Storage) Save(key string, value io.Reader) (string, error) {
+	if key == "" {
+		return "", errorx.New(errorx.ErrCodeParam, "empty key")
+	}
+	objectKey := s.prefix + key
+	index := indexScheme + s.bucket + "/" + objectKey
+
+	exist, err := s.client.headObject(s.bucket, objectKey)
+	if err != nil {
+		return "", errorx.NewCode(err, errorx.ErrCodeInternal, "failed to determine data is existing or not")
+	}
+	if exist {
+		return "", errorx.New(errorx.ErrCodeAlreadyExists, "key already exist")
+	}
+	if err := s.put(objectKey, value); err != nil {
+		return "", err
+	}
+
+	logger.WithFields(logrus.Fields{
+		"index": index,
+		"key":   key,
+	}).Debug("successfully saved")
+	return index, nil
+}
+
+// Load loads a piece of `Data`
+// key is the identification of a piece of `Data`, and it's decided by end-users
+// index is the index of stored data('s3://bucket/objectKey')
+func (s *Storage) Load(key string, index string) (io.ReadCloser, error) {
+	bucket, objectKey, err := s.parseIndex(key, index)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := s.client.getObject(bucket, objectKey)
+	if err != nil {
+		return nil, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to load data")
+	}
+
+	logger.WithFields(logrus.Fields{
+		"index": index,
+		"key":   key,
+	}).Debug("successfully loaded")
+	return reader, nil
+}
+
+// Exist checks existence of a piece of `Data`
+// key is the identification of a piece of `Data`, and it's decided by end-users
+// index is the index of stored data('s3://bucket/objectKey')
+func (s *Storage) Exist(key string, index string) (bool, error) {
+	bucket, objectKey, err := s.parseIndex(key, index)
+	if err != nil {
+		return false, err
+	}
+	exist, err := s.client.headObject(bucket, objectKey)
+	if err != nil {
+		return false, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to determine data is existing or not")
+	}
+	return exist, nil
+}
+
+// Delete deletes a piece of `Data`
+// key is the identification of a piece of `Data`, and it's decided by end-users
+// index is the index of stored data('s3://bucket/objectKey')
+func (s *Storage) Delete(key string, index string) error {
+	bucket, objectKey, err := s.parseIndex(key, index)
+	if err != nil {
+		return err
+	}
+	if err := s.client.deleteObject(bucket, objectKey); err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to delete data")
+	}
+
+	logger.WithFields(logrus.Fields{
+		"index": index,
+		"key":   key,
+	}).Debug("successfully deleted")
+	return nil
+}
+
+// Update updates a piece of `Data`
+// key is the identification of a piece of `Data`, and it's decided by end-users
+// index is the index of stored data('s3://bucket/objectKey')
+// returns new index
+func (s *Storage) Update(key string, index string, value io.Reader) (string, error) {
+	_, objectKey, err := s.parseIndex(key, index)
+	if err != nil {
+		return "", err
+	}
+	// the object is replaced only once the upload completes, so the original is kept if it fails
+	if err := s.put(objectKey, value); err != nil {
+		return "", err
+	}
+
+	logger.WithFields(logrus.Fields{
+		"index": index,
+		"key":   key,
+	}).Debug("successfully updated")
+	return index, nil
+}
+
+// put uploads value as the object, multipart upload is used if value is larger than a part
+func (s *Storage) put(objectKey string, value io.Reader) error {
+	// read the first part to decide whether to use multipart upload
+	first, eof, err := s.readPart(value)
+	if err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to read data")
+	}
+	if eof {
+		err = s.client.putObject(s.bucket, objectKey, first)
+	} else {
+		err = s.multipartUpload(objectKey, first, value)
+	}
+	if err != nil {
+		return errorx.NewCode(err, errorx.ErrCodeInternal, "failed to save data")
+	}
+	return nil
+}
+
+// multipartUpload uploads first and the rest of value part by part,
+// the upload is aborted if any part fails so that no garbage parts are left
+func (s *Storage) multipartUpload(objectKey string, first []byte, value io.Reader) error {
+	uploadID, err := s.client.createMultipartUpload(s.bucket, objectKey)
+	if err != nil {
+		return err
+	}
+
+	parts, err := s.uploadParts(objectKey, uploadID, first, value)
+	if err == nil {
+		err = s.client.completeMultipartUpload(s.bucket, objectKey, uploadID, parts)
+	}
+	if err != nil {
+		if aerr := s.client.abortMultipartUpload(s.bucket, objectKey, uploadID); aerr != nil {
+			logger.WithError(aerr).WithField("uploadID", uploadID).Warn("failed to abort multipart upload")
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *Storage) uploadParts(objectKey, uploadID string, first []byte, value io.Reader) ([]completedPart, error) {
+	var parts []completedPart
+	part, eof := first, false
+	for partNumber := 1; ; partNumber++ {
+		etag, err := s.client.uploadPart(s.bucket, objectKey, uploadID, partNumber, part)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, completedPart{PartNumber: partNumber, ETag: etag})
+		if eof {
+			return parts, nil
+		}
+
+		part, eof, err = s.readPart(value)
+		if err != nil {
+			return nil, errorx.NewCode(err, errorx.ErrCodeInternal, "failed to read data")
+		}
+		if eof && len(part) == 0 {
+			return parts, nil
+		}
+	}
+}
+
+// readPart reads at most partSize bytes from r, eof is true if r has no more data after the part
+func (s *Storage) readPart(r io.Reader) (part []byte, eof bool, err error) {
+	buf := make([]byte, s.partSize)
+	n, err := io.ReadFull(r, buf)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return buf[:n], true, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return buf, false, nil
+}
+
+// parseIndex parses index into bucket and object key, and checks the index is consistent with key and the bucket
+func (s *Storage) parseIndex(key, index string) (bucket, objectKey string, err error) {
+	if !strings.HasPrefix(index, indexScheme) {
+		return "", "", errorx.New(errorx.ErrCodeParam, "invalid s3 index: %s", index)
+	}
+	ss := strings.SplitN(strings.TrimPrefix(index, indexScheme), "/", 2)
+	if len(ss) != 2 || ss[0] == "" || ss[1] == "" {
+		return "", "", errorx.New(errorx.ErrCodeParam, "invalid s3 index: %s", index)
+	}
+	if ss[0] != s.bucket {
+		return "", "", errorx.New(errorx.ErrCodeParam, "s3 index %s is not in bucket %s", index, s.bucket)
+	}
+	if ss[1] != s.prefix+key {
+		return "", "", errorx.New(errorx.ErrCodeParam, "s3 index %s mismatches key %s", index, key)
+	}
+	return ss[0], ss[1], nil
+}
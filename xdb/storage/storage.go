@@ -14,10 +14,62 @@
 package storage
 
 import (
+	"errors"
 	"io"
 	"io/ioutil"
+	"strings"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+)
+
+const (
+	// copyKeySep separates the key of `Data` and the tag of its copy in the key of the copy
+	copyKeySep = "."
+	// copyIndexPrefix marks the index of a copy, it's followed by the key of the copy and its index in BasicStorage
+	copyIndexPrefix = "copy:"
 )
 
+// ErrUsageNotSupported is returned by Usage if the underlying storage is not able to report its usage
+var ErrUsageNotSupported = errors.New("usage not supported by storage")
+
+// CopyKey returns the key to save a copy of the `Data` of key under, tag distinguishes copies of the same `Data`.
+// Copies are kept beside the `Data`, such as a slice re-encrypted on the node storing it,
+// and are loaded by the key of the `Data` and the index returned when saving the copy
+func CopyKey(key, tag string) string {
+	return key + copyKeySep + tag
+}
+
+// KeyOf returns the key which the `Data` of key and index is saved under, it's the key of the copy for a copy
+func KeyOf(key, index string) string {
+	if copyKey, _, ok := parseCopyIndex(index); ok {
+		return copyKey
+	}
+	return key
+}
+
+func parseCopyIndex(index string) (copyKey, copyIndex string, ok bool) {
+	if !strings.HasPrefix(index, copyIndexPrefix) {
+		return "", "", false
+	}
+	ss := strings.SplitN(strings.TrimPrefix(index, copyIndexPrefix), ":", 2)
+	if len(ss) != 2 {
+		return "", "", false
+	}
+	return ss[0], ss[1], true
+}
+
+// locate returns the key and index of the `Data` in BasicStorage
+func locate(key, index string) (string, string, error) {
+	copyKey, copyIndex, ok := parseCopyIndex(index)
+	if !ok {
+		return key, index, nil
+	}
+	if !strings.HasPrefix(copyKey, key+copyKeySep) {
+		return "", "", errorx.New(errorx.ErrCodeParam, "index %s mismatches key %s", index, key)
+	}
+	return copyKey, copyIndex, nil
+}
+
 // BasicStorage is an abstraction used to refer to any underlying system or device
 // that XuperDB will store its data to.
 // key is the identification of a piece of `Data`, and it's decided by end-users
@@ -40,17 +92,70 @@ type BasicStorage interface {
 	Update(key string, index string, value io.Reader) (string, error)
 }
 
+// UsageStorage is implemented by storages which are able to report bytes occupied by stored `Data`
+type UsageStorage interface {
+	Usage() (uint64, error)
+}
+
 type Storage interface {
 	BasicStorage
 
 	//LoadStr loads a piece of `Data`, and convert it to a string
 	LoadStr(key string, index string) (string, error)
+
+	// Usage returns bytes occupied by stored `Data`
+	Usage() (uint64, error)
 }
 
 type storage struct {
 	BasicStorage
 }
 
+// Save saves a piece of `Data`, the index of the copy is returned if key is made by CopyKey
+func (s *storage) Save(key string, value io.Reader) (string, error) {
+	index, err := s.BasicStorage.Save(key, value)
+	if err != nil || !strings.Contains(key, copyKeySep) {
+		return index, err
+	}
+	return copyIndexPrefix + key + ":" + index, nil
+}
+
+func (s *storage) Load(key string, index string) (io.ReadCloser, error) {
+	key, index, err := locate(key, index)
+	if err != nil {
+		return nil, err
+	}
+	return s.BasicStorage.Load(key, index)
+}
+
+func (s *storage) Exist(key string, index string) (bool, error) {
+	key, index, err := locate(key, index)
+	if err != nil {
+		return false, err
+	}
+	return s.BasicStorage.Exist(key, index)
+}
+
+func (s *storage) Delete(key string, index string) error {
+	key, index, err := locate(key, index)
+	if err != nil {
+		return err
+	}
+	return s.BasicStorage.Delete(key, index)
+}
+
+func (s *storage) Update(key string, index string, value io.Reader) (string, error) {
+	copyKey, copyIndex, err := locate(key, index)
+	if err != nil {
+		return "", err
+	}
+	newIndex, err := s.BasicStorage.Update(copyKey, copyIndex, value)
+	if err != nil || copyKey == key {
+		return newIndex, err
+	}
+	return copyIndexPrefix + copyKey + ":" + newIndex, nil
+}
+
 func (s *storage) LoadStr(key string, index string) (string, error) {
 	f, err := s.Load(key, index)
 	if err != nil {
@@ -65,6 +170,14 @@ func (s *storage) LoadStr(key string, index string) (string, error) {
 	return string(content), nil
 }
 
+func (s *storage) Usage() (uint64, error) {
+	u, ok := s.BasicStorage.(UsageStorage)
+	if !ok {
+		return 0, ErrUsageNotSupported
+	}
+	return u.Usage()
+}
+
 func NewStorage(s BasicStorage) Storage {
 	return &storage{s}
 }
@@ -0,0 +1,103 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PaddlePaddle/PaddleDTX/xdb/errorx"
+)
+
+// memStorage keeps `Data` in memory, the index is the key prefixed by "idx-"
+type memStorage struct {
+	data map[string][]byte
+}
+
+func (s *memStorage) Save(key string, value io.Reader) (string, error) {
+	b, err := ioutil.ReadAll(value)
+	if err != nil {
+		return "", err
+	}
+	s.data[key] = b
+	return "idx-" + key, nil
+}
+
+func (s *memStorage) Load(key string, index string) (io.ReadCloser, error) {
+	b, ok := s.data[key]
+	if !ok || index != "idx-"+key {
+		return nil, errorx.New(errorx.ErrCodeNotFound, "not found")
+	}
+	return ioutil.NopCloser(bytes.NewReader(b)), nil
+}
+
+func (s *memStorage) Exist(key string, index string) (bool, error) {
+	_, ok := s.data[key]
+	return ok && index == "idx-"+key, nil
+}
+
+func (s *memStorage) Delete(key string, index string) error {
+	delete(s.data, key)
+	return nil
+}
+
+func (s *memStorage) Update(key string, index string, value io.Reader) (string, error) {
+	return s.Save(key, value)
+}
+
+func TestCopies(t *testing.T) {
+	mem := &memStorage{data: make(map[string][]byte)}
+	s := NewStorage(mem)
+
+	index, err := s.Save("slice", strings.NewReader("old"))
+	require.NoError(t, err)
+	require.Equal(t, "idx-slice", index)
+	require.Equal(t, "slice", KeyOf("slice", index))
+
+	// the copy is saved beside the `Data`, and located by the key of the `Data` and the copy index
+	copyKey := CopyKey("slice", "tag")
+	copyIndex, err := s.Save(copyKey, strings.NewReader("new"))
+	require.NoError(t, err)
+	require.NotEqual(t, "idx-"+copyKey, copyIndex)
+	require.Equal(t, copyKey, KeyOf("slice", copyIndex))
+	v, err := s.LoadStr("slice", copyIndex)
+	require.NoError(t, err)
+	require.Equal(t, "new", v)
+	v, err = s.LoadStr("slice", index)
+	require.NoError(t, err)
+	require.Equal(t, "old", v)
+
+	// the copy index is not accepted for other keys
+	_, err = s.Load("other", copyIndex)
+	require.True(t, errorx.Is(err, errorx.ErrCodeParam))
+
+	copyIndex, err = s.Update("slice", copyIndex, strings.NewReader("newer"))
+	require.NoError(t, err)
+	v, err = s.LoadStr("slice", copyIndex)
+	require.NoError(t, err)
+	require.Equal(t, "newer", v)
+
+	// deleting the `Data` leaves the copy
+	require.NoError(t, s.Delete("slice", index))
+	exist, err := s.Exist("slice", copyIndex)
+	require.NoError(t, err)
+	require.True(t, exist)
+	require.NoError(t, s.Delete("slice", copyIndex))
+	require.Empty(t, mem.data)
+}